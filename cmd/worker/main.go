@@ -9,7 +9,9 @@ import (
 	"time"
 
 	"awesomeEcommerce/internal/config"
+	"awesomeEcommerce/internal/domain"
 	"awesomeEcommerce/internal/messaging"
+	"awesomeEcommerce/internal/notification"
 	"awesomeEcommerce/internal/repository"
 	"awesomeEcommerce/internal/repository/cache"
 	"awesomeEcommerce/internal/repository/db"
@@ -66,6 +68,66 @@ func main() {
 			func(database *gorm.DB, redisClient *cache.RedisClient) repository.PaymentRepository {
 				return impl.NewPaymentRepository(database, redisClient)
 			},
+			func(database *gorm.DB) repository.RecommendationRepository {
+				return impl.NewRecommendationRepository(database)
+			},
+			func(database *gorm.DB, redisClient *cache.RedisClient) repository.InventoryRepository {
+				return impl.NewInventoryRepository(database, redisClient)
+			},
+			func(database *gorm.DB, redisClient *cache.RedisClient) repository.ReservationRepository {
+				return impl.NewReservationRepository(database, redisClient)
+			},
+			func(database *gorm.DB) repository.InventoryLedgerRepository {
+				return impl.NewInventoryLedgerRepository(database)
+			},
+			func(database *gorm.DB, redisClient *cache.RedisClient) repository.BackorderRepository {
+				return impl.NewBackorderRepository(database, redisClient)
+			},
+			func(database *gorm.DB) repository.StockAlertRepository {
+				return impl.NewStockAlertRepository(database)
+			},
+			func(database *gorm.DB) repository.StockSubscriptionRepository {
+				return impl.NewStockSubscriptionRepository(database)
+			},
+			func(database *gorm.DB, redisClient *cache.RedisClient) repository.DigitalDeliveryRepository {
+				return impl.NewDigitalDeliveryRepository(database, redisClient)
+			},
+			func(database *gorm.DB, redisClient *cache.RedisClient) repository.BundleRepository {
+				return impl.NewBundleRepository(database, redisClient)
+			},
+			func(database *gorm.DB) repository.ExchangeRateRepository {
+				return impl.NewExchangeRateRepository(database)
+			},
+			func(database *gorm.DB) repository.TranslationRepository {
+				return impl.NewTranslationRepository(database)
+			},
+			func(database *gorm.DB) repository.CouponRepository {
+				return impl.NewCouponRepository(database)
+			},
+			func(database *gorm.DB) repository.PromotionRepository {
+				return impl.NewPromotionRepository(database)
+			},
+			func(database *gorm.DB) repository.TaxRateRepository {
+				return impl.NewTaxRateRepository(database)
+			},
+			func(database *gorm.DB) repository.ShippingZoneRepository {
+				return impl.NewShippingZoneRepository(database)
+			},
+			func(database *gorm.DB) repository.ShippingMethodRepository {
+				return impl.NewShippingMethodRepository(database)
+			},
+			func(database *gorm.DB) repository.AbandonedCartRepository {
+				return impl.NewAbandonedCartRepository(database)
+			},
+			func(database *gorm.DB) repository.GiftCardRepository {
+				return impl.NewGiftCardRepository(database)
+			},
+			func(database *gorm.DB) repository.StoreCreditRepository {
+				return impl.NewStoreCreditRepository(database)
+			},
+			func(database *gorm.DB) repository.Transactor {
+				return impl.NewTransactor(database)
+			},
 
 			// Services
 			func(repo repository.UserRepository) service.UserService {
@@ -74,36 +136,162 @@ func main() {
 			func(repo repository.ProductRepository) service.ProductService {
 				return service.NewProductService(repo)
 			},
-			func(cartRepo repository.CartRepository, productRepo repository.ProductRepository, userRepo repository.UserRepository) service.CartService {
-				return service.NewCartService(cartRepo, productRepo, userRepo)
+			func(cartRepo repository.CartRepository, productRepo repository.ProductRepository, userRepo repository.UserRepository, reservationService service.ReservationService, backorderService service.BackorderService, bundleService service.BundleService, currencyService service.CurrencyService, couponService service.CouponService, promotionService service.PromotionService, taxCalculator service.TaxCalculator, shippingService service.ShippingService) service.CartService {
+				return service.NewCartService(cartRepo, productRepo, userRepo,
+					service.WithCartReservations(reservationService),
+					service.WithCartBackorders(backorderService),
+					service.WithCartBundles(bundleService),
+					service.WithCartCurrencies(currencyService),
+					service.WithCartCoupons(couponService),
+					service.WithCartPromotions(promotionService),
+					service.WithCartTaxes(taxCalculator),
+					service.WithCartShipping(shippingService),
+				)
+			},
+			func(inventoryRepo repository.InventoryRepository, ledgerRepo repository.InventoryLedgerRepository, productRepo repository.ProductRepository, cfg *config.Config) service.InventoryService {
+				return service.NewInventoryService(inventoryRepo, ledgerRepo, productRepo, domain.AllocationStrategy(cfg.Inventory.AllocationStrategy))
+			},
+			func(reservationRepo repository.ReservationRepository, orderRepo repository.OrderRepository, stateMachine service.OrderStateMachine, productRepo repository.ProductRepository, inventoryService service.InventoryService, cfg *config.Config) service.ReservationService {
+				return service.NewReservationService(reservationRepo, orderRepo, stateMachine, productRepo, inventoryService, cfg.Reservation.TTL)
+			},
+			func(backorderRepo repository.BackorderRepository, orderRepo repository.OrderRepository, reservationService service.ReservationService, inventoryService service.InventoryService) service.BackorderService {
+				return service.NewBackorderService(backorderRepo, orderRepo, reservationService, inventoryService)
+			},
+			func(bundleRepo repository.BundleRepository, productRepo repository.ProductRepository, reservationService service.ReservationService) service.BundleService {
+				return service.NewBundleService(bundleRepo, productRepo, reservationService)
+			},
+			func(rateRepo repository.ExchangeRateRepository, cfg *config.Config) service.CurrencyService {
+				return service.NewCurrencyService(rateRepo, cfg.Currency.BaseCurrency)
+			},
+			func(translationRepo repository.TranslationRepository, productRepo repository.ProductRepository, cfg *config.Config) service.LocalizationService {
+				return service.NewLocalizationService(translationRepo, productRepo, cfg.Locale.DefaultLocale)
+			},
+			func(couponRepo repository.CouponRepository) service.CouponService {
+				return service.NewCouponService(couponRepo)
+			},
+			func(promotionRepo repository.PromotionRepository) service.PromotionService {
+				return service.NewPromotionService(promotionRepo)
 			},
-			func(orderRepo repository.OrderRepository, cartRepo repository.CartRepository, productRepo repository.ProductRepository, userRepo repository.UserRepository, producer *messaging.KafkaProducer) service.OrderService {
-				return service.NewOrderService(orderRepo, cartRepo, productRepo, userRepo, producer)
+			func(rateRepo repository.TaxRateRepository, cfg *config.Config) service.TaxCalculator {
+				return service.NewTableTaxCalculator(rateRepo, domain.ParseTaxMode(cfg.Tax.Mode), cfg.Tax.DefaultCountry)
 			},
-			func(paymentRepo repository.PaymentRepository, orderRepo repository.OrderRepository, producer *messaging.KafkaProducer) service.PaymentService {
-				return service.NewPaymentService(paymentRepo, orderRepo, producer)
+			func(rateRepo repository.TaxRateRepository, userRepo repository.UserRepository) service.TaxService {
+				return service.NewTaxService(rateRepo, userRepo)
+			},
+			func(zoneRepo repository.ShippingZoneRepository, methodRepo repository.ShippingMethodRepository) service.ShippingService {
+				return service.NewShippingService(zoneRepo, methodRepo, service.NewLocalRateProvider())
+			},
+			func(paymentRepo repository.PaymentRepository, cfg *config.Config) (service.OrderStateMachine, error) {
+				transitions := domain.DefaultOrderTransitions()
+				if cfg.Order.StatusTransitions != "" {
+					var err error
+					if transitions, err = domain.ParseOrderTransitions(cfg.Order.StatusTransitions); err != nil {
+						return nil, err
+					}
+				}
+				return service.NewOrderStateMachine(transitions, service.DefaultOrderStatusGuards(paymentRepo)), nil
+			},
+			func(orderRepo repository.OrderRepository, cartRepo repository.CartRepository, productRepo repository.ProductRepository, userRepo repository.UserRepository, inventoryService service.InventoryService, reservationService service.ReservationService, backorderService service.BackorderService, bundleService service.BundleService, currencyService service.CurrencyService, localizationService service.LocalizationService, couponService service.CouponService, promotionService service.PromotionService, taxCalculator service.TaxCalculator, shippingService service.ShippingService, abandonedCartService service.AbandonedCartService, paymentService service.PaymentService, stateMachine service.OrderStateMachine, transactor repository.Transactor, producer *messaging.KafkaProducer) service.OrderService {
+				return service.NewOrderService(orderRepo, cartRepo, productRepo, userRepo, producer,
+					service.WithInventory(inventoryService),
+					service.WithReservations(reservationService),
+					service.WithBackorders(backorderService),
+					service.WithBundles(bundleService),
+					service.WithCurrencies(currencyService),
+					service.WithLocalization(localizationService),
+					service.WithCoupons(couponService),
+					service.WithPromotions(promotionService),
+					service.WithTaxes(taxCalculator),
+					service.WithShipping(shippingService),
+					service.WithAbandonedCarts(abandonedCartService),
+					service.WithPayments(paymentService),
+					service.WithStateMachine(stateMachine),
+					service.WithTransactor(transactor),
+				)
+			},
+			func(paymentRepo repository.PaymentRepository, orderRepo repository.OrderRepository, stateMachine service.OrderStateMachine, transactor repository.Transactor, reservationService service.ReservationService, fulfillmentService service.DigitalFulfillmentService, giftCardService service.GiftCardService, producer *messaging.KafkaProducer) service.PaymentService {
+				return service.NewPaymentService(paymentRepo, orderRepo, stateMachine, transactor, reservationService, fulfillmentService, giftCardService, producer)
+			},
+			func(giftCardRepo repository.GiftCardRepository, storeCreditRepo repository.StoreCreditRepository) service.GiftCardService {
+				return service.NewGiftCardService(giftCardRepo, storeCreditRepo)
+			},
+			func(recommendationRepo repository.RecommendationRepository, productRepo repository.ProductRepository, cartRepo repository.CartRepository, cfg *config.Config) service.RecommendationService {
+				return service.NewRecommendationService(recommendationRepo, productRepo, cartRepo, cfg.Recommendation.MaxRelated)
+			},
+			func(alertRepo repository.StockAlertRepository, productRepo repository.ProductRepository, producer *messaging.KafkaProducer, cfg *config.Config) service.StockAlertService {
+				// Low-stock alerts go to the log and the low-stock-alert topic
+				channel := notification.Broadcast{
+					notification.NewLogChannel(),
+					notification.NewKafkaChannel(producer, cfg.Kafka.Topics.LowStockAlert),
+				}
+				return service.NewStockAlertService(alertRepo, productRepo, channel, domain.ReorderSettings{
+					ReorderPoint:    cfg.StockAlert.DefaultReorderPoint,
+					ReorderQuantity: cfg.StockAlert.DefaultReorderQuantity,
+				})
+			},
+			func(subscriptionRepo repository.StockSubscriptionRepository, productRepo repository.ProductRepository, reservationService service.ReservationService, producer *messaging.KafkaProducer, cfg *config.Config) service.StockSubscriptionService {
+				// Back-in-stock notifications go to the log and the back-in-stock topic
+				channel := notification.Broadcast{
+					notification.NewLogChannel(),
+					notification.NewKafkaChannel(producer, cfg.Kafka.Topics.BackInStock),
+				}
+				return service.NewStockSubscriptionService(subscriptionRepo, productRepo, reservationService, channel, cfg.BackInStock.SubscriptionTTL)
+			},
+			func(abandonedRepo repository.AbandonedCartRepository, cartRepo repository.CartRepository, userRepo repository.UserRepository, producer *messaging.KafkaProducer, cfg *config.Config) service.AbandonedCartService {
+				// Abandoned cart reminders go to the log and the cart-abandoned topic
+				channel := notification.Broadcast{
+					notification.NewLogChannel(),
+					notification.NewKafkaChannel(producer, cfg.Kafka.Topics.CartAbandoned),
+				}
+				return service.NewAbandonedCartService(abandonedRepo, cartRepo, userRepo, channel, cfg.AbandonedCart.ReminderDelays, cfg.AbandonedCart.MaxIdle)
+			},
+			func(deliveryRepo repository.DigitalDeliveryRepository, productRepo repository.ProductRepository, orderRepo repository.OrderRepository, paymentRepo repository.PaymentRepository, stateMachine service.OrderStateMachine, userRepo repository.UserRepository, giftCardService service.GiftCardService, producer *messaging.KafkaProducer, cfg *config.Config) service.DigitalFulfillmentService {
+				// Digital deliveries go to the log and the digital-delivery topic
+				channel := notification.Broadcast{
+					notification.NewLogChannel(),
+					notification.NewKafkaChannel(producer, cfg.Kafka.Topics.DigitalDelivery),
+				}
+				return service.NewDigitalFulfillmentService(deliveryRepo, productRepo, orderRepo, paymentRepo, stateMachine, userRepo, channel, giftCardService, cfg.Digital.StorageDir, cfg.Digital.DownloadBaseURL, cfg.Digital.DownloadSecret, cfg.Digital.DownloadTTL)
 			},
 
 			// Workers
 			func(orderService service.OrderService, paymentService service.PaymentService, consumer *messaging.KafkaConsumer, producer *messaging.KafkaProducer) *worker.OrderWorker {
 				return worker.NewOrderWorker(orderService, paymentService, consumer, producer)
 			},
-			func(productService service.ProductService, consumer *messaging.KafkaConsumer, producer *messaging.KafkaProducer) *worker.ProductWorker {
-				return worker.NewProductWorker(productService, consumer, producer)
+			func(productService service.ProductService, stockAlertService service.StockAlertService, consumer *messaging.KafkaConsumer, producer *messaging.KafkaProducer) *worker.ProductWorker {
+				return worker.NewProductWorker(productService, stockAlertService, consumer, producer)
+			},
+			func(recommendationService service.RecommendationService, cfg *config.Config) *worker.RecommendationWorker {
+				return worker.NewRecommendationWorker(recommendationService, cfg.Recommendation.RefreshInterval, cfg.Recommendation.RebuildInterval)
+			},
+			func(reservationService service.ReservationService, cfg *config.Config) *worker.ReservationWorker {
+				return worker.NewReservationWorker(reservationService, cfg.Reservation.SweepInterval)
+			},
+			func(backorderService service.BackorderService, producer *messaging.KafkaProducer, cfg *config.Config) *worker.BackorderWorker {
+				return worker.NewBackorderWorker(backorderService, producer, cfg.Backorder.AllocationInterval)
+			},
+			func(stockAlertService service.StockAlertService, cfg *config.Config) *worker.StockAlertWorker {
+				return worker.NewStockAlertWorker(stockAlertService, cfg.StockAlert.CheckInterval)
+			},
+			func(subscriptionService service.StockSubscriptionService, cfg *config.Config) *worker.StockSubscriptionWorker {
+				return worker.NewStockSubscriptionWorker(subscriptionService, cfg.BackInStock.NotifyInterval)
+			},
+			func(abandonedCartService service.AbandonedCartService, cfg *config.Config) *worker.AbandonedCartWorker {
+				return worker.NewAbandonedCartWorker(abandonedCartService, cfg.AbandonedCart.CheckInterval)
 			},
 		),
 
 		// Register lifecycle hooks
 		fx.Invoke(
 			// Initialize database
-			func(database *gorm.DB) {
-				if err := db.AutoMigrate(database); err != nil {
+			func(database *gorm.DB, cfg *config.Config) {
+				if err := db.AutoMigrate(database, cfg.Currency.BaseCurrency); err != nil {
 					log.Fatalf("Failed to migrate database: %v", err)
 				}
 			},
 
 			// Start the workers
-			func(lc fx.Lifecycle, orderWorker *worker.OrderWorker, productWorker *worker.ProductWorker, cfg *config.Config) {
+			func(lc fx.Lifecycle, orderWorker *worker.OrderWorker, productWorker *worker.ProductWorker, recommendationWorker *worker.RecommendationWorker, reservationWorker *worker.ReservationWorker, backorderWorker *worker.BackorderWorker, stockAlertWorker *worker.StockAlertWorker, subscriptionWorker *worker.StockSubscriptionWorker, abandonedCartWorker *worker.AbandonedCartWorker, cfg *config.Config) {
 				workerCtx, cancel := context.WithCancel(context.Background())
 
 				lc.Append(fx.Hook{
@@ -116,6 +304,24 @@ func main() {
 						// Start the product worker
 						productWorker.Start(workerCtx)
 
+						// Start the recommendation worker
+						recommendationWorker.Start(workerCtx)
+
+						// Start the reservation worker
+						reservationWorker.Start(workerCtx)
+
+						// Start the backorder worker
+						backorderWorker.Start(workerCtx)
+
+						// Start the stock alert worker
+						stockAlertWorker.Start(workerCtx)
+
+						// Start the stock subscription worker
+						subscriptionWorker.Start(workerCtx)
+
+						// Start the abandoned cart worker
+						abandonedCartWorker.Start(workerCtx)
+
 						// Run initial product sync (optional)
 						go func() {
 							time.Sleep(5 * time.Second) // Wait for everything to initialize
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/middleware"
+	"awesomeEcommerce/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AbandonedCartHandler handles HTTP requests related to abandoned carts
+type AbandonedCartHandler struct {
+	abandonedCartService service.AbandonedCartService
+	userService          service.UserService
+}
+
+// NewAbandonedCartHandler creates a new AbandonedCartHandler
+func NewAbandonedCartHandler(abandonedCartService service.AbandonedCartService, userService service.UserService) *AbandonedCartHandler {
+	return &AbandonedCartHandler{
+		abandonedCartService: abandonedCartService,
+		userService:          userService,
+	}
+}
+
+// RegisterRoutes registers the routes for the AbandonedCartHandler
+func (h *AbandonedCartHandler) RegisterRoutes(router *gin.RouterGroup) {
+	// Unsubscribe links in cart reminders carry the reminder token
+	router.DELETE("/cart-reminders/:token", h.Unsubscribe)
+
+	abandoned := router.Group("/abandoned-carts")
+	{
+		// Admin routes (require authentication and admin role)
+		admin := abandoned.Use(middleware.AuthMiddleware(h.userService), middleware.RoleMiddleware("admin"))
+		{
+			admin.GET("", h.GetAbandonedCarts)
+			admin.GET("/stats", h.GetStats)
+		}
+	}
+}
+
+// Unsubscribe stops the cart reminders of a customer by a reminder token
+func (h *AbandonedCartHandler) Unsubscribe(c *gin.Context) {
+	if err := h.abandonedCartService.Unsubscribe(c, c.Param("token")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Unsubscribed successfully"})
+}
+
+// GetAbandonedCarts returns the abandoned carts, optionally by status
+func (h *AbandonedCartHandler) GetAbandonedCarts(c *gin.Context) {
+	status := domain.AbandonedCartStatus(c.Query("status"))
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	records, total, err := h.abandonedCartService.GetAbandonedCarts(c, status, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	recordList := []gin.H{}
+	for i := range records {
+		recordList = append(recordList, abandonedCartResponse(&records[i]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"abandoned_carts": recordList,
+		"meta": gin.H{
+			"total":     total,
+			"page":      page,
+			"page_size": pageSize,
+		},
+	})
+}
+
+// GetStats returns how many abandoned carts were recovered
+func (h *AbandonedCartHandler) GetStats(c *gin.Context) {
+	stats, err := h.abandonedCartService.GetStats(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get abandoned cart stats"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"stats": gin.H{
+			"abandoned":     stats.Abandoned,
+			"recovered":     stats.Recovered,
+			"unsubscribed":  stats.Unsubscribed,
+			"recovery_rate": stats.RecoveryRate,
+		},
+	})
+}
+
+// abandonedCartResponse formats an abandoned cart with its recovered amount
+// as a decimal number
+func abandonedCartResponse(abandoned *domain.AbandonedCart) gin.H {
+	return gin.H{
+		"id":                 abandoned.ID,
+		"cart_id":            abandoned.CartID,
+		"user_id":            abandoned.UserID,
+		"email":              abandoned.Email,
+		"status":             abandoned.Status,
+		"activity_at":        abandoned.ActivityAt,
+		"reminders_sent":     abandoned.RemindersSent,
+		"last_reminded_at":   abandoned.LastRemindedAt,
+		"unsubscribed_at":    abandoned.UnsubscribedAt,
+		"order_id":           abandoned.OrderID,
+		"recovered_at":       abandoned.RecoveredAt,
+		"recovered_amount":   abandoned.RecoveredAmount.Number(),
+		"recovered_currency": abandoned.RecoveredAmount.Currency,
+		"created_at":         abandoned.CreatedAt,
+		"updated_at":         abandoned.UpdatedAt,
+	}
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/middleware"
+	"awesomeEcommerce/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BundleHandler handles HTTP requests related to product bundles
+type BundleHandler struct {
+	bundleService       service.BundleService
+	userService         service.UserService
+	currencyService     service.CurrencyService
+	localizationService service.LocalizationService
+}
+
+// NewBundleHandler creates a new BundleHandler
+func NewBundleHandler(bundleService service.BundleService, userService service.UserService, currencyService service.CurrencyService, localizationService service.LocalizationService) *BundleHandler {
+	return &BundleHandler{
+		bundleService:       bundleService,
+		userService:         userService,
+		currencyService:     currencyService,
+		localizationService: localizationService,
+	}
+}
+
+// RegisterRoutes registers the routes for the BundleHandler
+func (h *BundleHandler) RegisterRoutes(router *gin.RouterGroup) {
+	// Public routes
+	router.GET("/products/:id/components", h.GetComponents)
+
+	// Admin routes
+	admin := router.Group("", middleware.AuthMiddleware(h.userService), middleware.RoleMiddleware("admin"))
+	{
+		admin.PUT("/products/:id/components", h.SetComponents)
+	}
+}
+
+// GetComponents returns the component products of a bundle and how many bundles are available
+func (h *BundleHandler) GetComponents(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	components, err := h.bundleService.GetComponents(c, uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get bundle components"})
+		return
+	}
+
+	available, err := h.bundleService.GetAvailability(c, uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get bundle availability"})
+		return
+	}
+
+	products := make([]*domain.Product, 0, len(components))
+	for i := range components {
+		products = append(products, &components[i].Product)
+	}
+	if err := localizeEmbeddedProducts(c, h.localizationService, products); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get bundle components"})
+		return
+	}
+
+	conversion, ok := requestConversion(c, h.currencyService)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"components": formatBundleComponents(components, conversion),
+		"available":  available,
+		"locale":     h.localizationService.Locale(c),
+	})
+}
+
+// SetComponents replaces the component products of a bundle
+func (h *BundleHandler) SetComponents(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var request struct {
+		Components []struct {
+			ProductID uint `json:"product_id" binding:"required"`
+			Quantity  int  `json:"quantity" binding:"required,gt=0"`
+		} `json:"components" binding:"required,min=1,dive"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	components := make([]domain.BundleComponent, 0, len(request.Components))
+	for _, component := range request.Components {
+		components = append(components, domain.BundleComponent{
+			ProductID: component.ProductID,
+			Quantity:  component.Quantity,
+		})
+	}
+
+	components, err = h.bundleService.SetComponents(c, uint(id), components)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Admins set prices in the base currency
+	base := domain.Conversion{Currency: h.currencyService.BaseCurrency(), Rate: 1}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Bundle components updated successfully",
+		"components": formatBundleComponents(components, base),
+	})
+}
+
+// formatBundleComponents formats bundle components for a response, priced in
+// the currency of a conversion
+func formatBundleComponents(components []domain.BundleComponent, conversion domain.Conversion) []gin.H {
+	response := make([]gin.H, 0, len(components))
+	for _, component := range components {
+		response = append(response, gin.H{
+			"product_id":   component.ProductID,
+			"product_name": component.Product.Name,
+			"price":        conversion.Convert(component.Product.Price).Number(),
+			"currency":     conversion.Currency,
+			"stock":        component.Product.Stock,
+			"quantity":     component.Quantity,
+		})
+	}
+	return response
+}
@@ -1,9 +1,12 @@
 package api
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
+	"awesomeEcommerce/internal/domain"
 	"awesomeEcommerce/internal/middleware"
 	"awesomeEcommerce/internal/service"
 
@@ -12,17 +15,21 @@ import (
 
 // CartHandler handles HTTP requests related to shopping carts
 type CartHandler struct {
-	cartService    service.CartService
-	userService    service.UserService
-	productService service.ProductService
+	cartService         service.CartService
+	userService         service.UserService
+	productService      service.ProductService
+	currencyService     service.CurrencyService
+	localizationService service.LocalizationService
 }
 
 // NewCartHandler creates a new CartHandler
-func NewCartHandler(cartService service.CartService, userService service.UserService, productService service.ProductService) *CartHandler {
+func NewCartHandler(cartService service.CartService, userService service.UserService, productService service.ProductService, currencyService service.CurrencyService, localizationService service.LocalizationService) *CartHandler {
 	return &CartHandler{
-		cartService:    cartService,
-		userService:    userService,
-		productService: productService,
+		cartService:         cartService,
+		userService:         userService,
+		productService:      productService,
+		currencyService:     currencyService,
+		localizationService: localizationService,
 	}
 }
 
@@ -39,6 +46,10 @@ func (h *CartHandler) RegisterRoutes(router *gin.RouterGroup) {
 			auth.DELETE("/items/:id", h.RemoveItemFromCart)
 			auth.DELETE("/items", h.ClearCart)
 			auth.GET("/total", h.GetCartTotal)
+			auth.GET("/shipping-options", h.GetShippingOptions)
+			auth.POST("/coupon", h.ApplyCoupon)
+			auth.DELETE("/coupon", h.RemoveCoupon)
+			auth.POST("/accept-prices", h.AcceptPriceChanges)
 		}
 	}
 }
@@ -57,17 +68,39 @@ func (h *CartHandler) GetCart(c *gin.Context) {
 		return
 	}
 
+	products := make([]*domain.Product, 0, len(cart.Items))
+	for i := range cart.Items {
+		products = append(products, &cart.Items[i].Product)
+	}
+	if err := localizeEmbeddedProducts(c, h.localizationService, products); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cart"})
+		return
+	}
+
+	conversion, ok := requestConversion(c, h.currencyService)
+	if !ok {
+		return
+	}
+
+	warnings, err := h.cartService.GetCartWarnings(c, cart.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cart"})
+		return
+	}
+
 	var cartItems []gin.H
 	for _, item := range cart.Items {
 		cartItems = append(cartItems, gin.H{
-			"id":         item.ID,
-			"product_id": item.ProductID,
-			"quantity":   item.Quantity,
+			"id":          item.ID,
+			"product_id":  item.ProductID,
+			"quantity":    item.Quantity,
+			"added_price": conversion.Convert(item.Price).Number(),
 			"product": gin.H{
 				"id":          item.Product.ID,
 				"name":        item.Product.Name,
 				"description": item.Product.Description,
-				"price":       item.Product.Price,
+				"price":       conversion.Convert(item.Product.Price).Number(),
+				"currency":    conversion.Currency,
 				"image_url":   item.Product.ImageURL,
 			},
 		})
@@ -75,10 +108,13 @@ func (h *CartHandler) GetCart(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"cart": gin.H{
-			"id":      cart.ID,
-			"user_id": cart.UserID,
-			"items":   cartItems,
+			"id":          cart.ID,
+			"user_id":     cart.UserID,
+			"items":       cartItems,
+			"coupon_code": cart.CouponCode,
 		},
+		"warnings": cartWarningResponses(warnings),
+		"locale":   h.localizationService.Locale(c),
 	})
 }
 
@@ -234,12 +270,195 @@ func (h *CartHandler) GetCartTotal(c *gin.Context) {
 		return
 	}
 
-	// Get the cart total
-	total, err := h.cartService.GetCartTotal(c, cart.ID)
+	conversion, ok := requestConversion(c, h.currencyService)
+	if !ok {
+		return
+	}
+
+	// Get the cart total with its discounts and taxes
+	setDestination(c, queryDestination(c))
+	summary, err := h.cartService.GetCartSummary(c, cart.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, cartSummaryResponse(summary, conversion))
+}
+
+// GetShippingOptions returns the ways the cart of the authenticated user can
+// be shipped to a destination, and what each costs
+func (h *CartHandler) GetShippingOptions(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Get the user's cart
+	cart, err := h.cartService.GetCartByUserID(c, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cart"})
+		return
+	}
+
+	if _, ok := requestConversion(c, h.currencyService); !ok {
+		return
+	}
+
+	setDestination(c, queryDestination(c))
+	options, err := h.cartService.GetShippingOptions(c, cart.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"total": total})
+	c.JSON(http.StatusOK, gin.H{"shipping_options": shippingOptionResponses(options)})
+}
+
+// ApplyCoupon applies a coupon code to the cart of the authenticated user
+func (h *CartHandler) ApplyCoupon(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var request struct {
+		Code string `json:"code" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	conversion, ok := requestConversion(c, h.currencyService)
+	if !ok {
+		return
+	}
+
+	setDestination(c, queryDestination(c))
+	summary, err := h.cartService.ApplyCoupon(c, userID.(uint), request.Code)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := cartSummaryResponse(summary, conversion)
+	response["message"] = "Coupon applied successfully"
+	c.JSON(http.StatusOK, response)
+}
+
+// RemoveCoupon removes the coupon code from the cart of the authenticated user
+func (h *CartHandler) RemoveCoupon(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	if err := h.cartService.RemoveCoupon(c, userID.(uint)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Coupon removed successfully"})
+}
+
+// AcceptPriceChanges accepts the current prices of the items in the cart of
+// the authenticated user that they confirmed, so it can be ordered at them
+func (h *CartHandler) AcceptPriceChanges(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var request struct {
+		Prices []struct {
+			CartItemID uint        `json:"cart_item_id" binding:"required"`
+			Price      json.Number `json:"price" binding:"required"`
+		} `json:"prices" binding:"required,dive"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	conversion, ok := requestConversion(c, h.currencyService)
+	if !ok {
+		return
+	}
+
+	// Prices are confirmed as the customer was shown them
+	confirmed := make(map[uint]domain.Money, len(request.Prices))
+	for _, price := range request.Prices {
+		amount, ok := requestAmount(c, price.Price, conversion.Currency)
+		if !ok {
+			return
+		}
+		confirmed[price.CartItemID] = amount
+	}
+
+	setDestination(c, queryDestination(c))
+	summary, err := h.cartService.AcceptPriceChanges(c, userID.(uint), confirmed)
+	if err != nil {
+		var changedErr *domain.CartChangedError
+		if errors.As(err, &changedErr) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "warnings": cartWarningResponses(changedErr.Warnings)})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := cartSummaryResponse(summary, conversion)
+	response["message"] = "Price changes accepted successfully"
+	c.JSON(http.StatusOK, response)
+}
+
+// cartSummaryResponse formats the price breakdown of a cart
+func cartSummaryResponse(summary *domain.CartSummary, conversion domain.Conversion) gin.H {
+	response := gin.H{
+		"subtotal":    summary.Subtotal.Number(),
+		"discount":    summary.Discount.Number(),
+		"discounts":   discountResponses(summary.Discounts),
+		"promotions":  appliedPromotionResponses(summary.Promotions),
+		"tax":         summary.Tax.Number(),
+		"taxes":       taxLineResponses(summary.Taxes),
+		"tax_mode":    summary.TaxMode,
+		"total":       summary.Total.Number(),
+		"currency":    conversion.Currency,
+		"coupon_code": summary.CouponCode,
+		"warnings":    cartWarningResponses(summary.Warnings),
+	}
+	if summary.CouponError != "" {
+		response["coupon_error"] = summary.CouponError
+	}
+	return response
+}
+
+// cartWarningResponses formats what changed about the items of a cart since
+// they were added
+func cartWarningResponses(warnings []domain.CartWarning) []gin.H {
+	responses := make([]gin.H, 0, len(warnings))
+	for _, warning := range warnings {
+		response := gin.H{
+			"type":         warning.Type,
+			"cart_item_id": warning.CartItemID,
+			"product_id":   warning.ProductID,
+			"product_name": warning.ProductName,
+		}
+		switch {
+		case warning.IsPriceChange():
+			response["previous_price"] = warning.PreviousPrice.Number()
+			response["current_price"] = warning.CurrentPrice.Number()
+		case warning.Type == domain.CartWarningInsufficientStock:
+			response["requested"] = warning.Requested
+			response["available"] = warning.Available
+		}
+		responses = append(responses, response)
+	}
+	return responses
 }
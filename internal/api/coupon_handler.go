@@ -0,0 +1,260 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/middleware"
+	"awesomeEcommerce/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CouponHandler handles HTTP requests related to coupons
+type CouponHandler struct {
+	couponService   service.CouponService
+	currencyService service.CurrencyService
+	userService     service.UserService
+}
+
+// NewCouponHandler creates a new CouponHandler
+func NewCouponHandler(couponService service.CouponService, currencyService service.CurrencyService, userService service.UserService) *CouponHandler {
+	return &CouponHandler{
+		couponService:   couponService,
+		currencyService: currencyService,
+		userService:     userService,
+	}
+}
+
+// RegisterRoutes registers the routes for the CouponHandler
+func (h *CouponHandler) RegisterRoutes(router *gin.RouterGroup) {
+	coupons := router.Group("/coupons")
+	{
+		// Admin routes (require authentication and admin role)
+		admin := coupons.Use(middleware.AuthMiddleware(h.userService), middleware.RoleMiddleware("admin"))
+		{
+			admin.GET("", h.GetCoupons)
+			admin.GET("/:id", h.GetCouponByID)
+			admin.POST("", h.CreateCoupon)
+			admin.PUT("/:id", h.UpdateCoupon)
+			admin.DELETE("/:id", h.DeleteCoupon)
+		}
+	}
+}
+
+// couponRequest is the body creating or replacing a coupon. Amounts are in
+// the base currency.
+type couponRequest struct {
+	Code         string            `json:"code" binding:"required"`
+	Description  string            `json:"description"`
+	Type         domain.CouponType `json:"type" binding:"required"`
+	PercentOff   float64           `json:"percent_off"`
+	AmountOff    json.Number       `json:"amount_off"`
+	MinSubtotal  json.Number       `json:"min_subtotal"`
+	ProductIDs   []uint            `json:"product_ids"`
+	CategoryIDs  []uint            `json:"category_ids"`
+	StartsAt     *time.Time        `json:"starts_at"`
+	EndsAt       *time.Time        `json:"ends_at"`
+	UsageLimit   int               `json:"usage_limit"`
+	PerUserLimit int               `json:"per_user_limit"`
+	Active       *bool             `json:"active"`
+}
+
+// GetCoupons returns all coupons
+func (h *CouponHandler) GetCoupons(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	coupons, total, err := h.couponService.GetCoupons(c, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get coupons"})
+		return
+	}
+
+	couponList := []gin.H{}
+	for i := range coupons {
+		couponList = append(couponList, couponResponse(&coupons[i]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"coupons": couponList,
+		"meta": gin.H{
+			"total":     total,
+			"page":      page,
+			"page_size": pageSize,
+		},
+	})
+}
+
+// GetCouponByID returns a specific coupon
+func (h *CouponHandler) GetCouponByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid coupon ID"})
+		return
+	}
+
+	coupon, err := h.couponService.GetCouponByID(c, uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Coupon not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"coupon": couponResponse(coupon)})
+}
+
+// CreateCoupon creates a new coupon
+func (h *CouponHandler) CreateCoupon(c *gin.Context) {
+	var request couponRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	coupon := &domain.Coupon{Active: true}
+	if !h.applyCouponRequest(c, coupon, request) {
+		return
+	}
+
+	if err := h.couponService.CreateCoupon(c, coupon); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Coupon created successfully",
+		"coupon":  couponResponse(coupon),
+	})
+}
+
+// UpdateCoupon replaces the settings of a coupon, keeping its usage count
+func (h *CouponHandler) UpdateCoupon(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid coupon ID"})
+		return
+	}
+
+	var request couponRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get the current coupon
+	coupon, err := h.couponService.GetCouponByID(c, uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Coupon not found"})
+		return
+	}
+
+	if !h.applyCouponRequest(c, coupon, request) {
+		return
+	}
+
+	if err := h.couponService.UpdateCoupon(c, coupon); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Coupon updated successfully",
+		"coupon":  couponResponse(coupon),
+	})
+}
+
+// DeleteCoupon deletes a coupon
+func (h *CouponHandler) DeleteCoupon(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid coupon ID"})
+		return
+	}
+
+	if err := h.couponService.DeleteCoupon(c, uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Coupon deleted successfully"})
+}
+
+// applyCouponRequest sets the fields of a coupon from a request, answering
+// the request when an amount is invalid
+func (h *CouponHandler) applyCouponRequest(c *gin.Context, coupon *domain.Coupon, request couponRequest) bool {
+	baseCurrency := h.currencyService.BaseCurrency()
+
+	amountOff := domain.Money{Currency: baseCurrency}
+	if request.AmountOff != "" {
+		var ok bool
+		if amountOff, ok = requestAmount(c, request.AmountOff, baseCurrency); !ok {
+			return false
+		}
+	}
+	minSubtotal := domain.Money{Currency: baseCurrency}
+	if request.MinSubtotal != "" {
+		var ok bool
+		if minSubtotal, ok = requestAmount(c, request.MinSubtotal, baseCurrency); !ok {
+			return false
+		}
+	}
+
+	coupon.Code = request.Code
+	coupon.Description = request.Description
+	coupon.Type = request.Type
+	coupon.PercentOff = request.PercentOff
+	coupon.AmountOff = amountOff
+	coupon.MinSubtotal = minSubtotal
+	coupon.ProductIDs = request.ProductIDs
+	coupon.CategoryIDs = request.CategoryIDs
+	coupon.StartsAt = request.StartsAt
+	coupon.EndsAt = request.EndsAt
+	coupon.UsageLimit = request.UsageLimit
+	coupon.PerUserLimit = request.PerUserLimit
+	if request.Active != nil {
+		coupon.Active = *request.Active
+	}
+	return true
+}
+
+// couponResponse formats a coupon with its amounts as decimal numbers
+func couponResponse(coupon *domain.Coupon) gin.H {
+	return gin.H{
+		"id":             coupon.ID,
+		"code":           coupon.Code,
+		"description":    coupon.Description,
+		"type":           coupon.Type,
+		"percent_off":    coupon.PercentOff,
+		"amount_off":     coupon.AmountOff.Number(),
+		"min_subtotal":   coupon.MinSubtotal.Number(),
+		"currency":       coupon.AmountOff.Currency,
+		"product_ids":    coupon.ProductIDs,
+		"category_ids":   coupon.CategoryIDs,
+		"starts_at":      coupon.StartsAt,
+		"ends_at":        coupon.EndsAt,
+		"usage_limit":    coupon.UsageLimit,
+		"per_user_limit": coupon.PerUserLimit,
+		"used_count":     coupon.UsedCount,
+		"active":         coupon.Active,
+		"created_at":     coupon.CreatedAt,
+		"updated_at":     coupon.UpdatedAt,
+	}
+}
+
+// discountResponses formats discount lines with their amounts as decimal numbers
+func discountResponses(discounts []domain.OrderDiscount) []gin.H {
+	responses := []gin.H{}
+	for _, discount := range discounts {
+		responses = append(responses, gin.H{
+			"product_id": discount.ProductID,
+			"source":     discount.Source,
+			"source_id":  discount.SourceID,
+			"label":      discount.Label,
+			"amount":     discount.Amount.Number(),
+		})
+	}
+	return responses
+}
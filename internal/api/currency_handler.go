@@ -0,0 +1,104 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/middleware"
+	"awesomeEcommerce/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CurrencyHandler handles HTTP requests related to currencies and exchange rates
+type CurrencyHandler struct {
+	currencyService service.CurrencyService
+	userService     service.UserService
+}
+
+// NewCurrencyHandler creates a new CurrencyHandler
+func NewCurrencyHandler(currencyService service.CurrencyService, userService service.UserService) *CurrencyHandler {
+	return &CurrencyHandler{
+		currencyService: currencyService,
+		userService:     userService,
+	}
+}
+
+// RegisterRoutes registers the routes for the CurrencyHandler
+func (h *CurrencyHandler) RegisterRoutes(router *gin.RouterGroup) {
+	currencies := router.Group("/currencies")
+	{
+		// Public routes
+		currencies.GET("", h.GetCurrencies)
+
+		// Admin routes
+		admin := currencies.Use(middleware.AuthMiddleware(h.userService), middleware.RoleMiddleware("admin"))
+		{
+			admin.PUT("/:code", h.SetRate)
+		}
+	}
+}
+
+// GetCurrencies returns the base currency and the exchange rates of the supported currencies
+func (h *CurrencyHandler) GetCurrencies(c *gin.Context) {
+	rates, err := h.currencyService.GetRates(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get exchange rates"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"base_currency": h.currencyService.BaseCurrency(),
+		"rates":         rates,
+	})
+}
+
+// SetRate sets the exchange rate of a currency
+func (h *CurrencyHandler) SetRate(c *gin.Context) {
+	var request struct {
+		Rate float64 `json:"rate" binding:"required,gt=0"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rate, err := h.currencyService.SetRate(c, c.Param("code"), request.Rate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Exchange rate updated successfully",
+		"rate":    rate,
+	})
+}
+
+// requestConversion returns the conversion of base prices to the currency
+// the request asked for, answering the request when the currency is not
+// supported
+func requestConversion(c *gin.Context, currencyService service.CurrencyService) (domain.Conversion, bool) {
+	conversion, err := currencyService.GetConversion(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return domain.Conversion{}, false
+	}
+	return conversion, true
+}
+
+// requestAmount reads an amount of a currency sent as a decimal number,
+// answering the request when it is not a positive amount of the currency
+func requestAmount(c *gin.Context, amount json.Number, currency string) (domain.Money, bool) {
+	money, err := domain.ParseMoney(amount.String(), currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return domain.Money{}, false
+	}
+	if !money.IsPositive() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be greater than zero"})
+		return domain.Money{}, false
+	}
+	return money, true
+}
@@ -0,0 +1,186 @@
+package api
+
+import (
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"awesomeEcommerce/internal/middleware"
+	"awesomeEcommerce/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DigitalHandler handles HTTP requests related to digital product delivery
+type DigitalHandler struct {
+	fulfillmentService service.DigitalFulfillmentService
+	orderService       service.OrderService
+	userService        service.UserService
+}
+
+// NewDigitalHandler creates a new DigitalHandler
+func NewDigitalHandler(fulfillmentService service.DigitalFulfillmentService, orderService service.OrderService, userService service.UserService) *DigitalHandler {
+	return &DigitalHandler{
+		fulfillmentService: fulfillmentService,
+		orderService:       orderService,
+		userService:        userService,
+	}
+}
+
+// RegisterRoutes registers the routes for the DigitalHandler
+func (h *DigitalHandler) RegisterRoutes(router *gin.RouterGroup) {
+	// Download links are signed, so they need no authentication
+	router.GET("/downloads/:order_id/:item_id", h.Download)
+
+	// Customer routes (require authentication)
+	router.GET("/orders/me/:id/digital", middleware.AuthMiddleware(h.userService), h.GetMyDeliveries)
+
+	// Admin routes
+	admin := router.Group("", middleware.AuthMiddleware(h.userService), middleware.RoleMiddleware("admin"))
+	{
+		admin.POST("/products/:id/license-keys", h.AddLicenseKeys)
+		admin.POST("/products/:id/download", h.UploadDownloadFile)
+		admin.POST("/orders/:id/deliver", h.DeliverOrder)
+	}
+}
+
+// AddLicenseKeys adds keys to the pool of a product delivered by license key
+func (h *DigitalHandler) AddLicenseKeys(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var request struct {
+		Keys []string `json:"keys" binding:"required,min=1"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	added, err := h.fulfillmentService.AddLicenseKeys(c, uint(id), request.Keys)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "License keys added successfully",
+		"added":   added,
+	})
+}
+
+// UploadDownloadFile stores the file of a product delivered by download
+func (h *DigitalHandler) UploadDownloadFile(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File is required"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	if err := h.fulfillmentService.SaveDownloadFile(c, uint(id), header.Filename, file); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Download file uploaded successfully",
+		"file":    header.Filename,
+		"size":    header.Size,
+	})
+}
+
+// DeliverOrder retries the delivery of the digital items of a paid order
+func (h *DigitalHandler) DeliverOrder(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	deliveries, err := h.fulfillmentService.DeliverOrder(c, uint(id))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "deliveries": deliveries})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Order delivered successfully",
+		"deliveries": deliveries,
+	})
+}
+
+// GetMyDeliveries returns the license keys and download links of one of the authenticated user's orders
+func (h *DigitalHandler) GetMyDeliveries(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	// Check if the order belongs to the user
+	order, err := h.orderService.GetOrderByID(c, uint(orderID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+	if order.UserID != userID.(uint) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return
+	}
+
+	deliveries, err := h.fulfillmentService.GetOrderDeliveries(c, order.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get deliveries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries})
+}
+
+// Download serves the file behind a signed download link
+func (h *DigitalHandler) Download(c *gin.Context) {
+	orderID, err := strconv.ParseUint(c.Param("order_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+	itemID, err := strconv.ParseUint(c.Param("item_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order item ID"})
+		return
+	}
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid download link"})
+		return
+	}
+
+	path, err := h.fulfillmentService.ResolveDownload(c, uint(orderID), uint(itemID), expires, c.Query("signature"))
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.FileAttachment(path, filepath.Base(path))
+}
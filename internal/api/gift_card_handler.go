@@ -0,0 +1,355 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/middleware"
+	"awesomeEcommerce/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GiftCardHandler handles HTTP requests related to gift cards and store credit
+type GiftCardHandler struct {
+	giftCardService service.GiftCardService
+	currencyService service.CurrencyService
+	userService     service.UserService
+}
+
+// NewGiftCardHandler creates a new GiftCardHandler
+func NewGiftCardHandler(giftCardService service.GiftCardService, currencyService service.CurrencyService, userService service.UserService) *GiftCardHandler {
+	return &GiftCardHandler{
+		giftCardService: giftCardService,
+		currencyService: currencyService,
+		userService:     userService,
+	}
+}
+
+// RegisterRoutes registers the routes for the GiftCardHandler
+func (h *GiftCardHandler) RegisterRoutes(router *gin.RouterGroup) {
+	// Customer routes (require authentication)
+	router.POST("/gift-cards/balance", middleware.AuthMiddleware(h.userService), h.CheckBalance)
+	router.GET("/users/me/store-credit", middleware.AuthMiddleware(h.userService), h.GetMyStoreCredit)
+
+	giftCards := router.Group("/gift-cards")
+	{
+		// Admin routes (require authentication and admin role)
+		admin := giftCards.Use(middleware.AuthMiddleware(h.userService), middleware.RoleMiddleware("admin"))
+		{
+			admin.GET("", h.GetGiftCards)
+			admin.POST("", h.IssueGiftCard)
+			admin.GET("/:id", h.GetGiftCardByID)
+			admin.PUT("/:id", h.UpdateGiftCard)
+			admin.GET("/:id/ledger", h.GetGiftCardLedger)
+		}
+	}
+
+	storeCredit := router.Group("/store-credit")
+	{
+		// Admin routes (require authentication and admin role)
+		admin := storeCredit.Use(middleware.AuthMiddleware(h.userService), middleware.RoleMiddleware("admin"))
+		{
+			admin.GET("/:user_id", h.GetStoreCredit)
+			admin.POST("/:user_id/adjustments", h.AdjustStoreCredit)
+		}
+	}
+}
+
+// CheckBalance returns the balance of a gift card by its code
+func (h *GiftCardHandler) CheckBalance(c *gin.Context) {
+	var request struct {
+		Code string `json:"code" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	card, err := h.giftCardService.GetGiftCardByCode(c, request.Code)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Gift card not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"gift_card": gin.H{
+			"code":       card.Code,
+			"balance":    card.Balance.Number(),
+			"currency":   card.Balance.Currency,
+			"expires_at": card.ExpiresAt,
+			"redeemable": card.IsRedeemable(time.Now()),
+		},
+	})
+}
+
+// GetMyStoreCredit returns the store credit of the current user
+func (h *GiftCardHandler) GetMyStoreCredit(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	h.respondStoreCredit(c, userID.(uint))
+}
+
+// GetGiftCards returns all gift cards (admin only)
+func (h *GiftCardHandler) GetGiftCards(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	cards, total, err := h.giftCardService.GetGiftCards(c, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get gift cards"})
+		return
+	}
+
+	cardList := []gin.H{}
+	for i := range cards {
+		cardList = append(cardList, giftCardResponse(&cards[i]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"gift_cards": cardList,
+		"meta": gin.H{
+			"total":     total,
+			"page":      page,
+			"page_size": pageSize,
+		},
+	})
+}
+
+// IssueGiftCard issues a gift card (admin only)
+func (h *GiftCardHandler) IssueGiftCard(c *gin.Context) {
+	var request struct {
+		Value          json.Number `json:"value" binding:"required"`
+		Currency       string      `json:"currency"`
+		RecipientEmail string      `json:"recipient_email" binding:"omitempty,email"`
+		ExpiresAt      *time.Time  `json:"expires_at"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Gift cards are issued in the base currency unless given another
+	currency := strings.ToUpper(request.Currency)
+	if currency == "" {
+		currency = h.currencyService.BaseCurrency()
+	}
+	value, ok := requestAmount(c, request.Value, currency)
+	if !ok {
+		return
+	}
+
+	card, err := h.giftCardService.IssueGiftCard(c, value, request.RecipientEmail, request.ExpiresAt)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":   "Gift card issued successfully",
+		"gift_card": giftCardResponse(card),
+	})
+}
+
+// GetGiftCardByID returns a gift card (admin only)
+func (h *GiftCardHandler) GetGiftCardByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid gift card ID"})
+		return
+	}
+
+	card, err := h.giftCardService.GetGiftCardByID(c, uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Gift card not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"gift_card": giftCardResponse(card)})
+}
+
+// UpdateGiftCard updates whether a gift card is active, its expiry and its recipient (admin only)
+func (h *GiftCardHandler) UpdateGiftCard(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid gift card ID"})
+		return
+	}
+
+	var request struct {
+		Active         *bool      `json:"active" binding:"required"`
+		RecipientEmail string     `json:"recipient_email" binding:"omitempty,email"`
+		ExpiresAt      *time.Time `json:"expires_at"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	card, err := h.giftCardService.UpdateGiftCard(c, uint(id), *request.Active, request.ExpiresAt, request.RecipientEmail)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Gift card updated successfully",
+		"gift_card": giftCardResponse(card),
+	})
+}
+
+// GetGiftCardLedger returns the balance movements of a gift card (admin only)
+func (h *GiftCardHandler) GetGiftCardLedger(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid gift card ID"})
+		return
+	}
+
+	entries, err := h.giftCardService.GetGiftCardLedger(c, uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"entries": balanceEntriesResponse(entries)})
+}
+
+// GetStoreCredit returns the store credit of a user (admin only)
+func (h *GiftCardHandler) GetStoreCredit(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	h.respondStoreCredit(c, uint(userID))
+}
+
+// AdjustStoreCredit credits or debits the store credit of a user (admin only)
+func (h *GiftCardHandler) AdjustStoreCredit(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	// A negative amount debits the store credit
+	var request struct {
+		Amount   json.Number `json:"amount" binding:"required"`
+		Currency string      `json:"currency"`
+		Note     string      `json:"note" binding:"max=255"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	currency := strings.ToUpper(request.Currency)
+	if currency == "" {
+		currency = h.currencyService.BaseCurrency()
+	}
+	delta, err := domain.ParseMoney(request.Amount.String(), currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	account, err := h.giftCardService.AdjustStoreCredit(c, uint(userID), delta, request.Note)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Store credit adjusted successfully",
+		"account": storeCreditAccountResponse(account),
+	})
+}
+
+// respondStoreCredit responds with the store credit accounts of a user and their ledger
+func (h *GiftCardHandler) respondStoreCredit(c *gin.Context, userID uint) {
+	accounts, err := h.giftCardService.GetStoreCredit(c, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get store credit"})
+		return
+	}
+	entries, err := h.giftCardService.GetStoreCreditLedger(c, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get store credit"})
+		return
+	}
+
+	accountList := []gin.H{}
+	for i := range accounts {
+		accountList = append(accountList, storeCreditAccountResponse(&accounts[i]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"accounts": accountList,
+		"entries":  balanceEntriesResponse(entries),
+	})
+}
+
+// giftCardResponse formats a gift card with its amounts as decimal numbers
+func giftCardResponse(card *domain.GiftCard) gin.H {
+	return gin.H{
+		"id":              card.ID,
+		"code":            card.Code,
+		"initial_value":   card.InitialValue.Number(),
+		"balance":         card.Balance.Number(),
+		"currency":        card.Balance.Currency,
+		"source":          card.Source,
+		"order_id":        card.OrderID,
+		"order_item_id":   card.OrderItemID,
+		"recipient_email": card.RecipientEmail,
+		"expires_at":      card.ExpiresAt,
+		"active":          card.Active,
+		"created_at":      card.CreatedAt,
+		"updated_at":      card.UpdatedAt,
+	}
+}
+
+// storeCreditAccountResponse formats a store credit account with its balance as a decimal number
+func storeCreditAccountResponse(account *domain.StoreCreditAccount) gin.H {
+	return gin.H{
+		"id":         account.ID,
+		"user_id":    account.UserID,
+		"balance":    account.Balance.Number(),
+		"currency":   account.Currency,
+		"updated_at": account.UpdatedAt,
+	}
+}
+
+// balanceEntriesResponse formats balance ledger entries with their amounts as decimal numbers
+func balanceEntriesResponse(entries []domain.BalanceEntry) []gin.H {
+	entryList := []gin.H{}
+	for _, entry := range entries {
+		entryList = append(entryList, gin.H{
+			"id":                      entry.ID,
+			"gift_card_id":            entry.GiftCardID,
+			"store_credit_account_id": entry.StoreCreditAccountID,
+			"delta":                   entry.Delta.Number(),
+			"balance":                 entry.Balance.Number(),
+			"currency":                entry.Balance.Currency,
+			"reason":                  entry.Reason,
+			"reference_id":            entry.ReferenceID,
+			"actor":                   entry.Actor,
+			"note":                    entry.Note,
+			"created_at":              entry.CreatedAt,
+		})
+	}
+	return entryList
+}
@@ -0,0 +1,368 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/middleware"
+	"awesomeEcommerce/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InventoryHandler handles HTTP requests related to warehouse inventory
+type InventoryHandler struct {
+	inventoryService   service.InventoryService
+	reservationService service.ReservationService
+	backorderService   service.BackorderService
+	stockAlertService  service.StockAlertService
+	userService        service.UserService
+}
+
+// NewInventoryHandler creates a new InventoryHandler
+func NewInventoryHandler(inventoryService service.InventoryService, reservationService service.ReservationService, backorderService service.BackorderService, stockAlertService service.StockAlertService, userService service.UserService) *InventoryHandler {
+	return &InventoryHandler{
+		inventoryService:   inventoryService,
+		reservationService: reservationService,
+		backorderService:   backorderService,
+		stockAlertService:  stockAlertService,
+		userService:        userService,
+	}
+}
+
+// RegisterRoutes registers the routes for the InventoryHandler
+func (h *InventoryHandler) RegisterRoutes(router *gin.RouterGroup) {
+	// Public routes
+	router.GET("/products/:id/availability", h.GetProductAvailability)
+
+	// Admin routes (require authentication and admin role)
+	inventory := router.Group("/inventory")
+	{
+		admin := inventory.Use(middleware.AuthMiddleware(h.userService), middleware.RoleMiddleware("admin"))
+		{
+			admin.GET("/warehouses", h.GetWarehouses)
+			admin.POST("/warehouses", h.CreateWarehouse)
+			admin.PUT("/warehouses/:id", h.UpdateWarehouse)
+			admin.PUT("/products/:id/levels", h.SetStockLevel)
+			admin.POST("/transfers", h.TransferStock)
+			admin.GET("/orders/:id/allocations", h.GetOrderAllocations)
+			admin.GET("/products/:id/ledger", h.GetProductLedger)
+			admin.GET("/reconciliation", h.ReconcileStock)
+			admin.GET("/products/:id/backorders", h.GetOutstandingBackorders)
+			admin.GET("/low-stock", h.GetLowStockReport)
+		}
+	}
+}
+
+// GetProductAvailability returns the stock of a product per warehouse and the
+// quantity available to sell once checkout reservations are taken out
+func (h *InventoryHandler) GetProductAvailability(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	availability, err := h.inventoryService.GetProductAvailability(c, uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
+	reserved, err := h.reservationService.GetReservedStock(c, uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get reserved stock"})
+		return
+	}
+
+	available := availability.Total - reserved
+	if available < 0 {
+		available = 0
+	}
+
+	locations := []gin.H{}
+	for _, level := range availability.Locations {
+		locations = append(locations, gin.H{
+			"warehouse_id":   level.WarehouseID,
+			"warehouse_code": level.Warehouse.Code,
+			"warehouse_name": level.Warehouse.Name,
+			"region":         level.Warehouse.Region,
+			"quantity":       level.Quantity,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"product_id": availability.ProductID,
+		"total":      availability.Total,
+		"reserved":   reserved,
+		"available":  available,
+		"locations":  locations,
+	})
+}
+
+// GetWarehouses returns all warehouses
+func (h *InventoryHandler) GetWarehouses(c *gin.Context) {
+	warehouses, err := h.inventoryService.GetWarehouses(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get warehouses"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"warehouses": warehouses})
+}
+
+// CreateWarehouse creates a new warehouse
+func (h *InventoryHandler) CreateWarehouse(c *gin.Context) {
+	var request struct {
+		Code     string `json:"code" binding:"required"`
+		Name     string `json:"name" binding:"required"`
+		Region   string `json:"region"`
+		Priority int    `json:"priority"`
+		Active   *bool  `json:"active"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	warehouse := &domain.Warehouse{
+		Code:     request.Code,
+		Name:     request.Name,
+		Region:   request.Region,
+		Priority: request.Priority,
+		Active:   true,
+	}
+	if request.Active != nil {
+		warehouse.Active = *request.Active
+	}
+
+	if err := h.inventoryService.CreateWarehouse(c, warehouse); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":   "Warehouse created successfully",
+		"warehouse": warehouse,
+	})
+}
+
+// UpdateWarehouse updates an existing warehouse
+func (h *InventoryHandler) UpdateWarehouse(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid warehouse ID"})
+		return
+	}
+
+	var request struct {
+		Name     string `json:"name"`
+		Region   string `json:"region"`
+		Priority *int   `json:"priority"`
+		Active   *bool  `json:"active"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get the current warehouse
+	warehouse, err := h.inventoryService.GetWarehouseByID(c, uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Warehouse not found"})
+		return
+	}
+
+	// Update fields if provided
+	if request.Name != "" {
+		warehouse.Name = request.Name
+	}
+	if request.Region != "" {
+		warehouse.Region = request.Region
+	}
+	if request.Priority != nil {
+		warehouse.Priority = *request.Priority
+	}
+	if request.Active != nil {
+		warehouse.Active = *request.Active
+	}
+
+	if err := h.inventoryService.UpdateWarehouse(c, warehouse); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Warehouse updated successfully",
+		"warehouse": warehouse,
+	})
+}
+
+// SetStockLevel sets the stock of a product at a warehouse
+func (h *InventoryHandler) SetStockLevel(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var request struct {
+		WarehouseID uint `json:"warehouse_id" binding:"required"`
+		Quantity    int  `json:"quantity" binding:"gte=0"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.inventoryService.SetStockLevel(c, uint(id), request.WarehouseID, request.Quantity); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Stock level updated successfully"})
+}
+
+// TransferStock moves stock of a product between warehouses
+func (h *InventoryHandler) TransferStock(c *gin.Context) {
+	var request struct {
+		ProductID       uint `json:"product_id" binding:"required"`
+		FromWarehouseID uint `json:"from_warehouse_id" binding:"required"`
+		ToWarehouseID   uint `json:"to_warehouse_id" binding:"required"`
+		Quantity        int  `json:"quantity" binding:"required,gt=0"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	transfer, err := h.inventoryService.TransferStock(c, request.ProductID, request.FromWarehouseID, request.ToWarehouseID, request.Quantity)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Stock transferred successfully",
+		"transfer": transfer,
+	})
+}
+
+// GetOrderAllocations returns the warehouses fulfilling an order
+func (h *InventoryHandler) GetOrderAllocations(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	allocations, err := h.inventoryService.GetOrderAllocations(c, uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get allocations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"allocations": allocations})
+}
+
+// GetProductLedger returns the stock movements of a product, newest first
+func (h *InventoryHandler) GetProductLedger(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	entries, total, err := h.inventoryService.GetProductLedger(c, uint(id), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"meta": gin.H{
+			"total":     total,
+			"page":      page,
+			"page_size": pageSize,
+		},
+	})
+}
+
+// ReconcileStock returns the products whose stock differs from the stock
+// recomputed from their ledger
+func (h *InventoryHandler) ReconcileStock(c *gin.Context) {
+	discrepancies, err := h.inventoryService.ReconcileStock(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reconcile stock"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"consistent":    len(discrepancies) == 0,
+		"discrepancies": discrepancies,
+	})
+}
+
+// GetOutstandingBackorders returns the order lines of a product still waiting
+// for stock, in the order incoming stock will be allocated to them
+func (h *InventoryHandler) GetOutstandingBackorders(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	items, err := h.backorderService.GetOutstandingBackorders(c, uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get backorders"})
+		return
+	}
+
+	backorders := []gin.H{}
+	outstanding := 0
+	for _, item := range items {
+		backorders = append(backorders, gin.H{
+			"order_id":      item.OrderID,
+			"order_item_id": item.ID,
+			"quantity":      item.BackorderedQuantity,
+			"allocated":     item.BackorderAllocated,
+			"outstanding":   item.OutstandingBackorder(),
+			"expected_at":   item.ExpectedAt,
+		})
+		outstanding += item.OutstandingBackorder()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"product_id":  id,
+		"outstanding": outstanding,
+		"backorders":  backorders,
+	})
+}
+
+// GetLowStockReport returns the products at or below their reorder point,
+// lowest stock first, with the quantity to reorder
+func (h *InventoryHandler) GetLowStockReport(c *gin.Context) {
+	lines, err := h.stockAlertService.GetLowStockReport(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get low-stock report"})
+		return
+	}
+
+	if lines == nil {
+		lines = []domain.LowStockReportLine{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"products": lines,
+		"total":    len(lines),
+	})
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -14,15 +15,17 @@ import (
 
 // OrderHandler handles HTTP requests related to orders
 type OrderHandler struct {
-	orderService service.OrderService
-	userService  service.UserService
+	orderService       service.OrderService
+	userService        service.UserService
+	idempotencyService service.IdempotencyService
 }
 
 // NewOrderHandler creates a new OrderHandler
-func NewOrderHandler(orderService service.OrderService, userService service.UserService) *OrderHandler {
+func NewOrderHandler(orderService service.OrderService, userService service.UserService, idempotencyService service.IdempotencyService) *OrderHandler {
 	return &OrderHandler{
-		orderService: orderService,
-		userService:  userService,
+		orderService:       orderService,
+		userService:        userService,
+		idempotencyService: idempotencyService,
 	}
 }
 
@@ -33,9 +36,10 @@ func (h *OrderHandler) RegisterRoutes(router *gin.RouterGroup) {
 		// Customer routes (require authentication)
 		auth := orders.Use(middleware.AuthMiddleware(h.userService))
 		{
-			auth.POST("", h.CreateOrder)
+			auth.POST("", middleware.IdempotencyMiddleware(h.idempotencyService), h.CreateOrder)
 			auth.GET("/me", h.GetMyOrders)
 			auth.GET("/me/:id", h.GetMyOrderByID)
+			auth.GET("/me/:id/timeline", h.GetMyOrderTimeline)
 			auth.POST("/me/:id/cancel", h.CancelOrder)
 		}
 
@@ -60,8 +64,12 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	}
 
 	var request struct {
-		ShippingAddress string `json:"shipping_address" binding:"required"`
-		BillingAddress  string `json:"billing_address" binding:"required"`
+		ShippingAddress    string `json:"shipping_address"` // Not needed for digital products only
+		BillingAddress     string `json:"billing_address" binding:"required"`
+		ShippingCountry    string `json:"shipping_country"`
+		ShippingRegion     string `json:"shipping_region"`
+		ShippingPostalCode string `json:"shipping_postal_code"`
+		ShippingMethodID   uint   `json:"shipping_method_id"`
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -69,9 +77,26 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		return
 	}
 
+	// Taxes follow where the order is delivered
+	setDestination(c, domain.Destination{
+		Country:    request.ShippingCountry,
+		Region:     request.ShippingRegion,
+		PostalCode: request.ShippingPostalCode,
+	})
+
 	// Create the order
-	order, err := h.orderService.CreateOrder(c, userID.(uint), request.ShippingAddress, request.BillingAddress)
+	order, err := h.orderService.CreateOrder(c, userID.(uint), request.ShippingAddress, request.BillingAddress, request.ShippingMethodID)
 	if err != nil {
+		var stockErr *domain.InsufficientStockError
+		if errors.As(err, &stockErr) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "shortages": stockErr.Shortages})
+			return
+		}
+		var changedErr *domain.CartChangedError
+		if errors.As(err, &changedErr) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "warnings": cartWarningResponses(changedErr.Warnings)})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -80,25 +105,52 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	var orderItems []gin.H
 	for _, item := range order.Items {
 		orderItems = append(orderItems, gin.H{
-			"id":           item.ID,
-			"product_id":   item.ProductID,
-			"product_name": item.ProductName,
-			"price":        item.Price,
-			"quantity":     item.Quantity,
+			"id":              item.ID,
+			"product_id":      item.ProductID,
+			"product_name":    item.ProductName,
+			"price":           item.Price.Number(),
+			"quantity":        item.Quantity,
+			"backordered":     item.IsBackordered(),
+			"expected_at":     item.ExpectedAt,
+			"product_type":    item.ProductType,
+			"delivered_at":    item.DeliveredAt,
+			"parent_item_id":  item.ParentItemID,
+			"bundle_quantity": item.BundleQuantity,
+			"backorder": gin.H{
+				"quantity":    item.BackorderedQuantity,
+				"allocated":   item.BackorderAllocated,
+				"outstanding": item.OutstandingBackorder(),
+			},
 		})
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Order created successfully",
 		"order": gin.H{
-			"id":               order.ID,
-			"user_id":          order.UserID,
-			"total_amount":     order.TotalAmount,
-			"status":           order.Status,
-			"shipping_address": order.ShippingAddress,
-			"billing_address":  order.BillingAddress,
-			"created_at":       order.CreatedAt,
-			"items":            orderItems,
+			"id":                 order.ID,
+			"user_id":            order.UserID,
+			"total_amount":       order.TotalAmount.Number(),
+			"subtotal_amount":    order.SubtotalAmount.Number(),
+			"discount_amount":    order.DiscountAmount.Number(),
+			"discounts":          discountResponses(order.Discounts),
+			"tax_amount":         order.TaxAmount.Number(),
+			"taxes":              taxLineResponses(order.Taxes),
+			"tax_mode":           order.TaxMode,
+			"tax_exempt":         order.TaxExempt,
+			"shipping_amount":    order.ShippingAmount.Number(),
+			"destination":        order.Destination,
+			"shipping_method_id": order.ShippingMethodID,
+			"shipping_method":    order.ShippingMethod,
+			"shipping_carrier":   order.ShippingCarrier,
+			"coupon_code":        order.CouponCode,
+			"currency":           order.TotalAmount.Currency,
+			"exchange_rate":      order.ExchangeRate,
+			"locale":             order.Locale,
+			"status":             order.Status,
+			"shipping_address":   order.ShippingAddress,
+			"billing_address":    order.BillingAddress,
+			"created_at":         order.CreatedAt,
+			"items":              orderItems,
 		},
 	})
 }
@@ -125,22 +177,49 @@ func (h *OrderHandler) GetMyOrders(c *gin.Context) {
 		var orderItems []gin.H
 		for _, item := range order.Items {
 			orderItems = append(orderItems, gin.H{
-				"id":           item.ID,
-				"product_id":   item.ProductID,
-				"product_name": item.ProductName,
-				"price":        item.Price,
-				"quantity":     item.Quantity,
+				"id":              item.ID,
+				"product_id":      item.ProductID,
+				"product_name":    item.ProductName,
+				"price":           item.Price.Number(),
+				"quantity":        item.Quantity,
+				"backordered":     item.IsBackordered(),
+				"expected_at":     item.ExpectedAt,
+				"product_type":    item.ProductType,
+				"delivered_at":    item.DeliveredAt,
+				"parent_item_id":  item.ParentItemID,
+				"bundle_quantity": item.BundleQuantity,
+				"backorder": gin.H{
+					"quantity":    item.BackorderedQuantity,
+					"allocated":   item.BackorderAllocated,
+					"outstanding": item.OutstandingBackorder(),
+				},
 			})
 		}
 
 		orderList = append(orderList, gin.H{
-			"id":               order.ID,
-			"total_amount":     order.TotalAmount,
-			"status":           order.Status,
-			"shipping_address": order.ShippingAddress,
-			"billing_address":  order.BillingAddress,
-			"created_at":       order.CreatedAt,
-			"items":            orderItems,
+			"id":                 order.ID,
+			"total_amount":       order.TotalAmount.Number(),
+			"subtotal_amount":    order.SubtotalAmount.Number(),
+			"discount_amount":    order.DiscountAmount.Number(),
+			"discounts":          discountResponses(order.Discounts),
+			"tax_amount":         order.TaxAmount.Number(),
+			"taxes":              taxLineResponses(order.Taxes),
+			"tax_mode":           order.TaxMode,
+			"tax_exempt":         order.TaxExempt,
+			"shipping_amount":    order.ShippingAmount.Number(),
+			"destination":        order.Destination,
+			"shipping_method_id": order.ShippingMethodID,
+			"shipping_method":    order.ShippingMethod,
+			"shipping_carrier":   order.ShippingCarrier,
+			"coupon_code":        order.CouponCode,
+			"currency":           order.TotalAmount.Currency,
+			"exchange_rate":      order.ExchangeRate,
+			"locale":             order.Locale,
+			"status":             order.Status,
+			"shipping_address":   order.ShippingAddress,
+			"billing_address":    order.BillingAddress,
+			"created_at":         order.CreatedAt,
+			"items":              orderItems,
 		})
 	}
 
@@ -185,28 +264,106 @@ func (h *OrderHandler) GetMyOrderByID(c *gin.Context) {
 	var orderItems []gin.H
 	for _, item := range order.Items {
 		orderItems = append(orderItems, gin.H{
-			"id":           item.ID,
-			"product_id":   item.ProductID,
-			"product_name": item.ProductName,
-			"price":        item.Price,
-			"quantity":     item.Quantity,
+			"id":              item.ID,
+			"product_id":      item.ProductID,
+			"product_name":    item.ProductName,
+			"price":           item.Price.Number(),
+			"quantity":        item.Quantity,
+			"backordered":     item.IsBackordered(),
+			"expected_at":     item.ExpectedAt,
+			"product_type":    item.ProductType,
+			"delivered_at":    item.DeliveredAt,
+			"parent_item_id":  item.ParentItemID,
+			"bundle_quantity": item.BundleQuantity,
+			"backorder": gin.H{
+				"quantity":    item.BackorderedQuantity,
+				"allocated":   item.BackorderAllocated,
+				"outstanding": item.OutstandingBackorder(),
+			},
 		})
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"order": gin.H{
-			"id":               order.ID,
-			"user_id":          order.UserID,
-			"total_amount":     order.TotalAmount,
-			"status":           order.Status,
-			"shipping_address": order.ShippingAddress,
-			"billing_address":  order.BillingAddress,
-			"created_at":       order.CreatedAt,
-			"items":            orderItems,
+			"id":                 order.ID,
+			"user_id":            order.UserID,
+			"total_amount":       order.TotalAmount.Number(),
+			"subtotal_amount":    order.SubtotalAmount.Number(),
+			"discount_amount":    order.DiscountAmount.Number(),
+			"discounts":          discountResponses(order.Discounts),
+			"tax_amount":         order.TaxAmount.Number(),
+			"taxes":              taxLineResponses(order.Taxes),
+			"tax_mode":           order.TaxMode,
+			"tax_exempt":         order.TaxExempt,
+			"shipping_amount":    order.ShippingAmount.Number(),
+			"destination":        order.Destination,
+			"shipping_method_id": order.ShippingMethodID,
+			"shipping_method":    order.ShippingMethod,
+			"shipping_carrier":   order.ShippingCarrier,
+			"coupon_code":        order.CouponCode,
+			"currency":           order.TotalAmount.Currency,
+			"exchange_rate":      order.ExchangeRate,
+			"locale":             order.Locale,
+			"status":             order.Status,
+			"shipping_address":   order.ShippingAddress,
+			"billing_address":    order.BillingAddress,
+			"created_at":         order.CreatedAt,
+			"items":              orderItems,
 		},
 	})
 }
 
+// GetMyOrderTimeline returns the status history of an order of the authenticated user
+func (h *OrderHandler) GetMyOrderTimeline(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	// Get the order
+	order, err := h.orderService.GetOrderByID(c, uint(orderID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+
+	// Check if the order belongs to the user
+	if order.UserID != userID.(uint) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return
+	}
+
+	history, err := h.orderService.GetOrderTimeline(c, order.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get order timeline"})
+		return
+	}
+
+	// Format the response. Who made each change stays with the store.
+	timeline := make([]gin.H, 0, len(history))
+	for _, entry := range history {
+		timeline = append(timeline, gin.H{
+			"from_status": entry.FromStatus,
+			"status":      entry.ToStatus,
+			"reason":      entry.Reason,
+			"created_at":  entry.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"order_id": order.ID,
+		"status":   order.Status,
+		"timeline": timeline,
+	})
+}
+
 // CancelOrder cancels an order for the authenticated user
 func (h *OrderHandler) CancelOrder(c *gin.Context) {
 	userID, exists := c.Get("userID")
@@ -236,6 +393,10 @@ func (h *OrderHandler) CancelOrder(c *gin.Context) {
 
 	// Cancel the order
 	err = h.orderService.CancelOrder(c, uint(orderID))
+	if errors.Is(err, domain.ErrOrderStatusChanged) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -258,13 +419,29 @@ func (h *OrderHandler) GetAllOrders(c *gin.Context) {
 	var orderList []gin.H
 	for _, order := range orders {
 		orderList = append(orderList, gin.H{
-			"id":               order.ID,
-			"user_id":          order.UserID,
-			"total_amount":     order.TotalAmount,
-			"status":           order.Status,
-			"shipping_address": order.ShippingAddress,
-			"billing_address":  order.BillingAddress,
-			"created_at":       order.CreatedAt,
+			"id":                 order.ID,
+			"user_id":            order.UserID,
+			"total_amount":       order.TotalAmount.Number(),
+			"subtotal_amount":    order.SubtotalAmount.Number(),
+			"discount_amount":    order.DiscountAmount.Number(),
+			"discounts":          discountResponses(order.Discounts),
+			"tax_amount":         order.TaxAmount.Number(),
+			"taxes":              taxLineResponses(order.Taxes),
+			"tax_mode":           order.TaxMode,
+			"tax_exempt":         order.TaxExempt,
+			"shipping_amount":    order.ShippingAmount.Number(),
+			"destination":        order.Destination,
+			"shipping_method_id": order.ShippingMethodID,
+			"shipping_method":    order.ShippingMethod,
+			"shipping_carrier":   order.ShippingCarrier,
+			"coupon_code":        order.CouponCode,
+			"currency":           order.TotalAmount.Currency,
+			"exchange_rate":      order.ExchangeRate,
+			"locale":             order.Locale,
+			"status":             order.Status,
+			"shipping_address":   order.ShippingAddress,
+			"billing_address":    order.BillingAddress,
+			"created_at":         order.CreatedAt,
 		})
 	}
 
@@ -297,24 +474,51 @@ func (h *OrderHandler) GetOrderByID(c *gin.Context) {
 	var orderItems []gin.H
 	for _, item := range order.Items {
 		orderItems = append(orderItems, gin.H{
-			"id":           item.ID,
-			"product_id":   item.ProductID,
-			"product_name": item.ProductName,
-			"price":        item.Price,
-			"quantity":     item.Quantity,
+			"id":              item.ID,
+			"product_id":      item.ProductID,
+			"product_name":    item.ProductName,
+			"price":           item.Price.Number(),
+			"quantity":        item.Quantity,
+			"backordered":     item.IsBackordered(),
+			"expected_at":     item.ExpectedAt,
+			"product_type":    item.ProductType,
+			"delivered_at":    item.DeliveredAt,
+			"parent_item_id":  item.ParentItemID,
+			"bundle_quantity": item.BundleQuantity,
+			"backorder": gin.H{
+				"quantity":    item.BackorderedQuantity,
+				"allocated":   item.BackorderAllocated,
+				"outstanding": item.OutstandingBackorder(),
+			},
 		})
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"order": gin.H{
-			"id":               order.ID,
-			"user_id":          order.UserID,
-			"total_amount":     order.TotalAmount,
-			"status":           order.Status,
-			"shipping_address": order.ShippingAddress,
-			"billing_address":  order.BillingAddress,
-			"created_at":       order.CreatedAt,
-			"items":            orderItems,
+			"id":                 order.ID,
+			"user_id":            order.UserID,
+			"total_amount":       order.TotalAmount.Number(),
+			"subtotal_amount":    order.SubtotalAmount.Number(),
+			"discount_amount":    order.DiscountAmount.Number(),
+			"discounts":          discountResponses(order.Discounts),
+			"tax_amount":         order.TaxAmount.Number(),
+			"taxes":              taxLineResponses(order.Taxes),
+			"tax_mode":           order.TaxMode,
+			"tax_exempt":         order.TaxExempt,
+			"shipping_amount":    order.ShippingAmount.Number(),
+			"destination":        order.Destination,
+			"shipping_method_id": order.ShippingMethodID,
+			"shipping_method":    order.ShippingMethod,
+			"shipping_carrier":   order.ShippingCarrier,
+			"coupon_code":        order.CouponCode,
+			"currency":           order.TotalAmount.Currency,
+			"exchange_rate":      order.ExchangeRate,
+			"locale":             order.Locale,
+			"status":             order.Status,
+			"shipping_address":   order.ShippingAddress,
+			"billing_address":    order.BillingAddress,
+			"created_at":         order.CreatedAt,
+			"items":              orderItems,
 		},
 	})
 }
@@ -329,6 +533,7 @@ func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 
 	var request struct {
 		Status domain.OrderStatus `json:"status" binding:"required"`
+		Reason string             `json:"reason" binding:"max=255"`
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -336,8 +541,12 @@ func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 		return
 	}
 
-	// Update the order status
-	err = h.orderService.UpdateOrderStatus(c, uint(orderID), request.Status)
+	// Update the order status, recording why in its history
+	err = h.orderService.UpdateOrderStatus(c, uint(orderID), request.Status, request.Reason)
+	if errors.Is(err, domain.ErrOrderStatusChanged) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -371,13 +580,29 @@ func (h *OrderHandler) GetOrdersByStatus(c *gin.Context) {
 	var orderList []gin.H
 	for _, order := range orders {
 		orderList = append(orderList, gin.H{
-			"id":               order.ID,
-			"user_id":          order.UserID,
-			"total_amount":     order.TotalAmount,
-			"status":           order.Status,
-			"shipping_address": order.ShippingAddress,
-			"billing_address":  order.BillingAddress,
-			"created_at":       order.CreatedAt,
+			"id":                 order.ID,
+			"user_id":            order.UserID,
+			"total_amount":       order.TotalAmount.Number(),
+			"subtotal_amount":    order.SubtotalAmount.Number(),
+			"discount_amount":    order.DiscountAmount.Number(),
+			"discounts":          discountResponses(order.Discounts),
+			"tax_amount":         order.TaxAmount.Number(),
+			"taxes":              taxLineResponses(order.Taxes),
+			"tax_mode":           order.TaxMode,
+			"tax_exempt":         order.TaxExempt,
+			"shipping_amount":    order.ShippingAmount.Number(),
+			"destination":        order.Destination,
+			"shipping_method_id": order.ShippingMethodID,
+			"shipping_method":    order.ShippingMethod,
+			"shipping_carrier":   order.ShippingCarrier,
+			"coupon_code":        order.CouponCode,
+			"currency":           order.TotalAmount.Currency,
+			"exchange_rate":      order.ExchangeRate,
+			"locale":             order.Locale,
+			"status":             order.Status,
+			"shipping_address":   order.ShippingAddress,
+			"billing_address":    order.BillingAddress,
+			"created_at":         order.CreatedAt,
 		})
 	}
 
@@ -426,13 +651,29 @@ func (h *OrderHandler) GetOrdersByDateRange(c *gin.Context) {
 	var orderList []gin.H
 	for _, order := range orders {
 		orderList = append(orderList, gin.H{
-			"id":               order.ID,
-			"user_id":          order.UserID,
-			"total_amount":     order.TotalAmount,
-			"status":           order.Status,
-			"shipping_address": order.ShippingAddress,
-			"billing_address":  order.BillingAddress,
-			"created_at":       order.CreatedAt,
+			"id":                 order.ID,
+			"user_id":            order.UserID,
+			"total_amount":       order.TotalAmount.Number(),
+			"subtotal_amount":    order.SubtotalAmount.Number(),
+			"discount_amount":    order.DiscountAmount.Number(),
+			"discounts":          discountResponses(order.Discounts),
+			"tax_amount":         order.TaxAmount.Number(),
+			"taxes":              taxLineResponses(order.Taxes),
+			"tax_mode":           order.TaxMode,
+			"tax_exempt":         order.TaxExempt,
+			"shipping_amount":    order.ShippingAmount.Number(),
+			"destination":        order.Destination,
+			"shipping_method_id": order.ShippingMethodID,
+			"shipping_method":    order.ShippingMethod,
+			"shipping_carrier":   order.ShippingCarrier,
+			"coupon_code":        order.CouponCode,
+			"currency":           order.TotalAmount.Currency,
+			"exchange_rate":      order.ExchangeRate,
+			"locale":             order.Locale,
+			"status":             order.Status,
+			"shipping_address":   order.ShippingAddress,
+			"billing_address":    order.BillingAddress,
+			"created_at":         order.CreatedAt,
 		})
 	}
 
@@ -1,6 +1,9 @@
 package api
 
 import (
+	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
 	"time"
@@ -14,17 +17,19 @@ import (
 
 // PaymentHandler handles HTTP requests related to payments
 type PaymentHandler struct {
-	paymentService service.PaymentService
-	orderService   service.OrderService
-	userService    service.UserService
+	paymentService     service.PaymentService
+	orderService       service.OrderService
+	userService        service.UserService
+	idempotencyService service.IdempotencyService
 }
 
 // NewPaymentHandler creates a new PaymentHandler
-func NewPaymentHandler(paymentService service.PaymentService, orderService service.OrderService, userService service.UserService) *PaymentHandler {
+func NewPaymentHandler(paymentService service.PaymentService, orderService service.OrderService, userService service.UserService, idempotencyService service.IdempotencyService) *PaymentHandler {
 	return &PaymentHandler{
-		paymentService: paymentService,
-		orderService:   orderService,
-		userService:    userService,
+		paymentService:     paymentService,
+		orderService:       orderService,
+		userService:        userService,
+		idempotencyService: idempotencyService,
 	}
 }
 
@@ -35,7 +40,7 @@ func (h *PaymentHandler) RegisterRoutes(router *gin.RouterGroup) {
 		// Customer routes (require authentication)
 		auth := payments.Use(middleware.AuthMiddleware(h.userService))
 		{
-			auth.POST("/orders/:id", h.CreatePayment)
+			auth.POST("/orders/:id", middleware.IdempotencyMiddleware(h.idempotencyService), h.CreatePayment)
 			auth.GET("/orders/:id", h.GetPaymentByOrderID)
 		}
 
@@ -67,9 +72,13 @@ func (h *PaymentHandler) CreatePayment(c *gin.Context) {
 		return
 	}
 
+	// Gift cards and store credit pay part of the order, the method the rest
 	var request struct {
-		Amount float64              `json:"amount" binding:"required,gt=0"`
-		Method domain.PaymentMethod `json:"method" binding:"required"`
+		Amount         json.Number          `json:"amount" binding:"required"`
+		Method         domain.PaymentMethod `json:"method" binding:"required"`
+		GiftCards      []string             `json:"gift_cards"`
+		UseStoreCredit bool                 `json:"use_store_credit"`
+		StoreCredit    json.Number          `json:"store_credit"`
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -90,8 +99,28 @@ func (h *PaymentHandler) CreatePayment(c *gin.Context) {
 		return
 	}
 
+	// Orders are paid in the currency they were placed in
+	amount, ok := requestAmount(c, request.Amount, order.TotalAmount.Currency)
+	if !ok {
+		return
+	}
+
+	var tenders []domain.TenderRequest
+	for _, code := range request.GiftCards {
+		tenders = append(tenders, domain.TenderRequest{Method: domain.PaymentMethodGiftCard, GiftCardCode: code})
+	}
+	if request.StoreCredit != "" {
+		storeCredit, ok := requestAmount(c, request.StoreCredit, order.TotalAmount.Currency)
+		if !ok {
+			return
+		}
+		tenders = append(tenders, domain.TenderRequest{Method: domain.PaymentMethodStoreCredit, Amount: storeCredit})
+	} else if request.UseStoreCredit {
+		tenders = append(tenders, domain.TenderRequest{Method: domain.PaymentMethodStoreCredit})
+	}
+
 	// Create the payment
-	payment, err := h.paymentService.CreatePayment(c, uint(orderID), request.Amount, request.Method)
+	payment, err := h.paymentService.CreatePayment(c, uint(orderID), amount, request.Method, tenders)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -118,12 +147,14 @@ func (h *PaymentHandler) CreatePayment(c *gin.Context) {
 		"payment": gin.H{
 			"id":             payment.ID,
 			"order_id":       payment.OrderID,
-			"amount":         payment.Amount,
-			"currency":       payment.Currency,
+			"amount":         payment.Amount.Number(),
+			"currency":       payment.Amount.Currency,
+			"exchange_rate":  payment.ExchangeRate,
 			"method":         payment.Method,
 			"status":         payment.Status,
 			"transaction_id": payment.TransactionID,
 			"payment_date":   payment.PaymentDate,
+			"tenders":        paymentTendersResponse(payment),
 		},
 	})
 }
@@ -166,12 +197,14 @@ func (h *PaymentHandler) GetPaymentByOrderID(c *gin.Context) {
 		"payment": gin.H{
 			"id":             payment.ID,
 			"order_id":       payment.OrderID,
-			"amount":         payment.Amount,
-			"currency":       payment.Currency,
+			"amount":         payment.Amount.Number(),
+			"currency":       payment.Amount.Currency,
+			"exchange_rate":  payment.ExchangeRate,
 			"method":         payment.Method,
 			"status":         payment.Status,
 			"transaction_id": payment.TransactionID,
 			"payment_date":   payment.PaymentDate,
+			"tenders":        paymentTendersResponse(payment),
 		},
 	})
 }
@@ -192,8 +225,9 @@ func (h *PaymentHandler) GetAllPayments(c *gin.Context) {
 		paymentList = append(paymentList, gin.H{
 			"id":             payment.ID,
 			"order_id":       payment.OrderID,
-			"amount":         payment.Amount,
-			"currency":       payment.Currency,
+			"amount":         payment.Amount.Number(),
+			"currency":       payment.Amount.Currency,
+			"exchange_rate":  payment.ExchangeRate,
 			"method":         payment.Method,
 			"status":         payment.Status,
 			"transaction_id": payment.TransactionID,
@@ -229,12 +263,14 @@ func (h *PaymentHandler) GetPaymentByID(c *gin.Context) {
 		"payment": gin.H{
 			"id":             payment.ID,
 			"order_id":       payment.OrderID,
-			"amount":         payment.Amount,
-			"currency":       payment.Currency,
+			"amount":         payment.Amount.Number(),
+			"currency":       payment.Amount.Currency,
+			"exchange_rate":  payment.ExchangeRate,
 			"method":         payment.Method,
 			"status":         payment.Status,
 			"transaction_id": payment.TransactionID,
 			"payment_date":   payment.PaymentDate,
+			"tenders":        paymentTendersResponse(payment),
 		},
 	})
 }
@@ -282,7 +318,21 @@ func (h *PaymentHandler) RefundPayment(c *gin.Context) {
 		return
 	}
 
-	err = h.paymentService.RefundPayment(c, uint(paymentID))
+	// Refunds go back to the original payment method unless asked otherwise
+	var request struct {
+		ToStoreCredit bool   `json:"to_store_credit"`
+		Reason        string `json:"reason" binding:"max=255"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if request.ToStoreCredit {
+		err = h.paymentService.RefundPaymentToStoreCredit(c, uint(paymentID), request.Reason)
+	} else {
+		err = h.paymentService.RefundPayment(c, uint(paymentID), request.Reason)
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -317,8 +367,9 @@ func (h *PaymentHandler) GetPaymentsByStatus(c *gin.Context) {
 		paymentList = append(paymentList, gin.H{
 			"id":             payment.ID,
 			"order_id":       payment.OrderID,
-			"amount":         payment.Amount,
-			"currency":       payment.Currency,
+			"amount":         payment.Amount.Number(),
+			"currency":       payment.Amount.Currency,
+			"exchange_rate":  payment.ExchangeRate,
 			"method":         payment.Method,
 			"status":         payment.Status,
 			"transaction_id": payment.TransactionID,
@@ -346,7 +397,9 @@ func (h *PaymentHandler) GetPaymentsByMethod(c *gin.Context) {
 	if method != domain.PaymentMethodCreditCard &&
 		method != domain.PaymentMethodDebitCard &&
 		method != domain.PaymentMethodPayPal &&
-		method != domain.PaymentMethodBankTransfer {
+		method != domain.PaymentMethodBankTransfer &&
+		method != domain.PaymentMethodGiftCard &&
+		method != domain.PaymentMethodStoreCredit {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment method"})
 		return
 	}
@@ -362,8 +415,9 @@ func (h *PaymentHandler) GetPaymentsByMethod(c *gin.Context) {
 		paymentList = append(paymentList, gin.H{
 			"id":             payment.ID,
 			"order_id":       payment.OrderID,
-			"amount":         payment.Amount,
-			"currency":       payment.Currency,
+			"amount":         payment.Amount.Number(),
+			"currency":       payment.Amount.Currency,
+			"exchange_rate":  payment.ExchangeRate,
 			"method":         payment.Method,
 			"status":         payment.Status,
 			"transaction_id": payment.TransactionID,
@@ -418,8 +472,9 @@ func (h *PaymentHandler) GetPaymentsByDateRange(c *gin.Context) {
 		paymentList = append(paymentList, gin.H{
 			"id":             payment.ID,
 			"order_id":       payment.OrderID,
-			"amount":         payment.Amount,
-			"currency":       payment.Currency,
+			"amount":         payment.Amount.Number(),
+			"currency":       payment.Amount.Currency,
+			"exchange_rate":  payment.ExchangeRate,
 			"method":         payment.Method,
 			"status":         payment.Status,
 			"transaction_id": payment.TransactionID,
@@ -436,3 +491,20 @@ func (h *PaymentHandler) GetPaymentsByDateRange(c *gin.Context) {
 		},
 	})
 }
+
+// paymentTendersResponse formats the parts of a payment taken from gift cards
+// and store credit with their amounts as decimal numbers
+func paymentTendersResponse(payment *domain.Payment) []gin.H {
+	tenders := []gin.H{}
+	for _, tender := range payment.Tenders {
+		tenders = append(tenders, gin.H{
+			"id":                      tender.ID,
+			"method":                  tender.Method,
+			"gift_card_id":            tender.GiftCardID,
+			"store_credit_account_id": tender.StoreCreditAccountID,
+			"amount":                  tender.Amount.Number(),
+			"currency":                tender.Amount.Currency,
+		})
+	}
+	return tenders
+}
@@ -1,8 +1,10 @@
 package api
 
 import (
+	"encoding/json"
 	"net/http"
 	"strconv"
+	"time"
 
 	"awesomeEcommerce/internal/domain"
 	"awesomeEcommerce/internal/middleware"
@@ -13,15 +15,19 @@ import (
 
 // ProductHandler handles HTTP requests related to products
 type ProductHandler struct {
-	productService service.ProductService
-	userService    service.UserService
+	productService      service.ProductService
+	userService         service.UserService
+	currencyService     service.CurrencyService
+	localizationService service.LocalizationService
 }
 
 // NewProductHandler creates a new ProductHandler
-func NewProductHandler(productService service.ProductService, userService service.UserService) *ProductHandler {
+func NewProductHandler(productService service.ProductService, userService service.UserService, currencyService service.CurrencyService, localizationService service.LocalizationService) *ProductHandler {
 	return &ProductHandler{
-		productService: productService,
-		userService:    userService,
+		productService:      productService,
+		userService:         userService,
+		currencyService:     currencyService,
+		localizationService: localizationService,
 	}
 }
 
@@ -44,6 +50,7 @@ func (h *ProductHandler) RegisterRoutes(router *gin.RouterGroup) {
 			admin.POST("/categories", h.CreateCategory)
 			admin.PUT("/categories/:id", h.UpdateCategory)
 			admin.DELETE("/categories/:id", h.DeleteCategory)
+			admin.GET("/cache/stats", h.GetCacheStats)
 		}
 	}
 }
@@ -59,13 +66,24 @@ func (h *ProductHandler) GetProducts(c *gin.Context) {
 		return
 	}
 
+	if err := h.localizationService.LocalizeProducts(c, products); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get products"})
+		return
+	}
+
+	conversion, ok := requestConversion(c, h.currencyService)
+	if !ok {
+		return
+	}
+
 	var productList []gin.H
 	for _, product := range products {
 		productList = append(productList, gin.H{
 			"id":          product.ID,
 			"name":        product.Name,
 			"description": product.Description,
-			"price":       product.Price,
+			"price":       conversion.Convert(product.Price).Number(),
+			"currency":    conversion.Currency,
 			"stock":       product.Stock,
 			"sku":         product.SKU,
 			"image_url":   product.ImageURL,
@@ -75,6 +93,7 @@ func (h *ProductHandler) GetProducts(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"products": productList,
+		"locale":   h.localizationService.Locale(c),
 		"meta": gin.H{
 			"total":     total,
 			"page":      page,
@@ -97,16 +116,49 @@ func (h *ProductHandler) GetProductByID(c *gin.Context) {
 		return
 	}
 
+	if err := h.localizationService.LocalizeProduct(c, product); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get product"})
+		return
+	}
+
+	conversion, ok := requestConversion(c, h.currencyService)
+	if !ok {
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
+		"locale": h.localizationService.Locale(c),
 		"product": gin.H{
 			"id":          product.ID,
 			"name":        product.Name,
 			"description": product.Description,
-			"price":       product.Price,
+			"price":       conversion.Convert(product.Price).Number(),
+			"currency":    conversion.Currency,
 			"stock":       product.Stock,
 			"sku":         product.SKU,
 			"image_url":   product.ImageURL,
 			"category_id": product.CategoryID,
+			"tax_class":   product.TaxClass,
+			"weight":      product.Weight,
+			"length":      product.Length,
+			"width":       product.Width,
+			"height":      product.Height,
+
+			"backorder_policy": product.BackorderPolicy,
+			"backorder_limit":  product.BackorderLimit,
+			"pre_order":        product.PreOrder,
+			"available_at":     product.AvailableAt,
+
+			"reorder_point":    product.ReorderPoint,
+			"reorder_quantity": product.ReorderQuantity,
+
+			"type":            product.Type,
+			"delivery_method": product.DeliveryMethod,
+
+			"bundle_pricing":  product.BundlePricing,
+			"bundle_discount": product.BundleDiscount,
+
+			"archived": product.Archived,
 		},
 	})
 }
@@ -114,13 +166,33 @@ func (h *ProductHandler) GetProductByID(c *gin.Context) {
 // CreateProduct creates a new product
 func (h *ProductHandler) CreateProduct(c *gin.Context) {
 	var request struct {
-		Name        string  `json:"name" binding:"required"`
-		Description string  `json:"description"`
-		Price       float64 `json:"price" binding:"required,gt=0"`
-		Stock       int     `json:"stock" binding:"required,gte=0"`
-		SKU         string  `json:"sku" binding:"required"`
-		ImageURL    string  `json:"image_url"`
-		CategoryID  uint    `json:"category_id"`
+		Name        string      `json:"name" binding:"required"`
+		Description string      `json:"description"`
+		Price       json.Number `json:"price" binding:"required"`
+		Stock       int         `json:"stock" binding:"gte=0"`
+		SKU         string      `json:"sku" binding:"required"`
+		ImageURL    string      `json:"image_url"`
+		CategoryID  uint        `json:"category_id"`
+		TaxClass    string      `json:"tax_class"`
+
+		Weight *int     `json:"weight" binding:"omitempty,gte=0"`
+		Length *float64 `json:"length" binding:"omitempty,gte=0"`
+		Width  *float64 `json:"width" binding:"omitempty,gte=0"`
+		Height *float64 `json:"height" binding:"omitempty,gte=0"`
+
+		BackorderPolicy string     `json:"backorder_policy" binding:"omitempty,oneof=deny limited unlimited"`
+		BackorderLimit  int        `json:"backorder_limit" binding:"gte=0"`
+		PreOrder        bool       `json:"pre_order"`
+		AvailableAt     *time.Time `json:"available_at"`
+
+		ReorderPoint    *int `json:"reorder_point" binding:"omitempty,gte=0"`
+		ReorderQuantity *int `json:"reorder_quantity" binding:"omitempty,gt=0"`
+
+		Type           string `json:"type" binding:"omitempty,oneof=physical digital bundle"`
+		DeliveryMethod string `json:"delivery_method" binding:"omitempty,oneof=license_key download gift_card"`
+
+		BundlePricing  string   `json:"bundle_pricing" binding:"omitempty,oneof=fixed percent_off"`
+		BundleDiscount *float64 `json:"bundle_discount" binding:"omitempty,gte=0,lte=100"`
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -128,14 +200,49 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 		return
 	}
 
+	// Admins set prices in the base currency
+	price, ok := requestAmount(c, request.Price, h.currencyService.BaseCurrency())
+	if !ok {
+		return
+	}
+
 	product := &domain.Product{
 		Name:        request.Name,
 		Description: request.Description,
-		Price:       request.Price,
+		Price:       price,
 		Stock:       request.Stock,
 		SKU:         request.SKU,
 		ImageURL:    request.ImageURL,
 		CategoryID:  request.CategoryID,
+		TaxClass:    request.TaxClass,
+
+		BackorderPolicy: domain.BackorderPolicy(request.BackorderPolicy),
+		BackorderLimit:  request.BackorderLimit,
+		PreOrder:        request.PreOrder,
+		AvailableAt:     request.AvailableAt,
+
+		ReorderPoint:    request.ReorderPoint,
+		ReorderQuantity: request.ReorderQuantity,
+
+		Type:           domain.ProductType(request.Type),
+		DeliveryMethod: domain.DeliveryMethod(request.DeliveryMethod),
+
+		BundlePricing: domain.BundlePricing(request.BundlePricing),
+	}
+	if request.BundleDiscount != nil {
+		product.BundleDiscount = *request.BundleDiscount
+	}
+	if request.Weight != nil {
+		product.Weight = *request.Weight
+	}
+	if request.Length != nil {
+		product.Length = *request.Length
+	}
+	if request.Width != nil {
+		product.Width = *request.Width
+	}
+	if request.Height != nil {
+		product.Height = *request.Height
 	}
 
 	if err := h.productService.CreateProduct(c, product); err != nil {
@@ -149,11 +256,32 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 			"id":          product.ID,
 			"name":        product.Name,
 			"description": product.Description,
-			"price":       product.Price,
+			"price":       product.Price.Number(),
 			"stock":       product.Stock,
 			"sku":         product.SKU,
 			"image_url":   product.ImageURL,
 			"category_id": product.CategoryID,
+			"tax_class":   product.TaxClass,
+			"weight":      product.Weight,
+			"length":      product.Length,
+			"width":       product.Width,
+			"height":      product.Height,
+
+			"backorder_policy": product.BackorderPolicy,
+			"backorder_limit":  product.BackorderLimit,
+			"pre_order":        product.PreOrder,
+			"available_at":     product.AvailableAt,
+
+			"reorder_point":    product.ReorderPoint,
+			"reorder_quantity": product.ReorderQuantity,
+
+			"type":            product.Type,
+			"delivery_method": product.DeliveryMethod,
+
+			"bundle_pricing":  product.BundlePricing,
+			"bundle_discount": product.BundleDiscount,
+
+			"archived": product.Archived,
 		},
 	})
 }
@@ -167,13 +295,35 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 	}
 
 	var request struct {
-		Name        string  `json:"name"`
-		Description string  `json:"description"`
-		Price       float64 `json:"price" binding:"omitempty,gt=0"`
-		Stock       int     `json:"stock" binding:"omitempty,gte=0"`
-		SKU         string  `json:"sku"`
-		ImageURL    string  `json:"image_url"`
-		CategoryID  uint    `json:"category_id"`
+		Name        string      `json:"name"`
+		Description string      `json:"description"`
+		Price       json.Number `json:"price"`
+		Stock       int         `json:"stock" binding:"omitempty,gte=0"`
+		SKU         string      `json:"sku"`
+		ImageURL    string      `json:"image_url"`
+		CategoryID  uint        `json:"category_id"`
+		TaxClass    string      `json:"tax_class"`
+
+		Weight *int     `json:"weight" binding:"omitempty,gte=0"`
+		Length *float64 `json:"length" binding:"omitempty,gte=0"`
+		Width  *float64 `json:"width" binding:"omitempty,gte=0"`
+		Height *float64 `json:"height" binding:"omitempty,gte=0"`
+
+		BackorderPolicy string     `json:"backorder_policy" binding:"omitempty,oneof=deny limited unlimited"`
+		BackorderLimit  *int       `json:"backorder_limit" binding:"omitempty,gte=0"`
+		PreOrder        *bool      `json:"pre_order"`
+		AvailableAt     *time.Time `json:"available_at"`
+
+		ReorderPoint    *int `json:"reorder_point" binding:"omitempty,gte=0"`
+		ReorderQuantity *int `json:"reorder_quantity" binding:"omitempty,gt=0"`
+
+		Type           string `json:"type" binding:"omitempty,oneof=physical digital bundle"`
+		DeliveryMethod string `json:"delivery_method" binding:"omitempty,oneof=license_key download gift_card"`
+
+		BundlePricing  string   `json:"bundle_pricing" binding:"omitempty,oneof=fixed percent_off"`
+		BundleDiscount *float64 `json:"bundle_discount" binding:"omitempty,gte=0,lte=100"`
+
+		Archived *bool `json:"archived"`
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -195,8 +345,12 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 	if request.Description != "" {
 		product.Description = request.Description
 	}
-	if request.Price > 0 {
-		product.Price = request.Price
+	if request.Price != "" {
+		price, ok := requestAmount(c, request.Price, h.currencyService.BaseCurrency())
+		if !ok {
+			return
+		}
+		product.Price = price
 	}
 	if request.Stock >= 0 {
 		product.Stock = request.Stock
@@ -210,6 +364,61 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 	if request.CategoryID > 0 {
 		product.CategoryID = request.CategoryID
 	}
+	if request.TaxClass != "" {
+		product.TaxClass = request.TaxClass
+	}
+	if request.Weight != nil {
+		product.Weight = *request.Weight
+	}
+	if request.Length != nil {
+		product.Length = *request.Length
+	}
+	if request.Width != nil {
+		product.Width = *request.Width
+	}
+	if request.Height != nil {
+		product.Height = *request.Height
+	}
+	if request.BackorderPolicy != "" {
+		product.BackorderPolicy = domain.BackorderPolicy(request.BackorderPolicy)
+	}
+	if request.BackorderLimit != nil {
+		product.BackorderLimit = *request.BackorderLimit
+	}
+	if request.PreOrder != nil {
+		product.PreOrder = *request.PreOrder
+	}
+	if request.AvailableAt != nil {
+		product.AvailableAt = request.AvailableAt
+	}
+	if request.ReorderPoint != nil {
+		product.ReorderPoint = request.ReorderPoint
+	}
+	if request.ReorderQuantity != nil {
+		product.ReorderQuantity = request.ReorderQuantity
+	}
+	if request.Type != "" {
+		product.Type = domain.ProductType(request.Type)
+		if product.Type != domain.ProductTypeDigital {
+			product.DeliveryMethod = ""
+		}
+		if product.Type != domain.ProductTypeBundle {
+			product.BundlePricing = ""
+			product.BundleDiscount = 0
+		}
+	}
+	if request.DeliveryMethod != "" {
+		product.DeliveryMethod = domain.DeliveryMethod(request.DeliveryMethod)
+	}
+	if request.BundlePricing != "" {
+		product.BundlePricing = domain.BundlePricing(request.BundlePricing)
+	}
+	if request.BundleDiscount != nil {
+		product.BundleDiscount = *request.BundleDiscount
+	}
+	if request.Archived != nil {
+		product.Archived = *request.Archived
+	}
 
 	if err := h.productService.UpdateProduct(c, product); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -222,11 +431,32 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 			"id":          product.ID,
 			"name":        product.Name,
 			"description": product.Description,
-			"price":       product.Price,
+			"price":       product.Price.Number(),
 			"stock":       product.Stock,
 			"sku":         product.SKU,
 			"image_url":   product.ImageURL,
 			"category_id": product.CategoryID,
+			"tax_class":   product.TaxClass,
+			"weight":      product.Weight,
+			"length":      product.Length,
+			"width":       product.Width,
+			"height":      product.Height,
+
+			"backorder_policy": product.BackorderPolicy,
+			"backorder_limit":  product.BackorderLimit,
+			"pre_order":        product.PreOrder,
+			"available_at":     product.AvailableAt,
+
+			"reorder_point":    product.ReorderPoint,
+			"reorder_quantity": product.ReorderQuantity,
+
+			"type":            product.Type,
+			"delivery_method": product.DeliveryMethod,
+
+			"bundle_pricing":  product.BundlePricing,
+			"bundle_discount": product.BundleDiscount,
+
+			"archived": product.Archived,
 		},
 	})
 }
@@ -255,16 +485,27 @@ func (h *ProductHandler) GetCategories(c *gin.Context) {
 		return
 	}
 
+	if err := h.localizationService.LocalizeCategories(c, categories); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get categories"})
+		return
+	}
+
 	var categoryList []gin.H
 	for _, category := range categories {
 		categoryList = append(categoryList, gin.H{
 			"id":        category.ID,
 			"name":      category.Name,
 			"parent_id": category.ParentID,
+
+			"reorder_point":    category.ReorderPoint,
+			"reorder_quantity": category.ReorderQuantity,
 		})
 	}
 
-	c.JSON(http.StatusOK, gin.H{"categories": categoryList})
+	c.JSON(http.StatusOK, gin.H{
+		"categories": categoryList,
+		"locale":     h.localizationService.Locale(c),
+	})
 }
 
 // GetProductsByCategory returns products by category ID with pagination
@@ -284,13 +525,24 @@ func (h *ProductHandler) GetProductsByCategory(c *gin.Context) {
 		return
 	}
 
+	if err := h.localizationService.LocalizeProducts(c, products); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get products"})
+		return
+	}
+
+	conversion, ok := requestConversion(c, h.currencyService)
+	if !ok {
+		return
+	}
+
 	var productList []gin.H
 	for _, product := range products {
 		productList = append(productList, gin.H{
 			"id":          product.ID,
 			"name":        product.Name,
 			"description": product.Description,
-			"price":       product.Price,
+			"price":       conversion.Convert(product.Price).Number(),
+			"currency":    conversion.Currency,
 			"stock":       product.Stock,
 			"sku":         product.SKU,
 			"image_url":   product.ImageURL,
@@ -300,6 +552,7 @@ func (h *ProductHandler) GetProductsByCategory(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"products": productList,
+		"locale":   h.localizationService.Locale(c),
 		"meta": gin.H{
 			"total":     total,
 			"page":      page,
@@ -313,6 +566,9 @@ func (h *ProductHandler) CreateCategory(c *gin.Context) {
 	var request struct {
 		Name     string `json:"name" binding:"required"`
 		ParentID *uint  `json:"parent_id"`
+
+		ReorderPoint    *int `json:"reorder_point" binding:"omitempty,gte=0"`
+		ReorderQuantity *int `json:"reorder_quantity" binding:"omitempty,gt=0"`
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -323,6 +579,9 @@ func (h *ProductHandler) CreateCategory(c *gin.Context) {
 	category := &domain.ProductCategory{
 		Name:     request.Name,
 		ParentID: request.ParentID,
+
+		ReorderPoint:    request.ReorderPoint,
+		ReorderQuantity: request.ReorderQuantity,
 	}
 
 	if err := h.productService.CreateCategory(c, category); err != nil {
@@ -336,6 +595,9 @@ func (h *ProductHandler) CreateCategory(c *gin.Context) {
 			"id":        category.ID,
 			"name":      category.Name,
 			"parent_id": category.ParentID,
+
+			"reorder_point":    category.ReorderPoint,
+			"reorder_quantity": category.ReorderQuantity,
 		},
 	})
 }
@@ -351,6 +613,9 @@ func (h *ProductHandler) UpdateCategory(c *gin.Context) {
 	var request struct {
 		Name     string `json:"name"`
 		ParentID *uint  `json:"parent_id"`
+
+		ReorderPoint    *int `json:"reorder_point" binding:"omitempty,gte=0"`
+		ReorderQuantity *int `json:"reorder_quantity" binding:"omitempty,gt=0"`
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -372,6 +637,12 @@ func (h *ProductHandler) UpdateCategory(c *gin.Context) {
 	if request.ParentID != nil {
 		category.ParentID = request.ParentID
 	}
+	if request.ReorderPoint != nil {
+		category.ReorderPoint = request.ReorderPoint
+	}
+	if request.ReorderQuantity != nil {
+		category.ReorderQuantity = request.ReorderQuantity
+	}
 
 	if err := h.productService.UpdateCategory(c, category); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -384,6 +655,9 @@ func (h *ProductHandler) UpdateCategory(c *gin.Context) {
 			"id":        category.ID,
 			"name":      category.Name,
 			"parent_id": category.ParentID,
+
+			"reorder_point":    category.ReorderPoint,
+			"reorder_quantity": category.ReorderQuantity,
 		},
 	})
 }
@@ -403,3 +677,14 @@ func (h *ProductHandler) DeleteCategory(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Category deleted successfully"})
 }
+
+// GetCacheStats returns the hit and miss counters of the product caches (admin only)
+func (h *ProductHandler) GetCacheStats(c *gin.Context) {
+	stats, err := h.productService.GetCacheStats(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cache stats"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"stats": stats})
+}
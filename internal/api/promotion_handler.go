@@ -0,0 +1,273 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/middleware"
+	"awesomeEcommerce/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PromotionHandler handles HTTP requests related to promotions
+type PromotionHandler struct {
+	promotionService service.PromotionService
+	currencyService  service.CurrencyService
+	userService      service.UserService
+}
+
+// NewPromotionHandler creates a new PromotionHandler
+func NewPromotionHandler(promotionService service.PromotionService, currencyService service.CurrencyService, userService service.UserService) *PromotionHandler {
+	return &PromotionHandler{
+		promotionService: promotionService,
+		currencyService:  currencyService,
+		userService:      userService,
+	}
+}
+
+// RegisterRoutes registers the routes for the PromotionHandler
+func (h *PromotionHandler) RegisterRoutes(router *gin.RouterGroup) {
+	promotions := router.Group("/promotions")
+	{
+		// Admin routes (require authentication and admin role)
+		admin := promotions.Use(middleware.AuthMiddleware(h.userService), middleware.RoleMiddleware("admin"))
+		{
+			admin.GET("", h.GetPromotions)
+			admin.GET("/:id", h.GetPromotionByID)
+			admin.POST("", h.CreatePromotion)
+			admin.PUT("/:id", h.UpdatePromotion)
+			admin.DELETE("/:id", h.DeletePromotion)
+		}
+	}
+}
+
+// promotionRequest is the body creating or replacing a promotion. Tier
+// thresholds are in the base currency.
+type promotionRequest struct {
+	Name        string                 `json:"name" binding:"required"`
+	Description string                 `json:"description"`
+	Type        domain.PromotionType   `json:"type" binding:"required"`
+	Priority    int                    `json:"priority"`
+	Exclusive   bool                   `json:"exclusive"`
+	ProductIDs  []uint                 `json:"product_ids"`
+	CategoryIDs []uint                 `json:"category_ids"`
+	PercentOff  float64                `json:"percent_off"`
+	BuyQuantity int                    `json:"buy_quantity"`
+	GetQuantity int                    `json:"get_quantity"`
+	MinQuantity int                    `json:"min_quantity"`
+	Tiers       []promotionTierRequest `json:"tiers"`
+	StartsAt    *time.Time             `json:"starts_at"`
+	EndsAt      *time.Time             `json:"ends_at"`
+	Active      *bool                  `json:"active"`
+}
+
+// promotionTierRequest is a spend tier of a promotion request
+type promotionTierRequest struct {
+	MinSubtotal json.Number `json:"min_subtotal" binding:"required"`
+	PercentOff  float64     `json:"percent_off"`
+}
+
+// GetPromotions returns all promotions, highest priority first
+func (h *PromotionHandler) GetPromotions(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	promotions, total, err := h.promotionService.GetPromotions(c, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get promotions"})
+		return
+	}
+
+	promotionList := []gin.H{}
+	for i := range promotions {
+		promotionList = append(promotionList, promotionResponse(&promotions[i]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"promotions": promotionList,
+		"meta": gin.H{
+			"total":     total,
+			"page":      page,
+			"page_size": pageSize,
+		},
+	})
+}
+
+// GetPromotionByID returns a specific promotion
+func (h *PromotionHandler) GetPromotionByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid promotion ID"})
+		return
+	}
+
+	promotion, err := h.promotionService.GetPromotionByID(c, uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Promotion not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"promotion": promotionResponse(promotion)})
+}
+
+// CreatePromotion creates a new promotion
+func (h *PromotionHandler) CreatePromotion(c *gin.Context) {
+	var request promotionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	promotion := &domain.Promotion{Active: true}
+	if !h.applyPromotionRequest(c, promotion, request) {
+		return
+	}
+
+	if err := h.promotionService.CreatePromotion(c, promotion); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":   "Promotion created successfully",
+		"promotion": promotionResponse(promotion),
+	})
+}
+
+// UpdatePromotion replaces the settings of a promotion
+func (h *PromotionHandler) UpdatePromotion(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid promotion ID"})
+		return
+	}
+
+	var request promotionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get the current promotion
+	promotion, err := h.promotionService.GetPromotionByID(c, uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Promotion not found"})
+		return
+	}
+
+	if !h.applyPromotionRequest(c, promotion, request) {
+		return
+	}
+
+	if err := h.promotionService.UpdatePromotion(c, promotion); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Promotion updated successfully",
+		"promotion": promotionResponse(promotion),
+	})
+}
+
+// DeletePromotion deletes a promotion
+func (h *PromotionHandler) DeletePromotion(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid promotion ID"})
+		return
+	}
+
+	if err := h.promotionService.DeletePromotion(c, uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Promotion deleted successfully"})
+}
+
+// applyPromotionRequest sets the fields of a promotion from a request,
+// answering the request when a tier threshold is invalid
+func (h *PromotionHandler) applyPromotionRequest(c *gin.Context, promotion *domain.Promotion, request promotionRequest) bool {
+	baseCurrency := h.currencyService.BaseCurrency()
+
+	tiers := make([]domain.PromotionTier, 0, len(request.Tiers))
+	for _, tier := range request.Tiers {
+		minSubtotal, ok := requestAmount(c, tier.MinSubtotal, baseCurrency)
+		if !ok {
+			return false
+		}
+		tiers = append(tiers, domain.PromotionTier{MinSubtotal: minSubtotal, PercentOff: tier.PercentOff})
+	}
+
+	promotion.Name = request.Name
+	promotion.Description = request.Description
+	promotion.Type = request.Type
+	promotion.Priority = request.Priority
+	promotion.Exclusive = request.Exclusive
+	promotion.ProductIDs = request.ProductIDs
+	promotion.CategoryIDs = request.CategoryIDs
+	promotion.PercentOff = request.PercentOff
+	promotion.BuyQuantity = request.BuyQuantity
+	promotion.GetQuantity = request.GetQuantity
+	promotion.MinQuantity = request.MinQuantity
+	promotion.Tiers = tiers
+	promotion.StartsAt = request.StartsAt
+	promotion.EndsAt = request.EndsAt
+	if request.Active != nil {
+		promotion.Active = *request.Active
+	}
+	return true
+}
+
+// promotionResponse formats a promotion with its tier thresholds as decimal numbers
+func promotionResponse(promotion *domain.Promotion) gin.H {
+	tiers := []gin.H{}
+	for _, tier := range promotion.Tiers {
+		tiers = append(tiers, gin.H{
+			"min_subtotal": tier.MinSubtotal.Number(),
+			"currency":     tier.MinSubtotal.Currency,
+			"percent_off":  tier.PercentOff,
+		})
+	}
+
+	return gin.H{
+		"id":           promotion.ID,
+		"name":         promotion.Name,
+		"description":  promotion.Description,
+		"type":         promotion.Type,
+		"priority":     promotion.Priority,
+		"exclusive":    promotion.Exclusive,
+		"product_ids":  promotion.ProductIDs,
+		"category_ids": promotion.CategoryIDs,
+		"percent_off":  promotion.PercentOff,
+		"buy_quantity": promotion.BuyQuantity,
+		"get_quantity": promotion.GetQuantity,
+		"min_quantity": promotion.MinQuantity,
+		"tiers":        tiers,
+		"starts_at":    promotion.StartsAt,
+		"ends_at":      promotion.EndsAt,
+		"active":       promotion.Active,
+		"created_at":   promotion.CreatedAt,
+		"updated_at":   promotion.UpdatedAt,
+	}
+}
+
+// appliedPromotionResponses explains the promotions applied to a cart, with
+// their amounts as decimal numbers
+func appliedPromotionResponses(promotions []domain.AppliedPromotion) []gin.H {
+	responses := []gin.H{}
+	for _, promotion := range promotions {
+		responses = append(responses, gin.H{
+			"promotion_id": promotion.PromotionID,
+			"name":         promotion.Name,
+			"product_ids":  promotion.ProductIDs,
+			"amount":       promotion.Amount.Number(),
+		})
+	}
+	return responses
+}
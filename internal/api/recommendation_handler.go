@@ -0,0 +1,141 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/middleware"
+	"awesomeEcommerce/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RecommendationHandler handles HTTP requests related to product recommendations
+type RecommendationHandler struct {
+	recommendationService service.RecommendationService
+	userService           service.UserService
+	currencyService       service.CurrencyService
+	localizationService   service.LocalizationService
+}
+
+// NewRecommendationHandler creates a new RecommendationHandler
+func NewRecommendationHandler(recommendationService service.RecommendationService, userService service.UserService, currencyService service.CurrencyService, localizationService service.LocalizationService) *RecommendationHandler {
+	return &RecommendationHandler{
+		recommendationService: recommendationService,
+		userService:           userService,
+		currencyService:       currencyService,
+		localizationService:   localizationService,
+	}
+}
+
+// RegisterRoutes registers the routes for the RecommendationHandler
+func (h *RecommendationHandler) RegisterRoutes(router *gin.RouterGroup) {
+	// Public routes
+	router.GET("/products/:id/related", h.GetRelatedProducts)
+
+	// Customer routes (require authentication)
+	carts := router.Group("/carts")
+	{
+		auth := carts.Use(middleware.AuthMiddleware(h.userService))
+		{
+			auth.GET("/me/recommendations", h.GetCartRecommendations)
+		}
+	}
+}
+
+// GetRelatedProducts returns products frequently bought together with or similar to a product
+func (h *RecommendationHandler) GetRelatedProducts(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	recommendations, err := h.recommendationService.GetRelatedProducts(c, uint(id), limit)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
+	if err := h.localizeRecommendations(c, recommendations); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get recommendations"})
+		return
+	}
+
+	conversion, ok := requestConversion(c, h.currencyService)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"products": formatRecommendations(recommendations, conversion),
+		"locale":   h.localizationService.Locale(c),
+	})
+}
+
+// GetCartRecommendations returns products the authenticated user may also like based on their cart
+func (h *RecommendationHandler) GetCartRecommendations(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	recommendations, err := h.recommendationService.GetCartRecommendations(c, userID.(uint), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get recommendations"})
+		return
+	}
+
+	if err := h.localizeRecommendations(c, recommendations); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get recommendations"})
+		return
+	}
+
+	conversion, ok := requestConversion(c, h.currencyService)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"products": formatRecommendations(recommendations, conversion),
+		"locale":   h.localizationService.Locale(c),
+	})
+}
+
+// localizeRecommendations localizes the recommended products
+func (h *RecommendationHandler) localizeRecommendations(c *gin.Context, recommendations []domain.ProductRecommendation) error {
+	products := make([]*domain.Product, 0, len(recommendations))
+	for i := range recommendations {
+		products = append(products, &recommendations[i].RelatedProduct)
+	}
+	return localizeEmbeddedProducts(c, h.localizationService, products)
+}
+
+// formatRecommendations formats recommendations as a product list priced in
+// the currency of a conversion
+func formatRecommendations(recommendations []domain.ProductRecommendation, conversion domain.Conversion) []gin.H {
+	productList := []gin.H{}
+	for _, recommendation := range recommendations {
+		product := recommendation.RelatedProduct
+		productList = append(productList, gin.H{
+			"id":          product.ID,
+			"name":        product.Name,
+			"description": product.Description,
+			"price":       conversion.Convert(product.Price).Number(),
+			"currency":    conversion.Currency,
+			"stock":       product.Stock,
+			"sku":         product.SKU,
+			"image_url":   product.ImageURL,
+			"category_id": product.CategoryID,
+			"reason":      recommendation.Type,
+			"score":       recommendation.Score,
+		})
+	}
+	return productList
+}
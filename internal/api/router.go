@@ -17,6 +17,21 @@ type Router struct {
 	cartHandler    *CartHandler
 	orderHandler   *OrderHandler
 	paymentHandler *PaymentHandler
+
+	recommendationHandler *RecommendationHandler
+	inventoryHandler      *InventoryHandler
+	subscriptionHandler   *StockSubscriptionHandler
+	digitalHandler        *DigitalHandler
+	bundleHandler         *BundleHandler
+	currencyHandler       *CurrencyHandler
+	translationHandler    *TranslationHandler
+	couponHandler         *CouponHandler
+	promotionHandler      *PromotionHandler
+	taxHandler            *TaxHandler
+	shippingHandler       *ShippingHandler
+	abandonedCartHandler  *AbandonedCartHandler
+	giftCardHandler       *GiftCardHandler
+	shipmentHandler       *ShipmentHandler
 }
 
 // NewRouter creates a new Router
@@ -26,22 +41,60 @@ func NewRouter(
 	cartService service.CartService,
 	orderService service.OrderService,
 	paymentService service.PaymentService,
+	recommendationService service.RecommendationService,
+	inventoryService service.InventoryService,
+	reservationService service.ReservationService,
+	backorderService service.BackorderService,
+	stockAlertService service.StockAlertService,
+	subscriptionService service.StockSubscriptionService,
+	fulfillmentService service.DigitalFulfillmentService,
+	bundleService service.BundleService,
+	currencyService service.CurrencyService,
+	localizationService service.LocalizationService,
+	couponService service.CouponService,
+	promotionService service.PromotionService,
+	taxService service.TaxService,
+	shippingService service.ShippingService,
+	abandonedCartService service.AbandonedCartService,
+	giftCardService service.GiftCardService,
+	idempotencyService service.IdempotencyService,
+	shipmentService service.ShipmentService,
 ) *Router {
 	return &Router{
 		userHandler:    NewUserHandler(userService),
-		productHandler: NewProductHandler(productService, userService),
-		cartHandler:    NewCartHandler(cartService, userService, productService),
-		orderHandler:   NewOrderHandler(orderService, userService),
-		paymentHandler: NewPaymentHandler(paymentService, orderService, userService),
+		productHandler: NewProductHandler(productService, userService, currencyService, localizationService),
+		cartHandler:    NewCartHandler(cartService, userService, productService, currencyService, localizationService),
+		orderHandler:   NewOrderHandler(orderService, userService, idempotencyService),
+		paymentHandler: NewPaymentHandler(paymentService, orderService, userService, idempotencyService),
+
+		recommendationHandler: NewRecommendationHandler(recommendationService, userService, currencyService, localizationService),
+		inventoryHandler:      NewInventoryHandler(inventoryService, reservationService, backorderService, stockAlertService, userService),
+		subscriptionHandler:   NewStockSubscriptionHandler(subscriptionService, userService),
+		digitalHandler:        NewDigitalHandler(fulfillmentService, orderService, userService),
+		bundleHandler:         NewBundleHandler(bundleService, userService, currencyService, localizationService),
+		currencyHandler:       NewCurrencyHandler(currencyService, userService),
+		translationHandler:    NewTranslationHandler(localizationService, userService),
+		couponHandler:         NewCouponHandler(couponService, currencyService, userService),
+		promotionHandler:      NewPromotionHandler(promotionService, currencyService, userService),
+		taxHandler:            NewTaxHandler(taxService, userService),
+		shippingHandler:       NewShippingHandler(shippingService, currencyService, userService),
+		abandonedCartHandler:  NewAbandonedCartHandler(abandonedCartService, userService),
+		giftCardHandler:       NewGiftCardHandler(giftCardService, currencyService, userService),
+		shipmentHandler:       NewShipmentHandler(shipmentService, orderService, userService),
 	}
 }
 
 // SetupRoutes sets up all the routes for the API
 func (r *Router) SetupRoutes(engine *gin.Engine) {
+	// Let services read the values middleware adds to the request context
+	engine.ContextWithFallback = true
+
 	// Apply global middleware
 	engine.Use(middleware.LoggingMiddleware())
 	engine.Use(middleware.CORSMiddleware())
 	engine.Use(middleware.SecureMiddleware())
+	engine.Use(middleware.CurrencyMiddleware())
+	engine.Use(middleware.LocaleMiddleware())
 
 	// Health check endpoint
 	engine.GET("/health", func(c *gin.Context) {
@@ -59,6 +112,20 @@ func (r *Router) SetupRoutes(engine *gin.Engine) {
 		r.cartHandler.RegisterRoutes(v1)
 		r.orderHandler.RegisterRoutes(v1)
 		r.paymentHandler.RegisterRoutes(v1)
+		r.recommendationHandler.RegisterRoutes(v1)
+		r.inventoryHandler.RegisterRoutes(v1)
+		r.subscriptionHandler.RegisterRoutes(v1)
+		r.digitalHandler.RegisterRoutes(v1)
+		r.bundleHandler.RegisterRoutes(v1)
+		r.currencyHandler.RegisterRoutes(v1)
+		r.translationHandler.RegisterRoutes(v1)
+		r.couponHandler.RegisterRoutes(v1)
+		r.promotionHandler.RegisterRoutes(v1)
+		r.taxHandler.RegisterRoutes(v1)
+		r.shippingHandler.RegisterRoutes(v1)
+		r.abandonedCartHandler.RegisterRoutes(v1)
+		r.giftCardHandler.RegisterRoutes(v1)
+		r.shipmentHandler.RegisterRoutes(v1)
 	}
 
 	// No route found handler
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/middleware"
+	"awesomeEcommerce/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ShipmentHandler handles HTTP requests related to order shipments and their tracking
+type ShipmentHandler struct {
+	shipmentService service.ShipmentService
+	orderService    service.OrderService
+	userService     service.UserService
+}
+
+// NewShipmentHandler creates a new ShipmentHandler
+func NewShipmentHandler(shipmentService service.ShipmentService, orderService service.OrderService, userService service.UserService) *ShipmentHandler {
+	return &ShipmentHandler{
+		shipmentService: shipmentService,
+		orderService:    orderService,
+		userService:     userService,
+	}
+}
+
+// RegisterRoutes registers the routes for the ShipmentHandler
+func (h *ShipmentHandler) RegisterRoutes(router *gin.RouterGroup) {
+	// Carriers sign their tracking updates, so the webhook needs no authentication
+	router.POST("/shipments/webhooks/:carrier", h.TrackingWebhook)
+
+	// Customer routes (require authentication)
+	router.GET("/orders/me/:id/shipments", middleware.AuthMiddleware(h.userService), h.GetMyShipments)
+
+	// Admin routes
+	admin := router.Group("", middleware.AuthMiddleware(h.userService), middleware.RoleMiddleware("admin"))
+	{
+		admin.GET("/orders/:id/shipments", h.GetShipments)
+		admin.POST("/orders/:id/shipments", h.CreateShipment)
+	}
+}
+
+// CreateShipment ships units of the lines of an order (admin only)
+func (h *ShipmentHandler) CreateShipment(c *gin.Context) {
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	var request struct {
+		Carrier        string `json:"carrier"`
+		TrackingNumber string `json:"tracking_number" binding:"required"`
+		Items          []struct {
+			OrderItemID uint `json:"order_item_id" binding:"required"`
+			Quantity    int  `json:"quantity" binding:"required,min=1"`
+		} `json:"items" binding:"dive"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	items := make([]domain.ShipmentItem, 0, len(request.Items))
+	for _, item := range request.Items {
+		items = append(items, domain.ShipmentItem{OrderItemID: item.OrderItemID, Quantity: item.Quantity})
+	}
+
+	shipment, err := h.shipmentService.CreateShipment(c, uint(orderID), request.Carrier, request.TrackingNumber, items)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Shipment created successfully",
+		"shipment": shipment,
+	})
+}
+
+// GetShipments returns the shipments of an order (admin only)
+func (h *ShipmentHandler) GetShipments(c *gin.Context) {
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	shipments, err := h.shipmentService.GetOrderShipments(c, uint(orderID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get shipments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"shipments": shipments})
+}
+
+// GetMyShipments returns the shipments of an order of the authenticated user
+func (h *ShipmentHandler) GetMyShipments(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	// Check if the order belongs to the user
+	order, err := h.orderService.GetOrderByID(c, uint(orderID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+	if order.UserID != userID.(uint) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return
+	}
+
+	shipments, err := h.shipmentService.GetOrderShipments(c, order.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get shipments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"shipments": shipments})
+}
+
+// TrackingWebhook applies the tracking updates a carrier posts
+func (h *ShipmentHandler) TrackingWebhook(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+		return
+	}
+
+	applied, err := h.shipmentService.HandleTrackingWebhook(c, c.Param("carrier"), c.Request.Header, body)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrUnknownCarrier):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, domain.ErrInvalidWebhookSignature):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case errors.Is(err, domain.ErrInvalidTrackingPayload):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply tracking updates"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"applied": applied})
+}
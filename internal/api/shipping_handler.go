@@ -0,0 +1,378 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/middleware"
+	"awesomeEcommerce/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ShippingHandler handles HTTP requests related to shipping zones and methods
+type ShippingHandler struct {
+	shippingService service.ShippingService
+	currencyService service.CurrencyService
+	userService     service.UserService
+}
+
+// NewShippingHandler creates a new ShippingHandler
+func NewShippingHandler(shippingService service.ShippingService, currencyService service.CurrencyService, userService service.UserService) *ShippingHandler {
+	return &ShippingHandler{
+		shippingService: shippingService,
+		currencyService: currencyService,
+		userService:     userService,
+	}
+}
+
+// RegisterRoutes registers the routes for the ShippingHandler
+func (h *ShippingHandler) RegisterRoutes(router *gin.RouterGroup) {
+	shipping := router.Group("/shipping")
+	{
+		// Admin routes (require authentication and admin role)
+		admin := shipping.Use(middleware.AuthMiddleware(h.userService), middleware.RoleMiddleware("admin"))
+		{
+			admin.GET("/zones", h.GetZones)
+			admin.GET("/zones/:id", h.GetZoneByID)
+			admin.POST("/zones", h.CreateZone)
+			admin.PUT("/zones/:id", h.UpdateZone)
+			admin.DELETE("/zones/:id", h.DeleteZone)
+
+			admin.GET("/methods", h.GetMethods)
+			admin.GET("/methods/:id", h.GetMethodByID)
+			admin.POST("/methods", h.CreateMethod)
+			admin.PUT("/methods/:id", h.UpdateMethod)
+			admin.DELETE("/methods/:id", h.DeleteMethod)
+		}
+	}
+}
+
+// shippingZoneRequest is the body creating or replacing a shipping zone
+type shippingZoneRequest struct {
+	Name           string   `json:"name" binding:"required"`
+	Countries      []string `json:"countries" binding:"required"`
+	Regions        []string `json:"regions"`
+	PostalPrefixes []string `json:"postal_prefixes"`
+}
+
+// shippingMethodRequest is the body creating or replacing a shipping method.
+// Amounts are in the base currency.
+type shippingMethodRequest struct {
+	ZoneID        *uint                     `json:"zone_id"`
+	Name          string                    `json:"name" binding:"required"`
+	Description   string                    `json:"description"`
+	Type          domain.ShippingMethodType `json:"type" binding:"required"`
+	Rate          json.Number               `json:"rate"`
+	PerKg         json.Number               `json:"per_kg"`
+	FreeOver      json.Number               `json:"free_over"`
+	Carrier       string                    `json:"carrier"`
+	Service       string                    `json:"service"`
+	PickupAddress string                    `json:"pickup_address"`
+	MinDays       int                       `json:"min_days"`
+	MaxDays       int                       `json:"max_days"`
+	SortOrder     int                       `json:"sort_order"`
+	Active        *bool                     `json:"active"`
+}
+
+// GetZones returns all shipping zones
+func (h *ShippingHandler) GetZones(c *gin.Context) {
+	zones, err := h.shippingService.GetZones(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get shipping zones"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"zones": zones})
+}
+
+// GetZoneByID returns a specific shipping zone
+func (h *ShippingHandler) GetZoneByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shipping zone ID"})
+		return
+	}
+
+	zone, err := h.shippingService.GetZoneByID(c, uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Shipping zone not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"zone": zone})
+}
+
+// CreateZone creates a new shipping zone
+func (h *ShippingHandler) CreateZone(c *gin.Context) {
+	var request shippingZoneRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	zone := &domain.ShippingZone{}
+	applyShippingZoneRequest(zone, request)
+
+	if err := h.shippingService.CreateZone(c, zone); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Shipping zone created successfully",
+		"zone":    zone,
+	})
+}
+
+// UpdateZone replaces the destinations of a shipping zone
+func (h *ShippingHandler) UpdateZone(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shipping zone ID"})
+		return
+	}
+
+	var request shippingZoneRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get the current shipping zone
+	zone, err := h.shippingService.GetZoneByID(c, uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Shipping zone not found"})
+		return
+	}
+
+	applyShippingZoneRequest(zone, request)
+
+	if err := h.shippingService.UpdateZone(c, zone); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Shipping zone updated successfully",
+		"zone":    zone,
+	})
+}
+
+// DeleteZone deletes a shipping zone
+func (h *ShippingHandler) DeleteZone(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shipping zone ID"})
+		return
+	}
+
+	if err := h.shippingService.DeleteZone(c, uint(id)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Shipping zone deleted successfully"})
+}
+
+// GetMethods returns all shipping methods, in the order they are offered
+func (h *ShippingHandler) GetMethods(c *gin.Context) {
+	methods, err := h.shippingService.GetMethods(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get shipping methods"})
+		return
+	}
+
+	methodList := []gin.H{}
+	for i := range methods {
+		methodList = append(methodList, shippingMethodResponse(&methods[i]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"methods": methodList})
+}
+
+// GetMethodByID returns a specific shipping method
+func (h *ShippingHandler) GetMethodByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shipping method ID"})
+		return
+	}
+
+	method, err := h.shippingService.GetMethodByID(c, uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Shipping method not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"method": shippingMethodResponse(method)})
+}
+
+// CreateMethod creates a new shipping method
+func (h *ShippingHandler) CreateMethod(c *gin.Context) {
+	var request shippingMethodRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	method := &domain.ShippingMethod{Active: true}
+	if !h.applyShippingMethodRequest(c, method, request) {
+		return
+	}
+
+	if err := h.shippingService.CreateMethod(c, method); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Shipping method created successfully",
+		"method":  shippingMethodResponse(method),
+	})
+}
+
+// UpdateMethod replaces the settings of a shipping method
+func (h *ShippingHandler) UpdateMethod(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shipping method ID"})
+		return
+	}
+
+	var request shippingMethodRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get the current shipping method
+	method, err := h.shippingService.GetMethodByID(c, uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Shipping method not found"})
+		return
+	}
+
+	if !h.applyShippingMethodRequest(c, method, request) {
+		return
+	}
+
+	if err := h.shippingService.UpdateMethod(c, method); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Shipping method updated successfully",
+		"method":  shippingMethodResponse(method),
+	})
+}
+
+// DeleteMethod deletes a shipping method
+func (h *ShippingHandler) DeleteMethod(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shipping method ID"})
+		return
+	}
+
+	if err := h.shippingService.DeleteMethod(c, uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Shipping method deleted successfully"})
+}
+
+// applyShippingZoneRequest sets the fields of a shipping zone from a request
+func applyShippingZoneRequest(zone *domain.ShippingZone, request shippingZoneRequest) {
+	zone.Name = request.Name
+	zone.Countries = request.Countries
+	zone.Regions = request.Regions
+	zone.PostalPrefixes = request.PostalPrefixes
+}
+
+// applyShippingMethodRequest sets the fields of a shipping method from a
+// request, answering the request when an amount is invalid
+func (h *ShippingHandler) applyShippingMethodRequest(c *gin.Context, method *domain.ShippingMethod, request shippingMethodRequest) bool {
+	baseCurrency := h.currencyService.BaseCurrency()
+
+	amounts := []json.Number{request.Rate, request.PerKg, request.FreeOver}
+	parsed := make([]domain.Money, len(amounts))
+	for i, amount := range amounts {
+		parsed[i] = domain.Money{Currency: baseCurrency}
+		if amount == "" {
+			continue
+		}
+		money, err := domain.ParseMoney(amount.String(), baseCurrency)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return false
+		}
+		parsed[i] = money
+	}
+
+	method.ZoneID = request.ZoneID
+	method.Name = request.Name
+	method.Description = request.Description
+	method.Type = request.Type
+	method.Rate = parsed[0]
+	method.PerKg = parsed[1]
+	method.FreeOver = parsed[2]
+	method.Carrier = request.Carrier
+	method.Service = request.Service
+	method.PickupAddress = request.PickupAddress
+	method.MinDays = request.MinDays
+	method.MaxDays = request.MaxDays
+	method.SortOrder = request.SortOrder
+	if request.Active != nil {
+		method.Active = *request.Active
+	}
+	return true
+}
+
+// shippingMethodResponse formats a shipping method with its amounts as decimal numbers
+func shippingMethodResponse(method *domain.ShippingMethod) gin.H {
+	return gin.H{
+		"id":             method.ID,
+		"zone_id":        method.ZoneID,
+		"name":           method.Name,
+		"description":    method.Description,
+		"type":           method.Type,
+		"rate":           method.Rate.Number(),
+		"per_kg":         method.PerKg.Number(),
+		"free_over":      method.FreeOver.Number(),
+		"currency":       method.Rate.Currency,
+		"carrier":        method.Carrier,
+		"service":        method.Service,
+		"pickup_address": method.PickupAddress,
+		"min_days":       method.MinDays,
+		"max_days":       method.MaxDays,
+		"sort_order":     method.SortOrder,
+		"active":         method.Active,
+		"created_at":     method.CreatedAt,
+		"updated_at":     method.UpdatedAt,
+	}
+}
+
+// shippingOptionResponses formats shipping options with their costs as decimal numbers
+func shippingOptionResponses(options []domain.ShippingOption) []gin.H {
+	responses := []gin.H{}
+	for _, option := range options {
+		responses = append(responses, gin.H{
+			"method_id":      option.MethodID,
+			"name":           option.Name,
+			"description":    option.Description,
+			"type":           option.Type,
+			"carrier":        option.Carrier,
+			"pickup_address": option.PickupAddress,
+			"min_days":       option.MinDays,
+			"max_days":       option.MaxDays,
+			"cost":           option.Cost.Number(),
+			"currency":       option.Cost.Currency,
+		})
+	}
+	return responses
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/middleware"
+	"awesomeEcommerce/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StockSubscriptionHandler handles HTTP requests related to back-in-stock subscriptions
+type StockSubscriptionHandler struct {
+	subscriptionService service.StockSubscriptionService
+	userService         service.UserService
+}
+
+// NewStockSubscriptionHandler creates a new StockSubscriptionHandler
+func NewStockSubscriptionHandler(subscriptionService service.StockSubscriptionService, userService service.UserService) *StockSubscriptionHandler {
+	return &StockSubscriptionHandler{
+		subscriptionService: subscriptionService,
+		userService:         userService,
+	}
+}
+
+// RegisterRoutes registers the routes for the StockSubscriptionHandler
+func (h *StockSubscriptionHandler) RegisterRoutes(router *gin.RouterGroup) {
+	// Guests subscribe by email, authenticated users with their account
+	router.POST("/products/:id/subscriptions", middleware.OptionalAuthMiddleware(h.userService), h.Subscribe)
+
+	// Unsubscribe links carry the subscription token
+	router.DELETE("/subscriptions/:token", h.Unsubscribe)
+
+	// Authenticated routes
+	router.GET("/users/me/subscriptions", middleware.AuthMiddleware(h.userService), h.GetMySubscriptions)
+}
+
+// Subscribe subscribes the authenticated user, or a guest by email, to an out-of-stock product
+func (h *StockSubscriptionHandler) Subscribe(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var request struct {
+		Email string `json:"email" binding:"omitempty,email"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Authenticated users are notified at their account email
+	var userID *uint
+	email := request.Email
+	if value, exists := c.Get("userID"); exists {
+		id := value.(uint)
+		userID = &id
+		email = c.GetString("email")
+	}
+	if email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Email is required"})
+		return
+	}
+
+	subscription, err := h.subscriptionService.Subscribe(c, uint(id), userID, email)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Subscribed to back-in-stock notifications",
+		"subscription": gin.H{
+			"id":                subscription.ID,
+			"product_id":        subscription.ProductID,
+			"email":             subscription.Email,
+			"status":            subscription.Status,
+			"expires_at":        subscription.ExpiresAt,
+			"unsubscribe_token": subscription.Token,
+		},
+	})
+}
+
+// Unsubscribe cancels a pending subscription by its token
+func (h *StockSubscriptionHandler) Unsubscribe(c *gin.Context) {
+	if err := h.subscriptionService.Unsubscribe(c, c.Param("token")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Unsubscribed successfully"})
+}
+
+// GetMySubscriptions returns the back-in-stock subscriptions of the authenticated user
+func (h *StockSubscriptionHandler) GetMySubscriptions(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	subscriptions, err := h.subscriptionService.GetUserSubscriptions(c, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get subscriptions"})
+		return
+	}
+
+	if subscriptions == nil {
+		subscriptions = []domain.StockSubscription{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"subscriptions": subscriptions})
+}
@@ -0,0 +1,228 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/middleware"
+	"awesomeEcommerce/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TaxHandler handles HTTP requests related to tax rates and exemptions
+type TaxHandler struct {
+	taxService  service.TaxService
+	userService service.UserService
+}
+
+// NewTaxHandler creates a new TaxHandler
+func NewTaxHandler(taxService service.TaxService, userService service.UserService) *TaxHandler {
+	return &TaxHandler{
+		taxService:  taxService,
+		userService: userService,
+	}
+}
+
+// RegisterRoutes registers the routes for the TaxHandler
+func (h *TaxHandler) RegisterRoutes(router *gin.RouterGroup) {
+	taxes := router.Group("/taxes")
+	{
+		// Admin routes (require authentication and admin role)
+		admin := taxes.Use(middleware.AuthMiddleware(h.userService), middleware.RoleMiddleware("admin"))
+		{
+			admin.GET("/rates", h.GetTaxRates)
+			admin.GET("/rates/:id", h.GetTaxRateByID)
+			admin.POST("/rates", h.CreateTaxRate)
+			admin.PUT("/rates/:id", h.UpdateTaxRate)
+			admin.DELETE("/rates/:id", h.DeleteTaxRate)
+			admin.PUT("/exemptions/:user_id", h.SetTaxExempt)
+		}
+	}
+}
+
+// taxRateRequest is the body creating or replacing a tax rate. Region and
+// postal prefix narrow the rate within its country when set.
+type taxRateRequest struct {
+	Name         string  `json:"name" binding:"required"`
+	Country      string  `json:"country" binding:"required"`
+	Region       string  `json:"region"`
+	PostalPrefix string  `json:"postal_prefix"`
+	TaxClass     string  `json:"tax_class"`
+	Rate         float64 `json:"rate"`
+}
+
+// GetTaxRates returns all tax rates
+func (h *TaxHandler) GetTaxRates(c *gin.Context) {
+	rates, err := h.taxService.GetTaxRates(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get tax rates"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tax_rates": rates})
+}
+
+// GetTaxRateByID returns a specific tax rate
+func (h *TaxHandler) GetTaxRateByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tax rate ID"})
+		return
+	}
+
+	rate, err := h.taxService.GetTaxRateByID(c, uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tax rate not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tax_rate": rate})
+}
+
+// CreateTaxRate creates a new tax rate
+func (h *TaxHandler) CreateTaxRate(c *gin.Context) {
+	var request taxRateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rate := &domain.TaxRate{}
+	applyTaxRateRequest(rate, request)
+
+	if err := h.taxService.CreateTaxRate(c, rate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Tax rate created successfully",
+		"tax_rate": rate,
+	})
+}
+
+// UpdateTaxRate replaces the settings of a tax rate
+func (h *TaxHandler) UpdateTaxRate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tax rate ID"})
+		return
+	}
+
+	var request taxRateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get the current tax rate
+	rate, err := h.taxService.GetTaxRateByID(c, uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tax rate not found"})
+		return
+	}
+
+	applyTaxRateRequest(rate, request)
+
+	if err := h.taxService.UpdateTaxRate(c, rate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Tax rate updated successfully",
+		"tax_rate": rate,
+	})
+}
+
+// DeleteTaxRate deletes a tax rate
+func (h *TaxHandler) DeleteTaxRate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tax rate ID"})
+		return
+	}
+
+	if err := h.taxService.DeleteTaxRate(c, uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tax rate deleted successfully"})
+}
+
+// SetTaxExempt sets whether a customer is exempt from tax
+func (h *TaxHandler) SetTaxExempt(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var request struct {
+		TaxExempt *bool `json:"tax_exempt" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.taxService.SetTaxExempt(c, uint(userID), *request.TaxExempt)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Tax exemption updated successfully",
+		"user_id":    user.ID,
+		"tax_exempt": user.TaxExempt,
+	})
+}
+
+// applyTaxRateRequest sets the fields of a tax rate from a request
+func applyTaxRateRequest(rate *domain.TaxRate, request taxRateRequest) {
+	rate.Name = request.Name
+	rate.Country = request.Country
+	rate.Region = request.Region
+	rate.PostalPrefix = request.PostalPrefix
+	rate.TaxClass = request.TaxClass
+	rate.Rate = request.Rate
+}
+
+// queryDestination reads the destination a cart is priced for from the
+// country, region and postal_code query parameters
+func queryDestination(c *gin.Context) domain.Destination {
+	return domain.Destination{
+		Country:    c.Query("country"),
+		Region:     c.Query("region"),
+		PostalCode: c.Query("postal_code"),
+	}
+}
+
+// setDestination records the destination a request delivers to, which the
+// services tax and ship to
+func setDestination(c *gin.Context, destination domain.Destination) {
+	c.Request = c.Request.WithContext(domain.WithDestination(c.Request.Context(), destination))
+}
+
+// taxLineResponses explains the taxes of a cart or order, with their amounts
+// as decimal numbers
+func taxLineResponses(taxes []domain.TaxLine) []gin.H {
+	responses := []gin.H{}
+	for _, tax := range taxes {
+		responses = append(responses, gin.H{
+			"product_id":  tax.ProductID,
+			"tax_rate_id": tax.TaxRateID,
+			"name":        tax.Name,
+			"tax_class":   tax.TaxClass,
+			"rate":        tax.Rate,
+			"taxable":     tax.Taxable.Number(),
+			"amount":      tax.Amount.Number(),
+		})
+	}
+	return responses
+}
@@ -0,0 +1,194 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/middleware"
+	"awesomeEcommerce/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TranslationHandler handles HTTP requests related to product and category translations
+type TranslationHandler struct {
+	localizationService service.LocalizationService
+	userService         service.UserService
+}
+
+// NewTranslationHandler creates a new TranslationHandler
+func NewTranslationHandler(localizationService service.LocalizationService, userService service.UserService) *TranslationHandler {
+	return &TranslationHandler{
+		localizationService: localizationService,
+		userService:         userService,
+	}
+}
+
+// RegisterRoutes registers the routes for the TranslationHandler
+func (h *TranslationHandler) RegisterRoutes(router *gin.RouterGroup) {
+	// Admin routes
+	admin := router.Group("", middleware.AuthMiddleware(h.userService), middleware.RoleMiddleware("admin"))
+	{
+		admin.GET("/products/:id/translations", h.GetProductTranslations)
+		admin.PUT("/products/:id/translations/:locale", h.SetProductTranslation)
+		admin.DELETE("/products/:id/translations/:locale", h.DeleteProductTranslation)
+		admin.GET("/products/categories/:id/translations", h.GetCategoryTranslations)
+		admin.PUT("/products/categories/:id/translations/:locale", h.SetCategoryTranslation)
+		admin.DELETE("/products/categories/:id/translations/:locale", h.DeleteCategoryTranslation)
+	}
+}
+
+// GetProductTranslations returns every translation of a product
+func (h *TranslationHandler) GetProductTranslations(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	translations, err := h.localizationService.GetProductTranslations(c, uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get translations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"default_locale": h.localizationService.DefaultLocale(),
+		"translations":   translations,
+	})
+}
+
+// SetProductTranslation creates or updates the translation of a product in a locale
+func (h *TranslationHandler) SetProductTranslation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var request struct {
+		Name        string `json:"name" binding:"required"`
+		Description string `json:"description"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	translation := &domain.ProductTranslation{
+		ProductID:   uint(id),
+		Locale:      c.Param("locale"),
+		Name:        request.Name,
+		Description: request.Description,
+	}
+	if err := h.localizationService.SetProductTranslation(c, translation); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Translation saved successfully",
+		"translation": translation,
+	})
+}
+
+// DeleteProductTranslation deletes the translation of a product in a locale
+func (h *TranslationHandler) DeleteProductTranslation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	if err := h.localizationService.DeleteProductTranslation(c, uint(id), c.Param("locale")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete translation"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Translation deleted successfully"})
+}
+
+// GetCategoryTranslations returns every translation of a category
+func (h *TranslationHandler) GetCategoryTranslations(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return
+	}
+
+	translations, err := h.localizationService.GetCategoryTranslations(c, uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get translations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"default_locale": h.localizationService.DefaultLocale(),
+		"translations":   translations,
+	})
+}
+
+// SetCategoryTranslation creates or updates the translation of a category in a locale
+func (h *TranslationHandler) SetCategoryTranslation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return
+	}
+
+	var request struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	translation := &domain.CategoryTranslation{
+		CategoryID: uint(id),
+		Locale:     c.Param("locale"),
+		Name:       request.Name,
+	}
+	if err := h.localizationService.SetCategoryTranslation(c, translation); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Translation saved successfully",
+		"translation": translation,
+	})
+}
+
+// DeleteCategoryTranslation deletes the translation of a category in a locale
+func (h *TranslationHandler) DeleteCategoryTranslation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return
+	}
+
+	if err := h.localizationService.DeleteCategoryTranslation(c, uint(id), c.Param("locale")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete translation"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Translation deleted successfully"})
+}
+
+// localizeEmbeddedProducts localizes products held by other values, such as
+// the products of cart items
+func localizeEmbeddedProducts(c *gin.Context, localizationService service.LocalizationService, products []*domain.Product) error {
+	values := make([]domain.Product, len(products))
+	for i, product := range products {
+		values[i] = *product
+	}
+	if err := localizationService.LocalizeProducts(c, values); err != nil {
+		return err
+	}
+	for i, product := range products {
+		*product = values[i]
+	}
+	return nil
+}
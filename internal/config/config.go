@@ -1,21 +1,45 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
 // Config represents the application configuration
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Redis    RedisConfig
-	Kafka    KafkaConfig
+	Server         ServerConfig
+	Database       DatabaseConfig
+	Redis          RedisConfig
+	Kafka          KafkaConfig
+	Recommendation RecommendationConfig
+	Inventory      InventoryConfig
+	Reservation    ReservationConfig
+	Backorder      BackorderConfig
+	StockAlert     StockAlertConfig
+	BackInStock    BackInStockConfig
+	Digital        DigitalConfig
+	Currency       CurrencyConfig
+	Locale         LocaleConfig
+	Tax            TaxConfig
+	AbandonedCart  AbandonedCartConfig
+	Idempotency    IdempotencyConfig
+	Order          OrderConfig
+	Shipment       ShipmentConfig
 }
 
+// EnvironmentDevelopment is the environment that may run without secrets
+const EnvironmentDevelopment = "development"
+
+// developmentSecret is the well-known secret used in development when a
+// secret is not set
+const developmentSecret = "development-secret"
+
 // ServerConfig represents the server configuration
 type ServerConfig struct {
+	Environment  string
 	Port         string
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
@@ -48,16 +72,106 @@ type KafkaConfig struct {
 
 // KafkaTopics represents the Kafka topics
 type KafkaTopics struct {
-	OrderCreated  string
-	OrderUpdated  string
-	ProductSync   string
-	PaymentStatus string
+	OrderCreated    string
+	OrderUpdated    string
+	ProductSync     string
+	PaymentStatus   string
+	LowStockAlert   string
+	BackInStock     string
+	DigitalDelivery string
+	CartAbandoned   string
+}
+
+// RecommendationConfig represents the product recommendation configuration
+type RecommendationConfig struct {
+	RefreshInterval time.Duration
+	RebuildInterval time.Duration
+	MaxRelated      int
+}
+
+// InventoryConfig represents the warehouse inventory configuration
+type InventoryConfig struct {
+	AllocationStrategy string
+}
+
+// ReservationConfig represents the checkout stock reservation configuration
+type ReservationConfig struct {
+	TTL           time.Duration
+	SweepInterval time.Duration
+}
+
+// BackorderConfig represents the backorder allocation configuration
+type BackorderConfig struct {
+	AllocationInterval time.Duration
+}
+
+// StockAlertConfig represents the low-stock alert configuration
+type StockAlertConfig struct {
+	DefaultReorderPoint    int
+	DefaultReorderQuantity int
+	CheckInterval          time.Duration
+}
+
+// BackInStockConfig represents the back-in-stock subscription configuration
+type BackInStockConfig struct {
+	SubscriptionTTL time.Duration
+	NotifyInterval  time.Duration
+}
+
+// DigitalConfig represents the digital product delivery configuration
+type DigitalConfig struct {
+	StorageDir      string
+	DownloadBaseURL string
+	DownloadSecret  string
+	DownloadTTL     time.Duration
+}
+
+// CurrencyConfig represents the currency configuration
+type CurrencyConfig struct {
+	BaseCurrency string
+	RatesFile    string
+}
+
+// LocaleConfig represents the localization configuration
+type LocaleConfig struct {
+	DefaultLocale string
+}
+
+// TaxConfig represents the tax configuration
+type TaxConfig struct {
+	Mode           string
+	DefaultCountry string
+}
+
+// AbandonedCartConfig represents the abandoned cart reminder configuration
+type AbandonedCartConfig struct {
+	ReminderDelays []time.Duration
+	MaxIdle        time.Duration
+	CheckInterval  time.Duration
+}
+
+// IdempotencyConfig represents the idempotency key configuration
+type IdempotencyConfig struct {
+	KeyTTL      time.Duration
+	LockTimeout time.Duration
+}
+
+// OrderConfig represents the order lifecycle configuration. StatusTransitions
+// overrides the default transitions, written as "from:to,to;from:to".
+type OrderConfig struct {
+	StatusTransitions string
+}
+
+// ShipmentConfig represents the shipment tracking configuration
+type ShipmentConfig struct {
+	WebhookSecret string
 }
 
 // LoadConfig loads the configuration from environment variables
-func LoadConfig() *Config {
-	return &Config{
+func LoadConfig() (*Config, error) {
+	cfg := &Config{
 		Server: ServerConfig{
+			Environment:  getEnv("APP_ENV", "production"),
 			Port:         getEnv("SERVER_PORT", "8080"),
 			ReadTimeout:  getDurationEnv("SERVER_READ_TIMEOUT", 10*time.Second),
 			WriteTimeout: getDurationEnv("SERVER_WRITE_TIMEOUT", 10*time.Second),
@@ -80,13 +194,102 @@ func LoadConfig() *Config {
 			Brokers: []string{getEnv("KAFKA_BROKER", "localhost:9092")},
 			GroupID: getEnv("KAFKA_GROUP_ID", "ecommerce-group"),
 			Topics: KafkaTopics{
-				OrderCreated:  getEnv("KAFKA_TOPIC_ORDER_CREATED", "order-created"),
-				OrderUpdated:  getEnv("KAFKA_TOPIC_ORDER_UPDATED", "order-updated"),
-				ProductSync:   getEnv("KAFKA_TOPIC_PRODUCT_SYNC", "product-sync"),
-				PaymentStatus: getEnv("KAFKA_TOPIC_PAYMENT_STATUS", "payment-status"),
+				OrderCreated:    getEnv("KAFKA_TOPIC_ORDER_CREATED", "order-created"),
+				OrderUpdated:    getEnv("KAFKA_TOPIC_ORDER_UPDATED", "order-updated"),
+				ProductSync:     getEnv("KAFKA_TOPIC_PRODUCT_SYNC", "product-sync"),
+				PaymentStatus:   getEnv("KAFKA_TOPIC_PAYMENT_STATUS", "payment-status"),
+				LowStockAlert:   getEnv("KAFKA_TOPIC_LOW_STOCK_ALERT", "low-stock-alert"),
+				BackInStock:     getEnv("KAFKA_TOPIC_BACK_IN_STOCK", "back-in-stock"),
+				DigitalDelivery: getEnv("KAFKA_TOPIC_DIGITAL_DELIVERY", "digital-delivery"),
+				CartAbandoned:   getEnv("KAFKA_TOPIC_CART_ABANDONED", "cart-abandoned"),
 			},
 		},
+		Recommendation: RecommendationConfig{
+			RefreshInterval: getDurationEnv("RECOMMENDATION_REFRESH_INTERVAL", time.Minute),
+			RebuildInterval: getDurationEnv("RECOMMENDATION_REBUILD_INTERVAL", 24*time.Hour),
+			MaxRelated:      getIntEnv("RECOMMENDATION_MAX_RELATED", 10),
+		},
+		Inventory: InventoryConfig{
+			AllocationStrategy: getEnv("INVENTORY_ALLOCATION_STRATEGY", "priority"),
+		},
+		Reservation: ReservationConfig{
+			TTL:           getDurationEnv("RESERVATION_TTL", 15*time.Minute),
+			SweepInterval: getDurationEnv("RESERVATION_SWEEP_INTERVAL", time.Minute),
+		},
+		Backorder: BackorderConfig{
+			AllocationInterval: getDurationEnv("BACKORDER_ALLOCATION_INTERVAL", time.Minute),
+		},
+		StockAlert: StockAlertConfig{
+			DefaultReorderPoint:    getIntEnv("STOCK_ALERT_DEFAULT_REORDER_POINT", 10),
+			DefaultReorderQuantity: getIntEnv("STOCK_ALERT_DEFAULT_REORDER_QUANTITY", 50),
+			CheckInterval:          getDurationEnv("STOCK_ALERT_CHECK_INTERVAL", 5*time.Minute),
+		},
+		BackInStock: BackInStockConfig{
+			SubscriptionTTL: getDurationEnv("BACK_IN_STOCK_SUBSCRIPTION_TTL", 90*24*time.Hour),
+			NotifyInterval:  getDurationEnv("BACK_IN_STOCK_NOTIFY_INTERVAL", time.Minute),
+		},
+		Digital: DigitalConfig{
+			StorageDir:      getEnv("DIGITAL_STORAGE_DIR", "./storage/digital"),
+			DownloadBaseURL: getEnv("DIGITAL_DOWNLOAD_BASE_URL", "http://localhost:8080"),
+			DownloadSecret:  os.Getenv("DIGITAL_DOWNLOAD_SECRET"),
+			DownloadTTL:     getDurationEnv("DIGITAL_DOWNLOAD_TTL", 24*time.Hour),
+		},
+		Currency: CurrencyConfig{
+			BaseCurrency: getEnv("CURRENCY_BASE", "USD"),
+			RatesFile:    getEnv("CURRENCY_RATES_FILE", ""),
+		},
+		Locale: LocaleConfig{
+			DefaultLocale: getEnv("LOCALE_DEFAULT", "en"),
+		},
+		Tax: TaxConfig{
+			Mode:           getEnv("TAX_MODE", "exclusive"),
+			DefaultCountry: getEnv("TAX_DEFAULT_COUNTRY", ""),
+		},
+		AbandonedCart: AbandonedCartConfig{
+			ReminderDelays: getDurationListEnv("ABANDONED_CART_REMINDER_DELAYS", []time.Duration{time.Hour, 24 * time.Hour, 72 * time.Hour}),
+			MaxIdle:        getDurationEnv("ABANDONED_CART_MAX_IDLE", 7*24*time.Hour),
+			CheckInterval:  getDurationEnv("ABANDONED_CART_CHECK_INTERVAL", 5*time.Minute),
+		},
+		Idempotency: IdempotencyConfig{
+			KeyTTL:      getDurationEnv("IDEMPOTENCY_KEY_TTL", 24*time.Hour),
+			LockTimeout: getDurationEnv("IDEMPOTENCY_LOCK_TIMEOUT", time.Minute),
+		},
+		Order: OrderConfig{
+			StatusTransitions: getEnv("ORDER_STATUS_TRANSITIONS", ""),
+		},
+		Shipment: ShipmentConfig{
+			WebhookSecret: os.Getenv("SHIPMENT_WEBHOOK_SECRET"),
+		},
 	}
+
+	if err := cfg.requireSecrets(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// requireSecrets refuses to run without the secrets signing what the
+// application hands out and what carriers send it. Development falls back
+// to a well-known secret.
+func (c *Config) requireSecrets() error {
+	secrets := []struct {
+		key   string
+		value *string
+	}{
+		{"DIGITAL_DOWNLOAD_SECRET", &c.Digital.DownloadSecret},
+		{"SHIPMENT_WEBHOOK_SECRET", &c.Shipment.WebhookSecret},
+	}
+
+	for _, secret := range secrets {
+		if *secret.value != "" {
+			continue
+		}
+		if c.Server.Environment != EnvironmentDevelopment {
+			return fmt.Errorf("%s must be set outside the %s environment", secret.key, EnvironmentDevelopment)
+		}
+		*secret.value = developmentSecret
+	}
+	return nil
 }
 
 // Helper functions to get environment variables with default values
@@ -120,4 +323,20 @@ func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
 		return defaultValue
 	}
 	return durationValue
-}
\ No newline at end of file
+}
+
+func getDurationListEnv(key string, defaultValue []time.Duration) []time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	var durations []time.Duration
+	for _, part := range strings.Split(value, ",") {
+		durationValue, err := time.ParseDuration(strings.TrimSpace(part))
+		if err != nil {
+			return defaultValue
+		}
+		durations = append(durations, durationValue)
+	}
+	return durations
+}
@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"time"
+)
+
+// AbandonedCartStatus represents the status of an abandoned cart
+type AbandonedCartStatus string
+
+const (
+	// AbandonedCartStatusAbandoned is a cart left with items that has not become an order
+	AbandonedCartStatusAbandoned AbandonedCartStatus = "abandoned"
+	// AbandonedCartStatusRecovered is an abandoned cart that became an order after a reminder
+	AbandonedCartStatusRecovered AbandonedCartStatus = "recovered"
+)
+
+// AbandonedCart tracks a cart whose items were left untouched, from its first
+// reminder until it becomes an order. A cart touched again keeps its record,
+// but starts the reminder sequence over. Customers who unsubscribe get no
+// more reminders about any of their carts.
+type AbandonedCart struct {
+	ID              uint                `json:"id" gorm:"primaryKey"`
+	CartID          uint                `json:"cart_id" gorm:"not null;index"`
+	UserID          uint                `json:"user_id" gorm:"not null;index"`
+	Email           string              `json:"email" gorm:"size:255;not null"`
+	Token           string              `json:"-" gorm:"size:64;uniqueIndex;not null"`
+	Status          AbandonedCartStatus `json:"status" gorm:"size:20;not null;default:'abandoned';index"`
+	ActivityAt      time.Time           `json:"activity_at" gorm:"not null"` // when the cart's items were last touched
+	RemindersSent   int                 `json:"reminders_sent" gorm:"not null;default:0"`
+	LastRemindedAt  *time.Time          `json:"last_reminded_at"`
+	UnsubscribedAt  *time.Time          `json:"unsubscribed_at"`
+	OrderID         *uint               `json:"order_id"`
+	RecoveredAt     *time.Time          `json:"recovered_at"`
+	RecoveredAmount Money               `json:"recovered_amount" gorm:"embedded;embeddedPrefix:recovered_"`
+	CreatedAt       time.Time           `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt       time.Time           `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for AbandonedCart
+func (AbandonedCart) TableName() string {
+	return "abandoned_carts"
+}
+
+// ReminderDue reports whether the next reminder of a sequence sent after the
+// given delays of inactivity is due. Each reminder also waits the gap between
+// its delay and the previous one after the previous reminder, so carts left
+// longer than several delays are not sent the reminders all at once.
+func (a *AbandonedCart) ReminderDue(delays []time.Duration, now time.Time) bool {
+	if a.UnsubscribedAt != nil || a.Status != AbandonedCartStatusAbandoned {
+		return false
+	}
+	step := a.RemindersSent
+	if step >= len(delays) {
+		return false
+	}
+	if now.Sub(a.ActivityAt) < delays[step] {
+		return false
+	}
+	if step > 0 && a.LastRemindedAt != nil && now.Sub(*a.LastRemindedAt) < delays[step]-delays[step-1] {
+		return false
+	}
+	return true
+}
+
+// AbandonedCartStats summarizes how many abandoned carts were recovered
+type AbandonedCartStats struct {
+	Abandoned    int64
+	Recovered    int64
+	Unsubscribed int64
+	RecoveryRate float64 // share of the tracked carts that were recovered
+}
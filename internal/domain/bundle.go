@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"time"
+)
+
+// BundlePricing represents how the price of a bundle is set
+type BundlePricing string
+
+const (
+	// BundlePricingFixed sells the bundle at its own price
+	BundlePricingFixed BundlePricing = "fixed"
+	// BundlePricingPercentOff sells the bundle at the sum of its components
+	// less the bundle discount percentage
+	BundlePricingPercentOff BundlePricing = "percent_off"
+)
+
+// BundleComponent represents a quantity of a product included in a bundle
+type BundleComponent struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	BundleID  uint      `json:"bundle_id" gorm:"not null;uniqueIndex:idx_bundle_component"`
+	ProductID uint      `json:"product_id" gorm:"not null;uniqueIndex:idx_bundle_component;index"`
+	Product   Product   `json:"product" gorm:"foreignKey:ProductID"`
+	Quantity  int       `json:"quantity" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// PercentOffPrice computes the price of a bundle selling its components at
+// discount percent off their sum
+func PercentOffPrice(components []BundleComponent, discount float64) (Money, error) {
+	var sum Money
+	for _, component := range components {
+		var err error
+		if sum, err = sum.Add(component.Product.Price.Multiply(component.Quantity)); err != nil {
+			return Money{}, err
+		}
+	}
+	return sum.Discount(discount), nil
+}
+
+// TableName specifies the table name for BundleComponent
+func (BundleComponent) TableName() string {
+	return "bundle_components"
+}
@@ -0,0 +1,7 @@
+package domain
+
+// CacheStats represents the hit and miss counters of a cache namespace
+type CacheStats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+}
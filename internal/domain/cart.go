@@ -1,29 +1,153 @@
 package domain
 
 import (
+	"strings"
 	"time"
 )
 
-// Cart represents a user's shopping cart
+// Cart represents a user's shopping cart. The coupon code applied to it is
+// checked again whenever the cart is priced. UpdatedAt moves whenever its items
+// change, so carts left untouched can be found.
 type Cart struct {
-	ID        uint       `json:"id" gorm:"primaryKey"`
-	UserID    uint       `json:"user_id" gorm:"not null"`
-	Items     []CartItem `json:"items" gorm:"foreignKey:CartID"`
-	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"user_id" gorm:"not null"`
+	Items      []CartItem `json:"items" gorm:"foreignKey:CartID"`
+	CouponCode string     `json:"coupon_code" gorm:"size:64"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt  time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
 }
 
-// CartItem represents an item in a user's shopping cart
+// CartSummary breaks down the price of a cart in the currency the customer
+// shops in, explaining the promotions applied to it. Tax is estimated for the
+// destination of the request, if any. A coupon that no longer applies is
+// reported instead of failing.
+type CartSummary struct {
+	Subtotal    Money
+	Discounts   []OrderDiscount
+	Promotions  []AppliedPromotion
+	Discount    Money
+	Taxes       []TaxLine
+	Tax         Money
+	TaxMode     TaxMode
+	Total       Money
+	CouponCode  string
+	CouponError string
+	Warnings    []CartWarning
+}
+
+// CartItem represents an item in a user's shopping cart. Price is the base
+// price of the product when it was added, or when the customer last accepted
+// a change to it. Items added before prices were recorded have none.
 type CartItem struct {
 	ID        uint      `json:"id" gorm:"primaryKey"`
 	CartID    uint      `json:"cart_id" gorm:"not null"`
 	ProductID uint      `json:"product_id" gorm:"not null"`
 	Product   Product   `json:"product" gorm:"foreignKey:ProductID"`
 	Quantity  int       `json:"quantity" gorm:"not null"`
+	Price     Money     `json:"price" gorm:"embedded;embeddedPrefix:price_"`
 	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
 }
 
+// PriceChanged reports whether the product's price differs from the price the
+// item was added at
+func (i *CartItem) PriceChanged() bool {
+	if !i.Price.IsPositive() {
+		return false
+	}
+	return i.Price.Amount != i.Product.Price.Amount || i.Price.Currency != i.Product.Price.Currency
+}
+
+// CartWarningType represents what changed about a cart item since it was added
+type CartWarningType string
+
+const (
+	// CartWarningPriceIncreased is an item whose product costs more than when added
+	CartWarningPriceIncreased CartWarningType = "price_increased"
+	// CartWarningPriceDecreased is an item whose product costs less than when added
+	CartWarningPriceDecreased CartWarningType = "price_decreased"
+	// CartWarningInsufficientStock is an item asking for more than can be ordered
+	CartWarningInsufficientStock CartWarningType = "insufficient_stock"
+	// CartWarningProductArchived is an item whose product is no longer sold
+	CartWarningProductArchived CartWarningType = "product_archived"
+)
+
+// CartWarning describes a change to a cart item since it was added. Prices
+// are in the currency the customer shops in. Stock warnings tell how many
+// units were asked for and how many can be ordered.
+type CartWarning struct {
+	Type          CartWarningType `json:"type"`
+	CartItemID    uint            `json:"cart_item_id"`
+	ProductID     uint            `json:"product_id"`
+	ProductName   string          `json:"product_name"`
+	PreviousPrice Money           `json:"previous_price"`
+	CurrentPrice  Money           `json:"current_price"`
+	Requested     int             `json:"requested,omitempty"`
+	Available     int             `json:"available,omitempty"`
+}
+
+// IsPriceChange reports whether the warning is about a changed price, which
+// the customer can accept
+func (w CartWarning) IsPriceChange() bool {
+	return w.Type == CartWarningPriceIncreased || w.Type == CartWarningPriceDecreased
+}
+
+// ArchivedWarning returns the warning for an item whose product was archived
+func ArchivedWarning(item *CartItem) CartWarning {
+	return CartWarning{
+		Type:        CartWarningProductArchived,
+		CartItemID:  item.ID,
+		ProductID:   item.ProductID,
+		ProductName: item.Product.Name,
+	}
+}
+
+// PriceWarning returns the warning for an item whose price changed, with the
+// prices converted for the customer
+func PriceWarning(item *CartItem, conversion Conversion) CartWarning {
+	warning := CartWarning{
+		Type:          CartWarningPriceDecreased,
+		CartItemID:    item.ID,
+		ProductID:     item.ProductID,
+		ProductName:   item.Product.Name,
+		PreviousPrice: conversion.Convert(item.Price),
+		CurrentPrice:  conversion.Convert(item.Product.Price),
+	}
+	if item.Product.Price.Amount > item.Price.Amount {
+		warning.Type = CartWarningPriceIncreased
+	}
+	return warning
+}
+
+// StockWarning returns the warning for an item that ran short of stock. The
+// shortage of a bundle is that of its component.
+func StockWarning(item *CartItem, shortage StockShortage) CartWarning {
+	return CartWarning{
+		Type:        CartWarningInsufficientStock,
+		CartItemID:  item.ID,
+		ProductID:   item.ProductID,
+		ProductName: item.Product.Name,
+		Requested:   shortage.Requested,
+		Available:   shortage.Available,
+	}
+}
+
+// CartChangedError is returned when a cart cannot be ordered as it is, because
+// products were archived or ran short of stock, or prices changed that the
+// customer has not accepted. It lists every change, not just the first.
+type CartChangedError struct {
+	Warnings []CartWarning
+}
+
+// Error lists the products that changed
+func (e *CartChangedError) Error() string {
+	names := make([]string, 0, len(e.Warnings))
+	for _, warning := range e.Warnings {
+		names = append(names, warning.ProductName)
+	}
+	return "cart changed since items were added: " + strings.Join(names, ", ")
+}
+
 // TableName specifies the table name for Cart
 func (Cart) TableName() string {
 	return "carts"
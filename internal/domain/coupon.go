@@ -0,0 +1,242 @@
+package domain
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// CouponType represents how a coupon takes value off a cart
+type CouponType string
+
+const (
+	// CouponTypePercent takes a percentage off the eligible lines
+	CouponTypePercent CouponType = "percent"
+	// CouponTypeFixed takes a fixed amount off the eligible lines, spread
+	// across them in proportion to their totals
+	CouponTypeFixed CouponType = "fixed"
+)
+
+// DiscountSource represents what granted a discount
+type DiscountSource string
+
+const (
+	DiscountSourceCoupon    DiscountSource = "coupon"
+	DiscountSourcePromotion DiscountSource = "promotion"
+	// DiscountSourceTaxExemption takes the tax prices include off them for
+	// tax-exempt customers
+	DiscountSourceTaxExemption DiscountSource = "tax_exemption"
+)
+
+// Coupon represents a promo code customers apply to their cart. Fixed
+// amounts and the minimum subtotal are set in the base currency. Without
+// eligible products or categories every line is eligible, and zero limits
+// are unlimited. Its used count is read with it, counting the orders that
+// redeemed it and were not cancelled.
+type Coupon struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	Code         string     `json:"code" gorm:"size:64;uniqueIndex;not null"`
+	Description  string     `json:"description" gorm:"size:255"`
+	Type         CouponType `json:"type" gorm:"size:20;not null"`
+	PercentOff   float64    `json:"percent_off" gorm:"type:decimal(5,2);not null;default:0"`
+	AmountOff    Money      `json:"amount_off" gorm:"embedded;embeddedPrefix:amount_off_"`
+	MinSubtotal  Money      `json:"min_subtotal" gorm:"embedded;embeddedPrefix:min_subtotal_"`
+	ProductIDs   []uint     `json:"product_ids" gorm:"serializer:json;type:text"`
+	CategoryIDs  []uint     `json:"category_ids" gorm:"serializer:json;type:text"`
+	StartsAt     *time.Time `json:"starts_at"`
+	EndsAt       *time.Time `json:"ends_at"`
+	UsageLimit   int        `json:"usage_limit" gorm:"not null;default:0"`
+	PerUserLimit int        `json:"per_user_limit" gorm:"not null;default:0"`
+	UsedCount    int        `json:"used_count" gorm:"->;-:migration"`
+	Active       bool       `json:"active" gorm:"not null;default:true"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// CouponRedemption records a coupon used by an order, counting towards the
+// coupon's usage limits unless the order is cancelled
+type CouponRedemption struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CouponID  uint      `json:"coupon_id" gorm:"not null;index:idx_coupon_redemption_user"`
+	UserID    uint      `json:"user_id" gorm:"not null;index:idx_coupon_redemption_user"`
+	OrderID   uint      `json:"order_id" gorm:"not null;uniqueIndex"`
+	Discount  Money     `json:"discount" gorm:"embedded;embeddedPrefix:discount_"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// OrderDiscount is an amount taken off a line of a cart or order, recording
+// what granted it
+type OrderDiscount struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	OrderID   uint           `json:"order_id" gorm:"not null;index"`
+	ProductID uint           `json:"product_id" gorm:"not null"`
+	Source    DiscountSource `json:"source" gorm:"size:20;not null"`
+	SourceID  uint           `json:"source_id" gorm:"not null"`
+	Label     string         `json:"label" gorm:"size:255"`
+	Amount    Money          `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+	CreatedAt time.Time      `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// PricedLine is a line of a cart or order at its price in the currency the
+// customer shops in, as discounts see it
+type PricedLine struct {
+	ProductID  uint
+	CategoryID uint
+	TaxClass   string
+	Quantity   int
+	Total      Money
+}
+
+// CouponError is returned when a coupon cannot be applied to a cart
+type CouponError struct {
+	Code   string
+	Reason string
+}
+
+// Error explains why the coupon cannot be applied
+func (e *CouponError) Error() string {
+	return e.Reason
+}
+
+// NormalizeCouponCode writes a code the way coupons store it, so that codes
+// match whatever case customers type them in
+func NormalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// CheckValidity reports why the coupon cannot be used at a time, if it cannot
+func (c *Coupon) CheckValidity(now time.Time) error {
+	switch {
+	case !c.Active:
+		return &CouponError{Code: c.Code, Reason: "coupon is not active"}
+	case c.StartsAt != nil && now.Before(*c.StartsAt):
+		return &CouponError{Code: c.Code, Reason: "coupon is not valid yet"}
+	case c.EndsAt != nil && !now.Before(*c.EndsAt):
+		return &CouponError{Code: c.Code, Reason: "coupon has expired"}
+	case c.UsageLimit > 0 && c.UsedCount >= c.UsageLimit:
+		return &CouponError{Code: c.Code, Reason: "coupon usage limit reached"}
+	}
+	return nil
+}
+
+// IsEligible reports whether the coupon discounts a line
+func (c *Coupon) IsEligible(line PricedLine) bool {
+	return lineMatches(c.ProductIDs, c.CategoryIDs, line)
+}
+
+// lineMatches reports whether a line is one of the products or in one of the
+// categories given, any line matching when none are given
+func lineMatches(productIDs, categoryIDs []uint, line PricedLine) bool {
+	if len(productIDs) == 0 && len(categoryIDs) == 0 {
+		return true
+	}
+	return slices.Contains(productIDs, line.ProductID) || slices.Contains(categoryIDs, line.CategoryID)
+}
+
+// Discounts computes the discount lines of the coupon on priced lines. The
+// minimum subtotal applies to the whole cart and amounts in the base currency
+// are converted first. A line is never discounted below zero.
+func (c *Coupon) Discounts(lines []PricedLine, conversion Conversion) ([]OrderDiscount, error) {
+	var subtotal, eligibleTotal Money
+	var eligible []PricedLine
+	for _, line := range lines {
+		var err error
+		if subtotal, err = subtotal.Add(line.Total); err != nil {
+			return nil, err
+		}
+		if c.IsEligible(line) && line.Total.IsPositive() {
+			eligible = append(eligible, line)
+			if eligibleTotal, err = eligibleTotal.Add(line.Total); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if c.MinSubtotal.IsPositive() && subtotal.Amount < conversion.Convert(c.MinSubtotal).Amount {
+		return nil, &CouponError{Code: c.Code, Reason: "cart subtotal is below the coupon minimum"}
+	}
+	if len(eligible) == 0 {
+		return nil, &CouponError{Code: c.Code, Reason: "no items in the cart are eligible for the coupon"}
+	}
+
+	amounts := make([]Money, len(eligible))
+	switch c.Type {
+	case CouponTypePercent:
+		for i, line := range eligible {
+			amounts[i] = line.Total.Percent(c.PercentOff)
+		}
+	case CouponTypeFixed:
+		// Spread the amount in proportion to the lines, the last line taking
+		// what rounding leaves so the lines add up to the coupon's value
+		value := min(conversion.Convert(c.AmountOff).Amount, eligibleTotal.Amount)
+		remaining := value
+		for i, line := range eligible {
+			share := remaining
+			if i < len(eligible)-1 {
+				share = line.Total.Amount * value / eligibleTotal.Amount
+			}
+			amounts[i] = Money{Amount: share, Currency: line.Total.Currency}
+			remaining -= share
+		}
+	}
+
+	discounts := make([]OrderDiscount, 0, len(eligible))
+	for i, line := range eligible {
+		if !amounts[i].IsPositive() {
+			continue
+		}
+		discounts = append(discounts, OrderDiscount{
+			ProductID: line.ProductID,
+			Source:    DiscountSourceCoupon,
+			SourceID:  c.ID,
+			Label:     c.Code,
+			Amount:    amounts[i],
+		})
+	}
+	return discounts, nil
+}
+
+// DiscountTotal adds up discount lines
+func DiscountTotal(discounts []OrderDiscount) (Money, error) {
+	var total Money
+	for _, discount := range discounts {
+		var err error
+		if total, err = total.Add(discount.Amount); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
+
+// NetLines returns the lines less the discounts taken off them, as the next
+// discounts see them
+func NetLines(lines []PricedLine, discounts []OrderDiscount) ([]PricedLine, error) {
+	net := slices.Clone(lines)
+	for _, discount := range discounts {
+		for i := range net {
+			if net[i].ProductID == discount.ProductID && net[i].Total.IsPositive() {
+				var err error
+				if net[i].Total, err = net[i].Total.Subtract(discount.Amount); err != nil {
+					return nil, err
+				}
+				break
+			}
+		}
+	}
+	return net, nil
+}
+
+// TableName specifies the table name for Coupon
+func (Coupon) TableName() string {
+	return "coupons"
+}
+
+// TableName specifies the table name for CouponRedemption
+func (CouponRedemption) TableName() string {
+	return "coupon_redemptions"
+}
+
+// TableName specifies the table name for OrderDiscount
+func (OrderDiscount) TableName() string {
+	return "order_discounts"
+}
@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"math"
+	"time"
+)
+
+// ExchangeRate represents how many units of a currency one unit of the
+// store's base currency buys
+type ExchangeRate struct {
+	Currency  string    `json:"currency" gorm:"primaryKey;size:3"`
+	Rate      float64   `json:"rate" gorm:"type:decimal(18,8);not null"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// zeroDecimalCurrencies are the currencies without minor units
+var zeroDecimalCurrencies = map[string]bool{
+	"BIF": true, "CLP": true, "DJF": true, "GNF": true, "ISK": true,
+	"JPY": true, "KMF": true, "KRW": true, "PYG": true, "RWF": true,
+	"UGX": true, "VND": true, "VUV": true, "XAF": true, "XOF": true,
+	"XPF": true,
+}
+
+// CurrencyDecimals returns the number of decimals of a currency, which its
+// minor units are worth
+func CurrencyDecimals(currency string) int {
+	if zeroDecimalCurrencies[currency] {
+		return 0
+	}
+	return 2
+}
+
+// Conversion converts amounts in the base currency to the currency a
+// customer shops in
+type Conversion struct {
+	Currency string  `json:"currency"`
+	Rate     float64 `json:"exchange_rate"`
+}
+
+// Convert converts an amount in the base currency, rounded half away from
+// zero to the minor units of the target currency. A conversion without a
+// currency leaves amounts as they are.
+func (c Conversion) Convert(amount Money) Money {
+	if c.Currency == "" || (c.Currency == amount.Currency && c.Rate == 1) {
+		return amount
+	}
+	scale := math.Pow10(CurrencyDecimals(c.Currency) - CurrencyDecimals(amount.Currency))
+	return Money{
+		Amount:   int64(math.Round(float64(amount.Amount) * c.Rate * scale)),
+		Currency: c.Currency,
+	}
+}
+
+// LineTotal converts a unit price and multiplies it by a quantity, so that
+// totals add up to the lines shown to the customer
+func (c Conversion) LineTotal(price Money, quantity int) Money {
+	return c.Convert(price).Multiply(quantity)
+}
+
+// TableName specifies the table name for ExchangeRate
+func (ExchangeRate) TableName() string {
+	return "exchange_rates"
+}
@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"time"
+)
+
+// LicenseKey represents a key from the uploaded pool of a digital product.
+// Keys are assigned to order lines once the order is paid.
+type LicenseKey struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	ProductID   uint       `json:"product_id" gorm:"not null;index"`
+	Key         string     `json:"key" gorm:"size:255;uniqueIndex;not null"`
+	OrderID     *uint      `json:"order_id" gorm:"index"`
+	OrderItemID *uint      `json:"order_item_id" gorm:"index"`
+	AssignedAt  *time.Time `json:"assigned_at"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// DigitalDelivery describes what the customer received for a digital order line
+type DigitalDelivery struct {
+	OrderItemID    uint           `json:"order_item_id"`
+	ProductID      uint           `json:"product_id"`
+	ProductName    string         `json:"product_name"`
+	DeliveryMethod DeliveryMethod `json:"delivery_method"`
+	LicenseKeys    []string       `json:"license_keys,omitempty"`
+	DownloadURL    string         `json:"download_url,omitempty"`
+	ExpiresAt      *time.Time     `json:"expires_at,omitempty"`
+	GiftCardCodes  []string       `json:"gift_card_codes,omitempty"`
+	DeliveredAt    *time.Time     `json:"delivered_at"`
+}
+
+// TableName specifies the table name for LicenseKey
+func (LicenseKey) TableName() string {
+	return "license_keys"
+}
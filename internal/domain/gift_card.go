@@ -0,0 +1,120 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrInsufficientBalance is returned when a gift card or store credit account
+// holds less than is taken from it
+var ErrInsufficientBalance = errors.New("insufficient balance")
+
+// GiftCardSource represents how a gift card came to be
+type GiftCardSource string
+
+const (
+	// GiftCardSourceAdmin is a gift card issued by an administrator
+	GiftCardSourceAdmin GiftCardSource = "admin"
+	// GiftCardSourcePurchase is a gift card bought as a product
+	GiftCardSourcePurchase GiftCardSource = "purchase"
+)
+
+// GiftCard represents a code holding a balance that pays for orders in its
+// currency. Cards bought as products record the order line they were bought with.
+type GiftCard struct {
+	ID             uint           `json:"id" gorm:"primaryKey"`
+	Code           string         `json:"code" gorm:"size:32;uniqueIndex;not null"`
+	InitialValue   Money          `json:"initial_value" gorm:"embedded;embeddedPrefix:initial_"`
+	Balance        Money          `json:"balance" gorm:"embedded;embeddedPrefix:balance_"`
+	Source         GiftCardSource `json:"source" gorm:"size:20;not null;default:'admin'"`
+	OrderID        *uint          `json:"order_id" gorm:"index"`
+	OrderItemID    *uint          `json:"order_item_id" gorm:"index"`
+	RecipientEmail string         `json:"recipient_email" gorm:"size:255"`
+	ExpiresAt      *time.Time     `json:"expires_at"`
+	Active         bool           `json:"active" gorm:"not null;default:true"`
+	CreatedAt      time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for GiftCard
+func (GiftCard) TableName() string {
+	return "gift_cards"
+}
+
+// IsRedeemable reports whether the gift card can pay for orders at a time
+func (g *GiftCard) IsRedeemable(now time.Time) bool {
+	if !g.Active || !g.Balance.IsPositive() {
+		return false
+	}
+	return g.ExpiresAt == nil || now.Before(*g.ExpiresAt)
+}
+
+// NormalizeGiftCardCode returns a gift card code the way codes are stored,
+// so customers can type them in any case, with spaces or with dashes
+func NormalizeGiftCardCode(code string) string {
+	return strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
+
+// StoreCreditAccount represents the store credit a customer holds in a
+// currency, spent on orders in that currency
+type StoreCreditAccount struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_store_credit_accounts_user_currency"`
+	Currency  string    `json:"currency" gorm:"size:3;not null;uniqueIndex:idx_store_credit_accounts_user_currency"`
+	Balance   Money     `json:"balance" gorm:"embedded;embeddedPrefix:balance_"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for StoreCreditAccount
+func (StoreCreditAccount) TableName() string {
+	return "store_credit_accounts"
+}
+
+// BalanceReason represents why the balance of a gift card or store credit
+// account changed
+type BalanceReason string
+
+const (
+	// BalanceReasonIssue is the value a gift card was issued with
+	BalanceReasonIssue BalanceReason = "issue"
+	// BalanceReasonRedemption is balance spent on an order
+	BalanceReasonRedemption BalanceReason = "redemption"
+	// BalanceReasonReversal is balance returned by a payment that failed or was not made
+	BalanceReasonReversal BalanceReason = "reversal"
+	// BalanceReasonRefund is balance returned or credited by a refunded payment
+	BalanceReasonRefund BalanceReason = "refund"
+	// BalanceReasonAdjustment is balance changed by an administrator
+	BalanceReasonAdjustment BalanceReason = "adjustment"
+)
+
+// BalanceChange describes why, for what and by whom a balance changed. The
+// reference is the order of redemptions, reversals and refunds.
+type BalanceChange struct {
+	Reason      BalanceReason
+	ReferenceID uint
+	Actor       string
+	Note        string
+}
+
+// BalanceEntry represents a movement of the balance of a gift card or a store
+// credit account. Entries are only ever appended, in the transaction that
+// changed the balance.
+type BalanceEntry struct {
+	ID                   uint          `json:"id" gorm:"primaryKey"`
+	GiftCardID           *uint         `json:"gift_card_id" gorm:"index"`
+	StoreCreditAccountID *uint         `json:"store_credit_account_id" gorm:"index"`
+	Delta                Money         `json:"delta" gorm:"embedded;embeddedPrefix:delta_"`
+	Balance              Money         `json:"balance" gorm:"embedded;embeddedPrefix:balance_"`
+	Reason               BalanceReason `json:"reason" gorm:"size:20;not null"`
+	ReferenceID          uint          `json:"reference_id"`
+	Actor                string        `json:"actor" gorm:"size:100"`
+	Note                 string        `json:"note" gorm:"size:255"`
+	CreatedAt            time.Time     `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName specifies the table name for BalanceEntry
+func (BalanceEntry) TableName() string {
+	return "balance_ledger"
+}
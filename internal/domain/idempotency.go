@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrInvalidIdempotencyKey is returned for an idempotency key that is too long
+	ErrInvalidIdempotencyKey = errors.New("idempotency key must be at most 255 characters")
+	// ErrIdempotencyKeyReused is returned when an idempotency key is sent again
+	// with a different request
+	ErrIdempotencyKeyReused = errors.New("idempotency key was already used with a different request")
+	// ErrIdempotencyKeyInProgress is returned when an idempotency key is sent
+	// again before the first request made with it has finished
+	ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is still in progress")
+	// ErrIdempotencyKeyLockLost is returned when the lock a request took on an
+	// idempotency key expired before the request finished
+	ErrIdempotencyKeyLockLost = errors.New("the lock on the idempotency key expired before the request finished")
+)
+
+// IdempotentResponse represents the response to a request made with an
+// idempotency key, replayed to repeated requests
+type IdempotentResponse struct {
+	StatusCode  int    `json:"status_code"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
+
+// IdempotencyRecord represents the first request made with an idempotency key.
+// The fingerprint identifies the request, so the key cannot be reused for
+// another one. The token identifies the request holding the key, so that only
+// it stores its response or releases the key. The response is nil while the
+// request is in progress.
+type IdempotencyRecord struct {
+	Key         string              `json:"key"`
+	Fingerprint string              `json:"fingerprint"`
+	Token       string              `json:"token"`
+	Response    *IdempotentResponse `json:"response"`
+	CreatedAt   time.Time           `json:"created_at"`
+}
+
+// IsCompleted reports whether the first request made with the key has its response
+func (r *IdempotencyRecord) IsCompleted() bool {
+	return r.Response != nil
+}
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"time"
+)
+
+// AllocationStrategy represents the rule used to pick the warehouses fulfilling an order line
+type AllocationStrategy string
+
+const (
+	// AllocationStrategyNearest prefers warehouses whose region appears in the shipping address
+	AllocationStrategyNearest AllocationStrategy = "nearest"
+	// AllocationStrategyPriority prefers warehouses with the lowest priority value
+	AllocationStrategyPriority AllocationStrategy = "priority"
+	// AllocationStrategySplit draws from warehouses in priority order without
+	// trying to ship each line from a single location first
+	AllocationStrategySplit AllocationStrategy = "split"
+)
+
+// Warehouse represents a stock location
+type Warehouse struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Code      string    `json:"code" gorm:"size:50;uniqueIndex;not null"`
+	Name      string    `json:"name" gorm:"size:255;not null"`
+	Region    string    `json:"region" gorm:"size:100"`
+	Priority  int       `json:"priority" gorm:"not null;default:0"`
+	Active    bool      `json:"active" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// InventoryLevel represents the stock of a product at a warehouse
+type InventoryLevel struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	ProductID   uint      `json:"product_id" gorm:"not null;uniqueIndex:idx_inventory_level"`
+	WarehouseID uint      `json:"warehouse_id" gorm:"not null;uniqueIndex:idx_inventory_level"`
+	Warehouse   Warehouse `json:"warehouse" gorm:"foreignKey:WarehouseID"`
+	Quantity    int       `json:"quantity" gorm:"not null;default:0"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// InventoryTransfer represents stock moved from one warehouse to another
+type InventoryTransfer struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	ProductID       uint      `json:"product_id" gorm:"not null;index"`
+	FromWarehouseID uint      `json:"from_warehouse_id" gorm:"not null"`
+	ToWarehouseID   uint      `json:"to_warehouse_id" gorm:"not null"`
+	Quantity        int       `json:"quantity" gorm:"not null"`
+	CreatedAt       time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// OrderAllocation represents stock of an order line reserved at a warehouse
+type OrderAllocation struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	OrderID     uint      `json:"order_id" gorm:"not null;index"`
+	ProductID   uint      `json:"product_id" gorm:"not null"`
+	WarehouseID uint      `json:"warehouse_id" gorm:"not null"`
+	Quantity    int       `json:"quantity" gorm:"not null"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// ProductAvailability represents the stock of a product across all warehouses
+type ProductAvailability struct {
+	ProductID uint             `json:"product_id"`
+	Total     int              `json:"total"`
+	Locations []InventoryLevel `json:"locations"`
+}
+
+// TableName specifies the table name for Warehouse
+func (Warehouse) TableName() string {
+	return "warehouses"
+}
+
+// TableName specifies the table name for InventoryLevel
+func (InventoryLevel) TableName() string {
+	return "inventory_levels"
+}
+
+// TableName specifies the table name for InventoryTransfer
+func (InventoryTransfer) TableName() string {
+	return "inventory_transfers"
+}
+
+// TableName specifies the table name for OrderAllocation
+func (OrderAllocation) TableName() string {
+	return "order_allocations"
+}
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// LedgerReason represents why a product's stock changed
+type LedgerReason string
+
+const (
+	// LedgerReasonOrder is stock taken by an order
+	LedgerReasonOrder LedgerReason = "order"
+	// LedgerReasonCancellation is stock returned by a cancelled order
+	LedgerReasonCancellation LedgerReason = "cancellation"
+	// LedgerReasonRefund is stock returned by a refunded order
+	LedgerReasonRefund LedgerReason = "refund"
+	// LedgerReasonManualAdjustment is stock set by an administrator
+	LedgerReasonManualAdjustment LedgerReason = "manual_adjustment"
+	// LedgerReasonImport is stock loaded from an external catalog
+	LedgerReasonImport LedgerReason = "import"
+	// LedgerReasonStockUpdateEvent is stock changed by a product-stock-update event
+	LedgerReasonStockUpdateEvent LedgerReason = "stock_update_event"
+	// LedgerReasonLicenseKeyUpload is stock added by uploading license keys
+	LedgerReasonLicenseKeyUpload LedgerReason = "license_key_upload"
+)
+
+// StockChange describes why, for what and by whom a product's stock changed
+type StockChange struct {
+	Reason      LedgerReason
+	ReferenceID uint
+	Actor       string
+}
+
+// InventoryLedgerEntry represents a stock movement of a product. Entries are
+// only ever appended, in the transaction that changed the stock.
+type InventoryLedgerEntry struct {
+	ID          uint         `json:"id" gorm:"primaryKey"`
+	ProductID   uint         `json:"product_id" gorm:"not null;index"`
+	Delta       int          `json:"delta" gorm:"not null"`
+	Balance     int          `json:"balance" gorm:"not null"`
+	Reason      LedgerReason `json:"reason" gorm:"size:30;not null"`
+	ReferenceID uint         `json:"reference_id"`
+	Actor       string       `json:"actor" gorm:"size:100"`
+	CreatedAt   time.Time    `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// StockDiscrepancy represents a product whose stock differs from the stock
+// recomputed from its ledger
+type StockDiscrepancy struct {
+	ProductID   uint   `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Stock       int    `json:"stock"`
+	LedgerStock int    `json:"ledger_stock"`
+}
+
+// TableName specifies the table name for InventoryLedgerEntry
+func (InventoryLedgerEntry) TableName() string {
+	return "inventory_ledger"
+}
+
+// stockChangeKey is the context key of the StockChange carried by a context
+type stockChangeKey struct{}
+
+// WithStockChange returns a context carrying the stock change that writes
+// setting a product's stock outright, such as saving a product, record in the
+// ledger. Writes applying a delta take their StockChange as an argument.
+func WithStockChange(ctx context.Context, change StockChange) context.Context {
+	return context.WithValue(ctx, stockChangeKey{}, change)
+}
+
+// StockChangeFromContext returns the stock change carried by a context,
+// defaulting to a manual adjustment by the system
+func StockChangeFromContext(ctx context.Context) StockChange {
+	if change, ok := ctx.Value(stockChangeKey{}).(StockChange); ok {
+		return change
+	}
+	return StockChange{Reason: LedgerReasonManualAdjustment, Actor: "system"}
+}
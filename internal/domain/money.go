@@ -0,0 +1,138 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// ErrCurrencyMismatch is returned when amounts in different currencies are
+// added or subtracted
+var ErrCurrencyMismatch = errors.New("amounts are in different currencies")
+
+// Money is an exact amount of a currency, held in the currency's minor units
+// (cents, or whole yen for currencies without minor units) so that sums and
+// comparisons never round
+type Money struct {
+	Amount   int64  `json:"amount" gorm:"not null;default:0"`
+	Currency string `json:"currency" gorm:"size:3;not null;default:'USD'"`
+}
+
+// ParseMoney reads a decimal amount in major units, such as "19.99", into
+// money of a currency. Amounts with more decimals than the currency has are
+// rejected rather than rounded.
+func ParseMoney(amount, currency string) (Money, error) {
+	value, ok := new(big.Rat).SetString(strings.TrimSpace(amount))
+	if !ok {
+		return Money{}, errors.New("invalid amount")
+	}
+
+	value.Mul(value, new(big.Rat).SetInt64(MinorUnits(currency)))
+	if !value.IsInt() {
+		return Money{}, errors.New("amount has more decimals than its currency")
+	}
+	if !value.Num().IsInt64() {
+		return Money{}, errors.New("amount is too large")
+	}
+	return Money{Amount: value.Num().Int64(), Currency: currency}, nil
+}
+
+// MinorUnits returns how many minor units make a major unit of a currency
+func MinorUnits(currency string) int64 {
+	return int64(math.Pow10(CurrencyDecimals(currency)))
+}
+
+// Add returns the sum of two amounts in the same currency. An amount without
+// a currency, such as the zero Money a sum starts from, takes the other's.
+func (m Money) Add(other Money) (Money, error) {
+	currency, err := commonCurrency(m, other)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: currency}, nil
+}
+
+// Subtract returns the amount less another amount in the same currency
+func (m Money) Subtract(other Money) (Money, error) {
+	currency, err := commonCurrency(m, other)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount - other.Amount, Currency: currency}, nil
+}
+
+// commonCurrency returns the currency two amounts are in, failing with
+// ErrCurrencyMismatch when they are in different ones
+func commonCurrency(m, other Money) (string, error) {
+	switch {
+	case m.Currency == "":
+		return other.Currency, nil
+	case other.Currency == "" || other.Currency == m.Currency:
+		return m.Currency, nil
+	default:
+		return "", ErrCurrencyMismatch
+	}
+}
+
+// Multiply returns the amount times a quantity, as for an order line
+func (m Money) Multiply(quantity int) Money {
+	m.Amount *= int64(quantity)
+	return m
+}
+
+// Percent returns percent of the amount, rounded half away from zero to
+// minor units
+func (m Money) Percent(percent float64) Money {
+	m.Amount = int64(math.Round(float64(m.Amount) * percent / 100))
+	return m
+}
+
+// Discount returns the amount less percent of it
+func (m Money) Discount(percent float64) Money {
+	m.Amount -= m.Percent(percent).Amount
+	return m
+}
+
+// IsPositive reports whether the amount is greater than zero
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+// Decimal writes the amount in major units with the decimals of its
+// currency, as in "19.99" or "3024"
+func (m Money) Decimal() string {
+	decimals := CurrencyDecimals(m.Currency)
+	if decimals == 0 {
+		return strconv.FormatInt(m.Amount, 10)
+	}
+
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	units := MinorUnits(m.Currency)
+	return sign + strconv.FormatInt(amount/units, 10) + "." + leftPad(strconv.FormatInt(amount%units, 10), decimals)
+}
+
+// Number returns the amount in major units as a JSON number, written
+// exactly rather than through a float
+func (m Money) Number() json.Number {
+	return json.Number(m.Decimal())
+}
+
+// String writes the amount followed by its currency, as in "19.99 USD"
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// leftPad pads digits with leading zeros to width
+func leftPad(digits string, width int) string {
+	if len(digits) >= width {
+		return digits
+	}
+	return strings.Repeat("0", width-len(digits)) + digits
+}
@@ -8,37 +8,136 @@ import (
 type OrderStatus string
 
 const (
-	OrderStatusPending    OrderStatus = "pending"
-	OrderStatusProcessing OrderStatus = "processing"
-	OrderStatusShipped    OrderStatus = "shipped"
-	OrderStatusDelivered  OrderStatus = "delivered"
-	OrderStatusCancelled  OrderStatus = "cancelled"
+	OrderStatusPending          OrderStatus = "pending"
+	OrderStatusProcessing       OrderStatus = "processing"
+	OrderStatusOnHold           OrderStatus = "on_hold"
+	OrderStatusPartiallyShipped OrderStatus = "partially_shipped"
+	OrderStatusShipped          OrderStatus = "shipped"
+	OrderStatusDelivered        OrderStatus = "delivered"
+	OrderStatusReturned         OrderStatus = "returned"
+	OrderStatusRefunded         OrderStatus = "refunded"
+	OrderStatusCancelled        OrderStatus = "cancelled"
 )
 
-// Order represents a customer order
+// Order represents a customer order. Prices and totals are in the currency
+// the customer shopped in, converted from the base currency at the exchange
+// rate of checkout. Item names are snapshots in the order's locale. The total
+// is what is charged: the subtotal of the lines less the discount lines, plus
+// shipping and, unless prices include it, the tax lines.
 type Order struct {
-	ID              uint        `json:"id" gorm:"primaryKey"`
-	UserID          uint        `json:"user_id" gorm:"not null"`
-	Items           []OrderItem `json:"items" gorm:"foreignKey:OrderID"`
-	TotalAmount     float64     `json:"total_amount" gorm:"type:decimal(10,2);not null"`
-	Status          OrderStatus `json:"status" gorm:"type:varchar(20);not null;default:'pending'"`
-	ShippingAddress string      `json:"shipping_address" gorm:"type:text;not null"`
-	BillingAddress  string      `json:"billing_address" gorm:"type:text;not null"`
-	PaymentID       *uint       `json:"payment_id"`
-	CreatedAt       time.Time   `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt       time.Time   `json:"updated_at" gorm:"autoUpdateTime"`
-}
-
-// OrderItem represents an item in a customer order
+	ID               uint            `json:"id" gorm:"primaryKey"`
+	UserID           uint            `json:"user_id" gorm:"not null"`
+	Items            []OrderItem     `json:"items" gorm:"foreignKey:OrderID"`
+	Discounts        []OrderDiscount `json:"discounts" gorm:"foreignKey:OrderID"`
+	Taxes            []TaxLine       `json:"taxes" gorm:"foreignKey:OrderID"`
+	SubtotalAmount   Money           `json:"subtotal_amount" gorm:"embedded;embeddedPrefix:subtotal_"`
+	DiscountAmount   Money           `json:"discount_amount" gorm:"embedded;embeddedPrefix:discount_"`
+	TaxAmount        Money           `json:"tax_amount" gorm:"embedded;embeddedPrefix:tax_"`
+	ShippingAmount   Money           `json:"shipping_amount" gorm:"embedded;embeddedPrefix:shipping_"`
+	TotalAmount      Money           `json:"total_amount" gorm:"embedded;embeddedPrefix:total_"`
+	TaxMode          TaxMode         `json:"tax_mode" gorm:"size:20;not null;default:'exclusive'"`
+	TaxExempt        bool            `json:"tax_exempt" gorm:"not null;default:false"`
+	Destination      Destination     `json:"destination" gorm:"embedded;embeddedPrefix:shipping_"`
+	ShippingMethodID *uint           `json:"shipping_method_id"`
+	ShippingMethod   string          `json:"shipping_method" gorm:"size:100"`
+	ShippingCarrier  string          `json:"shipping_carrier" gorm:"size:50"`
+	CouponID         *uint           `json:"coupon_id" gorm:"index"`
+	CouponCode       string          `json:"coupon_code" gorm:"size:64"`
+	ExchangeRate     float64         `json:"exchange_rate" gorm:"type:decimal(18,8);not null;default:1"`
+	Locale           string          `json:"locale" gorm:"size:35"`
+	Status           OrderStatus     `json:"status" gorm:"type:varchar(20);not null;default:'pending'"`
+	ShippingAddress  string          `json:"shipping_address" gorm:"type:text;not null"`
+	BillingAddress   string          `json:"billing_address" gorm:"type:text;not null"`
+	PaymentID        *uint           `json:"payment_id"`
+	CreatedAt        time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt        time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// OrderItem represents an item in a customer order. Units ordered beyond
+// the stock at checkout are backordered and allocated as stock arrives.
+// A bundle line is followed by a line per component, priced at zero, which
+// takes the component's units from stock.
 type OrderItem struct {
-	ID          uint      `json:"id" gorm:"primaryKey"`
-	OrderID     uint      `json:"order_id" gorm:"not null"`
-	ProductID   uint      `json:"product_id" gorm:"not null"`
-	ProductName string    `json:"product_name" gorm:"size:255;not null"`
-	Price       float64   `json:"price" gorm:"type:decimal(10,2);not null"`
-	Quantity    int       `json:"quantity" gorm:"not null"`
-	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	ID                  uint           `json:"id" gorm:"primaryKey"`
+	OrderID             uint           `json:"order_id" gorm:"not null"`
+	ProductID           uint           `json:"product_id" gorm:"not null"`
+	ProductName         string         `json:"product_name" gorm:"size:255;not null"`
+	Price               Money          `json:"price" gorm:"embedded;embeddedPrefix:price_"`
+	Quantity            int            `json:"quantity" gorm:"not null"`
+	BackorderedQuantity int            `json:"backordered_quantity" gorm:"not null;default:0"`
+	BackorderAllocated  int            `json:"backorder_allocated" gorm:"not null;default:0"`
+	ExpectedAt          *time.Time     `json:"expected_at"`
+	ProductType         ProductType    `json:"product_type" gorm:"size:20;not null;default:'physical'"`
+	DeliveryMethod      DeliveryMethod `json:"delivery_method" gorm:"size:20"`
+	DeliveredAt         *time.Time     `json:"delivered_at"`
+	ParentItemID        *uint          `json:"parent_item_id" gorm:"index"`
+	BundleQuantity      int            `json:"bundle_quantity" gorm:"not null;default:0"`
+	CreatedAt           time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt           time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// BackorderAllocation represents incoming stock handed to a backordered order line
+type BackorderAllocation struct {
+	OrderID     uint `json:"order_id"`
+	OrderItemID uint `json:"order_item_id"`
+	ProductID   uint `json:"product_id"`
+	Quantity    int  `json:"quantity"`
+	Remaining   int  `json:"remaining"`
+}
+
+// IsDigital reports whether the line is delivered online instead of shipped
+func (i OrderItem) IsDigital() bool {
+	return i.ProductType == ProductTypeDigital
+}
+
+// IsDelivered reports whether the digital line has been handed to the customer
+func (i OrderItem) IsDelivered() bool {
+	return i.DeliveredAt != nil
+}
+
+// RequiresShipping reports whether any line of the order has to be shipped
+func (o *Order) RequiresShipping() bool {
+	for _, item := range o.Items {
+		if !item.IsDigital() {
+			return true
+		}
+	}
+	return false
+}
+
+// IsBackordered reports whether part of the line was ordered beyond the stock at checkout
+func (i OrderItem) IsBackordered() bool {
+	return i.BackorderedQuantity > 0
+}
+
+// IsBundle reports whether the line is a bundle, whose component lines follow it
+func (i OrderItem) IsBundle() bool {
+	return i.ProductType == ProductTypeBundle
+}
+
+// IsBundleComponent reports whether the line is a component of a bundle line
+func (i OrderItem) IsBundleComponent() bool {
+	return i.BundleQuantity > 0
+}
+
+// InStockQuantity returns the units of the line taken from stock at checkout.
+// Downloads and gift cards are not taken from stock, and bundles are taken
+// through their component lines.
+func (i OrderItem) InStockQuantity() int {
+	if i.IsBundle() || (i.IsDigital() && i.DeliveryMethod != DeliveryMethodLicenseKey) {
+		return 0
+	}
+	return i.Quantity - i.BackorderedQuantity
+}
+
+// OutstandingBackorder returns the backordered units still waiting for stock
+func (i OrderItem) OutstandingBackorder() int {
+	return i.BackorderedQuantity - i.BackorderAllocated
+}
+
+// TakenFromStock returns the units of the line currently taken from stock
+func (i OrderItem) TakenFromStock() int {
+	return i.InStockQuantity() + i.BackorderAllocated
 }
 
 // TableName specifies the table name for Order
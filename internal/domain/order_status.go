@@ -0,0 +1,126 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrOrderStatusChanged is returned when an order moved to another status
+// since its transition was checked
+var ErrOrderStatusChanged = errors.New("order status changed, try again")
+
+// OrderStatuses lists every status an order can be in
+var OrderStatuses = []OrderStatus{
+	OrderStatusPending,
+	OrderStatusProcessing,
+	OrderStatusOnHold,
+	OrderStatusPartiallyShipped,
+	OrderStatusShipped,
+	OrderStatusDelivered,
+	OrderStatusReturned,
+	OrderStatusRefunded,
+	OrderStatusCancelled,
+}
+
+// OrderStatusChange describes why and by whom an order's status changed
+type OrderStatusChange struct {
+	Reason string
+	Actor  string
+}
+
+// OrderStatusHistory represents a status an order moved to. Entries are only
+// ever appended, in the transaction that changed the status; the first entry
+// of an order, from no status, records it being placed.
+type OrderStatusHistory struct {
+	ID         uint        `json:"id" gorm:"primaryKey"`
+	OrderID    uint        `json:"order_id" gorm:"not null;index"`
+	FromStatus OrderStatus `json:"from_status" gorm:"type:varchar(20)"`
+	ToStatus   OrderStatus `json:"to_status" gorm:"type:varchar(20);not null"`
+	Actor      string      `json:"actor" gorm:"size:100"`
+	Reason     string      `json:"reason" gorm:"size:255"`
+	CreatedAt  time.Time   `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName specifies the table name for OrderStatusHistory
+func (OrderStatusHistory) TableName() string {
+	return "order_status_history"
+}
+
+// OrderTransitions maps each status to the statuses an order in it can move to
+type OrderTransitions map[OrderStatus][]OrderStatus
+
+// DefaultOrderTransitions returns the transitions of the order lifecycle
+// used unless configured otherwise
+func DefaultOrderTransitions() OrderTransitions {
+	return OrderTransitions{
+		OrderStatusPending:          {OrderStatusProcessing, OrderStatusOnHold, OrderStatusCancelled},
+		OrderStatusProcessing:       {OrderStatusPartiallyShipped, OrderStatusShipped, OrderStatusDelivered, OrderStatusOnHold, OrderStatusRefunded, OrderStatusCancelled},
+		OrderStatusOnHold:           {OrderStatusPending, OrderStatusProcessing, OrderStatusCancelled},
+		OrderStatusPartiallyShipped: {OrderStatusShipped},
+		OrderStatusShipped:          {OrderStatusDelivered, OrderStatusReturned},
+		OrderStatusDelivered:        {OrderStatusReturned, OrderStatusRefunded},
+		OrderStatusReturned:         {OrderStatusRefunded},
+		OrderStatusRefunded:         {},
+		OrderStatusCancelled:        {OrderStatusRefunded},
+	}
+}
+
+// Allows reports whether an order can move from one status to another
+func (t OrderTransitions) Allows(from, to OrderStatus) bool {
+	for _, next := range t[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseOrderTransitions parses transitions written as semicolon separated
+// "from:to,to" entries, such as "pending:processing,cancelled;processing:shipped".
+// Statuses missing from the left of every entry are final.
+func ParseOrderTransitions(spec string) (OrderTransitions, error) {
+	transitions := OrderTransitions{}
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		from, targets, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid order transition %q", entry)
+		}
+		fromStatus, err := parseOrderStatus(from)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := transitions[fromStatus]; !ok {
+			transitions[fromStatus] = []OrderStatus{}
+		}
+		for _, target := range strings.Split(targets, ",") {
+			if strings.TrimSpace(target) == "" {
+				continue
+			}
+			toStatus, err := parseOrderStatus(target)
+			if err != nil {
+				return nil, err
+			}
+			transitions[fromStatus] = append(transitions[fromStatus], toStatus)
+		}
+	}
+	return transitions, nil
+}
+
+// parseOrderStatus returns the known order status named by s
+func parseOrderStatus(s string) (OrderStatus, error) {
+	status := OrderStatus(strings.ToLower(strings.TrimSpace(s)))
+	for _, known := range OrderStatuses {
+		if status == known {
+			return status, nil
+		}
+	}
+	return "", fmt.Errorf("unknown order status %q", strings.TrimSpace(s))
+}
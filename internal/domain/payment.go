@@ -22,23 +22,82 @@ const (
 	PaymentMethodDebitCard    PaymentMethod = "debit_card"
 	PaymentMethodPayPal       PaymentMethod = "paypal"
 	PaymentMethodBankTransfer PaymentMethod = "bank_transfer"
+	// PaymentMethodGiftCard pays from the balance of gift cards
+	PaymentMethodGiftCard PaymentMethod = "gift_card"
+	// PaymentMethodStoreCredit pays from the customer's store credit
+	PaymentMethodStoreCredit PaymentMethod = "store_credit"
 )
 
-// Payment represents a payment transaction
+// IsBalance reports whether the method pays from a balance held in the store
+// rather than through a payment provider
+func (m PaymentMethod) IsBalance() bool {
+	return m == PaymentMethodGiftCard || m == PaymentMethodStoreCredit
+}
+
+// Payment represents a payment transaction. Part of the amount may be taken
+// from gift cards and store credit as tenders, the rest being paid by Method.
 type Payment struct {
-	ID            uint          `json:"id" gorm:"primaryKey"`
-	OrderID       uint          `json:"order_id" gorm:"not null"`
-	Amount        float64       `json:"amount" gorm:"type:decimal(10,2);not null"`
-	Currency      string        `json:"currency" gorm:"size:3;not null;default:'USD'"`
-	Method        PaymentMethod `json:"method" gorm:"type:varchar(20);not null"`
-	Status        PaymentStatus `json:"status" gorm:"type:varchar(20);not null;default:'pending'"`
-	TransactionID string        `json:"transaction_id" gorm:"size:100"`
-	PaymentDate   *time.Time    `json:"payment_date"`
-	CreatedAt     time.Time     `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt     time.Time     `json:"updated_at" gorm:"autoUpdateTime"`
+	ID            uint            `json:"id" gorm:"primaryKey"`
+	OrderID       uint            `json:"order_id" gorm:"not null;uniqueIndex"`
+	Amount        Money           `json:"amount" gorm:"embedded"`
+	ExchangeRate  float64         `json:"exchange_rate" gorm:"type:decimal(18,8);not null;default:1"`
+	Method        PaymentMethod   `json:"method" gorm:"type:varchar(20);not null"`
+	Status        PaymentStatus   `json:"status" gorm:"type:varchar(20);not null;default:'pending'"`
+	TransactionID string          `json:"transaction_id" gorm:"size:100"`
+	PaymentDate   *time.Time      `json:"payment_date"`
+	Tenders       []PaymentTender `json:"tenders" gorm:"foreignKey:PaymentID"`
+	CreatedAt     time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// PaymentTender represents the part of a payment taken from a gift card or
+// from the customer's store credit
+type PaymentTender struct {
+	ID                   uint          `json:"id" gorm:"primaryKey"`
+	PaymentID            uint          `json:"payment_id" gorm:"not null;index"`
+	Method               PaymentMethod `json:"method" gorm:"type:varchar(20);not null"`
+	GiftCardID           *uint         `json:"gift_card_id" gorm:"index"`
+	StoreCreditAccountID *uint         `json:"store_credit_account_id"`
+	Amount               Money         `json:"amount" gorm:"embedded"`
+	CreatedAt            time.Time     `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TenderRequest asks for part of a payment to be taken from a gift card, by
+// its code, or from the customer's store credit. A zero amount takes as much
+// as the balance covers.
+type TenderRequest struct {
+	Method       PaymentMethod
+	GiftCardCode string
+	Amount       Money
 }
 
 // TableName specifies the table name for Payment
 func (Payment) TableName() string {
 	return "payments"
 }
+
+// TableName specifies the table name for PaymentTender
+func (PaymentTender) TableName() string {
+	return "payment_tenders"
+}
+
+// TenderTotal returns the part of the payment taken from gift cards and store credit
+func (p *Payment) TenderTotal() (Money, error) {
+	total := Money{Currency: p.Amount.Currency}
+	for _, tender := range p.Tenders {
+		var err error
+		if total, err = total.Add(tender.Amount); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
+
+// Remainder returns the part of the payment paid by its method
+func (p *Payment) Remainder() (Money, error) {
+	tenders, err := p.TenderTotal()
+	if err != nil {
+		return Money{}, err
+	}
+	return p.Amount.Subtract(tenders)
+}
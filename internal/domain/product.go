@@ -1,30 +1,154 @@
 package domain
 
 import (
+	"strings"
 	"time"
 )
 
-// Product represents a product in the e-commerce system
+// BackorderPolicy represents whether a product can be ordered beyond its stock
+type BackorderPolicy string
+
+const (
+	BackorderPolicyDeny      BackorderPolicy = "deny"
+	BackorderPolicyLimited   BackorderPolicy = "limited"
+	BackorderPolicyUnlimited BackorderPolicy = "unlimited"
+)
+
+// ProductType represents whether a product is shipped or delivered online
+type ProductType string
+
+const (
+	ProductTypePhysical ProductType = "physical"
+	ProductTypeDigital  ProductType = "digital"
+	// ProductTypeBundle is a kit of component products, sold from their stock
+	ProductTypeBundle ProductType = "bundle"
+)
+
+// DeliveryMethod represents how a digital product is delivered once paid
+type DeliveryMethod string
+
+const (
+	// DeliveryMethodLicenseKey hands out keys from the product's uploaded pool,
+	// so the pool size is the product's stock
+	DeliveryMethodLicenseKey DeliveryMethod = "license_key"
+	// DeliveryMethodDownload hands out time-limited links to the product's file
+	DeliveryMethodDownload DeliveryMethod = "download"
+	// DeliveryMethodGiftCard issues a gift card worth the price paid for each unit
+	DeliveryMethodGiftCard DeliveryMethod = "gift_card"
+)
+
+// Product represents a product in the e-commerce system. Shipped products
+// weigh Weight grams and are packed Length by Width by Height centimetres.
+// Archived products are no longer sold but stay on the orders and carts that
+// hold them.
 type Product struct {
-	ID          uint      `json:"id" gorm:"primaryKey"`
-	Name        string    `json:"name" gorm:"size:255;not null"`
-	Description string    `json:"description" gorm:"type:text"`
-	Price       float64   `json:"price" gorm:"type:decimal(10,2);not null"`
-	Stock       int       `json:"stock" gorm:"not null"`
-	SKU         string    `json:"sku" gorm:"size:50;uniqueIndex;not null"`
-	ImageURL    string    `json:"image_url" gorm:"size:255"`
-	CategoryID  uint      `json:"category_id"`
-	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
-}
-
-// ProductCategory represents a category for products
+	ID              uint            `json:"id" gorm:"primaryKey"`
+	Name            string          `json:"name" gorm:"size:255;not null"`
+	Description     string          `json:"description" gorm:"type:text"`
+	Price           Money           `json:"price" gorm:"embedded;embeddedPrefix:price_"`
+	Stock           int             `json:"stock" gorm:"not null"`
+	SKU             string          `json:"sku" gorm:"size:50;uniqueIndex;not null"`
+	ImageURL        string          `json:"image_url" gorm:"size:255"`
+	CategoryID      uint            `json:"category_id"`
+	BackorderPolicy BackorderPolicy `json:"backorder_policy" gorm:"size:20;not null;default:'deny'"`
+	BackorderLimit  int             `json:"backorder_limit" gorm:"not null;default:0"`
+	PreOrder        bool            `json:"pre_order" gorm:"not null;default:false"`
+	AvailableAt     *time.Time      `json:"available_at"`
+	ReorderPoint    *int            `json:"reorder_point"`
+	ReorderQuantity *int            `json:"reorder_quantity"`
+	Type            ProductType     `json:"type" gorm:"size:20;not null;default:'physical'"`
+	DeliveryMethod  DeliveryMethod  `json:"delivery_method" gorm:"size:20"`
+	DownloadFile    string          `json:"-" gorm:"size:255"`
+	BundlePricing   BundlePricing   `json:"bundle_pricing" gorm:"size:20"`
+	BundleDiscount  float64         `json:"bundle_discount" gorm:"type:decimal(5,2);not null;default:0"`
+	TaxClass        string          `json:"tax_class" gorm:"size:50;not null;default:'standard'"`
+	Weight          int             `json:"weight" gorm:"not null;default:0"`
+	Length          float64         `json:"length" gorm:"type:decimal(8,2);not null;default:0"`
+	Width           float64         `json:"width" gorm:"type:decimal(8,2);not null;default:0"`
+	Height          float64         `json:"height" gorm:"type:decimal(8,2);not null;default:0"`
+	Archived        bool            `json:"archived" gorm:"not null;default:false"`
+	CreatedAt       time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt       time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// ProductCategory represents a category for products. Its reorder settings
+// apply to the products and subcategories that don't set their own.
 type ProductCategory struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	Name      string    `json:"name" gorm:"size:100;not null"`
-	ParentID  *uint     `json:"parent_id" gorm:"default:null"`
-	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	Name            string    `json:"name" gorm:"size:100;not null"`
+	ParentID        *uint     `json:"parent_id" gorm:"default:null"`
+	ReorderPoint    *int      `json:"reorder_point"`
+	ReorderQuantity *int      `json:"reorder_quantity"`
+	CreatedAt       time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt       time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// StockLine is a quantity of a product taken from stock
+type StockLine struct {
+	ProductID uint
+	Quantity  int
+}
+
+// StockShortage describes a line asking for more of a product than is in stock
+type StockShortage struct {
+	ProductID   uint   `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Requested   int    `json:"requested"`
+	Available   int    `json:"available"`
+}
+
+// InsufficientStockError is returned when one or more lines cannot be taken
+// from stock. It lists every failing line, not just the first.
+type InsufficientStockError struct {
+	Shortages []StockShortage
+}
+
+// Error lists the products that are short of stock
+func (e *InsufficientStockError) Error() string {
+	names := make([]string, 0, len(e.Shortages))
+	for _, shortage := range e.Shortages {
+		names = append(names, shortage.ProductName)
+	}
+	return "insufficient stock for product: " + strings.Join(names, ", ")
+}
+
+// CanBackorder reports whether quantity more units of the product can be
+// ordered beyond its stock, on top of the outstanding backorders
+func (p *Product) CanBackorder(outstanding, quantity int) bool {
+	switch p.BackorderPolicy {
+	case BackorderPolicyUnlimited:
+		return true
+	case BackorderPolicyLimited:
+		return outstanding+quantity <= p.BackorderLimit
+	default:
+		return false
+	}
+}
+
+// Volume returns the volume, in cubic centimetres, of a unit of the product
+// as packed
+func (p *Product) Volume() float64 {
+	return p.Length * p.Width * p.Height
+}
+
+// IsDigital reports whether the product is delivered online instead of shipped
+func (p *Product) IsDigital() bool {
+	return p.Type == ProductTypeDigital
+}
+
+// IsBundle reports whether the product is a kit of component products
+func (p *Product) IsBundle() bool {
+	return p.Type == ProductTypeBundle
+}
+
+// TracksStock reports whether orders take the product from stock. Downloads
+// and gift cards can be sold any number of times, and bundles are taken from
+// the stock of their components.
+func (p *Product) TracksStock() bool {
+	if p.IsBundle() {
+		return false
+	}
+	return !p.IsDigital() || p.DeliveryMethod == DeliveryMethodLicenseKey
 }
 
 // TableName specifies the table name for Product
@@ -0,0 +1,254 @@
+package domain
+
+import (
+	"slices"
+	"sort"
+	"time"
+)
+
+// PromotionType represents the rule a promotion discounts carts by
+type PromotionType string
+
+const (
+	// PromotionTypeBuyXGetY takes percent off, all of it by default, the
+	// cheapest get quantity units of every buy plus get quantity eligible units
+	PromotionTypeBuyXGetY PromotionType = "buy_x_get_y"
+	// PromotionTypeSpendTiers takes the percent of the highest tier the
+	// eligible lines spend enough for off them
+	PromotionTypeSpendTiers PromotionType = "spend_tiers"
+	// PromotionTypeCategoryPercent takes a percentage off the lines of its
+	// categories
+	PromotionTypeCategoryPercent PromotionType = "category_percent"
+	// PromotionTypeCheapestFree gives the cheapest eligible unit away once the
+	// cart holds the minimum quantity of eligible units
+	PromotionTypeCheapestFree PromotionType = "cheapest_free"
+)
+
+// PromotionTier is a spend threshold, in the base currency, and the
+// percentage it takes off
+type PromotionTier struct {
+	MinSubtotal Money   `json:"min_subtotal"`
+	PercentOff  float64 `json:"percent_off"`
+}
+
+// Promotion represents a discount applied automatically to the carts its
+// rule matches. Promotions are applied by priority, highest first, each to
+// the lines as the promotions before it left them. An exclusive promotion
+// only applies when no promotion before it did, and stops the promotions
+// after it. Without eligible products or categories every line is eligible.
+type Promotion struct {
+	ID          uint            `json:"id" gorm:"primaryKey"`
+	Name        string          `json:"name" gorm:"size:255;not null"`
+	Description string          `json:"description" gorm:"size:255"`
+	Type        PromotionType   `json:"type" gorm:"size:20;not null"`
+	Priority    int             `json:"priority" gorm:"not null;default:0;index"`
+	Exclusive   bool            `json:"exclusive" gorm:"not null;default:false"`
+	ProductIDs  []uint          `json:"product_ids" gorm:"serializer:json;type:text"`
+	CategoryIDs []uint          `json:"category_ids" gorm:"serializer:json;type:text"`
+	PercentOff  float64         `json:"percent_off" gorm:"type:decimal(5,2);not null;default:0"`
+	BuyQuantity int             `json:"buy_quantity" gorm:"not null;default:0"`
+	GetQuantity int             `json:"get_quantity" gorm:"not null;default:0"`
+	MinQuantity int             `json:"min_quantity" gorm:"not null;default:0"`
+	Tiers       []PromotionTier `json:"tiers" gorm:"serializer:json;type:text"`
+	StartsAt    *time.Time      `json:"starts_at"`
+	EndsAt      *time.Time      `json:"ends_at"`
+	Active      bool            `json:"active" gorm:"not null;default:true"`
+	CreatedAt   time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// AppliedPromotion explains a promotion applied to a cart: the lines it
+// discounted and how much it took off them
+type AppliedPromotion struct {
+	PromotionID uint
+	Name        string
+	ProductIDs  []uint
+	Amount      Money
+}
+
+// IsLive reports whether the promotion applies at a time
+func (p *Promotion) IsLive(now time.Time) bool {
+	return p.Active &&
+		(p.StartsAt == nil || !now.Before(*p.StartsAt)) &&
+		(p.EndsAt == nil || now.Before(*p.EndsAt))
+}
+
+// IsEligible reports whether the promotion discounts a line
+func (p *Promotion) IsEligible(line PricedLine) bool {
+	return lineMatches(p.ProductIDs, p.CategoryIDs, line)
+}
+
+// Discounts computes the discount lines of the promotion on priced lines,
+// none when its rule does not match. Thresholds in the base currency are
+// converted first.
+func (p *Promotion) Discounts(lines []PricedLine, conversion Conversion) ([]OrderDiscount, error) {
+	var eligible []PricedLine
+	var eligibleTotal Money
+	units := 0
+	for _, line := range lines {
+		if p.IsEligible(line) && line.Total.IsPositive() && line.Quantity > 0 {
+			eligible = append(eligible, line)
+			var err error
+			if eligibleTotal, err = eligibleTotal.Add(line.Total); err != nil {
+				return nil, err
+			}
+			units += line.Quantity
+		}
+	}
+	if len(eligible) == 0 {
+		return nil, nil
+	}
+
+	var amounts []Money
+	switch p.Type {
+	case PromotionTypeCategoryPercent:
+		amounts = percentOff(eligible, p.PercentOff)
+	case PromotionTypeSpendTiers:
+		// Take the highest tier the eligible lines reach
+		var reached *PromotionTier
+		for i, tier := range p.Tiers {
+			threshold := conversion.Convert(tier.MinSubtotal)
+			if eligibleTotal.Amount >= threshold.Amount && (reached == nil || tier.MinSubtotal.Amount > reached.MinSubtotal.Amount) {
+				reached = &p.Tiers[i]
+			}
+		}
+		if reached == nil {
+			return nil, nil
+		}
+		amounts = percentOff(eligible, reached.PercentOff)
+	case PromotionTypeBuyXGetY:
+		if p.BuyQuantity <= 0 || p.GetQuantity <= 0 {
+			return nil, nil
+		}
+		free := units / (p.BuyQuantity + p.GetQuantity) * p.GetQuantity
+		amounts = cheapestUnitsOff(eligible, free, p.PercentOff)
+	case PromotionTypeCheapestFree:
+		if units < p.MinQuantity {
+			return nil, nil
+		}
+		amounts = cheapestUnitsOff(eligible, 1, 100)
+	}
+
+	var discounts []OrderDiscount
+	for i, line := range eligible {
+		if i >= len(amounts) || !amounts[i].IsPositive() {
+			continue
+		}
+		discounts = append(discounts, OrderDiscount{
+			ProductID: line.ProductID,
+			Source:    DiscountSourcePromotion,
+			SourceID:  p.ID,
+			Label:     p.Name,
+			Amount:    amounts[i],
+		})
+	}
+	return discounts, nil
+}
+
+// percentOff returns percent of every line
+func percentOff(lines []PricedLine, percent float64) []Money {
+	amounts := make([]Money, len(lines))
+	for i, line := range lines {
+		amounts[i] = line.Total.Percent(percent)
+	}
+	return amounts
+}
+
+// cheapestUnitsOff returns percent of the count cheapest units of the lines,
+// by line. A unit is priced at its share of its line, rounded down, so a line
+// is never discounted below zero.
+func cheapestUnitsOff(lines []PricedLine, count int, percent float64) []Money {
+	order := make([]int, len(lines))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return unitAmount(lines[order[a]]) < unitAmount(lines[order[b]])
+	})
+
+	amounts := make([]Money, len(lines))
+	for _, i := range order {
+		if count <= 0 {
+			break
+		}
+		units := min(count, lines[i].Quantity)
+		unit := Money{Amount: unitAmount(lines[i]), Currency: lines[i].Total.Currency}
+		amounts[i] = unit.Multiply(units).Percent(percent)
+		count -= units
+	}
+	return amounts
+}
+
+// unitAmount returns the price of a unit of a line, rounded down
+func unitAmount(line PricedLine) int64 {
+	return line.Total.Amount / int64(line.Quantity)
+}
+
+// ApplyPromotions computes the discount lines of promotions on priced lines,
+// applying them by priority, highest first and oldest first among equals.
+// Each promotion sees the lines less the discounts before it. An exclusive
+// promotion is skipped once another promotion applied, and no promotion
+// applies after it.
+func ApplyPromotions(promotions []Promotion, lines []PricedLine, conversion Conversion) ([]OrderDiscount, error) {
+	ordered := slices.Clone(promotions)
+	sort.SliceStable(ordered, func(a, b int) bool {
+		if ordered[a].Priority != ordered[b].Priority {
+			return ordered[a].Priority > ordered[b].Priority
+		}
+		return ordered[a].ID < ordered[b].ID
+	})
+
+	var applied []OrderDiscount
+	for i := range ordered {
+		promotion := &ordered[i]
+		if promotion.Exclusive && len(applied) > 0 {
+			continue
+		}
+
+		discounts, err := promotion.Discounts(lines, conversion)
+		if err != nil {
+			return nil, err
+		}
+		if len(discounts) == 0 {
+			continue
+		}
+		applied = append(applied, discounts...)
+		if lines, err = NetLines(lines, discounts); err != nil {
+			return nil, err
+		}
+
+		if promotion.Exclusive {
+			break
+		}
+	}
+	return applied, nil
+}
+
+// AppliedPromotions explains the promotions among discount lines, in the
+// order they applied
+func AppliedPromotions(discounts []OrderDiscount) ([]AppliedPromotion, error) {
+	var applied []AppliedPromotion
+	index := make(map[uint]int)
+	for _, discount := range discounts {
+		if discount.Source != DiscountSourcePromotion {
+			continue
+		}
+		i, ok := index[discount.SourceID]
+		if !ok {
+			i = len(applied)
+			index[discount.SourceID] = i
+			applied = append(applied, AppliedPromotion{PromotionID: discount.SourceID, Name: discount.Label})
+		}
+		applied[i].ProductIDs = append(applied[i].ProductIDs, discount.ProductID)
+		var err error
+		if applied[i].Amount, err = applied[i].Amount.Add(discount.Amount); err != nil {
+			return nil, err
+		}
+	}
+	return applied, nil
+}
+
+// TableName specifies the table name for Promotion
+func (Promotion) TableName() string {
+	return "promotions"
+}
@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"time"
+)
+
+// RecommendationType represents the signal a product recommendation is derived from
+type RecommendationType string
+
+const (
+	RecommendationTypeBoughtTogether RecommendationType = "bought_together"
+	RecommendationTypeSameCategory   RecommendationType = "same_category"
+)
+
+// ProductRecommendation represents a scored relation between two products
+type ProductRecommendation struct {
+	ID               uint               `json:"id" gorm:"primaryKey"`
+	ProductID        uint               `json:"product_id" gorm:"not null;uniqueIndex:idx_product_recommendation"`
+	RelatedProductID uint               `json:"related_product_id" gorm:"not null;uniqueIndex:idx_product_recommendation"`
+	RelatedProduct   Product            `json:"related_product" gorm:"foreignKey:RelatedProductID"`
+	Type             RecommendationType `json:"type" gorm:"type:varchar(20);not null;uniqueIndex:idx_product_recommendation"`
+	Score            float64            `json:"score" gorm:"not null;default:0"`
+	CreatedAt        time.Time          `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt        time.Time          `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for ProductRecommendation
+func (ProductRecommendation) TableName() string {
+	return "product_recommendations"
+}
@@ -0,0 +1,66 @@
+package domain
+
+import "context"
+
+// userIDKey is the context key of the ID of the user a request is made by
+type userIDKey struct{}
+
+// WithUserID returns a context carrying the ID of the authenticated user a
+// request is made by
+func WithUserID(ctx context.Context, userID uint) context.Context {
+	return context.WithValue(ctx, userIDKey{}, userID)
+}
+
+// UserIDFromContext returns the ID of the authenticated user carried by a
+// context, if any
+func UserIDFromContext(ctx context.Context) (uint, bool) {
+	userID, ok := ctx.Value(userIDKey{}).(uint)
+	return userID, ok
+}
+
+// currencyKey is the context key of the currency a request wants prices in
+type currencyKey struct{}
+
+// WithCurrency returns a context carrying the currency a request wants prices in
+func WithCurrency(ctx context.Context, currency string) context.Context {
+	return context.WithValue(ctx, currencyKey{}, currency)
+}
+
+// CurrencyFromContext returns the currency carried by a context, or an empty
+// string when the request asked for none
+func CurrencyFromContext(ctx context.Context) string {
+	currency, _ := ctx.Value(currencyKey{}).(string)
+	return currency
+}
+
+// localesKey is the context key of the locales a request wants content in
+type localesKey struct{}
+
+// WithLocales returns a context carrying the locales a request wants content
+// in, most preferred first
+func WithLocales(ctx context.Context, locales []string) context.Context {
+	return context.WithValue(ctx, localesKey{}, locales)
+}
+
+// LocalesFromContext returns the locales carried by a context, most preferred
+// first
+func LocalesFromContext(ctx context.Context) []string {
+	locales, _ := ctx.Value(localesKey{}).([]string)
+	return locales
+}
+
+// destinationKey is the context key of the destination a request delivers to
+type destinationKey struct{}
+
+// WithDestination returns a context carrying the destination a request
+// delivers to, which its taxes and shipping follow
+func WithDestination(ctx context.Context, destination Destination) context.Context {
+	return context.WithValue(ctx, destinationKey{}, destination)
+}
+
+// DestinationFromContext returns the destination carried by a context, as far
+// as the request gave one
+func DestinationFromContext(ctx context.Context) Destination {
+	destination, _ := ctx.Value(destinationKey{}).(Destination)
+	return destination
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"time"
+)
+
+// ReservationStatus represents the status of a stock reservation
+type ReservationStatus string
+
+const (
+	// ReservationStatusActive holds stock for an unpaid order until the reservation expires
+	ReservationStatusActive ReservationStatus = "active"
+	// ReservationStatusConverted means the order was paid and the stock decremented
+	ReservationStatusConverted ReservationStatus = "converted"
+	// ReservationStatusReleased means the order was cancelled and the stock put back on sale
+	ReservationStatusReleased ReservationStatus = "released"
+	// ReservationStatusExpired means the order was not paid in time
+	ReservationStatusExpired ReservationStatus = "expired"
+)
+
+// StockReservation represents stock held for an order line during checkout
+type StockReservation struct {
+	ID        uint              `json:"id" gorm:"primaryKey"`
+	ProductID uint              `json:"product_id" gorm:"not null;index:idx_reservation_product_status"`
+	OrderID   uint              `json:"order_id" gorm:"not null;index"`
+	Quantity  int               `json:"quantity" gorm:"not null"`
+	Status    ReservationStatus `json:"status" gorm:"size:20;not null;index:idx_reservation_product_status"`
+	ExpiresAt time.Time         `json:"expires_at" gorm:"not null;index"`
+	CreatedAt time.Time         `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time         `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// IsExpiredAt reports whether an active reservation stopped holding its stock
+// by a time, so other checkouts may have reserved it since
+func (r StockReservation) IsExpiredAt(now time.Time) bool {
+	return r.Status == ReservationStatusActive && !r.ExpiresAt.After(now)
+}
+
+// TableName specifies the table name for StockReservation
+func (StockReservation) TableName() string {
+	return "stock_reservations"
+}
@@ -0,0 +1,135 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ShipmentStatus represents where a shipment is on its way to the customer
+type ShipmentStatus string
+
+const (
+	// ShipmentStatusShipped is a shipment handed to its carrier
+	ShipmentStatusShipped ShipmentStatus = "shipped"
+	// ShipmentStatusInTransit is a shipment the carrier is moving
+	ShipmentStatusInTransit ShipmentStatus = "in_transit"
+	// ShipmentStatusOutForDelivery is a shipment on its last leg to the customer
+	ShipmentStatusOutForDelivery ShipmentStatus = "out_for_delivery"
+	// ShipmentStatusDelivered is a shipment the customer received
+	ShipmentStatusDelivered ShipmentStatus = "delivered"
+	// ShipmentStatusFailed is a shipment the carrier could not deliver
+	ShipmentStatusFailed ShipmentStatus = "failed"
+	// ShipmentStatusReturned is a shipment sent back to the store
+	ShipmentStatusReturned ShipmentStatus = "returned"
+)
+
+var (
+	// ErrUnknownCarrier is returned for tracking updates of a carrier without an adapter
+	ErrUnknownCarrier = errors.New("unknown carrier")
+	// ErrInvalidWebhookSignature is returned for tracking updates the carrier did not sign
+	ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+	// ErrInvalidTrackingPayload is returned for tracking updates the adapter cannot read
+	ErrInvalidTrackingPayload = errors.New("invalid tracking payload")
+)
+
+// Shipment represents a parcel of an order sent with a carrier. An order can
+// be split into several shipments, each carrying some units of its lines.
+type Shipment struct {
+	ID             uint           `json:"id" gorm:"primaryKey"`
+	OrderID        uint           `json:"order_id" gorm:"not null;index"`
+	Carrier        string         `json:"carrier" gorm:"size:50;not null;index:idx_shipment_tracking"`
+	TrackingNumber string         `json:"tracking_number" gorm:"size:100;not null;index:idx_shipment_tracking"`
+	Status         ShipmentStatus `json:"status" gorm:"type:varchar(20);not null;default:'shipped'"`
+	StatusDetail   string         `json:"status_detail" gorm:"size:255"`
+	Items          []ShipmentItem `json:"items" gorm:"foreignKey:ShipmentID"`
+	ShippedAt      time.Time      `json:"shipped_at"`
+	TrackedAt      *time.Time     `json:"tracked_at"`
+	DeliveredAt    *time.Time     `json:"delivered_at"`
+	CreatedAt      time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// ShipmentItem represents the units of an order line a shipment carries
+type ShipmentItem struct {
+	ID          uint `json:"id" gorm:"primaryKey"`
+	ShipmentID  uint `json:"shipment_id" gorm:"not null;index"`
+	OrderItemID uint `json:"order_item_id" gorm:"not null;index"`
+	ProductID   uint `json:"product_id" gorm:"not null"`
+	Quantity    int  `json:"quantity" gorm:"not null"`
+}
+
+// TrackingUpdate is an event a carrier reports about a shipment
+type TrackingUpdate struct {
+	TrackingNumber string
+	Status         ShipmentStatus
+	Detail         string
+	OccurredAt     time.Time
+}
+
+// IsShippable reports whether the line is sent in a shipment. Digital lines
+// are delivered online, and bundles are shipped through their component lines.
+func (i OrderItem) IsShippable() bool {
+	return !i.IsDigital() && !i.IsBundle()
+}
+
+// ReadyToShip returns the units of the line that have stock to ship, which
+// excludes backordered units still waiting for it
+func (i OrderItem) ReadyToShip() int {
+	return i.Quantity - i.OutstandingBackorder()
+}
+
+// ShippedQuantities returns the units of each order line the shipments carry
+func ShippedQuantities(shipments []Shipment) map[uint]int {
+	shipped := make(map[uint]int)
+	for _, shipment := range shipments {
+		for _, item := range shipment.Items {
+			shipped[item.OrderItemID] += item.Quantity
+		}
+	}
+	return shipped
+}
+
+// ShipmentOrderStatus derives the status of an order from its shipments: an
+// order is partially shipped until its shipments carry every unit of its
+// shippable lines, then shipped until all of them are delivered, or returned
+// once all of them come back. It returns false for orders without shipments.
+func ShipmentOrderStatus(order *Order, shipments []Shipment) (OrderStatus, bool) {
+	if len(shipments) == 0 {
+		return "", false
+	}
+
+	shipped := ShippedQuantities(shipments)
+	for _, item := range order.Items {
+		if item.IsShippable() && shipped[item.ID] < item.Quantity {
+			return OrderStatusPartiallyShipped, true
+		}
+	}
+
+	delivered, returned := 0, 0
+	for _, shipment := range shipments {
+		switch shipment.Status {
+		case ShipmentStatusDelivered:
+			delivered++
+		case ShipmentStatusReturned:
+			returned++
+		}
+	}
+	switch {
+	case returned == len(shipments):
+		return OrderStatusReturned, true
+	case delivered+returned == len(shipments):
+		return OrderStatusDelivered, true
+	default:
+		return OrderStatusShipped, true
+	}
+}
+
+// TableName specifies the table name for Shipment
+func (Shipment) TableName() string {
+	return "shipments"
+}
+
+// TableName specifies the table name for ShipmentItem
+func (ShipmentItem) TableName() string {
+	return "shipment_items"
+}
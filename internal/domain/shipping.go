@@ -0,0 +1,206 @@
+package domain
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ShippingMethodType represents how a shipping method prices a parcel
+type ShippingMethodType string
+
+const (
+	// ShippingMethodFlatRate charges its rate whatever is shipped
+	ShippingMethodFlatRate ShippingMethodType = "flat_rate"
+	// ShippingMethodWeightBased charges its rate plus its rate per started
+	// kilogram of the parcel
+	ShippingMethodWeightBased ShippingMethodType = "weight_based"
+	// ShippingMethodFreeOver charges its rate unless the goods cost at least
+	// its threshold
+	ShippingMethodFreeOver ShippingMethodType = "free_over"
+	// ShippingMethodPickup is collected from the store for free
+	ShippingMethodPickup ShippingMethodType = "pickup"
+	// ShippingMethodCarrier is priced by the rate provider of its carrier
+	ShippingMethodCarrier ShippingMethodType = "carrier"
+)
+
+// volumetricDivisor is the cubic centimetres carriers bill as a kilogram
+const volumetricDivisor = 5000
+
+// ShippingZone groups destinations shipped to alike: countries, optionally
+// narrowed to some of their regions and to postal codes starting with some
+// prefixes
+type ShippingZone struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	Name           string    `json:"name" gorm:"size:100;not null"`
+	Countries      []string  `json:"countries" gorm:"serializer:json;type:text"`
+	Regions        []string  `json:"regions" gorm:"serializer:json;type:text"`
+	PostalPrefixes []string  `json:"postal_prefixes" gorm:"serializer:json;type:text"`
+	CreatedAt      time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// ShippingMethod is a way of shipping to the destinations of a zone, or to
+// every destination when it has none. Amounts are set in the base currency.
+type ShippingMethod struct {
+	ID            uint               `json:"id" gorm:"primaryKey"`
+	ZoneID        *uint              `json:"zone_id" gorm:"index"`
+	Name          string             `json:"name" gorm:"size:100;not null"`
+	Description   string             `json:"description" gorm:"size:255"`
+	Type          ShippingMethodType `json:"type" gorm:"size:20;not null"`
+	Rate          Money              `json:"rate" gorm:"embedded;embeddedPrefix:rate_"`
+	PerKg         Money              `json:"per_kg" gorm:"embedded;embeddedPrefix:per_kg_"`
+	FreeOver      Money              `json:"free_over" gorm:"embedded;embeddedPrefix:free_over_"`
+	Carrier       string             `json:"carrier" gorm:"size:50"`
+	Service       string             `json:"service" gorm:"size:50"`
+	PickupAddress string             `json:"pickup_address" gorm:"size:255"`
+	MinDays       int                `json:"min_days" gorm:"not null;default:0"`
+	MaxDays       int                `json:"max_days" gorm:"not null;default:0"`
+	SortOrder     int                `json:"sort_order" gorm:"not null;default:0"`
+	Active        bool               `json:"active" gorm:"not null;default:true"`
+	CreatedAt     time.Time          `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time          `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// Parcel is what a cart or order ships, as shipping rates see it: the weight
+// in grams and volume in cubic centimetres of its shipped units, and what
+// the customer pays for its goods after discounts
+type Parcel struct {
+	Units    int
+	Weight   int
+	Volume   float64
+	Subtotal Money
+}
+
+// ShippingOption is a shipping method offered for a parcel and what it costs,
+// in the currency the customer shops in
+type ShippingOption struct {
+	MethodID      uint               `json:"method_id"`
+	Name          string             `json:"name"`
+	Description   string             `json:"description"`
+	Type          ShippingMethodType `json:"type"`
+	Carrier       string             `json:"carrier"`
+	PickupAddress string             `json:"pickup_address"`
+	MinDays       int                `json:"min_days"`
+	MaxDays       int                `json:"max_days"`
+	Cost          Money              `json:"cost"`
+}
+
+// Add adds units of a product to the parcel. Digital products are not shipped.
+func (p Parcel) Add(product *Product, quantity int) Parcel {
+	if product.IsDigital() {
+		return p
+	}
+	p.Units += quantity
+	p.Weight += product.Weight * quantity
+	p.Volume += product.Volume() * float64(quantity)
+	return p
+}
+
+// IsEmpty reports whether nothing in the parcel has to be shipped
+func (p Parcel) IsEmpty() bool {
+	return p.Units == 0
+}
+
+// BillableWeight returns the weight, in grams, carriers bill the parcel at:
+// its weight or, for light and bulky parcels, its volumetric weight
+func (p Parcel) BillableWeight() int {
+	volumetric := int(math.Ceil(p.Volume * 1000 / volumetricDivisor))
+	return max(p.Weight, volumetric)
+}
+
+// StartedKilograms returns how many kilograms, each started one counted
+// whole, a weight in grams is charged as
+func StartedKilograms(weight int) int {
+	return (weight + 999) / 1000
+}
+
+// Matches reports whether the zone covers a destination
+func (z *ShippingZone) Matches(destination Destination) bool {
+	if !slices.Contains(z.Countries, destination.Country) {
+		return false
+	}
+	if len(z.Regions) > 0 && !slices.Contains(z.Regions, destination.Region) {
+		return false
+	}
+	if len(z.PostalPrefixes) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(z.PostalPrefixes, func(prefix string) bool {
+		return strings.HasPrefix(destination.PostalCode, prefix)
+	})
+}
+
+// specificity ranks how narrowly the zone is located: zones of postal codes
+// first, then zones of regions before zones of whole countries
+func (z *ShippingZone) specificity() int {
+	rank := 0
+	if len(z.PostalPrefixes) > 0 {
+		rank += 2
+	}
+	if len(z.Regions) > 0 {
+		rank++
+	}
+	return rank
+}
+
+// FindShippingZone returns the most narrowly located of the zones covering
+// a destination, nil when none does
+func FindShippingZone(zones []ShippingZone, destination Destination) *ShippingZone {
+	var found *ShippingZone
+	for i := range zones {
+		if zones[i].Matches(destination) && (found == nil || zones[i].specificity() > found.specificity()) {
+			found = &zones[i]
+		}
+	}
+	return found
+}
+
+// Cost returns what the method charges for a parcel, converted to the
+// currency the customer shops in. Carrier methods are priced by their
+// carrier instead.
+func (m *ShippingMethod) Cost(parcel Parcel, conversion Conversion) (Money, error) {
+	switch m.Type {
+	case ShippingMethodWeightBased:
+		cost, err := m.Rate.Add(m.PerKg.Multiply(StartedKilograms(parcel.Weight)))
+		if err != nil {
+			return Money{}, err
+		}
+		return conversion.Convert(cost), nil
+	case ShippingMethodFreeOver:
+		if parcel.Subtotal.Amount >= conversion.Convert(m.FreeOver).Amount {
+			return conversion.Convert(Money{Currency: m.Rate.Currency}), nil
+		}
+		return conversion.Convert(m.Rate), nil
+	case ShippingMethodPickup:
+		return conversion.Convert(Money{Currency: m.Rate.Currency}), nil
+	default:
+		return conversion.Convert(m.Rate), nil
+	}
+}
+
+// Option offers the method at a cost
+func (m *ShippingMethod) Option(cost Money) ShippingOption {
+	return ShippingOption{
+		MethodID:      m.ID,
+		Name:          m.Name,
+		Description:   m.Description,
+		Type:          m.Type,
+		Carrier:       m.Carrier,
+		PickupAddress: m.PickupAddress,
+		MinDays:       m.MinDays,
+		MaxDays:       m.MaxDays,
+		Cost:          cost,
+	}
+}
+
+// TableName specifies the table name for ShippingZone
+func (ShippingZone) TableName() string {
+	return "shipping_zones"
+}
+
+// TableName specifies the table name for ShippingMethod
+func (ShippingMethod) TableName() string {
+	return "shipping_methods"
+}
@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"time"
+)
+
+// StockAlertState represents whether a product is alerting for low stock
+type StockAlertState string
+
+const (
+	// StockAlertStateOK is a product stocked above its reorder point
+	StockAlertStateOK StockAlertState = "ok"
+	// StockAlertStateLow is a product at or below its reorder point that has been alerted for
+	StockAlertStateLow StockAlertState = "low"
+)
+
+// ReorderSettings represents when a product needs replenishing and how much to order
+type ReorderSettings struct {
+	ReorderPoint    int    `json:"reorder_point"`
+	ReorderQuantity int    `json:"reorder_quantity"`
+	Source          string `json:"source"` // "product", "category" or "default"
+}
+
+// StockAlert tracks the low-stock alert state of a product. A product alerts
+// once when its stock falls to its reorder point and not again until its
+// stock has recovered above it.
+type StockAlert struct {
+	ID              uint            `json:"id" gorm:"primaryKey"`
+	ProductID       uint            `json:"product_id" gorm:"not null;uniqueIndex"`
+	State           StockAlertState `json:"state" gorm:"size:20;not null;default:'ok'"`
+	Stock           int             `json:"stock" gorm:"not null"`
+	ReorderPoint    int             `json:"reorder_point" gorm:"not null"`
+	ReorderQuantity int             `json:"reorder_quantity" gorm:"not null"`
+	AlertedAt       *time.Time      `json:"alerted_at"`
+	RecoveredAt     *time.Time      `json:"recovered_at"`
+	CreatedAt       time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt       time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// LowStockReportLine represents a product alerting for low stock
+type LowStockReportLine struct {
+	ProductID       uint       `json:"product_id"`
+	ProductName     string     `json:"product_name"`
+	SKU             string     `json:"sku"`
+	Stock           int        `json:"stock"`
+	ReorderPoint    int        `json:"reorder_point"`
+	ReorderQuantity int        `json:"reorder_quantity"`
+	AlertedAt       *time.Time `json:"alerted_at"`
+}
+
+// Transition moves the alert to the state matching the product's stock and
+// reports whether an alert must be sent. Only a product going low from the
+// ok state alerts; staying low, however far the stock falls, does not.
+func (a *StockAlert) Transition(stock int, settings ReorderSettings, now time.Time) bool {
+	a.Stock = stock
+	a.ReorderPoint = settings.ReorderPoint
+	a.ReorderQuantity = settings.ReorderQuantity
+
+	low := stock <= settings.ReorderPoint
+	switch {
+	case low && a.State != StockAlertStateLow:
+		a.State = StockAlertStateLow
+		a.AlertedAt = &now
+		return true
+	case !low && a.State == StockAlertStateLow:
+		a.State = StockAlertStateOK
+		a.RecoveredAt = &now
+	case a.State == "":
+		a.State = StockAlertStateOK
+	}
+	return false
+}
+
+// TableName specifies the table name for StockAlert
+func (StockAlert) TableName() string {
+	return "stock_alerts"
+}
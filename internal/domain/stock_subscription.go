@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"time"
+)
+
+// StockSubscriptionStatus represents the status of a back-in-stock subscription
+type StockSubscriptionStatus string
+
+const (
+	// StockSubscriptionStatusPending is a subscription waiting for the product to come back in stock
+	StockSubscriptionStatusPending StockSubscriptionStatus = "pending"
+	// StockSubscriptionStatusNotified is a subscription whose subscriber was told the product is back
+	StockSubscriptionStatusNotified StockSubscriptionStatus = "notified"
+	// StockSubscriptionStatusExpired is a subscription that waited longer than its expiry
+	StockSubscriptionStatusExpired StockSubscriptionStatus = "expired"
+	// StockSubscriptionStatusCancelled is a subscription cancelled by its subscriber
+	StockSubscriptionStatusCancelled StockSubscriptionStatus = "cancelled"
+)
+
+// StockSubscription represents a customer, or a guest by email, waiting for
+// an out-of-stock product to come back in stock. Subscribers are notified in
+// the order they subscribed.
+type StockSubscription struct {
+	ID         uint                    `json:"id" gorm:"primaryKey"`
+	ProductID  uint                    `json:"product_id" gorm:"not null;index"`
+	UserID     *uint                   `json:"user_id" gorm:"index"`
+	Email      string                  `json:"email" gorm:"size:255;not null"`
+	Token      string                  `json:"-" gorm:"size:64;uniqueIndex;not null"`
+	Status     StockSubscriptionStatus `json:"status" gorm:"size:20;not null;default:'pending'"`
+	ExpiresAt  time.Time               `json:"expires_at" gorm:"not null"`
+	NotifiedAt *time.Time              `json:"notified_at"`
+	CreatedAt  time.Time               `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt  time.Time               `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for StockSubscription
+func (StockSubscription) TableName() string {
+	return "stock_subscriptions"
+}
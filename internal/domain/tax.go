@@ -0,0 +1,157 @@
+package domain
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// TaxMode represents whether catalog prices include tax
+type TaxMode string
+
+const (
+	// TaxModeExclusive adds tax on top of prices
+	TaxModeExclusive TaxMode = "exclusive"
+	// TaxModeInclusive holds tax within prices, so totals report it without
+	// adding it
+	TaxModeInclusive TaxMode = "inclusive"
+)
+
+// TaxClassStandard is the tax class of products that do not set one
+const TaxClassStandard = "standard"
+
+// Destination is where an order is delivered, as taxes see it
+type Destination struct {
+	Country    string `json:"country" gorm:"size:2"`
+	Region     string `json:"region" gorm:"size:64"`
+	PostalCode string `json:"postal_code" gorm:"size:16"`
+}
+
+// TaxRate is the rate, in percent, taxing a tax class at destinations of a
+// country, optionally narrowed to a region and to postal codes starting
+// with a prefix
+type TaxRate struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	Name         string    `json:"name" gorm:"size:100;not null"`
+	Country      string    `json:"country" gorm:"size:2;not null;uniqueIndex:idx_tax_rate_location"`
+	Region       string    `json:"region" gorm:"size:64;not null;default:'';uniqueIndex:idx_tax_rate_location"`
+	PostalPrefix string    `json:"postal_prefix" gorm:"size:16;not null;default:'';uniqueIndex:idx_tax_rate_location"`
+	TaxClass     string    `json:"tax_class" gorm:"size:50;not null;default:'standard';uniqueIndex:idx_tax_rate_location"`
+	Rate         float64   `json:"rate" gorm:"type:decimal(7,4);not null"`
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TaxLine is the tax on a line of a cart or order, recording the rate that
+// set it and the amount it was worked out on, which includes the tax when
+// prices do
+type TaxLine struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	OrderID   uint      `json:"order_id" gorm:"not null;index"`
+	ProductID uint      `json:"product_id" gorm:"not null"`
+	TaxRateID uint      `json:"tax_rate_id" gorm:"not null"`
+	Name      string    `json:"name" gorm:"size:100"`
+	TaxClass  string    `json:"tax_class" gorm:"size:50"`
+	Rate      float64   `json:"rate" gorm:"type:decimal(7,4);not null"`
+	Taxable   Money     `json:"taxable" gorm:"embedded;embeddedPrefix:taxable_"`
+	Amount    Money     `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// NormalizeDestination writes a destination the way tax rates store it:
+// country and region upper case, postal code upper case without spaces
+func NormalizeDestination(destination Destination) Destination {
+	return Destination{
+		Country:    strings.ToUpper(strings.TrimSpace(destination.Country)),
+		Region:     strings.ToUpper(strings.TrimSpace(destination.Region)),
+		PostalCode: strings.ToUpper(strings.ReplaceAll(destination.PostalCode, " ", "")),
+	}
+}
+
+// ParseTaxMode reads a tax mode, prices having tax added unless they are
+// said to include it
+func ParseTaxMode(mode string) TaxMode {
+	if TaxMode(strings.ToLower(strings.TrimSpace(mode))) == TaxModeInclusive {
+		return TaxModeInclusive
+	}
+	return TaxModeExclusive
+}
+
+// Matches reports whether the rate taxes a tax class at a destination
+func (r *TaxRate) Matches(destination Destination, taxClass string) bool {
+	return r.TaxClass == taxClass &&
+		r.Country == destination.Country &&
+		(r.Region == "" || r.Region == destination.Region) &&
+		strings.HasPrefix(destination.PostalCode, r.PostalPrefix)
+}
+
+// specificity ranks how narrowly the rate is located: longer postal prefixes
+// first, then rates of a region before rates of the whole country
+func (r *TaxRate) specificity() int {
+	rank := 2 * len(r.PostalPrefix)
+	if r.Region != "" {
+		rank++
+	}
+	return rank
+}
+
+// FindTaxRate returns the most narrowly located of the rates taxing a tax
+// class at a destination, nil when none does
+func FindTaxRate(rates []TaxRate, destination Destination, taxClass string) *TaxRate {
+	var found *TaxRate
+	for i := range rates {
+		if rates[i].Matches(destination, taxClass) && (found == nil || rates[i].specificity() > found.specificity()) {
+			found = &rates[i]
+		}
+	}
+	return found
+}
+
+// Tax returns the tax at rate percent on an amount: on top of it when tax
+// is added, within it when prices include tax. Tax is rounded half away from
+// zero to minor units.
+func (m TaxMode) Tax(amount Money, rate float64) Money {
+	if m == TaxModeInclusive {
+		net := int64(math.Round(float64(amount.Amount) * 100 / (100 + rate)))
+		return Money{Amount: amount.Amount - net, Currency: amount.Currency}
+	}
+	return amount.Percent(rate)
+}
+
+// Total returns what is charged for a subtotal less its discount, with its
+// shipping and, when prices do not include it, its tax
+func (m TaxMode) Total(subtotal, discount, tax, shipping Money) (Money, error) {
+	total, err := subtotal.Subtract(discount)
+	if err != nil {
+		return Money{}, err
+	}
+	if total, err = total.Add(shipping); err != nil {
+		return Money{}, err
+	}
+	if m != TaxModeInclusive {
+		return total.Add(tax)
+	}
+	return total, nil
+}
+
+// TaxTotal adds up tax lines
+func TaxTotal(taxes []TaxLine) (Money, error) {
+	var total Money
+	for _, tax := range taxes {
+		var err error
+		if total, err = total.Add(tax.Amount); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
+
+// TableName specifies the table name for TaxRate
+func (TaxRate) TableName() string {
+	return "tax_rates"
+}
+
+// TableName specifies the table name for TaxLine
+func (TaxLine) TableName() string {
+	return "order_taxes"
+}
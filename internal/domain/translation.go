@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// ProductTranslation represents the name and description of a product in a locale
+type ProductTranslation struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	ProductID   uint      `json:"product_id" gorm:"not null;uniqueIndex:idx_product_locale"`
+	Locale      string    `json:"locale" gorm:"size:35;not null;uniqueIndex:idx_product_locale"`
+	Name        string    `json:"name" gorm:"size:255;not null"`
+	Description string    `json:"description" gorm:"type:text"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// CategoryTranslation represents the name of a product category in a locale
+type CategoryTranslation struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	CategoryID uint      `json:"category_id" gorm:"not null;uniqueIndex:idx_category_locale"`
+	Locale     string    `json:"locale" gorm:"size:35;not null;uniqueIndex:idx_category_locale"`
+	Name       string    `json:"name" gorm:"size:100;not null"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt  time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// NormalizeLocale writes a locale tag in its canonical case, a lowercase
+// language followed by uppercase regions and title-case scripts, as in
+// "pt-BR" or "zh-Hant-TW". Underscores are read as hyphens.
+func NormalizeLocale(locale string) string {
+	parts := strings.Split(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"), "-")
+	for i, part := range parts {
+		switch {
+		case i == 0:
+			parts[i] = strings.ToLower(part)
+		case len(part) == 4:
+			parts[i] = strings.ToUpper(part[:1]) + strings.ToLower(part[1:])
+		default:
+			parts[i] = strings.ToUpper(part)
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// LocaleFallbacks returns the locales to look content up in, most preferred
+// first: each requested locale followed by its less specific parents, as
+// "fr-CA" falls back to "fr", and finally the default locale
+func LocaleFallbacks(requested []string, defaultLocale string) []string {
+	var chain []string
+	seen := make(map[string]bool)
+	add := func(locale string) {
+		if locale != "" && !seen[locale] {
+			seen[locale] = true
+			chain = append(chain, locale)
+		}
+	}
+
+	for _, locale := range requested {
+		locale = NormalizeLocale(locale)
+		for locale != "" {
+			add(locale)
+			cut := strings.LastIndex(locale, "-")
+			if cut < 0 {
+				break
+			}
+			locale = locale[:cut]
+		}
+	}
+	add(NormalizeLocale(defaultLocale))
+
+	return chain
+}
+
+// TableName specifies the table name for ProductTranslation
+func (ProductTranslation) TableName() string {
+	return "product_translations"
+}
+
+// TableName specifies the table name for CategoryTranslation
+func (CategoryTranslation) TableName() string {
+	return "category_translations"
+}
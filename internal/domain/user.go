@@ -14,6 +14,7 @@ type User struct {
 	Phone     string    `json:"phone" gorm:"size:20"`
 	Address   string    `json:"address" gorm:"type:text"`
 	Role      string    `json:"role" gorm:"size:20;default:'customer'"`
+	TaxExempt bool      `json:"tax_exempt" gorm:"not null;default:false"`
 	Cart      Cart      `json:"cart" gorm:"foreignKey:UserID"`
 	Orders    []Order   `json:"orders" gorm:"foreignKey:UserID"`
 	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
@@ -32,6 +32,9 @@ func NewKafkaProducer(cfg *config.Config) *KafkaProducer {
 		cfg.Kafka.Topics.OrderUpdated,
 		cfg.Kafka.Topics.ProductSync,
 		cfg.Kafka.Topics.PaymentStatus,
+		cfg.Kafka.Topics.LowStockAlert,
+		cfg.Kafka.Topics.BackInStock,
+		cfg.Kafka.Topics.DigitalDelivery,
 	}
 
 	for _, topic := range topics {
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"awesomeEcommerce/internal/domain"
 	"awesomeEcommerce/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -57,11 +58,25 @@ func AuthMiddleware(userService service.UserService) gin.HandlerFunc {
 		c.Set("userID", user.ID)
 		c.Set("email", user.Email)
 		c.Set("role", user.Role)
+		c.Request = c.Request.WithContext(domain.WithUserID(c.Request.Context(), user.ID))
 
 		c.Next()
 	}
 }
 
+// OptionalAuthMiddleware authenticates the request like AuthMiddleware when it
+// has an Authorization header and lets it through as a guest otherwise
+func OptionalAuthMiddleware(userService service.UserService) gin.HandlerFunc {
+	auth := AuthMiddleware(userService)
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+		auth(c)
+	}
+}
+
 // RoleMiddleware is a middleware that checks if the user has the required role
 func RoleMiddleware(roles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
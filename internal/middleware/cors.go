@@ -9,7 +9,8 @@ func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Currency, Accept-Language, Idempotency-Key")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "Idempotent-Replayed")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE, PATCH")
 
 		if c.Request.Method == "OPTIONS" {
@@ -68,8 +69,9 @@ func CustomCORSMiddleware(allowOrigins []string, allowMethods []string, allowHea
 		if len(allowHeaders) > 0 {
 			c.Writer.Header().Set("Access-Control-Allow-Headers", joinStrings(allowHeaders))
 		} else {
-			c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+			c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Currency, Accept-Language, Idempotency-Key")
 		}
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "Idempotent-Replayed")
 
 		// Handle preflight requests
 		if c.Request.Method == "OPTIONS" {
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"strings"
+
+	"awesomeEcommerce/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CurrencyMiddleware records the currency the request wants prices in, taken
+// from the currency query parameter or else the X-Currency header
+func CurrencyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		currency := c.Query("currency")
+		if currency == "" {
+			currency = c.GetHeader("X-Currency")
+		}
+		if currency != "" {
+			c.Request = c.Request.WithContext(domain.WithCurrency(c.Request.Context(), strings.ToUpper(currency)))
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotencyMiddleware makes retries of a request sent with an
+// Idempotency-Key header safe. The first request with a key runs and its
+// response is stored; repeating the request replays that response instead of
+// running it again. The key cannot be reused for a different request, nor
+// repeated while the first request is still running. Keys belong to the
+// authenticated user, so it must run after AuthMiddleware, and a request
+// asking for another currency or locale is a different request, so it must
+// run after CurrencyMiddleware and LocaleMiddleware.
+func IdempotencyMiddleware(idempotencyService service.IdempotencyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Idempotency-Key")
+		if header == "" {
+			c.Next()
+			return
+		}
+
+		// Read the request body to fingerprint the request
+		var requestBody []byte
+		if c.Request.Body != nil {
+			var err error
+			if requestBody, err = io.ReadAll(c.Request.Body); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+				c.Abort()
+				return
+			}
+			// Restore the request body for further processing
+			c.Request.Body = io.NopCloser(bytes.NewBuffer(requestBody))
+		}
+
+		key := idempotencyScope(c) + header
+		ctx := c.Request.Context()
+		fingerprint := requestFingerprint(c.Request.Method, c.Request.URL.RequestURI(),
+			domain.CurrencyFromContext(ctx), domain.LocalesFromContext(ctx), requestBody)
+		record, err := idempotencyService.Begin(c, key, fingerprint)
+		if err != nil {
+			switch {
+			case errors.Is(err, domain.ErrInvalidIdempotencyKey):
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			case errors.Is(err, domain.ErrIdempotencyKeyReused):
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			case errors.Is(err, domain.ErrIdempotencyKeyInProgress):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check idempotency key"})
+			}
+			c.Abort()
+			return
+		}
+
+		// Replay the response to the first request
+		if record.IsCompleted() {
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(record.Response.StatusCode, record.Response.ContentType, record.Response.Body)
+			c.Abort()
+			return
+		}
+
+		// Capture the response to store it. A handler that panics leaves no
+		// response, so its key is released for the retry.
+		lock := record
+		writer := &bodyLogWriter{body: bytes.NewBufferString(""), ResponseWriter: c.Writer}
+		c.Writer = writer
+		finished := false
+		defer func() {
+			if !finished {
+				abandonIdempotencyKey(c, idempotencyService, lock)
+			}
+		}()
+
+		c.Next()
+		finished = true
+
+		// Server errors may not happen again, so they are not replayed
+		if writer.Status() >= http.StatusInternalServerError {
+			abandonIdempotencyKey(c, idempotencyService, lock)
+			return
+		}
+
+		// The response is already sent, so failing to store it cannot be
+		// reported to the client. Its key is then released once the lock times
+		// out, and a retry runs again.
+		if err := idempotencyService.Complete(c, lock, domain.IdempotentResponse{
+			StatusCode:  writer.Status(),
+			ContentType: writer.Header().Get("Content-Type"),
+			Body:        writer.body.Bytes(),
+		}); err != nil {
+			log.Printf("Failed to store the response for idempotency key %s: %v", lock.Key, err)
+		}
+	}
+}
+
+// abandonIdempotencyKey releases the key of a request that failed. If that
+// fails too, the key is released once its lock times out.
+func abandonIdempotencyKey(c *gin.Context, idempotencyService service.IdempotencyService, lock *domain.IdempotencyRecord) {
+	if err := idempotencyService.Abandon(c, lock); err != nil {
+		log.Printf("Failed to release idempotency key %s: %v", lock.Key, err)
+	}
+}
+
+// idempotencyScope returns the prefix keeping the idempotency keys of each
+// user apart
+func idempotencyScope(c *gin.Context) string {
+	if userID, exists := c.Get("userID"); exists {
+		return fmt.Sprintf("user:%v:", userID)
+	}
+	return "guest:"
+}
+
+// requestFingerprint identifies a request by its method, URI, body and the
+// currency and locales its response is given in
+func requestFingerprint(method, uri, currency string, locales []string, body []byte) string {
+	hasher := sha256.New()
+	hasher.Write([]byte(method + " " + uri + "\n"))
+	hasher.Write([]byte("currency: " + currency + "\n"))
+	hasher.Write([]byte("locales: " + strings.Join(locales, ",") + "\n"))
+	hasher.Write(body)
+	return hex.EncodeToString(hasher.Sum(nil))
+}
@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"awesomeEcommerce/internal/config"
+	"awesomeEcommerce/internal/repository/cache"
+	"awesomeEcommerce/internal/repository/impl"
+	"awesomeEcommerce/internal/service"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestFingerprint(t *testing.T) {
+	body := []byte(`{"shipping_address":"1 Main St"}`)
+	fingerprint := requestFingerprint("POST", "/api/v1/orders", "EUR", []string{"fr-FR", "en"}, body)
+
+	// The same request asked for in the same currency and locales is the same
+	assert.Equal(t, fingerprint, requestFingerprint("POST", "/api/v1/orders", "EUR", []string{"fr-FR", "en"}, body))
+
+	// Asking for another currency or locale changes the response, so it is
+	// another request
+	assert.NotEqual(t, fingerprint, requestFingerprint("POST", "/api/v1/orders", "USD", []string{"fr-FR", "en"}, body))
+	assert.NotEqual(t, fingerprint, requestFingerprint("POST", "/api/v1/orders", "EUR", []string{"de-DE"}, body))
+	assert.NotEqual(t, fingerprint, requestFingerprint("POST", "/api/v1/orders", "EUR", nil, body))
+	assert.NotEqual(t, fingerprint, requestFingerprint("POST", "/api/v1/orders", "EUR", []string{"fr-FR", "en"}, []byte(`{}`)))
+}
+
+// newIdempotentRouter serves POST /orders behind IdempotencyMiddleware, backed
+// by an in-memory Redis server. The handler creates an order numbered by how
+// many times it ran. Given a release channel, it first signals started and
+// waits for release.
+func newIdempotentRouter(t *testing.T, started, release chan struct{}) (*gin.Engine, *int32) {
+	server := miniredis.RunT(t)
+	client, err := cache.NewRedisClient(&config.Config{Redis: config.RedisConfig{Host: server.Host(), Port: server.Port()}})
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	idempotencyService := service.NewIdempotencyService(impl.NewIdempotencyRepository(client), 24*time.Hour, time.Minute)
+
+	var runs int32
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/orders", IdempotencyMiddleware(idempotencyService), func(c *gin.Context) {
+		if release != nil {
+			started <- struct{}{}
+			<-release
+		}
+		c.JSON(http.StatusCreated, gin.H{"id": atomic.AddInt32(&runs, 1)})
+	})
+	return router, &runs
+}
+
+// postOrder sends POST /orders with an idempotency key
+func postOrder(router *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	req.Header.Set("Idempotency-Key", key)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyMiddlewareReplay(t *testing.T) {
+	router, runs := newIdempotentRouter(t, nil, nil)
+
+	first := postOrder(router, "order-1", `{"cart_id":1}`)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get("Idempotent-Replayed"))
+
+	// The retry gets the stored response without the handler running again
+	retry := postOrder(router, "order-1", `{"cart_id":1}`)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, int32(1), atomic.LoadInt32(runs))
+}
+
+func TestIdempotencyMiddlewareInProgress(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	router, runs := newIdempotentRouter(t, started, release)
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- postOrder(router, "order-1", `{"cart_id":1}`)
+	}()
+	<-started
+
+	// The duplicate arrives while the first request is still running
+	duplicate := postOrder(router, "order-1", `{"cart_id":1}`)
+	assert.Equal(t, http.StatusConflict, duplicate.Code)
+
+	close(release)
+	assert.Equal(t, http.StatusCreated, (<-done).Code)
+	assert.Equal(t, int32(1), atomic.LoadInt32(runs))
+}
+
+func TestIdempotencyMiddlewareKeyReused(t *testing.T) {
+	router, runs := newIdempotentRouter(t, nil, nil)
+
+	assert.Equal(t, http.StatusCreated, postOrder(router, "order-1", `{"cart_id":1}`).Code)
+
+	// The key cannot be sent again with another request
+	reused := postOrder(router, "order-1", `{"cart_id":2}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, reused.Code)
+	assert.Equal(t, int32(1), atomic.LoadInt32(runs))
+}
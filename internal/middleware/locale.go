@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"awesomeEcommerce/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LocaleMiddleware records the locales the request wants content in, most
+// preferred first, taken from the locale query parameter or else the
+// Accept-Language header
+func LocaleMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var locales []string
+		if locale := c.Query("locale"); locale != "" {
+			locales = []string{locale}
+		} else {
+			locales = parseAcceptLanguage(c.GetHeader("Accept-Language"))
+		}
+		if len(locales) > 0 {
+			c.Request = c.Request.WithContext(domain.WithLocales(c.Request.Context(), locales))
+		}
+
+		c.Next()
+	}
+}
+
+// parseAcceptLanguage returns the languages of an Accept-Language header by
+// decreasing quality, leaving out the wildcard and refused languages
+func parseAcceptLanguage(header string) []string {
+	type language struct {
+		tag     string
+		quality float64
+	}
+
+	var languages []language
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.TrimSpace(fields[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range fields[1:] {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if q, err := strconv.ParseFloat(value, 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		if quality <= 0 {
+			continue
+		}
+		languages = append(languages, language{tag: tag, quality: quality})
+	}
+
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].quality > languages[j].quality
+	})
+
+	tags := make([]string, 0, len(languages))
+	for _, language := range languages {
+		tags = append(tags, language.tag)
+	}
+	return tags
+}
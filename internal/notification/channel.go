@@ -0,0 +1,85 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"awesomeEcommerce/internal/messaging"
+)
+
+// Notification is a message for the people running the store or its customers
+type Notification struct {
+	Type    string                 // e.g. "low-stock-alert"
+	Key     string                 // groups notifications about the same subject, e.g. a product ID
+	Subject string                 // one-line summary
+	Data    map[string]interface{} // details of the notification
+}
+
+// Channel delivers notifications
+type Channel interface {
+	// Send delivers a notification
+	Send(ctx context.Context, n Notification) error
+}
+
+// LogChannel writes notifications to the application log
+type LogChannel struct{}
+
+// NewLogChannel creates a new LogChannel
+func NewLogChannel() *LogChannel {
+	return &LogChannel{}
+}
+
+// Send writes the notification to the application log
+func (c *LogChannel) Send(ctx context.Context, n Notification) error {
+	log.Printf("[%s] %s", n.Type, n.Subject)
+	return nil
+}
+
+// KafkaChannel publishes notifications to a Kafka topic
+type KafkaChannel struct {
+	producer *messaging.KafkaProducer
+	topic    string
+}
+
+// NewKafkaChannel creates a new KafkaChannel publishing to topic
+func NewKafkaChannel(producer *messaging.KafkaProducer, topic string) *KafkaChannel {
+	return &KafkaChannel{
+		producer: producer,
+		topic:    topic,
+	}
+}
+
+// Send publishes the notification data, keyed by the notification key
+func (c *KafkaChannel) Send(ctx context.Context, n Notification) error {
+	payload := make(map[string]interface{}, len(n.Data)+3)
+	for key, value := range n.Data {
+		payload[key] = value
+	}
+	payload["type"] = n.Type
+	payload["subject"] = n.Subject
+	payload["timestamp"] = time.Now()
+
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return c.producer.Publish(ctx, c.topic, []byte(n.Key), payloadJSON)
+}
+
+// Broadcast delivers notifications through every channel it holds. A failing
+// channel does not stop the others.
+type Broadcast []Channel
+
+// Send delivers the notification through every channel
+func (b Broadcast) Send(ctx context.Context, n Notification) error {
+	var errs []error
+	for _, channel := range b {
+		if err := channel.Send(ctx, n); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
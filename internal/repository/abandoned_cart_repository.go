@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+
+	"awesomeEcommerce/internal/domain"
+)
+
+// AbandonedCartRepository defines the interface for abandoned cart repository operations
+type AbandonedCartRepository interface {
+	// Create creates a new abandoned cart record
+	Create(ctx context.Context, abandoned *domain.AbandonedCart) error
+
+	// Update updates an existing abandoned cart record
+	Update(ctx context.Context, abandoned *domain.AbandonedCart) error
+
+	// FindOpenByCartID retrieves the abandoned record of a cart not yet recovered
+	FindOpenByCartID(ctx context.Context, cartID uint) (*domain.AbandonedCart, error)
+
+	// FindByToken retrieves an abandoned cart record by its unsubscribe token
+	FindByToken(ctx context.Context, token string) (*domain.AbandonedCart, error)
+
+	// HasUnsubscribed reports whether a user unsubscribed from cart reminders
+	HasUnsubscribed(ctx context.Context, userID uint) (bool, error)
+
+	// FindAll retrieves abandoned cart records, newest first, optionally by
+	// status, with pagination
+	FindAll(ctx context.Context, status domain.AbandonedCartStatus, page, pageSize int) ([]domain.AbandonedCart, int64, error)
+
+	// GetStats counts the abandoned cart records by outcome
+	GetStats(ctx context.Context) (*domain.AbandonedCartStats, error)
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+
+	"awesomeEcommerce/internal/domain"
+)
+
+// BackorderRepository defines the interface for backorder repository operations
+type BackorderRepository interface {
+	// GetOutstandingQuantity sums the backordered units of a product still waiting for stock
+	GetOutstandingQuantity(ctx context.Context, productID uint) (int, error)
+
+	// FindOutstanding retrieves the order lines of a product still waiting for stock, oldest order first
+	FindOutstanding(ctx context.Context, productID uint) ([]domain.OrderItem, error)
+
+	// FindBackorderedProductIDs retrieves the products with backorders waiting for stock
+	FindBackorderedProductIDs(ctx context.Context) ([]uint, error)
+
+	// Allocate takes the unreserved stock of a product and hands it to its
+	// oldest backorders first, returning what each order line received
+	Allocate(ctx context.Context, productID uint) ([]domain.BackorderAllocation, error)
+}
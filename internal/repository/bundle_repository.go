@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+
+	"awesomeEcommerce/internal/domain"
+)
+
+// BundleRepository defines the interface for bundle repository operations
+type BundleRepository interface {
+	// FindComponents retrieves the components of a bundle with their products
+	FindComponents(ctx context.Context, bundleID uint) ([]domain.BundleComponent, error)
+
+	// SetComponents replaces the components of a bundle, repricing the bundle
+	// when it is sold at percent off its components
+	SetComponents(ctx context.Context, bundle *domain.Product, components []domain.BundleComponent) error
+}
@@ -40,6 +40,12 @@ func (r *RedisClient) Set(ctx context.Context, key string, value interface{}, ex
 	return r.client.Set(ctx, key, value, expiration).Err()
 }
 
+// SetNX stores a value in Redis with the given key and expiration time unless
+// the key exists, reporting whether it was stored
+func (r *RedisClient) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, key, value, expiration).Result()
+}
+
 // Get retrieves a value from Redis by key
 func (r *RedisClient) Get(ctx context.Context, key string) (string, error) {
 	return r.client.Get(ctx, key).Result()
@@ -50,6 +56,12 @@ func (r *RedisClient) Delete(ctx context.Context, key string) error {
 	return r.client.Del(ctx, key).Err()
 }
 
+// RunScript runs a Lua script on the given keys, loading it into Redis the
+// first time
+func (r *RedisClient) RunScript(ctx context.Context, script *redis.Script, keys []string, args ...interface{}) (interface{}, error) {
+	return script.Run(ctx, r.client, keys, args...).Result()
+}
+
 // HashSet sets a field in a Redis hash
 func (r *RedisClient) HashSet(ctx context.Context, key, field string, value interface{}) error {
 	return r.client.HSet(ctx, key, field, value).Err()
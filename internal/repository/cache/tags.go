@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// statsKey is the Redis hash holding the hit and miss counters of every cache namespace
+const statsKey = "cache:stats"
+
+// tagKey returns the Redis set key holding the cache keys tagged with tag
+func tagKey(tag string) string {
+	return "tag:" + tag
+}
+
+// SetWithTags stores a value like Set and records the key under each tag, so
+// that invalidating any of the tags removes the value
+func (r *RedisClient) SetWithTags(ctx context.Context, key string, value interface{}, expiration time.Duration, tags ...string) error {
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, key, value, expiration)
+		for _, tag := range tags {
+			pipe.SAdd(ctx, tagKey(tag), key)
+			// Keep the tag index alive as long as the longest lived value it
+			// points to: a new index takes the value's expiration, and an
+			// existing one is only ever extended, never shortened
+			pipe.ExpireNX(ctx, tagKey(tag), expiration)
+			pipe.ExpireGT(ctx, tagKey(tag), expiration)
+		}
+		return nil
+	})
+	return err
+}
+
+// InvalidateTags removes every key recorded under any of the tags, along with the tag indexes
+func (r *RedisClient) InvalidateTags(ctx context.Context, tags ...string) error {
+	for _, tag := range tags {
+		keys, err := r.client.SMembers(ctx, tagKey(tag)).Result()
+		if err != nil && err != redis.Nil {
+			return err
+		}
+
+		keys = append(keys, tagKey(tag))
+		if err := r.client.Del(ctx, keys...).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RecordHit increments the hit counter of a cache namespace
+func (r *RedisClient) RecordHit(ctx context.Context, namespace string) {
+	r.client.HIncrBy(ctx, statsKey, namespace+":hits", 1)
+}
+
+// RecordMiss increments the miss counter of a cache namespace
+func (r *RedisClient) RecordMiss(ctx context.Context, namespace string) {
+	r.client.HIncrBy(ctx, statsKey, namespace+":misses", 1)
+}
+
+// GetStats retrieves the hit and miss counters of every cache namespace
+func (r *RedisClient) GetStats(ctx context.Context) (map[string]domain.CacheStats, error) {
+	fields, err := r.client.HGetAll(ctx, statsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make(map[string]domain.CacheStats)
+	for field, value := range fields {
+		separator := strings.LastIndex(field, ":")
+		if separator < 0 {
+			continue
+		}
+		count, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		namespace := field[:separator]
+		entry := stats[namespace]
+		switch field[separator+1:] {
+		case "hits":
+			entry.Hits = count
+		case "misses":
+			entry.Misses = count
+		}
+		stats[namespace] = entry
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,110 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"awesomeEcommerce/internal/config"
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository/cache"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestClient starts an in-memory Redis server and connects a client to it
+func newTestClient(t *testing.T) (*cache.RedisClient, *miniredis.Miniredis) {
+	server := miniredis.RunT(t)
+
+	cfg := &config.Config{Redis: config.RedisConfig{Host: server.Host(), Port: server.Port()}}
+	client, err := cache.NewRedisClient(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+
+	return client, server
+}
+
+func TestInvalidateTags(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Through A Listing Tag", func(t *testing.T) {
+		client, server := newTestClient(t)
+
+		// A product and a listing showing it are both tagged with the product
+		require.NoError(t, client.SetWithTags(ctx, "product:1", "product", 30*time.Minute, "product:1"))
+		require.NoError(t, client.SetWithTags(ctx, "products:page:1:size:10", "listing", 10*time.Minute, "catalog", "product:1", "product:2"))
+		require.NoError(t, client.SetWithTags(ctx, "products:category:3:page:1:size:10", "other listing", 10*time.Minute, "category:3", "product:2"))
+
+		require.NoError(t, client.InvalidateTags(ctx, "product:1"))
+
+		assert.False(t, server.Exists("product:1"))
+		assert.False(t, server.Exists("products:page:1:size:10"))
+		assert.False(t, server.Exists("tag:product:1"))
+		assert.True(t, server.Exists("products:category:3:page:1:size:10"))
+	})
+
+	t.Run("Listing Catalog Tag", func(t *testing.T) {
+		client, server := newTestClient(t)
+
+		require.NoError(t, client.SetWithTags(ctx, "product:1", "product", 30*time.Minute, "product:1"))
+		require.NoError(t, client.SetWithTags(ctx, "products:page:1:size:10", "listing", 10*time.Minute, "catalog", "product:1"))
+
+		// Invalidating the catalog drops its pages but not the products on them
+		require.NoError(t, client.InvalidateTags(ctx, "catalog"))
+
+		assert.False(t, server.Exists("products:page:1:size:10"))
+		assert.True(t, server.Exists("product:1"))
+	})
+}
+
+func TestSetWithTagsExpiration(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Shorter Value Keeps Tag Alive", func(t *testing.T) {
+		client, server := newTestClient(t)
+
+		require.NoError(t, client.SetWithTags(ctx, "product:1", "product", 30*time.Minute, "product:1"))
+		require.NoError(t, client.SetWithTags(ctx, "products:page:1:size:10", "listing", 10*time.Minute, "catalog", "product:1"))
+
+		// Caching a listing for less time does not shorten the product's tag
+		assert.Equal(t, 30*time.Minute, server.TTL("tag:product:1"))
+		assert.Equal(t, 10*time.Minute, server.TTL("tag:catalog"))
+
+		// Once the listing expired, the tag still finds the product
+		server.FastForward(20 * time.Minute)
+		assert.False(t, server.Exists("products:page:1:size:10"))
+		assert.True(t, server.Exists("tag:product:1"))
+
+		require.NoError(t, client.InvalidateTags(ctx, "product:1"))
+		assert.False(t, server.Exists("product:1"))
+	})
+
+	t.Run("Longer Value Extends Tag", func(t *testing.T) {
+		client, server := newTestClient(t)
+
+		require.NoError(t, client.SetWithTags(ctx, "products:page:1:size:10", "listing", 10*time.Minute, "catalog", "product:1"))
+		require.NoError(t, client.SetWithTags(ctx, "product:1", "product", 30*time.Minute, "product:1"))
+
+		assert.Equal(t, 30*time.Minute, server.TTL("tag:product:1"))
+	})
+}
+
+func TestGetStats(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newTestClient(t)
+
+	client.RecordHit(ctx, "product")
+	client.RecordHit(ctx, "product")
+	client.RecordMiss(ctx, "product")
+	client.RecordMiss(ctx, "product_list")
+
+	stats, err := client.GetStats(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]domain.CacheStats{
+		"product":      {Hits: 2, Misses: 1},
+		"product_list": {Misses: 1},
+	}, stats)
+}
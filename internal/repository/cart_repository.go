@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"awesomeEcommerce/internal/domain"
 )
@@ -32,12 +33,19 @@ type CartRepository interface {
 	// RemoveItem removes an item from a cart
 	RemoveItem(ctx context.Context, cartID, itemID uint) error
 
-	// ClearCart removes all items from a cart
+	// ClearCart removes all items and the coupon code from a cart
 	ClearCart(ctx context.Context, cartID uint) error
 
+	// SetCouponCode applies a coupon code to a cart, or removes it when empty
+	SetCouponCode(ctx context.Context, cartID uint, code string) error
+
 	// GetCartItems retrieves all items in a cart
 	GetCartItems(ctx context.Context, cartID uint) ([]domain.CartItem, error)
 
 	// GetCartTotal calculates the total price of all items in a cart
-	GetCartTotal(ctx context.Context, cartID uint) (float64, error)
+	GetCartTotal(ctx context.Context, cartID uint) (domain.Money, error)
+
+	// FindIdle retrieves the carts with items last touched between two times,
+	// with their items and products
+	FindIdle(ctx context.Context, since, before time.Time) ([]domain.Cart, error)
 }
\ No newline at end of file
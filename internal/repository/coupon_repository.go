@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+
+	"awesomeEcommerce/internal/domain"
+)
+
+// CouponRepository defines the interface for coupon repository operations
+type CouponRepository interface {
+	// FindByID retrieves a coupon by its ID
+	FindByID(ctx context.Context, id uint) (*domain.Coupon, error)
+
+	// FindByCode retrieves a coupon by its normalized code
+	FindByCode(ctx context.Context, code string) (*domain.Coupon, error)
+
+	// FindAll retrieves all coupons with optional pagination
+	FindAll(ctx context.Context, page, pageSize int) ([]domain.Coupon, int64, error)
+
+	// Create creates a new coupon
+	Create(ctx context.Context, coupon *domain.Coupon) error
+
+	// Update updates an existing coupon
+	Update(ctx context.Context, coupon *domain.Coupon) error
+
+	// Delete deletes a coupon by its ID
+	Delete(ctx context.Context, id uint) error
+
+	// CountRedemptionsByUser counts the orders of a user that redeemed a
+	// coupon and were not cancelled
+	CountRedemptionsByUser(ctx context.Context, couponID, userID uint) (int64, error)
+}
@@ -46,8 +46,10 @@ func NewDatabase(cfg *config.Config) (*gorm.DB, error) {
 	return db, nil
 }
 
-// AutoMigrate automatically migrates the database schema
-func AutoMigrate(db *gorm.DB) error {
+// AutoMigrate automatically migrates the database schema. Amounts recorded
+// before money was held in minor units are converted, reading product
+// prices in baseCurrency.
+func AutoMigrate(db *gorm.DB, baseCurrency string) error {
 	log.Println("Running database migrations...")
 
 	// List of models to migrate
@@ -59,15 +61,64 @@ func AutoMigrate(db *gorm.DB) error {
 		&domain.CartItem{},
 		&domain.Order{},
 		&domain.OrderItem{},
+		&domain.OrderStatusHistory{},
 		&domain.Payment{},
+		&domain.ProductRecommendation{},
+		&domain.Warehouse{},
+		&domain.InventoryLevel{},
+		&domain.InventoryTransfer{},
+		&domain.OrderAllocation{},
+		&domain.StockReservation{},
+		&domain.InventoryLedgerEntry{},
+		&domain.StockAlert{},
+		&domain.StockSubscription{},
+		&domain.LicenseKey{},
+		&domain.BundleComponent{},
+		&domain.ExchangeRate{},
+		&domain.ProductTranslation{},
+		&domain.CategoryTranslation{},
+		&domain.Coupon{},
+		&domain.CouponRedemption{},
+		&domain.Promotion{},
+		&domain.OrderDiscount{},
+		&domain.TaxRate{},
+		&domain.TaxLine{},
+		&domain.ShippingZone{},
+		&domain.ShippingMethod{},
+		&domain.AbandonedCart{},
+		&domain.GiftCard{},
+		&domain.StoreCreditAccount{},
+		&domain.BalanceEntry{},
+		&domain.PaymentTender{},
+		&domain.Shipment{},
+		&domain.ShipmentItem{},
 	}
 
+	if err := migrateAmountsBefore(db, baseCurrency); err != nil {
+		return fmt.Errorf("failed to migrate amounts: %w", err)
+	}
+
+	// Orders from before totals were broken down only hold their total
+	breakDownTotals := db.Migrator().HasTable("orders") && !db.Migrator().HasColumn("orders", "subtotal_amount")
+
 	// Run migrations
 	err := db.AutoMigrate(models...)
 	if err != nil {
 		return fmt.Errorf("failed to migrate database: %w", err)
 	}
 
+	if err := migrateAmountsAfter(db, baseCurrency); err != nil {
+		return fmt.Errorf("failed to migrate amounts: %w", err)
+	}
+
+	if breakDownTotals {
+		err := db.Exec("UPDATE orders SET subtotal_amount = total_amount + discount_amount, subtotal_currency = total_currency, " +
+			"tax_currency = total_currency, shipping_currency = total_currency").Error
+		if err != nil {
+			return fmt.Errorf("failed to break down order totals: %w", err)
+		}
+	}
+
 	log.Println("Database migrations completed successfully")
 	return nil
 }
\ No newline at end of file
@@ -0,0 +1,169 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// dataMigration records a data migration that ran, so that one that must not
+// run twice is skipped when the migrations are retried
+type dataMigration struct {
+	Name  string `gorm:"primaryKey;size:191"`
+	RanAt time.Time
+}
+
+// TableName specifies the table name for dataMigration
+func (dataMigration) TableName() string {
+	return "data_migrations"
+}
+
+// runOnce runs a data migration unless it already ran, recording it in the
+// same transaction as its changes
+func runOnce(db *gorm.DB, name string, migrate func(tx *gorm.DB) error) error {
+	if err := db.AutoMigrate(&dataMigration{}); err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var ran int64
+		if err := tx.Model(&dataMigration{}).Where("name = ?", name).Count(&ran).Error; err != nil {
+			return err
+		}
+		if ran > 0 {
+			return nil
+		}
+
+		if err := migrate(tx); err != nil {
+			return err
+		}
+		return tx.Create(&dataMigration{Name: name, RanAt: time.Now()}).Error
+	})
+}
+
+// migrateAmountsBefore converts the decimal amount columns that keep their
+// name, orders.total_amount and payments.amount, to integer minor units in
+// the currency of each row. It runs before the schema is migrated, which
+// would otherwise truncate the decimals. MySQL commits each change of a
+// column's type on its own, so scaling is recorded as done: a migration
+// failing before the column became an integer does not scale it again
+// when retried.
+func migrateAmountsBefore(db *gorm.DB, baseCurrency string) error {
+	for _, table := range []struct{ name, amount string }{
+		{"orders", "total_amount"},
+		{"payments", "amount"},
+	} {
+		isDecimal, err := hasDecimalColumn(db, table.name, table.amount)
+		if err != nil {
+			return err
+		}
+		if !isDecimal {
+			continue
+		}
+
+		// Make room for the amounts in minor units before scaling them
+		if err := db.Exec(fmt.Sprintf("ALTER TABLE %s MODIFY %s DECIMAL(20,2) NOT NULL", table.name, table.amount)).Error; err != nil {
+			return err
+		}
+
+		// Rows from before currencies were recorded are in the base currency
+		currencyColumn := "currency"
+		if !db.Migrator().HasColumn(table.name, currencyColumn) {
+			currencyColumn = ""
+		}
+		err = runOnce(db, fmt.Sprintf("scale %s.%s to minor units", table.name, table.amount), func(tx *gorm.DB) error {
+			return scaleToMinorUnits(tx, table.name, table.name+"."+table.amount, table.name+"."+table.amount, currencyColumn, baseCurrency)
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := db.Exec(fmt.Sprintf("ALTER TABLE %s MODIFY %s BIGINT NOT NULL DEFAULT 0", table.name, table.amount)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateAmountsAfter moves the amounts held in columns that were renamed
+// into the money columns the schema migration added: the currency of orders
+// into total_currency, and product and order item prices into price_amount
+// and price_currency. The old columns are dropped once moved.
+func migrateAmountsAfter(db *gorm.DB, baseCurrency string) error {
+	if db.Migrator().HasColumn("orders", "currency") {
+		if err := db.Exec("UPDATE orders SET total_currency = currency").Error; err != nil {
+			return err
+		}
+		if err := db.Migrator().DropColumn("orders", "currency"); err != nil {
+			return err
+		}
+	}
+
+	// Product prices are in the base currency
+	if db.Migrator().HasColumn("products", "price") {
+		if err := db.Exec("UPDATE products SET price_currency = ?", strings.ToUpper(baseCurrency)).Error; err != nil {
+			return err
+		}
+		if err := scaleToMinorUnits(db, "products", "products.price_amount", "products.price", "", baseCurrency); err != nil {
+			return err
+		}
+		if err := db.Migrator().DropColumn("products", "price"); err != nil {
+			return err
+		}
+	}
+
+	// Order item prices are in the currency of their order
+	if db.Migrator().HasColumn("order_items", "price") {
+		if err := db.Exec("UPDATE order_items JOIN orders ON orders.id = order_items.order_id SET order_items.price_currency = orders.total_currency").Error; err != nil {
+			return err
+		}
+		if err := scaleToMinorUnits(db, "order_items", "order_items.price_amount", "order_items.price", "price_currency", baseCurrency); err != nil {
+			return err
+		}
+		if err := db.Migrator().DropColumn("order_items", "price"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scaleToMinorUnits sets target to source in the minor units of each row's
+// currency, read from currencyColumn or, without one, the base currency
+func scaleToMinorUnits(db *gorm.DB, table, target, source, currencyColumn, baseCurrency string) error {
+	update := fmt.Sprintf("UPDATE %s SET %s = ROUND(%s * ?)", table, target, source)
+	if currencyColumn == "" {
+		return db.Exec(update, domain.MinorUnits(strings.ToUpper(baseCurrency))).Error
+	}
+
+	var currencies []string
+	if err := db.Table(table).Distinct(currencyColumn).Pluck(currencyColumn, &currencies).Error; err != nil {
+		return err
+	}
+	for _, currency := range currencies {
+		if err := db.Exec(update+fmt.Sprintf(" WHERE %s.%s = ?", table, currencyColumn), domain.MinorUnits(strings.ToUpper(currency)), currency).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasDecimalColumn reports whether a table has a column of decimal type
+func hasDecimalColumn(db *gorm.DB, table, column string) (bool, error) {
+	if !db.Migrator().HasTable(table) {
+		return false, nil
+	}
+	columnTypes, err := db.Migrator().ColumnTypes(table)
+	if err != nil {
+		return false, err
+	}
+	for _, columnType := range columnTypes {
+		if columnType.Name() == column {
+			return strings.EqualFold(columnType.DatabaseTypeName(), "decimal"), nil
+		}
+	}
+	return false, nil
+}
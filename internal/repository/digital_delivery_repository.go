@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+)
+
+// DigitalDeliveryRepository defines the interface for digital product delivery repository operations
+type DigitalDeliveryRepository interface {
+	// AddLicenseKeys adds keys to the pool of a product, raising its stock by the number of keys
+	AddLicenseKeys(ctx context.Context, productID uint, keys []string, change domain.StockChange) error
+
+	// DeliverItem marks an order line delivered, assigning it license keys from
+	// the pool when it is delivered by key. Delivering a line twice returns the
+	// keys it was first given.
+	DeliverItem(ctx context.Context, item *domain.OrderItem, deliveredAt time.Time) ([]domain.LicenseKey, error)
+
+	// FindLicenseKeysByOrderID retrieves the license keys assigned to an order
+	FindLicenseKeysByOrderID(ctx context.Context, orderID uint) ([]domain.LicenseKey, error)
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+
+	"awesomeEcommerce/internal/domain"
+)
+
+// ExchangeRateRepository defines the interface for exchange rate repository operations
+type ExchangeRateRepository interface {
+	// FindByCurrency retrieves the exchange rate of a currency
+	FindByCurrency(ctx context.Context, currency string) (*domain.ExchangeRate, error)
+
+	// FindAll retrieves every exchange rate, ordered by currency
+	FindAll(ctx context.Context) ([]domain.ExchangeRate, error)
+
+	// Save creates or updates exchange rates
+	Save(ctx context.Context, rates []domain.ExchangeRate) error
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+
+	"awesomeEcommerce/internal/domain"
+)
+
+// GiftCardRepository defines the interface for gift card repository operations
+type GiftCardRepository interface {
+	// FindByID retrieves a gift card by its ID
+	FindByID(ctx context.Context, id uint) (*domain.GiftCard, error)
+
+	// FindByCode retrieves a gift card by its code
+	FindByCode(ctx context.Context, code string) (*domain.GiftCard, error)
+
+	// FindByOrderItemID retrieves the gift cards bought with an order line
+	FindByOrderItemID(ctx context.Context, orderItemID uint) ([]domain.GiftCard, error)
+
+	// FindAll retrieves all gift cards with pagination, newest first
+	FindAll(ctx context.Context, page, pageSize int) ([]domain.GiftCard, int64, error)
+
+	// Create creates a new gift card, recording its value in the balance ledger
+	Create(ctx context.Context, card *domain.GiftCard, change domain.BalanceChange) error
+
+	// Update updates whether a gift card is active, its expiry and its
+	// recipient. The balance only changes through Adjust.
+	Update(ctx context.Context, card *domain.GiftCard) error
+
+	// Adjust changes the balance of a gift card by delta and records the change
+	// in the balance ledger, failing with domain.ErrInsufficientBalance rather
+	// than take the balance below zero
+	Adjust(ctx context.Context, id uint, delta domain.Money, change domain.BalanceChange) (*domain.GiftCard, error)
+
+	// FindLedger retrieves the balance ledger of a gift card, newest first
+	FindLedger(ctx context.Context, id uint) ([]domain.BalanceEntry, error)
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+)
+
+// IdempotencyRepository defines the interface for idempotency key repository operations
+type IdempotencyRepository interface {
+	// FindByKey retrieves the record of an idempotency key, or nil if it has none
+	FindByKey(ctx context.Context, key string) (*domain.IdempotencyRecord, error)
+
+	// Create stores the record of an idempotency key for ttl unless the key
+	// already has one, reporting whether it was stored
+	Create(ctx context.Context, record *domain.IdempotencyRecord, ttl time.Duration) (bool, error)
+
+	// Update replaces the record of an idempotency key, keeping it for ttl.
+	// The key must still be held by the request with the record's token,
+	// otherwise domain.ErrIdempotencyKeyLockLost is returned.
+	Update(ctx context.Context, record *domain.IdempotencyRecord, ttl time.Duration) error
+
+	// Delete deletes the record of an idempotency key if it is still held by
+	// the request with the token
+	Delete(ctx context.Context, key, token string) error
+}
@@ -0,0 +1,102 @@
+package impl
+
+import (
+	"context"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// AbandonedCartRepositoryImpl implements the AbandonedCartRepository interface
+type AbandonedCartRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewAbandonedCartRepository creates a new AbandonedCartRepositoryImpl
+func NewAbandonedCartRepository(db *gorm.DB) repository.AbandonedCartRepository {
+	return &AbandonedCartRepositoryImpl{
+		db: db,
+	}
+}
+
+// Create creates a new abandoned cart record
+func (r *AbandonedCartRepositoryImpl) Create(ctx context.Context, abandoned *domain.AbandonedCart) error {
+	return r.db.Create(abandoned).Error
+}
+
+// Update updates an existing abandoned cart record
+func (r *AbandonedCartRepositoryImpl) Update(ctx context.Context, abandoned *domain.AbandonedCart) error {
+	return r.db.Save(abandoned).Error
+}
+
+// FindOpenByCartID retrieves the abandoned record of a cart not yet recovered
+func (r *AbandonedCartRepositoryImpl) FindOpenByCartID(ctx context.Context, cartID uint) (*domain.AbandonedCart, error) {
+	var abandoned domain.AbandonedCart
+	if err := r.db.Where("cart_id = ? AND status = ?", cartID, domain.AbandonedCartStatusAbandoned).
+		Order("id DESC").
+		First(&abandoned).Error; err != nil {
+		return nil, err
+	}
+	return &abandoned, nil
+}
+
+// FindByToken retrieves an abandoned cart record by its unsubscribe token
+func (r *AbandonedCartRepositoryImpl) FindByToken(ctx context.Context, token string) (*domain.AbandonedCart, error) {
+	var abandoned domain.AbandonedCart
+	if err := r.db.Where("token = ?", token).First(&abandoned).Error; err != nil {
+		return nil, err
+	}
+	return &abandoned, nil
+}
+
+// HasUnsubscribed reports whether a user unsubscribed from cart reminders
+func (r *AbandonedCartRepositoryImpl) HasUnsubscribed(ctx context.Context, userID uint) (bool, error) {
+	var count int64
+	if err := r.db.Model(&domain.AbandonedCart{}).
+		Where("user_id = ? AND unsubscribed_at IS NOT NULL", userID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// FindAll retrieves abandoned cart records, newest first, optionally by
+// status, with pagination
+func (r *AbandonedCartRepositoryImpl) FindAll(ctx context.Context, status domain.AbandonedCartStatus, page, pageSize int) ([]domain.AbandonedCart, int64, error) {
+	var records []domain.AbandonedCart
+	var total int64
+
+	// Filter each query separately, as a counted query cannot be reused
+	filter := func(db *gorm.DB) *gorm.DB {
+		if status != "" {
+			return db.Where("status = ?", status)
+		}
+		return db
+	}
+	if err := r.db.Model(&domain.AbandonedCart{}).Scopes(filter).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := r.db.Scopes(filter).Order("id DESC").Offset(offset).Limit(pageSize).Find(&records).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return records, total, nil
+}
+
+// GetStats counts the abandoned cart records by outcome
+func (r *AbandonedCartRepositoryImpl) GetStats(ctx context.Context) (*domain.AbandonedCartStats, error) {
+	var stats domain.AbandonedCartStats
+	if err := r.db.Model(&domain.AbandonedCart{}).
+		Select("COALESCE(SUM(CASE WHEN status = ? THEN 1 ELSE 0 END), 0) AS abandoned, "+
+			"COALESCE(SUM(CASE WHEN status = ? THEN 1 ELSE 0 END), 0) AS recovered, "+
+			"COALESCE(SUM(CASE WHEN unsubscribed_at IS NOT NULL THEN 1 ELSE 0 END), 0) AS unsubscribed",
+			domain.AbandonedCartStatusAbandoned, domain.AbandonedCartStatusRecovered).
+		Scan(&stats).Error; err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
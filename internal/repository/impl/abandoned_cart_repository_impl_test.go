@@ -0,0 +1,135 @@
+package impl_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+	"awesomeEcommerce/internal/repository/impl"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// AbandonedCartRepositoryTestSuite is a test suite for AbandonedCartRepositoryImpl
+type AbandonedCartRepositoryTestSuite struct {
+	suite.Suite
+	repo    repository.AbandonedCartRepository
+	sqlMock sqlmock.Sqlmock
+	ctx     context.Context
+}
+
+// SetupTest sets up the test suite
+func (s *AbandonedCartRepositoryTestSuite) SetupTest() {
+	db, sqlMock := newMockDB(s.T())
+	s.repo = impl.NewAbandonedCartRepository(db)
+	s.sqlMock = sqlMock
+	s.ctx = context.Background()
+}
+
+// TestFindOpenByCartID tests the FindOpenByCartID method
+func (s *AbandonedCartRepositoryTestSuite) TestFindOpenByCartID() {
+	s.Run("Success", func() {
+		// Test case: The latest record of the cart not yet recovered
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `abandoned_carts` WHERE cart_id = ? AND status = ? ORDER BY id DESC")).
+			WithArgs(1, domain.AbandonedCartStatusAbandoned).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "cart_id", "status", "reminders_sent"}).
+				AddRow(3, 1, domain.AbandonedCartStatusAbandoned, 1))
+
+		// Execute
+		abandoned, err := s.repo.FindOpenByCartID(s.ctx, 1)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), uint(3), abandoned.ID)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestHasUnsubscribed tests the HasUnsubscribed method
+func (s *AbandonedCartRepositoryTestSuite) TestHasUnsubscribed() {
+	s.Run("Success", func() {
+		// Test case: Any record of the user with an unsubscribe time counts
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `abandoned_carts` WHERE user_id = ? AND unsubscribed_at IS NOT NULL")).
+			WithArgs(7).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+		// Execute
+		unsubscribed, err := s.repo.HasUnsubscribed(s.ctx, 7)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.True(s.T(), unsubscribed)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestFindAll tests the FindAll method
+func (s *AbandonedCartRepositoryTestSuite) TestFindAll() {
+	s.Run("Success - By Status", func() {
+		// Test case: Both the count and the page are filtered by status
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `abandoned_carts` WHERE status = ?")).
+			WithArgs(domain.AbandonedCartStatusRecovered).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(11))
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `abandoned_carts` WHERE status = ? ORDER BY id DESC LIMIT 10 OFFSET 10")).
+			WithArgs(domain.AbandonedCartStatusRecovered).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(1, domain.AbandonedCartStatusRecovered))
+
+		// Execute
+		records, total, err := s.repo.FindAll(s.ctx, domain.AbandonedCartStatusRecovered, 2, 10)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), int64(11), total)
+		assert.Len(s.T(), records, 1)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+
+	s.Run("Success - Any Status", func() {
+		// Reset mock
+		s.SetupTest()
+
+		// Test case: Without a status every record is listed
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `abandoned_carts`") + "$").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `abandoned_carts` ORDER BY id DESC LIMIT 10") + "$").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).
+				AddRow(2, domain.AbandonedCartStatusAbandoned).
+				AddRow(1, domain.AbandonedCartStatusRecovered))
+
+		// Execute
+		records, total, err := s.repo.FindAll(s.ctx, "", 1, 10)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), int64(2), total)
+		assert.Len(s.T(), records, 2)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestGetStats tests the GetStats method
+func (s *AbandonedCartRepositoryTestSuite) TestGetStats() {
+	s.Run("Success", func() {
+		// Test case: The records are counted by outcome in one query
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(SUM(CASE WHEN status = ? THEN 1 ELSE 0 END), 0) AS abandoned, COALESCE(SUM(CASE WHEN status = ? THEN 1 ELSE 0 END), 0) AS recovered")).
+			WithArgs(domain.AbandonedCartStatusAbandoned, domain.AbandonedCartStatusRecovered).
+			WillReturnRows(sqlmock.NewRows([]string{"abandoned", "recovered", "unsubscribed"}).AddRow(6, 2, 1))
+
+		// Execute
+		stats, err := s.repo.GetStats(s.ctx)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), &domain.AbandonedCartStats{Abandoned: 6, Recovered: 2, Unsubscribed: 1}, stats)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestAbandonedCartRepositorySuite runs the test suite
+func TestAbandonedCartRepositorySuite(t *testing.T) {
+	suite.Run(t, new(AbandonedCartRepositoryTestSuite))
+}
@@ -0,0 +1,148 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+	"awesomeEcommerce/internal/repository/cache"
+
+	"gorm.io/gorm"
+)
+
+// BackorderRepositoryImpl implements the BackorderRepository interface
+type BackorderRepositoryImpl struct {
+	db    *gorm.DB
+	cache *cache.RedisClient
+}
+
+// NewBackorderRepository creates a new BackorderRepositoryImpl
+func NewBackorderRepository(db *gorm.DB, cache *cache.RedisClient) repository.BackorderRepository {
+	return &BackorderRepositoryImpl{
+		db:    db,
+		cache: cache,
+	}
+}
+
+// GetOutstandingQuantity sums the backordered units of a product still waiting for stock
+func (r *BackorderRepositoryImpl) GetOutstandingQuantity(ctx context.Context, productID uint) (int, error) {
+	var outstanding int
+	if err := outstandingBackorders(r.db).
+		Where("order_items.product_id = ?", productID).
+		Select("COALESCE(SUM(order_items.backordered_quantity - order_items.backorder_allocated), 0)").
+		Scan(&outstanding).Error; err != nil {
+		return 0, err
+	}
+	return outstanding, nil
+}
+
+// FindOutstanding retrieves the order lines of a product still waiting for stock, oldest order first
+func (r *BackorderRepositoryImpl) FindOutstanding(ctx context.Context, productID uint) ([]domain.OrderItem, error) {
+	return findOutstanding(r.db, productID)
+}
+
+// FindBackorderedProductIDs retrieves the products with backorders waiting for stock
+func (r *BackorderRepositoryImpl) FindBackorderedProductIDs(ctx context.Context) ([]uint, error) {
+	var productIDs []uint
+	if err := outstandingBackorders(r.db).
+		Distinct().
+		Order("order_items.product_id ASC").
+		Pluck("order_items.product_id", &productIDs).Error; err != nil {
+		return nil, err
+	}
+	return productIDs, nil
+}
+
+// Allocate takes the unreserved stock of a product and hands it to its
+// oldest backorders first, returning what each order line received
+func (r *BackorderRepositoryImpl) Allocate(ctx context.Context, productID uint) ([]domain.BackorderAllocation, error) {
+	var allocations []domain.BackorderAllocation
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the product row so checkouts cannot take the stock meanwhile
+		stock, err := lockProductStock(tx, productID)
+		if err != nil {
+			return err
+		}
+		reserved, err := reservedQuantity(tx, productID)
+		if err != nil {
+			return err
+		}
+
+		available := stock - reserved
+		if available <= 0 {
+			return nil
+		}
+
+		items, err := findOutstanding(tx, productID)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			if available == 0 {
+				break
+			}
+			take := min(item.OutstandingBackorder(), available)
+
+			if err := tx.Model(&domain.OrderItem{}).
+				Where("id = ?", item.ID).
+				Update("backorder_allocated", gorm.Expr("backorder_allocated + ?", take)).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&domain.Product{}).
+				Where("id = ?", productID).
+				Update("stock", gorm.Expr("stock - ?", take)).Error; err != nil {
+				return err
+			}
+
+			change := domain.StockChange{Reason: domain.LedgerReasonOrder, ReferenceID: item.OrderID, Actor: "backorder-allocation"}
+			if err := recordStockChange(tx, productID, -take, change); err != nil {
+				return err
+			}
+
+			allocations = append(allocations, domain.BackorderAllocation{
+				OrderID:     item.OrderID,
+				OrderItemID: item.ID,
+				ProductID:   productID,
+				Quantity:    take,
+				Remaining:   item.OutstandingBackorder() - take,
+			})
+			available -= take
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Invalidate the product and the orders whose lines changed
+	if len(allocations) > 0 {
+		r.cache.InvalidateTags(ctx, productTag(productID))
+		for _, allocation := range allocations {
+			r.cache.Delete(ctx, fmt.Sprintf("order:%d", allocation.OrderID))
+		}
+	}
+
+	return allocations, nil
+}
+
+// outstandingBackorders scopes a query to order lines of live orders still waiting for stock
+func outstandingBackorders(db *gorm.DB) *gorm.DB {
+	return db.Model(&domain.OrderItem{}).
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Where("orders.status IN ?", []domain.OrderStatus{domain.OrderStatusPending, domain.OrderStatusProcessing}).
+		Where("order_items.backordered_quantity > order_items.backorder_allocated")
+}
+
+// findOutstanding retrieves the order lines of a product still waiting for stock, oldest order first
+func findOutstanding(db *gorm.DB, productID uint) ([]domain.OrderItem, error) {
+	var items []domain.OrderItem
+	if err := outstandingBackorders(db).
+		Where("order_items.product_id = ?", productID).
+		Order("orders.created_at ASC, order_items.id ASC").
+		Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
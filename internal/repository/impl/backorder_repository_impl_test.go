@@ -0,0 +1,133 @@
+package impl_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+	"awesomeEcommerce/internal/repository/impl"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// BackorderRepositoryTestSuite is a test suite for BackorderRepositoryImpl
+type BackorderRepositoryTestSuite struct {
+	suite.Suite
+	repo    repository.BackorderRepository
+	sqlMock sqlmock.Sqlmock
+	ctx     context.Context
+}
+
+// SetupTest sets up the test suite
+func (s *BackorderRepositoryTestSuite) SetupTest() {
+	db, sqlMock := newMockDB(s.T())
+	s.repo = impl.NewBackorderRepository(db, newMockCache(s.T()))
+	s.sqlMock = sqlMock
+	s.ctx = context.Background()
+}
+
+// outstandingFilter is the condition that scopes a query to order lines of
+// live orders still waiting for stock
+var outstandingFilter = regexp.QuoteMeta("JOIN orders ON orders.id = order_items.order_id WHERE orders.status IN (?,?) AND order_items.backordered_quantity > order_items.backorder_allocated AND order_items.product_id = ?")
+
+// TestGetOutstandingQuantity tests the GetOutstandingQuantity method
+func (s *BackorderRepositoryTestSuite) TestGetOutstandingQuantity() {
+	s.Run("Success", func() {
+		// Test case: Only the unallocated units of pending and processing
+		// orders are counted
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(SUM(order_items.backordered_quantity - order_items.backorder_allocated), 0) FROM `order_items`")+" "+outstandingFilter).
+			WithArgs(domain.OrderStatusPending, domain.OrderStatusProcessing, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"outstanding"}).AddRow(7))
+
+		// Execute
+		outstanding, err := s.repo.GetOutstandingQuantity(s.ctx, 1)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), 7, outstanding)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestAllocate tests the Allocate method
+func (s *BackorderRepositoryTestSuite) TestAllocate() {
+	s.Run("Success", func() {
+		// Test case: The unreserved stock fills the oldest backorder and part
+		// of the next one
+		s.sqlMock.ExpectBegin()
+		s.sqlMock.ExpectQuery("SELECT `id`,`stock` FROM `products` .* FOR UPDATE").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "stock"}).AddRow(1, 6))
+		s.sqlMock.ExpectQuery("SELECT COALESCE\\(SUM\\(quantity\\), 0\\) FROM `stock_reservations`").
+			WillReturnRows(sqlmock.NewRows([]string{"reserved"}).AddRow(1))
+		s.sqlMock.ExpectQuery(outstandingFilter+regexp.QuoteMeta(" ORDER BY orders.created_at ASC, order_items.id ASC")).
+			WithArgs(domain.OrderStatusPending, domain.OrderStatusProcessing, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "product_id", "backordered_quantity", "backorder_allocated"}).
+				AddRow(10, 100, 1, 3, 0).
+				AddRow(11, 101, 1, 4, 1))
+
+		// The oldest line takes the 3 units it is waiting for
+		s.sqlMock.ExpectExec(regexp.QuoteMeta("UPDATE `order_items` SET `backorder_allocated`=backorder_allocated + ?")).
+			WithArgs(3, sqlmock.AnyArg(), 10).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		s.sqlMock.ExpectExec(regexp.QuoteMeta("UPDATE `products` SET `stock`=stock - ?")).
+			WithArgs(3, sqlmock.AnyArg(), 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		s.sqlMock.ExpectQuery("SELECT `id`,`stock` FROM `products`").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "stock"}).AddRow(1, 3))
+		s.sqlMock.ExpectExec("INSERT INTO `inventory_ledger`").WillReturnResult(sqlmock.NewResult(1, 1))
+
+		// The next one takes the 2 units left of the 3 it is waiting for
+		s.sqlMock.ExpectExec(regexp.QuoteMeta("UPDATE `order_items` SET `backorder_allocated`=backorder_allocated + ?")).
+			WithArgs(2, sqlmock.AnyArg(), 11).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		s.sqlMock.ExpectExec(regexp.QuoteMeta("UPDATE `products` SET `stock`=stock - ?")).
+			WithArgs(2, sqlmock.AnyArg(), 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		s.sqlMock.ExpectQuery("SELECT `id`,`stock` FROM `products`").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "stock"}).AddRow(1, 1))
+		s.sqlMock.ExpectExec("INSERT INTO `inventory_ledger`").WillReturnResult(sqlmock.NewResult(2, 1))
+		s.sqlMock.ExpectCommit()
+
+		// Execute
+		allocations, err := s.repo.Allocate(s.ctx, 1)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), []domain.BackorderAllocation{
+			{OrderID: 100, OrderItemID: 10, ProductID: 1, Quantity: 3, Remaining: 0},
+			{OrderID: 101, OrderItemID: 11, ProductID: 1, Quantity: 2, Remaining: 1},
+		}, allocations)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+
+	s.Run("Success - No Stock Available", func() {
+		// Reset mock
+		s.SetupTest()
+
+		// Test case: The stock is all reserved, so no backorder is touched
+		s.sqlMock.ExpectBegin()
+		s.sqlMock.ExpectQuery("SELECT `id`,`stock` FROM `products` .* FOR UPDATE").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "stock"}).AddRow(1, 2))
+		s.sqlMock.ExpectQuery("SELECT COALESCE\\(SUM\\(quantity\\), 0\\) FROM `stock_reservations`").
+			WillReturnRows(sqlmock.NewRows([]string{"reserved"}).AddRow(2))
+		s.sqlMock.ExpectCommit()
+
+		// Execute
+		allocations, err := s.repo.Allocate(s.ctx, 1)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Empty(s.T(), allocations)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestBackorderRepositorySuite runs the test suite
+func TestBackorderRepositorySuite(t *testing.T) {
+	suite.Run(t, new(BackorderRepositoryTestSuite))
+}
@@ -0,0 +1,102 @@
+package impl
+
+import (
+	"context"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+	"awesomeEcommerce/internal/repository/cache"
+
+	"gorm.io/gorm"
+)
+
+// BundleRepositoryImpl implements the BundleRepository interface
+type BundleRepositoryImpl struct {
+	db    *gorm.DB
+	cache *cache.RedisClient
+}
+
+// NewBundleRepository creates a new BundleRepositoryImpl
+func NewBundleRepository(db *gorm.DB, cache *cache.RedisClient) repository.BundleRepository {
+	return &BundleRepositoryImpl{
+		db:    db,
+		cache: cache,
+	}
+}
+
+// FindComponents retrieves the components of a bundle with their products
+func (r *BundleRepositoryImpl) FindComponents(ctx context.Context, bundleID uint) ([]domain.BundleComponent, error) {
+	var components []domain.BundleComponent
+	if err := r.db.Preload("Product").Where("bundle_id = ?", bundleID).Order("id").Find(&components).Error; err != nil {
+		return nil, err
+	}
+	return components, nil
+}
+
+// SetComponents replaces the components of a bundle, repricing the bundle
+// when it is sold at percent off its components
+func (r *BundleRepositoryImpl) SetComponents(ctx context.Context, bundle *domain.Product, components []domain.BundleComponent) error {
+	var prices map[uint]domain.Money
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("bundle_id = ?", bundle.ID).Delete(&domain.BundleComponent{}).Error; err != nil {
+			return err
+		}
+
+		for i := range components {
+			components[i].ID = 0
+			components[i].BundleID = bundle.ID
+			if err := tx.Omit("Product").Create(&components[i]).Error; err != nil {
+				return err
+			}
+		}
+
+		var err error
+		prices, err = repriceBundles(tx, bundle.ID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if price, ok := prices[bundle.ID]; ok {
+		bundle.Price = price
+	}
+
+	// Invalidate the bundle and every listing it shows up in
+	r.cache.InvalidateTags(ctx, productTag(bundle.ID))
+
+	return nil
+}
+
+// repriceBundles reprices the bundles sold at percent off their components
+// among a product and the bundles it is a component of, returning the new
+// price of each bundle repriced
+func repriceBundles(tx *gorm.DB, productID uint) (map[uint]domain.Money, error) {
+	var bundles []domain.Product
+	if err := tx.Where("bundle_pricing = ?", domain.BundlePricingPercentOff).
+		Where("id = ? OR id IN (?)", productID, tx.Model(&domain.BundleComponent{}).Select("bundle_id").Where("product_id = ?", productID)).
+		Find(&bundles).Error; err != nil {
+		return nil, err
+	}
+
+	prices := make(map[uint]domain.Money, len(bundles))
+	for _, bundle := range bundles {
+		var components []domain.BundleComponent
+		if err := tx.Preload("Product").Where("bundle_id = ?", bundle.ID).Find(&components).Error; err != nil {
+			return nil, err
+		}
+
+		// Components are priced in the bundle's currency
+		componentPrice, err := domain.PercentOffPrice(components, bundle.BundleDiscount)
+		if err != nil {
+			return nil, err
+		}
+		price := domain.Money{Amount: componentPrice.Amount, Currency: bundle.Price.Currency}
+		if err := tx.Model(&domain.Product{}).Where("id = ?", bundle.ID).Update("price_amount", price.Amount).Error; err != nil {
+			return nil, err
+		}
+		prices[bundle.ID] = price
+	}
+
+	return prices, nil
+}
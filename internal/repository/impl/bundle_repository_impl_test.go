@@ -0,0 +1,124 @@
+package impl_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+	"awesomeEcommerce/internal/repository/impl"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// BundleRepositoryTestSuite is a test suite for BundleRepositoryImpl
+type BundleRepositoryTestSuite struct {
+	suite.Suite
+	repo    repository.BundleRepository
+	sqlMock sqlmock.Sqlmock
+	ctx     context.Context
+}
+
+// SetupTest sets up the test suite
+func (s *BundleRepositoryTestSuite) SetupTest() {
+	db, sqlMock := newMockDB(s.T())
+	s.repo = impl.NewBundleRepository(db, newMockCache(s.T()))
+	s.sqlMock = sqlMock
+	s.ctx = context.Background()
+}
+
+// TestFindComponents tests the FindComponents method
+func (s *BundleRepositoryTestSuite) TestFindComponents() {
+	s.Run("Success", func() {
+		// Test case: The components come with their products
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `bundle_components` WHERE bundle_id = ? ORDER BY id")).
+			WithArgs(5).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "bundle_id", "product_id", "quantity"}).AddRow(1, 5, 1, 2))
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `products` WHERE `products`.`id` = ?")).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Component"))
+
+		// Execute
+		components, err := s.repo.FindComponents(s.ctx, 5)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Len(s.T(), components, 1)
+		assert.Equal(s.T(), "Component", components[0].Product.Name)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestSetComponents tests the SetComponents method
+func (s *BundleRepositoryTestSuite) TestSetComponents() {
+	s.Run("Success - Percent Off", func() {
+		// Test case: The components are replaced and the bundle repriced at
+		// 10 percent off their sum
+		bundle := &domain.Product{ID: 5, Price: domain.Money{Amount: 3000, Currency: "EUR"}}
+		s.sqlMock.ExpectBegin()
+		s.sqlMock.ExpectExec(regexp.QuoteMeta("DELETE FROM `bundle_components` WHERE bundle_id = ?")).
+			WithArgs(5).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		s.sqlMock.ExpectExec("INSERT INTO `bundle_components`").WillReturnResult(sqlmock.NewResult(1, 1))
+		s.sqlMock.ExpectExec("INSERT INTO `bundle_components`").WillReturnResult(sqlmock.NewResult(2, 1))
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `products` WHERE bundle_pricing = ? AND (id = ? OR id IN (SELECT `bundle_id` FROM `bundle_components` WHERE product_id = ?))")).
+			WithArgs(domain.BundlePricingPercentOff, 5, 5).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "price_amount", "price_currency", "bundle_pricing", "bundle_discount"}).
+				AddRow(5, 3000, "EUR", domain.BundlePricingPercentOff, 10))
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `bundle_components` WHERE bundle_id = ?")).
+			WithArgs(5).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "bundle_id", "product_id", "quantity"}).
+				AddRow(1, 5, 1, 2).
+				AddRow(2, 5, 2, 1))
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `products` WHERE `products`.`id` IN (?,?)")).
+			WithArgs(1, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "price_amount", "price_currency"}).
+				AddRow(1, 1000, "EUR").
+				AddRow(2, 500, "EUR"))
+		s.sqlMock.ExpectExec(regexp.QuoteMeta("UPDATE `products` SET `price_amount`=?")).
+			WithArgs(2250, sqlmock.AnyArg(), 5).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		s.sqlMock.ExpectCommit()
+
+		// Execute
+		err := s.repo.SetComponents(s.ctx, bundle, []domain.BundleComponent{
+			{ProductID: 1, Quantity: 2},
+			{ProductID: 2, Quantity: 1},
+		})
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), domain.Money{Amount: 2250, Currency: "EUR"}, bundle.Price)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+
+	s.Run("Error - Database Error", func() {
+		// Reset mock
+		s.SetupTest()
+
+		// Test case: The old components are kept when a new one fails
+		expectedError := errors.New("database error")
+		bundle := &domain.Product{ID: 5, Price: domain.Money{Amount: 3000, Currency: "EUR"}}
+		s.sqlMock.ExpectBegin()
+		s.sqlMock.ExpectExec("DELETE FROM `bundle_components`").WillReturnResult(sqlmock.NewResult(0, 1))
+		s.sqlMock.ExpectExec("INSERT INTO `bundle_components`").WillReturnError(expectedError)
+		s.sqlMock.ExpectRollback()
+
+		// Execute
+		err := s.repo.SetComponents(s.ctx, bundle, []domain.BundleComponent{{ProductID: 1, Quantity: 2}})
+
+		// Assert
+		assert.ErrorIs(s.T(), err, expectedError)
+		assert.Equal(s.T(), int64(3000), bundle.Price.Amount)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestBundleRepositorySuite runs the test suite
+func TestBundleRepositorySuite(t *testing.T) {
+	suite.Run(t, new(BundleRepositoryTestSuite))
+}
@@ -37,12 +37,12 @@ func (r *CartRepositoryImpl) FindByID(ctx context.Context, id uint) (*domain.Car
 		var cart domain.Cart
 		if err := json.Unmarshal([]byte(cachedCart), &cart); err == nil {
 			// Get cart items separately
-			if err := r.db.Where("cart_id = ?", id).Find(&cart.Items).Error; err != nil {
+			if err := conn(ctx, r.db).Where("cart_id = ?", id).Find(&cart.Items).Error; err != nil {
 				return nil, err
 			}
 			// Load product details for each cart item
 			for i := range cart.Items {
-				if err := r.db.First(&cart.Items[i].Product, cart.Items[i].ProductID).Error; err != nil {
+				if err := conn(ctx, r.db).First(&cart.Items[i].Product, cart.Items[i].ProductID).Error; err != nil {
 					return nil, err
 				}
 			}
@@ -52,18 +52,18 @@ func (r *CartRepositoryImpl) FindByID(ctx context.Context, id uint) (*domain.Car
 
 	// Cache miss, get from database
 	var cart domain.Cart
-	if err := r.db.First(&cart, id).Error; err != nil {
+	if err := conn(ctx, r.db).First(&cart, id).Error; err != nil {
 		return nil, err
 	}
 
 	// Get cart items
-	if err := r.db.Where("cart_id = ?", id).Find(&cart.Items).Error; err != nil {
+	if err := conn(ctx, r.db).Where("cart_id = ?", id).Find(&cart.Items).Error; err != nil {
 		return nil, err
 	}
 
 	// Load product details for each cart item
 	for i := range cart.Items {
-		if err := r.db.First(&cart.Items[i].Product, cart.Items[i].ProductID).Error; err != nil {
+		if err := conn(ctx, r.db).First(&cart.Items[i].Product, cart.Items[i].ProductID).Error; err != nil {
 			return nil, err
 		}
 	}
@@ -73,7 +73,7 @@ func (r *CartRepositoryImpl) FindByID(ctx context.Context, id uint) (*domain.Car
 	cartCopy.Items = nil
 	cartJSON, err := json.Marshal(cartCopy)
 	if err == nil {
-		r.cache.Set(ctx, cacheKey, cartJSON, 15*time.Minute)
+		afterCommit(ctx, func() { r.cache.Set(ctx, cacheKey, cartJSON, 15*time.Minute) })
 	}
 
 	return &cart, nil
@@ -94,18 +94,18 @@ func (r *CartRepositoryImpl) FindByUserID(ctx context.Context, userID uint) (*do
 
 	// Cache miss, get from database
 	var cart domain.Cart
-	if err := r.db.Where("user_id = ?", userID).First(&cart).Error; err != nil {
+	if err := conn(ctx, r.db).Where("user_id = ?", userID).First(&cart).Error; err != nil {
 		return nil, err
 	}
 
 	// Get cart items
-	if err := r.db.Where("cart_id = ?", cart.ID).Find(&cart.Items).Error; err != nil {
+	if err := conn(ctx, r.db).Where("cart_id = ?", cart.ID).Find(&cart.Items).Error; err != nil {
 		return nil, err
 	}
 
 	// Load product details for each cart item
 	for i := range cart.Items {
-		if err := r.db.First(&cart.Items[i].Product, cart.Items[i].ProductID).Error; err != nil {
+		if err := conn(ctx, r.db).First(&cart.Items[i].Product, cart.Items[i].ProductID).Error; err != nil {
 			return nil, err
 		}
 	}
@@ -113,7 +113,7 @@ func (r *CartRepositoryImpl) FindByUserID(ctx context.Context, userID uint) (*do
 	// Store cart ID in cache for future requests
 	cartIDJSON, err := json.Marshal(cart.ID)
 	if err == nil {
-		r.cache.Set(ctx, cacheKey, cartIDJSON, 15*time.Minute)
+		afterCommit(ctx, func() { r.cache.Set(ctx, cacheKey, cartIDJSON, 15*time.Minute) })
 	}
 
 	return &cart, nil
@@ -121,7 +121,7 @@ func (r *CartRepositoryImpl) FindByUserID(ctx context.Context, userID uint) (*do
 
 // Create creates a new cart
 func (r *CartRepositoryImpl) Create(ctx context.Context, cart *domain.Cart) error {
-	if err := r.db.Create(cart).Error; err != nil {
+	if err := conn(ctx, r.db).Create(cart).Error; err != nil {
 		return err
 	}
 
@@ -129,7 +129,7 @@ func (r *CartRepositoryImpl) Create(ctx context.Context, cart *domain.Cart) erro
 	cacheKey := fmt.Sprintf("cart:user:%d", cart.UserID)
 	cartIDJSON, err := json.Marshal(cart.ID)
 	if err == nil {
-		r.cache.Set(ctx, cacheKey, cartIDJSON, 15*time.Minute)
+		afterCommit(ctx, func() { r.cache.Set(ctx, cacheKey, cartIDJSON, 15*time.Minute) })
 	}
 
 	return nil
@@ -137,13 +137,13 @@ func (r *CartRepositoryImpl) Create(ctx context.Context, cart *domain.Cart) erro
 
 // Update updates an existing cart
 func (r *CartRepositoryImpl) Update(ctx context.Context, cart *domain.Cart) error {
-	if err := r.db.Save(cart).Error; err != nil {
+	if err := conn(ctx, r.db).Save(cart).Error; err != nil {
 		return err
 	}
 
 	// Invalidate cache
 	cacheKey := fmt.Sprintf("cart:%d", cart.ID)
-	r.cache.Delete(ctx, cacheKey)
+	afterCommit(ctx, func() { r.cache.Delete(ctx, cacheKey) })
 
 	return nil
 }
@@ -152,23 +152,23 @@ func (r *CartRepositoryImpl) Update(ctx context.Context, cart *domain.Cart) erro
 func (r *CartRepositoryImpl) Delete(ctx context.Context, id uint) error {
 	// Get the cart to find the user ID
 	var cart domain.Cart
-	if err := r.db.First(&cart, id).Error; err != nil {
+	if err := conn(ctx, r.db).First(&cart, id).Error; err != nil {
 		return err
 	}
 
 	// Delete cart items first
-	if err := r.db.Where("cart_id = ?", id).Delete(&domain.CartItem{}).Error; err != nil {
+	if err := conn(ctx, r.db).Where("cart_id = ?", id).Delete(&domain.CartItem{}).Error; err != nil {
 		return err
 	}
 
 	// Delete the cart
-	if err := r.db.Delete(&domain.Cart{}, id).Error; err != nil {
+	if err := conn(ctx, r.db).Delete(&domain.Cart{}, id).Error; err != nil {
 		return err
 	}
 
 	// Invalidate caches
-	r.cache.Delete(ctx, fmt.Sprintf("cart:%d", id))
-	r.cache.Delete(ctx, fmt.Sprintf("cart:user:%d", cart.UserID))
+	afterCommit(ctx, func() { r.cache.Delete(ctx, fmt.Sprintf("cart:%d", id)) })
+	afterCommit(ctx, func() { r.cache.Delete(ctx, fmt.Sprintf("cart:user:%d", cart.UserID)) })
 
 	return nil
 }
@@ -177,7 +177,7 @@ func (r *CartRepositoryImpl) Delete(ctx context.Context, id uint) error {
 func (r *CartRepositoryImpl) AddItem(ctx context.Context, cartItem *domain.CartItem) error {
 	// Check if the item already exists in the cart
 	var existingItem domain.CartItem
-	err := r.db.Where("cart_id = ? AND product_id = ?", cartItem.CartID, cartItem.ProductID).First(&existingItem).Error
+	err := conn(ctx, r.db).Where("cart_id = ? AND product_id = ?", cartItem.CartID, cartItem.ProductID).First(&existingItem).Error
 	if err == nil {
 		// Item exists, update quantity
 		existingItem.Quantity += cartItem.Quantity
@@ -185,48 +185,72 @@ func (r *CartRepositoryImpl) AddItem(ctx context.Context, cartItem *domain.CartI
 	}
 
 	// Item doesn't exist, create new
-	if err := r.db.Create(cartItem).Error; err != nil {
+	if err := conn(ctx, r.db).Create(cartItem).Error; err != nil {
+		return err
+	}
+	if err := r.touch(ctx, cartItem.CartID); err != nil {
 		return err
 	}
 
 	// Invalidate cache
-	r.cache.Delete(ctx, fmt.Sprintf("cart:%d", cartItem.CartID))
+	afterCommit(ctx, func() { r.cache.Delete(ctx, fmt.Sprintf("cart:%d", cartItem.CartID)) })
 
 	return nil
 }
 
 // UpdateItem updates an item in a cart
 func (r *CartRepositoryImpl) UpdateItem(ctx context.Context, cartItem *domain.CartItem) error {
-	if err := r.db.Save(cartItem).Error; err != nil {
+	if err := conn(ctx, r.db).Save(cartItem).Error; err != nil {
+		return err
+	}
+	if err := r.touch(ctx, cartItem.CartID); err != nil {
 		return err
 	}
 
 	// Invalidate cache
-	r.cache.Delete(ctx, fmt.Sprintf("cart:%d", cartItem.CartID))
+	afterCommit(ctx, func() { r.cache.Delete(ctx, fmt.Sprintf("cart:%d", cartItem.CartID)) })
 
 	return nil
 }
 
 // RemoveItem removes an item from a cart
 func (r *CartRepositoryImpl) RemoveItem(ctx context.Context, cartID, itemID uint) error {
-	if err := r.db.Where("id = ? AND cart_id = ?", itemID, cartID).Delete(&domain.CartItem{}).Error; err != nil {
+	if err := conn(ctx, r.db).Where("id = ? AND cart_id = ?", itemID, cartID).Delete(&domain.CartItem{}).Error; err != nil {
+		return err
+	}
+	if err := r.touch(ctx, cartID); err != nil {
 		return err
 	}
 
 	// Invalidate cache
-	r.cache.Delete(ctx, fmt.Sprintf("cart:%d", cartID))
+	afterCommit(ctx, func() { r.cache.Delete(ctx, fmt.Sprintf("cart:%d", cartID)) })
 
 	return nil
 }
 
-// ClearCart removes all items from a cart
+// ClearCart removes all items and the coupon code from a cart
 func (r *CartRepositoryImpl) ClearCart(ctx context.Context, cartID uint) error {
-	if err := r.db.Where("cart_id = ?", cartID).Delete(&domain.CartItem{}).Error; err != nil {
+	if err := conn(ctx, r.db).Where("cart_id = ?", cartID).Delete(&domain.CartItem{}).Error; err != nil {
+		return err
+	}
+	if err := conn(ctx, r.db).Model(&domain.Cart{}).Where("id = ?", cartID).Update("coupon_code", "").Error; err != nil {
 		return err
 	}
 
 	// Invalidate cache
-	r.cache.Delete(ctx, fmt.Sprintf("cart:%d", cartID))
+	afterCommit(ctx, func() { r.cache.Delete(ctx, fmt.Sprintf("cart:%d", cartID)) })
+
+	return nil
+}
+
+// SetCouponCode applies a coupon code to a cart, or removes it when empty
+func (r *CartRepositoryImpl) SetCouponCode(ctx context.Context, cartID uint, code string) error {
+	if err := conn(ctx, r.db).Model(&domain.Cart{}).Where("id = ?", cartID).Update("coupon_code", code).Error; err != nil {
+		return err
+	}
+
+	// Invalidate cache
+	afterCommit(ctx, func() { r.cache.Delete(ctx, fmt.Sprintf("cart:%d", cartID)) })
 
 	return nil
 }
@@ -234,13 +258,13 @@ func (r *CartRepositoryImpl) ClearCart(ctx context.Context, cartID uint) error {
 // GetCartItems retrieves all items in a cart
 func (r *CartRepositoryImpl) GetCartItems(ctx context.Context, cartID uint) ([]domain.CartItem, error) {
 	var items []domain.CartItem
-	if err := r.db.Where("cart_id = ?", cartID).Find(&items).Error; err != nil {
+	if err := conn(ctx, r.db).Where("cart_id = ?", cartID).Find(&items).Error; err != nil {
 		return nil, err
 	}
 
 	// Load product details for each cart item
 	for i := range items {
-		if err := r.db.First(&items[i].Product, items[i].ProductID).Error; err != nil {
+		if err := conn(ctx, r.db).First(&items[i].Product, items[i].ProductID).Error; err != nil {
 			return nil, err
 		}
 	}
@@ -249,16 +273,37 @@ func (r *CartRepositoryImpl) GetCartItems(ctx context.Context, cartID uint) ([]d
 }
 
 // GetCartTotal calculates the total price of all items in a cart
-func (r *CartRepositoryImpl) GetCartTotal(ctx context.Context, cartID uint) (float64, error) {
+func (r *CartRepositoryImpl) GetCartTotal(ctx context.Context, cartID uint) (domain.Money, error) {
 	items, err := r.GetCartItems(ctx, cartID)
 	if err != nil {
-		return 0, err
+		return domain.Money{}, err
 	}
 
-	var total float64
+	var total domain.Money
 	for _, item := range items {
-		total += item.Product.Price * float64(item.Quantity)
+		if total, err = total.Add(item.Product.Price.Multiply(item.Quantity)); err != nil {
+			return domain.Money{}, err
+		}
 	}
 
 	return total, nil
-}
\ No newline at end of file
+}
+// FindIdle retrieves the carts with items last touched between two times,
+// with their items and products
+func (r *CartRepositoryImpl) FindIdle(ctx context.Context, since, before time.Time) ([]domain.Cart, error) {
+	var carts []domain.Cart
+	if err := conn(ctx, r.db).Where("updated_at > ? AND updated_at <= ?", since, before).
+		Where("EXISTS (SELECT 1 FROM cart_items WHERE cart_items.cart_id = carts.id)").
+		Preload("Items.Product").
+		Order("updated_at ASC").
+		Find(&carts).Error; err != nil {
+		return nil, err
+	}
+	return carts, nil
+}
+
+// touch records that the items of a cart changed, which abandoned cart
+// detection measures inactivity from
+func (r *CartRepositoryImpl) touch(ctx context.Context, cartID uint) error {
+	return conn(ctx, r.db).Model(&domain.Cart{}).Where("id = ?", cartID).Update("updated_at", time.Now()).Error
+}
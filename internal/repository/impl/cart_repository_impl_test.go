@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"awesomeEcommerce/internal/domain"
 	"awesomeEcommerce/internal/repository"
@@ -69,6 +70,11 @@ func (m *MockCartRepository) ClearCart(ctx context.Context, cartID uint) error {
 	return args.Error(0)
 }
 
+func (m *MockCartRepository) SetCouponCode(ctx context.Context, cartID uint, code string) error {
+	args := m.Called(ctx, cartID, code)
+	return args.Error(0)
+}
+
 func (m *MockCartRepository) GetCartItems(ctx context.Context, cartID uint) ([]domain.CartItem, error) {
 	args := m.Called(ctx, cartID)
 	if args.Get(0) == nil {
@@ -77,9 +83,17 @@ func (m *MockCartRepository) GetCartItems(ctx context.Context, cartID uint) ([]d
 	return args.Get(0).([]domain.CartItem), args.Error(1)
 }
 
-func (m *MockCartRepository) GetCartTotal(ctx context.Context, cartID uint) (float64, error) {
+func (m *MockCartRepository) GetCartTotal(ctx context.Context, cartID uint) (domain.Money, error) {
 	args := m.Called(ctx, cartID)
-	return args.Get(0).(float64), args.Error(1)
+	return args.Get(0).(domain.Money), args.Error(1)
+}
+
+func (m *MockCartRepository) FindIdle(ctx context.Context, since, before time.Time) ([]domain.Cart, error) {
+	args := m.Called(ctx, since, before)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Cart), args.Error(1)
 }
 
 // CartRepositoryTestSuite is a test suite for CartRepository
@@ -114,7 +128,7 @@ func (s *CartRepositoryTestSuite) TestFindByID() {
 					Product: domain.Product{
 						ID:    uint(2),
 						Name:  "Test Product",
-						Price: 10.99,
+						Price: usd(1099),
 					},
 				},
 			},
@@ -173,7 +187,7 @@ func (s *CartRepositoryTestSuite) TestFindByUserID() {
 					Product: domain.Product{
 						ID:    uint(2),
 						Name:  "Test Product",
-						Price: 10.99,
+						Price: usd(1099),
 					},
 				},
 			},
@@ -531,7 +545,7 @@ func (s *CartRepositoryTestSuite) TestGetCartItems() {
 				Product: domain.Product{
 					ID:    uint(2),
 					Name:  "Product 1",
-					Price: 10.99,
+					Price: usd(1099),
 				},
 			},
 			{
@@ -542,7 +556,7 @@ func (s *CartRepositoryTestSuite) TestGetCartItems() {
 				Product: domain.Product{
 					ID:    uint(3),
 					Name:  "Product 2",
-					Price: 5.99,
+					Price: usd(599),
 				},
 			},
 		}
@@ -607,7 +621,7 @@ func (s *CartRepositoryTestSuite) TestGetCartTotal() {
 	s.Run("Success", func() {
 		// Test case: Successfully calculate the total price of a cart
 		cartID := uint(1)
-		expectedTotal := 38.96 // (10.99 * 3) + (5.99 * 1) = 38.96
+		expectedTotal := usd(3896) // (10.99 * 3) + (5.99 * 1) = 38.96
 
 		mockRepo.On("GetCartTotal", s.ctx, cartID).Return(expectedTotal, nil).Once()
 
@@ -627,7 +641,7 @@ func (s *CartRepositoryTestSuite) TestGetCartTotal() {
 
 		// Test case: Cart is empty
 		cartID := uint(1)
-		expectedTotal := 0.0
+		expectedTotal := domain.Money{}
 
 		mockRepo.On("GetCartTotal", s.ctx, cartID).Return(expectedTotal, nil).Once()
 
@@ -649,19 +663,43 @@ func (s *CartRepositoryTestSuite) TestGetCartTotal() {
 		cartID := uint(999)
 		expectedError := errors.New("cart not found")
 
-		mockRepo.On("GetCartTotal", s.ctx, cartID).Return(0.0, expectedError).Once()
+		mockRepo.On("GetCartTotal", s.ctx, cartID).Return(domain.Money{}, expectedError).Once()
 
 		// Execute
 		total, err := s.mockRepo.GetCartTotal(s.ctx, cartID)
 
 		// Assert
 		assert.Error(s.T(), err)
-		assert.Equal(s.T(), 0.0, total)
+		assert.Equal(s.T(), domain.Money{}, total)
 		assert.Equal(s.T(), expectedError, err)
 		mockRepo.AssertExpectations(s.T())
 	})
 }
 
+// TestFindIdle tests the FindIdle method
+func (s *CartRepositoryTestSuite) TestFindIdle() {
+	mockRepo := s.mockRepo.(*MockCartRepository)
+
+	s.Run("Success", func() {
+		// Test case: Find the carts left untouched within a window
+		before := time.Now().Add(-time.Hour)
+		since := time.Now().Add(-7 * 24 * time.Hour)
+		expectedCarts := []domain.Cart{
+			{ID: 1, UserID: 1, UpdatedAt: before.Add(-time.Minute), Items: []domain.CartItem{{ID: 1, CartID: 1, ProductID: 1, Quantity: 2}}},
+		}
+
+		mockRepo.On("FindIdle", s.ctx, since, before).Return(expectedCarts, nil).Once()
+
+		// Execute
+		carts, err := s.mockRepo.FindIdle(s.ctx, since, before)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), expectedCarts, carts)
+		mockRepo.AssertExpectations(s.T())
+	})
+}
+
 // TestCartRepositorySuite runs the test suite
 func TestCartRepositorySuite(t *testing.T) {
 	suite.Run(t, new(CartRepositoryTestSuite))
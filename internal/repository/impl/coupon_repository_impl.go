@@ -0,0 +1,157 @@
+package impl
+
+import (
+	"context"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CouponRepositoryImpl implements the CouponRepository interface
+type CouponRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewCouponRepository creates a new CouponRepositoryImpl
+func NewCouponRepository(db *gorm.DB) repository.CouponRepository {
+	return &CouponRepositoryImpl{
+		db: db,
+	}
+}
+
+// FindByID retrieves a coupon by its ID
+func (r *CouponRepositoryImpl) FindByID(ctx context.Context, id uint) (*domain.Coupon, error) {
+	var coupon domain.Coupon
+	if err := withUsedCount(r.db).First(&coupon, id).Error; err != nil {
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+// FindByCode retrieves a coupon by its normalized code
+func (r *CouponRepositoryImpl) FindByCode(ctx context.Context, code string) (*domain.Coupon, error) {
+	var coupon domain.Coupon
+	if err := withUsedCount(r.db).Where("code = ?", code).First(&coupon).Error; err != nil {
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+// FindAll retrieves all coupons with optional pagination
+func (r *CouponRepositoryImpl) FindAll(ctx context.Context, page, pageSize int) ([]domain.Coupon, int64, error) {
+	var coupons []domain.Coupon
+	var total int64
+
+	// Count total records
+	if err := r.db.Model(&domain.Coupon{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Apply pagination
+	offset := (page - 1) * pageSize
+	if err := withUsedCount(r.db).Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&coupons).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return coupons, total, nil
+}
+
+// Create creates a new coupon
+func (r *CouponRepositoryImpl) Create(ctx context.Context, coupon *domain.Coupon) error {
+	return r.db.Create(coupon).Error
+}
+
+// Update updates an existing coupon
+func (r *CouponRepositoryImpl) Update(ctx context.Context, coupon *domain.Coupon) error {
+	return r.db.Save(coupon).Error
+}
+
+// Delete deletes a coupon by its ID
+func (r *CouponRepositoryImpl) Delete(ctx context.Context, id uint) error {
+	return r.db.Delete(&domain.Coupon{}, id).Error
+}
+
+// CountRedemptionsByUser counts the orders of a user that redeemed a coupon
+// and were not cancelled
+func (r *CouponRepositoryImpl) CountRedemptionsByUser(ctx context.Context, couponID, userID uint) (int64, error) {
+	return countRedemptions(r.db, couponID, &userID)
+}
+
+// withUsedCount selects coupons along with the number of orders not
+// cancelled that redeemed them
+func withUsedCount(db *gorm.DB) *gorm.DB {
+	used := db.Model(&domain.CouponRedemption{}).
+		Select("COUNT(*)").
+		Joins("JOIN orders ON orders.id = coupon_redemptions.order_id").
+		Where("coupon_redemptions.coupon_id = coupons.id AND orders.status <> ?", domain.OrderStatusCancelled)
+	return db.Model(&domain.Coupon{}).Select("coupons.*, (?) AS used_count", used)
+}
+
+// countRedemptions counts the orders not cancelled that redeemed a coupon,
+// only those of a user when one is given
+func countRedemptions(db *gorm.DB, couponID uint, userID *uint) (int64, error) {
+	query := db.Model(&domain.CouponRedemption{}).
+		Joins("JOIN orders ON orders.id = coupon_redemptions.order_id").
+		Where("coupon_redemptions.coupon_id = ? AND orders.status <> ?", couponID, domain.OrderStatusCancelled)
+	if userID != nil {
+		query = query.Where("coupon_redemptions.user_id = ?", *userID)
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// redeemCoupon records the coupon of an order within the transaction creating
+// it. The coupon row stays locked until the transaction ends, so concurrent
+// checkouts count its uses one at a time and never exceed its limits.
+func redeemCoupon(tx *gorm.DB, order *domain.Order) error {
+	var coupon domain.Coupon
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id", "code", "usage_limit", "per_user_limit").
+		First(&coupon, *order.CouponID).Error; err != nil {
+		return err
+	}
+
+	if coupon.UsageLimit > 0 {
+		used, err := countRedemptions(tx, coupon.ID, nil)
+		if err != nil {
+			return err
+		}
+		if used >= int64(coupon.UsageLimit) {
+			return &domain.CouponError{Code: coupon.Code, Reason: "coupon usage limit reached"}
+		}
+	}
+	if coupon.PerUserLimit > 0 {
+		used, err := countRedemptions(tx, coupon.ID, &order.UserID)
+		if err != nil {
+			return err
+		}
+		if used >= int64(coupon.PerUserLimit) {
+			return &domain.CouponError{Code: coupon.Code, Reason: "coupon already used the maximum number of times"}
+		}
+	}
+
+	// Record what the coupon took off, apart from the order's promotions
+	discount := domain.Money{Currency: order.DiscountAmount.Currency}
+	for _, line := range order.Discounts {
+		if line.Source == domain.DiscountSourceCoupon {
+			var err error
+			if discount, err = discount.Add(line.Amount); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Create(&domain.CouponRedemption{
+		CouponID: coupon.ID,
+		UserID:   order.UserID,
+		OrderID:  order.ID,
+		Discount: discount,
+	}).Error
+}
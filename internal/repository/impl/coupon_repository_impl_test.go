@@ -0,0 +1,120 @@
+package impl_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+	"awesomeEcommerce/internal/repository/impl"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// CouponRepositoryTestSuite is a test suite for CouponRepositoryImpl
+type CouponRepositoryTestSuite struct {
+	suite.Suite
+	repo    repository.CouponRepository
+	sqlMock sqlmock.Sqlmock
+	ctx     context.Context
+}
+
+// SetupTest sets up the test suite
+func (s *CouponRepositoryTestSuite) SetupTest() {
+	db, sqlMock := newMockDB(s.T())
+	s.repo = impl.NewCouponRepository(db)
+	s.sqlMock = sqlMock
+	s.ctx = context.Background()
+}
+
+// usedCount is the subquery counting the orders not cancelled that redeemed a coupon
+var usedCount = regexp.QuoteMeta("(SELECT COUNT(*) FROM `coupon_redemptions` JOIN orders ON orders.id = coupon_redemptions.order_id WHERE coupon_redemptions.coupon_id = coupons.id AND orders.status <> ?) AS used_count")
+
+// TestFindByCode tests the FindByCode method
+func (s *CouponRepositoryTestSuite) TestFindByCode() {
+	s.Run("Success", func() {
+		// Test case: The coupon comes with the number of orders not cancelled
+		// that redeemed it
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT coupons.*, ")+usedCount+regexp.QuoteMeta(" FROM `coupons` WHERE code = ?")).
+			WithArgs(domain.OrderStatusCancelled, "SAVE10").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "code", "type", "usage_limit", "used_count"}).
+				AddRow(1, "SAVE10", domain.CouponTypePercent, 100, 42))
+
+		// Execute
+		coupon, err := s.repo.FindByCode(s.ctx, "SAVE10")
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), "SAVE10", coupon.Code)
+		assert.Equal(s.T(), 42, coupon.UsedCount)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+
+	s.Run("Error - Not Found", func() {
+		// Reset mock
+		s.SetupTest()
+
+		// Test case: No coupon has the code
+		s.sqlMock.ExpectQuery("FROM `coupons` WHERE code = \\?").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "code"}))
+
+		// Execute
+		coupon, err := s.repo.FindByCode(s.ctx, "UNKNOWN")
+
+		// Assert
+		assert.ErrorIs(s.T(), err, gorm.ErrRecordNotFound)
+		assert.Nil(s.T(), coupon)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestFindAll tests the FindAll method
+func (s *CouponRepositoryTestSuite) TestFindAll() {
+	s.Run("Success", func() {
+		// Test case: A page of coupons, newest first, with their use counts
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `coupons`")).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+		s.sqlMock.ExpectQuery(usedCount + regexp.QuoteMeta(" FROM `coupons` ORDER BY created_at DESC LIMIT 2")).
+			WithArgs(domain.OrderStatusCancelled).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "code", "used_count"}).
+				AddRow(3, "NEW", 0).
+				AddRow(2, "OLD", 7))
+
+		// Execute
+		coupons, total, err := s.repo.FindAll(s.ctx, 1, 2)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), int64(3), total)
+		assert.Len(s.T(), coupons, 2)
+		assert.Equal(s.T(), 7, coupons[1].UsedCount)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestCountRedemptionsByUser tests the CountRedemptionsByUser method
+func (s *CouponRepositoryTestSuite) TestCountRedemptionsByUser() {
+	s.Run("Success", func() {
+		// Test case: Only the user's orders that were not cancelled count
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `coupon_redemptions` JOIN orders ON orders.id = coupon_redemptions.order_id WHERE (coupon_redemptions.coupon_id = ? AND orders.status <> ?) AND coupon_redemptions.user_id = ?")).
+			WithArgs(1, domain.OrderStatusCancelled, 7).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+		// Execute
+		count, err := s.repo.CountRedemptionsByUser(s.ctx, 1, 7)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), int64(2), count)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestCouponRepositorySuite runs the test suite
+func TestCouponRepositorySuite(t *testing.T) {
+	suite.Run(t, new(CouponRepositoryTestSuite))
+}
@@ -0,0 +1,117 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+	"awesomeEcommerce/internal/repository/cache"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DigitalDeliveryRepositoryImpl implements the DigitalDeliveryRepository interface
+type DigitalDeliveryRepositoryImpl struct {
+	db    *gorm.DB
+	cache *cache.RedisClient
+}
+
+// NewDigitalDeliveryRepository creates a new DigitalDeliveryRepositoryImpl
+func NewDigitalDeliveryRepository(db *gorm.DB, cache *cache.RedisClient) repository.DigitalDeliveryRepository {
+	return &DigitalDeliveryRepositoryImpl{
+		db:    db,
+		cache: cache,
+	}
+}
+
+// AddLicenseKeys adds keys to the pool of a product, raising its stock by the number of keys
+func (r *DigitalDeliveryRepositoryImpl) AddLicenseKeys(ctx context.Context, productID uint, keys []string, change domain.StockChange) error {
+	licenseKeys := make([]domain.LicenseKey, 0, len(keys))
+	for _, key := range keys {
+		licenseKeys = append(licenseKeys, domain.LicenseKey{ProductID: productID, Key: key})
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockProductStock(tx, productID); err != nil {
+			return err
+		}
+
+		if err := tx.Create(&licenseKeys).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&domain.Product{}).Where("id = ?", productID).Update("stock", gorm.Expr("stock + ?", len(keys))).Error; err != nil {
+			return err
+		}
+
+		return recordStockChange(tx, productID, len(keys), change)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Invalidate the product and every listing it shows up in
+	r.cache.InvalidateTags(ctx, productTag(productID))
+
+	return nil
+}
+
+// DeliverItem marks an order line delivered, assigning it license keys from
+// the pool when it is delivered by key. The keys were taken from stock when
+// the order was paid, so the pool always has enough unless stock was changed
+// by hand.
+func (r *DigitalDeliveryRepositoryImpl) DeliverItem(ctx context.Context, item *domain.OrderItem, deliveredAt time.Time) ([]domain.LicenseKey, error) {
+	var keys []domain.LicenseKey
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("order_item_id = ?", item.ID).Order("id").Find(&keys).Error; err != nil {
+			return err
+		}
+
+		if item.DeliveryMethod == domain.DeliveryMethodLicenseKey && len(keys) < item.Quantity {
+			// Skip keys other deliveries are assigning so they never get the same key
+			var available []domain.LicenseKey
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+				Where("product_id = ? AND order_item_id IS NULL", item.ProductID).
+				Order("id").
+				Limit(item.Quantity - len(keys)).
+				Find(&available).Error; err != nil {
+				return err
+			}
+			if len(available) < item.Quantity-len(keys) {
+				return fmt.Errorf("not enough license keys for product: %s", item.ProductName)
+			}
+
+			for i := range available {
+				available[i].OrderID = &item.OrderID
+				available[i].OrderItemID = &item.ID
+				available[i].AssignedAt = &deliveredAt
+				if err := tx.Save(&available[i]).Error; err != nil {
+					return err
+				}
+			}
+			keys = append(keys, available...)
+		}
+
+		// A line delivered meanwhile keeps its first delivery time
+		return tx.Model(&domain.OrderItem{}).
+			Where("id = ? AND delivered_at IS NULL", item.ID).
+			Update("delivered_at", deliveredAt).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// FindLicenseKeysByOrderID retrieves the license keys assigned to an order
+func (r *DigitalDeliveryRepositoryImpl) FindLicenseKeysByOrderID(ctx context.Context, orderID uint) ([]domain.LicenseKey, error) {
+	var keys []domain.LicenseKey
+	if err := r.db.Where("order_id = ?", orderID).Order("id").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
@@ -0,0 +1,145 @@
+package impl_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+	"awesomeEcommerce/internal/repository/impl"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// DigitalDeliveryRepositoryTestSuite is a test suite for DigitalDeliveryRepositoryImpl
+type DigitalDeliveryRepositoryTestSuite struct {
+	suite.Suite
+	repo    repository.DigitalDeliveryRepository
+	sqlMock sqlmock.Sqlmock
+	ctx     context.Context
+}
+
+// SetupTest sets up the test suite
+func (s *DigitalDeliveryRepositoryTestSuite) SetupTest() {
+	db, sqlMock := newMockDB(s.T())
+	s.repo = impl.NewDigitalDeliveryRepository(db, newMockCache(s.T()))
+	s.sqlMock = sqlMock
+	s.ctx = context.Background()
+}
+
+// TestAddLicenseKeys tests the AddLicenseKeys method
+func (s *DigitalDeliveryRepositoryTestSuite) TestAddLicenseKeys() {
+	s.Run("Success", func() {
+		// Test case: The keys join the pool and raise the product's stock
+		change := domain.StockChange{Reason: domain.LedgerReasonManualAdjustment, Actor: "user:1"}
+		s.sqlMock.ExpectBegin()
+		s.sqlMock.ExpectQuery("SELECT `id`,`stock` FROM `products` .* FOR UPDATE").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "stock"}).AddRow(1, 0))
+		s.sqlMock.ExpectExec(regexp.QuoteMeta("INSERT INTO `license_keys` (`product_id`,`key`,`order_id`,`order_item_id`,`assigned_at`,`created_at`) VALUES (?,?,?,?,?,?),(?,?,?,?,?,?)")).
+			WillReturnResult(sqlmock.NewResult(1, 2))
+		s.sqlMock.ExpectExec(regexp.QuoteMeta("UPDATE `products` SET `stock`=stock + ?")).
+			WithArgs(2, sqlmock.AnyArg(), 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		s.sqlMock.ExpectQuery("SELECT `id`,`stock` FROM `products`").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "stock"}).AddRow(1, 2))
+		s.sqlMock.ExpectExec("INSERT INTO `inventory_ledger`").WillReturnResult(sqlmock.NewResult(1, 1))
+		s.sqlMock.ExpectCommit()
+
+		// Execute
+		err := s.repo.AddLicenseKeys(s.ctx, 1, []string{"KEY-1", "KEY-2"}, change)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestDeliverItem tests the DeliverItem method
+func (s *DigitalDeliveryRepositoryTestSuite) TestDeliverItem() {
+	deliveredAt := time.Now()
+	keyColumns := []string{"id", "product_id", "key", "order_id", "order_item_id"}
+
+	s.Run("Success", func() {
+		// Test case: A line that already holds one key is topped up from the
+		// unassigned pool, skipping keys other deliveries have locked
+		item := &domain.OrderItem{ID: 10, OrderID: 100, ProductID: 1, ProductName: "Game", Quantity: 3, DeliveryMethod: domain.DeliveryMethodLicenseKey}
+		s.sqlMock.ExpectBegin()
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `license_keys` WHERE order_item_id = ? ORDER BY id")).
+			WithArgs(10).
+			WillReturnRows(sqlmock.NewRows(keyColumns).AddRow(1, 1, "KEY-1", 100, 10))
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `license_keys` WHERE product_id = ? AND order_item_id IS NULL ORDER BY id LIMIT 2 FOR UPDATE SKIP LOCKED")).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(keyColumns).
+				AddRow(2, 1, "KEY-2", nil, nil).
+				AddRow(3, 1, "KEY-3", nil, nil))
+		s.sqlMock.ExpectExec("UPDATE `license_keys` SET").WillReturnResult(sqlmock.NewResult(0, 1))
+		s.sqlMock.ExpectExec("UPDATE `license_keys` SET").WillReturnResult(sqlmock.NewResult(0, 1))
+		s.sqlMock.ExpectExec(regexp.QuoteMeta("UPDATE `order_items` SET `delivered_at`=?,`updated_at`=? WHERE id = ? AND delivered_at IS NULL")).
+			WithArgs(deliveredAt, sqlmock.AnyArg(), 10).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		s.sqlMock.ExpectCommit()
+
+		// Execute
+		keys, err := s.repo.DeliverItem(s.ctx, item, deliveredAt)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Len(s.T(), keys, 3)
+		assert.Equal(s.T(), &item.ID, keys[2].OrderItemID)
+		assert.Equal(s.T(), &deliveredAt, keys[2].AssignedAt)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+
+	s.Run("Error - Not Enough Keys", func() {
+		// Reset mock
+		s.SetupTest()
+
+		// Test case: The pool ran dry, so nothing is assigned
+		item := &domain.OrderItem{ID: 10, OrderID: 100, ProductID: 1, ProductName: "Game", Quantity: 2, DeliveryMethod: domain.DeliveryMethodLicenseKey}
+		s.sqlMock.ExpectBegin()
+		s.sqlMock.ExpectQuery("SELECT \\* FROM `license_keys` WHERE order_item_id = \\?").
+			WillReturnRows(sqlmock.NewRows(keyColumns))
+		s.sqlMock.ExpectQuery("SELECT \\* FROM `license_keys` WHERE product_id = \\?").
+			WillReturnRows(sqlmock.NewRows(keyColumns).AddRow(2, 1, "KEY-2", nil, nil))
+		s.sqlMock.ExpectRollback()
+
+		// Execute
+		keys, err := s.repo.DeliverItem(s.ctx, item, deliveredAt)
+
+		// Assert
+		assert.EqualError(s.T(), err, "not enough license keys for product: Game")
+		assert.Nil(s.T(), keys)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+
+	s.Run("Success - Download", func() {
+		// Reset mock
+		s.SetupTest()
+
+		// Test case: A download line is only marked delivered
+		item := &domain.OrderItem{ID: 11, OrderID: 100, ProductID: 2, Quantity: 1, DeliveryMethod: domain.DeliveryMethodDownload}
+		s.sqlMock.ExpectBegin()
+		s.sqlMock.ExpectQuery("SELECT \\* FROM `license_keys` WHERE order_item_id = \\?").
+			WillReturnRows(sqlmock.NewRows(keyColumns))
+		s.sqlMock.ExpectExec("UPDATE `order_items` SET `delivered_at`").WillReturnResult(sqlmock.NewResult(0, 1))
+		s.sqlMock.ExpectCommit()
+
+		// Execute
+		keys, err := s.repo.DeliverItem(s.ctx, item, deliveredAt)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Empty(s.T(), keys)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestDigitalDeliveryRepositorySuite runs the test suite
+func TestDigitalDeliveryRepositorySuite(t *testing.T) {
+	suite.Run(t, new(DigitalDeliveryRepositoryTestSuite))
+}
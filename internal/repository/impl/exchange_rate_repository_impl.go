@@ -0,0 +1,52 @@
+package impl
+
+import (
+	"context"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ExchangeRateRepositoryImpl implements the ExchangeRateRepository interface
+type ExchangeRateRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewExchangeRateRepository creates a new ExchangeRateRepositoryImpl
+func NewExchangeRateRepository(db *gorm.DB) repository.ExchangeRateRepository {
+	return &ExchangeRateRepositoryImpl{
+		db: db,
+	}
+}
+
+// FindByCurrency retrieves the exchange rate of a currency
+func (r *ExchangeRateRepositoryImpl) FindByCurrency(ctx context.Context, currency string) (*domain.ExchangeRate, error) {
+	var rate domain.ExchangeRate
+	if err := r.db.Where("currency = ?", currency).First(&rate).Error; err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+// FindAll retrieves every exchange rate, ordered by currency
+func (r *ExchangeRateRepositoryImpl) FindAll(ctx context.Context) ([]domain.ExchangeRate, error) {
+	var rates []domain.ExchangeRate
+	if err := r.db.Order("currency").Find(&rates).Error; err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+// Save creates or updates exchange rates
+func (r *ExchangeRateRepositoryImpl) Save(ctx context.Context, rates []domain.ExchangeRate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "currency"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "updated_at"}),
+	}).Create(&rates).Error
+}
@@ -0,0 +1,106 @@
+package impl_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+	"awesomeEcommerce/internal/repository/impl"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// ExchangeRateRepositoryTestSuite is a test suite for ExchangeRateRepositoryImpl
+type ExchangeRateRepositoryTestSuite struct {
+	suite.Suite
+	repo    repository.ExchangeRateRepository
+	sqlMock sqlmock.Sqlmock
+	ctx     context.Context
+}
+
+// SetupTest sets up the test suite
+func (s *ExchangeRateRepositoryTestSuite) SetupTest() {
+	db, sqlMock := newMockDB(s.T())
+	s.repo = impl.NewExchangeRateRepository(db)
+	s.sqlMock = sqlMock
+	s.ctx = context.Background()
+}
+
+// TestFindByCurrency tests the FindByCurrency method
+func (s *ExchangeRateRepositoryTestSuite) TestFindByCurrency() {
+	s.Run("Success", func() {
+		// Test case: The rate of a known currency
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `exchange_rates` WHERE currency = ?")).
+			WithArgs("USD").
+			WillReturnRows(sqlmock.NewRows([]string{"currency", "rate"}).AddRow("USD", 1.08))
+
+		// Execute
+		rate, err := s.repo.FindByCurrency(s.ctx, "USD")
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), 1.08, rate.Rate)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+
+	s.Run("Error - Unknown Currency", func() {
+		// Reset mock
+		s.SetupTest()
+
+		// Test case: No rate is stored for the currency
+		s.sqlMock.ExpectQuery("SELECT \\* FROM `exchange_rates`").
+			WillReturnRows(sqlmock.NewRows([]string{"currency", "rate"}))
+
+		// Execute
+		rate, err := s.repo.FindByCurrency(s.ctx, "XYZ")
+
+		// Assert
+		assert.ErrorIs(s.T(), err, gorm.ErrRecordNotFound)
+		assert.Nil(s.T(), rate)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestSave tests the Save method
+func (s *ExchangeRateRepositoryTestSuite) TestSave() {
+	s.Run("Success", func() {
+		// Test case: The rates are inserted, or updated when they exist
+		s.sqlMock.ExpectExec(regexp.QuoteMeta("INSERT INTO `exchange_rates` (`currency`,`rate`,`updated_at`) VALUES (?,?,?),(?,?,?) ON DUPLICATE KEY UPDATE `rate`=VALUES(`rate`),`updated_at`=VALUES(`updated_at`)")).
+			WithArgs("GBP", 0.85, sqlmock.AnyArg(), "USD", 1.08, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		// Execute
+		err := s.repo.Save(s.ctx, []domain.ExchangeRate{
+			{Currency: "GBP", Rate: 0.85},
+			{Currency: "USD", Rate: 1.08},
+		})
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+
+	s.Run("No Rates", func() {
+		// Reset mock
+		s.SetupTest()
+
+		// Test case: Nothing to save
+
+		// Execute
+		err := s.repo.Save(s.ctx, nil)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestExchangeRateRepositorySuite runs the test suite
+func TestExchangeRateRepositorySuite(t *testing.T) {
+	suite.Run(t, new(ExchangeRateRepositoryTestSuite))
+}
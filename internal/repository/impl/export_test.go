@@ -0,0 +1,8 @@
+package impl
+
+// Conn and AfterCommit expose the unit of work of a context to the tests of
+// TransactorImpl
+var (
+	Conn        = conn
+	AfterCommit = afterCommit
+)
@@ -0,0 +1,139 @@
+package impl
+
+import (
+	"context"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// GiftCardRepositoryImpl implements the GiftCardRepository interface
+type GiftCardRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewGiftCardRepository creates a new GiftCardRepositoryImpl
+func NewGiftCardRepository(db *gorm.DB) repository.GiftCardRepository {
+	return &GiftCardRepositoryImpl{
+		db: db,
+	}
+}
+
+// FindByID retrieves a gift card by its ID
+func (r *GiftCardRepositoryImpl) FindByID(ctx context.Context, id uint) (*domain.GiftCard, error) {
+	var card domain.GiftCard
+	if err := conn(ctx, r.db).First(&card, id).Error; err != nil {
+		return nil, err
+	}
+	return &card, nil
+}
+
+// FindByCode retrieves a gift card by its code
+func (r *GiftCardRepositoryImpl) FindByCode(ctx context.Context, code string) (*domain.GiftCard, error) {
+	var card domain.GiftCard
+	if err := conn(ctx, r.db).Where("code = ?", code).First(&card).Error; err != nil {
+		return nil, err
+	}
+	return &card, nil
+}
+
+// FindByOrderItemID retrieves the gift cards bought with an order line
+func (r *GiftCardRepositoryImpl) FindByOrderItemID(ctx context.Context, orderItemID uint) ([]domain.GiftCard, error) {
+	var cards []domain.GiftCard
+	if err := conn(ctx, r.db).Where("order_item_id = ?", orderItemID).Order("id").Find(&cards).Error; err != nil {
+		return nil, err
+	}
+	return cards, nil
+}
+
+// FindAll retrieves all gift cards with pagination, newest first
+func (r *GiftCardRepositoryImpl) FindAll(ctx context.Context, page, pageSize int) ([]domain.GiftCard, int64, error) {
+	var cards []domain.GiftCard
+	var total int64
+
+	if err := conn(ctx, r.db).Model(&domain.GiftCard{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := conn(ctx, r.db).Order("id DESC").Offset(offset).Limit(pageSize).Find(&cards).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return cards, total, nil
+}
+
+// Create creates a new gift card, recording its value in the balance ledger
+func (r *GiftCardRepositoryImpl) Create(ctx context.Context, card *domain.GiftCard, change domain.BalanceChange) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(card).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&domain.BalanceEntry{
+			GiftCardID:  &card.ID,
+			Delta:       card.Balance,
+			Balance:     card.Balance,
+			Reason:      change.Reason,
+			ReferenceID: change.ReferenceID,
+			Actor:       change.Actor,
+			Note:        change.Note,
+		}).Error
+	})
+}
+
+// Update updates whether a gift card is active, its expiry and its recipient.
+// The balance only changes through Adjust.
+func (r *GiftCardRepositoryImpl) Update(ctx context.Context, card *domain.GiftCard) error {
+	return conn(ctx, r.db).Model(card).Select("active", "expires_at", "recipient_email").Updates(card).Error
+}
+
+// Adjust changes the balance of a gift card by delta and records the change in
+// the balance ledger, failing with domain.ErrInsufficientBalance rather than
+// take the balance below zero
+func (r *GiftCardRepositoryImpl) Adjust(ctx context.Context, id uint, delta domain.Money, change domain.BalanceChange) (*domain.GiftCard, error) {
+	var card domain.GiftCard
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		// The balance guard makes the check and the change one atomic statement,
+		// so concurrent checkouts can never spend the same balance twice
+		result := tx.Model(&domain.GiftCard{}).
+			Where("id = ? AND balance_amount + ? >= 0", id, delta.Amount).
+			Update("balance_amount", gorm.Expr("balance_amount + ?", delta.Amount))
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if err := tx.First(&card, id).Error; err != nil {
+			return err
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrInsufficientBalance
+		}
+
+		return tx.Create(&domain.BalanceEntry{
+			GiftCardID:  &card.ID,
+			Delta:       domain.Money{Amount: delta.Amount, Currency: card.Balance.Currency},
+			Balance:     card.Balance,
+			Reason:      change.Reason,
+			ReferenceID: change.ReferenceID,
+			Actor:       change.Actor,
+			Note:        change.Note,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &card, nil
+}
+
+// FindLedger retrieves the balance ledger of a gift card, newest first
+func (r *GiftCardRepositoryImpl) FindLedger(ctx context.Context, id uint) ([]domain.BalanceEntry, error) {
+	var entries []domain.BalanceEntry
+	if err := conn(ctx, r.db).Where("gift_card_id = ?", id).Order("id DESC").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
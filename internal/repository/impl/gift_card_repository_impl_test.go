@@ -0,0 +1,137 @@
+package impl_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+	"awesomeEcommerce/internal/repository/impl"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// GiftCardRepositoryTestSuite is a test suite for GiftCardRepositoryImpl
+type GiftCardRepositoryTestSuite struct {
+	suite.Suite
+	repo    repository.GiftCardRepository
+	sqlMock sqlmock.Sqlmock
+	ctx     context.Context
+}
+
+// SetupTest sets up the test suite
+func (s *GiftCardRepositoryTestSuite) SetupTest() {
+	db, sqlMock := newMockDB(s.T())
+	s.repo = impl.NewGiftCardRepository(db)
+	s.sqlMock = sqlMock
+	s.ctx = context.Background()
+}
+
+// giftCardColumns are the columns of a gift card row returned by the tests
+var giftCardColumns = []string{"id", "code", "balance_amount", "balance_currency", "active"}
+
+// TestCreate tests the Create method
+func (s *GiftCardRepositoryTestSuite) TestCreate() {
+	s.Run("Success", func() {
+		// Test case: The card's value is recorded as its first ledger entry
+		card := &domain.GiftCard{
+			Code:         "GIFT1234",
+			InitialValue: domain.Money{Amount: 5000, Currency: "EUR"},
+			Balance:      domain.Money{Amount: 5000, Currency: "EUR"},
+			Source:       domain.GiftCardSourceAdmin,
+			Active:       true,
+		}
+		change := domain.BalanceChange{Reason: domain.BalanceReasonIssue, Actor: "user:1"}
+		s.sqlMock.ExpectBegin()
+		s.sqlMock.ExpectExec("INSERT INTO `gift_cards`").WillReturnResult(sqlmock.NewResult(3, 1))
+		s.sqlMock.ExpectExec("INSERT INTO `balance_ledger`").
+			WithArgs(uint(3), nil, int64(5000), "EUR", int64(5000), "EUR", domain.BalanceReasonIssue, uint(0), "user:1", "", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		s.sqlMock.ExpectCommit()
+
+		// Execute
+		err := s.repo.Create(s.ctx, card, change)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), uint(3), card.ID)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestUpdate tests the Update method
+func (s *GiftCardRepositoryTestSuite) TestUpdate() {
+	s.Run("Success", func() {
+		// Test case: Only the card's state, expiry and recipient are written,
+		// never its balance
+		card := &domain.GiftCard{ID: 3, Balance: domain.Money{Amount: 1, Currency: "EUR"}, RecipientEmail: "friend@example.com"}
+		s.sqlMock.ExpectExec(regexp.QuoteMeta("UPDATE `gift_cards` SET `recipient_email`=?,`expires_at`=?,`active`=?,`updated_at`=? WHERE `id` = ?")).
+			WithArgs("friend@example.com", nil, false, sqlmock.AnyArg(), 3).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		// Execute
+		err := s.repo.Update(s.ctx, card)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestAdjust tests the Adjust method
+func (s *GiftCardRepositoryTestSuite) TestAdjust() {
+	change := domain.BalanceChange{Reason: domain.BalanceReasonRedemption, ReferenceID: 9}
+
+	s.Run("Success", func() {
+		// Test case: The balance is taken down only if it covers the amount,
+		// and the new balance is recorded in the ledger
+		s.sqlMock.ExpectBegin()
+		s.sqlMock.ExpectExec(regexp.QuoteMeta("UPDATE `gift_cards` SET `balance_amount`=balance_amount + ?,`updated_at`=? WHERE id = ? AND balance_amount + ? >= 0")).
+			WithArgs(-2000, sqlmock.AnyArg(), 3, -2000).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `gift_cards` WHERE `gift_cards`.`id` = ?")).
+			WithArgs(3).
+			WillReturnRows(sqlmock.NewRows(giftCardColumns).AddRow(3, "GIFT1234", 3000, "EUR", true))
+		s.sqlMock.ExpectExec("INSERT INTO `balance_ledger`").
+			WithArgs(uint(3), nil, int64(-2000), "EUR", int64(3000), "EUR", domain.BalanceReasonRedemption, uint(9), "", "", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		s.sqlMock.ExpectCommit()
+
+		// Execute
+		card, err := s.repo.Adjust(s.ctx, 3, domain.Money{Amount: -2000, Currency: "EUR"}, change)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), int64(3000), card.Balance.Amount)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+
+	s.Run("Error - Insufficient Balance", func() {
+		// Reset mock
+		s.SetupTest()
+
+		// Test case: The balance does not cover the amount, so nothing changes
+		s.sqlMock.ExpectBegin()
+		s.sqlMock.ExpectExec("UPDATE `gift_cards` SET `balance_amount`=balance_amount \\+ \\?").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		s.sqlMock.ExpectQuery("SELECT \\* FROM `gift_cards`").
+			WillReturnRows(sqlmock.NewRows(giftCardColumns).AddRow(3, "GIFT1234", 1000, "EUR", true))
+		s.sqlMock.ExpectRollback()
+
+		// Execute
+		card, err := s.repo.Adjust(s.ctx, 3, domain.Money{Amount: -2000, Currency: "EUR"}, change)
+
+		// Assert
+		assert.ErrorIs(s.T(), err, domain.ErrInsufficientBalance)
+		assert.Nil(s.T(), card)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestGiftCardRepositorySuite runs the test suite
+func TestGiftCardRepositorySuite(t *testing.T) {
+	suite.Run(t, new(GiftCardRepositoryTestSuite))
+}
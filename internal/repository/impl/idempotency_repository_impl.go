@@ -0,0 +1,109 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+	"awesomeEcommerce/internal/repository/cache"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// IdempotencyRepositoryImpl implements the IdempotencyRepository interface.
+// Records only live in Redis, expiring with their keys.
+type IdempotencyRepositoryImpl struct {
+	cache *cache.RedisClient
+}
+
+// NewIdempotencyRepository creates a new IdempotencyRepositoryImpl
+func NewIdempotencyRepository(cache *cache.RedisClient) repository.IdempotencyRepository {
+	return &IdempotencyRepositoryImpl{
+		cache: cache,
+	}
+}
+
+// updateHeldIdempotencyKey replaces the record of an idempotency key (ARGV[2])
+// for ARGV[3] milliseconds if the key is held by the token in ARGV[1]
+var updateHeldIdempotencyKey = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if not current or cjson.decode(current).token ~= ARGV[1] then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+return 1
+`)
+
+// deleteHeldIdempotencyKey deletes the record of an idempotency key if the key
+// is held by the token in ARGV[1]
+var deleteHeldIdempotencyKey = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if not current or cjson.decode(current).token ~= ARGV[1] then
+	return 0
+end
+return redis.call('DEL', KEYS[1])
+`)
+
+// idempotencyKey returns the Redis key holding the record of an idempotency key
+func idempotencyKey(key string) string {
+	return fmt.Sprintf("idempotency:%s", key)
+}
+
+// FindByKey retrieves the record of an idempotency key, or nil if it has none
+func (r *IdempotencyRepositoryImpl) FindByKey(ctx context.Context, key string) (*domain.IdempotencyRecord, error) {
+	cached, err := r.cache.Get(ctx, idempotencyKey(key))
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var record domain.IdempotencyRecord
+	if err := json.Unmarshal([]byte(cached), &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Create stores the record of an idempotency key for ttl unless the key
+// already has one, reporting whether it was stored. Only one of concurrent
+// requests with the same key stores its record.
+func (r *IdempotencyRepositoryImpl) Create(ctx context.Context, record *domain.IdempotencyRecord, ttl time.Duration) (bool, error) {
+	recordJSON, err := json.Marshal(record)
+	if err != nil {
+		return false, err
+	}
+	return r.cache.SetNX(ctx, idempotencyKey(record.Key), recordJSON, ttl)
+}
+
+// Update replaces the record of an idempotency key, keeping it for ttl. The
+// token is checked and the record replaced in one script, so a request whose
+// lock expired cannot overwrite the record of the request that took the key
+// over.
+func (r *IdempotencyRepositoryImpl) Update(ctx context.Context, record *domain.IdempotencyRecord, ttl time.Duration) error {
+	recordJSON, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	updated, err := r.cache.RunScript(ctx, updateHeldIdempotencyKey, []string{idempotencyKey(record.Key)},
+		record.Token, recordJSON, ttl.Milliseconds())
+	if err != nil {
+		return err
+	}
+	if updated.(int64) == 0 {
+		return domain.ErrIdempotencyKeyLockLost
+	}
+	return nil
+}
+
+// Delete deletes the record of an idempotency key if it is still held by the
+// request with the token
+func (r *IdempotencyRepositoryImpl) Delete(ctx context.Context, key, token string) error {
+	_, err := r.cache.RunScript(ctx, deleteHeldIdempotencyKey, []string{idempotencyKey(key)}, token)
+	return err
+}
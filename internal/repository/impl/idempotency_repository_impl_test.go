@@ -0,0 +1,166 @@
+package impl_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+	"awesomeEcommerce/internal/repository/impl"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// IdempotencyRepositoryTestSuite is a test suite for IdempotencyRepositoryImpl
+type IdempotencyRepositoryTestSuite struct {
+	suite.Suite
+	repo repository.IdempotencyRepository
+	ctx  context.Context
+}
+
+// SetupTest sets up the test suite
+func (s *IdempotencyRepositoryTestSuite) SetupTest() {
+	s.repo = impl.NewIdempotencyRepository(newMockCache(s.T()))
+	s.ctx = context.Background()
+}
+
+// lockRecord returns the record of a request holding key under token
+func lockRecord(key, token string) *domain.IdempotencyRecord {
+	return &domain.IdempotencyRecord{Key: key, Fingerprint: "abc", Token: token}
+}
+
+// TestCreate tests the Create method
+func (s *IdempotencyRepositoryTestSuite) TestCreate() {
+	s.Run("Success", func() {
+		// Test case: Only the first request with the key stores its record
+		created, err := s.repo.Create(s.ctx, lockRecord("user:1:order-1", "t1"), time.Minute)
+		assert.NoError(s.T(), err)
+		assert.True(s.T(), created)
+
+		// Execute
+		created, err = s.repo.Create(s.ctx, lockRecord("user:1:order-1", "t2"), time.Minute)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.False(s.T(), created)
+		record, err := s.repo.FindByKey(s.ctx, "user:1:order-1")
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), "t1", record.Token)
+	})
+}
+
+// TestFindByKey tests the FindByKey method
+func (s *IdempotencyRepositoryTestSuite) TestFindByKey() {
+	s.Run("Not Found", func() {
+		// Execute
+		record, err := s.repo.FindByKey(s.ctx, "user:1:unknown")
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Nil(s.T(), record)
+	})
+}
+
+// TestUpdate tests the Update method
+func (s *IdempotencyRepositoryTestSuite) TestUpdate() {
+	response := &domain.IdempotentResponse{StatusCode: 201, ContentType: "application/json", Body: []byte(`{"id":1}`)}
+
+	s.Run("Success", func() {
+		// Test case: The request holding the key stores its response
+		_, err := s.repo.Create(s.ctx, lockRecord("user:1:order-1", "t1"), time.Minute)
+		assert.NoError(s.T(), err)
+		completed := lockRecord("user:1:order-1", "t1")
+		completed.Response = response
+
+		// Execute
+		err = s.repo.Update(s.ctx, completed, 24*time.Hour)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		record, err := s.repo.FindByKey(s.ctx, "user:1:order-1")
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), response, record.Response)
+	})
+
+	s.Run("Error - Lock Lost", func() {
+		// Reset mock
+		s.SetupTest()
+
+		// Test case: Another request took the key over after the lock expired
+		_, err := s.repo.Create(s.ctx, lockRecord("user:1:order-1", "t2"), time.Minute)
+		assert.NoError(s.T(), err)
+		completed := lockRecord("user:1:order-1", "t1")
+		completed.Response = response
+
+		// Execute
+		err = s.repo.Update(s.ctx, completed, 24*time.Hour)
+
+		// Assert
+		assert.ErrorIs(s.T(), err, domain.ErrIdempotencyKeyLockLost)
+		record, err := s.repo.FindByKey(s.ctx, "user:1:order-1")
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), "t2", record.Token)
+		assert.False(s.T(), record.IsCompleted())
+	})
+
+	s.Run("Error - Lock Expired", func() {
+		// Reset mock
+		s.SetupTest()
+
+		// Test case: The key has no record left to replace
+		completed := lockRecord("user:1:order-1", "t1")
+		completed.Response = response
+
+		// Execute
+		err := s.repo.Update(s.ctx, completed, 24*time.Hour)
+
+		// Assert
+		assert.ErrorIs(s.T(), err, domain.ErrIdempotencyKeyLockLost)
+		record, err := s.repo.FindByKey(s.ctx, "user:1:order-1")
+		assert.NoError(s.T(), err)
+		assert.Nil(s.T(), record)
+	})
+}
+
+// TestDelete tests the Delete method
+func (s *IdempotencyRepositoryTestSuite) TestDelete() {
+	s.Run("Success", func() {
+		// Test case: The request holding the key releases it
+		_, err := s.repo.Create(s.ctx, lockRecord("user:1:order-1", "t1"), time.Minute)
+		assert.NoError(s.T(), err)
+
+		// Execute
+		err = s.repo.Delete(s.ctx, "user:1:order-1", "t1")
+
+		// Assert
+		assert.NoError(s.T(), err)
+		record, err := s.repo.FindByKey(s.ctx, "user:1:order-1")
+		assert.NoError(s.T(), err)
+		assert.Nil(s.T(), record)
+	})
+
+	s.Run("Success - Held By Another Request", func() {
+		// Reset mock
+		s.SetupTest()
+
+		// Test case: A request whose lock expired leaves the new holder's record
+		_, err := s.repo.Create(s.ctx, lockRecord("user:1:order-1", "t2"), time.Minute)
+		assert.NoError(s.T(), err)
+
+		// Execute
+		err = s.repo.Delete(s.ctx, "user:1:order-1", "t1")
+
+		// Assert
+		assert.NoError(s.T(), err)
+		record, err := s.repo.FindByKey(s.ctx, "user:1:order-1")
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), "t2", record.Token)
+	})
+}
+
+// TestIdempotencyRepositorySuite runs the test suite
+func TestIdempotencyRepositorySuite(t *testing.T) {
+	suite.Run(t, new(IdempotencyRepositoryTestSuite))
+}
@@ -0,0 +1,97 @@
+package impl
+
+import (
+	"context"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// InventoryLedgerRepositoryImpl implements the InventoryLedgerRepository interface
+type InventoryLedgerRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewInventoryLedgerRepository creates a new InventoryLedgerRepositoryImpl
+func NewInventoryLedgerRepository(db *gorm.DB) repository.InventoryLedgerRepository {
+	return &InventoryLedgerRepositoryImpl{
+		db: db,
+	}
+}
+
+// FindByProductID retrieves the stock movements of a product, newest first, with optional pagination
+func (r *InventoryLedgerRepositoryImpl) FindByProductID(ctx context.Context, productID uint, page, pageSize int) ([]domain.InventoryLedgerEntry, int64, error) {
+	var entries []domain.InventoryLedgerEntry
+	var total int64
+
+	// Count total records
+	if err := r.db.Model(&domain.InventoryLedgerEntry{}).Where("product_id = ?", productID).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Apply pagination
+	offset := (page - 1) * pageSize
+	if err := r.db.Where("product_id = ?", productID).Order("id DESC").Offset(offset).Limit(pageSize).Find(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
+
+// Reconcile recomputes the stock of every product from its ledger and
+// returns the products whose stock differs. Products that had stock before
+// the ledger existed start from the balance preceding their first entry.
+func (r *InventoryLedgerRepositoryImpl) Reconcile(ctx context.Context) ([]domain.StockDiscrepancy, error) {
+	var discrepancies []domain.StockDiscrepancy
+	err := r.db.Raw(`
+		SELECT p.id AS product_id, p.name AS product_name, p.stock AS stock,
+			f.balance - f.delta + s.total_delta AS ledger_stock
+		FROM products p
+		JOIN (
+			SELECT product_id, MIN(id) AS first_id, SUM(delta) AS total_delta
+			FROM inventory_ledger
+			GROUP BY product_id
+		) s ON s.product_id = p.id
+		JOIN inventory_ledger f ON f.id = s.first_id
+		WHERE p.stock <> f.balance - f.delta + s.total_delta
+		ORDER BY p.id`).Scan(&discrepancies).Error
+	if err != nil {
+		return nil, err
+	}
+	return discrepancies, nil
+}
+
+// recordStockChange appends a stock movement of a product to the inventory
+// ledger. It must run in the transaction that applied the delta, after the
+// update, so the recorded balance is the one the change produced.
+func recordStockChange(tx *gorm.DB, productID uint, delta int, change domain.StockChange) error {
+	if delta == 0 {
+		return nil
+	}
+
+	var product domain.Product
+	if err := tx.Select("id", "stock").First(&product, productID).Error; err != nil {
+		return err
+	}
+
+	return tx.Create(&domain.InventoryLedgerEntry{
+		ProductID:   productID,
+		Delta:       delta,
+		Balance:     product.Stock,
+		Reason:      change.Reason,
+		ReferenceID: change.ReferenceID,
+		Actor:       change.Actor,
+	}).Error
+}
+
+// lockProductStock reads the stock of a product, locking its row until the transaction ends
+func lockProductStock(tx *gorm.DB, productID uint) (int, error) {
+	var product domain.Product
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "stock").First(&product, productID).Error; err != nil {
+		return 0, err
+	}
+	return product.Stock, nil
+}
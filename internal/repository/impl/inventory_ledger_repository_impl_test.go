@@ -0,0 +1,101 @@
+package impl_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+	"awesomeEcommerce/internal/repository/impl"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// InventoryLedgerRepositoryTestSuite is a test suite for InventoryLedgerRepositoryImpl
+type InventoryLedgerRepositoryTestSuite struct {
+	suite.Suite
+	repo    repository.InventoryLedgerRepository
+	sqlMock sqlmock.Sqlmock
+	ctx     context.Context
+}
+
+// SetupTest sets up the test suite
+func (s *InventoryLedgerRepositoryTestSuite) SetupTest() {
+	db, sqlMock := newMockDB(s.T())
+	s.repo = impl.NewInventoryLedgerRepository(db)
+	s.sqlMock = sqlMock
+	s.ctx = context.Background()
+}
+
+// TestFindByProductID tests the FindByProductID method
+func (s *InventoryLedgerRepositoryTestSuite) TestFindByProductID() {
+	s.Run("Success", func() {
+		// Test case: The second page of movements, newest first
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `inventory_ledger` WHERE product_id = ?")).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(12))
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `inventory_ledger` WHERE product_id = ? ORDER BY id DESC LIMIT 10 OFFSET 10")).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "product_id", "delta", "balance", "reason"}).
+				AddRow(2, 1, -1, 9, domain.LedgerReasonOrder).
+				AddRow(1, 1, 10, 10, domain.LedgerReasonManualAdjustment))
+
+		// Execute
+		entries, total, err := s.repo.FindByProductID(s.ctx, 1, 2, 10)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), int64(12), total)
+		assert.Len(s.T(), entries, 2)
+		assert.Equal(s.T(), uint(2), entries[0].ID)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+
+	s.Run("Error - Database Error", func() {
+		// Reset mock
+		s.SetupTest()
+
+		// Test case: Database error
+		expectedError := errors.New("database error")
+		s.sqlMock.ExpectQuery("SELECT count\\(\\*\\) FROM `inventory_ledger`").WillReturnError(expectedError)
+
+		// Execute
+		entries, total, err := s.repo.FindByProductID(s.ctx, 1, 1, 10)
+
+		// Assert
+		assert.ErrorIs(s.T(), err, expectedError)
+		assert.Nil(s.T(), entries)
+		assert.Zero(s.T(), total)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestReconcile tests the Reconcile method
+func (s *InventoryLedgerRepositoryTestSuite) TestReconcile() {
+	s.Run("Success", func() {
+		// Test case: Products whose stock differs from the balance before their
+		// first entry plus every movement since are reported
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("WHERE p.stock <> f.balance - f.delta + s.total_delta")).
+			WillReturnRows(sqlmock.NewRows([]string{"product_id", "product_name", "stock", "ledger_stock"}).
+				AddRow(1, "Product 1", 7, 9))
+
+		// Execute
+		discrepancies, err := s.repo.Reconcile(s.ctx)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), []domain.StockDiscrepancy{
+			{ProductID: 1, ProductName: "Product 1", Stock: 7, LedgerStock: 9},
+		}, discrepancies)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestInventoryLedgerRepositorySuite runs the test suite
+func TestInventoryLedgerRepositorySuite(t *testing.T) {
+	suite.Run(t, new(InventoryLedgerRepositoryTestSuite))
+}
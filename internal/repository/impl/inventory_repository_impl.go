@@ -0,0 +1,205 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+	"awesomeEcommerce/internal/repository/cache"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// InventoryRepositoryImpl implements the InventoryRepository interface
+type InventoryRepositoryImpl struct {
+	db    *gorm.DB
+	cache *cache.RedisClient
+}
+
+// NewInventoryRepository creates a new InventoryRepositoryImpl
+func NewInventoryRepository(db *gorm.DB, cache *cache.RedisClient) repository.InventoryRepository {
+	return &InventoryRepositoryImpl{
+		db:    db,
+		cache: cache,
+	}
+}
+
+// FindWarehouses retrieves all warehouses
+func (r *InventoryRepositoryImpl) FindWarehouses(ctx context.Context) ([]domain.Warehouse, error) {
+	var warehouses []domain.Warehouse
+	if err := conn(ctx, r.db).Order("priority ASC, id ASC").Find(&warehouses).Error; err != nil {
+		return nil, err
+	}
+	return warehouses, nil
+}
+
+// FindWarehouseByID retrieves a warehouse by its ID
+func (r *InventoryRepositoryImpl) FindWarehouseByID(ctx context.Context, id uint) (*domain.Warehouse, error) {
+	var warehouse domain.Warehouse
+	if err := conn(ctx, r.db).First(&warehouse, id).Error; err != nil {
+		return nil, err
+	}
+	return &warehouse, nil
+}
+
+// CreateWarehouse creates a new warehouse
+func (r *InventoryRepositoryImpl) CreateWarehouse(ctx context.Context, warehouse *domain.Warehouse) error {
+	return conn(ctx, r.db).Create(warehouse).Error
+}
+
+// UpdateWarehouse updates an existing warehouse
+func (r *InventoryRepositoryImpl) UpdateWarehouse(ctx context.Context, warehouse *domain.Warehouse) error {
+	return conn(ctx, r.db).Save(warehouse).Error
+}
+
+// FindLevelsByProductID retrieves the stock of a product at every warehouse
+func (r *InventoryRepositoryImpl) FindLevelsByProductID(ctx context.Context, productID uint) ([]domain.InventoryLevel, error) {
+	var levels []domain.InventoryLevel
+	if err := conn(ctx, r.db).Preload("Warehouse").Where("product_id = ?", productID).Find(&levels).Error; err != nil {
+		return nil, err
+	}
+	return levels, nil
+}
+
+// SetLevel sets the stock of a product at a warehouse and resyncs the product's total stock
+func (r *InventoryRepositoryImpl) SetLevel(ctx context.Context, productID, warehouseID uint, quantity int) error {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		level := &domain.InventoryLevel{
+			ProductID:   productID,
+			WarehouseID: warehouseID,
+			Quantity:    quantity,
+		}
+		if err := tx.Omit("Warehouse").Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "product_id"}, {Name: "warehouse_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"quantity", "updated_at"}),
+		}).Create(level).Error; err != nil {
+			return err
+		}
+
+		return syncProductStock(tx, productID, domain.StockChangeFromContext(ctx))
+	})
+	if err != nil {
+		return err
+	}
+
+	// Invalidate the product and every listing it shows up in
+	afterCommit(ctx, func() { r.cache.InvalidateTags(ctx, productTag(productID)) })
+
+	return nil
+}
+
+// Transfer moves stock of a product between warehouses
+func (r *InventoryRepositoryImpl) Transfer(ctx context.Context, transfer *domain.InventoryTransfer) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		// Take the stock out of the source warehouse if it has enough
+		result := tx.Model(&domain.InventoryLevel{}).
+			Where("product_id = ? AND warehouse_id = ? AND quantity >= ?", transfer.ProductID, transfer.FromWarehouseID, transfer.Quantity).
+			Update("quantity", gorm.Expr("quantity - ?", transfer.Quantity))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("insufficient stock at warehouse %d", transfer.FromWarehouseID)
+		}
+
+		// Put it into the destination warehouse
+		level := &domain.InventoryLevel{
+			ProductID:   transfer.ProductID,
+			WarehouseID: transfer.ToWarehouseID,
+			Quantity:    transfer.Quantity,
+		}
+		if err := tx.Omit("Warehouse").Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "product_id"}, {Name: "warehouse_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"quantity":   gorm.Expr("quantity + ?", transfer.Quantity),
+				"updated_at": gorm.Expr("CURRENT_TIMESTAMP"),
+			}),
+		}).Create(level).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(transfer).Error
+	})
+}
+
+// Allocate reserves stock at warehouses for an order, all or nothing
+func (r *InventoryRepositoryImpl) Allocate(ctx context.Context, allocations []domain.OrderAllocation) error {
+	if len(allocations) == 0 {
+		return nil
+	}
+
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		for i := range allocations {
+			allocation := &allocations[i]
+
+			result := tx.Model(&domain.InventoryLevel{}).
+				Where("product_id = ? AND warehouse_id = ? AND quantity >= ?", allocation.ProductID, allocation.WarehouseID, allocation.Quantity).
+				Update("quantity", gorm.Expr("quantity - ?", allocation.Quantity))
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return fmt.Errorf("insufficient stock for product %d at warehouse %d", allocation.ProductID, allocation.WarehouseID)
+			}
+
+			if err := tx.Create(allocation).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// FindAllocationsByOrderID retrieves the stock reserved for an order
+func (r *InventoryRepositoryImpl) FindAllocationsByOrderID(ctx context.Context, orderID uint) ([]domain.OrderAllocation, error) {
+	var allocations []domain.OrderAllocation
+	if err := conn(ctx, r.db).Where("order_id = ?", orderID).Find(&allocations).Error; err != nil {
+		return nil, err
+	}
+	return allocations, nil
+}
+
+// ReleaseAllocations returns the stock reserved for an order to its warehouses
+func (r *InventoryRepositoryImpl) ReleaseAllocations(ctx context.Context, orderID uint) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var allocations []domain.OrderAllocation
+		if err := tx.Where("order_id = ?", orderID).Find(&allocations).Error; err != nil {
+			return err
+		}
+
+		for _, allocation := range allocations {
+			if err := tx.Model(&domain.InventoryLevel{}).
+				Where("product_id = ? AND warehouse_id = ?", allocation.ProductID, allocation.WarehouseID).
+				Update("quantity", gorm.Expr("quantity + ?", allocation.Quantity)).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Where("order_id = ?", orderID).Delete(&domain.OrderAllocation{}).Error
+	})
+}
+
+// syncProductStock sets a product's stock to the sum of its warehouse levels
+// and records the difference in the inventory ledger
+func syncProductStock(tx *gorm.DB, productID uint, change domain.StockChange) error {
+	stock, err := lockProductStock(tx, productID)
+	if err != nil {
+		return err
+	}
+
+	var total int
+	if err := tx.Model(&domain.InventoryLevel{}).
+		Select("COALESCE(SUM(quantity), 0)").
+		Where("product_id = ?", productID).
+		Scan(&total).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Model(&domain.Product{}).Where("id = ?", productID).Update("stock", total).Error; err != nil {
+		return err
+	}
+
+	return recordStockChange(tx, productID, total-stock, change)
+}
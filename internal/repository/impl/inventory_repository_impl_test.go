@@ -0,0 +1,251 @@
+package impl_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+	"awesomeEcommerce/internal/repository/impl"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// InventoryRepositoryTestSuite is a test suite for InventoryRepositoryImpl
+type InventoryRepositoryTestSuite struct {
+	suite.Suite
+	repo    repository.InventoryRepository
+	sqlMock sqlmock.Sqlmock
+	ctx     context.Context
+}
+
+// SetupTest sets up the test suite
+func (s *InventoryRepositoryTestSuite) SetupTest() {
+	db, sqlMock := newMockDB(s.T())
+	s.repo = impl.NewInventoryRepository(db, newMockCache(s.T()))
+	s.sqlMock = sqlMock
+	s.ctx = context.Background()
+}
+
+// TestFindLevelsByProductID tests the FindLevelsByProductID method
+func (s *InventoryRepositoryTestSuite) TestFindLevelsByProductID() {
+	s.Run("Success", func() {
+		// Test case: The levels come with their warehouses
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `inventory_levels` WHERE product_id = ?")).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "product_id", "warehouse_id", "quantity"}).
+				AddRow(1, 1, 1, 5).
+				AddRow(2, 1, 2, 3))
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `warehouses` WHERE `warehouses`.`id` IN (?,?)")).
+			WithArgs(1, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "code"}).AddRow(1, "EU-1").AddRow(2, "US-1"))
+
+		// Execute
+		levels, err := s.repo.FindLevelsByProductID(s.ctx, 1)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Len(s.T(), levels, 2)
+		assert.Equal(s.T(), "US-1", levels[1].Warehouse.Code)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+
+	s.Run("Error - Database Error", func() {
+		// Reset mock
+		s.SetupTest()
+
+		// Test case: Database error
+		expectedError := errors.New("database error")
+		s.sqlMock.ExpectQuery("SELECT \\* FROM `inventory_levels`").WillReturnError(expectedError)
+
+		// Execute
+		levels, err := s.repo.FindLevelsByProductID(s.ctx, 1)
+
+		// Assert
+		assert.ErrorIs(s.T(), err, expectedError)
+		assert.Nil(s.T(), levels)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestSetLevel tests the SetLevel method
+func (s *InventoryRepositoryTestSuite) TestSetLevel() {
+	s.Run("Success", func() {
+		// Test case: The level is upserted and the product's stock resynced to
+		// the sum of its levels, with the difference recorded in the ledger
+		s.sqlMock.ExpectBegin()
+		s.sqlMock.ExpectExec(regexp.QuoteMeta("INSERT INTO `inventory_levels`") + ".*" +
+			regexp.QuoteMeta("ON DUPLICATE KEY UPDATE `quantity`=VALUES(`quantity`),`updated_at`=VALUES(`updated_at`)")).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		s.sqlMock.ExpectQuery("SELECT `id`,`stock` FROM `products` .* FOR UPDATE").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "stock"}).AddRow(1, 10))
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(SUM(quantity), 0) FROM `inventory_levels` WHERE product_id = ?")).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(15))
+		s.sqlMock.ExpectExec("UPDATE `products` SET `stock`=\\?").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		s.sqlMock.ExpectQuery("SELECT `id`,`stock` FROM `products`").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "stock"}).AddRow(1, 15))
+		s.sqlMock.ExpectExec("INSERT INTO `inventory_ledger`").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		s.sqlMock.ExpectCommit()
+
+		// Execute
+		err := s.repo.SetLevel(s.ctx, 1, 2, 8)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestTransfer tests the Transfer method
+func (s *InventoryRepositoryTestSuite) TestTransfer() {
+	transfer := &domain.InventoryTransfer{
+		ProductID:       1,
+		FromWarehouseID: 1,
+		ToWarehouseID:   2,
+		Quantity:        5,
+	}
+
+	s.Run("Success", func() {
+		// Test case: The stock leaves one warehouse and arrives at the other
+		s.sqlMock.ExpectBegin()
+		s.sqlMock.ExpectExec(regexp.QuoteMeta("UPDATE `inventory_levels` SET `quantity`=quantity - ?")+".*"+
+			regexp.QuoteMeta("WHERE product_id = ? AND warehouse_id = ? AND quantity >= ?")).
+			WithArgs(5, sqlmock.AnyArg(), 1, 1, 5).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		s.sqlMock.ExpectExec(regexp.QuoteMeta("INSERT INTO `inventory_levels`") + ".*" +
+			regexp.QuoteMeta("ON DUPLICATE KEY UPDATE `quantity`=quantity + ?")).
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		s.sqlMock.ExpectExec("INSERT INTO `inventory_transfers`").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		s.sqlMock.ExpectCommit()
+
+		// Execute
+		err := s.repo.Transfer(s.ctx, transfer)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+
+	s.Run("Error - Insufficient Stock", func() {
+		// Reset mock
+		s.SetupTest()
+
+		// Test case: The source warehouse has less than the quantity moved
+		s.sqlMock.ExpectBegin()
+		s.sqlMock.ExpectExec("UPDATE `inventory_levels` SET `quantity`=quantity - \\?").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		s.sqlMock.ExpectRollback()
+
+		// Execute
+		err := s.repo.Transfer(s.ctx, transfer)
+
+		// Assert
+		assert.EqualError(s.T(), err, "insufficient stock at warehouse 1")
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestAllocate tests the Allocate method
+func (s *InventoryRepositoryTestSuite) TestAllocate() {
+	allocations := []domain.OrderAllocation{
+		{OrderID: 1, ProductID: 1, WarehouseID: 1, Quantity: 2},
+		{OrderID: 1, ProductID: 2, WarehouseID: 2, Quantity: 1},
+	}
+
+	s.Run("Success", func() {
+		// Test case: Every line is taken from its warehouse
+		s.sqlMock.ExpectBegin()
+		s.sqlMock.ExpectExec("UPDATE `inventory_levels` SET `quantity`=quantity - \\?").
+			WithArgs(2, sqlmock.AnyArg(), 1, 1, 2).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		s.sqlMock.ExpectExec("INSERT INTO `order_allocations`").WillReturnResult(sqlmock.NewResult(1, 1))
+		s.sqlMock.ExpectExec("UPDATE `inventory_levels` SET `quantity`=quantity - \\?").
+			WithArgs(1, sqlmock.AnyArg(), 2, 2, 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		s.sqlMock.ExpectExec("INSERT INTO `order_allocations`").WillReturnResult(sqlmock.NewResult(2, 1))
+		s.sqlMock.ExpectCommit()
+
+		// Execute
+		err := s.repo.Allocate(s.ctx, allocations)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+
+	s.Run("Error - Insufficient Stock", func() {
+		// Reset mock
+		s.SetupTest()
+
+		// Test case: The second line is short, so the first is rolled back too
+		s.sqlMock.ExpectBegin()
+		s.sqlMock.ExpectExec("UPDATE `inventory_levels` SET `quantity`=quantity - \\?").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		s.sqlMock.ExpectExec("INSERT INTO `order_allocations`").WillReturnResult(sqlmock.NewResult(1, 1))
+		s.sqlMock.ExpectExec("UPDATE `inventory_levels` SET `quantity`=quantity - \\?").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		s.sqlMock.ExpectRollback()
+
+		// Execute
+		err := s.repo.Allocate(s.ctx, allocations)
+
+		// Assert
+		assert.EqualError(s.T(), err, "insufficient stock for product 2 at warehouse 2")
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+
+	s.Run("No Allocations", func() {
+		// Reset mock
+		s.SetupTest()
+
+		// Test case: Nothing to reserve
+
+		// Execute
+		err := s.repo.Allocate(s.ctx, nil)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestReleaseAllocations tests the ReleaseAllocations method
+func (s *InventoryRepositoryTestSuite) TestReleaseAllocations() {
+	s.Run("Success", func() {
+		// Test case: Each allocation goes back to its warehouse and is removed
+		s.sqlMock.ExpectBegin()
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `order_allocations` WHERE order_id = ?")).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "product_id", "warehouse_id", "quantity"}).
+				AddRow(1, 1, 1, 1, 2))
+		s.sqlMock.ExpectExec(regexp.QuoteMeta("UPDATE `inventory_levels` SET `quantity`=quantity + ?")).
+			WithArgs(2, sqlmock.AnyArg(), 1, 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		s.sqlMock.ExpectExec(regexp.QuoteMeta("DELETE FROM `order_allocations` WHERE order_id = ?")).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		s.sqlMock.ExpectCommit()
+
+		// Execute
+		err := s.repo.ReleaseAllocations(s.ctx, 1)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestInventoryRepositorySuite runs the test suite
+func TestInventoryRepositorySuite(t *testing.T) {
+	suite.Run(t, new(InventoryRepositoryTestSuite))
+}
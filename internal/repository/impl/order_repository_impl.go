@@ -11,6 +11,7 @@ import (
 	"awesomeEcommerce/internal/repository/cache"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // OrderRepositoryImpl implements the OrderRepository interface
@@ -37,7 +38,7 @@ func (r *OrderRepositoryImpl) FindByID(ctx context.Context, id uint) (*domain.Or
 		var order domain.Order
 		if err := json.Unmarshal([]byte(cachedOrder), &order); err == nil {
 			// Get order items separately
-			if err := r.db.Where("order_id = ?", id).Find(&order.Items).Error; err != nil {
+			if err := conn(ctx, r.db).Where("order_id = ?", id).Find(&order.Items).Error; err != nil {
 				return nil, err
 			}
 			return &order, nil
@@ -46,12 +47,20 @@ func (r *OrderRepositoryImpl) FindByID(ctx context.Context, id uint) (*domain.Or
 
 	// Cache miss, get from database
 	var order domain.Order
-	if err := r.db.First(&order, id).Error; err != nil {
+	if err := conn(ctx, r.db).First(&order, id).Error; err != nil {
 		return nil, err
 	}
 
 	// Get order items
-	if err := r.db.Where("order_id = ?", id).Find(&order.Items).Error; err != nil {
+	if err := conn(ctx, r.db).Where("order_id = ?", id).Find(&order.Items).Error; err != nil {
+		return nil, err
+	}
+
+	// Get discount and tax lines, which never change once the order is placed
+	if err := conn(ctx, r.db).Where("order_id = ?", id).Find(&order.Discounts).Error; err != nil {
+		return nil, err
+	}
+	if err := conn(ctx, r.db).Where("order_id = ?", id).Find(&order.Taxes).Error; err != nil {
 		return nil, err
 	}
 
@@ -60,7 +69,7 @@ func (r *OrderRepositoryImpl) FindByID(ctx context.Context, id uint) (*domain.Or
 	orderCopy.Items = nil
 	orderJSON, err := json.Marshal(orderCopy)
 	if err == nil {
-		r.cache.Set(ctx, cacheKey, orderJSON, 30*time.Minute)
+		afterCommit(ctx, func() { r.cache.Set(ctx, cacheKey, orderJSON, 30*time.Minute) })
 	}
 
 	return &order, nil
@@ -72,19 +81,19 @@ func (r *OrderRepositoryImpl) FindByUserID(ctx context.Context, userID uint, pag
 	var total int64
 
 	// Count total records for the user
-	if err := r.db.Model(&domain.Order{}).Where("user_id = ?", userID).Count(&total).Error; err != nil {
+	if err := conn(ctx, r.db).Model(&domain.Order{}).Where("user_id = ?", userID).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// Apply pagination
 	offset := (page - 1) * pageSize
-	if err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&orders).Error; err != nil {
+	if err := conn(ctx, r.db).Where("user_id = ?", userID).Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&orders).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// Get items for each order
 	for i := range orders {
-		if err := r.db.Where("order_id = ?", orders[i].ID).Find(&orders[i].Items).Error; err != nil {
+		if err := conn(ctx, r.db).Where("order_id = ?", orders[i].ID).Find(&orders[i].Items).Error; err != nil {
 			return nil, 0, err
 		}
 	}
@@ -94,57 +103,136 @@ func (r *OrderRepositoryImpl) FindByUserID(ctx context.Context, userID uint, pag
 
 // Create creates a new order
 func (r *OrderRepositoryImpl) Create(ctx context.Context, order *domain.Order) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
-		// Create the order
-		if err := tx.Create(order).Error; err != nil {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		// Create the order, its lines being created below
+		if err := tx.Omit("Items", "Discounts", "Taxes").Create(order).Error; err != nil {
 			return err
 		}
 
-		// Create order items
+		// Create order items, linking the component lines of a bundle to the
+		// bundle line they follow
+		var bundleItemID *uint
 		for i := range order.Items {
 			order.Items[i].OrderID = order.ID
+			if order.Items[i].IsBundleComponent() {
+				order.Items[i].ParentItemID = bundleItemID
+			}
 			if err := tx.Create(&order.Items[i]).Error; err != nil {
 				return err
 			}
+			if order.Items[i].IsBundle() {
+				bundleItemID = &order.Items[i].ID
+			}
 		}
 
-		return nil
+		// Record the discount lines and count the coupon's use with the order,
+		// so an order is never placed on a coupon past its limits
+		for i := range order.Discounts {
+			order.Discounts[i].OrderID = order.ID
+			if err := tx.Create(&order.Discounts[i]).Error; err != nil {
+				return err
+			}
+		}
+		if order.CouponID != nil {
+			if err := redeemCoupon(tx, order); err != nil {
+				return err
+			}
+		}
+
+		// Record the tax lines
+		for i := range order.Taxes {
+			order.Taxes[i].OrderID = order.ID
+			if err := tx.Create(&order.Taxes[i]).Error; err != nil {
+				return err
+			}
+		}
+
+		// Start the status history with the order being placed
+		return tx.Create(&domain.OrderStatusHistory{
+			OrderID:  order.ID,
+			ToStatus: order.Status,
+			Actor:    fmt.Sprintf("user:%d", order.UserID),
+			Reason:   "order placed",
+		}).Error
 	})
 }
 
 // Update updates an existing order
 func (r *OrderRepositoryImpl) Update(ctx context.Context, order *domain.Order) error {
-	if err := r.db.Save(order).Error; err != nil {
+	if err := conn(ctx, r.db).Save(order).Error; err != nil {
 		return err
 	}
 
 	// Invalidate cache
 	cacheKey := fmt.Sprintf("order:%d", order.ID)
-	r.cache.Delete(ctx, cacheKey)
+	afterCommit(ctx, func() { r.cache.Delete(ctx, cacheKey) })
 
 	return nil
 }
 
-// UpdateStatus updates the status of an order
-func (r *OrderRepositoryImpl) UpdateStatus(ctx context.Context, id uint, status domain.OrderStatus) error {
-	if err := r.db.Model(&domain.Order{}).Where("id = ?", id).Update("status", status).Error; err != nil {
+// UpdateStatus moves an order from the status its transition was checked from
+// to another and records the change in its status history. The order is
+// locked while its status is compared and changed, so a transition checked
+// against a status another change already left is refused rather than
+// applied, and the history holds the status each change actually started from.
+func (r *OrderRepositoryImpl) UpdateStatus(ctx context.Context, id uint, from, to domain.OrderStatus, change domain.OrderStatusChange) error {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var order domain.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "status").First(&order, id).Error; err != nil {
+			return err
+		}
+		if order.Status != from {
+			return domain.ErrOrderStatusChanged
+		}
+
+		if err := tx.Model(&domain.Order{}).Where("id = ?", id).Update("status", to).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&domain.OrderStatusHistory{
+			OrderID:    id,
+			FromStatus: from,
+			ToStatus:   to,
+			Actor:      change.Actor,
+			Reason:     change.Reason,
+		}).Error
+	})
+	if err != nil {
 		return err
 	}
 
 	// Invalidate cache
 	cacheKey := fmt.Sprintf("order:%d", id)
-	r.cache.Delete(ctx, cacheKey)
+	afterCommit(ctx, func() { r.cache.Delete(ctx, cacheKey) })
 
 	return nil
 }
 
+// FindStatusHistory retrieves the status history of an order, oldest first
+func (r *OrderRepositoryImpl) FindStatusHistory(ctx context.Context, orderID uint) ([]domain.OrderStatusHistory, error) {
+	var history []domain.OrderStatusHistory
+	if err := conn(ctx, r.db).Where("order_id = ?", orderID).Order("created_at, id").Find(&history).Error; err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
 // Delete deletes an order by its ID
 func (r *OrderRepositoryImpl) Delete(ctx context.Context, id uint) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
-		// Delete order items first
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		// Delete order items, discount and tax lines and the status history first
 		if err := tx.Where("order_id = ?", id).Delete(&domain.OrderItem{}).Error; err != nil {
 			return err
 		}
+		if err := tx.Where("order_id = ?", id).Delete(&domain.OrderDiscount{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("order_id = ?", id).Delete(&domain.TaxLine{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("order_id = ?", id).Delete(&domain.OrderStatusHistory{}).Error; err != nil {
+			return err
+		}
 
 		// Delete the order
 		if err := tx.Delete(&domain.Order{}, id).Error; err != nil {
@@ -153,7 +241,7 @@ func (r *OrderRepositoryImpl) Delete(ctx context.Context, id uint) error {
 
 		// Invalidate cache
 		cacheKey := fmt.Sprintf("order:%d", id)
-		r.cache.Delete(ctx, cacheKey)
+		afterCommit(ctx, func() { r.cache.Delete(ctx, cacheKey) })
 
 		return nil
 	})
@@ -161,13 +249,13 @@ func (r *OrderRepositoryImpl) Delete(ctx context.Context, id uint) error {
 
 // AddOrderItem adds an item to an order
 func (r *OrderRepositoryImpl) AddOrderItem(ctx context.Context, orderItem *domain.OrderItem) error {
-	if err := r.db.Create(orderItem).Error; err != nil {
+	if err := conn(ctx, r.db).Create(orderItem).Error; err != nil {
 		return err
 	}
 
 	// Invalidate cache
 	cacheKey := fmt.Sprintf("order:%d", orderItem.OrderID)
-	r.cache.Delete(ctx, cacheKey)
+	afterCommit(ctx, func() { r.cache.Delete(ctx, cacheKey) })
 
 	return nil
 }
@@ -175,7 +263,7 @@ func (r *OrderRepositoryImpl) AddOrderItem(ctx context.Context, orderItem *domai
 // GetOrderItems retrieves all items in an order
 func (r *OrderRepositoryImpl) GetOrderItems(ctx context.Context, orderID uint) ([]domain.OrderItem, error) {
 	var items []domain.OrderItem
-	if err := r.db.Where("order_id = ?", orderID).Find(&items).Error; err != nil {
+	if err := conn(ctx, r.db).Where("order_id = ?", orderID).Find(&items).Error; err != nil {
 		return nil, err
 	}
 	return items, nil
@@ -187,19 +275,19 @@ func (r *OrderRepositoryImpl) FindAll(ctx context.Context, page, pageSize int) (
 	var total int64
 
 	// Count total records
-	if err := r.db.Model(&domain.Order{}).Count(&total).Error; err != nil {
+	if err := conn(ctx, r.db).Model(&domain.Order{}).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// Apply pagination
 	offset := (page - 1) * pageSize
-	if err := r.db.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&orders).Error; err != nil {
+	if err := conn(ctx, r.db).Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&orders).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// Get items for each order
 	for i := range orders {
-		if err := r.db.Where("order_id = ?", orders[i].ID).Find(&orders[i].Items).Error; err != nil {
+		if err := conn(ctx, r.db).Where("order_id = ?", orders[i].ID).Find(&orders[i].Items).Error; err != nil {
 			return nil, 0, err
 		}
 	}
@@ -213,19 +301,19 @@ func (r *OrderRepositoryImpl) FindByStatus(ctx context.Context, status domain.Or
 	var total int64
 
 	// Count total records for the status
-	if err := r.db.Model(&domain.Order{}).Where("status = ?", status).Count(&total).Error; err != nil {
+	if err := conn(ctx, r.db).Model(&domain.Order{}).Where("status = ?", status).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// Apply pagination
 	offset := (page - 1) * pageSize
-	if err := r.db.Where("status = ?", status).Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&orders).Error; err != nil {
+	if err := conn(ctx, r.db).Where("status = ?", status).Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&orders).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// Get items for each order
 	for i := range orders {
-		if err := r.db.Where("order_id = ?", orders[i].ID).Find(&orders[i].Items).Error; err != nil {
+		if err := conn(ctx, r.db).Where("order_id = ?", orders[i].ID).Find(&orders[i].Items).Error; err != nil {
 			return nil, 0, err
 		}
 	}
@@ -233,15 +321,44 @@ func (r *OrderRepositoryImpl) FindByStatus(ctx context.Context, status domain.Or
 	return orders, total, nil
 }
 
-// GetOrderTotal calculates the total price of an order
-func (r *OrderRepositoryImpl) GetOrderTotal(ctx context.Context, orderID uint) (float64, error) {
-	var total float64
-	err := r.db.Model(&domain.OrderItem{}).
-		Select("SUM(price * quantity)").
+// GetOrderTotal calculates the total price of an order, less its discounts
+func (r *OrderRepositoryImpl) GetOrderTotal(ctx context.Context, orderID uint) (domain.Money, error) {
+	// Lines are in the order's currency, so their minor units add up exactly
+	var total domain.Money
+	err := conn(ctx, r.db).Model(&domain.OrderItem{}).
+		Select("COALESCE(SUM(price_amount * quantity), 0) AS amount, COALESCE(MAX(price_currency), '') AS currency").
 		Where("order_id = ?", orderID).
 		Scan(&total).Error
 	if err != nil {
-		return 0, err
+		return domain.Money{}, err
+	}
+
+	var discount int64
+	err = conn(ctx, r.db).Model(&domain.OrderDiscount{}).
+		Select("COALESCE(SUM(amount_amount), 0)").
+		Where("order_id = ?", orderID).
+		Scan(&discount).Error
+	if err != nil {
+		return domain.Money{}, err
+	}
+	total.Amount -= discount
+
+	// Add shipping, and tax unless prices include it
+	var order domain.Order
+	if err := conn(ctx, r.db).Select("tax_mode", "shipping_amount", "shipping_currency").First(&order, orderID).Error; err != nil {
+		return domain.Money{}, err
+	}
+	total.Amount += order.ShippingAmount.Amount
+	if order.TaxMode != domain.TaxModeInclusive {
+		var tax int64
+		err = conn(ctx, r.db).Model(&domain.TaxLine{}).
+			Select("COALESCE(SUM(amount_amount), 0)").
+			Where("order_id = ?", orderID).
+			Scan(&tax).Error
+		if err != nil {
+			return domain.Money{}, err
+		}
+		total.Amount += tax
 	}
 	return total, nil
 }
@@ -252,7 +369,7 @@ func (r *OrderRepositoryImpl) FindByDateRange(ctx context.Context, startDate, en
 	var total int64
 
 	// Count total records in the date range
-	if err := r.db.Model(&domain.Order{}).
+	if err := conn(ctx, r.db).Model(&domain.Order{}).
 		Where("created_at BETWEEN ? AND ?", startDate, endDate).
 		Count(&total).Error; err != nil {
 		return nil, 0, err
@@ -260,7 +377,7 @@ func (r *OrderRepositoryImpl) FindByDateRange(ctx context.Context, startDate, en
 
 	// Apply pagination
 	offset := (page - 1) * pageSize
-	if err := r.db.Where("created_at BETWEEN ? AND ?", startDate, endDate).
+	if err := conn(ctx, r.db).Where("created_at BETWEEN ? AND ?", startDate, endDate).
 		Order("created_at DESC").
 		Offset(offset).
 		Limit(pageSize).
@@ -270,7 +387,7 @@ func (r *OrderRepositoryImpl) FindByDateRange(ctx context.Context, startDate, en
 
 	// Get items for each order
 	for i := range orders {
-		if err := r.db.Where("order_id = ?", orders[i].ID).Find(&orders[i].Items).Error; err != nil {
+		if err := conn(ctx, r.db).Where("order_id = ?", orders[i].ID).Find(&orders[i].Items).Error; err != nil {
 			return nil, 0, err
 		}
 	}
@@ -6,11 +6,17 @@ import (
 	"testing"
 	"time"
 
+	"awesomeEcommerce/internal/config"
 	"awesomeEcommerce/internal/domain"
 	"awesomeEcommerce/internal/repository"
+	"awesomeEcommerce/internal/repository/cache"
+	"awesomeEcommerce/internal/repository/impl"
 
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -45,11 +51,19 @@ func (m *MockOrderRepository) Update(ctx context.Context, order *domain.Order) e
 	return args.Error(0)
 }
 
-func (m *MockOrderRepository) UpdateStatus(ctx context.Context, id uint, status domain.OrderStatus) error {
-	args := m.Called(ctx, id, status)
+func (m *MockOrderRepository) UpdateStatus(ctx context.Context, id uint, from, to domain.OrderStatus, change domain.OrderStatusChange) error {
+	args := m.Called(ctx, id, from, to, change)
 	return args.Error(0)
 }
 
+func (m *MockOrderRepository) FindStatusHistory(ctx context.Context, orderID uint) ([]domain.OrderStatusHistory, error) {
+	args := m.Called(ctx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.OrderStatusHistory), args.Error(1)
+}
+
 func (m *MockOrderRepository) Delete(ctx context.Context, id uint) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
@@ -84,9 +98,9 @@ func (m *MockOrderRepository) FindByStatus(ctx context.Context, status domain.Or
 	return args.Get(0).([]domain.Order), args.Get(1).(int64), args.Error(2)
 }
 
-func (m *MockOrderRepository) GetOrderTotal(ctx context.Context, orderID uint) (float64, error) {
+func (m *MockOrderRepository) GetOrderTotal(ctx context.Context, orderID uint) (domain.Money, error) {
 	args := m.Called(ctx, orderID)
-	return args.Get(0).(float64), args.Error(1)
+	return args.Get(0).(domain.Money), args.Error(1)
 }
 
 func (m *MockOrderRepository) FindByDateRange(ctx context.Context, startDate, endDate string, page, pageSize int) ([]domain.Order, int64, error) {
@@ -120,7 +134,7 @@ func (s *OrderRepositoryTestSuite) TestFindByID() {
 		expectedOrder := &domain.Order{
 			ID:              orderID,
 			UserID:          uint(1),
-			TotalAmount:     99.99,
+			TotalAmount:     usd(9999),
 			Status:          domain.OrderStatusPending,
 			ShippingAddress: "123 Shipping St",
 			BillingAddress:  "123 Billing St",
@@ -130,7 +144,7 @@ func (s *OrderRepositoryTestSuite) TestFindByID() {
 					OrderID:     orderID,
 					ProductID:   uint(2),
 					ProductName: "Test Product",
-					Price:       49.99,
+					Price:       usd(4999),
 					Quantity:    2,
 				},
 			},
@@ -182,7 +196,7 @@ func (s *OrderRepositoryTestSuite) TestFindByUserID() {
 			{
 				ID:              uint(1),
 				UserID:          userID,
-				TotalAmount:     99.99,
+				TotalAmount:     usd(9999),
 				Status:          domain.OrderStatusPending,
 				ShippingAddress: "123 Shipping St",
 				BillingAddress:  "123 Billing St",
@@ -192,7 +206,7 @@ func (s *OrderRepositoryTestSuite) TestFindByUserID() {
 						OrderID:     uint(1),
 						ProductID:   uint(2),
 						ProductName: "Test Product 1",
-						Price:       49.99,
+						Price:       usd(4999),
 						Quantity:    2,
 					},
 				},
@@ -200,7 +214,7 @@ func (s *OrderRepositoryTestSuite) TestFindByUserID() {
 			{
 				ID:              uint(2),
 				UserID:          userID,
-				TotalAmount:     29.99,
+				TotalAmount:     usd(2999),
 				Status:          domain.OrderStatusDelivered,
 				ShippingAddress: "123 Shipping St",
 				BillingAddress:  "123 Billing St",
@@ -210,7 +224,7 @@ func (s *OrderRepositoryTestSuite) TestFindByUserID() {
 						OrderID:     uint(2),
 						ProductID:   uint(3),
 						ProductName: "Test Product 2",
-						Price:       29.99,
+						Price:       usd(2999),
 						Quantity:    1,
 					},
 				},
@@ -287,7 +301,7 @@ func (s *OrderRepositoryTestSuite) TestCreate() {
 		// Test case: Successfully create an order
 		order := &domain.Order{
 			UserID:          uint(1),
-			TotalAmount:     99.99,
+			TotalAmount:     usd(9999),
 			Status:          domain.OrderStatusPending,
 			ShippingAddress: "123 Shipping St",
 			BillingAddress:  "123 Billing St",
@@ -295,7 +309,7 @@ func (s *OrderRepositoryTestSuite) TestCreate() {
 				{
 					ProductID:   uint(2),
 					ProductName: "Test Product",
-					Price:       49.99,
+					Price:       usd(4999),
 					Quantity:    2,
 				},
 			},
@@ -319,7 +333,7 @@ func (s *OrderRepositoryTestSuite) TestCreate() {
 		// Test case: Database error
 		order := &domain.Order{
 			UserID:          uint(1),
-			TotalAmount:     99.99,
+			TotalAmount:     usd(9999),
 			Status:          domain.OrderStatusPending,
 			ShippingAddress: "123 Shipping St",
 			BillingAddress:  "123 Billing St",
@@ -327,7 +341,7 @@ func (s *OrderRepositoryTestSuite) TestCreate() {
 				{
 					ProductID:   uint(2),
 					ProductName: "Test Product",
-					Price:       49.99,
+					Price:       usd(4999),
 					Quantity:    2,
 				},
 			},
@@ -355,7 +369,7 @@ func (s *OrderRepositoryTestSuite) TestUpdate() {
 		order := &domain.Order{
 			ID:              uint(1),
 			UserID:          uint(1),
-			TotalAmount:     129.99,
+			TotalAmount:     usd(12999),
 			Status:          domain.OrderStatusProcessing,
 			ShippingAddress: "123 Shipping St",
 			BillingAddress:  "123 Billing St",
@@ -380,7 +394,7 @@ func (s *OrderRepositoryTestSuite) TestUpdate() {
 		order := &domain.Order{
 			ID:              uint(999),
 			UserID:          uint(1),
-			TotalAmount:     129.99,
+			TotalAmount:     usd(12999),
 			Status:          domain.OrderStatusProcessing,
 			ShippingAddress: "123 Shipping St",
 			BillingAddress:  "123 Billing St",
@@ -402,16 +416,17 @@ func (s *OrderRepositoryTestSuite) TestUpdate() {
 // TestUpdateStatus tests the UpdateStatus method
 func (s *OrderRepositoryTestSuite) TestUpdateStatus() {
 	mockRepo := s.mockRepo.(*MockOrderRepository)
+	change := domain.OrderStatusChange{Reason: "handed to carrier", Actor: "user:1"}
 
 	s.Run("Success", func() {
 		// Test case: Successfully update an order status
 		orderID := uint(1)
 		newStatus := domain.OrderStatusShipped
 
-		mockRepo.On("UpdateStatus", s.ctx, orderID, newStatus).Return(nil).Once()
+		mockRepo.On("UpdateStatus", s.ctx, orderID, domain.OrderStatusProcessing, newStatus, change).Return(nil).Once()
 
 		// Execute
-		err := s.mockRepo.UpdateStatus(s.ctx, orderID, newStatus)
+		err := s.mockRepo.UpdateStatus(s.ctx, orderID, domain.OrderStatusProcessing, newStatus, change)
 
 		// Assert
 		assert.NoError(s.T(), err)
@@ -428,10 +443,10 @@ func (s *OrderRepositoryTestSuite) TestUpdateStatus() {
 		newStatus := domain.OrderStatusShipped
 		expectedError := errors.New("order not found")
 
-		mockRepo.On("UpdateStatus", s.ctx, orderID, newStatus).Return(expectedError).Once()
+		mockRepo.On("UpdateStatus", s.ctx, orderID, domain.OrderStatusProcessing, newStatus, change).Return(expectedError).Once()
 
 		// Execute
-		err := s.mockRepo.UpdateStatus(s.ctx, orderID, newStatus)
+		err := s.mockRepo.UpdateStatus(s.ctx, orderID, domain.OrderStatusProcessing, newStatus, change)
 
 		// Assert
 		assert.Error(s.T(), err)
@@ -440,6 +455,50 @@ func (s *OrderRepositoryTestSuite) TestUpdateStatus() {
 	})
 }
 
+// TestFindStatusHistory tests the FindStatusHistory method
+func (s *OrderRepositoryTestSuite) TestFindStatusHistory() {
+	mockRepo := s.mockRepo.(*MockOrderRepository)
+
+	s.Run("Success", func() {
+		// Test case: The history starts with the order being placed
+		orderID := uint(1)
+		expectedHistory := []domain.OrderStatusHistory{
+			{ID: 1, OrderID: orderID, ToStatus: domain.OrderStatusPending, Actor: "user:2", Reason: "order placed"},
+			{ID: 2, OrderID: orderID, FromStatus: domain.OrderStatusPending, ToStatus: domain.OrderStatusProcessing, Actor: "system", Reason: "payment completed"},
+		}
+
+		mockRepo.On("FindStatusHistory", s.ctx, orderID).Return(expectedHistory, nil).Once()
+
+		// Execute
+		history, err := s.mockRepo.FindStatusHistory(s.ctx, orderID)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), expectedHistory, history)
+		mockRepo.AssertExpectations(s.T())
+	})
+
+	s.Run("Error", func() {
+		// Reset mock
+		s.SetupTest()
+		mockRepo = s.mockRepo.(*MockOrderRepository)
+
+		// Test case: Database error
+		orderID := uint(1)
+		expectedError := errors.New("database error")
+
+		mockRepo.On("FindStatusHistory", s.ctx, orderID).Return(nil, expectedError).Once()
+
+		// Execute
+		history, err := s.mockRepo.FindStatusHistory(s.ctx, orderID)
+
+		// Assert
+		assert.Equal(s.T(), expectedError, err)
+		assert.Nil(s.T(), history)
+		mockRepo.AssertExpectations(s.T())
+	})
+}
+
 // TestDelete tests the Delete method
 func (s *OrderRepositoryTestSuite) TestDelete() {
 	mockRepo := s.mockRepo.(*MockOrderRepository)
@@ -489,7 +548,7 @@ func (s *OrderRepositoryTestSuite) TestAddOrderItem() {
 			OrderID:     uint(1),
 			ProductID:   uint(3),
 			ProductName: "New Product",
-			Price:       19.99,
+			Price:       usd(1999),
 			Quantity:    1,
 		}
 
@@ -513,7 +572,7 @@ func (s *OrderRepositoryTestSuite) TestAddOrderItem() {
 			OrderID:     uint(999),
 			ProductID:   uint(3),
 			ProductName: "New Product",
-			Price:       19.99,
+			Price:       usd(1999),
 			Quantity:    1,
 		}
 		expectedError := errors.New("order not found")
@@ -543,7 +602,7 @@ func (s *OrderRepositoryTestSuite) TestGetOrderItems() {
 				OrderID:     orderID,
 				ProductID:   uint(2),
 				ProductName: "Test Product 1",
-				Price:       49.99,
+				Price:       usd(4999),
 				Quantity:    2,
 			},
 			{
@@ -551,7 +610,7 @@ func (s *OrderRepositoryTestSuite) TestGetOrderItems() {
 				OrderID:     orderID,
 				ProductID:   uint(3),
 				ProductName: "Test Product 2",
-				Price:       29.99,
+				Price:       usd(2999),
 				Quantity:    1,
 			},
 		}
@@ -621,7 +680,7 @@ func (s *OrderRepositoryTestSuite) TestFindAll() {
 			{
 				ID:              uint(1),
 				UserID:          uint(1),
-				TotalAmount:     99.99,
+				TotalAmount:     usd(9999),
 				Status:          domain.OrderStatusPending,
 				ShippingAddress: "123 Shipping St",
 				BillingAddress:  "123 Billing St",
@@ -631,7 +690,7 @@ func (s *OrderRepositoryTestSuite) TestFindAll() {
 						OrderID:     uint(1),
 						ProductID:   uint(2),
 						ProductName: "Test Product 1",
-						Price:       49.99,
+						Price:       usd(4999),
 						Quantity:    2,
 					},
 				},
@@ -639,7 +698,7 @@ func (s *OrderRepositoryTestSuite) TestFindAll() {
 			{
 				ID:              uint(2),
 				UserID:          uint(2),
-				TotalAmount:     29.99,
+				TotalAmount:     usd(2999),
 				Status:          domain.OrderStatusDelivered,
 				ShippingAddress: "456 Shipping St",
 				BillingAddress:  "456 Billing St",
@@ -649,7 +708,7 @@ func (s *OrderRepositoryTestSuite) TestFindAll() {
 						OrderID:     uint(2),
 						ProductID:   uint(3),
 						ProductName: "Test Product 2",
-						Price:       29.99,
+						Price:       usd(2999),
 						Quantity:    1,
 					},
 				},
@@ -729,7 +788,7 @@ func (s *OrderRepositoryTestSuite) TestFindByStatus() {
 			{
 				ID:              uint(1),
 				UserID:          uint(1),
-				TotalAmount:     99.99,
+				TotalAmount:     usd(9999),
 				Status:          status,
 				ShippingAddress: "123 Shipping St",
 				BillingAddress:  "123 Billing St",
@@ -739,7 +798,7 @@ func (s *OrderRepositoryTestSuite) TestFindByStatus() {
 						OrderID:     uint(1),
 						ProductID:   uint(2),
 						ProductName: "Test Product 1",
-						Price:       49.99,
+						Price:       usd(4999),
 						Quantity:    2,
 					},
 				},
@@ -747,7 +806,7 @@ func (s *OrderRepositoryTestSuite) TestFindByStatus() {
 			{
 				ID:              uint(3),
 				UserID:          uint(3),
-				TotalAmount:     79.99,
+				TotalAmount:     usd(7999),
 				Status:          status,
 				ShippingAddress: "789 Shipping St",
 				BillingAddress:  "789 Billing St",
@@ -757,7 +816,7 @@ func (s *OrderRepositoryTestSuite) TestFindByStatus() {
 						OrderID:     uint(3),
 						ProductID:   uint(4),
 						ProductName: "Test Product 3",
-						Price:       79.99,
+						Price:       usd(7999),
 						Quantity:    1,
 					},
 				},
@@ -833,7 +892,7 @@ func (s *OrderRepositoryTestSuite) TestGetOrderTotal() {
 	s.Run("Success", func() {
 		// Test case: Successfully calculate the total price of an order
 		orderID := uint(1)
-		expectedTotal := 129.97 // (49.99 * 2) + (29.99 * 1) = 129.97
+		expectedTotal := usd(12997) // (49.99 * 2) + (29.99 * 1) = 129.97
 
 		mockRepo.On("GetOrderTotal", s.ctx, orderID).Return(expectedTotal, nil).Once()
 
@@ -853,7 +912,7 @@ func (s *OrderRepositoryTestSuite) TestGetOrderTotal() {
 
 		// Test case: Order has no items
 		orderID := uint(2)
-		expectedTotal := 0.0
+		expectedTotal := domain.Money{}
 
 		mockRepo.On("GetOrderTotal", s.ctx, orderID).Return(expectedTotal, nil).Once()
 
@@ -875,14 +934,14 @@ func (s *OrderRepositoryTestSuite) TestGetOrderTotal() {
 		orderID := uint(999)
 		expectedError := errors.New("order not found")
 
-		mockRepo.On("GetOrderTotal", s.ctx, orderID).Return(0.0, expectedError).Once()
+		mockRepo.On("GetOrderTotal", s.ctx, orderID).Return(domain.Money{}, expectedError).Once()
 
 		// Execute
 		total, err := s.mockRepo.GetOrderTotal(s.ctx, orderID)
 
 		// Assert
 		assert.Error(s.T(), err)
-		assert.Equal(s.T(), 0.0, total)
+		assert.Equal(s.T(), domain.Money{}, total)
 		assert.Equal(s.T(), expectedError, err)
 		mockRepo.AssertExpectations(s.T())
 	})
@@ -902,7 +961,7 @@ func (s *OrderRepositoryTestSuite) TestFindByDateRange() {
 			{
 				ID:              uint(1),
 				UserID:          uint(1),
-				TotalAmount:     99.99,
+				TotalAmount:     usd(9999),
 				Status:          domain.OrderStatusPending,
 				ShippingAddress: "123 Shipping St",
 				BillingAddress:  "123 Billing St",
@@ -913,7 +972,7 @@ func (s *OrderRepositoryTestSuite) TestFindByDateRange() {
 						OrderID:     uint(1),
 						ProductID:   uint(2),
 						ProductName: "Test Product 1",
-						Price:       49.99,
+						Price:       usd(4999),
 						Quantity:    2,
 					},
 				},
@@ -921,7 +980,7 @@ func (s *OrderRepositoryTestSuite) TestFindByDateRange() {
 			{
 				ID:              uint(2),
 				UserID:          uint(2),
-				TotalAmount:     29.99,
+				TotalAmount:     usd(2999),
 				Status:          domain.OrderStatusDelivered,
 				ShippingAddress: "456 Shipping St",
 				BillingAddress:  "456 Billing St",
@@ -932,7 +991,7 @@ func (s *OrderRepositoryTestSuite) TestFindByDateRange() {
 						OrderID:     uint(2),
 						ProductID:   uint(3),
 						ProductName: "Test Product 2",
-						Price:       29.99,
+						Price:       usd(2999),
 						Quantity:    1,
 					},
 				},
@@ -1032,3 +1091,53 @@ func (s *OrderRepositoryTestSuite) TestFindByDateRange() {
 func TestOrderRepositorySuite(t *testing.T) {
 	suite.Run(t, new(OrderRepositoryTestSuite))
 }
+
+func TestOrderRepositoryImplUpdateStatus(t *testing.T) {
+	ctx := context.Background()
+	change := domain.OrderStatusChange{Reason: "handed to carrier", Actor: "user:1"}
+
+	newRepository := func(t *testing.T) (repository.OrderRepository, sqlmock.Sqlmock) {
+		server := miniredis.RunT(t)
+		client, err := cache.NewRedisClient(&config.Config{Redis: config.RedisConfig{Host: server.Host(), Port: server.Port()}})
+		require.NoError(t, err)
+		t.Cleanup(func() { client.Close() })
+
+		db, sqlMock := newMockDB(t)
+		return impl.NewOrderRepository(db, client), sqlMock
+	}
+
+	t.Run("Status Checked", func(t *testing.T) {
+		orderRepo, sqlMock := newRepository(t)
+
+		// The order is still in the status the transition was checked from
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery("SELECT `id`,`status` FROM `orders` .* FOR UPDATE").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(1, domain.OrderStatusProcessing))
+		sqlMock.ExpectExec("UPDATE `orders` SET `status`").WillReturnResult(sqlmock.NewResult(0, 1))
+		sqlMock.ExpectExec("INSERT INTO `order_status_history`").
+			WithArgs(uint(1), domain.OrderStatusProcessing, domain.OrderStatusShipped, "user:1", "handed to carrier", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		sqlMock.ExpectCommit()
+
+		err := orderRepo.UpdateStatus(ctx, 1, domain.OrderStatusProcessing, domain.OrderStatusShipped, change)
+
+		assert.NoError(t, err)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("Status Changed Meanwhile", func(t *testing.T) {
+		orderRepo, sqlMock := newRepository(t)
+
+		// Another change cancelled the order after the transition was checked,
+		// so nothing is written
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery("SELECT `id`,`status` FROM `orders` .* FOR UPDATE").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(1, domain.OrderStatusCancelled))
+		sqlMock.ExpectRollback()
+
+		err := orderRepo.UpdateStatus(ctx, 1, domain.OrderStatusProcessing, domain.OrderStatusShipped, change)
+
+		assert.ErrorIs(t, err, domain.ErrOrderStatusChanged)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+}
@@ -42,14 +42,14 @@ func (r *PaymentRepositoryImpl) FindByID(ctx context.Context, id uint) (*domain.
 
 	// Cache miss, get from database
 	var payment domain.Payment
-	if err := r.db.First(&payment, id).Error; err != nil {
+	if err := conn(ctx, r.db).Preload("Tenders").First(&payment, id).Error; err != nil {
 		return nil, err
 	}
 
 	// Store in cache for future requests
 	paymentJSON, err := json.Marshal(payment)
 	if err == nil {
-		r.cache.Set(ctx, cacheKey, paymentJSON, 30*time.Minute)
+		afterCommit(ctx, func() { r.cache.Set(ctx, cacheKey, paymentJSON, 30*time.Minute) })
 	}
 
 	return &payment, nil
@@ -70,20 +70,20 @@ func (r *PaymentRepositoryImpl) FindByOrderID(ctx context.Context, orderID uint)
 
 	// Cache miss, get from database
 	var payment domain.Payment
-	if err := r.db.Where("order_id = ?", orderID).First(&payment).Error; err != nil {
+	if err := conn(ctx, r.db).Preload("Tenders").Where("order_id = ?", orderID).First(&payment).Error; err != nil {
 		return nil, err
 	}
 
 	// Store payment ID in cache for future requests
 	paymentIDJSON, err := json.Marshal(payment.ID)
 	if err == nil {
-		r.cache.Set(ctx, cacheKey, paymentIDJSON, 30*time.Minute)
+		afterCommit(ctx, func() { r.cache.Set(ctx, cacheKey, paymentIDJSON, 30*time.Minute) })
 	}
 
 	// Also cache the full payment
 	paymentJSON, err := json.Marshal(payment)
 	if err == nil {
-		r.cache.Set(ctx, fmt.Sprintf("payment:%d", payment.ID), paymentJSON, 30*time.Minute)
+		afterCommit(ctx, func() { r.cache.Set(ctx, fmt.Sprintf("payment:%d", payment.ID), paymentJSON, 30*time.Minute) })
 	}
 
 	return &payment, nil
@@ -91,7 +91,7 @@ func (r *PaymentRepositoryImpl) FindByOrderID(ctx context.Context, orderID uint)
 
 // Create creates a new payment
 func (r *PaymentRepositoryImpl) Create(ctx context.Context, payment *domain.Payment) error {
-	if err := r.db.Create(payment).Error; err != nil {
+	if err := conn(ctx, r.db).Create(payment).Error; err != nil {
 		return err
 	}
 
@@ -99,7 +99,7 @@ func (r *PaymentRepositoryImpl) Create(ctx context.Context, payment *domain.Paym
 	cacheKey := fmt.Sprintf("payment:order:%d", payment.OrderID)
 	paymentIDJSON, err := json.Marshal(payment.ID)
 	if err == nil {
-		r.cache.Set(ctx, cacheKey, paymentIDJSON, 30*time.Minute)
+		afterCommit(ctx, func() { r.cache.Set(ctx, cacheKey, paymentIDJSON, 30*time.Minute) })
 	}
 
 	return nil
@@ -107,14 +107,12 @@ func (r *PaymentRepositoryImpl) Create(ctx context.Context, payment *domain.Paym
 
 // Update updates an existing payment
 func (r *PaymentRepositoryImpl) Update(ctx context.Context, payment *domain.Payment) error {
-	if err := r.db.Save(payment).Error; err != nil {
+	if err := conn(ctx, r.db).Save(payment).Error; err != nil {
 		return err
 	}
 
 	// Invalidate caches
-	r.cache.Delete(ctx, fmt.Sprintf("payment:%d", payment.ID))
-	r.cache.Delete(ctx, fmt.Sprintf("payment:order:%d", payment.OrderID))
-	r.cache.Delete(ctx, fmt.Sprintf("payment:transaction:%s", payment.TransactionID))
+	r.invalidate(ctx, payment)
 
 	return nil
 }
@@ -123,21 +121,17 @@ func (r *PaymentRepositoryImpl) Update(ctx context.Context, payment *domain.Paym
 func (r *PaymentRepositoryImpl) UpdateStatus(ctx context.Context, id uint, status domain.PaymentStatus) error {
 	// Get the payment first to get the order ID and transaction ID
 	var payment domain.Payment
-	if err := r.db.First(&payment, id).Error; err != nil {
+	if err := conn(ctx, r.db).First(&payment, id).Error; err != nil {
 		return err
 	}
 
 	// Update the status
-	if err := r.db.Model(&domain.Payment{}).Where("id = ?", id).Update("status", status).Error; err != nil {
+	if err := conn(ctx, r.db).Model(&domain.Payment{}).Where("id = ?", id).Update("status", status).Error; err != nil {
 		return err
 	}
 
 	// Invalidate caches
-	r.cache.Delete(ctx, fmt.Sprintf("payment:%d", id))
-	r.cache.Delete(ctx, fmt.Sprintf("payment:order:%d", payment.OrderID))
-	if payment.TransactionID != "" {
-		r.cache.Delete(ctx, fmt.Sprintf("payment:transaction:%s", payment.TransactionID))
-	}
+	r.invalidate(ctx, &payment)
 
 	return nil
 }
@@ -146,23 +140,41 @@ func (r *PaymentRepositoryImpl) UpdateStatus(ctx context.Context, id uint, statu
 func (r *PaymentRepositoryImpl) Delete(ctx context.Context, id uint) error {
 	// Get the payment first to get the order ID and transaction ID
 	var payment domain.Payment
-	if err := r.db.First(&payment, id).Error; err != nil {
+	if err := conn(ctx, r.db).First(&payment, id).Error; err != nil {
 		return err
 	}
 
 	// Delete the payment
-	if err := r.db.Delete(&domain.Payment{}, id).Error; err != nil {
+	if err := conn(ctx, r.db).Delete(&domain.Payment{}, id).Error; err != nil {
 		return err
 	}
 
 	// Invalidate caches
-	r.cache.Delete(ctx, fmt.Sprintf("payment:%d", id))
-	r.cache.Delete(ctx, fmt.Sprintf("payment:order:%d", payment.OrderID))
+	r.invalidate(ctx, &payment)
+
+	return nil
+}
+
+// invalidate drops the cached entries of a payment. They are dropped straight
+// away, so reads later in the same transaction see the change, and again once
+// the transaction commits, as reads outside it may have cached the old
+// payment meanwhile.
+func (r *PaymentRepositoryImpl) invalidate(ctx context.Context, payment *domain.Payment) {
+	keys := []string{
+		fmt.Sprintf("payment:%d", payment.ID),
+		fmt.Sprintf("payment:order:%d", payment.OrderID),
+	}
 	if payment.TransactionID != "" {
-		r.cache.Delete(ctx, fmt.Sprintf("payment:transaction:%s", payment.TransactionID))
+		keys = append(keys, fmt.Sprintf("payment:transaction:%s", payment.TransactionID))
 	}
 
-	return nil
+	drop := func() {
+		for _, key := range keys {
+			r.cache.Delete(ctx, key)
+		}
+	}
+	drop()
+	afterCommit(ctx, drop)
 }
 
 // FindByTransactionID retrieves a payment by its transaction ID
@@ -180,20 +192,20 @@ func (r *PaymentRepositoryImpl) FindByTransactionID(ctx context.Context, transac
 
 	// Cache miss, get from database
 	var payment domain.Payment
-	if err := r.db.Where("transaction_id = ?", transactionID).First(&payment).Error; err != nil {
+	if err := conn(ctx, r.db).Where("transaction_id = ?", transactionID).First(&payment).Error; err != nil {
 		return nil, err
 	}
 
 	// Store payment ID in cache for future requests
 	paymentIDJSON, err := json.Marshal(payment.ID)
 	if err == nil {
-		r.cache.Set(ctx, cacheKey, paymentIDJSON, 30*time.Minute)
+		afterCommit(ctx, func() { r.cache.Set(ctx, cacheKey, paymentIDJSON, 30*time.Minute) })
 	}
 
 	// Also cache the full payment
 	paymentJSON, err := json.Marshal(payment)
 	if err == nil {
-		r.cache.Set(ctx, fmt.Sprintf("payment:%d", payment.ID), paymentJSON, 30*time.Minute)
+		afterCommit(ctx, func() { r.cache.Set(ctx, fmt.Sprintf("payment:%d", payment.ID), paymentJSON, 30*time.Minute) })
 	}
 
 	return &payment, nil
@@ -205,13 +217,13 @@ func (r *PaymentRepositoryImpl) FindAll(ctx context.Context, page, pageSize int)
 	var total int64
 
 	// Count total records
-	if err := r.db.Model(&domain.Payment{}).Count(&total).Error; err != nil {
+	if err := conn(ctx, r.db).Model(&domain.Payment{}).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// Apply pagination
 	offset := (page - 1) * pageSize
-	if err := r.db.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&payments).Error; err != nil {
+	if err := conn(ctx, r.db).Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&payments).Error; err != nil {
 		return nil, 0, err
 	}
 
@@ -224,13 +236,13 @@ func (r *PaymentRepositoryImpl) FindByStatus(ctx context.Context, status domain.
 	var total int64
 
 	// Count total records for the status
-	if err := r.db.Model(&domain.Payment{}).Where("status = ?", status).Count(&total).Error; err != nil {
+	if err := conn(ctx, r.db).Model(&domain.Payment{}).Where("status = ?", status).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// Apply pagination
 	offset := (page - 1) * pageSize
-	if err := r.db.Where("status = ?", status).Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&payments).Error; err != nil {
+	if err := conn(ctx, r.db).Where("status = ?", status).Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&payments).Error; err != nil {
 		return nil, 0, err
 	}
 
@@ -243,7 +255,7 @@ func (r *PaymentRepositoryImpl) FindByDateRange(ctx context.Context, startDate,
 	var total int64
 
 	// Count total records in the date range
-	if err := r.db.Model(&domain.Payment{}).
+	if err := conn(ctx, r.db).Model(&domain.Payment{}).
 		Where("created_at BETWEEN ? AND ?", startDate, endDate).
 		Count(&total).Error; err != nil {
 		return nil, 0, err
@@ -251,7 +263,7 @@ func (r *PaymentRepositoryImpl) FindByDateRange(ctx context.Context, startDate,
 
 	// Apply pagination
 	offset := (page - 1) * pageSize
-	if err := r.db.Where("created_at BETWEEN ? AND ?", startDate, endDate).
+	if err := conn(ctx, r.db).Where("created_at BETWEEN ? AND ?", startDate, endDate).
 		Order("created_at DESC").
 		Offset(offset).
 		Limit(pageSize).
@@ -268,13 +280,13 @@ func (r *PaymentRepositoryImpl) FindByMethod(ctx context.Context, method domain.
 	var total int64
 
 	// Count total records for the method
-	if err := r.db.Model(&domain.Payment{}).Where("method = ?", method).Count(&total).Error; err != nil {
+	if err := conn(ctx, r.db).Model(&domain.Payment{}).Where("method = ?", method).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// Apply pagination
 	offset := (page - 1) * pageSize
-	if err := r.db.Where("method = ?", method).Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&payments).Error; err != nil {
+	if err := conn(ctx, r.db).Where("method = ?", method).Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&payments).Error; err != nil {
 		return nil, 0, err
 	}
 
@@ -119,8 +119,7 @@ func (s *PaymentRepositoryTestSuite) TestFindByID() {
 		expectedPayment := &domain.Payment{
 			ID:            paymentID,
 			OrderID:       uint(1),
-			Amount:        100.50,
-			Currency:      "USD",
+			Amount:        usd(10050),
 			Method:        domain.PaymentMethodCreditCard,
 			Status:        domain.PaymentStatusCompleted,
 			TransactionID: "txn_123456789",
@@ -174,8 +173,7 @@ func (s *PaymentRepositoryTestSuite) TestFindByOrderID() {
 		expectedPayment := &domain.Payment{
 			ID:            paymentID,
 			OrderID:       orderID,
-			Amount:        100.50,
-			Currency:      "USD",
+			Amount:        usd(10050),
 			Method:        domain.PaymentMethodCreditCard,
 			Status:        domain.PaymentStatusCompleted,
 			TransactionID: "txn_123456789",
@@ -226,8 +224,7 @@ func (s *PaymentRepositoryTestSuite) TestCreate() {
 		paymentDate := time.Now()
 		payment := &domain.Payment{
 			OrderID:       uint(1),
-			Amount:        100.50,
-			Currency:      "USD",
+			Amount:        usd(10050),
 			Method:        domain.PaymentMethodCreditCard,
 			Status:        domain.PaymentStatusPending,
 			TransactionID: "txn_123456789",
@@ -253,8 +250,7 @@ func (s *PaymentRepositoryTestSuite) TestCreate() {
 		paymentDate := time.Now()
 		payment := &domain.Payment{
 			OrderID:       uint(1),
-			Amount:        100.50,
-			Currency:      "USD",
+			Amount:        usd(10050),
 			Method:        domain.PaymentMethodCreditCard,
 			Status:        domain.PaymentStatusPending,
 			TransactionID: "txn_123456789",
@@ -284,8 +280,7 @@ func (s *PaymentRepositoryTestSuite) TestUpdate() {
 		payment := &domain.Payment{
 			ID:            uint(1),
 			OrderID:       uint(1),
-			Amount:        100.50,
-			Currency:      "USD",
+			Amount:        usd(10050),
 			Method:        domain.PaymentMethodCreditCard,
 			Status:        domain.PaymentStatusCompleted,
 			TransactionID: "txn_123456789",
@@ -312,8 +307,7 @@ func (s *PaymentRepositoryTestSuite) TestUpdate() {
 		payment := &domain.Payment{
 			ID:            uint(1),
 			OrderID:       uint(1),
-			Amount:        100.50,
-			Currency:      "USD",
+			Amount:        usd(10050),
 			Method:        domain.PaymentMethodCreditCard,
 			Status:        domain.PaymentStatusCompleted,
 			TransactionID: "txn_123456789",
@@ -424,8 +418,7 @@ func (s *PaymentRepositoryTestSuite) TestFindByTransactionID() {
 		expectedPayment := &domain.Payment{
 			ID:            uint(1),
 			OrderID:       uint(1),
-			Amount:        100.50,
-			Currency:      "USD",
+			Amount:        usd(10050),
 			Method:        domain.PaymentMethodCreditCard,
 			Status:        domain.PaymentStatusCompleted,
 			TransactionID: transactionID,
@@ -480,8 +473,7 @@ func (s *PaymentRepositoryTestSuite) TestFindAll() {
 			{
 				ID:            uint(1),
 				OrderID:       uint(1),
-				Amount:        100.50,
-				Currency:      "USD",
+				Amount:        usd(10050),
 				Method:        domain.PaymentMethodCreditCard,
 				Status:        domain.PaymentStatusCompleted,
 				TransactionID: "txn_123456789",
@@ -492,8 +484,7 @@ func (s *PaymentRepositoryTestSuite) TestFindAll() {
 			{
 				ID:            uint(2),
 				OrderID:       uint(2),
-				Amount:        200.75,
-				Currency:      "EUR",
+				Amount:        domain.Money{Amount: 20075, Currency: "EUR"},
 				Method:        domain.PaymentMethodPayPal,
 				Status:        domain.PaymentStatusPending,
 				TransactionID: "txn_987654321",
@@ -577,8 +568,7 @@ func (s *PaymentRepositoryTestSuite) TestFindByStatus() {
 			{
 				ID:            uint(1),
 				OrderID:       uint(1),
-				Amount:        100.50,
-				Currency:      "USD",
+				Amount:        usd(10050),
 				Method:        domain.PaymentMethodCreditCard,
 				Status:        status,
 				TransactionID: "txn_123456789",
@@ -589,8 +579,7 @@ func (s *PaymentRepositoryTestSuite) TestFindByStatus() {
 			{
 				ID:            uint(3),
 				OrderID:       uint(3),
-				Amount:        300.25,
-				Currency:      "USD",
+				Amount:        usd(30025),
 				Method:        domain.PaymentMethodDebitCard,
 				Status:        status,
 				TransactionID: "txn_567891234",
@@ -677,8 +666,7 @@ func (s *PaymentRepositoryTestSuite) TestFindByDateRange() {
 			{
 				ID:            uint(1),
 				OrderID:       uint(1),
-				Amount:        100.50,
-				Currency:      "USD",
+				Amount:        usd(10050),
 				Method:        domain.PaymentMethodCreditCard,
 				Status:        domain.PaymentStatusCompleted,
 				TransactionID: "txn_123456789",
@@ -689,8 +677,7 @@ func (s *PaymentRepositoryTestSuite) TestFindByDateRange() {
 			{
 				ID:            uint(2),
 				OrderID:       uint(2),
-				Amount:        200.75,
-				Currency:      "EUR",
+				Amount:        domain.Money{Amount: 20075, Currency: "EUR"},
 				Method:        domain.PaymentMethodPayPal,
 				Status:        domain.PaymentStatusPending,
 				TransactionID: "txn_987654321",
@@ -778,8 +765,7 @@ func (s *PaymentRepositoryTestSuite) TestFindByMethod() {
 			{
 				ID:            uint(1),
 				OrderID:       uint(1),
-				Amount:        100.50,
-				Currency:      "USD",
+				Amount:        usd(10050),
 				Method:        method,
 				Status:        domain.PaymentStatusCompleted,
 				TransactionID: "txn_123456789",
@@ -790,8 +776,7 @@ func (s *PaymentRepositoryTestSuite) TestFindByMethod() {
 			{
 				ID:            uint(3),
 				OrderID:       uint(3),
-				Amount:        300.25,
-				Currency:      "USD",
+				Amount:        usd(30025),
 				Method:        method,
 				Status:        domain.PaymentStatusPending,
 				TransactionID: "txn_567891234",
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"time"
 
 	"awesomeEcommerce/internal/domain"
@@ -19,6 +20,30 @@ type ProductRepositoryImpl struct {
 	cache *cache.RedisClient
 }
 
+// Cache tags grouping the cached product data that must be invalidated together
+const (
+	// catalogTag tags every unfiltered product listing
+	catalogTag = "catalog"
+
+	// categoriesTag tags the category list
+	categoriesTag = "categories"
+)
+
+// productKey returns the cache key of a product
+func productKey(id uint) string {
+	return fmt.Sprintf("product:%d", id)
+}
+
+// productTag returns the cache tag of a product and every listing it shows up in
+func productTag(id uint) string {
+	return fmt.Sprintf("product:%d", id)
+}
+
+// categoryTag returns the cache tag of a category and its product listings
+func categoryTag(id uint) string {
+	return fmt.Sprintf("category:%d", id)
+}
+
 // NewProductRepository creates a new ProductRepositoryImpl
 func NewProductRepository(db *gorm.DB, cache *cache.RedisClient) repository.ProductRepository {
 	return &ProductRepositoryImpl{
@@ -30,98 +55,202 @@ func NewProductRepository(db *gorm.DB, cache *cache.RedisClient) repository.Prod
 // FindByID retrieves a product by its ID
 func (r *ProductRepositoryImpl) FindByID(ctx context.Context, id uint) (*domain.Product, error) {
 	// Try to get from cache first
-	cacheKey := fmt.Sprintf("product:%d", id)
+	cacheKey := productKey(id)
 	cachedProduct, err := r.cache.Get(ctx, cacheKey)
 	if err == nil {
 		// Cache hit
 		var product domain.Product
 		if err := json.Unmarshal([]byte(cachedProduct), &product); err == nil {
+			r.cache.RecordHit(ctx, "product")
 			return &product, nil
 		}
 	}
+	r.cache.RecordMiss(ctx, "product")
 
 	// Cache miss, get from database
 	var product domain.Product
-	if err := r.db.First(&product, id).Error; err != nil {
+	if err := conn(ctx, r.db).First(&product, id).Error; err != nil {
 		return nil, err
 	}
 
 	// Store in cache for future requests
 	productJSON, err := json.Marshal(product)
 	if err == nil {
-		r.cache.Set(ctx, cacheKey, productJSON, 30*time.Minute)
+		afterCommit(ctx, func() { r.cache.SetWithTags(ctx, cacheKey, productJSON, 30*time.Minute, productTag(product.ID)) })
 	}
 
 	return &product, nil
 }
 
-// FindAll retrieves all products with optional pagination
+// FindAll retrieves all products still sold with optional pagination
 func (r *ProductRepositoryImpl) FindAll(ctx context.Context, page, pageSize int) ([]domain.Product, int64, error) {
+	// Try to get from cache first
+	cacheKey := fmt.Sprintf("products:page:%d:size:%d", page, pageSize)
+	if listing, ok := r.getCachedListing(ctx, cacheKey); ok {
+		return listing.Products, listing.Total, nil
+	}
+
 	var products []domain.Product
 	var total int64
 
 	// Count total records
-	if err := r.db.Model(&domain.Product{}).Count(&total).Error; err != nil {
+	if err := conn(ctx, r.db).Model(&domain.Product{}).Where("archived = ?", false).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// Apply pagination
 	offset := (page - 1) * pageSize
-	if err := r.db.Offset(offset).Limit(pageSize).Find(&products).Error; err != nil {
+	if err := conn(ctx, r.db).Where("archived = ?", false).Offset(offset).Limit(pageSize).Find(&products).Error; err != nil {
 		return nil, 0, err
 	}
 
+	// Store in cache for future requests
+	r.setCachedListing(ctx, cacheKey, products, total, catalogTag)
+
 	return products, total, nil
 }
 
-// FindByCategory retrieves products by category ID with optional pagination
+// FindByCategory retrieves products still sold by category ID with optional pagination
 func (r *ProductRepositoryImpl) FindByCategory(ctx context.Context, categoryID uint, page, pageSize int) ([]domain.Product, int64, error) {
+	// Try to get from cache first
+	cacheKey := fmt.Sprintf("products:category:%d:page:%d:size:%d", categoryID, page, pageSize)
+	if listing, ok := r.getCachedListing(ctx, cacheKey); ok {
+		return listing.Products, listing.Total, nil
+	}
+
 	var products []domain.Product
 	var total int64
 
 	// Count total records for the category
-	if err := r.db.Model(&domain.Product{}).Where("category_id = ?", categoryID).Count(&total).Error; err != nil {
+	if err := conn(ctx, r.db).Model(&domain.Product{}).Where("category_id = ? AND archived = ?", categoryID, false).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// Apply pagination
 	offset := (page - 1) * pageSize
-	if err := r.db.Where("category_id = ?", categoryID).Offset(offset).Limit(pageSize).Find(&products).Error; err != nil {
+	if err := conn(ctx, r.db).Where("category_id = ? AND archived = ?", categoryID, false).Offset(offset).Limit(pageSize).Find(&products).Error; err != nil {
 		return nil, 0, err
 	}
 
+	// Store in cache for future requests
+	r.setCachedListing(ctx, cacheKey, products, total, categoryTag(categoryID))
+
 	return products, total, nil
 }
 
+// productListing is the cached form of a page of products
+type productListing struct {
+	Products []domain.Product `json:"products"`
+	Total    int64            `json:"total"`
+}
+
+// getCachedListing retrieves a cached page of products
+func (r *ProductRepositoryImpl) getCachedListing(ctx context.Context, cacheKey string) (*productListing, bool) {
+	cachedListing, err := r.cache.Get(ctx, cacheKey)
+	if err == nil {
+		// Cache hit
+		var listing productListing
+		if err := json.Unmarshal([]byte(cachedListing), &listing); err == nil {
+			r.cache.RecordHit(ctx, "product_list")
+			return &listing, true
+		}
+	}
+	r.cache.RecordMiss(ctx, "product_list")
+	return nil, false
+}
+
+// setCachedListing caches a page of products under the given tag and the tag of
+// every product on the page, so that changing any of them invalidates the page
+func (r *ProductRepositoryImpl) setCachedListing(ctx context.Context, cacheKey string, products []domain.Product, total int64, tag string) {
+	listingJSON, err := json.Marshal(productListing{Products: products, Total: total})
+	if err != nil {
+		return
+	}
+
+	tags := []string{tag}
+	for _, product := range products {
+		tags = append(tags, productTag(product.ID))
+	}
+	afterCommit(ctx, func() { r.cache.SetWithTags(ctx, cacheKey, listingJSON, 10*time.Minute, tags...) })
+}
+
 // Create creates a new product
 func (r *ProductRepositoryImpl) Create(ctx context.Context, product *domain.Product) error {
-	return r.db.Create(product).Error
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(product).Error; err != nil {
+			return err
+		}
+
+		// Open the product's ledger with its initial stock
+		return recordStockChange(tx, product.ID, product.Stock, domain.StockChangeFromContext(ctx))
+	})
+	if err != nil {
+		return err
+	}
+
+	// Invalidate the listings the new product shows up in
+	afterCommit(ctx, func() { r.cache.InvalidateTags(ctx, catalogTag, categoryTag(product.CategoryID)) })
+
+	return nil
 }
 
 // Update updates an existing product
 func (r *ProductRepositoryImpl) Update(ctx context.Context, product *domain.Product) error {
-	// Update in database
-	if err := r.db.Save(product).Error; err != nil {
+	// Update in database, recording any change of stock in the ledger
+	var prices map[uint]domain.Money
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		stock, err := lockProductStock(tx, product.ID)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Save(product).Error; err != nil {
+			return err
+		}
+
+		// Bundles sold at percent off follow the prices of their components
+		if prices, err = repriceBundles(tx, product.ID); err != nil {
+			return err
+		}
+
+		return recordStockChange(tx, product.ID, product.Stock-stock, domain.StockChangeFromContext(ctx))
+	})
+	if err != nil {
 		return err
 	}
+	if price, ok := prices[product.ID]; ok {
+		product.Price = price
+	}
 
-	// Invalidate cache
-	cacheKey := fmt.Sprintf("product:%d", product.ID)
-	r.cache.Delete(ctx, cacheKey)
+	// Invalidate the product and every listing it shows up in, including the
+	// listings of the category it may have moved to and the repriced bundles.
+	// Archiving or restoring a product changes the whole catalogue.
+	ids := []uint{product.ID}
+	for bundleID := range prices {
+		if bundleID != product.ID {
+			ids = append(ids, bundleID)
+		}
+	}
+	r.invalidateProducts(ctx, ids, categoryTag(product.CategoryID), catalogTag)
 
 	return nil
 }
 
 // Delete deletes a product by its ID
 func (r *ProductRepositoryImpl) Delete(ctx context.Context, id uint) error {
+	// Get the product to find its category
+	var product domain.Product
+	if err := conn(ctx, r.db).First(&product, id).Error; err != nil {
+		return err
+	}
+
 	// Delete from database
-	if err := r.db.Delete(&domain.Product{}, id).Error; err != nil {
+	if err := conn(ctx, r.db).Delete(&domain.Product{}, id).Error; err != nil {
 		return err
 	}
 
-	// Invalidate cache
-	cacheKey := fmt.Sprintf("product:%d", id)
-	r.cache.Delete(ctx, cacheKey)
+	// Invalidate the product and every listing whose pages or totals change
+	r.invalidateProducts(ctx, []uint{id}, catalogTag, categoryTag(product.CategoryID))
 
 	return nil
 }
@@ -135,68 +264,244 @@ func (r *ProductRepositoryImpl) FindBySKU(ctx context.Context, sku string) (*dom
 		// Cache hit
 		var product domain.Product
 		if err := json.Unmarshal([]byte(cachedProduct), &product); err == nil {
+			r.cache.RecordHit(ctx, "product")
 			return &product, nil
 		}
 	}
+	r.cache.RecordMiss(ctx, "product")
 
 	// Cache miss, get from database
 	var product domain.Product
-	if err := r.db.Where("sku = ?", sku).First(&product).Error; err != nil {
+	if err := conn(ctx, r.db).Where("sku = ?", sku).First(&product).Error; err != nil {
 		return nil, err
 	}
 
 	// Store in cache for future requests
 	productJSON, err := json.Marshal(product)
 	if err == nil {
-		r.cache.Set(ctx, cacheKey, productJSON, 30*time.Minute)
+		afterCommit(ctx, func() { r.cache.SetWithTags(ctx, cacheKey, productJSON, 30*time.Minute, productTag(product.ID)) })
 	}
 
 	return &product, nil
 }
 
-// UpdateStock updates the stock of a product
-func (r *ProductRepositoryImpl) UpdateStock(ctx context.Context, id uint, quantity int) error {
+// UpdateStock updates the stock of a product and records the change in the inventory ledger.
+// A decrease fails with *domain.InsufficientStockError rather than take stock below zero.
+func (r *ProductRepositoryImpl) UpdateStock(ctx context.Context, id uint, quantity int, change domain.StockChange) error {
+	if quantity < 0 {
+		return r.DecrementStock(ctx, []domain.StockLine{{ProductID: id, Quantity: -quantity}}, change)
+	}
+
 	// Update stock in database
-	if err := r.db.Model(&domain.Product{}).Where("id = ?", id).Update("stock", gorm.Expr("stock + ?", quantity)).Error; err != nil {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.Product{}).Where("id = ?", id).Update("stock", gorm.Expr("stock + ?", quantity)).Error; err != nil {
+			return err
+		}
+
+		return recordStockChange(tx, id, quantity, change)
+	})
+	if err != nil {
 		return err
 	}
 
-	// Invalidate cache
-	cacheKey := fmt.Sprintf("product:%d", id)
-	r.cache.Delete(ctx, cacheKey)
+	// Invalidate the product and every listing it shows up in
+	r.invalidateProducts(ctx, []uint{id})
 
 	return nil
 }
 
+// DecrementStock takes every line from stock in one transaction, all or nothing,
+// failing with *domain.InsufficientStockError listing each line short of stock
+func (r *ProductRepositoryImpl) DecrementStock(ctx context.Context, lines []domain.StockLine, change domain.StockChange) error {
+	lines = mergeStockLines(lines)
+
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var shortages []domain.StockShortage
+		for _, line := range lines {
+			// The stock guard makes the check and the decrement one atomic statement,
+			// so concurrent checkouts can never take the same units twice
+			result := tx.Model(&domain.Product{}).
+				Where("id = ? AND stock >= ?", line.ProductID, line.Quantity).
+				Update("stock", gorm.Expr("stock - ?", line.Quantity))
+			if result.Error != nil {
+				return result.Error
+			}
+
+			if result.RowsAffected == 0 {
+				var product domain.Product
+				if err := tx.Select("id", "name", "stock").First(&product, line.ProductID).Error; err != nil {
+					return err
+				}
+				shortages = append(shortages, domain.StockShortage{
+					ProductID:   product.ID,
+					ProductName: product.Name,
+					Requested:   line.Quantity,
+					Available:   product.Stock,
+				})
+				continue
+			}
+
+			if err := recordStockChange(tx, line.ProductID, -line.Quantity, change); err != nil {
+				return err
+			}
+		}
+
+		// Rolling back returns the lines already taken
+		if len(shortages) > 0 {
+			return &domain.InsufficientStockError{Shortages: shortages}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Invalidate the products and every listing they show up in
+	ids := make([]uint, 0, len(lines))
+	for _, line := range lines {
+		ids = append(ids, line.ProductID)
+	}
+	if len(ids) > 0 {
+		r.invalidateProducts(ctx, ids)
+	}
+
+	return nil
+}
+
+// invalidateProducts removes the cached products and every value under their
+// tags or the given tags once the transaction commits. The products' own keys
+// are deleted directly, so they go even if their tag indexes are already gone.
+func (r *ProductRepositoryImpl) invalidateProducts(ctx context.Context, ids []uint, tags ...string) {
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, productKey(id))
+		tags = append(tags, productTag(id))
+	}
+
+	afterCommit(ctx, func() {
+		for _, key := range keys {
+			r.cache.Delete(ctx, key)
+		}
+		r.cache.InvalidateTags(ctx, tags...)
+	})
+}
+
+// GetCacheStats retrieves the hit and miss counters of the product caches by namespace
+func (r *ProductRepositoryImpl) GetCacheStats(ctx context.Context) (map[string]domain.CacheStats, error) {
+	return r.cache.GetStats(ctx)
+}
+
+// mergeStockLines combines the lines of each product and orders them by
+// product ID, so that concurrent transactions lock product rows in the same
+// order and cannot deadlock
+func mergeStockLines(lines []domain.StockLine) []domain.StockLine {
+	quantities := make(map[uint]int, len(lines))
+	for _, line := range lines {
+		quantities[line.ProductID] += line.Quantity
+	}
+
+	merged := make([]domain.StockLine, 0, len(quantities))
+	for productID, quantity := range quantities {
+		merged = append(merged, domain.StockLine{ProductID: productID, Quantity: quantity})
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].ProductID < merged[j].ProductID
+	})
+	return merged
+}
+
 // FindCategories retrieves all product categories
 func (r *ProductRepositoryImpl) FindCategories(ctx context.Context) ([]domain.ProductCategory, error) {
+	// Try to get from cache first
+	cacheKey := "categories"
+	cachedCategories, err := r.cache.Get(ctx, cacheKey)
+	if err == nil {
+		// Cache hit
+		var categories []domain.ProductCategory
+		if err := json.Unmarshal([]byte(cachedCategories), &categories); err == nil {
+			r.cache.RecordHit(ctx, "category")
+			return categories, nil
+		}
+	}
+	r.cache.RecordMiss(ctx, "category")
+
+	// Cache miss, get from database
 	var categories []domain.ProductCategory
-	if err := r.db.Find(&categories).Error; err != nil {
+	if err := conn(ctx, r.db).Find(&categories).Error; err != nil {
 		return nil, err
 	}
+
+	// Store in cache for future requests
+	categoriesJSON, err := json.Marshal(categories)
+	if err == nil {
+		afterCommit(ctx, func() { r.cache.SetWithTags(ctx, cacheKey, categoriesJSON, 30*time.Minute, categoriesTag) })
+	}
+
 	return categories, nil
 }
 
 // FindCategoryByID retrieves a product category by its ID
 func (r *ProductRepositoryImpl) FindCategoryByID(ctx context.Context, id uint) (*domain.ProductCategory, error) {
+	// Try to get from cache first
+	cacheKey := fmt.Sprintf("category:%d", id)
+	cachedCategory, err := r.cache.Get(ctx, cacheKey)
+	if err == nil {
+		// Cache hit
+		var category domain.ProductCategory
+		if err := json.Unmarshal([]byte(cachedCategory), &category); err == nil {
+			r.cache.RecordHit(ctx, "category")
+			return &category, nil
+		}
+	}
+	r.cache.RecordMiss(ctx, "category")
+
+	// Cache miss, get from database
 	var category domain.ProductCategory
-	if err := r.db.First(&category, id).Error; err != nil {
+	if err := conn(ctx, r.db).First(&category, id).Error; err != nil {
 		return nil, err
 	}
+
+	// Store in cache for future requests
+	categoryJSON, err := json.Marshal(category)
+	if err == nil {
+		afterCommit(ctx, func() { r.cache.SetWithTags(ctx, cacheKey, categoryJSON, 30*time.Minute, categoryTag(id)) })
+	}
+
 	return &category, nil
 }
 
 // CreateCategory creates a new product category
 func (r *ProductRepositoryImpl) CreateCategory(ctx context.Context, category *domain.ProductCategory) error {
-	return r.db.Create(category).Error
+	if err := conn(ctx, r.db).Create(category).Error; err != nil {
+		return err
+	}
+
+	// Invalidate cache
+	afterCommit(ctx, func() { r.cache.InvalidateTags(ctx, categoriesTag) })
+
+	return nil
 }
 
 // UpdateCategory updates an existing product category
 func (r *ProductRepositoryImpl) UpdateCategory(ctx context.Context, category *domain.ProductCategory) error {
-	return r.db.Save(category).Error
+	if err := conn(ctx, r.db).Save(category).Error; err != nil {
+		return err
+	}
+
+	// Invalidate cache
+	afterCommit(ctx, func() { r.cache.InvalidateTags(ctx, categoriesTag, categoryTag(category.ID)) })
+
+	return nil
 }
 
 // DeleteCategory deletes a product category by its ID
 func (r *ProductRepositoryImpl) DeleteCategory(ctx context.Context, id uint) error {
-	return r.db.Delete(&domain.ProductCategory{}, id).Error
+	if err := conn(ctx, r.db).Delete(&domain.ProductCategory{}, id).Error; err != nil {
+		return err
+	}
+
+	// Invalidate cache
+	afterCommit(ctx, func() { r.cache.InvalidateTags(ctx, categoriesTag, categoryTag(id)) })
+
+	return nil
 }
\ No newline at end of file
@@ -14,6 +14,11 @@ import (
 	"github.com/stretchr/testify/suite"
 )
 
+// usd returns an amount of US dollars in cents
+func usd(cents int64) domain.Money {
+	return domain.Money{Amount: cents, Currency: "USD"}
+}
+
 // MockProductRepository is a mock implementation of the ProductRepository interface
 type MockProductRepository struct {
 	mock.Mock
@@ -66,11 +71,24 @@ func (m *MockProductRepository) FindBySKU(ctx context.Context, sku string) (*dom
 	return args.Get(0).(*domain.Product), args.Error(1)
 }
 
-func (m *MockProductRepository) UpdateStock(ctx context.Context, id uint, quantity int) error {
-	args := m.Called(ctx, id, quantity)
+func (m *MockProductRepository) UpdateStock(ctx context.Context, id uint, quantity int, change domain.StockChange) error {
+	args := m.Called(ctx, id, quantity, change)
 	return args.Error(0)
 }
 
+func (m *MockProductRepository) DecrementStock(ctx context.Context, lines []domain.StockLine, change domain.StockChange) error {
+	args := m.Called(ctx, lines, change)
+	return args.Error(0)
+}
+
+func (m *MockProductRepository) GetCacheStats(ctx context.Context) (map[string]domain.CacheStats, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]domain.CacheStats), args.Error(1)
+}
+
 func (m *MockProductRepository) FindCategories(ctx context.Context) ([]domain.ProductCategory, error) {
 	args := m.Called(ctx)
 	if args.Get(0) == nil {
@@ -148,7 +166,7 @@ func (s *ProductRepositoryTestSuite) TestFindByID() {
 			ID:          productID,
 			Name:        "Test Product",
 			Description: "This is a test product",
-			Price:       99.99,
+			Price:       usd(9999),
 			Stock:       100,
 			SKU:         "TEST-SKU-123",
 			ImageURL:    "http://example.com/image.jpg",
@@ -201,7 +219,7 @@ func (s *ProductRepositoryTestSuite) TestFindAll() {
 				ID:          uint(1),
 				Name:        "Test Product 1",
 				Description: "This is test product 1",
-				Price:       99.99,
+				Price:       usd(9999),
 				Stock:       100,
 				SKU:         "TEST-SKU-1",
 				ImageURL:    "http://example.com/image1.jpg",
@@ -211,7 +229,7 @@ func (s *ProductRepositoryTestSuite) TestFindAll() {
 				ID:          uint(2),
 				Name:        "Test Product 2",
 				Description: "This is test product 2",
-				Price:       49.99,
+				Price:       usd(4999),
 				Stock:       50,
 				SKU:         "TEST-SKU-2",
 				ImageURL:    "http://example.com/image2.jpg",
@@ -293,7 +311,7 @@ func (s *ProductRepositoryTestSuite) TestFindByCategory() {
 				ID:          uint(1),
 				Name:        "Test Product 1",
 				Description: "This is test product 1",
-				Price:       99.99,
+				Price:       usd(9999),
 				Stock:       100,
 				SKU:         "TEST-SKU-1",
 				ImageURL:    "http://example.com/image1.jpg",
@@ -303,7 +321,7 @@ func (s *ProductRepositoryTestSuite) TestFindByCategory() {
 				ID:          uint(3),
 				Name:        "Test Product 3",
 				Description: "This is test product 3",
-				Price:       79.99,
+				Price:       usd(7999),
 				Stock:       75,
 				SKU:         "TEST-SKU-3",
 				ImageURL:    "http://example.com/image3.jpg",
@@ -382,7 +400,7 @@ func (s *ProductRepositoryTestSuite) TestCreate() {
 		product := &domain.Product{
 			Name:        "New Test Product",
 			Description: "This is a new test product",
-			Price:       129.99,
+			Price:       usd(12999),
 			Stock:       200,
 			SKU:         "NEW-TEST-SKU",
 			ImageURL:    "http://example.com/new-image.jpg",
@@ -408,7 +426,7 @@ func (s *ProductRepositoryTestSuite) TestCreate() {
 		product := &domain.Product{
 			Name:        "New Test Product",
 			Description: "This is a new test product",
-			Price:       129.99,
+			Price:       usd(12999),
 			Stock:       200,
 			SKU:         "NEW-TEST-SKU",
 			ImageURL:    "http://example.com/new-image.jpg",
@@ -438,7 +456,7 @@ func (s *ProductRepositoryTestSuite) TestUpdate() {
 			ID:          uint(1),
 			Name:        "Updated Test Product",
 			Description: "This is an updated test product",
-			Price:       149.99,
+			Price:       usd(14999),
 			Stock:       150,
 			SKU:         "TEST-SKU-1",
 			ImageURL:    "http://example.com/updated-image.jpg",
@@ -465,7 +483,7 @@ func (s *ProductRepositoryTestSuite) TestUpdate() {
 			ID:          uint(999),
 			Name:        "Updated Test Product",
 			Description: "This is an updated test product",
-			Price:       149.99,
+			Price:       usd(14999),
 			Stock:       150,
 			SKU:         "TEST-SKU-999",
 			ImageURL:    "http://example.com/updated-image.jpg",
@@ -535,7 +553,7 @@ func (s *ProductRepositoryTestSuite) TestFindBySKU() {
 			ID:          uint(1),
 			Name:        "Test Product",
 			Description: "This is a test product",
-			Price:       99.99,
+			Price:       usd(9999),
 			Stock:       100,
 			SKU:         sku,
 			ImageURL:    "http://example.com/image.jpg",
@@ -578,16 +596,17 @@ func (s *ProductRepositoryTestSuite) TestFindBySKU() {
 // TestUpdateStock tests the UpdateStock method
 func (s *ProductRepositoryTestSuite) TestUpdateStock() {
 	mockRepo := s.mockRepo.(*MockProductRepository)
+	change := domain.StockChange{Reason: domain.LedgerReasonManualAdjustment, Actor: "user:1"}
 
 	s.Run("Success - Increase Stock", func() {
 		// Test case: Successfully increase product stock
 		productID := uint(1)
 		quantity := 50
 
-		mockRepo.On("UpdateStock", s.ctx, productID, quantity).Return(nil).Once()
+		mockRepo.On("UpdateStock", s.ctx, productID, quantity, change).Return(nil).Once()
 
 		// Execute
-		err := s.mockRepo.UpdateStock(s.ctx, productID, quantity)
+		err := s.mockRepo.UpdateStock(s.ctx, productID, quantity, change)
 
 		// Assert
 		assert.NoError(s.T(), err)
@@ -603,10 +622,10 @@ func (s *ProductRepositoryTestSuite) TestUpdateStock() {
 		productID := uint(1)
 		quantity := -20
 
-		mockRepo.On("UpdateStock", s.ctx, productID, quantity).Return(nil).Once()
+		mockRepo.On("UpdateStock", s.ctx, productID, quantity, change).Return(nil).Once()
 
 		// Execute
-		err := s.mockRepo.UpdateStock(s.ctx, productID, quantity)
+		err := s.mockRepo.UpdateStock(s.ctx, productID, quantity, change)
 
 		// Assert
 		assert.NoError(s.T(), err)
@@ -623,10 +642,10 @@ func (s *ProductRepositoryTestSuite) TestUpdateStock() {
 		quantity := 10
 		expectedError := errors.New("product not found")
 
-		mockRepo.On("UpdateStock", s.ctx, productID, quantity).Return(expectedError).Once()
+		mockRepo.On("UpdateStock", s.ctx, productID, quantity, change).Return(expectedError).Once()
 
 		// Execute
-		err := s.mockRepo.UpdateStock(s.ctx, productID, quantity)
+		err := s.mockRepo.UpdateStock(s.ctx, productID, quantity, change)
 
 		// Assert
 		assert.Error(s.T(), err)
@@ -644,10 +663,10 @@ func (s *ProductRepositoryTestSuite) TestUpdateStock() {
 		quantity := -200
 		expectedError := errors.New("insufficient stock")
 
-		mockRepo.On("UpdateStock", s.ctx, productID, quantity).Return(expectedError).Once()
+		mockRepo.On("UpdateStock", s.ctx, productID, quantity, change).Return(expectedError).Once()
 
 		// Execute
-		err := s.mockRepo.UpdateStock(s.ctx, productID, quantity)
+		err := s.mockRepo.UpdateStock(s.ctx, productID, quantity, change)
 
 		// Assert
 		assert.Error(s.T(), err)
@@ -950,6 +969,29 @@ func (s *ProductRepositoryTestSuite) TestDeleteCategory() {
 	})
 }
 
+// TestGetCacheStats tests the GetCacheStats method
+func (s *ProductRepositoryTestSuite) TestGetCacheStats() {
+	mockRepo := s.mockRepo.(*MockProductRepository)
+
+	s.Run("Success", func() {
+		// Test case: Successfully read the hit and miss counters
+		stats := map[string]domain.CacheStats{
+			"product":      {Hits: 8, Misses: 2},
+			"product_list": {Hits: 3, Misses: 1},
+		}
+
+		mockRepo.On("GetCacheStats", s.ctx).Return(stats, nil).Once()
+
+		// Execute
+		result, err := s.mockRepo.GetCacheStats(s.ctx)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), stats, result)
+		mockRepo.AssertExpectations(s.T())
+	})
+}
+
 // TestProductRepositorySuite runs the test suite
 func TestProductRepositorySuite(t *testing.T) {
 	suite.Run(t, new(ProductRepositoryTestSuite))
@@ -0,0 +1,82 @@
+package impl
+
+import (
+	"context"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// PromotionRepositoryImpl implements the PromotionRepository interface
+type PromotionRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewPromotionRepository creates a new PromotionRepositoryImpl
+func NewPromotionRepository(db *gorm.DB) repository.PromotionRepository {
+	return &PromotionRepositoryImpl{
+		db: db,
+	}
+}
+
+// FindByID retrieves a promotion by its ID
+func (r *PromotionRepositoryImpl) FindByID(ctx context.Context, id uint) (*domain.Promotion, error) {
+	var promotion domain.Promotion
+	if err := r.db.First(&promotion, id).Error; err != nil {
+		return nil, err
+	}
+	return &promotion, nil
+}
+
+// FindAll retrieves all promotions with optional pagination
+func (r *PromotionRepositoryImpl) FindAll(ctx context.Context, page, pageSize int) ([]domain.Promotion, int64, error) {
+	var promotions []domain.Promotion
+	var total int64
+
+	// Count total records
+	if err := r.db.Model(&domain.Promotion{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Apply pagination
+	offset := (page - 1) * pageSize
+	if err := r.db.Order("priority DESC, id").Offset(offset).Limit(pageSize).Find(&promotions).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return promotions, total, nil
+}
+
+// FindLive retrieves the active promotions running at a time, highest
+// priority first
+func (r *PromotionRepositoryImpl) FindLive(ctx context.Context, at time.Time) ([]domain.Promotion, error) {
+	var promotions []domain.Promotion
+	err := r.db.
+		Where("active = ?", true).
+		Where("starts_at IS NULL OR starts_at <= ?", at).
+		Where("ends_at IS NULL OR ends_at > ?", at).
+		Order("priority DESC, id").
+		Find(&promotions).Error
+	if err != nil {
+		return nil, err
+	}
+	return promotions, nil
+}
+
+// Create creates a new promotion
+func (r *PromotionRepositoryImpl) Create(ctx context.Context, promotion *domain.Promotion) error {
+	return r.db.Create(promotion).Error
+}
+
+// Update updates an existing promotion
+func (r *PromotionRepositoryImpl) Update(ctx context.Context, promotion *domain.Promotion) error {
+	return r.db.Save(promotion).Error
+}
+
+// Delete deletes a promotion by its ID
+func (r *PromotionRepositoryImpl) Delete(ctx context.Context, id uint) error {
+	return r.db.Delete(&domain.Promotion{}, id).Error
+}
@@ -0,0 +1,127 @@
+package impl_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+	"awesomeEcommerce/internal/repository/impl"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// PromotionRepositoryTestSuite is a test suite for PromotionRepositoryImpl
+type PromotionRepositoryTestSuite struct {
+	suite.Suite
+	repo    repository.PromotionRepository
+	sqlMock sqlmock.Sqlmock
+	ctx     context.Context
+}
+
+// SetupTest sets up the test suite
+func (s *PromotionRepositoryTestSuite) SetupTest() {
+	db, sqlMock := newMockDB(s.T())
+	s.repo = impl.NewPromotionRepository(db)
+	s.sqlMock = sqlMock
+	s.ctx = context.Background()
+}
+
+// TestFindLive tests the FindLive method
+func (s *PromotionRepositoryTestSuite) TestFindLive() {
+	at := time.Now()
+
+	s.Run("Success", func() {
+		// Test case: Active promotions whose window includes the time, highest
+		// priority first, with their tiers decoded
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `promotions` WHERE active = ? AND (starts_at IS NULL OR starts_at <= ?) AND (ends_at IS NULL OR ends_at > ?) ORDER BY priority DESC, id")).
+			WithArgs(true, at, at).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "type", "priority", "tiers"}).
+				AddRow(2, "Spend more", domain.PromotionTypeSpendTiers, 10, `[{"min_subtotal":{"amount":5000,"currency":"EUR"},"percent_off":10}]`).
+				AddRow(1, "Two for one", domain.PromotionTypeBuyXGetY, 0, nil))
+
+		// Execute
+		promotions, err := s.repo.FindLive(s.ctx, at)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Len(s.T(), promotions, 2)
+		assert.Equal(s.T(), []domain.PromotionTier{
+			{MinSubtotal: domain.Money{Amount: 5000, Currency: "EUR"}, PercentOff: 10},
+		}, promotions[0].Tiers)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+
+	s.Run("Error - Database Error", func() {
+		// Reset mock
+		s.SetupTest()
+
+		// Test case: Database error
+		expectedError := errors.New("database error")
+		s.sqlMock.ExpectQuery("SELECT \\* FROM `promotions`").WillReturnError(expectedError)
+
+		// Execute
+		promotions, err := s.repo.FindLive(s.ctx, at)
+
+		// Assert
+		assert.ErrorIs(s.T(), err, expectedError)
+		assert.Nil(s.T(), promotions)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestFindAll tests the FindAll method
+func (s *PromotionRepositoryTestSuite) TestFindAll() {
+	s.Run("Success", func() {
+		// Test case: The second page of promotions, highest priority first
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `promotions`")).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `promotions` ORDER BY priority DESC, id LIMIT 2 OFFSET 2")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(3, "Last"))
+
+		// Execute
+		promotions, total, err := s.repo.FindAll(s.ctx, 2, 2)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), int64(3), total)
+		assert.Len(s.T(), promotions, 1)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestCreate tests the Create method
+func (s *PromotionRepositoryTestSuite) TestCreate() {
+	s.Run("Success", func() {
+		// Test case: The product IDs are stored as JSON, and unset lists as NULL
+		promotion := &domain.Promotion{
+			Name:       "Kitchen week",
+			Type:       domain.PromotionTypeCategoryPercent,
+			ProductIDs: []uint{1, 2},
+			PercentOff: 15,
+			Active:     true,
+		}
+		s.sqlMock.ExpectExec("INSERT INTO `promotions`").
+			WithArgs("Kitchen week", "", domain.PromotionTypeCategoryPercent, 0, false, "[1,2]", nil, 15.0, 0, 0, 0, nil,
+				nil, nil, true, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(4, 1))
+
+		// Execute
+		err := s.repo.Create(s.ctx, promotion)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), uint(4), promotion.ID)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestPromotionRepositorySuite runs the test suite
+func TestPromotionRepositorySuite(t *testing.T) {
+	suite.Run(t, new(PromotionRepositoryTestSuite))
+}
@@ -0,0 +1,160 @@
+package impl
+
+import (
+	"context"
+	"slices"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// unpurchasedOrderStatuses are the statuses of orders whose products were not
+// bought together after all, which are left out of bought-together scores
+var unpurchasedOrderStatuses = []domain.OrderStatus{
+	domain.OrderStatusCancelled,
+	domain.OrderStatusReturned,
+	domain.OrderStatusRefunded,
+}
+
+// RecommendationRepositoryImpl implements the RecommendationRepository interface
+type RecommendationRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewRecommendationRepository creates a new RecommendationRepositoryImpl
+func NewRecommendationRepository(db *gorm.DB) repository.RecommendationRepository {
+	return &RecommendationRepositoryImpl{
+		db: db,
+	}
+}
+
+// FindByProductID retrieves the highest scored recommendations for a product
+func (r *RecommendationRepositoryImpl) FindByProductID(ctx context.Context, productID uint, limit int) ([]domain.ProductRecommendation, error) {
+	var recommendations []domain.ProductRecommendation
+	if err := conn(ctx, r.db).Preload("RelatedProduct").
+		Where("product_id = ?", productID).
+		Order("score DESC").
+		Limit(limit).
+		Find(&recommendations).Error; err != nil {
+		return nil, err
+	}
+	return recommendations, nil
+}
+
+// FindByProductIDs retrieves recommendations for any of the given products
+func (r *RecommendationRepositoryImpl) FindByProductIDs(ctx context.Context, productIDs []uint) ([]domain.ProductRecommendation, error) {
+	var recommendations []domain.ProductRecommendation
+	if len(productIDs) == 0 {
+		return recommendations, nil
+	}
+
+	if err := conn(ctx, r.db).Preload("RelatedProduct").
+		Where("product_id IN ?", productIDs).
+		Order("score DESC").
+		Find(&recommendations).Error; err != nil {
+		return nil, err
+	}
+	return recommendations, nil
+}
+
+// IncrementBoughtTogether adds delta to the bought-together score of a product pair
+func (r *RecommendationRepositoryImpl) IncrementBoughtTogether(ctx context.Context, productID, relatedProductID uint, delta float64) error {
+	recommendation := &domain.ProductRecommendation{
+		ProductID:        productID,
+		RelatedProductID: relatedProductID,
+		Type:             domain.RecommendationTypeBoughtTogether,
+		Score:            delta,
+	}
+
+	return conn(ctx, r.db).Omit("RelatedProduct").Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "product_id"}, {Name: "related_product_id"}, {Name: "type"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"score":      gorm.Expr("score + ?", delta),
+			"updated_at": gorm.Expr("CURRENT_TIMESTAMP"),
+		}),
+	}).Create(recommendation).Error
+}
+
+// RebuildBoughtTogether recomputes bought-together scores from the items of
+// purchased orders up to the given order ID
+func (r *RecommendationRepositoryImpl) RebuildBoughtTogether(ctx context.Context, upToOrderID uint) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		// Drop the previous scores
+		if err := tx.Where("type = ?", domain.RecommendationTypeBoughtTogether).Delete(&domain.ProductRecommendation{}).Error; err != nil {
+			return err
+		}
+
+		// Count the orders in which each pair of products appears together
+		return tx.Exec(`INSERT INTO product_recommendations (product_id, related_product_id, type, score, created_at, updated_at)
+			SELECT a.product_id, b.product_id, ?, COUNT(DISTINCT a.order_id), CURRENT_TIMESTAMP, CURRENT_TIMESTAMP
+			FROM order_items a
+			JOIN order_items b ON a.order_id = b.order_id AND a.product_id <> b.product_id
+			JOIN orders o ON o.id = a.order_id
+			WHERE a.order_id <= ? AND o.status NOT IN ?
+			GROUP BY a.product_id, b.product_id`,
+			domain.RecommendationTypeBoughtTogether, upToOrderID, unpurchasedOrderStatuses).Error
+	})
+}
+
+// ReplaceByType replaces all recommendations of a type with the given set
+func (r *RecommendationRepositoryImpl) ReplaceByType(ctx context.Context, recommendationType domain.RecommendationType, recommendations []domain.ProductRecommendation) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("type = ?", recommendationType).Delete(&domain.ProductRecommendation{}).Error; err != nil {
+			return err
+		}
+
+		if len(recommendations) == 0 {
+			return nil
+		}
+
+		return tx.Omit("RelatedProduct").CreateInBatches(recommendations, 500).Error
+	})
+}
+
+// GetLatestOrderID retrieves the ID of the most recently created order
+func (r *RecommendationRepositoryImpl) GetLatestOrderID(ctx context.Context) (uint, error) {
+	var latestID uint
+	if err := conn(ctx, r.db).Model(&domain.Order{}).Select("COALESCE(MAX(id), 0)").Scan(&latestID).Error; err != nil {
+		return 0, err
+	}
+	return latestID, nil
+}
+
+// FindOrderItemsAfter retrieves the items of the purchased orders among the
+// next limit orders created after the given order ID, and the ID of the last
+// of those orders, or afterOrderID when there are none
+func (r *RecommendationRepositoryImpl) FindOrderItemsAfter(ctx context.Context, afterOrderID uint, limit int) ([]domain.OrderItem, uint, error) {
+	var orders []domain.Order
+	if err := conn(ctx, r.db).Select("id", "status").
+		Where("id > ?", afterOrderID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&orders).Error; err != nil {
+		return nil, afterOrderID, err
+	}
+
+	var items []domain.OrderItem
+	if len(orders) == 0 {
+		return items, afterOrderID, nil
+	}
+	lastOrderID := orders[len(orders)-1].ID
+
+	// Orders that were not purchased after all still move the batch on
+	var orderIDs []uint
+	for _, order := range orders {
+		if !slices.Contains(unpurchasedOrderStatuses, order.Status) {
+			orderIDs = append(orderIDs, order.ID)
+		}
+	}
+	if len(orderIDs) == 0 {
+		return items, lastOrderID, nil
+	}
+
+	if err := conn(ctx, r.db).Where("order_id IN ?", orderIDs).Order("order_id ASC").Find(&items).Error; err != nil {
+		return nil, afterOrderID, err
+	}
+	return items, lastOrderID, nil
+}
@@ -0,0 +1,238 @@
+package impl_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+	"awesomeEcommerce/internal/repository/impl"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// RecommendationRepositoryTestSuite is a test suite for RecommendationRepositoryImpl
+type RecommendationRepositoryTestSuite struct {
+	suite.Suite
+	repo    repository.RecommendationRepository
+	sqlMock sqlmock.Sqlmock
+	ctx     context.Context
+}
+
+// SetupTest sets up the test suite
+func (s *RecommendationRepositoryTestSuite) SetupTest() {
+	db, sqlMock := newMockDB(s.T())
+	s.repo = impl.NewRecommendationRepository(db)
+	s.sqlMock = sqlMock
+	s.ctx = context.Background()
+}
+
+// TestFindByProductID tests the FindByProductID method
+func (s *RecommendationRepositoryTestSuite) TestFindByProductID() {
+	s.Run("Success", func() {
+		// Test case: The highest scored recommendations come with their products
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `product_recommendations` WHERE product_id = ? ORDER BY score DESC LIMIT 5")).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "product_id", "related_product_id", "type", "score"}).
+				AddRow(1, 1, 2, domain.RecommendationTypeBoughtTogether, 3.0))
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `products` WHERE `products`.`id` = ?")).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "Related Product"))
+
+		// Execute
+		recommendations, err := s.repo.FindByProductID(s.ctx, 1, 5)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Len(s.T(), recommendations, 1)
+		assert.Equal(s.T(), "Related Product", recommendations[0].RelatedProduct.Name)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+
+	s.Run("Error", func() {
+		// Reset mock
+		s.SetupTest()
+
+		// Test case: Database error
+		expectedError := errors.New("database error")
+		s.sqlMock.ExpectQuery("SELECT \\* FROM `product_recommendations`").WillReturnError(expectedError)
+
+		// Execute
+		recommendations, err := s.repo.FindByProductID(s.ctx, 1, 5)
+
+		// Assert
+		assert.ErrorIs(s.T(), err, expectedError)
+		assert.Nil(s.T(), recommendations)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestFindByProductIDs tests the FindByProductIDs method
+func (s *RecommendationRepositoryTestSuite) TestFindByProductIDs() {
+	s.Run("No Products", func() {
+		// Test case: Nothing is queried for an empty set of products
+
+		// Execute
+		recommendations, err := s.repo.FindByProductIDs(s.ctx, nil)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Empty(s.T(), recommendations)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestIncrementBoughtTogether tests the IncrementBoughtTogether method
+func (s *RecommendationRepositoryTestSuite) TestIncrementBoughtTogether() {
+	s.Run("Success", func() {
+		// Test case: The pair is inserted, or its score raised when it exists
+		s.sqlMock.ExpectExec(regexp.QuoteMeta("INSERT INTO `product_recommendations`") + ".*" +
+			regexp.QuoteMeta("ON DUPLICATE KEY UPDATE `score`=score + ?")).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		// Execute
+		err := s.repo.IncrementBoughtTogether(s.ctx, 1, 2, 1.0)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestRebuildBoughtTogether tests the RebuildBoughtTogether method
+func (s *RecommendationRepositoryTestSuite) TestRebuildBoughtTogether() {
+	s.Run("Success", func() {
+		// Test case: The scores are recounted from purchased orders only, up
+		// to the given order
+		s.sqlMock.ExpectBegin()
+		s.sqlMock.ExpectExec(regexp.QuoteMeta("DELETE FROM `product_recommendations` WHERE type = ?")).
+			WithArgs(domain.RecommendationTypeBoughtTogether).
+			WillReturnResult(sqlmock.NewResult(0, 4))
+		s.sqlMock.ExpectExec(regexp.QuoteMeta("WHERE a.order_id <= ? AND o.status NOT IN (?,?,?)")).
+			WithArgs(domain.RecommendationTypeBoughtTogether, 42,
+				domain.OrderStatusCancelled, domain.OrderStatusReturned, domain.OrderStatusRefunded).
+			WillReturnResult(sqlmock.NewResult(0, 6))
+		s.sqlMock.ExpectCommit()
+
+		// Execute
+		err := s.repo.RebuildBoughtTogether(s.ctx, 42)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+
+	s.Run("Error", func() {
+		// Reset mock
+		s.SetupTest()
+
+		// Test case: The previous scores are kept when the recount fails
+		expectedError := errors.New("database error")
+		s.sqlMock.ExpectBegin()
+		s.sqlMock.ExpectExec("DELETE FROM `product_recommendations`").WillReturnResult(sqlmock.NewResult(0, 4))
+		s.sqlMock.ExpectExec("INSERT INTO product_recommendations").WillReturnError(expectedError)
+		s.sqlMock.ExpectRollback()
+
+		// Execute
+		err := s.repo.RebuildBoughtTogether(s.ctx, 42)
+
+		// Assert
+		assert.ErrorIs(s.T(), err, expectedError)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestGetLatestOrderID tests the GetLatestOrderID method
+func (s *RecommendationRepositoryTestSuite) TestGetLatestOrderID() {
+	s.Run("Success", func() {
+		// Test case: The highest order ID is returned
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(MAX(id), 0) FROM `orders`")).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(42))
+
+		// Execute
+		latestID, err := s.repo.GetLatestOrderID(s.ctx)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), uint(42), latestID)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestFindOrderItemsAfter tests the FindOrderItemsAfter method
+func (s *RecommendationRepositoryTestSuite) TestFindOrderItemsAfter() {
+	s.Run("Success", func() {
+		// Test case: Only the items of purchased orders are returned, and the
+		// batch ends at the last order seen
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`status` FROM `orders` WHERE id > ?") + ".*" +
+			regexp.QuoteMeta("ORDER BY id ASC LIMIT 100")).
+			WithArgs(10).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).
+				AddRow(11, domain.OrderStatusDelivered).
+				AddRow(12, domain.OrderStatusCancelled).
+				AddRow(13, domain.OrderStatusRefunded).
+				AddRow(14, domain.OrderStatusProcessing))
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `order_items` WHERE order_id IN (?,?) ORDER BY order_id ASC")).
+			WithArgs(11, 14).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "product_id"}).
+				AddRow(1, 11, 1).
+				AddRow(2, 11, 2).
+				AddRow(3, 14, 1))
+
+		// Execute
+		items, lastOrderID, err := s.repo.FindOrderItemsAfter(s.ctx, 10, 100)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Len(s.T(), items, 3)
+		assert.Equal(s.T(), uint(14), lastOrderID)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+
+	s.Run("Success - No New Orders", func() {
+		// Reset mock
+		s.SetupTest()
+
+		// Test case: No orders after the watermark
+		s.sqlMock.ExpectQuery("SELECT `id`,`status` FROM `orders`").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "status"}))
+
+		// Execute
+		items, lastOrderID, err := s.repo.FindOrderItemsAfter(s.ctx, 10, 100)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Empty(s.T(), items)
+		assert.Equal(s.T(), uint(10), lastOrderID)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+
+	s.Run("Success - Only Cancelled Orders", func() {
+		// Reset mock
+		s.SetupTest()
+
+		// Test case: A batch of cancelled orders has no items but moves on
+		s.sqlMock.ExpectQuery("SELECT `id`,`status` FROM `orders`").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).
+				AddRow(11, domain.OrderStatusCancelled).
+				AddRow(12, domain.OrderStatusReturned))
+
+		// Execute
+		items, lastOrderID, err := s.repo.FindOrderItemsAfter(s.ctx, 10, 100)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Empty(s.T(), items)
+		assert.Equal(s.T(), uint(12), lastOrderID)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestRecommendationRepositorySuite runs the test suite
+func TestRecommendationRepositorySuite(t *testing.T) {
+	suite.Run(t, new(RecommendationRepositoryTestSuite))
+}
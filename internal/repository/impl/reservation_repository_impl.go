@@ -0,0 +1,231 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+	"awesomeEcommerce/internal/repository/cache"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReservationRepositoryImpl implements the ReservationRepository interface
+type ReservationRepositoryImpl struct {
+	db    *gorm.DB
+	cache *cache.RedisClient
+}
+
+// NewReservationRepository creates a new ReservationRepositoryImpl
+func NewReservationRepository(db *gorm.DB, cache *cache.RedisClient) repository.ReservationRepository {
+	return &ReservationRepositoryImpl{
+		db:    db,
+		cache: cache,
+	}
+}
+
+// Reserve holds stock for order lines, all or nothing, failing with
+// *domain.InsufficientStockError listing each line whose product does not
+// have enough stock left after its active reservations
+func (r *ReservationRepositoryImpl) Reserve(ctx context.Context, reservations []domain.StockReservation) error {
+	if len(reservations) == 0 {
+		return nil
+	}
+
+	// Lock product rows in ID order so concurrent checkouts cannot deadlock
+	sort.SliceStable(reservations, func(i, j int) bool {
+		return reservations[i].ProductID < reservations[j].ProductID
+	})
+
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var shortages []domain.StockShortage
+		for i := range reservations {
+			reservation := &reservations[i]
+
+			// Lock the product row so concurrent checkouts reserve one at a time
+			var product domain.Product
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Select("id", "name", "stock").
+				First(&product, reservation.ProductID).Error; err != nil {
+				return err
+			}
+
+			reserved, err := reservedQuantity(tx, reservation.ProductID)
+			if err != nil {
+				return err
+			}
+			if available := product.Stock - reserved; available < reservation.Quantity {
+				shortages = append(shortages, domain.StockShortage{
+					ProductID:   product.ID,
+					ProductName: product.Name,
+					Requested:   reservation.Quantity,
+					Available:   max(available, 0),
+				})
+				continue
+			}
+
+			if err := tx.Create(reservation).Error; err != nil {
+				return err
+			}
+		}
+
+		// Rolling back drops the reservations already made
+		if len(shortages) > 0 {
+			return &domain.InsufficientStockError{Shortages: shortages}
+		}
+		return nil
+	})
+}
+
+// FindByOrderID retrieves the reservations of an order
+func (r *ReservationRepositoryImpl) FindByOrderID(ctx context.Context, orderID uint) ([]domain.StockReservation, error) {
+	var reservations []domain.StockReservation
+	if err := conn(ctx, r.db).Where("order_id = ?", orderID).Find(&reservations).Error; err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
+
+// GetReservedQuantity sums the active, unexpired reservations of a product
+func (r *ReservationRepositoryImpl) GetReservedQuantity(ctx context.Context, productID uint) (int, error) {
+	return reservedQuantity(conn(ctx, r.db), productID)
+}
+
+// Convert decrements product stock by the active reservations of an order
+// and marks them converted, returning the number of reservations converted.
+// It fails if any of them expired.
+func (r *ReservationRepositoryImpl) Convert(ctx context.Context, orderID uint, change domain.StockChange) (int, error) {
+	var reservations []domain.StockReservation
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("order_id = ? AND status = ?", orderID, domain.ReservationStatusActive).
+			Find(&reservations).Error; err != nil {
+			return err
+		}
+
+		// A lapsed reservation no longer holds its stock for the order
+		now := time.Now()
+		for _, reservation := range reservations {
+			if reservation.IsExpiredAt(now) {
+				return errors.New("stock reservation has expired")
+			}
+		}
+
+		for _, reservation := range reservations {
+			result := tx.Model(&domain.Product{}).
+				Where("id = ? AND stock >= ?", reservation.ProductID, reservation.Quantity).
+				Update("stock", gorm.Expr("stock - ?", reservation.Quantity))
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return fmt.Errorf("insufficient stock for product %d", reservation.ProductID)
+			}
+
+			if err := recordStockChange(tx, reservation.ProductID, -reservation.Quantity, change); err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&domain.StockReservation{}).
+			Where("order_id = ? AND status = ?", orderID, domain.ReservationStatusActive).
+			Update("status", domain.ReservationStatusConverted).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	r.invalidateProducts(ctx, reservations)
+
+	return len(reservations), nil
+}
+
+// Release puts the stock held by an order back on sale: active reservations
+// are released and converted ones are returned to product stock, except for
+// delivered digital lines whose license keys cannot be taken back
+func (r *ReservationRepositoryImpl) Release(ctx context.Context, orderID uint, change domain.StockChange) error {
+	var converted []domain.StockReservation
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		delivered := tx.Model(&domain.OrderItem{}).
+			Select("product_id").
+			Where("order_id = ? AND delivered_at IS NOT NULL", orderID)
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("order_id = ? AND status = ?", orderID, domain.ReservationStatusConverted).
+			Where("product_id NOT IN (?)", delivered).
+			Find(&converted).Error; err != nil {
+			return err
+		}
+
+		for _, reservation := range converted {
+			if err := tx.Model(&domain.Product{}).
+				Where("id = ?", reservation.ProductID).
+				Update("stock", gorm.Expr("stock + ?", reservation.Quantity)).Error; err != nil {
+				return err
+			}
+
+			if err := recordStockChange(tx, reservation.ProductID, reservation.Quantity, change); err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&domain.StockReservation{}).
+			Where("order_id = ? AND status IN ?", orderID, []domain.ReservationStatus{domain.ReservationStatusActive, domain.ReservationStatusConverted}).
+			Update("status", domain.ReservationStatusReleased).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	r.invalidateProducts(ctx, converted)
+
+	return nil
+}
+
+// FindExpiredOrderIDs retrieves orders holding active reservations that expired before a time
+func (r *ReservationRepositoryImpl) FindExpiredOrderIDs(ctx context.Context, before time.Time, limit int) ([]uint, error) {
+	var orderIDs []uint
+	if err := conn(ctx, r.db).Model(&domain.StockReservation{}).
+		Where("status = ? AND expires_at <= ?", domain.ReservationStatusActive, before).
+		Distinct().
+		Order("order_id ASC").
+		Limit(limit).
+		Pluck("order_id", &orderIDs).Error; err != nil {
+		return nil, err
+	}
+	return orderIDs, nil
+}
+
+// Expire marks the active reservations of an order expired
+func (r *ReservationRepositoryImpl) Expire(ctx context.Context, orderID uint) error {
+	return conn(ctx, r.db).Model(&domain.StockReservation{}).
+		Where("order_id = ? AND status = ?", orderID, domain.ReservationStatusActive).
+		Update("status", domain.ReservationStatusExpired).Error
+}
+
+// invalidateProducts invalidates the cached products whose stock changed
+func (r *ReservationRepositoryImpl) invalidateProducts(ctx context.Context, reservations []domain.StockReservation) {
+	tags := make([]string, 0, len(reservations))
+	for _, reservation := range reservations {
+		tags = append(tags, productTag(reservation.ProductID))
+	}
+	if len(tags) > 0 {
+		afterCommit(ctx, func() { r.cache.InvalidateTags(ctx, tags...) })
+	}
+}
+
+// reservedQuantity sums the active, unexpired reservations of a product
+func reservedQuantity(db *gorm.DB, productID uint) (int, error) {
+	var reserved int
+	if err := db.Model(&domain.StockReservation{}).
+		Select("COALESCE(SUM(quantity), 0)").
+		Where("product_id = ? AND status = ? AND expires_at > ?", productID, domain.ReservationStatusActive, time.Now()).
+		Scan(&reserved).Error; err != nil {
+		return 0, err
+	}
+	return reserved, nil
+}
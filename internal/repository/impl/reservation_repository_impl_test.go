@@ -0,0 +1,206 @@
+package impl_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+	"awesomeEcommerce/internal/repository/impl"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// ReservationRepositoryTestSuite is a test suite for ReservationRepositoryImpl
+type ReservationRepositoryTestSuite struct {
+	suite.Suite
+	repo    repository.ReservationRepository
+	sqlMock sqlmock.Sqlmock
+	ctx     context.Context
+}
+
+// SetupTest sets up the test suite
+func (s *ReservationRepositoryTestSuite) SetupTest() {
+	db, sqlMock := newMockDB(s.T())
+	s.repo = impl.NewReservationRepository(db, newMockCache(s.T()))
+	s.sqlMock = sqlMock
+	s.ctx = context.Background()
+}
+
+// expectLockProduct expects a product row to be locked for a reservation
+func (s *ReservationRepositoryTestSuite) expectLockProduct(productID uint, name string, stock, reserved int) {
+	s.sqlMock.ExpectQuery("SELECT `id`,`name`,`stock` FROM `products` .* FOR UPDATE").
+		WithArgs(productID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "stock"}).AddRow(productID, name, stock))
+	s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(SUM(quantity), 0) FROM `stock_reservations` WHERE product_id = ? AND status = ? AND expires_at > ?")).
+		WithArgs(productID, domain.ReservationStatusActive, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"reserved"}).AddRow(reserved))
+}
+
+// TestReserve tests the Reserve method
+func (s *ReservationRepositoryTestSuite) TestReserve() {
+	expiresAt := time.Now().Add(15 * time.Minute)
+
+	s.Run("Success", func() {
+		// Test case: Products are locked in ID order and each line reserved
+		// against the stock left after other reservations
+		s.sqlMock.ExpectBegin()
+		s.expectLockProduct(1, "Product 1", 10, 4)
+		s.sqlMock.ExpectExec("INSERT INTO `stock_reservations`").WillReturnResult(sqlmock.NewResult(1, 1))
+		s.expectLockProduct(2, "Product 2", 5, 0)
+		s.sqlMock.ExpectExec("INSERT INTO `stock_reservations`").WillReturnResult(sqlmock.NewResult(2, 1))
+		s.sqlMock.ExpectCommit()
+
+		// Execute
+		err := s.repo.Reserve(s.ctx, []domain.StockReservation{
+			{ProductID: 2, OrderID: 1, Quantity: 5, Status: domain.ReservationStatusActive, ExpiresAt: expiresAt},
+			{ProductID: 1, OrderID: 1, Quantity: 6, Status: domain.ReservationStatusActive, ExpiresAt: expiresAt},
+		})
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+
+	s.Run("Error - Insufficient Stock", func() {
+		// Reset mock
+		s.SetupTest()
+
+		// Test case: Every short line is reported and nothing is reserved
+		s.sqlMock.ExpectBegin()
+		s.expectLockProduct(1, "Product 1", 10, 8)
+		s.expectLockProduct(2, "Product 2", 5, 0)
+		s.sqlMock.ExpectExec("INSERT INTO `stock_reservations`").WillReturnResult(sqlmock.NewResult(1, 1))
+		s.sqlMock.ExpectRollback()
+
+		// Execute
+		err := s.repo.Reserve(s.ctx, []domain.StockReservation{
+			{ProductID: 1, OrderID: 1, Quantity: 3, Status: domain.ReservationStatusActive, ExpiresAt: expiresAt},
+			{ProductID: 2, OrderID: 1, Quantity: 5, Status: domain.ReservationStatusActive, ExpiresAt: expiresAt},
+		})
+
+		// Assert
+		var stockErr *domain.InsufficientStockError
+		assert.ErrorAs(s.T(), err, &stockErr)
+		assert.Equal(s.T(), []domain.StockShortage{
+			{ProductID: 1, ProductName: "Product 1", Requested: 3, Available: 2},
+		}, stockErr.Shortages)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestConvert tests the Convert method
+func (s *ReservationRepositoryTestSuite) TestConvert() {
+	change := domain.StockChange{Reason: domain.LedgerReasonOrder, ReferenceID: 1}
+	reservationColumns := []string{"id", "product_id", "order_id", "quantity", "status", "expires_at"}
+
+	s.Run("Success", func() {
+		// Test case: The reserved stock is taken off the product and recorded
+		s.sqlMock.ExpectBegin()
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `stock_reservations` WHERE order_id = ? AND status = ? FOR UPDATE")).
+			WithArgs(1, domain.ReservationStatusActive).
+			WillReturnRows(sqlmock.NewRows(reservationColumns).
+				AddRow(1, 1, 1, 2, domain.ReservationStatusActive, time.Now().Add(time.Minute)))
+		s.sqlMock.ExpectExec(regexp.QuoteMeta("UPDATE `products` SET `stock`=stock - ?")+".*"+
+			regexp.QuoteMeta("WHERE id = ? AND stock >= ?")).
+			WithArgs(2, sqlmock.AnyArg(), 1, 2).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		s.sqlMock.ExpectQuery("SELECT `id`,`stock` FROM `products`").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "stock"}).AddRow(1, 8))
+		s.sqlMock.ExpectExec("INSERT INTO `inventory_ledger`").WillReturnResult(sqlmock.NewResult(1, 1))
+		s.sqlMock.ExpectExec(regexp.QuoteMeta("UPDATE `stock_reservations` SET `status`=?")).
+			WithArgs(domain.ReservationStatusConverted, sqlmock.AnyArg(), 1, domain.ReservationStatusActive).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		s.sqlMock.ExpectCommit()
+
+		// Execute
+		converted, err := s.repo.Convert(s.ctx, 1, change)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), 1, converted)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+
+	s.Run("Error - Expired Reservation", func() {
+		// Reset mock
+		s.SetupTest()
+
+		// Test case: A lapsed reservation fails the conversion
+		s.sqlMock.ExpectBegin()
+		s.sqlMock.ExpectQuery("SELECT \\* FROM `stock_reservations`").
+			WillReturnRows(sqlmock.NewRows(reservationColumns).
+				AddRow(1, 1, 1, 2, domain.ReservationStatusActive, time.Now().Add(-time.Minute)))
+		s.sqlMock.ExpectRollback()
+
+		// Execute
+		converted, err := s.repo.Convert(s.ctx, 1, change)
+
+		// Assert
+		assert.EqualError(s.T(), err, "stock reservation has expired")
+		assert.Zero(s.T(), converted)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestRelease tests the Release method
+func (s *ReservationRepositoryTestSuite) TestRelease() {
+	s.Run("Success", func() {
+		// Test case: Converted lines that were not delivered go back to stock
+		// and every held reservation is released
+		change := domain.StockChange{Reason: domain.LedgerReasonCancellation, ReferenceID: 1}
+		s.sqlMock.ExpectBegin()
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `stock_reservations` WHERE (order_id = ? AND status = ?) AND product_id NOT IN (SELECT `product_id` FROM `order_items` WHERE order_id = ? AND delivered_at IS NOT NULL) FOR UPDATE")).
+			WithArgs(1, domain.ReservationStatusConverted, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "product_id", "order_id", "quantity", "status"}).
+				AddRow(1, 1, 1, 2, domain.ReservationStatusConverted))
+		s.sqlMock.ExpectExec(regexp.QuoteMeta("UPDATE `products` SET `stock`=stock + ?")).
+			WithArgs(2, sqlmock.AnyArg(), 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		s.sqlMock.ExpectQuery("SELECT `id`,`stock` FROM `products`").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "stock"}).AddRow(1, 10))
+		s.sqlMock.ExpectExec("INSERT INTO `inventory_ledger`").WillReturnResult(sqlmock.NewResult(1, 1))
+		s.sqlMock.ExpectExec(regexp.QuoteMeta("UPDATE `stock_reservations` SET `status`=?")+".*"+
+			regexp.QuoteMeta("WHERE order_id = ? AND status IN (?,?)")).
+			WithArgs(domain.ReservationStatusReleased, sqlmock.AnyArg(), 1, domain.ReservationStatusActive, domain.ReservationStatusConverted).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		s.sqlMock.ExpectCommit()
+
+		// Execute
+		err := s.repo.Release(s.ctx, 1, change)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestFindExpiredOrderIDs tests the FindExpiredOrderIDs method
+func (s *ReservationRepositoryTestSuite) TestFindExpiredOrderIDs() {
+	s.Run("Success", func() {
+		// Test case: Each order holding lapsed reservations is listed once
+		before := time.Now()
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT DISTINCT `order_id` FROM `stock_reservations` WHERE status = ? AND expires_at <= ? ORDER BY order_id ASC LIMIT 10")).
+			WithArgs(domain.ReservationStatusActive, before).
+			WillReturnRows(sqlmock.NewRows([]string{"order_id"}).AddRow(1).AddRow(3))
+
+		// Execute
+		orderIDs, err := s.repo.FindExpiredOrderIDs(s.ctx, before, 10)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), []uint{1, 3}, orderIDs)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestReservationRepositorySuite runs the test suite
+func TestReservationRepositorySuite(t *testing.T) {
+	suite.Run(t, new(ReservationRepositoryTestSuite))
+}
@@ -0,0 +1,54 @@
+package impl
+
+import (
+	"context"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ShipmentRepositoryImpl implements the ShipmentRepository interface
+type ShipmentRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewShipmentRepository creates a new ShipmentRepositoryImpl
+func NewShipmentRepository(db *gorm.DB) repository.ShipmentRepository {
+	return &ShipmentRepositoryImpl{
+		db: db,
+	}
+}
+
+// FindByOrderID retrieves the shipments of an order with their items, oldest first
+func (r *ShipmentRepositoryImpl) FindByOrderID(ctx context.Context, orderID uint) ([]domain.Shipment, error) {
+	var shipments []domain.Shipment
+	if err := conn(ctx, r.db).Preload("Items").Where("order_id = ?", orderID).Order("id").Find(&shipments).Error; err != nil {
+		return nil, err
+	}
+	return shipments, nil
+}
+
+// FindByTrackingNumber retrieves the shipment a carrier tracks by a tracking
+// number. Within a transaction the shipment stays locked until the
+// transaction ends, so the tracking updates of a shipment apply one at a
+// time, each to the state the last one left.
+func (r *ShipmentRepositoryImpl) FindByTrackingNumber(ctx context.Context, carrier, trackingNumber string) (*domain.Shipment, error) {
+	var shipment domain.Shipment
+	if err := conn(ctx, r.db).Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").Where("carrier = ? AND tracking_number = ?", carrier, trackingNumber).First(&shipment).Error; err != nil {
+		return nil, err
+	}
+	return &shipment, nil
+}
+
+// Create creates a new shipment with its items
+func (r *ShipmentRepositoryImpl) Create(ctx context.Context, shipment *domain.Shipment) error {
+	return conn(ctx, r.db).Create(shipment).Error
+}
+
+// Update updates the tracking of an existing shipment, leaving its items unchanged
+func (r *ShipmentRepositoryImpl) Update(ctx context.Context, shipment *domain.Shipment) error {
+	return conn(ctx, r.db).Omit("Items").Save(shipment).Error
+}
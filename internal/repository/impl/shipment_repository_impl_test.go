@@ -0,0 +1,155 @@
+package impl_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+	"awesomeEcommerce/internal/repository/impl"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// ShipmentRepositoryTestSuite is a test suite for ShipmentRepositoryImpl
+type ShipmentRepositoryTestSuite struct {
+	suite.Suite
+	repo    repository.ShipmentRepository
+	sqlMock sqlmock.Sqlmock
+	ctx     context.Context
+}
+
+// SetupTest sets up the test suite
+func (s *ShipmentRepositoryTestSuite) SetupTest() {
+	db, sqlMock := newMockDB(s.T())
+	s.repo = impl.NewShipmentRepository(db)
+	s.sqlMock = sqlMock
+	s.ctx = context.Background()
+}
+
+// TestFindByOrderID tests the FindByOrderID method
+func (s *ShipmentRepositoryTestSuite) TestFindByOrderID() {
+	s.Run("Success", func() {
+		// Test case: The shipments of the order, oldest first, with their items
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `shipments` WHERE order_id = ? ORDER BY id")).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "carrier", "tracking_number", "status"}).
+				AddRow(1, 1, "dhl", "TRACK1", domain.ShipmentStatusDelivered).
+				AddRow(2, 1, "ups", "TRACK2", domain.ShipmentStatusShipped))
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `shipment_items` WHERE `shipment_items`.`shipment_id` IN (?,?)")).
+			WithArgs(1, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "shipment_id", "order_item_id", "product_id", "quantity"}).
+				AddRow(1, 1, 10, 100, 2).
+				AddRow(2, 2, 10, 100, 1))
+
+		// Execute
+		shipments, err := s.repo.FindByOrderID(s.ctx, 1)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Len(s.T(), shipments, 2)
+		assert.Len(s.T(), shipments[1].Items, 1)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestFindByTrackingNumber tests the FindByTrackingNumber method
+func (s *ShipmentRepositoryTestSuite) TestFindByTrackingNumber() {
+	s.Run("Success", func() {
+		// Test case: The shipment is locked, so tracking updates apply one at a time
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `shipments` WHERE carrier = ? AND tracking_number = ? ORDER BY `shipments`.`id` LIMIT 1 FOR UPDATE")).
+			WithArgs("dhl", "TRACK1").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "carrier", "tracking_number", "status"}).
+				AddRow(1, 1, "dhl", "TRACK1", domain.ShipmentStatusInTransit))
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `shipment_items` WHERE `shipment_items`.`shipment_id` = ?")).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "shipment_id", "order_item_id", "product_id", "quantity"}).
+				AddRow(1, 1, 10, 100, 2))
+
+		// Execute
+		shipment, err := s.repo.FindByTrackingNumber(s.ctx, "dhl", "TRACK1")
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), domain.ShipmentStatusInTransit, shipment.Status)
+		assert.Len(s.T(), shipment.Items, 1)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+
+	s.Run("Error - Not Found", func() {
+		// Reset mock
+		s.SetupTest()
+
+		// Test case: The carrier tracks no shipment by the number
+		s.sqlMock.ExpectQuery("SELECT \\* FROM `shipments`").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		// Execute
+		shipment, err := s.repo.FindByTrackingNumber(s.ctx, "dhl", "UNKNOWN")
+
+		// Assert
+		assert.ErrorIs(s.T(), err, gorm.ErrRecordNotFound)
+		assert.Nil(s.T(), shipment)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestCreate tests the Create method
+func (s *ShipmentRepositoryTestSuite) TestCreate() {
+	s.Run("Success", func() {
+		// Test case: The shipment is created with its items
+		shipment := &domain.Shipment{
+			OrderID:        1,
+			Carrier:        "dhl",
+			TrackingNumber: "TRACK1",
+			Status:         domain.ShipmentStatusShipped,
+			Items:          []domain.ShipmentItem{{OrderItemID: 10, ProductID: 100, Quantity: 2}},
+		}
+		s.sqlMock.ExpectExec("INSERT INTO `shipments`").WillReturnResult(sqlmock.NewResult(3, 1))
+		s.sqlMock.ExpectExec("INSERT INTO `shipment_items`").
+			WithArgs(uint(3), uint(10), uint(100), 2).
+			WillReturnResult(sqlmock.NewResult(5, 1))
+
+		// Execute
+		err := s.repo.Create(s.ctx, shipment)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), uint(3), shipment.ID)
+		assert.Equal(s.T(), uint(3), shipment.Items[0].ShipmentID)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestUpdate tests the Update method
+func (s *ShipmentRepositoryTestSuite) TestUpdate() {
+	s.Run("Success", func() {
+		// Test case: Only the shipment's tracking is saved, not its items
+		shipment := &domain.Shipment{
+			ID:             3,
+			OrderID:        1,
+			Carrier:        "dhl",
+			TrackingNumber: "TRACK1",
+			Status:         domain.ShipmentStatusDelivered,
+			Items:          []domain.ShipmentItem{{ID: 5, ShipmentID: 3, OrderItemID: 10, ProductID: 100, Quantity: 2}},
+		}
+		s.sqlMock.ExpectExec(regexp.QuoteMeta("UPDATE `shipments` SET `order_id`=?,`carrier`=?,`tracking_number`=?,`status`=?")).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		// Execute
+		err := s.repo.Update(s.ctx, shipment)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestShipmentRepositorySuite runs the test suite
+func TestShipmentRepositorySuite(t *testing.T) {
+	suite.Run(t, new(ShipmentRepositoryTestSuite))
+}
@@ -0,0 +1,64 @@
+package impl
+
+import (
+	"context"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// ShippingMethodRepositoryImpl implements the ShippingMethodRepository interface
+type ShippingMethodRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewShippingMethodRepository creates a new ShippingMethodRepositoryImpl
+func NewShippingMethodRepository(db *gorm.DB) repository.ShippingMethodRepository {
+	return &ShippingMethodRepositoryImpl{
+		db: db,
+	}
+}
+
+// FindByID retrieves a shipping method by its ID
+func (r *ShippingMethodRepositoryImpl) FindByID(ctx context.Context, id uint) (*domain.ShippingMethod, error) {
+	var method domain.ShippingMethod
+	if err := r.db.First(&method, id).Error; err != nil {
+		return nil, err
+	}
+	return &method, nil
+}
+
+// FindAll retrieves every shipping method, in the order they are offered
+func (r *ShippingMethodRepositoryImpl) FindAll(ctx context.Context) ([]domain.ShippingMethod, error) {
+	var methods []domain.ShippingMethod
+	if err := r.db.Order("sort_order, id").Find(&methods).Error; err != nil {
+		return nil, err
+	}
+	return methods, nil
+}
+
+// FindActive retrieves the active shipping methods, in the order they are offered
+func (r *ShippingMethodRepositoryImpl) FindActive(ctx context.Context) ([]domain.ShippingMethod, error) {
+	var methods []domain.ShippingMethod
+	if err := r.db.Where("active = ?", true).Order("sort_order, id").Find(&methods).Error; err != nil {
+		return nil, err
+	}
+	return methods, nil
+}
+
+// Create creates a new shipping method
+func (r *ShippingMethodRepositoryImpl) Create(ctx context.Context, method *domain.ShippingMethod) error {
+	return r.db.Create(method).Error
+}
+
+// Update updates an existing shipping method
+func (r *ShippingMethodRepositoryImpl) Update(ctx context.Context, method *domain.ShippingMethod) error {
+	return r.db.Save(method).Error
+}
+
+// Delete deletes a shipping method by its ID
+func (r *ShippingMethodRepositoryImpl) Delete(ctx context.Context, id uint) error {
+	return r.db.Delete(&domain.ShippingMethod{}, id).Error
+}
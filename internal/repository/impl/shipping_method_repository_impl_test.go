@@ -0,0 +1,93 @@
+package impl_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+	"awesomeEcommerce/internal/repository/impl"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// ShippingMethodRepositoryTestSuite is a test suite for ShippingMethodRepositoryImpl
+type ShippingMethodRepositoryTestSuite struct {
+	suite.Suite
+	repo    repository.ShippingMethodRepository
+	sqlMock sqlmock.Sqlmock
+	ctx     context.Context
+}
+
+// SetupTest sets up the test suite
+func (s *ShippingMethodRepositoryTestSuite) SetupTest() {
+	db, sqlMock := newMockDB(s.T())
+	s.repo = impl.NewShippingMethodRepository(db)
+	s.sqlMock = sqlMock
+	s.ctx = context.Background()
+}
+
+// TestFindActive tests the FindActive method
+func (s *ShippingMethodRepositoryTestSuite) TestFindActive() {
+	s.Run("Success", func() {
+		// Test case: Active methods, in the order they are offered, with their
+		// rates read from the embedded columns
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `shipping_methods` WHERE active = ? ORDER BY sort_order, id")).
+			WithArgs(true).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "zone_id", "name", "type", "rate_amount", "rate_currency", "sort_order", "active"}).
+				AddRow(1, nil, "Standard", domain.ShippingMethodFlatRate, 499, "EUR", 0, true).
+				AddRow(2, 1, "Express", domain.ShippingMethodFlatRate, 1299, "EUR", 1, true))
+
+		// Execute
+		methods, err := s.repo.FindActive(s.ctx)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Len(s.T(), methods, 2)
+		assert.Nil(s.T(), methods[0].ZoneID)
+		assert.Equal(s.T(), domain.Money{Amount: 1299, Currency: "EUR"}, methods[1].Rate)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestUpdate tests the Update method
+func (s *ShippingMethodRepositoryTestSuite) TestUpdate() {
+	s.Run("Success", func() {
+		// Test case: Every column of the method is saved
+		method := &domain.ShippingMethod{ID: 1, Name: "Standard", Type: domain.ShippingMethodFlatRate, Rate: domain.Money{Amount: 599, Currency: "EUR"}}
+		s.sqlMock.ExpectExec(regexp.QuoteMeta("UPDATE `shipping_methods` SET `zone_id`=?,`name`=?,`description`=?,`type`=?,`rate_amount`=?,`rate_currency`=?")).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		// Execute
+		err := s.repo.Update(s.ctx, method)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestDelete tests the Delete method
+func (s *ShippingMethodRepositoryTestSuite) TestDelete() {
+	s.Run("Success", func() {
+		// Test case: The method is deleted by its ID
+		s.sqlMock.ExpectExec(regexp.QuoteMeta("DELETE FROM `shipping_methods` WHERE `shipping_methods`.`id` = ?")).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		// Execute
+		err := s.repo.Delete(s.ctx, 1)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestShippingMethodRepositorySuite runs the test suite
+func TestShippingMethodRepositorySuite(t *testing.T) {
+	suite.Run(t, new(ShippingMethodRepositoryTestSuite))
+}
@@ -0,0 +1,55 @@
+package impl
+
+import (
+	"context"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// ShippingZoneRepositoryImpl implements the ShippingZoneRepository interface
+type ShippingZoneRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewShippingZoneRepository creates a new ShippingZoneRepositoryImpl
+func NewShippingZoneRepository(db *gorm.DB) repository.ShippingZoneRepository {
+	return &ShippingZoneRepositoryImpl{
+		db: db,
+	}
+}
+
+// FindByID retrieves a shipping zone by its ID
+func (r *ShippingZoneRepositoryImpl) FindByID(ctx context.Context, id uint) (*domain.ShippingZone, error) {
+	var zone domain.ShippingZone
+	if err := r.db.First(&zone, id).Error; err != nil {
+		return nil, err
+	}
+	return &zone, nil
+}
+
+// FindAll retrieves every shipping zone, ordered by name
+func (r *ShippingZoneRepositoryImpl) FindAll(ctx context.Context) ([]domain.ShippingZone, error) {
+	var zones []domain.ShippingZone
+	if err := r.db.Order("name, id").Find(&zones).Error; err != nil {
+		return nil, err
+	}
+	return zones, nil
+}
+
+// Create creates a new shipping zone
+func (r *ShippingZoneRepositoryImpl) Create(ctx context.Context, zone *domain.ShippingZone) error {
+	return r.db.Create(zone).Error
+}
+
+// Update updates an existing shipping zone
+func (r *ShippingZoneRepositoryImpl) Update(ctx context.Context, zone *domain.ShippingZone) error {
+	return r.db.Save(zone).Error
+}
+
+// Delete deletes a shipping zone by its ID
+func (r *ShippingZoneRepositoryImpl) Delete(ctx context.Context, id uint) error {
+	return r.db.Delete(&domain.ShippingZone{}, id).Error
+}
@@ -0,0 +1,111 @@
+package impl_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+	"awesomeEcommerce/internal/repository/impl"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// ShippingZoneRepositoryTestSuite is a test suite for ShippingZoneRepositoryImpl
+type ShippingZoneRepositoryTestSuite struct {
+	suite.Suite
+	repo    repository.ShippingZoneRepository
+	sqlMock sqlmock.Sqlmock
+	ctx     context.Context
+}
+
+// SetupTest sets up the test suite
+func (s *ShippingZoneRepositoryTestSuite) SetupTest() {
+	db, sqlMock := newMockDB(s.T())
+	s.repo = impl.NewShippingZoneRepository(db)
+	s.sqlMock = sqlMock
+	s.ctx = context.Background()
+}
+
+// TestFindByID tests the FindByID method
+func (s *ShippingZoneRepositoryTestSuite) TestFindByID() {
+	s.Run("Success", func() {
+		// Test case: The zone's destinations are decoded from JSON
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `shipping_zones` WHERE `shipping_zones`.`id` = ?")).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "countries", "regions", "postal_prefixes"}).
+				AddRow(1, "Alps", `["AT","CH"]`, nil, `["6"]`))
+
+		// Execute
+		zone, err := s.repo.FindByID(s.ctx, 1)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), []string{"AT", "CH"}, zone.Countries)
+		assert.Empty(s.T(), zone.Regions)
+		assert.Equal(s.T(), []string{"6"}, zone.PostalPrefixes)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+
+	s.Run("Error - Not Found", func() {
+		// Reset mock
+		s.SetupTest()
+
+		// Test case: No zone has the ID
+		s.sqlMock.ExpectQuery("SELECT \\* FROM `shipping_zones`").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		// Execute
+		zone, err := s.repo.FindByID(s.ctx, 99)
+
+		// Assert
+		assert.ErrorIs(s.T(), err, gorm.ErrRecordNotFound)
+		assert.Nil(s.T(), zone)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestFindAll tests the FindAll method
+func (s *ShippingZoneRepositoryTestSuite) TestFindAll() {
+	s.Run("Success", func() {
+		// Test case: Every zone, ordered by name
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `shipping_zones` ORDER BY name, id")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Alps").AddRow(2, "Nordics"))
+
+		// Execute
+		zones, err := s.repo.FindAll(s.ctx)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Len(s.T(), zones, 2)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestCreate tests the Create method
+func (s *ShippingZoneRepositoryTestSuite) TestCreate() {
+	s.Run("Success", func() {
+		// Test case: The zone's destinations are stored as JSON
+		zone := &domain.ShippingZone{Name: "Nordics", Countries: []string{"DK", "NO", "SE"}}
+		s.sqlMock.ExpectExec("INSERT INTO `shipping_zones`").
+			WithArgs("Nordics", `["DK","NO","SE"]`, nil, nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(2, 1))
+
+		// Execute
+		err := s.repo.Create(s.ctx, zone)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), uint(2), zone.ID)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestShippingZoneRepositorySuite runs the test suite
+func TestShippingZoneRepositorySuite(t *testing.T) {
+	suite.Run(t, new(ShippingZoneRepositoryTestSuite))
+}
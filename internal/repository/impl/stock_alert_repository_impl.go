@@ -0,0 +1,53 @@
+package impl
+
+import (
+	"context"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// StockAlertRepositoryImpl implements the StockAlertRepository interface
+type StockAlertRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewStockAlertRepository creates a new StockAlertRepositoryImpl
+func NewStockAlertRepository(db *gorm.DB) repository.StockAlertRepository {
+	return &StockAlertRepositoryImpl{
+		db: db,
+	}
+}
+
+// FindByProductID retrieves the alert state of a product, a new alert in the
+// ok state when the product has never been checked
+func (r *StockAlertRepositoryImpl) FindByProductID(ctx context.Context, productID uint) (*domain.StockAlert, error) {
+	alert := domain.StockAlert{State: domain.StockAlertStateOK}
+	if err := r.db.Where(domain.StockAlert{ProductID: productID}).FirstOrInit(&alert).Error; err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+// Save creates or updates the alert state of a product
+func (r *StockAlertRepositoryImpl) Save(ctx context.Context, alert *domain.StockAlert) error {
+	return r.db.Save(alert).Error
+}
+
+// FindLowStock retrieves the products alerting for low stock, lowest stock first
+func (r *StockAlertRepositoryImpl) FindLowStock(ctx context.Context) ([]domain.LowStockReportLine, error) {
+	var lines []domain.LowStockReportLine
+	err := r.db.Table("stock_alerts").
+		Select("products.id AS product_id, products.name AS product_name, products.sku, products.stock, "+
+			"stock_alerts.reorder_point, stock_alerts.reorder_quantity, stock_alerts.alerted_at").
+		Joins("JOIN products ON products.id = stock_alerts.product_id").
+		Where("stock_alerts.state = ?", domain.StockAlertStateLow).
+		Order("products.stock ASC, products.id ASC").
+		Scan(&lines).Error
+	if err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
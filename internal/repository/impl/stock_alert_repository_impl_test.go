@@ -0,0 +1,96 @@
+package impl_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+	"awesomeEcommerce/internal/repository/impl"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// StockAlertRepositoryTestSuite is a test suite for StockAlertRepositoryImpl
+type StockAlertRepositoryTestSuite struct {
+	suite.Suite
+	repo    repository.StockAlertRepository
+	sqlMock sqlmock.Sqlmock
+	ctx     context.Context
+}
+
+// SetupTest sets up the test suite
+func (s *StockAlertRepositoryTestSuite) SetupTest() {
+	db, sqlMock := newMockDB(s.T())
+	s.repo = impl.NewStockAlertRepository(db)
+	s.sqlMock = sqlMock
+	s.ctx = context.Background()
+}
+
+// TestFindByProductID tests the FindByProductID method
+func (s *StockAlertRepositoryTestSuite) TestFindByProductID() {
+	s.Run("Success", func() {
+		// Test case: The stored alert state is returned
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `stock_alerts` WHERE `stock_alerts`.`product_id` = ?")).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "product_id", "state", "stock", "reorder_point"}).
+				AddRow(1, 1, domain.StockAlertStateLow, 2, 5))
+
+		// Execute
+		alert, err := s.repo.FindByProductID(s.ctx, 1)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), uint(1), alert.ID)
+		assert.Equal(s.T(), domain.StockAlertStateLow, alert.State)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+
+	s.Run("Success - Never Checked", func() {
+		// Reset mock
+		s.SetupTest()
+
+		// Test case: A product without an alert starts in the ok state
+		s.sqlMock.ExpectQuery("SELECT \\* FROM `stock_alerts`").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "product_id", "state"}))
+
+		// Execute
+		alert, err := s.repo.FindByProductID(s.ctx, 1)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Zero(s.T(), alert.ID)
+		assert.Equal(s.T(), uint(1), alert.ProductID)
+		assert.Equal(s.T(), domain.StockAlertStateOK, alert.State)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestFindLowStock tests the FindLowStock method
+func (s *StockAlertRepositoryTestSuite) TestFindLowStock() {
+	s.Run("Success", func() {
+		// Test case: Products alerting for low stock, lowest stock first
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("FROM `stock_alerts` JOIN products ON products.id = stock_alerts.product_id WHERE stock_alerts.state = ? ORDER BY products.stock ASC, products.id ASC")).
+			WithArgs(domain.StockAlertStateLow).
+			WillReturnRows(sqlmock.NewRows([]string{"product_id", "product_name", "stock", "reorder_point"}).
+				AddRow(2, "Product 2", 0, 5).
+				AddRow(1, "Product 1", 3, 5))
+
+		// Execute
+		lines, err := s.repo.FindLowStock(s.ctx)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Len(s.T(), lines, 2)
+		assert.Equal(s.T(), "Product 2", lines[0].ProductName)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestStockAlertRepositorySuite runs the test suite
+func TestStockAlertRepositorySuite(t *testing.T) {
+	suite.Run(t, new(StockAlertRepositoryTestSuite))
+}
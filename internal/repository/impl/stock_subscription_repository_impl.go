@@ -0,0 +1,134 @@
+package impl
+
+import (
+	"context"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// StockSubscriptionRepositoryImpl implements the StockSubscriptionRepository interface
+type StockSubscriptionRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewStockSubscriptionRepository creates a new StockSubscriptionRepositoryImpl
+func NewStockSubscriptionRepository(db *gorm.DB) repository.StockSubscriptionRepository {
+	return &StockSubscriptionRepositoryImpl{
+		db: db,
+	}
+}
+
+// Create creates a new subscription
+func (r *StockSubscriptionRepositoryImpl) Create(ctx context.Context, subscription *domain.StockSubscription) error {
+	return r.db.Create(subscription).Error
+}
+
+// FindPendingByEmail retrieves the pending subscription of an email to a product
+func (r *StockSubscriptionRepositoryImpl) FindPendingByEmail(ctx context.Context, productID uint, email string) (*domain.StockSubscription, error) {
+	var subscription domain.StockSubscription
+	if err := r.db.Where("product_id = ? AND email = ? AND status = ?", productID, email, domain.StockSubscriptionStatusPending).
+		First(&subscription).Error; err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// FindByToken retrieves a subscription by its unsubscribe token
+func (r *StockSubscriptionRepositoryImpl) FindByToken(ctx context.Context, token string) (*domain.StockSubscription, error) {
+	var subscription domain.StockSubscription
+	if err := r.db.Where("token = ?", token).First(&subscription).Error; err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// FindByUserID retrieves the subscriptions of a user, newest first
+func (r *StockSubscriptionRepositoryImpl) FindByUserID(ctx context.Context, userID uint) ([]domain.StockSubscription, error) {
+	var subscriptions []domain.StockSubscription
+	if err := r.db.Where("user_id = ?", userID).Order("id DESC").Find(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// UpdateStatus updates the status of a subscription
+func (r *StockSubscriptionRepositoryImpl) UpdateStatus(ctx context.Context, id uint, status domain.StockSubscriptionStatus) error {
+	return r.db.Model(&domain.StockSubscription{}).Where("id = ?", id).Update("status", status).Error
+}
+
+// FindSubscribedProductIDs retrieves the products with pending subscriptions
+func (r *StockSubscriptionRepositoryImpl) FindSubscribedProductIDs(ctx context.Context) ([]uint, error) {
+	var productIDs []uint
+	if err := r.db.Model(&domain.StockSubscription{}).
+		Where("status = ? AND expires_at > ?", domain.StockSubscriptionStatusPending, time.Now()).
+		Distinct().
+		Order("product_id ASC").
+		Pluck("product_id", &productIDs).Error; err != nil {
+		return nil, err
+	}
+	return productIDs, nil
+}
+
+// FindLastRestock retrieves when the stock of a product last moved from zero
+// to positive, nil when it never has. Every stock change is in the inventory
+// ledger, so a restock is an entry taking the balance from zero or below to above it.
+func (r *StockSubscriptionRepositoryImpl) FindLastRestock(ctx context.Context, productID uint) (*time.Time, error) {
+	var restock struct {
+		RestockedAt *time.Time
+	}
+	if err := r.db.Model(&domain.InventoryLedgerEntry{}).
+		Select("MAX(created_at) AS restocked_at").
+		Where("product_id = ? AND balance > 0 AND balance - delta <= 0", productID).
+		Scan(&restock).Error; err != nil {
+		return nil, err
+	}
+	return restock.RestockedAt, nil
+}
+
+// CountNotifiedSince counts the subscribers of a product notified since a time
+func (r *StockSubscriptionRepositoryImpl) CountNotifiedSince(ctx context.Context, productID uint, since time.Time) (int64, error) {
+	var count int64
+	if err := r.db.Model(&domain.StockSubscription{}).
+		Where("product_id = ? AND status = ? AND notified_at >= ?", productID, domain.StockSubscriptionStatusNotified, since).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// FindPendingBefore retrieves up to limit pending subscriptions to a product
+// made before a time, oldest first
+func (r *StockSubscriptionRepositoryImpl) FindPendingBefore(ctx context.Context, productID uint, before time.Time, limit int) ([]domain.StockSubscription, error) {
+	var subscriptions []domain.StockSubscription
+	if err := r.db.Where("product_id = ? AND status = ? AND expires_at > ? AND created_at <= ?",
+		productID, domain.StockSubscriptionStatusPending, time.Now(), before).
+		Order("created_at ASC, id ASC").
+		Limit(limit).
+		Find(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// MarkNotified marks a pending subscription as notified
+func (r *StockSubscriptionRepositoryImpl) MarkNotified(ctx context.Context, id uint, notifiedAt time.Time) error {
+	return r.db.Model(&domain.StockSubscription{}).
+		Where("id = ? AND status = ?", id, domain.StockSubscriptionStatusPending).
+		Updates(map[string]interface{}{
+			"status":      domain.StockSubscriptionStatusNotified,
+			"notified_at": notifiedAt,
+		}).Error
+}
+
+// ExpireBefore expires the pending subscriptions whose expiry is before a time,
+// returning how many expired
+func (r *StockSubscriptionRepositoryImpl) ExpireBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.Model(&domain.StockSubscription{}).
+		Where("status = ? AND expires_at <= ?", domain.StockSubscriptionStatusPending, before).
+		Update("status", domain.StockSubscriptionStatusExpired)
+	return result.RowsAffected, result.Error
+}
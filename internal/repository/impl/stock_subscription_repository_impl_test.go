@@ -0,0 +1,152 @@
+package impl_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+	"awesomeEcommerce/internal/repository/impl"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// StockSubscriptionRepositoryTestSuite is a test suite for StockSubscriptionRepositoryImpl
+type StockSubscriptionRepositoryTestSuite struct {
+	suite.Suite
+	repo    repository.StockSubscriptionRepository
+	sqlMock sqlmock.Sqlmock
+	ctx     context.Context
+}
+
+// SetupTest sets up the test suite
+func (s *StockSubscriptionRepositoryTestSuite) SetupTest() {
+	db, sqlMock := newMockDB(s.T())
+	s.repo = impl.NewStockSubscriptionRepository(db)
+	s.sqlMock = sqlMock
+	s.ctx = context.Background()
+}
+
+// TestFindPendingByEmail tests the FindPendingByEmail method
+func (s *StockSubscriptionRepositoryTestSuite) TestFindPendingByEmail() {
+	s.Run("Error - Not Found", func() {
+		// Test case: The email has no pending subscription to the product
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `stock_subscriptions` WHERE product_id = ? AND email = ? AND status = ?")).
+			WithArgs(1, "guest@example.com", domain.StockSubscriptionStatusPending).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		// Execute
+		subscription, err := s.repo.FindPendingByEmail(s.ctx, 1, "guest@example.com")
+
+		// Assert
+		assert.ErrorIs(s.T(), err, gorm.ErrRecordNotFound)
+		assert.Nil(s.T(), subscription)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestFindLastRestock tests the FindLastRestock method
+func (s *StockSubscriptionRepositoryTestSuite) TestFindLastRestock() {
+	s.Run("Success", func() {
+		// Test case: The latest ledger entry taking the stock above zero
+		restockedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT MAX(created_at) AS restocked_at FROM `inventory_ledger` WHERE product_id = ? AND balance > 0 AND balance - delta <= 0")).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"restocked_at"}).AddRow(restockedAt))
+
+		// Execute
+		lastRestock, err := s.repo.FindLastRestock(s.ctx, 1)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), &restockedAt, lastRestock)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+
+	s.Run("Success - Never Restocked", func() {
+		// Reset mock
+		s.SetupTest()
+
+		// Test case: The stock never went from zero to positive
+		s.sqlMock.ExpectQuery("SELECT MAX\\(created_at\\) AS restocked_at FROM `inventory_ledger`").
+			WillReturnRows(sqlmock.NewRows([]string{"restocked_at"}).AddRow(nil))
+
+		// Execute
+		lastRestock, err := s.repo.FindLastRestock(s.ctx, 1)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Nil(s.T(), lastRestock)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestFindPendingBefore tests the FindPendingBefore method
+func (s *StockSubscriptionRepositoryTestSuite) TestFindPendingBefore() {
+	s.Run("Success", func() {
+		// Test case: Unexpired pending subscriptions made before the restock,
+		// oldest first
+		before := time.Now()
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `stock_subscriptions` WHERE product_id = ? AND status = ? AND expires_at > ? AND created_at <= ? ORDER BY created_at ASC, id ASC LIMIT 50")).
+			WithArgs(1, domain.StockSubscriptionStatusPending, sqlmock.AnyArg(), before).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "product_id", "email", "status"}).
+				AddRow(3, 1, "first@example.com", domain.StockSubscriptionStatusPending).
+				AddRow(5, 1, "second@example.com", domain.StockSubscriptionStatusPending))
+
+		// Execute
+		subscriptions, err := s.repo.FindPendingBefore(s.ctx, 1, before, 50)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Len(s.T(), subscriptions, 2)
+		assert.Equal(s.T(), "first@example.com", subscriptions[0].Email)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestMarkNotified tests the MarkNotified method
+func (s *StockSubscriptionRepositoryTestSuite) TestMarkNotified() {
+	s.Run("Success", func() {
+		// Test case: Only a subscription still pending is marked notified
+		notifiedAt := time.Now()
+		s.sqlMock.ExpectExec(regexp.QuoteMeta("UPDATE `stock_subscriptions` SET `notified_at`=?,`status`=?,`updated_at`=? WHERE id = ? AND status = ?")).
+			WithArgs(notifiedAt, domain.StockSubscriptionStatusNotified, sqlmock.AnyArg(), 1, domain.StockSubscriptionStatusPending).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		// Execute
+		err := s.repo.MarkNotified(s.ctx, 1, notifiedAt)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestExpireBefore tests the ExpireBefore method
+func (s *StockSubscriptionRepositoryTestSuite) TestExpireBefore() {
+	s.Run("Success", func() {
+		// Test case: Lapsed pending subscriptions are expired and counted
+		before := time.Now()
+		s.sqlMock.ExpectExec(regexp.QuoteMeta("UPDATE `stock_subscriptions` SET `status`=?,`updated_at`=? WHERE status = ? AND expires_at <= ?")).
+			WithArgs(domain.StockSubscriptionStatusExpired, sqlmock.AnyArg(), domain.StockSubscriptionStatusPending, before).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+
+		// Execute
+		expired, err := s.repo.ExpireBefore(s.ctx, before)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), int64(3), expired)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestStockSubscriptionRepositorySuite runs the test suite
+func TestStockSubscriptionRepositorySuite(t *testing.T) {
+	suite.Run(t, new(StockSubscriptionRepositoryTestSuite))
+}
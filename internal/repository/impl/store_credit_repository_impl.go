@@ -0,0 +1,108 @@
+package impl
+
+import (
+	"context"
+	"errors"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StoreCreditRepositoryImpl implements the StoreCreditRepository interface
+type StoreCreditRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewStoreCreditRepository creates a new StoreCreditRepositoryImpl
+func NewStoreCreditRepository(db *gorm.DB) repository.StoreCreditRepository {
+	return &StoreCreditRepositoryImpl{
+		db: db,
+	}
+}
+
+// FindByUserID retrieves the store credit accounts of a user, one per currency
+func (r *StoreCreditRepositoryImpl) FindByUserID(ctx context.Context, userID uint) ([]domain.StoreCreditAccount, error) {
+	var accounts []domain.StoreCreditAccount
+	if err := conn(ctx, r.db).Where("user_id = ?", userID).Order("currency").Find(&accounts).Error; err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// FindAccount retrieves the store credit account of a user in a currency
+func (r *StoreCreditRepositoryImpl) FindAccount(ctx context.Context, userID uint, currency string) (*domain.StoreCreditAccount, error) {
+	var account domain.StoreCreditAccount
+	if err := conn(ctx, r.db).Where("user_id = ? AND currency = ?", userID, currency).First(&account).Error; err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// Adjust changes the store credit of a user in the currency of delta, opening
+// the account on its first credit, and records the change in the balance
+// ledger. It fails with domain.ErrInsufficientBalance rather than take the
+// balance below zero.
+func (r *StoreCreditRepositoryImpl) Adjust(ctx context.Context, userID uint, delta domain.Money, change domain.BalanceChange) (*domain.StoreCreditAccount, error) {
+	var account domain.StoreCreditAccount
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		// Lock the account until the transaction ends, so concurrent checkouts
+		// can never spend the same credit twice
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND currency = ?", userID, delta.Currency).
+			First(&account).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if delta.Amount < 0 {
+				return domain.ErrInsufficientBalance
+			}
+			account = domain.StoreCreditAccount{
+				UserID:   userID,
+				Currency: delta.Currency,
+				Balance:  delta,
+			}
+			if err := tx.Create(&account).Error; err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		default:
+			if account.Balance.Amount+delta.Amount < 0 {
+				return domain.ErrInsufficientBalance
+			}
+			account.Balance.Amount += delta.Amount
+			if err := tx.Model(&account).Update("balance_amount", account.Balance.Amount).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Create(&domain.BalanceEntry{
+			StoreCreditAccountID: &account.ID,
+			Delta:                delta,
+			Balance:              account.Balance,
+			Reason:               change.Reason,
+			ReferenceID:          change.ReferenceID,
+			Actor:                change.Actor,
+			Note:                 change.Note,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &account, nil
+}
+
+// FindLedger retrieves the balance ledger of every store credit account of a user, newest first
+func (r *StoreCreditRepositoryImpl) FindLedger(ctx context.Context, userID uint) ([]domain.BalanceEntry, error) {
+	var entries []domain.BalanceEntry
+	if err := conn(ctx, r.db).Joins("JOIN store_credit_accounts ON store_credit_accounts.id = balance_ledger.store_credit_account_id").
+		Where("store_credit_accounts.user_id = ?", userID).
+		Order("balance_ledger.id DESC").
+		Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,155 @@
+package impl_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+	"awesomeEcommerce/internal/repository/impl"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// StoreCreditRepositoryTestSuite is a test suite for StoreCreditRepositoryImpl
+type StoreCreditRepositoryTestSuite struct {
+	suite.Suite
+	repo    repository.StoreCreditRepository
+	sqlMock sqlmock.Sqlmock
+	ctx     context.Context
+}
+
+// SetupTest sets up the test suite
+func (s *StoreCreditRepositoryTestSuite) SetupTest() {
+	db, sqlMock := newMockDB(s.T())
+	s.repo = impl.NewStoreCreditRepository(db)
+	s.sqlMock = sqlMock
+	s.ctx = context.Background()
+}
+
+// lockAccount is the query locking the store credit account of a user in a currency
+var lockAccount = regexp.QuoteMeta("SELECT * FROM `store_credit_accounts` WHERE user_id = ? AND currency = ? ORDER BY `store_credit_accounts`.`id` LIMIT 1 FOR UPDATE")
+
+// TestAdjust tests the Adjust method
+func (s *StoreCreditRepositoryTestSuite) TestAdjust() {
+	accountColumns := []string{"id", "user_id", "currency", "balance_amount", "balance_currency"}
+
+	s.Run("Success - Spend", func() {
+		// Test case: Credit is taken from the locked account and the new
+		// balance recorded in the ledger
+		change := domain.BalanceChange{Reason: domain.BalanceReasonRedemption, ReferenceID: 9}
+		s.sqlMock.ExpectBegin()
+		s.sqlMock.ExpectQuery(lockAccount).
+			WithArgs(7, "EUR").
+			WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(2, 7, "EUR", 5000, "EUR"))
+		s.sqlMock.ExpectExec(regexp.QuoteMeta("UPDATE `store_credit_accounts` SET `balance_amount`=?,`updated_at`=? WHERE `id` = ?")).
+			WithArgs(3000, sqlmock.AnyArg(), 2).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		s.sqlMock.ExpectExec("INSERT INTO `balance_ledger`").
+			WithArgs(nil, uint(2), int64(-2000), "EUR", int64(3000), "EUR", domain.BalanceReasonRedemption, uint(9), "", "", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		s.sqlMock.ExpectCommit()
+
+		// Execute
+		account, err := s.repo.Adjust(s.ctx, 7, domain.Money{Amount: -2000, Currency: "EUR"}, change)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), int64(3000), account.Balance.Amount)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+
+	s.Run("Success - First Credit", func() {
+		// Reset mock
+		s.SetupTest()
+
+		// Test case: A user without credit in the currency gets an account
+		change := domain.BalanceChange{Reason: domain.BalanceReasonRefund, ReferenceID: 9}
+		s.sqlMock.ExpectBegin()
+		s.sqlMock.ExpectQuery(lockAccount).
+			WithArgs(7, "USD").
+			WillReturnRows(sqlmock.NewRows(accountColumns))
+		s.sqlMock.ExpectExec("INSERT INTO `store_credit_accounts`").
+			WithArgs(7, "USD", int64(1500), "USD", sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(4, 1))
+		s.sqlMock.ExpectExec("INSERT INTO `balance_ledger`").
+			WithArgs(nil, uint(4), int64(1500), "USD", int64(1500), "USD", domain.BalanceReasonRefund, uint(9), "", "", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		s.sqlMock.ExpectCommit()
+
+		// Execute
+		account, err := s.repo.Adjust(s.ctx, 7, domain.Money{Amount: 1500, Currency: "USD"}, change)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), uint(4), account.ID)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+
+	s.Run("Error - Insufficient Balance", func() {
+		// Reset mock
+		s.SetupTest()
+
+		// Test case: The account holds less than is spent
+		change := domain.BalanceChange{Reason: domain.BalanceReasonRedemption, ReferenceID: 9}
+		s.sqlMock.ExpectBegin()
+		s.sqlMock.ExpectQuery(lockAccount).
+			WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(2, 7, "EUR", 1000, "EUR"))
+		s.sqlMock.ExpectRollback()
+
+		// Execute
+		account, err := s.repo.Adjust(s.ctx, 7, domain.Money{Amount: -2000, Currency: "EUR"}, change)
+
+		// Assert
+		assert.ErrorIs(s.T(), err, domain.ErrInsufficientBalance)
+		assert.Nil(s.T(), account)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+
+	s.Run("Error - No Account", func() {
+		// Reset mock
+		s.SetupTest()
+
+		// Test case: Credit cannot be spent before the account exists
+		change := domain.BalanceChange{Reason: domain.BalanceReasonRedemption, ReferenceID: 9}
+		s.sqlMock.ExpectBegin()
+		s.sqlMock.ExpectQuery(lockAccount).WillReturnRows(sqlmock.NewRows(accountColumns))
+		s.sqlMock.ExpectRollback()
+
+		// Execute
+		account, err := s.repo.Adjust(s.ctx, 7, domain.Money{Amount: -2000, Currency: "EUR"}, change)
+
+		// Assert
+		assert.ErrorIs(s.T(), err, domain.ErrInsufficientBalance)
+		assert.Nil(s.T(), account)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestFindLedger tests the FindLedger method
+func (s *StoreCreditRepositoryTestSuite) TestFindLedger() {
+	s.Run("Success", func() {
+		// Test case: The entries of every account of the user, newest first
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("FROM `balance_ledger` JOIN store_credit_accounts ON store_credit_accounts.id = balance_ledger.store_credit_account_id WHERE store_credit_accounts.user_id = ? ORDER BY balance_ledger.id DESC")).
+			WithArgs(7).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "store_credit_account_id", "delta_amount", "delta_currency"}).
+				AddRow(2, 4, 1500, "USD").
+				AddRow(1, 2, -2000, "EUR"))
+
+		// Execute
+		entries, err := s.repo.FindLedger(s.ctx, 7)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Len(s.T(), entries, 2)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestStoreCreditRepositorySuite runs the test suite
+func TestStoreCreditRepositorySuite(t *testing.T) {
+	suite.Run(t, new(StoreCreditRepositoryTestSuite))
+}
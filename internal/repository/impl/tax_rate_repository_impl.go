@@ -0,0 +1,64 @@
+package impl
+
+import (
+	"context"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// TaxRateRepositoryImpl implements the TaxRateRepository interface
+type TaxRateRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewTaxRateRepository creates a new TaxRateRepositoryImpl
+func NewTaxRateRepository(db *gorm.DB) repository.TaxRateRepository {
+	return &TaxRateRepositoryImpl{
+		db: db,
+	}
+}
+
+// FindByID retrieves a tax rate by its ID
+func (r *TaxRateRepositoryImpl) FindByID(ctx context.Context, id uint) (*domain.TaxRate, error) {
+	var rate domain.TaxRate
+	if err := r.db.First(&rate, id).Error; err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+// FindAll retrieves every tax rate, ordered by location and tax class
+func (r *TaxRateRepositoryImpl) FindAll(ctx context.Context) ([]domain.TaxRate, error) {
+	var rates []domain.TaxRate
+	if err := r.db.Order("country, region, postal_prefix, tax_class").Find(&rates).Error; err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+// FindByCountry retrieves the tax rates of a country
+func (r *TaxRateRepositoryImpl) FindByCountry(ctx context.Context, country string) ([]domain.TaxRate, error) {
+	var rates []domain.TaxRate
+	if err := r.db.Where("country = ?", country).Find(&rates).Error; err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+// Create creates a new tax rate
+func (r *TaxRateRepositoryImpl) Create(ctx context.Context, rate *domain.TaxRate) error {
+	return r.db.Create(rate).Error
+}
+
+// Update updates an existing tax rate
+func (r *TaxRateRepositoryImpl) Update(ctx context.Context, rate *domain.TaxRate) error {
+	return r.db.Save(rate).Error
+}
+
+// Delete deletes a tax rate by its ID
+func (r *TaxRateRepositoryImpl) Delete(ctx context.Context, id uint) error {
+	return r.db.Delete(&domain.TaxRate{}, id).Error
+}
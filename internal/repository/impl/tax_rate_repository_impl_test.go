@@ -0,0 +1,111 @@
+package impl_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"awesomeEcommerce/internal/repository"
+	"awesomeEcommerce/internal/repository/impl"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// TaxRateRepositoryTestSuite is a test suite for TaxRateRepositoryImpl
+type TaxRateRepositoryTestSuite struct {
+	suite.Suite
+	repo    repository.TaxRateRepository
+	sqlMock sqlmock.Sqlmock
+	ctx     context.Context
+}
+
+// SetupTest sets up the test suite
+func (s *TaxRateRepositoryTestSuite) SetupTest() {
+	db, sqlMock := newMockDB(s.T())
+	s.repo = impl.NewTaxRateRepository(db)
+	s.sqlMock = sqlMock
+	s.ctx = context.Background()
+}
+
+// TestFindByID tests the FindByID method
+func (s *TaxRateRepositoryTestSuite) TestFindByID() {
+	s.Run("Error - Not Found", func() {
+		// Test case: No tax rate has the ID
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `tax_rates` WHERE `tax_rates`.`id` = ?")).
+			WithArgs(99).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		// Execute
+		rate, err := s.repo.FindByID(s.ctx, 99)
+
+		// Assert
+		assert.ErrorIs(s.T(), err, gorm.ErrRecordNotFound)
+		assert.Nil(s.T(), rate)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestFindAll tests the FindAll method
+func (s *TaxRateRepositoryTestSuite) TestFindAll() {
+	s.Run("Success", func() {
+		// Test case: Every rate, ordered by location and tax class
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `tax_rates` ORDER BY country, region, postal_prefix, tax_class")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "country", "region", "tax_class", "rate"}).
+				AddRow(1, "DE", "", "standard", 0.19).
+				AddRow(2, "US", "CA", "standard", 0.0725))
+
+		// Execute
+		rates, err := s.repo.FindAll(s.ctx)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Len(s.T(), rates, 2)
+		assert.Equal(s.T(), 0.0725, rates[1].Rate)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestFindByCountry tests the FindByCountry method
+func (s *TaxRateRepositoryTestSuite) TestFindByCountry() {
+	s.Run("Success", func() {
+		// Test case: Every rate of the country, whatever its region
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `tax_rates` WHERE country = ?")).
+			WithArgs("US").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "country", "region", "rate"}).
+				AddRow(2, "US", "CA", 0.0725).
+				AddRow(3, "US", "NY", 0.04))
+
+		// Execute
+		rates, err := s.repo.FindByCountry(s.ctx, "US")
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Len(s.T(), rates, 2)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestDelete tests the Delete method
+func (s *TaxRateRepositoryTestSuite) TestDelete() {
+	s.Run("Success", func() {
+		// Test case: The rate is deleted by its ID
+		s.sqlMock.ExpectExec(regexp.QuoteMeta("DELETE FROM `tax_rates` WHERE `tax_rates`.`id` = ?")).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		// Execute
+		err := s.repo.Delete(s.ctx, 1)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestTaxRateRepositorySuite runs the test suite
+func TestTaxRateRepositorySuite(t *testing.T) {
+	suite.Run(t, new(TaxRateRepositoryTestSuite))
+}
@@ -0,0 +1,72 @@
+package impl
+
+import (
+	"context"
+
+	"awesomeEcommerce/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// unitOfWorkKey is the context key of the unit of work in progress
+type unitOfWorkKey struct{}
+
+// unitOfWork is a transaction in progress and the work waiting for it to commit
+type unitOfWork struct {
+	tx          *gorm.DB
+	afterCommit []func()
+}
+
+// TransactorImpl implements the Transactor interface
+type TransactorImpl struct {
+	db *gorm.DB
+}
+
+// NewTransactor creates a new TransactorImpl
+func NewTransactor(db *gorm.DB) repository.Transactor {
+	return &TransactorImpl{
+		db: db,
+	}
+}
+
+// WithinTransaction runs fn in a database transaction carried by the context
+// fn is given. Cache changes made by repositories in the transaction are
+// applied once it commits, and dropped if it rolls back.
+func (t *TransactorImpl) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(unitOfWorkKey{}).(*unitOfWork); ok {
+		return fn(ctx)
+	}
+
+	work := &unitOfWork{}
+	err := t.db.Transaction(func(tx *gorm.DB) error {
+		work.tx = tx
+		return fn(context.WithValue(ctx, unitOfWorkKey{}, work))
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, fn := range work.afterCommit {
+		fn()
+	}
+	return nil
+}
+
+// conn returns the transaction carried by the context, or db outside one
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if work, ok := ctx.Value(unitOfWorkKey{}).(*unitOfWork); ok {
+		return work.tx
+	}
+	return db
+}
+
+// afterCommit runs fn once the transaction carried by the context commits, or
+// straight away outside one. Cache changes wait for the commit so that the
+// cache never serves rows that were rolled back.
+func afterCommit(ctx context.Context, fn func()) {
+	if work, ok := ctx.Value(unitOfWorkKey{}).(*unitOfWork); ok {
+		work.afterCommit = append(work.afterCommit, fn)
+		return
+	}
+	fn()
+}
@@ -0,0 +1,339 @@
+package impl_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"awesomeEcommerce/internal/config"
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+	"awesomeEcommerce/internal/repository/cache"
+	"awesomeEcommerce/internal/repository/impl"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// MockTransactor is a mock implementation of the Transactor interface that
+// runs the work it is given
+type MockTransactor struct {
+	mock.Mock
+}
+
+func (m *MockTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	args := m.Called(ctx)
+	if err := args.Error(0); err != nil {
+		return err
+	}
+	return fn(ctx)
+}
+
+// TransactorTestSuite is a test suite for Transactor
+type TransactorTestSuite struct {
+	suite.Suite
+	mockTransactor repository.Transactor
+	ctx            context.Context
+}
+
+// SetupTest sets up the test suite
+func (s *TransactorTestSuite) SetupTest() {
+	s.mockTransactor = new(MockTransactor)
+	s.ctx = context.Background()
+}
+
+// TestWithinTransaction tests the WithinTransaction method
+func (s *TransactorTestSuite) TestWithinTransaction() {
+	mockTransactor := s.mockTransactor.(*MockTransactor)
+
+	s.Run("Success", func() {
+		// Test case: The work runs and the transaction commits
+		mockTransactor.On("WithinTransaction", s.ctx).Return(nil).Once()
+		ran := false
+
+		// Execute
+		err := s.mockTransactor.WithinTransaction(s.ctx, func(ctx context.Context) error {
+			ran = true
+			return nil
+		})
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.True(s.T(), ran)
+		mockTransactor.AssertExpectations(s.T())
+	})
+
+	s.Run("Error - Work Failed", func() {
+		// Reset mock
+		s.SetupTest()
+		mockTransactor = s.mockTransactor.(*MockTransactor)
+
+		// Test case: The error of the work rolls the transaction back and is returned
+		mockTransactor.On("WithinTransaction", s.ctx).Return(nil).Once()
+		workErr := errors.New("database error")
+
+		// Execute
+		err := s.mockTransactor.WithinTransaction(s.ctx, func(ctx context.Context) error {
+			return workErr
+		})
+
+		// Assert
+		assert.Equal(s.T(), workErr, err)
+		mockTransactor.AssertExpectations(s.T())
+	})
+
+	s.Run("Error - Transaction Not Started", func() {
+		// Reset mock
+		s.SetupTest()
+		mockTransactor = s.mockTransactor.(*MockTransactor)
+
+		// Test case: No work runs when the transaction cannot begin
+		mockTransactor.On("WithinTransaction", s.ctx).Return(errors.New("connection refused")).Once()
+		ran := false
+
+		// Execute
+		err := s.mockTransactor.WithinTransaction(s.ctx, func(ctx context.Context) error {
+			ran = true
+			return nil
+		})
+
+		// Assert
+		assert.EqualError(s.T(), err, "connection refused")
+		assert.False(s.T(), ran)
+		mockTransactor.AssertExpectations(s.T())
+	})
+}
+
+// TestTransactorSuite runs the test suite
+func TestTransactorSuite(t *testing.T) {
+	suite.Run(t, new(TransactorTestSuite))
+}
+
+// TransactorImplTestSuite is a test suite for TransactorImpl, run against a
+// mocked MySQL connection
+type TransactorImplTestSuite struct {
+	suite.Suite
+	sqlMock    sqlmock.Sqlmock
+	db         *gorm.DB
+	transactor repository.Transactor
+	ctx        context.Context
+}
+
+// newMockDB opens a database on a mocked MySQL connection, which expects the
+// statements a test sets on the returned mock
+func newMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	sqlDB, sqlMock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}), &gorm.Config{
+		Logger:                 logger.Discard,
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+
+	return db, sqlMock
+}
+
+// newMockCache connects a cache client to an in-memory Redis server
+func newMockCache(t *testing.T) *cache.RedisClient {
+	server := miniredis.RunT(t)
+	client, err := cache.NewRedisClient(&config.Config{Redis: config.RedisConfig{Host: server.Host(), Port: server.Port()}})
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+
+	return client
+}
+
+// SetupTest sets up the test suite
+func (s *TransactorImplTestSuite) SetupTest() {
+	s.db, s.sqlMock = newMockDB(s.T())
+	s.transactor = impl.NewTransactor(s.db)
+	s.ctx = context.Background()
+}
+
+// TestWithinTransaction tests the WithinTransaction method
+func (s *TransactorImplTestSuite) TestWithinTransaction() {
+	s.Run("Success - Commits", func() {
+		s.SetupTest()
+
+		// Test case: The work runs in the transaction, which commits before
+		// the work waiting for it runs
+		s.sqlMock.ExpectBegin()
+		s.sqlMock.ExpectExec("UPDATE products SET stock = stock - 1").WillReturnResult(sqlmock.NewResult(0, 1))
+		s.sqlMock.ExpectCommit()
+		committed := false
+
+		// Execute
+		err := s.transactor.WithinTransaction(s.ctx, func(ctx context.Context) error {
+			impl.AfterCommit(ctx, func() {
+				committed = s.sqlMock.ExpectationsWereMet() == nil
+			})
+			return impl.Conn(ctx, s.db).Exec("UPDATE products SET stock = stock - 1").Error
+		})
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.True(s.T(), committed)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+
+	s.Run("Error - Rolls Back On Error", func() {
+		s.SetupTest()
+
+		// Test case: The error of the work rolls the transaction back, and the
+		// work waiting for the commit never runs
+		s.sqlMock.ExpectBegin()
+		s.sqlMock.ExpectExec("UPDATE products SET stock = stock - 1").WillReturnResult(sqlmock.NewResult(0, 1))
+		s.sqlMock.ExpectRollback()
+		workErr := errors.New("insufficient stock")
+		ranAfterCommit := false
+
+		// Execute
+		err := s.transactor.WithinTransaction(s.ctx, func(ctx context.Context) error {
+			impl.AfterCommit(ctx, func() { ranAfterCommit = true })
+			if err := impl.Conn(ctx, s.db).Exec("UPDATE products SET stock = stock - 1").Error; err != nil {
+				return err
+			}
+			return workErr
+		})
+
+		// Assert
+		assert.Equal(s.T(), workErr, err)
+		assert.False(s.T(), ranAfterCommit)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+
+	s.Run("Error - Rolls Back On Panic", func() {
+		s.SetupTest()
+
+		// Test case: A panic in the work rolls the transaction back and goes
+		// on to the caller
+		s.sqlMock.ExpectBegin()
+		s.sqlMock.ExpectRollback()
+		ranAfterCommit := false
+
+		// Execute
+		work := func() {
+			s.transactor.WithinTransaction(s.ctx, func(ctx context.Context) error {
+				impl.AfterCommit(ctx, func() { ranAfterCommit = true })
+				panic("unexpected state")
+			})
+		}
+
+		// Assert
+		assert.PanicsWithValue(s.T(), "unexpected state", work)
+		assert.False(s.T(), ranAfterCommit)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+
+	s.Run("Error - Commit Failed", func() {
+		s.SetupTest()
+
+		// Test case: The work waiting for a commit that failed never runs
+		s.sqlMock.ExpectBegin()
+		s.sqlMock.ExpectCommit().WillReturnError(errors.New("connection lost"))
+		ranAfterCommit := false
+
+		// Execute
+		err := s.transactor.WithinTransaction(s.ctx, func(ctx context.Context) error {
+			impl.AfterCommit(ctx, func() { ranAfterCommit = true })
+			return nil
+		})
+
+		// Assert
+		assert.EqualError(s.T(), err, "connection lost")
+		assert.False(s.T(), ranAfterCommit)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+
+	s.Run("Success - Nested Work Joins The Transaction", func() {
+		s.SetupTest()
+
+		// Test case: Work started within a transaction runs in it, and what it
+		// waits for runs once the outer transaction commits
+		s.sqlMock.ExpectBegin()
+		s.sqlMock.ExpectExec("UPDATE products SET stock = stock - 1").WillReturnResult(sqlmock.NewResult(0, 1))
+		s.sqlMock.ExpectExec("DELETE FROM cart_items").WillReturnResult(sqlmock.NewResult(0, 1))
+		s.sqlMock.ExpectCommit()
+		var ran []string
+
+		// Execute
+		err := s.transactor.WithinTransaction(s.ctx, func(ctx context.Context) error {
+			if err := impl.Conn(ctx, s.db).Exec("UPDATE products SET stock = stock - 1").Error; err != nil {
+				return err
+			}
+			err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+				impl.AfterCommit(ctx, func() { ran = append(ran, "inner") })
+				return impl.Conn(ctx, s.db).Exec("DELETE FROM cart_items").Error
+			})
+			ran = append(ran, "outer")
+			return err
+		})
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), []string{"outer", "inner"}, ran)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+
+	s.Run("Error - Repositories Roll Back Together", func() {
+		s.SetupTest()
+
+		// Test case: A gift card redeemed for a payment that cannot be saved
+		// keeps its balance, as both run in the one transaction
+		giftCards := impl.NewGiftCardRepository(s.db)
+		payments := impl.NewPaymentRepository(s.db, newMockCache(s.T()))
+		s.sqlMock.ExpectBegin()
+		s.sqlMock.ExpectExec("SAVEPOINT").WillReturnResult(sqlmock.NewResult(0, 0))
+		s.sqlMock.ExpectExec("UPDATE `gift_cards`").WillReturnResult(sqlmock.NewResult(0, 1))
+		s.sqlMock.ExpectQuery("SELECT \\* FROM `gift_cards`").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "balance_amount", "balance_currency"}).AddRow(5, 0, "USD"))
+		s.sqlMock.ExpectExec("INSERT INTO `balance_ledger`").WillReturnResult(sqlmock.NewResult(1, 1))
+		s.sqlMock.ExpectExec("INSERT INTO `payments`").WillReturnError(errors.New("duplicate entry"))
+		s.sqlMock.ExpectRollback()
+
+		// Execute
+		err := s.transactor.WithinTransaction(s.ctx, func(ctx context.Context) error {
+			change := domain.BalanceChange{Reason: domain.BalanceReasonRedemption, ReferenceID: 1}
+			if _, err := giftCards.Adjust(ctx, 5, domain.Money{Amount: -3000, Currency: "USD"}, change); err != nil {
+				return err
+			}
+			return payments.Create(ctx, &domain.Payment{OrderID: 1, Amount: domain.Money{Amount: 3000, Currency: "USD"}, Method: domain.PaymentMethodGiftCard})
+		})
+
+		// Assert
+		assert.EqualError(s.T(), err, "duplicate entry")
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+
+	s.Run("Success - Outside A Transaction", func() {
+		s.SetupTest()
+
+		// Test case: Without a transaction, statements run on the database and
+		// nothing waits for a commit
+		s.sqlMock.ExpectExec("UPDATE products SET stock = stock - 1").WillReturnResult(sqlmock.NewResult(0, 1))
+		ran := false
+
+		// Execute
+		err := impl.Conn(s.ctx, s.db).Exec("UPDATE products SET stock = stock - 1").Error
+		impl.AfterCommit(s.ctx, func() { ran = true })
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.True(s.T(), ran)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestTransactorImplSuite runs the test suite
+func TestTransactorImplSuite(t *testing.T) {
+	suite.Run(t, new(TransactorImplTestSuite))
+}
@@ -0,0 +1,91 @@
+package impl
+
+import (
+	"context"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TranslationRepositoryImpl implements the TranslationRepository interface
+type TranslationRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewTranslationRepository creates a new TranslationRepositoryImpl
+func NewTranslationRepository(db *gorm.DB) repository.TranslationRepository {
+	return &TranslationRepositoryImpl{
+		db: db,
+	}
+}
+
+// FindProductTranslations retrieves the translations of products in any of the given locales
+func (r *TranslationRepositoryImpl) FindProductTranslations(ctx context.Context, productIDs []uint, locales []string) ([]domain.ProductTranslation, error) {
+	var translations []domain.ProductTranslation
+	if len(productIDs) == 0 || len(locales) == 0 {
+		return translations, nil
+	}
+	if err := r.db.Where("product_id IN ? AND locale IN ?", productIDs, locales).Find(&translations).Error; err != nil {
+		return nil, err
+	}
+	return translations, nil
+}
+
+// FindProductTranslationsByProductID retrieves every translation of a product, ordered by locale
+func (r *TranslationRepositoryImpl) FindProductTranslationsByProductID(ctx context.Context, productID uint) ([]domain.ProductTranslation, error) {
+	var translations []domain.ProductTranslation
+	if err := r.db.Where("product_id = ?", productID).Order("locale").Find(&translations).Error; err != nil {
+		return nil, err
+	}
+	return translations, nil
+}
+
+// SaveProductTranslation creates or updates the translation of a product in a locale
+func (r *TranslationRepositoryImpl) SaveProductTranslation(ctx context.Context, translation *domain.ProductTranslation) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "product_id"}, {Name: "locale"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "description", "updated_at"}),
+	}).Create(translation).Error
+}
+
+// DeleteProductTranslation deletes the translation of a product in a locale
+func (r *TranslationRepositoryImpl) DeleteProductTranslation(ctx context.Context, productID uint, locale string) error {
+	return r.db.Where("product_id = ? AND locale = ?", productID, locale).Delete(&domain.ProductTranslation{}).Error
+}
+
+// FindCategoryTranslations retrieves the translations of categories in any of the given locales
+func (r *TranslationRepositoryImpl) FindCategoryTranslations(ctx context.Context, categoryIDs []uint, locales []string) ([]domain.CategoryTranslation, error) {
+	var translations []domain.CategoryTranslation
+	if len(categoryIDs) == 0 || len(locales) == 0 {
+		return translations, nil
+	}
+	if err := r.db.Where("category_id IN ? AND locale IN ?", categoryIDs, locales).Find(&translations).Error; err != nil {
+		return nil, err
+	}
+	return translations, nil
+}
+
+// FindCategoryTranslationsByCategoryID retrieves every translation of a category, ordered by locale
+func (r *TranslationRepositoryImpl) FindCategoryTranslationsByCategoryID(ctx context.Context, categoryID uint) ([]domain.CategoryTranslation, error) {
+	var translations []domain.CategoryTranslation
+	if err := r.db.Where("category_id = ?", categoryID).Order("locale").Find(&translations).Error; err != nil {
+		return nil, err
+	}
+	return translations, nil
+}
+
+// SaveCategoryTranslation creates or updates the translation of a category in a locale
+func (r *TranslationRepositoryImpl) SaveCategoryTranslation(ctx context.Context, translation *domain.CategoryTranslation) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "category_id"}, {Name: "locale"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "updated_at"}),
+	}).Create(translation).Error
+}
+
+// DeleteCategoryTranslation deletes the translation of a category in a locale
+func (r *TranslationRepositoryImpl) DeleteCategoryTranslation(ctx context.Context, categoryID uint, locale string) error {
+	return r.db.Where("category_id = ? AND locale = ?", categoryID, locale).Delete(&domain.CategoryTranslation{}).Error
+}
@@ -0,0 +1,124 @@
+package impl_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+	"awesomeEcommerce/internal/repository/impl"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// TranslationRepositoryTestSuite is a test suite for TranslationRepositoryImpl
+type TranslationRepositoryTestSuite struct {
+	suite.Suite
+	repo    repository.TranslationRepository
+	sqlMock sqlmock.Sqlmock
+	ctx     context.Context
+}
+
+// SetupTest sets up the test suite
+func (s *TranslationRepositoryTestSuite) SetupTest() {
+	db, sqlMock := newMockDB(s.T())
+	s.repo = impl.NewTranslationRepository(db)
+	s.sqlMock = sqlMock
+	s.ctx = context.Background()
+}
+
+// TestFindProductTranslations tests the FindProductTranslations method
+func (s *TranslationRepositoryTestSuite) TestFindProductTranslations() {
+	s.Run("Success", func() {
+		// Test case: The translations of the products in any fallback locale
+		s.sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `product_translations` WHERE product_id IN (?,?) AND locale IN (?,?)")).
+			WithArgs(1, 2, "fr-CA", "fr").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "product_id", "locale", "name"}).
+				AddRow(1, 1, "fr", "Chaise").
+				AddRow(2, 2, "fr-CA", "Tuque"))
+
+		// Execute
+		translations, err := s.repo.FindProductTranslations(s.ctx, []uint{1, 2}, []string{"fr-CA", "fr"})
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Len(s.T(), translations, 2)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+
+	s.Run("No Locales", func() {
+		// Reset mock
+		s.SetupTest()
+
+		// Test case: Nothing is queried without locales to look up
+
+		// Execute
+		translations, err := s.repo.FindProductTranslations(s.ctx, []uint{1, 2}, nil)
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.Empty(s.T(), translations)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestSaveProductTranslation tests the SaveProductTranslation method
+func (s *TranslationRepositoryTestSuite) TestSaveProductTranslation() {
+	s.Run("Success", func() {
+		// Test case: The translation is inserted, or replaced when the product
+		// already has one in the locale
+		s.sqlMock.ExpectExec(regexp.QuoteMeta("INSERT INTO `product_translations`") + ".*" +
+			regexp.QuoteMeta("ON DUPLICATE KEY UPDATE `name`=VALUES(`name`),`description`=VALUES(`description`),`updated_at`=VALUES(`updated_at`)")).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		// Execute
+		err := s.repo.SaveProductTranslation(s.ctx, &domain.ProductTranslation{ProductID: 1, Locale: "fr", Name: "Chaise"})
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestDeleteProductTranslation tests the DeleteProductTranslation method
+func (s *TranslationRepositoryTestSuite) TestDeleteProductTranslation() {
+	s.Run("Success", func() {
+		// Test case: Only the translation in the given locale is deleted
+		s.sqlMock.ExpectExec(regexp.QuoteMeta("DELETE FROM `product_translations` WHERE product_id = ? AND locale = ?")).
+			WithArgs(1, "fr").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		// Execute
+		err := s.repo.DeleteProductTranslation(s.ctx, 1, "fr")
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestSaveCategoryTranslation tests the SaveCategoryTranslation method
+func (s *TranslationRepositoryTestSuite) TestSaveCategoryTranslation() {
+	s.Run("Success", func() {
+		// Test case: The translation is inserted, or replaced when the category
+		// already has one in the locale
+		s.sqlMock.ExpectExec(regexp.QuoteMeta("INSERT INTO `category_translations`") + ".*" +
+			regexp.QuoteMeta("ON DUPLICATE KEY UPDATE `name`=VALUES(`name`),`updated_at`=VALUES(`updated_at`)")).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		// Execute
+		err := s.repo.SaveCategoryTranslation(s.ctx, &domain.CategoryTranslation{CategoryID: 1, Locale: "fr", Name: "Meubles"})
+
+		// Assert
+		assert.NoError(s.T(), err)
+		assert.NoError(s.T(), s.sqlMock.ExpectationsWereMet())
+	})
+}
+
+// TestTranslationRepositorySuite runs the test suite
+func TestTranslationRepositorySuite(t *testing.T) {
+	suite.Run(t, new(TranslationRepositoryTestSuite))
+}
@@ -561,7 +561,7 @@ func (s *UserRepositoryTestSuite) TestGetUserOrders() {
 						OrderID:   1,
 						ProductID: 2,
 						Quantity:  3,
-						Price:     10.99,
+						Price:     usd(1099),
 					},
 				},
 			},
@@ -575,7 +575,7 @@ func (s *UserRepositoryTestSuite) TestGetUserOrders() {
 						OrderID:   2,
 						ProductID: 3,
 						Quantity:  1,
-						Price:     5.99,
+						Price:     usd(599),
 					},
 				},
 			},
@@ -653,7 +653,7 @@ func (s *UserRepositoryTestSuite) TestGetUserCart() {
 					Product: domain.Product{
 						ID:    2,
 						Name:  "Test Product",
-						Price: 10.99,
+						Price: usd(1099),
 					},
 				},
 			},
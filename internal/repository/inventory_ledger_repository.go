@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+
+	"awesomeEcommerce/internal/domain"
+)
+
+// InventoryLedgerRepository defines the interface for inventory ledger repository operations
+type InventoryLedgerRepository interface {
+	// FindByProductID retrieves the stock movements of a product, newest first, with optional pagination
+	FindByProductID(ctx context.Context, productID uint, page, pageSize int) ([]domain.InventoryLedgerEntry, int64, error)
+
+	// Reconcile recomputes the stock of every product from its ledger and
+	// returns the products whose stock differs
+	Reconcile(ctx context.Context) ([]domain.StockDiscrepancy, error)
+}
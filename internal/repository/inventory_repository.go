@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+
+	"awesomeEcommerce/internal/domain"
+)
+
+// InventoryRepository defines the interface for warehouse inventory repository operations
+type InventoryRepository interface {
+	// FindWarehouses retrieves all warehouses
+	FindWarehouses(ctx context.Context) ([]domain.Warehouse, error)
+
+	// FindWarehouseByID retrieves a warehouse by its ID
+	FindWarehouseByID(ctx context.Context, id uint) (*domain.Warehouse, error)
+
+	// CreateWarehouse creates a new warehouse
+	CreateWarehouse(ctx context.Context, warehouse *domain.Warehouse) error
+
+	// UpdateWarehouse updates an existing warehouse
+	UpdateWarehouse(ctx context.Context, warehouse *domain.Warehouse) error
+
+	// FindLevelsByProductID retrieves the stock of a product at every warehouse
+	FindLevelsByProductID(ctx context.Context, productID uint) ([]domain.InventoryLevel, error)
+
+	// SetLevel sets the stock of a product at a warehouse and resyncs the product's total stock
+	SetLevel(ctx context.Context, productID, warehouseID uint, quantity int) error
+
+	// Transfer moves stock of a product between warehouses
+	Transfer(ctx context.Context, transfer *domain.InventoryTransfer) error
+
+	// Allocate reserves stock at warehouses for an order, all or nothing
+	Allocate(ctx context.Context, allocations []domain.OrderAllocation) error
+
+	// FindAllocationsByOrderID retrieves the stock reserved for an order
+	FindAllocationsByOrderID(ctx context.Context, orderID uint) ([]domain.OrderAllocation, error)
+
+	// ReleaseAllocations returns the stock reserved for an order to its warehouses
+	ReleaseAllocations(ctx context.Context, orderID uint) error
+}
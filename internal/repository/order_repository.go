@@ -20,8 +20,13 @@ type OrderRepository interface {
 	// Update updates an existing order
 	Update(ctx context.Context, order *domain.Order) error
 
-	// UpdateStatus updates the status of an order
-	UpdateStatus(ctx context.Context, id uint, status domain.OrderStatus) error
+	// UpdateStatus moves an order from the status its transition was checked
+	// from to another and records the change in its status history. It fails
+	// with domain.ErrOrderStatusChanged once the order is in another status.
+	UpdateStatus(ctx context.Context, id uint, from, to domain.OrderStatus, change domain.OrderStatusChange) error
+
+	// FindStatusHistory retrieves the status history of an order, oldest first
+	FindStatusHistory(ctx context.Context, orderID uint) ([]domain.OrderStatusHistory, error)
 
 	// Delete deletes an order by its ID
 	Delete(ctx context.Context, id uint) error
@@ -38,8 +43,9 @@ type OrderRepository interface {
 	// FindByStatus retrieves orders by status with optional pagination
 	FindByStatus(ctx context.Context, status domain.OrderStatus, page, pageSize int) ([]domain.Order, int64, error)
 
-	// GetOrderTotal calculates the total price of an order
-	GetOrderTotal(ctx context.Context, orderID uint) (float64, error)
+	// GetOrderTotal calculates the total price of an order, less its discounts,
+	// with its shipping and any tax its prices do not include
+	GetOrderTotal(ctx context.Context, orderID uint) (domain.Money, error)
 
 	// FindByDateRange retrieves orders created within a date range
 	FindByDateRange(ctx context.Context, startDate, endDate string, page, pageSize int) ([]domain.Order, int64, error)
@@ -11,10 +11,10 @@ type ProductRepository interface {
 	// FindByID retrieves a product by its ID
 	FindByID(ctx context.Context, id uint) (*domain.Product, error)
 
-	// FindAll retrieves all products with optional pagination
+	// FindAll retrieves all products still sold with optional pagination
 	FindAll(ctx context.Context, page, pageSize int) ([]domain.Product, int64, error)
 
-	// FindByCategory retrieves products by category ID with optional pagination
+	// FindByCategory retrieves products still sold by category ID with optional pagination
 	FindByCategory(ctx context.Context, categoryID uint, page, pageSize int) ([]domain.Product, int64, error)
 
 	// Create creates a new product
@@ -29,8 +29,13 @@ type ProductRepository interface {
 	// FindBySKU retrieves a product by its SKU
 	FindBySKU(ctx context.Context, sku string) (*domain.Product, error)
 
-	// UpdateStock updates the stock of a product
-	UpdateStock(ctx context.Context, id uint, quantity int) error
+	// UpdateStock updates the stock of a product and records the change in the inventory ledger.
+	// A decrease fails with *domain.InsufficientStockError rather than take stock below zero.
+	UpdateStock(ctx context.Context, id uint, quantity int, change domain.StockChange) error
+
+	// DecrementStock takes every line from stock in one transaction, all or nothing,
+	// failing with *domain.InsufficientStockError listing each line short of stock
+	DecrementStock(ctx context.Context, lines []domain.StockLine, change domain.StockChange) error
 
 	// FindCategories retrieves all product categories
 	FindCategories(ctx context.Context) ([]domain.ProductCategory, error)
@@ -46,4 +51,7 @@ type ProductRepository interface {
 
 	// DeleteCategory deletes a product category by its ID
 	DeleteCategory(ctx context.Context, id uint) error
+
+	// GetCacheStats retrieves the hit and miss counters of the product caches by namespace
+	GetCacheStats(ctx context.Context) (map[string]domain.CacheStats, error)
 }
\ No newline at end of file
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+)
+
+// PromotionRepository defines the interface for promotion repository operations
+type PromotionRepository interface {
+	// FindByID retrieves a promotion by its ID
+	FindByID(ctx context.Context, id uint) (*domain.Promotion, error)
+
+	// FindAll retrieves all promotions with optional pagination
+	FindAll(ctx context.Context, page, pageSize int) ([]domain.Promotion, int64, error)
+
+	// FindLive retrieves the active promotions running at a time, highest
+	// priority first
+	FindLive(ctx context.Context, at time.Time) ([]domain.Promotion, error)
+
+	// Create creates a new promotion
+	Create(ctx context.Context, promotion *domain.Promotion) error
+
+	// Update updates an existing promotion
+	Update(ctx context.Context, promotion *domain.Promotion) error
+
+	// Delete deletes a promotion by its ID
+	Delete(ctx context.Context, id uint) error
+}
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+
+	"awesomeEcommerce/internal/domain"
+)
+
+// RecommendationRepository defines the interface for product recommendation repository operations
+type RecommendationRepository interface {
+	// FindByProductID retrieves the highest scored recommendations for a product
+	FindByProductID(ctx context.Context, productID uint, limit int) ([]domain.ProductRecommendation, error)
+
+	// FindByProductIDs retrieves recommendations for any of the given products
+	FindByProductIDs(ctx context.Context, productIDs []uint) ([]domain.ProductRecommendation, error)
+
+	// IncrementBoughtTogether adds delta to the bought-together score of a product pair
+	IncrementBoughtTogether(ctx context.Context, productID, relatedProductID uint, delta float64) error
+
+	// RebuildBoughtTogether recomputes bought-together scores from the items of
+	// purchased orders up to the given order ID
+	RebuildBoughtTogether(ctx context.Context, upToOrderID uint) error
+
+	// ReplaceByType replaces all recommendations of a type with the given set
+	ReplaceByType(ctx context.Context, recommendationType domain.RecommendationType, recommendations []domain.ProductRecommendation) error
+
+	// GetLatestOrderID retrieves the ID of the most recently created order
+	GetLatestOrderID(ctx context.Context) (uint, error)
+
+	// FindOrderItemsAfter retrieves the items of the purchased orders among the
+	// next limit orders created after the given order ID, and the ID of the last
+	// of those orders, or afterOrderID when there are none
+	FindOrderItemsAfter(ctx context.Context, afterOrderID uint, limit int) ([]domain.OrderItem, uint, error)
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+)
+
+// ReservationRepository defines the interface for stock reservation repository operations
+type ReservationRepository interface {
+	// Reserve holds stock for order lines, all or nothing, failing with
+	// *domain.InsufficientStockError listing each line whose product does not
+	// have enough stock left after its active reservations
+	Reserve(ctx context.Context, reservations []domain.StockReservation) error
+
+	// FindByOrderID retrieves the reservations of an order
+	FindByOrderID(ctx context.Context, orderID uint) ([]domain.StockReservation, error)
+
+	// GetReservedQuantity sums the active, unexpired reservations of a product
+	GetReservedQuantity(ctx context.Context, productID uint) (int, error)
+
+	// Convert decrements product stock by the active reservations of an order
+	// and marks them converted, returning the number of reservations converted.
+	// It fails if any of them expired.
+	Convert(ctx context.Context, orderID uint, change domain.StockChange) (int, error)
+
+	// Release puts the stock held by an order back on sale: active reservations
+	// are released and converted ones are returned to product stock
+	Release(ctx context.Context, orderID uint, change domain.StockChange) error
+
+	// FindExpiredOrderIDs retrieves orders holding active reservations that expired before a time
+	FindExpiredOrderIDs(ctx context.Context, before time.Time, limit int) ([]uint, error)
+
+	// Expire marks the active reservations of an order expired
+	Expire(ctx context.Context, orderID uint) error
+}
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+
+	"awesomeEcommerce/internal/domain"
+)
+
+// ShipmentRepository defines the interface for shipment repository operations
+type ShipmentRepository interface {
+	// FindByOrderID retrieves the shipments of an order with their items, oldest first
+	FindByOrderID(ctx context.Context, orderID uint) ([]domain.Shipment, error)
+
+	// FindByTrackingNumber retrieves the shipment a carrier tracks by a
+	// tracking number. Within a transaction the shipment stays locked until
+	// the transaction ends.
+	FindByTrackingNumber(ctx context.Context, carrier, trackingNumber string) (*domain.Shipment, error)
+
+	// Create creates a new shipment with its items
+	Create(ctx context.Context, shipment *domain.Shipment) error
+
+	// Update updates the tracking of an existing shipment, leaving its items unchanged
+	Update(ctx context.Context, shipment *domain.Shipment) error
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+
+	"awesomeEcommerce/internal/domain"
+)
+
+// ShippingMethodRepository defines the interface for shipping method repository operations
+type ShippingMethodRepository interface {
+	// FindByID retrieves a shipping method by its ID
+	FindByID(ctx context.Context, id uint) (*domain.ShippingMethod, error)
+
+	// FindAll retrieves every shipping method, in the order they are offered
+	FindAll(ctx context.Context) ([]domain.ShippingMethod, error)
+
+	// FindActive retrieves the active shipping methods, in the order they are offered
+	FindActive(ctx context.Context) ([]domain.ShippingMethod, error)
+
+	// Create creates a new shipping method
+	Create(ctx context.Context, method *domain.ShippingMethod) error
+
+	// Update updates an existing shipping method
+	Update(ctx context.Context, method *domain.ShippingMethod) error
+
+	// Delete deletes a shipping method by its ID
+	Delete(ctx context.Context, id uint) error
+}
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+
+	"awesomeEcommerce/internal/domain"
+)
+
+// ShippingZoneRepository defines the interface for shipping zone repository operations
+type ShippingZoneRepository interface {
+	// FindByID retrieves a shipping zone by its ID
+	FindByID(ctx context.Context, id uint) (*domain.ShippingZone, error)
+
+	// FindAll retrieves every shipping zone, ordered by name
+	FindAll(ctx context.Context) ([]domain.ShippingZone, error)
+
+	// Create creates a new shipping zone
+	Create(ctx context.Context, zone *domain.ShippingZone) error
+
+	// Update updates an existing shipping zone
+	Update(ctx context.Context, zone *domain.ShippingZone) error
+
+	// Delete deletes a shipping zone by its ID
+	Delete(ctx context.Context, id uint) error
+}
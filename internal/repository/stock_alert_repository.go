@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+
+	"awesomeEcommerce/internal/domain"
+)
+
+// StockAlertRepository defines the interface for low-stock alert repository operations
+type StockAlertRepository interface {
+	// FindByProductID retrieves the alert state of a product, a new alert in the
+	// ok state when the product has never been checked
+	FindByProductID(ctx context.Context, productID uint) (*domain.StockAlert, error)
+
+	// Save creates or updates the alert state of a product
+	Save(ctx context.Context, alert *domain.StockAlert) error
+
+	// FindLowStock retrieves the products alerting for low stock, lowest stock first
+	FindLowStock(ctx context.Context) ([]domain.LowStockReportLine, error)
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+)
+
+// StockSubscriptionRepository defines the interface for back-in-stock subscription repository operations
+type StockSubscriptionRepository interface {
+	// Create creates a new subscription
+	Create(ctx context.Context, subscription *domain.StockSubscription) error
+
+	// FindPendingByEmail retrieves the pending subscription of an email to a product
+	FindPendingByEmail(ctx context.Context, productID uint, email string) (*domain.StockSubscription, error)
+
+	// FindByToken retrieves a subscription by its unsubscribe token
+	FindByToken(ctx context.Context, token string) (*domain.StockSubscription, error)
+
+	// FindByUserID retrieves the subscriptions of a user, newest first
+	FindByUserID(ctx context.Context, userID uint) ([]domain.StockSubscription, error)
+
+	// UpdateStatus updates the status of a subscription
+	UpdateStatus(ctx context.Context, id uint, status domain.StockSubscriptionStatus) error
+
+	// FindSubscribedProductIDs retrieves the products with pending subscriptions
+	FindSubscribedProductIDs(ctx context.Context) ([]uint, error)
+
+	// FindLastRestock retrieves when the stock of a product last moved from zero
+	// to positive, nil when it never has
+	FindLastRestock(ctx context.Context, productID uint) (*time.Time, error)
+
+	// CountNotifiedSince counts the subscribers of a product notified since a time
+	CountNotifiedSince(ctx context.Context, productID uint, since time.Time) (int64, error)
+
+	// FindPendingBefore retrieves up to limit pending subscriptions to a product
+	// made before a time, oldest first
+	FindPendingBefore(ctx context.Context, productID uint, before time.Time, limit int) ([]domain.StockSubscription, error)
+
+	// MarkNotified marks a pending subscription as notified
+	MarkNotified(ctx context.Context, id uint, notifiedAt time.Time) error
+
+	// ExpireBefore expires the pending subscriptions whose expiry is before a time,
+	// returning how many expired
+	ExpireBefore(ctx context.Context, before time.Time) (int64, error)
+}
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+
+	"awesomeEcommerce/internal/domain"
+)
+
+// StoreCreditRepository defines the interface for store credit repository operations
+type StoreCreditRepository interface {
+	// FindByUserID retrieves the store credit accounts of a user, one per currency
+	FindByUserID(ctx context.Context, userID uint) ([]domain.StoreCreditAccount, error)
+
+	// FindAccount retrieves the store credit account of a user in a currency
+	FindAccount(ctx context.Context, userID uint, currency string) (*domain.StoreCreditAccount, error)
+
+	// Adjust changes the store credit of a user in the currency of delta,
+	// opening the account on its first credit, and records the change in the
+	// balance ledger. It fails with domain.ErrInsufficientBalance rather than
+	// take the balance below zero.
+	Adjust(ctx context.Context, userID uint, delta domain.Money, change domain.BalanceChange) (*domain.StoreCreditAccount, error)
+
+	// FindLedger retrieves the balance ledger of every store credit account of a user, newest first
+	FindLedger(ctx context.Context, userID uint) ([]domain.BalanceEntry, error)
+}
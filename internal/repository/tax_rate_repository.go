@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+
+	"awesomeEcommerce/internal/domain"
+)
+
+// TaxRateRepository defines the interface for tax rate repository operations
+type TaxRateRepository interface {
+	// FindByID retrieves a tax rate by its ID
+	FindByID(ctx context.Context, id uint) (*domain.TaxRate, error)
+
+	// FindAll retrieves every tax rate, ordered by location and tax class
+	FindAll(ctx context.Context) ([]domain.TaxRate, error)
+
+	// FindByCountry retrieves the tax rates of a country
+	FindByCountry(ctx context.Context, country string) ([]domain.TaxRate, error)
+
+	// Create creates a new tax rate
+	Create(ctx context.Context, rate *domain.TaxRate) error
+
+	// Update updates an existing tax rate
+	Update(ctx context.Context, rate *domain.TaxRate) error
+
+	// Delete deletes a tax rate by its ID
+	Delete(ctx context.Context, id uint) error
+}
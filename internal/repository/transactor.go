@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+)
+
+// Transactor defines the interface for running repository operations as a
+// single unit of work
+type Transactor interface {
+	// WithinTransaction runs fn in a database transaction carried by the
+	// context fn is given, which repositories called with that context take
+	// part in. The transaction commits if fn returns nil and rolls back
+	// otherwise. Calls nested in a transaction join it.
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
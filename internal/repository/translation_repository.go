@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+
+	"awesomeEcommerce/internal/domain"
+)
+
+// TranslationRepository defines the interface for product and category translation repository operations
+type TranslationRepository interface {
+	// FindProductTranslations retrieves the translations of products in any of the given locales
+	FindProductTranslations(ctx context.Context, productIDs []uint, locales []string) ([]domain.ProductTranslation, error)
+
+	// FindProductTranslationsByProductID retrieves every translation of a product, ordered by locale
+	FindProductTranslationsByProductID(ctx context.Context, productID uint) ([]domain.ProductTranslation, error)
+
+	// SaveProductTranslation creates or updates the translation of a product in a locale
+	SaveProductTranslation(ctx context.Context, translation *domain.ProductTranslation) error
+
+	// DeleteProductTranslation deletes the translation of a product in a locale
+	DeleteProductTranslation(ctx context.Context, productID uint, locale string) error
+
+	// FindCategoryTranslations retrieves the translations of categories in any of the given locales
+	FindCategoryTranslations(ctx context.Context, categoryIDs []uint, locales []string) ([]domain.CategoryTranslation, error)
+
+	// FindCategoryTranslationsByCategoryID retrieves every translation of a category, ordered by locale
+	FindCategoryTranslationsByCategoryID(ctx context.Context, categoryID uint) ([]domain.CategoryTranslation, error)
+
+	// SaveCategoryTranslation creates or updates the translation of a category in a locale
+	SaveCategoryTranslation(ctx context.Context, translation *domain.CategoryTranslation) error
+
+	// DeleteCategoryTranslation deletes the translation of a category in a locale
+	DeleteCategoryTranslation(ctx context.Context, categoryID uint, locale string) error
+}
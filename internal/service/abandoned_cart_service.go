@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/notification"
+	"awesomeEcommerce/internal/repository"
+)
+
+// AbandonedCartService defines the interface for abandoned cart detection and recovery business logic
+type AbandonedCartService interface {
+	// SendReminders sends the customers of carts left untouched the reminders
+	// now due, returning how many were sent
+	SendReminders(ctx context.Context) (int, error)
+
+	// Unsubscribe stops the cart reminders of a customer with an unsubscribe token
+	Unsubscribe(ctx context.Context, token string) error
+
+	// RecordRecovery records that an abandoned cart became an order. Carts
+	// that were never reminded are not recorded.
+	RecordRecovery(ctx context.Context, cartID uint, order *domain.Order) error
+
+	// GetAbandonedCarts retrieves abandoned cart records, optionally by status, with pagination
+	GetAbandonedCarts(ctx context.Context, status domain.AbandonedCartStatus, page, pageSize int) ([]domain.AbandonedCart, int64, error)
+
+	// GetStats summarizes how many abandoned carts were recovered
+	GetStats(ctx context.Context) (*domain.AbandonedCartStats, error)
+}
+
+// AbandonedCartServiceImpl implements the AbandonedCartService interface
+type AbandonedCartServiceImpl struct {
+	abandonedRepo repository.AbandonedCartRepository
+	cartRepo      repository.CartRepository
+	userRepo      repository.UserRepository
+	channel       notification.Channel
+	delays        []time.Duration
+	maxIdle       time.Duration
+}
+
+// NewAbandonedCartService creates a new AbandonedCartServiceImpl. Customers
+// are reminded of carts left untouched after each of the delays in turn,
+// until the cart has been left longer than maxIdle.
+func NewAbandonedCartService(
+	abandonedRepo repository.AbandonedCartRepository,
+	cartRepo repository.CartRepository,
+	userRepo repository.UserRepository,
+	channel notification.Channel,
+	delays []time.Duration,
+	maxIdle time.Duration,
+) AbandonedCartService {
+	sorted := append([]time.Duration(nil), delays...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	return &AbandonedCartServiceImpl{
+		abandonedRepo: abandonedRepo,
+		cartRepo:      cartRepo,
+		userRepo:      userRepo,
+		channel:       channel,
+		delays:        sorted,
+		maxIdle:       maxIdle,
+	}
+}
+
+// SendReminders sends the customers of carts left untouched the reminders
+// now due, returning how many were sent. A failing cart does not stop the others.
+func (s *AbandonedCartServiceImpl) SendReminders(ctx context.Context) (int, error) {
+	if len(s.delays) == 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	carts, err := s.cartRepo.FindIdle(ctx, now.Add(-s.maxIdle), now.Add(-s.delays[0]))
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	var errs []error
+	for i := range carts {
+		reminded, err := s.remindCart(ctx, &carts[i], now)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("cart %d: %w", carts[i].ID, err))
+		}
+		if reminded {
+			sent++
+		}
+	}
+
+	return sent, errors.Join(errs...)
+}
+
+// remindCart sends the next reminder of a cart if it is due. A cart is only
+// recorded as abandoned once its first reminder is sent, and a cart touched
+// since its last reminder starts the sequence over.
+func (s *AbandonedCartServiceImpl) remindCart(ctx context.Context, cart *domain.Cart, now time.Time) (bool, error) {
+	abandoned, err := s.abandonedRepo.FindOpenByCartID(ctx, cart.ID)
+	if err != nil {
+		unsubscribed, err := s.abandonedRepo.HasUnsubscribed(ctx, cart.UserID)
+		if err != nil || unsubscribed {
+			return false, err
+		}
+
+		user, err := s.userRepo.FindByID(ctx, cart.UserID)
+		if err != nil {
+			return false, err
+		}
+		token, err := newSubscriptionToken()
+		if err != nil {
+			return false, err
+		}
+		abandoned = &domain.AbandonedCart{
+			CartID:     cart.ID,
+			UserID:     cart.UserID,
+			Email:      user.Email,
+			Token:      token,
+			Status:     domain.AbandonedCartStatusAbandoned,
+			ActivityAt: cart.UpdatedAt,
+		}
+	} else if cart.UpdatedAt.After(abandoned.ActivityAt) {
+		abandoned.ActivityAt = cart.UpdatedAt
+		abandoned.RemindersSent = 0
+		abandoned.LastRemindedAt = nil
+	}
+
+	if !abandoned.ReminderDue(s.delays, now) {
+		return false, nil
+	}
+
+	reminder, err := cartAbandonedNotification(cart, abandoned, len(s.delays))
+	if err != nil {
+		return false, err
+	}
+	if err := s.channel.Send(ctx, reminder); err != nil {
+		return false, err
+	}
+
+	abandoned.RemindersSent++
+	abandoned.LastRemindedAt = &now
+	if abandoned.ID == 0 {
+		return true, s.abandonedRepo.Create(ctx, abandoned)
+	}
+	return true, s.abandonedRepo.Update(ctx, abandoned)
+}
+
+// Unsubscribe stops the cart reminders of a customer with an unsubscribe
+// token. Unsubscribing twice is not an error.
+func (s *AbandonedCartServiceImpl) Unsubscribe(ctx context.Context, token string) error {
+	abandoned, err := s.abandonedRepo.FindByToken(ctx, token)
+	if err != nil {
+		return errors.New("abandoned cart not found")
+	}
+	if abandoned.UnsubscribedAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	abandoned.UnsubscribedAt = &now
+	return s.abandonedRepo.Update(ctx, abandoned)
+}
+
+// RecordRecovery records that an abandoned cart became an order, and how
+// much the order was worth. Carts that were never reminded are not recorded.
+func (s *AbandonedCartServiceImpl) RecordRecovery(ctx context.Context, cartID uint, order *domain.Order) error {
+	abandoned, err := s.abandonedRepo.FindOpenByCartID(ctx, cartID)
+	if err != nil {
+		return nil
+	}
+
+	now := time.Now()
+	abandoned.Status = domain.AbandonedCartStatusRecovered
+	abandoned.OrderID = &order.ID
+	abandoned.RecoveredAt = &now
+	abandoned.RecoveredAmount = order.TotalAmount
+	return s.abandonedRepo.Update(ctx, abandoned)
+}
+
+// GetAbandonedCarts retrieves abandoned cart records, optionally by status, with pagination
+func (s *AbandonedCartServiceImpl) GetAbandonedCarts(ctx context.Context, status domain.AbandonedCartStatus, page, pageSize int) ([]domain.AbandonedCart, int64, error) {
+	switch status {
+	case "", domain.AbandonedCartStatusAbandoned, domain.AbandonedCartStatusRecovered:
+	default:
+		return nil, 0, errors.New("invalid abandoned cart status")
+	}
+	return s.abandonedRepo.FindAll(ctx, status, page, pageSize)
+}
+
+// GetStats summarizes how many abandoned carts were recovered
+func (s *AbandonedCartServiceImpl) GetStats(ctx context.Context) (*domain.AbandonedCartStats, error) {
+	stats, err := s.abandonedRepo.GetStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tracked := stats.Abandoned + stats.Recovered; tracked > 0 {
+		stats.RecoveryRate = float64(stats.Recovered) / float64(tracked)
+	}
+	return stats, nil
+}
+
+// cartAbandonedNotification builds the reminder of a step of the sequence,
+// carrying the contents of the cart priced in the base currency
+func cartAbandonedNotification(cart *domain.Cart, abandoned *domain.AbandonedCart, steps int) (notification.Notification, error) {
+	step := abandoned.RemindersSent + 1
+
+	items := make([]map[string]interface{}, 0, len(cart.Items))
+	var subtotal domain.Money
+	for _, item := range cart.Items {
+		items = append(items, map[string]interface{}{
+			"product_id":   item.ProductID,
+			"product_name": item.Product.Name,
+			"product_sku":  item.Product.SKU,
+			"quantity":     item.Quantity,
+			"price":        item.Product.Price,
+		})
+		var err error
+		if subtotal, err = subtotal.Add(item.Product.Price.Multiply(item.Quantity)); err != nil {
+			return notification.Notification{}, err
+		}
+	}
+
+	return notification.Notification{
+		Type:    "cart-abandoned",
+		Key:     strconv.FormatUint(uint64(cart.ID), 10),
+		Subject: fmt.Sprintf("Cart %d was left untouched (reminder %d of %d)", cart.ID, step, steps),
+		Data: map[string]interface{}{
+			"cart_id":           cart.ID,
+			"user_id":           cart.UserID,
+			"email":             abandoned.Email,
+			"step":              step,
+			"steps":             steps,
+			"items":             items,
+			"subtotal":          subtotal,
+			"coupon_code":       cart.CouponCode,
+			"last_activity_at":  abandoned.ActivityAt,
+			"unsubscribe_token": abandoned.Token,
+		},
+	}, nil
+}
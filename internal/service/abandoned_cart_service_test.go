@@ -0,0 +1,386 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/notification"
+	"awesomeEcommerce/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockAbandonedCartRepository struct {
+	mock.Mock
+}
+
+func (m *MockAbandonedCartRepository) Create(ctx context.Context, abandoned *domain.AbandonedCart) error {
+	args := m.Called(ctx, abandoned)
+	return args.Error(0)
+}
+
+func (m *MockAbandonedCartRepository) Update(ctx context.Context, abandoned *domain.AbandonedCart) error {
+	args := m.Called(ctx, abandoned)
+	return args.Error(0)
+}
+
+func (m *MockAbandonedCartRepository) FindOpenByCartID(ctx context.Context, cartID uint) (*domain.AbandonedCart, error) {
+	args := m.Called(ctx, cartID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.AbandonedCart), args.Error(1)
+}
+
+func (m *MockAbandonedCartRepository) FindByToken(ctx context.Context, token string) (*domain.AbandonedCart, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.AbandonedCart), args.Error(1)
+}
+
+func (m *MockAbandonedCartRepository) HasUnsubscribed(ctx context.Context, userID uint) (bool, error) {
+	args := m.Called(ctx, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAbandonedCartRepository) FindAll(ctx context.Context, status domain.AbandonedCartStatus, page, pageSize int) ([]domain.AbandonedCart, int64, error) {
+	args := m.Called(ctx, status, page, pageSize)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]domain.AbandonedCart), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockAbandonedCartRepository) GetStats(ctx context.Context) (*domain.AbandonedCartStats, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.AbandonedCartStats), args.Error(1)
+}
+
+// reminderDelays is a three-step reminder sequence
+var reminderDelays = []time.Duration{time.Hour, 24 * time.Hour, 72 * time.Hour}
+
+const maxCartIdle = 7 * 24 * time.Hour
+
+// idleCart returns a cart of one product last touched a while ago
+func idleCart(idle time.Duration) domain.Cart {
+	return domain.Cart{
+		ID:        1,
+		UserID:    2,
+		UpdatedAt: time.Now().Add(-idle),
+		Items: []domain.CartItem{
+			{ID: 1, CartID: 1, ProductID: 3, Quantity: 2, Product: domain.Product{ID: 3, Name: "Test Product", SKU: "TP-1", Price: usd(1500)}},
+		},
+	}
+}
+
+func TestSendAbandonedCartReminders(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("First reminder records the cart with its contents", func(t *testing.T) {
+		// Setup
+		mockAbandonedRepo := new(MockAbandonedCartRepository)
+		mockCartRepo := new(MockCartRepository)
+		mockUserRepo := new(MockUserRepository)
+		mockChannel := new(MockChannel)
+		abandonedCartService := service.NewAbandonedCartService(mockAbandonedRepo, mockCartRepo, mockUserRepo, mockChannel, reminderDelays, maxCartIdle)
+
+		cart := idleCart(2 * time.Hour)
+		mockCartRepo.On("FindIdle", ctx, mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).Return([]domain.Cart{cart}, nil)
+		mockAbandonedRepo.On("FindOpenByCartID", ctx, uint(1)).Return(nil, errors.New("record not found"))
+		mockAbandonedRepo.On("HasUnsubscribed", ctx, uint(2)).Return(false, nil)
+		mockUserRepo.On("FindByID", ctx, uint(2)).Return(&domain.User{ID: 2, Email: "customer@example.com"}, nil)
+
+		var sent notification.Notification
+		mockChannel.On("Send", ctx, mock.AnythingOfType("notification.Notification")).Run(func(args mock.Arguments) {
+			sent = args.Get(1).(notification.Notification)
+		}).Return(nil)
+		var created *domain.AbandonedCart
+		mockAbandonedRepo.On("Create", ctx, mock.AnythingOfType("*domain.AbandonedCart")).Run(func(args mock.Arguments) {
+			created = args.Get(1).(*domain.AbandonedCart)
+		}).Return(nil)
+
+		// Execute
+		count, err := abandonedCartService.SendReminders(ctx)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+		assert.Equal(t, "cart-abandoned", sent.Type)
+		assert.Equal(t, 1, sent.Data["step"])
+		assert.Equal(t, 3, sent.Data["steps"])
+		assert.Equal(t, usd(3000), sent.Data["subtotal"])
+		assert.Len(t, sent.Data["items"], 1)
+		require.NotNil(t, created)
+		assert.Equal(t, "customer@example.com", created.Email)
+		assert.Equal(t, 1, created.RemindersSent)
+		assert.Len(t, created.Token, 64)
+		assert.Equal(t, created.Token, sent.Data["unsubscribe_token"])
+		assert.Equal(t, cart.UpdatedAt, created.ActivityAt)
+	})
+
+	t.Run("Next reminder waits for its delay", func(t *testing.T) {
+		// Setup
+		mockAbandonedRepo := new(MockAbandonedCartRepository)
+		mockCartRepo := new(MockCartRepository)
+		mockChannel := new(MockChannel)
+		abandonedCartService := service.NewAbandonedCartService(mockAbandonedRepo, mockCartRepo, new(MockUserRepository), mockChannel, reminderDelays, maxCartIdle)
+
+		cart := idleCart(10 * time.Hour)
+		remindedAt := time.Now().Add(-9 * time.Hour)
+		abandoned := &domain.AbandonedCart{ID: 4, CartID: 1, UserID: 2, Status: domain.AbandonedCartStatusAbandoned, ActivityAt: cart.UpdatedAt, RemindersSent: 1, LastRemindedAt: &remindedAt}
+		mockCartRepo.On("FindIdle", ctx, mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).Return([]domain.Cart{cart}, nil)
+		mockAbandonedRepo.On("FindOpenByCartID", ctx, uint(1)).Return(abandoned, nil)
+
+		// Execute
+		count, err := abandonedCartService.SendReminders(ctx)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 0, count)
+		mockChannel.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Long-idle carts are not sent every reminder at once", func(t *testing.T) {
+		// Setup
+		mockAbandonedRepo := new(MockAbandonedCartRepository)
+		mockCartRepo := new(MockCartRepository)
+		mockChannel := new(MockChannel)
+		abandonedCartService := service.NewAbandonedCartService(mockAbandonedRepo, mockCartRepo, new(MockUserRepository), mockChannel, reminderDelays, maxCartIdle)
+
+		// Idle past the third delay, but reminded for the first time an hour ago
+		cart := idleCart(4 * 24 * time.Hour)
+		remindedAt := time.Now().Add(-time.Hour)
+		abandoned := &domain.AbandonedCart{ID: 4, CartID: 1, UserID: 2, Status: domain.AbandonedCartStatusAbandoned, ActivityAt: cart.UpdatedAt, RemindersSent: 1, LastRemindedAt: &remindedAt}
+		mockCartRepo.On("FindIdle", ctx, mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).Return([]domain.Cart{cart}, nil)
+		mockAbandonedRepo.On("FindOpenByCartID", ctx, uint(1)).Return(abandoned, nil)
+
+		// Execute
+		count, err := abandonedCartService.SendReminders(ctx)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 0, count)
+		mockChannel.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Touched cart starts the sequence over", func(t *testing.T) {
+		// Setup
+		mockAbandonedRepo := new(MockAbandonedCartRepository)
+		mockCartRepo := new(MockCartRepository)
+		mockChannel := new(MockChannel)
+		abandonedCartService := service.NewAbandonedCartService(mockAbandonedRepo, mockCartRepo, new(MockUserRepository), mockChannel, reminderDelays, maxCartIdle)
+
+		cart := idleCart(2 * time.Hour)
+		remindedAt := time.Now().Add(-48 * time.Hour)
+		abandoned := &domain.AbandonedCart{ID: 4, CartID: 1, UserID: 2, Status: domain.AbandonedCartStatusAbandoned, ActivityAt: time.Now().Add(-50 * time.Hour), RemindersSent: 2, LastRemindedAt: &remindedAt}
+		mockCartRepo.On("FindIdle", ctx, mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).Return([]domain.Cart{cart}, nil)
+		mockAbandonedRepo.On("FindOpenByCartID", ctx, uint(1)).Return(abandoned, nil)
+
+		var step interface{}
+		mockChannel.On("Send", ctx, mock.AnythingOfType("notification.Notification")).Run(func(args mock.Arguments) {
+			step = args.Get(1).(notification.Notification).Data["step"]
+		}).Return(nil)
+		mockAbandonedRepo.On("Update", ctx, abandoned).Return(nil)
+
+		// Execute
+		count, err := abandonedCartService.SendReminders(ctx)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+		assert.Equal(t, 1, step)
+		assert.Equal(t, 1, abandoned.RemindersSent)
+		assert.Equal(t, cart.UpdatedAt, abandoned.ActivityAt)
+	})
+
+	t.Run("Unsubscribed customers are not reminded", func(t *testing.T) {
+		// Setup
+		mockAbandonedRepo := new(MockAbandonedCartRepository)
+		mockCartRepo := new(MockCartRepository)
+		mockChannel := new(MockChannel)
+		abandonedCartService := service.NewAbandonedCartService(mockAbandonedRepo, mockCartRepo, new(MockUserRepository), mockChannel, reminderDelays, maxCartIdle)
+
+		mockCartRepo.On("FindIdle", ctx, mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).Return([]domain.Cart{idleCart(2 * time.Hour)}, nil)
+		mockAbandonedRepo.On("FindOpenByCartID", ctx, uint(1)).Return(nil, errors.New("record not found"))
+		mockAbandonedRepo.On("HasUnsubscribed", ctx, uint(2)).Return(true, nil)
+
+		// Execute
+		count, err := abandonedCartService.SendReminders(ctx)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 0, count)
+		mockChannel.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+		mockAbandonedRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Failed reminder is not recorded", func(t *testing.T) {
+		// Setup
+		mockAbandonedRepo := new(MockAbandonedCartRepository)
+		mockCartRepo := new(MockCartRepository)
+		mockUserRepo := new(MockUserRepository)
+		mockChannel := new(MockChannel)
+		abandonedCartService := service.NewAbandonedCartService(mockAbandonedRepo, mockCartRepo, mockUserRepo, mockChannel, reminderDelays, maxCartIdle)
+
+		mockCartRepo.On("FindIdle", ctx, mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).Return([]domain.Cart{idleCart(2 * time.Hour)}, nil)
+		mockAbandonedRepo.On("FindOpenByCartID", ctx, uint(1)).Return(nil, errors.New("record not found"))
+		mockAbandonedRepo.On("HasUnsubscribed", ctx, uint(2)).Return(false, nil)
+		mockUserRepo.On("FindByID", ctx, uint(2)).Return(&domain.User{ID: 2, Email: "customer@example.com"}, nil)
+		mockChannel.On("Send", ctx, mock.AnythingOfType("notification.Notification")).Return(errors.New("broker unavailable"))
+
+		// Execute
+		count, err := abandonedCartService.SendReminders(ctx)
+
+		// Assert
+		assert.ErrorContains(t, err, "broker unavailable")
+		assert.Equal(t, 0, count)
+		mockAbandonedRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("No reminder sequence", func(t *testing.T) {
+		// Setup
+		mockCartRepo := new(MockCartRepository)
+		abandonedCartService := service.NewAbandonedCartService(new(MockAbandonedCartRepository), mockCartRepo, new(MockUserRepository), new(MockChannel), nil, maxCartIdle)
+
+		// Execute
+		count, err := abandonedCartService.SendReminders(ctx)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 0, count)
+		mockCartRepo.AssertNotCalled(t, "FindIdle", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestUnsubscribeCartReminders(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		// Setup
+		mockAbandonedRepo := new(MockAbandonedCartRepository)
+		abandonedCartService := service.NewAbandonedCartService(mockAbandonedRepo, new(MockCartRepository), new(MockUserRepository), new(MockChannel), reminderDelays, maxCartIdle)
+
+		abandoned := &domain.AbandonedCart{ID: 4, Token: "token", Status: domain.AbandonedCartStatusAbandoned}
+		mockAbandonedRepo.On("FindByToken", ctx, "token").Return(abandoned, nil)
+		mockAbandonedRepo.On("Update", ctx, abandoned).Return(nil)
+
+		// Execute
+		err := abandonedCartService.Unsubscribe(ctx, "token")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.NotNil(t, abandoned.UnsubscribedAt)
+		mockAbandonedRepo.AssertExpectations(t)
+	})
+
+	t.Run("Unknown token", func(t *testing.T) {
+		// Setup
+		mockAbandonedRepo := new(MockAbandonedCartRepository)
+		abandonedCartService := service.NewAbandonedCartService(mockAbandonedRepo, new(MockCartRepository), new(MockUserRepository), new(MockChannel), reminderDelays, maxCartIdle)
+
+		mockAbandonedRepo.On("FindByToken", ctx, "unknown").Return(nil, errors.New("record not found"))
+
+		// Execute
+		err := abandonedCartService.Unsubscribe(ctx, "unknown")
+
+		// Assert
+		assert.EqualError(t, err, "abandoned cart not found")
+	})
+}
+
+func TestRecordRecovery(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Reminded cart is recovered", func(t *testing.T) {
+		// Setup
+		mockAbandonedRepo := new(MockAbandonedCartRepository)
+		abandonedCartService := service.NewAbandonedCartService(mockAbandonedRepo, new(MockCartRepository), new(MockUserRepository), new(MockChannel), reminderDelays, maxCartIdle)
+
+		abandoned := &domain.AbandonedCart{ID: 4, CartID: 1, Status: domain.AbandonedCartStatusAbandoned, RemindersSent: 2}
+		mockAbandonedRepo.On("FindOpenByCartID", ctx, uint(1)).Return(abandoned, nil)
+		mockAbandonedRepo.On("Update", ctx, abandoned).Return(nil)
+
+		// Execute
+		err := abandonedCartService.RecordRecovery(ctx, 1, &domain.Order{ID: 9, TotalAmount: usd(3000)})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, domain.AbandonedCartStatusRecovered, abandoned.Status)
+		assert.Equal(t, uintPtr(9), abandoned.OrderID)
+		assert.Equal(t, usd(3000), abandoned.RecoveredAmount)
+		assert.NotNil(t, abandoned.RecoveredAt)
+	})
+
+	t.Run("Cart never reminded", func(t *testing.T) {
+		// Setup
+		mockAbandonedRepo := new(MockAbandonedCartRepository)
+		abandonedCartService := service.NewAbandonedCartService(mockAbandonedRepo, new(MockCartRepository), new(MockUserRepository), new(MockChannel), reminderDelays, maxCartIdle)
+
+		mockAbandonedRepo.On("FindOpenByCartID", ctx, uint(1)).Return(nil, errors.New("record not found"))
+
+		// Execute
+		err := abandonedCartService.RecordRecovery(ctx, 1, &domain.Order{ID: 9})
+
+		// Assert
+		assert.NoError(t, err)
+		mockAbandonedRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Creating an order recovers its cart", func(t *testing.T) {
+		// Setup
+		mockOrderRepo := new(MockOrderRepository)
+		mockCartRepo := new(MockCartRepository)
+		mockProductRepo := new(MockProductRepository)
+		mockUserRepo := new(MockUserRepository)
+		mockAbandonedRepo := new(MockAbandonedCartRepository)
+		abandonedCartService := service.NewAbandonedCartService(mockAbandonedRepo, mockCartRepo, mockUserRepo, new(MockChannel), reminderDelays, maxCartIdle)
+		orderService := service.NewOrderService(mockOrderRepo, mockCartRepo, mockProductRepo, mockUserRepo, nil, service.WithAbandonedCarts(abandonedCartService))
+
+		cart := &domain.Cart{ID: 1, UserID: 2, Items: []domain.CartItem{{ID: 1, CartID: 1, ProductID: 3, Quantity: 2}}}
+		abandoned := &domain.AbandonedCart{ID: 4, CartID: 1, Status: domain.AbandonedCartStatusAbandoned, RemindersSent: 1}
+		mockUserRepo.On("FindByID", ctx, uint(2)).Return(&domain.User{ID: 2}, nil)
+		mockCartRepo.On("FindByUserID", ctx, uint(2)).Return(cart, nil)
+		mockProductRepo.On("FindByID", ctx, uint(3)).Return(&domain.Product{ID: 3, Name: "Test Product", Price: usd(1500), Stock: 10}, nil)
+		mockProductRepo.On("DecrementStock", ctx, []domain.StockLine{{ProductID: 3, Quantity: 2}}, mock.AnythingOfType("domain.StockChange")).Return(nil)
+		mockOrderRepo.On("Create", ctx, mock.AnythingOfType("*domain.Order")).Return(nil)
+		mockCartRepo.On("ClearCart", ctx, uint(1)).Return(nil)
+		mockAbandonedRepo.On("FindOpenByCartID", ctx, uint(1)).Return(abandoned, nil)
+		mockAbandonedRepo.On("Update", ctx, abandoned).Return(nil)
+
+		// Execute
+		order, err := orderService.CreateOrder(ctx, 2, "123 Main St", "123 Main St", 0)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, domain.AbandonedCartStatusRecovered, abandoned.Status)
+		assert.Equal(t, order.TotalAmount, abandoned.RecoveredAmount)
+		mockAbandonedRepo.AssertExpectations(t)
+	})
+}
+
+func TestGetAbandonedCartStats(t *testing.T) {
+	ctx := context.Background()
+
+	// Setup
+	mockAbandonedRepo := new(MockAbandonedCartRepository)
+	abandonedCartService := service.NewAbandonedCartService(mockAbandonedRepo, new(MockCartRepository), new(MockUserRepository), new(MockChannel), reminderDelays, maxCartIdle)
+
+	mockAbandonedRepo.On("GetStats", ctx).Return(&domain.AbandonedCartStats{Abandoned: 6, Recovered: 2, Unsubscribed: 1}, nil)
+
+	// Execute
+	stats, err := abandonedCartService.GetStats(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	assert.InDelta(t, 0.25, stats.RecoveryRate, 0.0001)
+}
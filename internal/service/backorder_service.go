@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+)
+
+// BackorderService defines the interface for backorder and pre-order business logic
+type BackorderService interface {
+	// SplitLine divides quantity units of a product into the units taken from
+	// stock and the units backordered, failing with *domain.InsufficientStockError
+	// when the product's backorder policy refuses the rest
+	SplitLine(ctx context.Context, product *domain.Product, quantity int) (inStock, backordered int, err error)
+
+	// AllocateIncomingStock hands stock that arrived to the oldest backorders
+	// first, returning what each order line received
+	AllocateIncomingStock(ctx context.Context) ([]domain.BackorderAllocation, error)
+
+	// GetOutstandingBackorders retrieves the order lines of a product still waiting for stock
+	GetOutstandingBackorders(ctx context.Context, productID uint) ([]domain.OrderItem, error)
+}
+
+// BackorderServiceImpl implements the BackorderService interface
+type BackorderServiceImpl struct {
+	backorderRepo repository.BackorderRepository
+	orderRepo     repository.OrderRepository
+	reservations  ReservationService
+	inventory     InventoryService
+}
+
+// NewBackorderService creates a new BackorderServiceImpl
+func NewBackorderService(
+	backorderRepo repository.BackorderRepository,
+	orderRepo repository.OrderRepository,
+	reservations ReservationService,
+	inventory InventoryService,
+) BackorderService {
+	return &BackorderServiceImpl{
+		backorderRepo: backorderRepo,
+		orderRepo:     orderRepo,
+		reservations:  reservations,
+		inventory:     inventory,
+	}
+}
+
+// SplitLine divides quantity units of a product into the units taken from
+// stock and the units backordered. Stock already promised to outstanding
+// backorders is not sold again, and pre-order products backorder every unit.
+func (s *BackorderServiceImpl) SplitLine(ctx context.Context, product *domain.Product, quantity int) (int, int, error) {
+	outstanding, err := s.backorderRepo.GetOutstandingQuantity(ctx, product.ID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	available := 0
+	if !product.PreOrder {
+		reserved := 0
+		if s.reservations != nil {
+			if reserved, err = s.reservations.GetReservedStock(ctx, product.ID); err != nil {
+				return 0, 0, err
+			}
+		}
+		available = max(product.Stock-reserved-outstanding, 0)
+	}
+
+	inStock := min(quantity, available)
+	backordered := quantity - inStock
+	if backordered > 0 && !product.CanBackorder(outstanding, backordered) {
+		return 0, 0, &domain.InsufficientStockError{Shortages: []domain.StockShortage{{
+			ProductID:   product.ID,
+			ProductName: product.Name,
+			Requested:   quantity,
+			Available:   available,
+		}}}
+	}
+
+	return inStock, backordered, nil
+}
+
+// AllocateIncomingStock hands stock that arrived to the oldest backorders
+// first, returning what each order line received. Allocated units are also
+// taken from the warehouses that will ship them.
+func (s *BackorderServiceImpl) AllocateIncomingStock(ctx context.Context) ([]domain.BackorderAllocation, error) {
+	productIDs, err := s.backorderRepo.FindBackorderedProductIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var allocations []domain.BackorderAllocation
+	var errs []error
+	for _, productID := range productIDs {
+		productAllocations, err := s.backorderRepo.Allocate(ctx, productID)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if s.inventory != nil {
+			for _, allocation := range productAllocations {
+				if err := s.allocateWarehouseStock(ctx, allocation); err != nil {
+					errs = append(errs, err)
+				}
+			}
+		}
+		allocations = append(allocations, productAllocations...)
+	}
+
+	return allocations, errors.Join(errs...)
+}
+
+// allocateWarehouseStock takes the units allocated to a backorder from the
+// warehouses fulfilling its order
+func (s *BackorderServiceImpl) allocateWarehouseStock(ctx context.Context, allocation domain.BackorderAllocation) error {
+	order, err := s.orderRepo.FindByID(ctx, allocation.OrderID)
+	if err != nil {
+		return err
+	}
+
+	return s.inventory.AllocateOrder(ctx, &domain.Order{
+		ID:              order.ID,
+		ShippingAddress: order.ShippingAddress,
+		Items: []domain.OrderItem{{
+			ProductID: allocation.ProductID,
+			Quantity:  allocation.Quantity,
+		}},
+	})
+}
+
+// GetOutstandingBackorders retrieves the order lines of a product still waiting for stock
+func (s *BackorderServiceImpl) GetOutstandingBackorders(ctx context.Context, productID uint) ([]domain.OrderItem, error) {
+	return s.backorderRepo.FindOutstanding(ctx, productID)
+}
@@ -0,0 +1,264 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockBackorderRepository struct {
+	mock.Mock
+}
+
+func (m *MockBackorderRepository) GetOutstandingQuantity(ctx context.Context, productID uint) (int, error) {
+	args := m.Called(ctx, productID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockBackorderRepository) FindOutstanding(ctx context.Context, productID uint) ([]domain.OrderItem, error) {
+	args := m.Called(ctx, productID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.OrderItem), args.Error(1)
+}
+
+func (m *MockBackorderRepository) FindBackorderedProductIDs(ctx context.Context) ([]uint, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (m *MockBackorderRepository) Allocate(ctx context.Context, productID uint) ([]domain.BackorderAllocation, error) {
+	args := m.Called(ctx, productID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.BackorderAllocation), args.Error(1)
+}
+
+func TestSplitLine(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name            string
+		product         domain.Product
+		outstanding     int
+		quantity        int
+		wantInStock     int
+		wantBackordered int
+		wantErr         bool
+	}{
+		{
+			name:        "In stock",
+			product:     domain.Product{ID: 1, Stock: 10, BackorderPolicy: domain.BackorderPolicyDeny},
+			quantity:    4,
+			wantInStock: 4,
+		},
+		{
+			name:     "Deny policy refuses the rest",
+			product:  domain.Product{ID: 1, Stock: 3, BackorderPolicy: domain.BackorderPolicyDeny},
+			quantity: 4,
+			wantErr:  true,
+		},
+		{
+			name:            "Limited policy within limit",
+			product:         domain.Product{ID: 1, Stock: 3, BackorderPolicy: domain.BackorderPolicyLimited, BackorderLimit: 5},
+			quantity:        6,
+			wantInStock:     3,
+			wantBackordered: 3,
+		},
+		{
+			name:        "Limited policy over limit",
+			product:     domain.Product{ID: 1, Stock: 0, BackorderPolicy: domain.BackorderPolicyLimited, BackorderLimit: 5},
+			outstanding: 4,
+			quantity:    2,
+			wantErr:     true,
+		},
+		{
+			name:            "Stock promised to outstanding backorders is not sold again",
+			product:         domain.Product{ID: 1, Stock: 2, BackorderPolicy: domain.BackorderPolicyUnlimited},
+			outstanding:     2,
+			quantity:        1,
+			wantBackordered: 1,
+		},
+		{
+			name:            "Pre-order backorders every unit",
+			product:         domain.Product{ID: 1, Stock: 5, BackorderPolicy: domain.BackorderPolicyUnlimited, PreOrder: true},
+			quantity:        2,
+			wantBackordered: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockBackorderRepo := new(MockBackorderRepository)
+			backorderService := service.NewBackorderService(mockBackorderRepo, new(MockOrderRepository), nil, nil)
+
+			mockBackorderRepo.On("GetOutstandingQuantity", ctx, tt.product.ID).Return(tt.outstanding, nil)
+
+			// Execute
+			inStock, backordered, err := backorderService.SplitLine(ctx, &tt.product, tt.quantity)
+
+			// Assert
+			if tt.wantErr {
+				var stockErr *domain.InsufficientStockError
+				require.True(t, errors.As(err, &stockErr))
+				assert.Equal(t, tt.quantity, stockErr.Shortages[0].Requested)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantInStock, inStock)
+			assert.Equal(t, tt.wantBackordered, backordered)
+		})
+	}
+}
+
+func TestAllocateIncomingStock(t *testing.T) {
+	// Setup
+	mockBackorderRepo := new(MockBackorderRepository)
+	backorderService := service.NewBackorderService(mockBackorderRepo, new(MockOrderRepository), nil, nil)
+	ctx := context.Background()
+
+	allocations := []domain.BackorderAllocation{
+		{OrderID: 3, OrderItemID: 5, ProductID: 1, Quantity: 2, Remaining: 0},
+		{OrderID: 4, OrderItemID: 7, ProductID: 1, Quantity: 1, Remaining: 2},
+	}
+	mockBackorderRepo.On("FindBackorderedProductIDs", ctx).Return([]uint{1, 2}, nil)
+	mockBackorderRepo.On("Allocate", ctx, uint(1)).Return(allocations, nil)
+	mockBackorderRepo.On("Allocate", ctx, uint(2)).Return(nil, errors.New("database error"))
+
+	// Execute
+	result, err := backorderService.AllocateIncomingStock(ctx)
+
+	// Assert: a failing product does not hold back the others
+	assert.Error(t, err)
+	assert.Equal(t, allocations, result)
+	mockBackorderRepo.AssertExpectations(t)
+}
+
+func TestCreateOrderWithBackorder(t *testing.T) {
+	// Setup
+	mockOrderRepo := new(MockOrderRepository)
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(MockProductRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockBackorderRepo := new(MockBackorderRepository)
+	backorderService := service.NewBackorderService(mockBackorderRepo, mockOrderRepo, nil, nil)
+	orderService := service.NewOrderService(mockOrderRepo, mockCartRepo, mockProductRepo, mockUserRepo, nil, service.WithBackorders(backorderService))
+	ctx := context.Background()
+
+	availableAt := time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)
+	cart := &domain.Cart{
+		ID:     1,
+		UserID: 1,
+		Items: []domain.CartItem{
+			{ProductID: 1, Quantity: 5},
+			{ProductID: 2, Quantity: 1},
+		},
+	}
+	mockUserRepo.On("FindByID", ctx, uint(1)).Return(&domain.User{ID: 1}, nil)
+	mockCartRepo.On("FindByUserID", ctx, uint(1)).Return(cart, nil)
+	mockProductRepo.On("FindByID", ctx, uint(1)).Return(&domain.Product{
+		ID: 1, Name: "Backorderable", Price: usd(1000), Stock: 3, BackorderPolicy: domain.BackorderPolicyUnlimited,
+	}, nil)
+	mockProductRepo.On("FindByID", ctx, uint(2)).Return(&domain.Product{
+		ID: 2, Name: "Upcoming", Price: usd(3000), BackorderPolicy: domain.BackorderPolicyUnlimited, PreOrder: true, AvailableAt: &availableAt,
+	}, nil)
+	mockBackorderRepo.On("GetOutstandingQuantity", ctx, mock.Anything).Return(0, nil)
+	mockOrderRepo.On("Create", ctx, mock.AnythingOfType("*domain.Order")).Return(nil)
+	mockProductRepo.On("DecrementStock", ctx, []domain.StockLine{{ProductID: 1, Quantity: 3}}, mock.AnythingOfType("domain.StockChange")).Return(nil)
+	mockCartRepo.On("ClearCart", ctx, cart.ID).Return(nil)
+
+	// Execute
+	order, err := orderService.CreateOrder(ctx, 1, "123 Main St", "123 Main St", 0)
+
+	// Assert: only the units in stock are taken, the rest wait for stock
+	require.NoError(t, err)
+	require.Len(t, order.Items, 2)
+	assert.Equal(t, 2, order.Items[0].BackorderedQuantity)
+	assert.Nil(t, order.Items[0].ExpectedAt)
+	assert.True(t, order.Items[1].IsBackordered())
+	assert.Equal(t, 1, order.Items[1].BackorderedQuantity)
+	assert.Equal(t, &availableAt, order.Items[1].ExpectedAt)
+	mockProductRepo.AssertExpectations(t)
+}
+
+func TestCancelOrderWithAllocatedBackorder(t *testing.T) {
+	// Setup
+	mockOrderRepo := new(MockOrderRepository)
+	mockProductRepo := new(MockProductRepository)
+	orderService := service.NewOrderService(mockOrderRepo, new(MockCartRepository), mockProductRepo, new(MockUserRepository), nil)
+	ctx := context.Background()
+
+	// Two units came from stock at checkout and one arrived since for the backorder
+	order := &domain.Order{
+		ID:     9,
+		Status: domain.OrderStatusPending,
+		Items: []domain.OrderItem{
+			{ProductID: 1, Quantity: 5, BackorderedQuantity: 3, BackorderAllocated: 1},
+			{ProductID: 2, Quantity: 1, BackorderedQuantity: 1},
+		},
+	}
+	mockOrderRepo.On("FindByID", ctx, order.ID).Return(order, nil)
+	mockOrderRepo.On("UpdateStatus", ctx, order.ID, domain.OrderStatusPending, domain.OrderStatusCancelled, mock.Anything).Return(nil)
+	mockProductRepo.On("UpdateStock", ctx, uint(1), 3, domain.StockChange{Reason: domain.LedgerReasonCancellation, ReferenceID: order.ID, Actor: "system"}).Return(nil)
+
+	// Execute
+	err := orderService.CancelOrder(ctx, order.ID)
+
+	// Assert
+	assert.NoError(t, err)
+	mockProductRepo.AssertExpectations(t)
+	mockProductRepo.AssertNotCalled(t, "UpdateStock", ctx, uint(2), mock.Anything, mock.Anything)
+}
+
+func TestCreateProductBackorderSettings(t *testing.T) {
+	ctx := context.Background()
+	availableAt := time.Now().Add(30 * 24 * time.Hour)
+
+	tests := []struct {
+		name    string
+		product domain.Product
+		wantErr string
+	}{
+		{name: "Defaults to deny", product: domain.Product{Name: "Plain"}},
+		{name: "Limited without limit", product: domain.Product{BackorderPolicy: domain.BackorderPolicyLimited}, wantErr: "backorder limit must be greater than zero"},
+		{name: "Unknown policy", product: domain.Product{BackorderPolicy: "sometimes"}, wantErr: "invalid backorder policy"},
+		{name: "Pre-order without date", product: domain.Product{BackorderPolicy: domain.BackorderPolicyUnlimited, PreOrder: true}, wantErr: "pre-order products need an availability date"},
+		{name: "Pre-order denying backorders", product: domain.Product{PreOrder: true, AvailableAt: &availableAt}, wantErr: "pre-order products need a backorder policy allowing backorders"},
+		{name: "Pre-order", product: domain.Product{BackorderPolicy: domain.BackorderPolicyLimited, BackorderLimit: 100, PreOrder: true, AvailableAt: &availableAt}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockRepo := new(MockProductRepository)
+			productService := service.NewProductService(mockRepo)
+			mockRepo.On("Create", ctx, &tt.product).Return(nil)
+
+			// Execute
+			err := productService.CreateProduct(ctx, &tt.product)
+
+			// Assert
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotEqual(t, domain.BackorderPolicy(""), tt.product.BackorderPolicy)
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+)
+
+// BundleService defines the interface for product bundle business logic
+type BundleService interface {
+	// SetComponents replaces the component products of a bundle
+	SetComponents(ctx context.Context, bundleID uint, components []domain.BundleComponent) ([]domain.BundleComponent, error)
+
+	// GetComponents retrieves the component products of a bundle
+	GetComponents(ctx context.Context, bundleID uint) ([]domain.BundleComponent, error)
+
+	// GetAvailability calculates how many bundles the stock of their components
+	// not held by checkout reservations can make
+	GetAvailability(ctx context.Context, bundleID uint) (int, error)
+}
+
+// BundleServiceImpl implements the BundleService interface
+type BundleServiceImpl struct {
+	bundleRepo   repository.BundleRepository
+	productRepo  repository.ProductRepository
+	reservations ReservationService
+}
+
+// NewBundleService creates a new BundleServiceImpl
+func NewBundleService(
+	bundleRepo repository.BundleRepository,
+	productRepo repository.ProductRepository,
+	reservations ReservationService,
+) BundleService {
+	return &BundleServiceImpl{
+		bundleRepo:   bundleRepo,
+		productRepo:  productRepo,
+		reservations: reservations,
+	}
+}
+
+// SetComponents replaces the component products of a bundle. Components must
+// be distinct physical products, so that bundles never nest.
+func (s *BundleServiceImpl) SetComponents(ctx context.Context, bundleID uint, components []domain.BundleComponent) ([]domain.BundleComponent, error) {
+	bundle, err := s.productRepo.FindByID(ctx, bundleID)
+	if err != nil {
+		return nil, errors.New("product not found")
+	}
+	if !bundle.IsBundle() {
+		return nil, errors.New("product is not a bundle")
+	}
+	if len(components) == 0 {
+		return nil, errors.New("bundle needs at least one component")
+	}
+
+	seen := make(map[uint]bool, len(components))
+	for i, component := range components {
+		if component.Quantity <= 0 {
+			return nil, errors.New("component quantity must be greater than zero")
+		}
+		if component.ProductID == bundleID {
+			return nil, errors.New("a bundle cannot contain itself")
+		}
+		if seen[component.ProductID] {
+			return nil, errors.New("duplicate component product")
+		}
+		seen[component.ProductID] = true
+
+		product, err := s.productRepo.FindByID(ctx, component.ProductID)
+		if err != nil {
+			return nil, errors.New("component product not found")
+		}
+		if product.IsBundle() {
+			return nil, errors.New("a bundle cannot contain another bundle")
+		}
+		if product.IsDigital() {
+			return nil, errors.New("digital products cannot be bundled")
+		}
+		components[i].Product = *product
+	}
+
+	if err := s.bundleRepo.SetComponents(ctx, bundle, components); err != nil {
+		return nil, err
+	}
+	return components, nil
+}
+
+// GetComponents retrieves the component products of a bundle
+func (s *BundleServiceImpl) GetComponents(ctx context.Context, bundleID uint) ([]domain.BundleComponent, error) {
+	return s.bundleRepo.FindComponents(ctx, bundleID)
+}
+
+// GetAvailability calculates how many bundles the stock of their components
+// not held by checkout reservations can make. A bundle without components is
+// unavailable.
+func (s *BundleServiceImpl) GetAvailability(ctx context.Context, bundleID uint) (int, error) {
+	components, err := s.bundleRepo.FindComponents(ctx, bundleID)
+	if err != nil {
+		return 0, err
+	}
+	if len(components) == 0 {
+		return 0, nil
+	}
+
+	available := -1
+	for _, component := range components {
+		stock := component.Product.Stock
+		if s.reservations != nil {
+			reserved, err := s.reservations.GetReservedStock(ctx, component.ProductID)
+			if err != nil {
+				return 0, err
+			}
+			stock -= reserved
+		}
+		if stock < 0 {
+			stock = 0
+		}
+
+		if bundles := stock / component.Quantity; available < 0 || bundles < available {
+			available = bundles
+		}
+	}
+
+	return available, nil
+}
@@ -0,0 +1,261 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockBundleRepository struct {
+	mock.Mock
+}
+
+func (m *MockBundleRepository) FindComponents(ctx context.Context, bundleID uint) ([]domain.BundleComponent, error) {
+	args := m.Called(ctx, bundleID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.BundleComponent), args.Error(1)
+}
+
+func (m *MockBundleRepository) SetComponents(ctx context.Context, bundle *domain.Product, components []domain.BundleComponent) error {
+	args := m.Called(ctx, bundle, components)
+	return args.Error(0)
+}
+
+// giftSet is a bundle of two mugs and a bag of coffee
+func giftSet() (*domain.Product, []domain.BundleComponent) {
+	bundle := &domain.Product{ID: 10, Name: "Gift Set", Price: usd(2500), Type: domain.ProductTypeBundle, BundlePricing: domain.BundlePricingFixed}
+	components := []domain.BundleComponent{
+		{BundleID: 10, ProductID: 1, Quantity: 2, Product: domain.Product{ID: 1, Name: "Mug", Price: usd(800), Stock: 9, Type: domain.ProductTypePhysical}},
+		{BundleID: 10, ProductID: 2, Quantity: 1, Product: domain.Product{ID: 2, Name: "Coffee", Price: usd(1200), Stock: 3, Type: domain.ProductTypePhysical}},
+	}
+	return bundle, components
+}
+
+func TestSetBundleComponents(t *testing.T) {
+	ctx := context.Background()
+	bundle, _ := giftSet()
+	products := map[uint]*domain.Product{
+		1: {ID: 1, Name: "Mug", Type: domain.ProductTypePhysical},
+		2: {ID: 2, Name: "Coffee", Type: domain.ProductTypePhysical},
+		3: {ID: 3, Name: "Other Set", Type: domain.ProductTypeBundle},
+		4: {ID: 4, Name: "Ebook", Type: domain.ProductTypeDigital, DeliveryMethod: domain.DeliveryMethodDownload},
+	}
+
+	tests := []struct {
+		name       string
+		bundle     *domain.Product
+		components []domain.BundleComponent
+		wantErr    string
+	}{
+		{
+			name:       "Not a bundle",
+			bundle:     products[1],
+			components: []domain.BundleComponent{{ProductID: 2, Quantity: 1}},
+			wantErr:    "product is not a bundle",
+		},
+		{
+			name:    "No components",
+			bundle:  bundle,
+			wantErr: "bundle needs at least one component",
+		},
+		{
+			name:       "Zero quantity",
+			bundle:     bundle,
+			components: []domain.BundleComponent{{ProductID: 1, Quantity: 0}},
+			wantErr:    "component quantity must be greater than zero",
+		},
+		{
+			name:       "Contains itself",
+			bundle:     bundle,
+			components: []domain.BundleComponent{{ProductID: 10, Quantity: 1}},
+			wantErr:    "a bundle cannot contain itself",
+		},
+		{
+			name:       "Duplicate component",
+			bundle:     bundle,
+			components: []domain.BundleComponent{{ProductID: 1, Quantity: 1}, {ProductID: 1, Quantity: 2}},
+			wantErr:    "duplicate component product",
+		},
+		{
+			name:       "Unknown component",
+			bundle:     bundle,
+			components: []domain.BundleComponent{{ProductID: 99, Quantity: 1}},
+			wantErr:    "component product not found",
+		},
+		{
+			name:       "Nested bundle",
+			bundle:     bundle,
+			components: []domain.BundleComponent{{ProductID: 3, Quantity: 1}},
+			wantErr:    "a bundle cannot contain another bundle",
+		},
+		{
+			name:       "Digital component",
+			bundle:     bundle,
+			components: []domain.BundleComponent{{ProductID: 4, Quantity: 1}},
+			wantErr:    "digital products cannot be bundled",
+		},
+		{
+			name:       "Success",
+			bundle:     bundle,
+			components: []domain.BundleComponent{{ProductID: 1, Quantity: 2}, {ProductID: 2, Quantity: 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockBundleRepo := new(MockBundleRepository)
+			mockProductRepo := new(MockProductRepository)
+			bundleService := service.NewBundleService(mockBundleRepo, mockProductRepo, nil)
+
+			mockProductRepo.On("FindByID", ctx, tt.bundle.ID).Return(tt.bundle, nil)
+			for id, product := range products {
+				mockProductRepo.On("FindByID", ctx, id).Return(product, nil)
+			}
+			mockProductRepo.On("FindByID", ctx, uint(99)).Return(nil, errors.New("record not found"))
+			mockBundleRepo.On("SetComponents", ctx, tt.bundle, mock.Anything).Return(nil)
+
+			// Execute
+			result, err := bundleService.SetComponents(ctx, tt.bundle.ID, tt.components)
+
+			// Assert
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				mockBundleRepo.AssertNotCalled(t, "SetComponents", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "Mug", result[0].Product.Name)
+			mockBundleRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetBundleAvailability(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Limited by the scarcest component", func(t *testing.T) {
+		// Setup
+		mockBundleRepo := new(MockBundleRepository)
+		bundleService := service.NewBundleService(mockBundleRepo, new(MockProductRepository), nil)
+		_, components := giftSet()
+		mockBundleRepo.On("FindComponents", ctx, uint(10)).Return(components, nil)
+
+		// Execute
+		available, err := bundleService.GetAvailability(ctx, 10)
+
+		// Assert: 9 mugs make 4 sets, 3 bags of coffee make 3
+		assert.NoError(t, err)
+		assert.Equal(t, 3, available)
+	})
+
+	t.Run("Without components", func(t *testing.T) {
+		// Setup
+		mockBundleRepo := new(MockBundleRepository)
+		bundleService := service.NewBundleService(mockBundleRepo, new(MockProductRepository), nil)
+		mockBundleRepo.On("FindComponents", ctx, uint(10)).Return([]domain.BundleComponent{}, nil)
+
+		// Execute
+		available, err := bundleService.GetAvailability(ctx, 10)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 0, available)
+	})
+}
+
+func TestPercentOffPrice(t *testing.T) {
+	_, components := giftSet()
+
+	// Two mugs at 8 and a bag of coffee at 12, 15% off
+	price, err := domain.PercentOffPrice(components, 15)
+	assert.NoError(t, err)
+	assert.Equal(t, usd(2380), price)
+	price, err = domain.PercentOffPrice(components, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, usd(2800), price)
+
+	// Components priced in different currencies cannot be added up
+	components[0].Product.Price.Currency = "EUR"
+	_, err = domain.PercentOffPrice(components, 15)
+	assert.ErrorIs(t, err, domain.ErrCurrencyMismatch)
+}
+
+func TestCreateOrderWithBundle(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		quantity      int
+		wantShortages []domain.StockShortage
+	}{
+		{name: "Components in stock", quantity: 2},
+		{
+			name:          "Component short of stock",
+			quantity:      4,
+			wantShortages: []domain.StockShortage{{ProductID: 2, ProductName: "Coffee", Requested: 4, Available: 3}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockOrderRepo := new(MockOrderRepository)
+			mockCartRepo := new(MockCartRepository)
+			mockProductRepo := new(MockProductRepository)
+			mockUserRepo := new(MockUserRepository)
+			mockBundleRepo := new(MockBundleRepository)
+			bundleService := service.NewBundleService(mockBundleRepo, mockProductRepo, nil)
+			orderService := service.NewOrderService(mockOrderRepo, mockCartRepo, mockProductRepo, mockUserRepo, nil, service.WithBundles(bundleService))
+
+			bundle, components := giftSet()
+			cart := &domain.Cart{ID: 1, UserID: 1, Items: []domain.CartItem{{ProductID: bundle.ID, Quantity: tt.quantity}}}
+			mockUserRepo.On("FindByID", ctx, uint(1)).Return(&domain.User{ID: 1}, nil)
+			mockCartRepo.On("FindByUserID", ctx, uint(1)).Return(cart, nil)
+			mockProductRepo.On("FindByID", ctx, bundle.ID).Return(bundle, nil)
+			mockBundleRepo.On("FindComponents", ctx, bundle.ID).Return(components, nil)
+			mockOrderRepo.On("Create", ctx, mock.AnythingOfType("*domain.Order")).Return(nil)
+			mockProductRepo.On("DecrementStock", ctx, mock.Anything, mock.Anything).Return(nil)
+			mockCartRepo.On("ClearCart", ctx, cart.ID).Return(nil)
+
+			// Execute
+			order, err := orderService.CreateOrder(ctx, 1, "1 Shipping St", "1 Billing St", 0)
+
+			// Assert
+			if tt.wantShortages != nil {
+				var stockErr *domain.InsufficientStockError
+				require.ErrorAs(t, err, &stockErr)
+				assert.Equal(t, tt.wantShortages, stockErr.Shortages)
+				mockOrderRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, order.Items, 3)
+
+			bundleLine := order.Items[0]
+			assert.True(t, bundleLine.IsBundle())
+			assert.Equal(t, usd(2500), bundleLine.Price)
+			assert.Equal(t, 0, bundleLine.InStockQuantity())
+
+			mugs := order.Items[1]
+			assert.True(t, mugs.IsBundleComponent())
+			assert.Equal(t, domain.Money{}, mugs.Price)
+			assert.Equal(t, 4, mugs.Quantity)
+			assert.Equal(t, 2, mugs.BundleQuantity)
+
+			mockProductRepo.AssertCalled(t, "DecrementStock", ctx, []domain.StockLine{
+				{ProductID: 1, Quantity: 4},
+				{ProductID: 2, Quantity: 2},
+			}, mock.Anything)
+		})
+	}
+}
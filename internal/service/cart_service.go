@@ -34,8 +34,33 @@ type CartService interface {
 	// GetCartItems retrieves all items in a cart
 	GetCartItems(ctx context.Context, cartID uint) ([]domain.CartItem, error)
 
-	// GetCartTotal calculates the total price of all items in a cart
-	GetCartTotal(ctx context.Context, cartID uint) (float64, error)
+	// GetCartTotal calculates the total price of all items in a cart, in the
+	// currency the customer shops in, less its promotions and coupon and with
+	// the tax at the destination of the request
+	GetCartTotal(ctx context.Context, cartID uint) (domain.Money, error)
+
+	// GetCartSummary breaks down the price of a cart into its subtotal,
+	// discounts, tax and total, in the currency the customer shops in, warning
+	// of what changed about its items since they were added
+	GetCartSummary(ctx context.Context, cartID uint) (*domain.CartSummary, error)
+
+	// GetCartWarnings reports what changed about the items of a cart since
+	// they were added, with prices in the currency the customer shops in
+	GetCartWarnings(ctx context.Context, cartID uint) ([]domain.CartWarning, error)
+
+	// AcceptPriceChanges accepts the current prices the customer confirmed of
+	// the items in the cart of a user, so it can be ordered at them
+	AcceptPriceChanges(ctx context.Context, userID uint, confirmed map[uint]domain.Money) (*domain.CartSummary, error)
+
+	// GetShippingOptions returns the ways the items of a cart can be shipped
+	// to the destination of the request, and what each costs
+	GetShippingOptions(ctx context.Context, cartID uint) ([]domain.ShippingOption, error)
+
+	// ApplyCoupon applies a coupon code to the cart of a user
+	ApplyCoupon(ctx context.Context, userID uint, code string) (*domain.CartSummary, error)
+
+	// RemoveCoupon removes the coupon code from the cart of a user
+	RemoveCoupon(ctx context.Context, userID uint) error
 }
 
 // CartServiceImpl implements the CartService interface
@@ -43,6 +68,59 @@ type CartServiceImpl struct {
 	cartRepo    repository.CartRepository
 	productRepo repository.ProductRepository
 	userRepo    repository.UserRepository
+
+	reservations ReservationService
+	backorders   BackorderService
+	bundles      BundleService
+	currencies   CurrencyService
+	coupons      CouponService
+	promotions   PromotionService
+	taxes        TaxCalculator
+	shipping     ShippingService
+}
+
+// CartServiceOption sets an optional collaborator of a CartServiceImpl.
+// Carts skip the steps of the collaborators that are not set.
+type CartServiceOption func(*CartServiceImpl)
+
+// WithCartReservations leaves out of carts the stock held by checkout reservations
+func WithCartReservations(reservations ReservationService) CartServiceOption {
+	return func(s *CartServiceImpl) { s.reservations = reservations }
+}
+
+// WithCartBackorders lets carts take products beyond their stock as their policy allows
+func WithCartBackorders(backorders BackorderService) CartServiceOption {
+	return func(s *CartServiceImpl) { s.backorders = backorders }
+}
+
+// WithCartBundles checks bundles in carts against the stock of their components
+func WithCartBundles(bundles BundleService) CartServiceOption {
+	return func(s *CartServiceImpl) { s.bundles = bundles }
+}
+
+// WithCartCurrencies prices carts in the currency of the request
+func WithCartCurrencies(currencies CurrencyService) CartServiceOption {
+	return func(s *CartServiceImpl) { s.currencies = currencies }
+}
+
+// WithCartCoupons applies the coupons of carts to their totals
+func WithCartCoupons(coupons CouponService) CartServiceOption {
+	return func(s *CartServiceImpl) { s.coupons = coupons }
+}
+
+// WithCartPromotions applies the running promotions to cart totals
+func WithCartPromotions(promotions PromotionService) CartServiceOption {
+	return func(s *CartServiceImpl) { s.promotions = promotions }
+}
+
+// WithCartTaxes adds taxes to cart totals
+func WithCartTaxes(taxes TaxCalculator) CartServiceOption {
+	return func(s *CartServiceImpl) { s.taxes = taxes }
+}
+
+// WithCartShipping quotes the shipping options of carts
+func WithCartShipping(shipping ShippingService) CartServiceOption {
+	return func(s *CartServiceImpl) { s.shipping = shipping }
 }
 
 // NewCartService creates a new CartServiceImpl
@@ -50,12 +128,17 @@ func NewCartService(
 	cartRepo repository.CartRepository,
 	productRepo repository.ProductRepository,
 	userRepo repository.UserRepository,
+	opts ...CartServiceOption,
 ) CartService {
-	return &CartServiceImpl{
+	s := &CartServiceImpl{
 		cartRepo:    cartRepo,
 		productRepo: productRepo,
 		userRepo:    userRepo,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // GetCartByID retrieves a cart by its ID
@@ -118,21 +201,26 @@ func (s *CartServiceImpl) AddItemToCart(ctx context.Context, userID, productID u
 		return err
 	}
 
-	// Check if product exists and has enough stock
+	// Check if product exists, is still sold and has enough stock
 	product, err := s.productRepo.FindByID(ctx, productID)
 	if err != nil {
 		return errors.New("product not found")
 	}
+	if product.Archived {
+		return errors.New("product is no longer available")
+	}
 
-	if product.Stock < quantity {
-		return errors.New("insufficient stock")
+	if err := s.checkStock(ctx, product, quantity); err != nil {
+		return err
 	}
 
-	// Add item to cart
+	// Add item to cart at the current price. Adding more of an item already
+	// in the cart keeps the price it was added at.
 	cartItem := &domain.CartItem{
 		CartID:    cart.ID,
 		ProductID: productID,
 		Quantity:  quantity,
+		Price:     product.Price,
 	}
 
 	return s.cartRepo.AddItem(ctx, cartItem)
@@ -168,8 +256,8 @@ func (s *CartServiceImpl) UpdateCartItem(ctx context.Context, cartID, itemID uin
 		return errors.New("product not found")
 	}
 
-	if product.Stock < quantity {
-		return errors.New("insufficient stock")
+	if err := s.checkStock(ctx, product, quantity); err != nil {
+		return err
 	}
 
 	// Update the cart item
@@ -192,7 +280,360 @@ func (s *CartServiceImpl) GetCartItems(ctx context.Context, cartID uint) ([]doma
 	return s.cartRepo.GetCartItems(ctx, cartID)
 }
 
-// GetCartTotal calculates the total price of all items in a cart
-func (s *CartServiceImpl) GetCartTotal(ctx context.Context, cartID uint) (float64, error) {
-	return s.cartRepo.GetCartTotal(ctx, cartID)
+// GetCartTotal calculates the total price of all items in a cart, in the
+// currency the customer shops in, less its promotions and coupon and with the
+// tax at the destination of the request
+func (s *CartServiceImpl) GetCartTotal(ctx context.Context, cartID uint) (domain.Money, error) {
+	if s.currencies == nil && s.coupons == nil && s.promotions == nil && s.taxes == nil {
+		return s.cartRepo.GetCartTotal(ctx, cartID)
+	}
+
+	summary, err := s.GetCartSummary(ctx, cartID)
+	if err != nil {
+		return domain.Money{}, err
+	}
+	return summary.Total, nil
+}
+
+// GetCartSummary breaks down the price of a cart into its subtotal, discounts,
+// tax and total, in the currency the customer shops in. A coupon that no longer
+// applies, say because items were removed, is reported rather than failing,
+// and stays on the cart in case it applies again. So are items whose product
+// was archived, ran short of stock or changed price since it was added.
+func (s *CartServiceImpl) GetCartSummary(ctx context.Context, cartID uint) (*domain.CartSummary, error) {
+	conversion, err := s.conversion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.cartRepo.GetCartItems(ctx, cartID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Add up the converted lines, as the order will
+	lines := pricedCartLines(items, conversion)
+	summary := &domain.CartSummary{
+		Subtotal: domain.Money{Currency: conversion.Currency},
+		Discount: domain.Money{Currency: conversion.Currency},
+	}
+	for _, line := range lines {
+		if summary.Subtotal, err = summary.Subtotal.Add(line.Total); err != nil {
+			return nil, err
+		}
+	}
+	if summary.Warnings, err = s.cartWarnings(ctx, items, conversion); err != nil {
+		return nil, err
+	}
+
+	// Apply the running promotions, then the cart's coupon to what they leave
+	discounts, lines, err := applyPromotions(ctx, s.promotions, lines, conversion)
+	if err != nil {
+		return nil, err
+	}
+	summary.Discounts = discounts
+
+	// Coupons and taxes depend on whose cart it is
+	var cart *domain.Cart
+	if s.coupons != nil || s.taxes != nil {
+		if cart, err = s.cartRepo.FindByID(ctx, cartID); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.coupons != nil && cart.CouponCode != "" {
+		summary.CouponCode = cart.CouponCode
+		_, couponDiscounts, err := s.coupons.Evaluate(ctx, cart.UserID, cart.CouponCode, lines, conversion)
+		var couponErr *domain.CouponError
+		switch {
+		case errors.As(err, &couponErr):
+			summary.CouponError = couponErr.Reason
+		case err != nil:
+			return nil, err
+		default:
+			summary.Discounts = append(summary.Discounts, couponDiscounts...)
+			if lines, err = domain.NetLines(lines, couponDiscounts); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Estimate the tax on what the discounts leave, at the destination of the request
+	if s.taxes != nil {
+		user, err := s.userRepo.FindByID(ctx, cart.UserID)
+		if err != nil {
+			return nil, errors.New("user not found")
+		}
+		taxes, exemptions, err := calculateTax(ctx, s.taxes, user, lines)
+		if err != nil {
+			return nil, err
+		}
+		summary.Taxes = taxes
+		summary.Discounts = append(summary.Discounts, exemptions...)
+	}
+
+	if summary.Promotions, err = domain.AppliedPromotions(summary.Discounts); err != nil {
+		return nil, err
+	}
+	discount, err := domain.DiscountTotal(summary.Discounts)
+	if err != nil {
+		return nil, err
+	}
+	if summary.Discount, err = summary.Discount.Add(discount); err != nil {
+		return nil, err
+	}
+	tax, err := domain.TaxTotal(summary.Taxes)
+	if err != nil {
+		return nil, err
+	}
+	if summary.Tax, err = (domain.Money{Currency: conversion.Currency}).Add(tax); err != nil {
+		return nil, err
+	}
+	summary.TaxMode = taxMode(s.taxes)
+	if summary.Total, err = summary.TaxMode.Total(summary.Subtotal, summary.Discount, summary.Tax, domain.Money{}); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// GetShippingOptions returns the ways the items of a cart can be shipped to
+// the destination of the request, and what each costs. Free shipping
+// thresholds apply to the cart after its discounts.
+func (s *CartServiceImpl) GetShippingOptions(ctx context.Context, cartID uint) ([]domain.ShippingOption, error) {
+	if s.shipping == nil {
+		return nil, nil
+	}
+
+	summary, err := s.GetCartSummary(ctx, cartID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.cartRepo.GetCartItems(ctx, cartID)
+	if err != nil {
+		return nil, err
+	}
+	conversion, err := s.conversion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	subtotal, err := summary.Subtotal.Subtract(summary.Discount)
+	if err != nil {
+		return nil, err
+	}
+	parcel := domain.Parcel{Subtotal: subtotal}
+	for i := range items {
+		parcel = parcel.Add(&items[i].Product, items[i].Quantity)
+	}
+	return s.shipping.Options(ctx, requestDestination(ctx), parcel, conversion)
+}
+
+// ApplyCoupon applies a coupon code to the cart of a user, once the coupon
+// applies to the cart as it is
+func (s *CartServiceImpl) ApplyCoupon(ctx context.Context, userID uint, code string) (*domain.CartSummary, error) {
+	if s.coupons == nil {
+		return nil, errors.New("coupons are not supported")
+	}
+
+	cart, err := s.GetCartByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(cart.Items) == 0 {
+		return nil, errors.New("cart is empty")
+	}
+
+	conversion, err := s.conversion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_, lines, err := applyPromotions(ctx, s.promotions, pricedCartLines(cart.Items, conversion), conversion)
+	if err != nil {
+		return nil, err
+	}
+	coupon, _, err := s.coupons.Evaluate(ctx, userID, code, lines, conversion)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.cartRepo.SetCouponCode(ctx, cart.ID, coupon.Code); err != nil {
+		return nil, err
+	}
+	return s.GetCartSummary(ctx, cart.ID)
+}
+
+// GetCartWarnings reports what changed about the items of a cart since they
+// were added: products that were archived, ran short of stock or changed price
+func (s *CartServiceImpl) GetCartWarnings(ctx context.Context, cartID uint) ([]domain.CartWarning, error) {
+	conversion, err := s.conversion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.cartRepo.GetCartItems(ctx, cartID)
+	if err != nil {
+		return nil, err
+	}
+	return s.cartWarnings(ctx, items, conversion)
+}
+
+// AcceptPriceChanges accepts the current prices of the items in the cart of a
+// user, so it can be ordered at them. Confirmed maps the ID of each item whose
+// price changed to the price the customer was shown, in the currency they shop
+// in. If any price changed again or was not confirmed, none is accepted and a
+// *domain.CartChangedError lists the prices as they are now. Items whose price
+// did not change are left as they are.
+func (s *CartServiceImpl) AcceptPriceChanges(ctx context.Context, userID uint, confirmed map[uint]domain.Money) (*domain.CartSummary, error) {
+	cart, err := s.GetCartByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	conversion, err := s.conversion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var accepted []domain.CartItem
+	var changed []domain.CartWarning
+	for i := range cart.Items {
+		item := &cart.Items[i]
+		if !item.PriceChanged() {
+			continue
+		}
+		if price, ok := confirmed[item.ID]; !ok || price != conversion.Convert(item.Product.Price) {
+			changed = append(changed, domain.PriceWarning(item, conversion))
+			continue
+		}
+		accepted = append(accepted, *item)
+	}
+	if len(changed) > 0 {
+		return nil, &domain.CartChangedError{Warnings: changed}
+	}
+
+	for i := range accepted {
+		accepted[i].Price = accepted[i].Product.Price
+		if err := s.cartRepo.UpdateItem(ctx, &accepted[i]); err != nil {
+			return nil, err
+		}
+	}
+	return s.GetCartSummary(ctx, cart.ID)
+}
+
+// RemoveCoupon removes the coupon code from the cart of a user
+func (s *CartServiceImpl) RemoveCoupon(ctx context.Context, userID uint) error {
+	cart, err := s.GetCartByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return s.cartRepo.SetCouponCode(ctx, cart.ID, "")
+}
+
+// conversion returns the conversion of base prices to the currency the
+// customer shops in. Without currency support prices stay as they are.
+func (s *CartServiceImpl) conversion(ctx context.Context) (domain.Conversion, error) {
+	if s.currencies == nil {
+		return domain.Conversion{Rate: 1}, nil
+	}
+	return s.currencies.GetConversion(ctx)
+}
+
+// cartWarnings reports what changed about the items of a cart since they were added
+func (s *CartServiceImpl) cartWarnings(ctx context.Context, items []domain.CartItem, conversion domain.Conversion) ([]domain.CartWarning, error) {
+	var warnings []domain.CartWarning
+	for i := range items {
+		item := &items[i]
+		if item.Product.Archived {
+			warnings = append(warnings, domain.ArchivedWarning(item))
+			continue
+		}
+
+		err := s.checkStock(ctx, &item.Product, item.Quantity)
+		var stockErr *domain.InsufficientStockError
+		switch {
+		case errors.As(err, &stockErr):
+			warnings = appendStockWarnings(warnings, item, stockErr.Shortages)
+		case err != nil:
+			return nil, err
+		}
+
+		if item.PriceChanged() {
+			warnings = append(warnings, domain.PriceWarning(item, conversion))
+		}
+	}
+	return warnings, nil
+}
+
+// pricedCartLines prices the items of a cart in the currency the customer shops in
+func pricedCartLines(items []domain.CartItem, conversion domain.Conversion) []domain.PricedLine {
+	lines := make([]domain.PricedLine, 0, len(items))
+	for _, item := range items {
+		lines = append(lines, domain.PricedLine{
+			ProductID:  item.ProductID,
+			CategoryID: item.Product.CategoryID,
+			TaxClass:   item.Product.TaxClass,
+			Quantity:   item.Quantity,
+			Total:      conversion.LineTotal(item.Product.Price, item.Quantity),
+		})
+	}
+	return lines
+}
+
+// checkStock checks that quantity units of a product can be ordered, either
+// from stock or as backorders the product's policy allows. Downloads are
+// never out of stock, and bundles are as available as their components.
+// Shortages are returned as an InsufficientStockError.
+func (s *CartServiceImpl) checkStock(ctx context.Context, product *domain.Product, quantity int) error {
+	if product.IsBundle() && s.bundles != nil {
+		components, err := s.bundles.GetComponents(ctx, product.ID)
+		if err != nil {
+			return err
+		}
+		if len(components) == 0 {
+			return shortageError(product, quantity, 0)
+		}
+		for _, component := range components {
+			if err := s.checkStock(ctx, &component.Product, quantity*component.Quantity); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if !product.TracksStock() {
+		return nil
+	}
+	if s.backorders != nil {
+		_, _, err := s.backorders.SplitLine(ctx, product, quantity)
+		return err
+	}
+
+	available, err := s.availableStock(ctx, product)
+	if err != nil {
+		return err
+	}
+	if available < quantity {
+		return shortageError(product, quantity, max(available, 0))
+	}
+	return nil
+}
+
+// shortageError reports that quantity units of a product were asked for when
+// only available can be ordered
+func shortageError(product *domain.Product, quantity, available int) error {
+	return &domain.InsufficientStockError{Shortages: []domain.StockShortage{{
+		ProductID:   product.ID,
+		ProductName: product.Name,
+		Requested:   quantity,
+		Available:   available,
+	}}}
+}
+
+// availableStock returns the stock of a product not held by checkout reservations
+func (s *CartServiceImpl) availableStock(ctx context.Context, product *domain.Product) (int, error) {
+	if s.reservations == nil {
+		return product.Stock, nil
+	}
+
+	reserved, err := s.reservations.GetReservedStock(ctx, product.ID)
+	if err != nil {
+		return 0, err
+	}
+	return product.Stock - reserved, nil
 }
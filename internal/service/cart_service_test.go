@@ -3,6 +3,7 @@ package service_test
 import (
 	"context"
 	"errors"
+	"slices"
 	"testing"
 
 	"awesomeEcommerce/internal/domain"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGetCartByID(t *testing.T) {
@@ -338,7 +340,7 @@ func TestAddItemToCart(t *testing.T) {
 		product := &domain.Product{
 			ID:    productID,
 			Name:  "Test Product",
-			Price: 10.99,
+			Price: usd(1099),
 			Stock: 10,
 		}
 
@@ -346,7 +348,9 @@ func TestAddItemToCart(t *testing.T) {
 		mockUserRepo.On("FindByID", ctx, userID).Return(user, nil).Once()
 		mockCartRepo.On("FindByUserID", ctx, userID).Return(cart, nil).Once()
 		mockProductRepo.On("FindByID", ctx, productID).Return(product, nil).Once()
-		mockCartRepo.On("AddItem", ctx, mock.AnythingOfType("*domain.CartItem")).Return(nil).Once()
+		mockCartRepo.On("AddItem", ctx, mock.MatchedBy(func(item *domain.CartItem) bool {
+			return item.Quantity == quantity && item.Price == product.Price
+		})).Return(nil).Once()
 
 		// Execute
 		err := cartService.AddItemToCart(ctx, userID, productID, quantity)
@@ -454,7 +458,7 @@ func TestAddItemToCart(t *testing.T) {
 		product := &domain.Product{
 			ID:    productID,
 			Name:  "Test Product",
-			Price: 10.99,
+			Price: usd(1099),
 			Stock: 2, // Less than requested quantity
 		}
 
@@ -495,7 +499,7 @@ func TestAddItemToCart(t *testing.T) {
 		product := &domain.Product{
 			ID:    productID,
 			Name:  "Test Product",
-			Price: 10.99,
+			Price: usd(1099),
 			Stock: 10,
 		}
 
@@ -541,7 +545,7 @@ func TestUpdateCartItem(t *testing.T) {
 		product := &domain.Product{
 			ID:    uint(3),
 			Name:  "Test Product",
-			Price: 10.99,
+			Price: usd(1099),
 			Stock: 10,
 		}
 
@@ -662,7 +666,7 @@ func TestUpdateCartItem(t *testing.T) {
 		product := &domain.Product{
 			ID:    uint(3),
 			Name:  "Test Product",
-			Price: 10.99,
+			Price: usd(1099),
 			Stock: 3, // Less than requested quantity
 		}
 
@@ -721,7 +725,7 @@ func TestUpdateCartItem(t *testing.T) {
 		product := &domain.Product{
 			ID:    uint(3),
 			Name:  "Test Product",
-			Price: 10.99,
+			Price: usd(1099),
 			Stock: 10,
 		}
 
@@ -894,7 +898,7 @@ func TestGetCartTotal(t *testing.T) {
 
 	t.Run("Success", func(t *testing.T) {
 		// Test data
-		expectedTotal := 99.99
+		expectedTotal := usd(9999)
 
 		// Expectations
 		mockCartRepo.On("GetCartTotal", ctx, cartID).Return(expectedTotal, nil).Once()
@@ -916,15 +920,153 @@ func TestGetCartTotal(t *testing.T) {
 		cartService := service.NewCartService(mockCartRepo, mockProductRepo, mockUserRepo)
 
 		// Expectations
-		mockCartRepo.On("GetCartTotal", ctx, cartID).Return(0.0, errors.New("database error")).Once()
+		mockCartRepo.On("GetCartTotal", ctx, cartID).Return(domain.Money{}, errors.New("database error")).Once()
 
 		// Execute
 		total, err := cartService.GetCartTotal(ctx, cartID)
 
 		// Assert
 		assert.Error(t, err)
-		assert.Equal(t, 0.0, total)
+		assert.Equal(t, domain.Money{}, total)
 		assert.Contains(t, err.Error(), "database error")
 		mockCartRepo.AssertExpectations(t)
 	})
 }
+
+func TestGetCartWarnings(t *testing.T) {
+	ctx := context.Background()
+	cartID := uint(1)
+
+	t.Run("Changed Items", func(t *testing.T) {
+		mockCartRepo := new(MockCartRepository)
+		cartService := service.NewCartService(mockCartRepo, new(MockProductRepository), new(MockUserRepository))
+
+		items := []domain.CartItem{
+			{ID: 1, ProductID: 1, Quantity: 1, Price: usd(1000), Product: domain.Product{ID: 1, Name: "Dearer", Price: usd(1200), Stock: 5}},
+			{ID: 2, ProductID: 2, Quantity: 1, Price: usd(1000), Product: domain.Product{ID: 2, Name: "Cheaper", Price: usd(800), Stock: 5}},
+			{ID: 3, ProductID: 3, Quantity: 4, Price: usd(500), Product: domain.Product{ID: 3, Name: "Scarce", Price: usd(500), Stock: 3}},
+			{ID: 4, ProductID: 4, Quantity: 1, Price: usd(500), Product: domain.Product{ID: 4, Name: "Retired", Price: usd(900), Stock: 5, Archived: true}},
+		}
+		mockCartRepo.On("GetCartItems", ctx, cartID).Return(items, nil).Once()
+
+		warnings, err := cartService.GetCartWarnings(ctx, cartID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []domain.CartWarning{
+			{Type: domain.CartWarningPriceIncreased, CartItemID: 1, ProductID: 1, ProductName: "Dearer", PreviousPrice: usd(1000), CurrentPrice: usd(1200)},
+			{Type: domain.CartWarningPriceDecreased, CartItemID: 2, ProductID: 2, ProductName: "Cheaper", PreviousPrice: usd(1000), CurrentPrice: usd(800)},
+			{Type: domain.CartWarningInsufficientStock, CartItemID: 3, ProductID: 3, ProductName: "Scarce", Requested: 4, Available: 3},
+			{Type: domain.CartWarningProductArchived, CartItemID: 4, ProductID: 4, ProductName: "Retired"},
+		}, warnings)
+		mockCartRepo.AssertExpectations(t)
+	})
+
+	t.Run("Unknown Price", func(t *testing.T) {
+		mockCartRepo := new(MockCartRepository)
+		cartService := service.NewCartService(mockCartRepo, new(MockProductRepository), new(MockUserRepository))
+
+		// Items added before prices were recorded have nothing to compare with
+		items := []domain.CartItem{
+			{ID: 1, ProductID: 1, Quantity: 1, Product: domain.Product{ID: 1, Name: "Test Product", Price: usd(1200), Stock: 5}},
+		}
+		mockCartRepo.On("GetCartItems", ctx, cartID).Return(items, nil).Once()
+
+		warnings, err := cartService.GetCartWarnings(ctx, cartID)
+
+		assert.NoError(t, err)
+		assert.Empty(t, warnings)
+		mockCartRepo.AssertExpectations(t)
+	})
+}
+
+func TestAcceptPriceChanges(t *testing.T) {
+	ctx := context.Background()
+	userID := uint(1)
+
+	mockCartRepo := new(MockCartRepository)
+	mockUserRepo := new(MockUserRepository)
+	cartService := service.NewCartService(mockCartRepo, new(MockProductRepository), mockUserRepo)
+
+	items := []domain.CartItem{
+		{ID: 1, CartID: 1, ProductID: 1, Quantity: 1, Price: usd(1000), Product: domain.Product{ID: 1, Name: "Dearer", Price: usd(1200), Stock: 5}},
+		{ID: 2, CartID: 1, ProductID: 2, Quantity: 1, Price: usd(700), Product: domain.Product{ID: 2, Name: "Unchanged", Price: usd(700), Stock: 5}},
+	}
+	cart := &domain.Cart{ID: 1, UserID: userID, Items: items}
+	accepted := []domain.CartItem{items[0], items[1]}
+	accepted[0].Price = usd(1200)
+
+	mockUserRepo.On("FindByID", ctx, userID).Return(&domain.User{ID: userID}, nil).Once()
+	mockCartRepo.On("FindByUserID", ctx, userID).Return(cart, nil).Once()
+	mockCartRepo.On("UpdateItem", ctx, mock.MatchedBy(func(item *domain.CartItem) bool {
+		return item.ID == 1 && item.Price == usd(1200)
+	})).Return(nil).Once()
+	mockCartRepo.On("GetCartItems", ctx, cart.ID).Return(accepted, nil).Once()
+
+	summary, err := cartService.AcceptPriceChanges(ctx, userID, map[uint]domain.Money{1: usd(1200)})
+
+	assert.NoError(t, err)
+	assert.Empty(t, summary.Warnings)
+	assert.Equal(t, usd(1900), summary.Total)
+	mockUserRepo.AssertExpectations(t)
+	mockCartRepo.AssertExpectations(t)
+}
+
+func TestAcceptPriceChangesNotConfirmed(t *testing.T) {
+	ctx := context.Background()
+	userID := uint(1)
+
+	items := []domain.CartItem{
+		{ID: 1, CartID: 1, ProductID: 1, Quantity: 1, Price: usd(1000), Product: domain.Product{ID: 1, Name: "Dearer", Price: usd(1300), Stock: 5}},
+		{ID: 2, CartID: 1, ProductID: 2, Quantity: 1, Price: usd(700), Product: domain.Product{ID: 2, Name: "Cheaper", Price: usd(600), Stock: 5}},
+	}
+
+	tests := []struct {
+		name      string
+		confirmed map[uint]domain.Money
+	}{
+		{name: "Price changed again", confirmed: map[uint]domain.Money{1: usd(1200), 2: usd(600)}},
+		{name: "Price not confirmed", confirmed: map[uint]domain.Money{2: usd(600)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCartRepo := new(MockCartRepository)
+			mockUserRepo := new(MockUserRepository)
+			cartService := service.NewCartService(mockCartRepo, new(MockProductRepository), mockUserRepo)
+
+			mockUserRepo.On("FindByID", ctx, userID).Return(&domain.User{ID: userID}, nil).Once()
+			mockCartRepo.On("FindByUserID", ctx, userID).Return(&domain.Cart{ID: 1, UserID: userID, Items: slices.Clone(items)}, nil).Once()
+
+			summary, err := cartService.AcceptPriceChanges(ctx, userID, tt.confirmed)
+
+			// No price is accepted, and the customer sees the price as it is now
+			assert.Nil(t, summary)
+			var changedErr *domain.CartChangedError
+			require.ErrorAs(t, err, &changedErr)
+			require.Len(t, changedErr.Warnings, 1)
+			assert.Equal(t, uint(1), changedErr.Warnings[0].CartItemID)
+			assert.Equal(t, usd(1300), changedErr.Warnings[0].CurrentPrice)
+			mockCartRepo.AssertNotCalled(t, "UpdateItem", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestAddArchivedProductToCart(t *testing.T) {
+	ctx := context.Background()
+	userID := uint(1)
+	productID := uint(2)
+
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(MockProductRepository)
+	mockUserRepo := new(MockUserRepository)
+	cartService := service.NewCartService(mockCartRepo, mockProductRepo, mockUserRepo)
+
+	mockUserRepo.On("FindByID", ctx, userID).Return(&domain.User{ID: userID}, nil).Once()
+	mockCartRepo.On("FindByUserID", ctx, userID).Return(&domain.Cart{ID: 1, UserID: userID}, nil).Once()
+	mockProductRepo.On("FindByID", ctx, productID).Return(&domain.Product{ID: productID, Price: usd(1000), Stock: 5, Archived: true}, nil).Once()
+
+	err := cartService.AddItemToCart(ctx, userID, productID, 1)
+
+	assert.EqualError(t, err, "product is no longer available")
+	mockCartRepo.AssertNotCalled(t, "AddItem")
+}
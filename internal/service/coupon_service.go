@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+)
+
+// CouponService defines the interface for coupon business logic
+type CouponService interface {
+	// GetCoupons retrieves all coupons with optional pagination
+	GetCoupons(ctx context.Context, page, pageSize int) ([]domain.Coupon, int64, error)
+
+	// GetCouponByID retrieves a coupon by its ID
+	GetCouponByID(ctx context.Context, id uint) (*domain.Coupon, error)
+
+	// CreateCoupon creates a new coupon
+	CreateCoupon(ctx context.Context, coupon *domain.Coupon) error
+
+	// UpdateCoupon updates an existing coupon
+	UpdateCoupon(ctx context.Context, coupon *domain.Coupon) error
+
+	// DeleteCoupon deletes a coupon
+	DeleteCoupon(ctx context.Context, id uint) error
+
+	// Evaluate checks that a user can apply a coupon code to priced lines and
+	// computes its discount lines, failing with *domain.CouponError when the
+	// coupon cannot be applied
+	Evaluate(ctx context.Context, userID uint, code string, lines []domain.PricedLine, conversion domain.Conversion) (*domain.Coupon, []domain.OrderDiscount, error)
+}
+
+// CouponServiceImpl implements the CouponService interface
+type CouponServiceImpl struct {
+	couponRepo repository.CouponRepository
+}
+
+// NewCouponService creates a new CouponServiceImpl
+func NewCouponService(couponRepo repository.CouponRepository) CouponService {
+	return &CouponServiceImpl{
+		couponRepo: couponRepo,
+	}
+}
+
+// GetCoupons retrieves all coupons with optional pagination
+func (s *CouponServiceImpl) GetCoupons(ctx context.Context, page, pageSize int) ([]domain.Coupon, int64, error) {
+	return s.couponRepo.FindAll(ctx, page, pageSize)
+}
+
+// GetCouponByID retrieves a coupon by its ID
+func (s *CouponServiceImpl) GetCouponByID(ctx context.Context, id uint) (*domain.Coupon, error) {
+	return s.couponRepo.FindByID(ctx, id)
+}
+
+// CreateCoupon creates a new coupon
+func (s *CouponServiceImpl) CreateCoupon(ctx context.Context, coupon *domain.Coupon) error {
+	if err := validateCoupon(coupon); err != nil {
+		return err
+	}
+
+	// Check if the code is taken
+	if _, err := s.couponRepo.FindByCode(ctx, coupon.Code); err == nil {
+		return errors.New("coupon code already exists")
+	}
+
+	return s.couponRepo.Create(ctx, coupon)
+}
+
+// UpdateCoupon updates an existing coupon
+func (s *CouponServiceImpl) UpdateCoupon(ctx context.Context, coupon *domain.Coupon) error {
+	if err := validateCoupon(coupon); err != nil {
+		return err
+	}
+
+	// Check if the code is taken by another coupon
+	if existing, err := s.couponRepo.FindByCode(ctx, coupon.Code); err == nil && existing.ID != coupon.ID {
+		return errors.New("coupon code already exists")
+	}
+
+	return s.couponRepo.Update(ctx, coupon)
+}
+
+// DeleteCoupon deletes a coupon
+func (s *CouponServiceImpl) DeleteCoupon(ctx context.Context, id uint) error {
+	if _, err := s.couponRepo.FindByID(ctx, id); err != nil {
+		return errors.New("coupon not found")
+	}
+	return s.couponRepo.Delete(ctx, id)
+}
+
+// Evaluate checks that a user can apply a coupon code to priced lines and
+// computes its discount lines. Usage limits checked here may be reached by
+// the time the order is placed, which counts the use under a lock. Uses by
+// cancelled orders do not count.
+func (s *CouponServiceImpl) Evaluate(ctx context.Context, userID uint, code string, lines []domain.PricedLine, conversion domain.Conversion) (*domain.Coupon, []domain.OrderDiscount, error) {
+	code = domain.NormalizeCouponCode(code)
+	coupon, err := s.couponRepo.FindByCode(ctx, code)
+	if err != nil {
+		return nil, nil, &domain.CouponError{Code: code, Reason: "coupon not found"}
+	}
+
+	if err := coupon.CheckValidity(time.Now()); err != nil {
+		return nil, nil, err
+	}
+
+	if coupon.PerUserLimit > 0 {
+		used, err := s.couponRepo.CountRedemptionsByUser(ctx, coupon.ID, userID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if used >= int64(coupon.PerUserLimit) {
+			return nil, nil, &domain.CouponError{Code: code, Reason: "coupon already used the maximum number of times"}
+		}
+	}
+
+	discounts, err := coupon.Discounts(lines, conversion)
+	if err != nil {
+		return nil, nil, err
+	}
+	return coupon, discounts, nil
+}
+
+// validateCoupon normalizes the code of a coupon and checks its settings
+func validateCoupon(coupon *domain.Coupon) error {
+	coupon.Code = domain.NormalizeCouponCode(coupon.Code)
+	if coupon.Code == "" {
+		return errors.New("coupon code is required")
+	}
+	if len(coupon.Code) > 64 {
+		return errors.New("coupon code is too long")
+	}
+
+	switch coupon.Type {
+	case domain.CouponTypePercent:
+		if coupon.PercentOff <= 0 || coupon.PercentOff > 100 {
+			return errors.New("percent off must be between 0 and 100")
+		}
+	case domain.CouponTypeFixed:
+		if !coupon.AmountOff.IsPositive() {
+			return errors.New("amount off must be greater than zero")
+		}
+	default:
+		return errors.New("invalid coupon type")
+	}
+
+	if coupon.MinSubtotal.Amount < 0 {
+		return errors.New("minimum subtotal cannot be negative")
+	}
+	if coupon.UsageLimit < 0 || coupon.PerUserLimit < 0 {
+		return errors.New("usage limits cannot be negative")
+	}
+	if coupon.StartsAt != nil && coupon.EndsAt != nil && !coupon.EndsAt.After(*coupon.StartsAt) {
+		return errors.New("coupon must end after it starts")
+	}
+	return nil
+}
@@ -0,0 +1,375 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockCouponRepository struct {
+	mock.Mock
+}
+
+func (m *MockCouponRepository) FindByID(ctx context.Context, id uint) (*domain.Coupon, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Coupon), args.Error(1)
+}
+
+func (m *MockCouponRepository) FindByCode(ctx context.Context, code string) (*domain.Coupon, error) {
+	args := m.Called(ctx, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Coupon), args.Error(1)
+}
+
+func (m *MockCouponRepository) FindAll(ctx context.Context, page, pageSize int) ([]domain.Coupon, int64, error) {
+	args := m.Called(ctx, page, pageSize)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]domain.Coupon), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockCouponRepository) Create(ctx context.Context, coupon *domain.Coupon) error {
+	args := m.Called(ctx, coupon)
+	return args.Error(0)
+}
+
+func (m *MockCouponRepository) Update(ctx context.Context, coupon *domain.Coupon) error {
+	args := m.Called(ctx, coupon)
+	return args.Error(0)
+}
+
+func (m *MockCouponRepository) Delete(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockCouponRepository) CountRedemptionsByUser(ctx context.Context, couponID, userID uint) (int64, error) {
+	args := m.Called(ctx, couponID, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// springSale is a coupon taking 10% off every line
+func springSale() *domain.Coupon {
+	return &domain.Coupon{ID: 1, Code: "SPRING10", Type: domain.CouponTypePercent, PercentOff: 10, Active: true}
+}
+
+func TestCouponDiscounts(t *testing.T) {
+	// A mug and a teapot in category 10, a bag of coffee in category 20
+	lines := []domain.PricedLine{
+		{ProductID: 1, CategoryID: 10, Total: usd(2000)},
+		{ProductID: 2, CategoryID: 20, Total: usd(1000)},
+		{ProductID: 3, CategoryID: 10, Total: usd(333)},
+	}
+
+	tests := []struct {
+		name       string
+		coupon     domain.Coupon
+		conversion domain.Conversion
+		lines      []domain.PricedLine
+		want       map[uint]domain.Money
+		wantErr    string
+	}{
+		{
+			name:   "Percent off every line",
+			coupon: domain.Coupon{Type: domain.CouponTypePercent, PercentOff: 15},
+			want:   map[uint]domain.Money{1: usd(300), 2: usd(150), 3: usd(50)},
+		},
+		{
+			name:   "Percent off a category",
+			coupon: domain.Coupon{Type: domain.CouponTypePercent, PercentOff: 10, CategoryIDs: []uint{10}},
+			want:   map[uint]domain.Money{1: usd(200), 3: usd(33)},
+		},
+		{
+			name:   "Fixed amount spread across lines",
+			coupon: domain.Coupon{Type: domain.CouponTypeFixed, AmountOff: usd(1000)},
+			want:   map[uint]domain.Money{1: usd(600), 2: usd(300), 3: usd(100)},
+		},
+		{
+			name:   "Fixed amount capped at the eligible lines",
+			coupon: domain.Coupon{Type: domain.CouponTypeFixed, AmountOff: usd(5000), ProductIDs: []uint{2}},
+			want:   map[uint]domain.Money{2: usd(1000)},
+		},
+		{
+			name:       "Fixed amount converted",
+			coupon:     domain.Coupon{Type: domain.CouponTypeFixed, AmountOff: usd(1000), ProductIDs: []uint{1}},
+			conversion: domain.Conversion{Currency: "EUR", Rate: 0.5},
+			lines:      []domain.PricedLine{{ProductID: 1, Total: domain.Money{Amount: 1000, Currency: "EUR"}}},
+			want:       map[uint]domain.Money{1: {Amount: 500, Currency: "EUR"}},
+		},
+		{
+			name:    "Below the minimum subtotal",
+			coupon:  domain.Coupon{Type: domain.CouponTypePercent, PercentOff: 10, MinSubtotal: usd(5000)},
+			wantErr: "cart subtotal is below the coupon minimum",
+		},
+		{
+			name:    "No eligible lines",
+			coupon:  domain.Coupon{Type: domain.CouponTypePercent, PercentOff: 10, ProductIDs: []uint{99}},
+			wantErr: "no items in the cart are eligible for the coupon",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.lines == nil {
+				tt.lines = lines
+			}
+			if tt.conversion.Rate == 0 {
+				tt.conversion.Rate = 1
+			}
+
+			discounts, err := tt.coupon.Discounts(tt.lines, tt.conversion)
+			if tt.wantErr != "" {
+				var couponErr *domain.CouponError
+				require.ErrorAs(t, err, &couponErr)
+				assert.Equal(t, tt.wantErr, couponErr.Reason)
+				return
+			}
+
+			require.NoError(t, err)
+			got := make(map[uint]domain.Money, len(discounts))
+			for _, discount := range discounts {
+				assert.Equal(t, domain.DiscountSourceCoupon, discount.Source)
+				got[discount.ProductID] = discount.Amount
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEvaluateCoupon(t *testing.T) {
+	ctx := context.Background()
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+	lines := []domain.PricedLine{{ProductID: 1, Total: usd(2000)}}
+
+	tests := []struct {
+		name    string
+		coupon  *domain.Coupon
+		used    int64
+		wantErr string
+	}{
+		{name: "Unknown code", wantErr: "coupon not found"},
+		{name: "Inactive", coupon: &domain.Coupon{ID: 1, Code: "SPRING10", Type: domain.CouponTypePercent, PercentOff: 10}, wantErr: "coupon is not active"},
+		{name: "Not started", coupon: &domain.Coupon{ID: 1, Code: "SPRING10", Type: domain.CouponTypePercent, PercentOff: 10, Active: true, StartsAt: &future}, wantErr: "coupon is not valid yet"},
+		{name: "Expired", coupon: &domain.Coupon{ID: 1, Code: "SPRING10", Type: domain.CouponTypePercent, PercentOff: 10, Active: true, EndsAt: &past}, wantErr: "coupon has expired"},
+		{name: "Used up", coupon: &domain.Coupon{ID: 1, Code: "SPRING10", Type: domain.CouponTypePercent, PercentOff: 10, Active: true, UsageLimit: 100, UsedCount: 100}, wantErr: "coupon usage limit reached"},
+		{name: "Used up by the user", coupon: &domain.Coupon{ID: 1, Code: "SPRING10", Type: domain.CouponTypePercent, PercentOff: 10, Active: true, PerUserLimit: 1}, used: 1, wantErr: "coupon already used the maximum number of times"},
+		{name: "Success", coupon: &domain.Coupon{ID: 1, Code: "SPRING10", Type: domain.CouponTypePercent, PercentOff: 10, Active: true, PerUserLimit: 2, StartsAt: &past, EndsAt: &future}, used: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockCouponRepo := new(MockCouponRepository)
+			couponService := service.NewCouponService(mockCouponRepo)
+			if tt.coupon != nil {
+				mockCouponRepo.On("FindByCode", ctx, "SPRING10").Return(tt.coupon, nil)
+			} else {
+				mockCouponRepo.On("FindByCode", ctx, "SPRING10").Return(nil, errors.New("record not found"))
+			}
+			mockCouponRepo.On("CountRedemptionsByUser", ctx, uint(1), uint(7)).Return(tt.used, nil)
+
+			// Execute: codes match whatever case they are typed in
+			coupon, discounts, err := couponService.Evaluate(ctx, 7, " spring10 ", lines, domain.Conversion{Rate: 1})
+
+			// Assert
+			if tt.wantErr != "" {
+				var couponErr *domain.CouponError
+				require.ErrorAs(t, err, &couponErr)
+				assert.Equal(t, tt.wantErr, couponErr.Reason)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "SPRING10", coupon.Code)
+			require.Len(t, discounts, 1)
+			assert.Equal(t, usd(200), discounts[0].Amount)
+		})
+	}
+}
+
+func TestCreateCoupon(t *testing.T) {
+	ctx := context.Background()
+	starts := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	ends := starts.Add(-time.Hour)
+
+	tests := []struct {
+		name    string
+		coupon  domain.Coupon
+		wantErr string
+	}{
+		{name: "Missing code", coupon: domain.Coupon{Code: " ", Type: domain.CouponTypePercent, PercentOff: 10}, wantErr: "coupon code is required"},
+		{name: "Invalid type", coupon: domain.Coupon{Code: "SPRING10", Type: "bogo"}, wantErr: "invalid coupon type"},
+		{name: "Percent out of range", coupon: domain.Coupon{Code: "SPRING10", Type: domain.CouponTypePercent, PercentOff: 110}, wantErr: "percent off must be between 0 and 100"},
+		{name: "Fixed without amount", coupon: domain.Coupon{Code: "WELCOME5", Type: domain.CouponTypeFixed}, wantErr: "amount off must be greater than zero"},
+		{name: "Ends before it starts", coupon: domain.Coupon{Code: "SPRING10", Type: domain.CouponTypePercent, PercentOff: 10, StartsAt: &starts, EndsAt: &ends}, wantErr: "coupon must end after it starts"},
+		{name: "Code taken", coupon: domain.Coupon{Code: "taken", Type: domain.CouponTypePercent, PercentOff: 10}, wantErr: "coupon code already exists"},
+		{name: "Success", coupon: domain.Coupon{Code: "spring10", Type: domain.CouponTypePercent, PercentOff: 10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockCouponRepo := new(MockCouponRepository)
+			couponService := service.NewCouponService(mockCouponRepo)
+			mockCouponRepo.On("FindByCode", ctx, "TAKEN").Return(&domain.Coupon{ID: 2, Code: "TAKEN"}, nil)
+			mockCouponRepo.On("FindByCode", ctx, "SPRING10").Return(nil, errors.New("record not found"))
+			mockCouponRepo.On("Create", ctx, mock.AnythingOfType("*domain.Coupon")).Return(nil)
+
+			// Execute
+			coupon := tt.coupon
+			err := couponService.CreateCoupon(ctx, &coupon)
+
+			// Assert
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				mockCouponRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "SPRING10", coupon.Code)
+			mockCouponRepo.AssertCalled(t, "Create", ctx, &coupon)
+		})
+	}
+}
+
+func TestApplyCoupon(t *testing.T) {
+	// Setup
+	mockCartRepo := new(MockCartRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockCouponRepo := new(MockCouponRepository)
+	couponService := service.NewCouponService(mockCouponRepo)
+	cartService := service.NewCartService(mockCartRepo, new(MockProductRepository), mockUserRepo, service.WithCartCoupons(couponService))
+
+	ctx := context.Background()
+	items := []domain.CartItem{
+		{ID: 1, CartID: 1, ProductID: 1, Quantity: 2, Product: domain.Product{ID: 1, Price: usd(1000)}},
+		{ID: 2, CartID: 1, ProductID: 2, Quantity: 1, Product: domain.Product{ID: 2, Price: usd(550)}},
+	}
+	mockUserRepo.On("FindByID", ctx, uint(1)).Return(&domain.User{ID: 1}, nil)
+	mockCartRepo.On("FindByUserID", ctx, uint(1)).Return(&domain.Cart{ID: 1, UserID: 1, Items: items}, nil)
+	mockCouponRepo.On("FindByCode", ctx, "SPRING10").Return(springSale(), nil)
+	mockCartRepo.On("SetCouponCode", ctx, uint(1), "SPRING10").Return(nil).Once()
+	mockCartRepo.On("GetCartItems", ctx, uint(1)).Return(items, nil)
+	mockCartRepo.On("FindByID", ctx, uint(1)).Return(&domain.Cart{ID: 1, UserID: 1, Items: items, CouponCode: "SPRING10"}, nil)
+
+	// Execute
+	summary, err := cartService.ApplyCoupon(ctx, 1, "spring10")
+
+	// Assert: the discount shows in the cart's price breakdown
+	require.NoError(t, err)
+	assert.Equal(t, usd(2550), summary.Subtotal)
+	assert.Equal(t, usd(255), summary.Discount)
+	assert.Equal(t, usd(2295), summary.Total)
+	assert.Equal(t, "SPRING10", summary.CouponCode)
+	assert.Len(t, summary.Discounts, 2)
+	mockCartRepo.AssertExpectations(t)
+}
+
+func TestGetCartSummaryWithStaleCoupon(t *testing.T) {
+	// Setup
+	mockCartRepo := new(MockCartRepository)
+	mockCouponRepo := new(MockCouponRepository)
+	couponService := service.NewCouponService(mockCouponRepo)
+	cartService := service.NewCartService(mockCartRepo, new(MockProductRepository), new(MockUserRepository), service.WithCartCoupons(couponService))
+
+	ctx := context.Background()
+	items := []domain.CartItem{{ID: 1, CartID: 1, ProductID: 1, Quantity: 1, Product: domain.Product{ID: 1, Price: usd(1000)}}}
+	coupon := springSale()
+	coupon.MinSubtotal = usd(2000)
+	mockCartRepo.On("GetCartItems", ctx, uint(1)).Return(items, nil)
+	mockCartRepo.On("FindByID", ctx, uint(1)).Return(&domain.Cart{ID: 1, UserID: 1, Items: items, CouponCode: "SPRING10"}, nil)
+	mockCouponRepo.On("FindByCode", ctx, "SPRING10").Return(coupon, nil)
+
+	// Execute
+	summary, err := cartService.GetCartSummary(ctx, 1)
+
+	// Assert: the coupon stays on the cart but takes nothing off
+	require.NoError(t, err)
+	assert.Equal(t, usd(1000), summary.Total)
+	assert.Equal(t, "SPRING10", summary.CouponCode)
+	assert.Equal(t, "cart subtotal is below the coupon minimum", summary.CouponError)
+	assert.Empty(t, summary.Discounts)
+}
+
+func TestCreateOrderWithCoupon(t *testing.T) {
+	// Setup
+	mockOrderRepo := new(MockOrderRepository)
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(MockProductRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockCouponRepo := new(MockCouponRepository)
+	couponService := service.NewCouponService(mockCouponRepo)
+	orderService := service.NewOrderService(mockOrderRepo, mockCartRepo, mockProductRepo, mockUserRepo, nil, service.WithCoupons(couponService))
+
+	ctx := context.Background()
+	cart := &domain.Cart{ID: 1, UserID: 1, CouponCode: "WELCOME5", Items: []domain.CartItem{
+		{ProductID: 1, Quantity: 2},
+		{ProductID: 2, Quantity: 1},
+	}}
+	coupon := &domain.Coupon{ID: 3, Code: "WELCOME5", Type: domain.CouponTypeFixed, AmountOff: usd(500), CategoryIDs: []uint{10}, Active: true}
+	mockUserRepo.On("FindByID", ctx, uint(1)).Return(&domain.User{ID: 1}, nil)
+	mockCartRepo.On("FindByUserID", ctx, uint(1)).Return(cart, nil)
+	mockProductRepo.On("FindByID", ctx, uint(1)).Return(&domain.Product{ID: 1, Name: "Mug", Price: usd(800), Stock: 10, CategoryID: 10}, nil)
+	mockProductRepo.On("FindByID", ctx, uint(2)).Return(&domain.Product{ID: 2, Name: "Coffee", Price: usd(1200), Stock: 10, CategoryID: 20}, nil)
+	mockCouponRepo.On("FindByCode", ctx, "WELCOME5").Return(coupon, nil)
+	mockProductRepo.On("DecrementStock", ctx, mock.Anything, mock.Anything).Return(nil)
+	mockOrderRepo.On("Create", ctx, mock.AnythingOfType("*domain.Order")).Return(nil)
+	mockCartRepo.On("ClearCart", ctx, cart.ID).Return(nil)
+
+	// Execute
+	order, err := orderService.CreateOrder(ctx, 1, "1 Shipping St", "1 Billing St", 0)
+
+	// Assert: only the mugs are discounted, and the order records the coupon
+	require.NoError(t, err)
+	assert.Equal(t, usd(500), order.DiscountAmount)
+	assert.Equal(t, usd(2300), order.TotalAmount)
+	require.Len(t, order.Discounts, 1)
+	assert.Equal(t, uint(1), order.Discounts[0].ProductID)
+	assert.Equal(t, "WELCOME5", order.Discounts[0].Label)
+	require.NotNil(t, order.CouponID)
+	assert.Equal(t, uint(3), *order.CouponID)
+	assert.Equal(t, "WELCOME5", order.CouponCode)
+}
+
+func TestCreateOrderWithExpiredCoupon(t *testing.T) {
+	// Setup
+	mockOrderRepo := new(MockOrderRepository)
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(MockProductRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockCouponRepo := new(MockCouponRepository)
+	couponService := service.NewCouponService(mockCouponRepo)
+	orderService := service.NewOrderService(mockOrderRepo, mockCartRepo, mockProductRepo, mockUserRepo, nil, service.WithCoupons(couponService))
+
+	ctx := context.Background()
+	ended := time.Now().Add(-time.Minute)
+	coupon := springSale()
+	coupon.EndsAt = &ended
+	cart := &domain.Cart{ID: 1, UserID: 1, CouponCode: "SPRING10", Items: []domain.CartItem{{ProductID: 1, Quantity: 1}}}
+	mockUserRepo.On("FindByID", ctx, uint(1)).Return(&domain.User{ID: 1}, nil)
+	mockCartRepo.On("FindByUserID", ctx, uint(1)).Return(cart, nil)
+	mockProductRepo.On("FindByID", ctx, uint(1)).Return(&domain.Product{ID: 1, Name: "Mug", Price: usd(800), Stock: 10}, nil)
+	mockCouponRepo.On("FindByCode", ctx, "SPRING10").Return(coupon, nil)
+
+	// Execute
+	order, err := orderService.CreateOrder(ctx, 1, "1 Shipping St", "1 Billing St", 0)
+
+	// Assert: the customer is told rather than charged the full price
+	assert.EqualError(t, err, "coupon has expired")
+	assert.Nil(t, order)
+	mockOrderRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"regexp"
+	"strings"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+)
+
+// currencyCodePattern matches ISO 4217 currency codes
+var currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// CurrencyService defines the interface for currency conversion business logic
+type CurrencyService interface {
+	// BaseCurrency returns the currency catalog prices are set in
+	BaseCurrency() string
+
+	// GetConversion returns the conversion of base prices to the currency the
+	// request asked for, or to the base currency when it asked for none
+	GetConversion(ctx context.Context) (domain.Conversion, error)
+
+	// GetRates retrieves the exchange rates of the supported currencies
+	GetRates(ctx context.Context) ([]domain.ExchangeRate, error)
+
+	// SetRate sets the exchange rate of a currency
+	SetRate(ctx context.Context, currency string, rate float64) (*domain.ExchangeRate, error)
+
+	// LoadRates loads exchange rates from a JSON file mapping currency codes
+	// to rates, returning how many were loaded
+	LoadRates(ctx context.Context, path string) (int, error)
+}
+
+// CurrencyServiceImpl implements the CurrencyService interface
+type CurrencyServiceImpl struct {
+	rateRepo     repository.ExchangeRateRepository
+	baseCurrency string
+}
+
+// NewCurrencyService creates a new CurrencyServiceImpl. Catalog prices are
+// set in baseCurrency.
+func NewCurrencyService(rateRepo repository.ExchangeRateRepository, baseCurrency string) CurrencyService {
+	return &CurrencyServiceImpl{
+		rateRepo:     rateRepo,
+		baseCurrency: strings.ToUpper(baseCurrency),
+	}
+}
+
+// BaseCurrency returns the currency catalog prices are set in
+func (s *CurrencyServiceImpl) BaseCurrency() string {
+	return s.baseCurrency
+}
+
+// GetConversion returns the conversion of base prices to the currency the
+// request asked for, or to the base currency when it asked for none
+func (s *CurrencyServiceImpl) GetConversion(ctx context.Context) (domain.Conversion, error) {
+	currency := strings.ToUpper(domain.CurrencyFromContext(ctx))
+	if currency == "" || currency == s.baseCurrency {
+		return domain.Conversion{Currency: s.baseCurrency, Rate: 1}, nil
+	}
+
+	rate, err := s.rateRepo.FindByCurrency(ctx, currency)
+	if err != nil {
+		return domain.Conversion{}, errors.New("unsupported currency")
+	}
+	return domain.Conversion{Currency: rate.Currency, Rate: rate.Rate}, nil
+}
+
+// GetRates retrieves the exchange rates of the supported currencies
+func (s *CurrencyServiceImpl) GetRates(ctx context.Context) ([]domain.ExchangeRate, error) {
+	return s.rateRepo.FindAll(ctx)
+}
+
+// SetRate sets the exchange rate of a currency
+func (s *CurrencyServiceImpl) SetRate(ctx context.Context, currency string, rate float64) (*domain.ExchangeRate, error) {
+	exchangeRate, err := s.validateRate(strings.ToUpper(currency), rate)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.rateRepo.Save(ctx, []domain.ExchangeRate{exchangeRate}); err != nil {
+		return nil, err
+	}
+	return &exchangeRate, nil
+}
+
+// LoadRates loads exchange rates from a JSON file mapping currency codes
+// to rates, returning how many were loaded. Every rate is checked before
+// any is saved.
+func (s *CurrencyServiceImpl) LoadRates(ctx context.Context, path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var entries map[string]float64
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return 0, errors.New("invalid exchange rates file")
+	}
+
+	rates := make([]domain.ExchangeRate, 0, len(entries))
+	for currency, rate := range entries {
+		exchangeRate, err := s.validateRate(strings.ToUpper(currency), rate)
+		if err != nil {
+			return 0, err
+		}
+		rates = append(rates, exchangeRate)
+	}
+
+	if err := s.rateRepo.Save(ctx, rates); err != nil {
+		return 0, err
+	}
+	return len(rates), nil
+}
+
+// validateRate checks the exchange rate of a currency other than the base currency
+func (s *CurrencyServiceImpl) validateRate(currency string, rate float64) (domain.ExchangeRate, error) {
+	if !currencyCodePattern.MatchString(currency) {
+		return domain.ExchangeRate{}, errors.New("invalid currency code")
+	}
+	if currency == s.baseCurrency {
+		return domain.ExchangeRate{}, errors.New("the base currency has no exchange rate")
+	}
+	if rate <= 0 {
+		return domain.ExchangeRate{}, errors.New("exchange rate must be greater than zero")
+	}
+	return domain.ExchangeRate{Currency: currency, Rate: rate}, nil
+}
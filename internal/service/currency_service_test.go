@@ -0,0 +1,324 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockExchangeRateRepository struct {
+	mock.Mock
+}
+
+func (m *MockExchangeRateRepository) FindByCurrency(ctx context.Context, currency string) (*domain.ExchangeRate, error) {
+	args := m.Called(ctx, currency)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ExchangeRate), args.Error(1)
+}
+
+func (m *MockExchangeRateRepository) FindAll(ctx context.Context) ([]domain.ExchangeRate, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.ExchangeRate), args.Error(1)
+}
+
+func (m *MockExchangeRateRepository) Save(ctx context.Context, rates []domain.ExchangeRate) error {
+	args := m.Called(ctx, rates)
+	return args.Error(0)
+}
+
+func TestGetConversion(t *testing.T) {
+	tests := []struct {
+		name     string
+		currency string
+		want     domain.Conversion
+		wantErr  string
+	}{
+		{name: "No currency asked for", want: domain.Conversion{Currency: "USD", Rate: 1}},
+		{name: "Base currency", currency: "usd", want: domain.Conversion{Currency: "USD", Rate: 1}},
+		{name: "Supported currency", currency: "eur", want: domain.Conversion{Currency: "EUR", Rate: 0.92}},
+		{name: "Unsupported currency", currency: "XYZ", wantErr: "unsupported currency"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockRateRepo := new(MockExchangeRateRepository)
+			currencyService := service.NewCurrencyService(mockRateRepo, "usd")
+			ctx := context.Background()
+			if tt.currency != "" {
+				ctx = domain.WithCurrency(ctx, tt.currency)
+			}
+			mockRateRepo.On("FindByCurrency", ctx, "EUR").Return(&domain.ExchangeRate{Currency: "EUR", Rate: 0.92}, nil)
+			mockRateRepo.On("FindByCurrency", ctx, "XYZ").Return(nil, errors.New("record not found"))
+
+			// Execute
+			conversion, err := currencyService.GetConversion(ctx)
+
+			// Assert
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, conversion)
+		})
+	}
+}
+
+func TestSetExchangeRate(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		currency string
+		rate     float64
+		wantErr  string
+	}{
+		{name: "Invalid code", currency: "EURO", rate: 0.92, wantErr: "invalid currency code"},
+		{name: "Base currency", currency: "USD", rate: 1, wantErr: "the base currency has no exchange rate"},
+		{name: "Zero rate", currency: "EUR", rate: 0, wantErr: "exchange rate must be greater than zero"},
+		{name: "Success", currency: "eur", rate: 0.92},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockRateRepo := new(MockExchangeRateRepository)
+			currencyService := service.NewCurrencyService(mockRateRepo, "USD")
+			mockRateRepo.On("Save", ctx, []domain.ExchangeRate{{Currency: "EUR", Rate: 0.92}}).Return(nil)
+
+			// Execute
+			rate, err := currencyService.SetRate(ctx, tt.currency, tt.rate)
+
+			// Assert
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				mockRateRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "EUR", rate.Currency)
+			mockRateRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLoadExchangeRates(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		// Setup
+		path := filepath.Join(t.TempDir(), "rates.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"jpy": 151.2}`), 0o644))
+		mockRateRepo := new(MockExchangeRateRepository)
+		currencyService := service.NewCurrencyService(mockRateRepo, "USD")
+		mockRateRepo.On("Save", ctx, []domain.ExchangeRate{{Currency: "JPY", Rate: 151.2}}).Return(nil)
+
+		// Execute
+		loaded, err := currencyService.LoadRates(ctx, path)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 1, loaded)
+		mockRateRepo.AssertExpectations(t)
+	})
+
+	t.Run("Invalid rate", func(t *testing.T) {
+		// Setup
+		path := filepath.Join(t.TempDir(), "rates.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"EUR": 0.92, "GBP": -1}`), 0o644))
+		mockRateRepo := new(MockExchangeRateRepository)
+		currencyService := service.NewCurrencyService(mockRateRepo, "USD")
+
+		// Execute
+		loaded, err := currencyService.LoadRates(ctx, path)
+
+		// Assert: nothing is saved when any rate is invalid
+		assert.EqualError(t, err, "exchange rate must be greater than zero")
+		assert.Equal(t, 0, loaded)
+		mockRateRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	})
+}
+
+func TestConversionRounding(t *testing.T) {
+	tests := []struct {
+		name       string
+		conversion domain.Conversion
+		price      domain.Money
+		quantity   int
+		wantPrice  domain.Money
+		wantTotal  domain.Money
+	}{
+		{name: "Cents", conversion: domain.Conversion{Currency: "EUR", Rate: 0.9234}, price: usd(1999), quantity: 3, wantPrice: domain.Money{Amount: 1846, Currency: "EUR"}, wantTotal: domain.Money{Amount: 5538, Currency: "EUR"}},
+		{name: "Zero decimals", conversion: domain.Conversion{Currency: "JPY", Rate: 151.27}, price: usd(1999), quantity: 3, wantPrice: domain.Money{Amount: 3024, Currency: "JPY"}, wantTotal: domain.Money{Amount: 9072, Currency: "JPY"}},
+		{name: "Base currency", conversion: domain.Conversion{Currency: "USD", Rate: 1}, price: usd(1999), quantity: 3, wantPrice: usd(1999), wantTotal: usd(5997)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantPrice, tt.conversion.Convert(tt.price))
+			assert.Equal(t, tt.wantTotal, tt.conversion.LineTotal(tt.price, tt.quantity))
+		})
+	}
+}
+
+func TestCreateOrderInCurrency(t *testing.T) {
+	// Setup
+	mockOrderRepo := new(MockOrderRepository)
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(MockProductRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockRateRepo := new(MockExchangeRateRepository)
+	currencyService := service.NewCurrencyService(mockRateRepo, "USD")
+	orderService := service.NewOrderService(mockOrderRepo, mockCartRepo, mockProductRepo, mockUserRepo, nil, service.WithCurrencies(currencyService))
+
+	ctx := domain.WithCurrency(context.Background(), "EUR")
+	cart := &domain.Cart{ID: 1, UserID: 1, Items: []domain.CartItem{
+		{ProductID: 1, Quantity: 3},
+		{ProductID: 2, Quantity: 1},
+	}}
+	mockUserRepo.On("FindByID", ctx, uint(1)).Return(&domain.User{ID: 1}, nil)
+	mockCartRepo.On("FindByUserID", ctx, uint(1)).Return(cart, nil)
+	mockRateRepo.On("FindByCurrency", ctx, "EUR").Return(&domain.ExchangeRate{Currency: "EUR", Rate: 0.9234}, nil)
+	mockProductRepo.On("FindByID", ctx, uint(1)).Return(&domain.Product{ID: 1, Name: "Mug", Price: usd(1999), Stock: 10}, nil)
+	mockProductRepo.On("FindByID", ctx, uint(2)).Return(&domain.Product{ID: 2, Name: "Coffee", Price: usd(1000), Stock: 10}, nil)
+	mockProductRepo.On("DecrementStock", ctx, mock.Anything, mock.Anything).Return(nil)
+	mockOrderRepo.On("Create", ctx, mock.AnythingOfType("*domain.Order")).Return(nil)
+	mockCartRepo.On("ClearCart", ctx, cart.ID).Return(nil)
+
+	// Execute
+	order, err := orderService.CreateOrder(ctx, 1, "1 Shipping St", "1 Billing St", 0)
+
+	// Assert: the total adds up to the converted lines
+	require.NoError(t, err)
+	assert.Equal(t, 0.9234, order.ExchangeRate)
+	assert.Equal(t, domain.Money{Amount: 1846, Currency: "EUR"}, order.Items[0].Price)
+	assert.Equal(t, domain.Money{Amount: 923, Currency: "EUR"}, order.Items[1].Price)
+	assert.Equal(t, domain.Money{Amount: 6461, Currency: "EUR"}, order.TotalAmount)
+}
+
+func TestCreateOrderInUnsupportedCurrency(t *testing.T) {
+	// Setup
+	mockOrderRepo := new(MockOrderRepository)
+	mockCartRepo := new(MockCartRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockRateRepo := new(MockExchangeRateRepository)
+	currencyService := service.NewCurrencyService(mockRateRepo, "USD")
+	orderService := service.NewOrderService(mockOrderRepo, mockCartRepo, new(MockProductRepository), mockUserRepo, nil, service.WithCurrencies(currencyService))
+
+	ctx := domain.WithCurrency(context.Background(), "XYZ")
+	cart := &domain.Cart{ID: 1, UserID: 1, Items: []domain.CartItem{{ProductID: 1, Quantity: 1}}}
+	mockUserRepo.On("FindByID", ctx, uint(1)).Return(&domain.User{ID: 1}, nil)
+	mockCartRepo.On("FindByUserID", ctx, uint(1)).Return(cart, nil)
+	mockRateRepo.On("FindByCurrency", ctx, "XYZ").Return(nil, errors.New("record not found"))
+
+	// Execute
+	order, err := orderService.CreateOrder(ctx, 1, "1 Shipping St", "1 Billing St", 0)
+
+	// Assert
+	assert.EqualError(t, err, "unsupported currency")
+	assert.Nil(t, order)
+	mockOrderRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestGetCartTotalInCurrency(t *testing.T) {
+	// Setup
+	mockCartRepo := new(MockCartRepository)
+	mockRateRepo := new(MockExchangeRateRepository)
+	currencyService := service.NewCurrencyService(mockRateRepo, "USD")
+	cartService := service.NewCartService(mockCartRepo, new(MockProductRepository), new(MockUserRepository), service.WithCartCurrencies(currencyService))
+
+	ctx := domain.WithCurrency(context.Background(), "JPY")
+	mockRateRepo.On("FindByCurrency", ctx, "JPY").Return(&domain.ExchangeRate{Currency: "JPY", Rate: 151.27}, nil)
+	mockCartRepo.On("GetCartItems", ctx, uint(1)).Return([]domain.CartItem{
+		{ProductID: 1, Quantity: 3, Product: domain.Product{ID: 1, Price: usd(1999)}},
+	}, nil)
+
+	// Execute
+	total, err := cartService.GetCartTotal(ctx, 1)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Money{Amount: 9072, Currency: "JPY"}, total)
+}
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		currency string
+		want     domain.Money
+		wantErr  string
+	}{
+		{name: "Cents", amount: "19.99", currency: "USD", want: usd(1999)},
+		{name: "Whole amount", amount: "20", currency: "USD", want: usd(2000)},
+		{name: "No binary rounding", amount: "0.29", currency: "USD", want: usd(29)},
+		{name: "Zero decimals", amount: "3024", currency: "JPY", want: domain.Money{Amount: 3024, Currency: "JPY"}},
+		{name: "Fraction of a cent", amount: "19.999", currency: "USD", wantErr: "amount has more decimals than its currency"},
+		{name: "Fraction of a yen", amount: "3024.5", currency: "JPY", wantErr: "amount has more decimals than its currency"},
+		{name: "Not a number", amount: "ten", currency: "USD", wantErr: "invalid amount"},
+		{name: "Too large", amount: "1e30", currency: "USD", wantErr: "amount is too large"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			money, err := domain.ParseMoney(tt.amount, tt.currency)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, money)
+		})
+	}
+}
+
+func TestMoneyDecimal(t *testing.T) {
+	assert.Equal(t, "19.99", usd(1999).Decimal())
+	assert.Equal(t, "0.05", usd(5).Decimal())
+	assert.Equal(t, "-1.50", usd(-150).Decimal())
+	assert.Equal(t, "3024", domain.Money{Amount: 3024, Currency: "JPY"}.Decimal())
+	assert.Equal(t, "19.99 USD", usd(1999).String())
+
+	// Ten lines at 0.10 add up to exactly 1.00
+	total := domain.Money{}
+	for i := 0; i < 10; i++ {
+		var err error
+		total, err = total.Add(usd(10))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, usd(100), total)
+	assert.Equal(t, "1.00", string(total.Number()))
+}
+
+func TestMoneyCurrencyMismatch(t *testing.T) {
+	eur := domain.Money{Amount: 500, Currency: "EUR"}
+
+	_, err := usd(1000).Add(eur)
+	assert.ErrorIs(t, err, domain.ErrCurrencyMismatch)
+
+	_, err = usd(1000).Subtract(eur)
+	assert.ErrorIs(t, err, domain.ErrCurrencyMismatch)
+
+	// Amounts without a currency take the other's
+	sum, err := domain.Money{}.Add(eur)
+	assert.NoError(t, err)
+	assert.Equal(t, eur, sum)
+	difference, err := usd(1000).Subtract(domain.Money{})
+	assert.NoError(t, err)
+	assert.Equal(t, usd(1000), difference)
+}
@@ -0,0 +1,408 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/notification"
+	"awesomeEcommerce/internal/repository"
+)
+
+// DigitalFulfillmentService defines the interface for digital product delivery business logic
+type DigitalFulfillmentService interface {
+	// AddLicenseKeys adds keys to the pool of a product delivered by license key,
+	// returning how many were added
+	AddLicenseKeys(ctx context.Context, productID uint, keys []string) (int, error)
+
+	// SaveDownloadFile stores the file of a product delivered by download
+	SaveDownloadFile(ctx context.Context, productID uint, filename string, content io.Reader) error
+
+	// DeliverOrder delivers the digital lines of a paid order and tells the
+	// customer. Lines delivered before are not delivered again.
+	DeliverOrder(ctx context.Context, orderID uint) ([]domain.DigitalDelivery, error)
+
+	// GetOrderDeliveries retrieves the delivered digital lines of an order,
+	// with fresh download links
+	GetOrderDeliveries(ctx context.Context, orderID uint) ([]domain.DigitalDelivery, error)
+
+	// ResolveDownload checks a signed download link, returning the path of the file it serves
+	ResolveDownload(ctx context.Context, orderID, itemID uint, expires int64, signature string) (string, error)
+}
+
+// DigitalFulfillmentServiceImpl implements the DigitalFulfillmentService interface
+type DigitalFulfillmentServiceImpl struct {
+	deliveryRepo repository.DigitalDeliveryRepository
+	productRepo  repository.ProductRepository
+	orderRepo    repository.OrderRepository
+	paymentRepo  repository.PaymentRepository
+	stateMachine OrderStateMachine
+	userRepo     repository.UserRepository
+	channel      notification.Channel
+	giftCards    GiftCardService
+	storageDir   string
+	baseURL      string
+	secret       []byte
+	ttl          time.Duration
+}
+
+// NewDigitalFulfillmentService creates a new DigitalFulfillmentServiceImpl.
+// Download files are kept under storageDir and served by links on baseURL,
+// signed with secret and valid for ttl. Only orders whose payment in
+// paymentRepo is completed are delivered. Gift card lines are issued by giftCards.
+// Orders delivered in full move through the lifecycle of stateMachine, the
+// default one when nil.
+func NewDigitalFulfillmentService(
+	deliveryRepo repository.DigitalDeliveryRepository,
+	productRepo repository.ProductRepository,
+	orderRepo repository.OrderRepository,
+	paymentRepo repository.PaymentRepository,
+	stateMachine OrderStateMachine,
+	userRepo repository.UserRepository,
+	channel notification.Channel,
+	giftCards GiftCardService,
+	storageDir string,
+	baseURL string,
+	secret string,
+	ttl time.Duration,
+) DigitalFulfillmentService {
+	if stateMachine == nil {
+		stateMachine = NewOrderStateMachine(domain.DefaultOrderTransitions(), DefaultOrderStatusGuards(nil))
+	}
+
+	return &DigitalFulfillmentServiceImpl{
+		deliveryRepo: deliveryRepo,
+		productRepo:  productRepo,
+		orderRepo:    orderRepo,
+		paymentRepo:  paymentRepo,
+		stateMachine: stateMachine,
+		userRepo:     userRepo,
+		channel:      channel,
+		giftCards:    giftCards,
+		storageDir:   storageDir,
+		baseURL:      strings.TrimSuffix(baseURL, "/"),
+		secret:       []byte(secret),
+		ttl:          ttl,
+	}
+}
+
+// AddLicenseKeys adds keys to the pool of a product delivered by license key,
+// returning how many were added. Blank and repeated keys are skipped.
+func (s *DigitalFulfillmentServiceImpl) AddLicenseKeys(ctx context.Context, productID uint, keys []string) (int, error) {
+	product, err := s.productRepo.FindByID(ctx, productID)
+	if err != nil {
+		return 0, errors.New("product not found")
+	}
+	if !product.IsDigital() || product.DeliveryMethod != domain.DeliveryMethodLicenseKey {
+		return 0, errors.New("product is not delivered by license key")
+	}
+
+	seen := make(map[string]bool, len(keys))
+	pool := make([]string, 0, len(keys))
+	for _, key := range keys {
+		key = strings.TrimSpace(key)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		pool = append(pool, key)
+	}
+	if len(pool) == 0 {
+		return 0, errors.New("no license keys given")
+	}
+
+	if err := s.deliveryRepo.AddLicenseKeys(ctx, productID, pool, stockChange(ctx, domain.LedgerReasonLicenseKeyUpload, 0)); err != nil {
+		return 0, err
+	}
+	return len(pool), nil
+}
+
+// SaveDownloadFile stores the file of a product delivered by download,
+// replacing the file it had before
+func (s *DigitalFulfillmentServiceImpl) SaveDownloadFile(ctx context.Context, productID uint, filename string, content io.Reader) error {
+	product, err := s.productRepo.FindByID(ctx, productID)
+	if err != nil {
+		return errors.New("product not found")
+	}
+	if !product.IsDigital() || product.DeliveryMethod != domain.DeliveryMethodDownload {
+		return errors.New("product is not delivered by download")
+	}
+
+	// Only the base name is kept, so uploads never escape the storage directory
+	filename = filepath.Base(filename)
+	if filename == "." || filename == ".." || filename == string(filepath.Separator) {
+		return errors.New("invalid file name")
+	}
+
+	dir := filepath.Join(s.storageDir, strconv.FormatUint(uint64(productID), 10))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so downloads never see a partial file
+	tmp, err := os.CreateTemp(dir, ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, filename)); err != nil {
+		return err
+	}
+
+	product.DownloadFile = filepath.Join(strconv.FormatUint(uint64(productID), 10), filename)
+	return s.productRepo.Update(ctx, product)
+}
+
+// DeliverOrder delivers the digital lines of a paid order and tells the
+// customer. Lines delivered before are not delivered again. Orders with only
+// digital lines are delivered once every line is.
+func (s *DigitalFulfillmentServiceImpl) DeliverOrder(ctx context.Context, orderID uint) ([]domain.DigitalDelivery, error) {
+	order, err := s.orderRepo.FindByID(ctx, orderID)
+	if err != nil {
+		return nil, errors.New("order not found")
+	}
+	if !s.isPaid(ctx, order) {
+		return nil, errors.New("order has not been paid")
+	}
+
+	now := time.Now()
+	var deliveries []domain.DigitalDelivery
+	var errs []error
+	delivered := 0
+	for i := range order.Items {
+		item := &order.Items[i]
+		if !item.IsDigital() {
+			continue
+		}
+
+		wasDelivered := item.IsDelivered()
+		if !wasDelivered && item.DeliveryMethod == domain.DeliveryMethodDownload {
+			if err := s.checkDownloadFile(ctx, item); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+
+		// Gift cards are issued before the line is marked delivered, so a
+		// failed issue is retried with the rest of the delivery
+		var cards []domain.GiftCard
+		if item.DeliveryMethod == domain.DeliveryMethodGiftCard {
+			if cards, err = s.issueGiftCards(ctx, order, item); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+
+		keys, err := s.deliveryRepo.DeliverItem(ctx, item, now)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !wasDelivered {
+			item.DeliveredAt = &now
+			delivered++
+		}
+
+		deliveries = append(deliveries, withGiftCards(s.delivery(*item, keys, now), cards))
+	}
+
+	// Tell the customer about the lines delivered now
+	if delivered > 0 && s.channel != nil {
+		if err := s.channel.Send(ctx, s.deliveryNotification(ctx, order, deliveries)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) == 0 && !order.RequiresShipping() && order.Status != domain.OrderStatusDelivered {
+		if err := transitionOrder(ctx, s.orderRepo, s.stateMachine, order, domain.OrderStatusDelivered, orderStatusChange(ctx, "digital items delivered")); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return deliveries, errors.Join(errs...)
+}
+
+// GetOrderDeliveries retrieves the delivered digital lines of an order, with
+// fresh download links
+func (s *DigitalFulfillmentServiceImpl) GetOrderDeliveries(ctx context.Context, orderID uint) ([]domain.DigitalDelivery, error) {
+	order, err := s.orderRepo.FindByID(ctx, orderID)
+	if err != nil {
+		return nil, errors.New("order not found")
+	}
+
+	keys, err := s.deliveryRepo.FindLicenseKeysByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	keysByItem := make(map[uint][]domain.LicenseKey)
+	for _, key := range keys {
+		if key.OrderItemID != nil {
+			keysByItem[*key.OrderItemID] = append(keysByItem[*key.OrderItemID], key)
+		}
+	}
+
+	now := time.Now()
+	deliveries := []domain.DigitalDelivery{}
+	for _, item := range order.Items {
+		if !item.IsDigital() || !item.IsDelivered() {
+			continue
+		}
+
+		delivery := s.delivery(item, keysByItem[item.ID], now)
+		if item.DeliveryMethod == domain.DeliveryMethodGiftCard && s.giftCards != nil {
+			cards, err := s.giftCards.GetPurchasedGiftCards(ctx, item.ID)
+			if err != nil {
+				return nil, err
+			}
+			delivery = withGiftCards(delivery, cards)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, nil
+}
+
+// ResolveDownload checks a signed download link, returning the path of the file it serves
+func (s *DigitalFulfillmentServiceImpl) ResolveDownload(ctx context.Context, orderID, itemID uint, expires int64, signature string) (string, error) {
+	expected := s.sign(orderID, itemID, expires)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return "", errors.New("invalid download link")
+	}
+	if time.Now().Unix() > expires {
+		return "", errors.New("download link has expired")
+	}
+
+	order, err := s.orderRepo.FindByID(ctx, orderID)
+	if err != nil {
+		return "", errors.New("order not found")
+	}
+	if !s.isPaid(ctx, order) {
+		return "", errors.New("order has not been paid")
+	}
+
+	for _, item := range order.Items {
+		if item.ID != itemID {
+			continue
+		}
+		if item.DeliveryMethod != domain.DeliveryMethodDownload || !item.IsDelivered() {
+			break
+		}
+
+		product, err := s.productRepo.FindByID(ctx, item.ProductID)
+		if err != nil || product.DownloadFile == "" {
+			break
+		}
+		return filepath.Join(s.storageDir, product.DownloadFile), nil
+	}
+
+	return "", errors.New("download not found")
+}
+
+// checkDownloadFile checks that the product of a download line has a file to download
+func (s *DigitalFulfillmentServiceImpl) checkDownloadFile(ctx context.Context, item *domain.OrderItem) error {
+	product, err := s.productRepo.FindByID(ctx, item.ProductID)
+	if err != nil {
+		return errors.New("product not found")
+	}
+	if product.DownloadFile == "" {
+		return fmt.Errorf("no download file for product: %s", item.ProductName)
+	}
+	return nil
+}
+
+// issueGiftCards issues the gift cards bought with a line
+func (s *DigitalFulfillmentServiceImpl) issueGiftCards(ctx context.Context, order *domain.Order, item *domain.OrderItem) ([]domain.GiftCard, error) {
+	if s.giftCards == nil {
+		return nil, fmt.Errorf("gift cards are not available for product: %s", item.ProductName)
+	}
+	return s.giftCards.IssuePurchasedGiftCards(ctx, order, item)
+}
+
+// withGiftCards adds the codes of the gift cards a line was delivered as
+func withGiftCards(delivery domain.DigitalDelivery, cards []domain.GiftCard) domain.DigitalDelivery {
+	for _, card := range cards {
+		delivery.GiftCardCodes = append(delivery.GiftCardCodes, card.Code)
+	}
+	return delivery
+}
+
+// delivery describes a delivered line, signing a download link valid from now
+func (s *DigitalFulfillmentServiceImpl) delivery(item domain.OrderItem, keys []domain.LicenseKey, now time.Time) domain.DigitalDelivery {
+	delivery := domain.DigitalDelivery{
+		OrderItemID:    item.ID,
+		ProductID:      item.ProductID,
+		ProductName:    item.ProductName,
+		DeliveryMethod: item.DeliveryMethod,
+		DeliveredAt:    item.DeliveredAt,
+	}
+
+	switch item.DeliveryMethod {
+	case domain.DeliveryMethodLicenseKey:
+		for _, key := range keys {
+			delivery.LicenseKeys = append(delivery.LicenseKeys, key.Key)
+		}
+	case domain.DeliveryMethodDownload:
+		expiresAt := now.Add(s.ttl)
+		delivery.ExpiresAt = &expiresAt
+		delivery.DownloadURL = fmt.Sprintf("%s/api/v1/downloads/%d/%d?expires=%d&signature=%s",
+			s.baseURL, item.OrderID, item.ID, expiresAt.Unix(), s.sign(item.OrderID, item.ID, expiresAt.Unix()))
+	}
+
+	return delivery
+}
+
+// sign computes the signature of a download link
+func (s *DigitalFulfillmentServiceImpl) sign(orderID, itemID uint, expires int64) string {
+	mac := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(mac, "%d:%d:%d", orderID, itemID, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliveryNotification builds the notification handing a customer their digital lines
+func (s *DigitalFulfillmentServiceImpl) deliveryNotification(ctx context.Context, order *domain.Order, deliveries []domain.DigitalDelivery) notification.Notification {
+	data := map[string]interface{}{
+		"order_id":   order.ID,
+		"user_id":    order.UserID,
+		"deliveries": deliveries,
+	}
+	if s.userRepo != nil {
+		if user, err := s.userRepo.FindByID(ctx, order.UserID); err == nil {
+			data["email"] = user.Email
+		}
+	}
+
+	return notification.Notification{
+		Type:    "digital-delivery",
+		Key:     strconv.FormatUint(uint64(order.ID), 10),
+		Subject: fmt.Sprintf("Your digital items from order #%d", order.ID),
+		Data:    data,
+	}
+}
+
+// isPaid reports whether an order has been paid and is still open. An order
+// on hold may have been held before or after it was paid, so its payment says.
+func (s *DigitalFulfillmentServiceImpl) isPaid(ctx context.Context, order *domain.Order) bool {
+	switch order.Status {
+	case domain.OrderStatusCancelled, domain.OrderStatusReturned, domain.OrderStatusRefunded:
+		return false
+	}
+	payment, err := s.paymentRepo.FindByOrderID(ctx, order.ID)
+	return err == nil && payment.Status == domain.PaymentStatusCompleted
+}
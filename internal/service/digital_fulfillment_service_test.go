@@ -0,0 +1,494 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/notification"
+	"awesomeEcommerce/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockDigitalDeliveryRepository struct {
+	mock.Mock
+}
+
+func (m *MockDigitalDeliveryRepository) AddLicenseKeys(ctx context.Context, productID uint, keys []string, change domain.StockChange) error {
+	args := m.Called(ctx, productID, keys, change)
+	return args.Error(0)
+}
+
+func (m *MockDigitalDeliveryRepository) DeliverItem(ctx context.Context, item *domain.OrderItem, deliveredAt time.Time) ([]domain.LicenseKey, error) {
+	args := m.Called(ctx, item, deliveredAt)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.LicenseKey), args.Error(1)
+}
+
+func (m *MockDigitalDeliveryRepository) FindLicenseKeysByOrderID(ctx context.Context, orderID uint) ([]domain.LicenseKey, error) {
+	args := m.Called(ctx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.LicenseKey), args.Error(1)
+}
+
+func newDigitalFulfillmentService(deliveryRepo *MockDigitalDeliveryRepository, productRepo *MockProductRepository, orderRepo *MockOrderRepository, channel notification.Channel, storageDir string) service.DigitalFulfillmentService {
+	return service.NewDigitalFulfillmentService(deliveryRepo, productRepo, orderRepo, paymentsWithStatus(domain.PaymentStatusCompleted), nil, nil, channel, nil, storageDir, "https://shop.example.com/", "secret", time.Hour)
+}
+
+// paymentsWithStatus is a payment repository whose orders are paid by a
+// payment in status
+func paymentsWithStatus(status domain.PaymentStatus) *MockPaymentRepository {
+	mockPaymentRepo := new(MockPaymentRepository)
+	mockPaymentRepo.On("FindByOrderID", mock.Anything, mock.Anything).Return(&domain.Payment{ID: 9, OrderID: 2, Status: status}, nil).Maybe()
+	return mockPaymentRepo
+}
+
+// digitalOrder is a paid order with a line delivered by key and one by download
+func digitalOrder() *domain.Order {
+	return &domain.Order{
+		ID:     2,
+		UserID: 1,
+		Status: domain.OrderStatusProcessing,
+		Items: []domain.OrderItem{
+			{ID: 3, OrderID: 2, ProductID: 1, ProductName: "Editor", Quantity: 1, ProductType: domain.ProductTypeDigital, DeliveryMethod: domain.DeliveryMethodLicenseKey},
+			{ID: 4, OrderID: 2, ProductID: 5, ProductName: "Soundtrack", Quantity: 1, ProductType: domain.ProductTypeDigital, DeliveryMethod: domain.DeliveryMethodDownload},
+		},
+	}
+}
+
+func TestAddLicenseKeys(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		product   *domain.Product
+		keys      []string
+		wantAdded []string
+		wantErr   string
+	}{
+		{
+			name:    "Physical product",
+			product: &domain.Product{ID: 1, Type: domain.ProductTypePhysical},
+			keys:    []string{"AAAA-1111"},
+			wantErr: "product is not delivered by license key",
+		},
+		{
+			name:    "Download product",
+			product: &domain.Product{ID: 1, Type: domain.ProductTypeDigital, DeliveryMethod: domain.DeliveryMethodDownload},
+			keys:    []string{"AAAA-1111"},
+			wantErr: "product is not delivered by license key",
+		},
+		{
+			name:    "Blank keys only",
+			product: &domain.Product{ID: 1, Type: domain.ProductTypeDigital, DeliveryMethod: domain.DeliveryMethodLicenseKey},
+			keys:    []string{" ", ""},
+			wantErr: "no license keys given",
+		},
+		{
+			name:      "Blank and repeated keys are skipped",
+			product:   &domain.Product{ID: 1, Type: domain.ProductTypeDigital, DeliveryMethod: domain.DeliveryMethodLicenseKey},
+			keys:      []string{"AAAA-1111", " BBBB-2222 ", "", "AAAA-1111"},
+			wantAdded: []string{"AAAA-1111", "BBBB-2222"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockDeliveryRepo := new(MockDigitalDeliveryRepository)
+			mockProductRepo := new(MockProductRepository)
+			fulfillmentService := newDigitalFulfillmentService(mockDeliveryRepo, mockProductRepo, new(MockOrderRepository), nil, t.TempDir())
+
+			mockProductRepo.On("FindByID", ctx, uint(1)).Return(tt.product, nil)
+			mockDeliveryRepo.On("AddLicenseKeys", ctx, uint(1), tt.wantAdded, domain.StockChange{Reason: domain.LedgerReasonLicenseKeyUpload, Actor: "system"}).Return(nil)
+
+			// Execute
+			added, err := fulfillmentService.AddLicenseKeys(ctx, 1, tt.keys)
+
+			// Assert
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				mockDeliveryRepo.AssertNotCalled(t, "AddLicenseKeys", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, len(tt.wantAdded), added)
+			mockDeliveryRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSaveDownloadFile(t *testing.T) {
+	// Setup
+	mockProductRepo := new(MockProductRepository)
+	storageDir := t.TempDir()
+	fulfillmentService := newDigitalFulfillmentService(new(MockDigitalDeliveryRepository), mockProductRepo, new(MockOrderRepository), nil, storageDir)
+	ctx := context.Background()
+
+	product := &domain.Product{ID: 5, Type: domain.ProductTypeDigital, DeliveryMethod: domain.DeliveryMethodDownload}
+	mockProductRepo.On("FindByID", ctx, uint(5)).Return(product, nil)
+	mockProductRepo.On("Update", ctx, product).Return(nil)
+
+	// Execute: the path of the upload is dropped
+	err := fulfillmentService.SaveDownloadFile(ctx, 5, "../../soundtrack.zip", strings.NewReader("music"))
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("5", "soundtrack.zip"), product.DownloadFile)
+	content, err := os.ReadFile(filepath.Join(storageDir, "5", "soundtrack.zip"))
+	require.NoError(t, err)
+	assert.Equal(t, "music", string(content))
+}
+
+func TestDeliverOrder(t *testing.T) {
+	// Setup
+	mockDeliveryRepo := new(MockDigitalDeliveryRepository)
+	mockProductRepo := new(MockProductRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	mockChannel := new(MockChannel)
+	storageDir := t.TempDir()
+	fulfillmentService := newDigitalFulfillmentService(mockDeliveryRepo, mockProductRepo, mockOrderRepo, mockChannel, storageDir)
+	ctx := context.Background()
+
+	order := digitalOrder()
+	mockOrderRepo.On("FindByID", ctx, order.ID).Return(order, nil)
+	mockProductRepo.On("FindByID", ctx, uint(5)).Return(&domain.Product{ID: 5, DownloadFile: filepath.Join("5", "soundtrack.zip")}, nil)
+	mockDeliveryRepo.On("DeliverItem", ctx, &order.Items[0], mock.AnythingOfType("time.Time")).Return([]domain.LicenseKey{{ID: 7, Key: "AAAA-1111"}}, nil)
+	mockDeliveryRepo.On("DeliverItem", ctx, &order.Items[1], mock.AnythingOfType("time.Time")).Return([]domain.LicenseKey{}, nil)
+	mockChannel.On("Send", ctx, mock.AnythingOfType("notification.Notification")).Return(nil)
+	mockOrderRepo.On("UpdateStatus", ctx, order.ID, domain.OrderStatusProcessing, domain.OrderStatusDelivered, domain.OrderStatusChange{Reason: "digital items delivered", Actor: "system"}).Return(nil)
+
+	// Execute
+	deliveries, err := fulfillmentService.DeliverOrder(ctx, order.ID)
+
+	// Assert: an order of digital lines only is delivered at once
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+	assert.Equal(t, []string{"AAAA-1111"}, deliveries[0].LicenseKeys)
+	assert.Empty(t, deliveries[0].DownloadURL)
+	assert.True(t, strings.HasPrefix(deliveries[1].DownloadURL, "https://shop.example.com/api/v1/downloads/2/4?"))
+	mockChannel.AssertNumberOfCalls(t, "Send", 1)
+	mockOrderRepo.AssertExpectations(t)
+
+	// The signed link resolves to the file of the product
+	link, err := url.Parse(deliveries[1].DownloadURL)
+	require.NoError(t, err)
+	expires, err := strconv.ParseInt(link.Query().Get("expires"), 10, 64)
+	require.NoError(t, err)
+	path, err := fulfillmentService.ResolveDownload(ctx, 2, 4, expires, link.Query().Get("signature"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(storageDir, "5", "soundtrack.zip"), path)
+}
+
+func TestDeliverOrderWithPhysicalLine(t *testing.T) {
+	// Setup
+	mockDeliveryRepo := new(MockDigitalDeliveryRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	mockChannel := new(MockChannel)
+	fulfillmentService := newDigitalFulfillmentService(mockDeliveryRepo, new(MockProductRepository), mockOrderRepo, mockChannel, t.TempDir())
+	ctx := context.Background()
+
+	order := digitalOrder()
+	order.Items = append(order.Items[:1], domain.OrderItem{ID: 6, OrderID: 2, ProductID: 8, Quantity: 1, ProductType: domain.ProductTypePhysical})
+	mockOrderRepo.On("FindByID", ctx, order.ID).Return(order, nil)
+	mockDeliveryRepo.On("DeliverItem", ctx, &order.Items[0], mock.AnythingOfType("time.Time")).Return([]domain.LicenseKey{{ID: 7, Key: "AAAA-1111"}}, nil)
+	mockChannel.On("Send", ctx, mock.Anything).Return(nil)
+
+	// Execute
+	deliveries, err := fulfillmentService.DeliverOrder(ctx, order.ID)
+
+	// Assert: the order still has to be shipped
+	require.NoError(t, err)
+	assert.Len(t, deliveries, 1)
+	mockOrderRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDeliverPaidOrder(t *testing.T) {
+	ctx := context.Background()
+
+	// Orders held or shipped in part after payment still get their keys
+	for _, status := range []domain.OrderStatus{domain.OrderStatusOnHold, domain.OrderStatusPartiallyShipped} {
+		t.Run(string(status), func(t *testing.T) {
+			// Setup
+			mockDeliveryRepo := new(MockDigitalDeliveryRepository)
+			mockOrderRepo := new(MockOrderRepository)
+			mockChannel := new(MockChannel)
+			fulfillmentService := newDigitalFulfillmentService(mockDeliveryRepo, new(MockProductRepository), mockOrderRepo, mockChannel, t.TempDir())
+			order := digitalOrder()
+			order.Status = status
+			order.Items = append(order.Items[:1], domain.OrderItem{ID: 6, OrderID: 2, ProductID: 8, Quantity: 1, ProductType: domain.ProductTypePhysical})
+			mockOrderRepo.On("FindByID", ctx, order.ID).Return(order, nil)
+			mockDeliveryRepo.On("DeliverItem", ctx, &order.Items[0], mock.AnythingOfType("time.Time")).Return([]domain.LicenseKey{{ID: 7, Key: "AAAA-1111"}}, nil)
+			mockChannel.On("Send", ctx, mock.Anything).Return(nil)
+
+			// Execute
+			deliveries, err := fulfillmentService.DeliverOrder(ctx, order.ID)
+
+			// Assert
+			require.NoError(t, err)
+			require.Len(t, deliveries, 1)
+			assert.Equal(t, []string{"AAAA-1111"}, deliveries[0].LicenseKeys)
+		})
+	}
+}
+
+func TestDeliverOrderTwice(t *testing.T) {
+	// Setup
+	mockDeliveryRepo := new(MockDigitalDeliveryRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	mockChannel := new(MockChannel)
+	fulfillmentService := newDigitalFulfillmentService(mockDeliveryRepo, new(MockProductRepository), mockOrderRepo, mockChannel, t.TempDir())
+	ctx := context.Background()
+
+	deliveredAt := time.Now().Add(-time.Hour)
+	order := digitalOrder()
+	order.Status = domain.OrderStatusDelivered
+	order.Items = order.Items[:1]
+	order.Items[0].DeliveredAt = &deliveredAt
+	mockOrderRepo.On("FindByID", ctx, order.ID).Return(order, nil)
+	mockDeliveryRepo.On("DeliverItem", ctx, &order.Items[0], mock.AnythingOfType("time.Time")).Return([]domain.LicenseKey{{ID: 7, Key: "AAAA-1111"}}, nil)
+
+	// Execute
+	deliveries, err := fulfillmentService.DeliverOrder(ctx, order.ID)
+
+	// Assert: the same keys come back and the customer is not told again
+	require.NoError(t, err)
+	assert.Equal(t, []string{"AAAA-1111"}, deliveries[0].LicenseKeys)
+	assert.Equal(t, &deliveredAt, deliveries[0].DeliveredAt)
+	mockChannel.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	mockOrderRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDeliverOrderFailures(t *testing.T) {
+	ctx := context.Background()
+
+	unpaid := []struct {
+		name          string
+		status        domain.OrderStatus
+		paymentStatus domain.PaymentStatus
+	}{
+		{name: "Unpaid order", status: domain.OrderStatusPending, paymentStatus: domain.PaymentStatusPending},
+		{name: "Order held before payment", status: domain.OrderStatusOnHold, paymentStatus: domain.PaymentStatusPending},
+		{name: "Refunded order", status: domain.OrderStatusRefunded, paymentStatus: domain.PaymentStatusRefunded},
+		{name: "Cancelled order", status: domain.OrderStatusCancelled, paymentStatus: domain.PaymentStatusCompleted},
+	}
+	for _, tt := range unpaid {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockDeliveryRepo := new(MockDigitalDeliveryRepository)
+			mockOrderRepo := new(MockOrderRepository)
+			fulfillmentService := service.NewDigitalFulfillmentService(mockDeliveryRepo, new(MockProductRepository), mockOrderRepo, paymentsWithStatus(tt.paymentStatus), nil, nil, nil, nil, t.TempDir(), "https://shop.example.com/", "secret", time.Hour)
+			order := digitalOrder()
+			order.Status = tt.status
+			mockOrderRepo.On("FindByID", ctx, order.ID).Return(order, nil)
+
+			// Execute
+			_, err := fulfillmentService.DeliverOrder(ctx, order.ID)
+
+			// Assert
+			assert.EqualError(t, err, "order has not been paid")
+			mockDeliveryRepo.AssertNotCalled(t, "DeliverItem", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("Key pool empty", func(t *testing.T) {
+		// Setup
+		mockDeliveryRepo := new(MockDigitalDeliveryRepository)
+		mockProductRepo := new(MockProductRepository)
+		mockOrderRepo := new(MockOrderRepository)
+		mockChannel := new(MockChannel)
+		fulfillmentService := newDigitalFulfillmentService(mockDeliveryRepo, mockProductRepo, mockOrderRepo, mockChannel, t.TempDir())
+		order := digitalOrder()
+		mockOrderRepo.On("FindByID", ctx, order.ID).Return(order, nil)
+		mockProductRepo.On("FindByID", ctx, uint(5)).Return(&domain.Product{ID: 5, DownloadFile: "5/soundtrack.zip"}, nil)
+		mockDeliveryRepo.On("DeliverItem", ctx, &order.Items[0], mock.AnythingOfType("time.Time")).Return(nil, errors.New("not enough license keys for product: Editor"))
+		mockDeliveryRepo.On("DeliverItem", ctx, &order.Items[1], mock.AnythingOfType("time.Time")).Return([]domain.LicenseKey{}, nil)
+		mockChannel.On("Send", ctx, mock.Anything).Return(nil)
+
+		// Execute
+		deliveries, err := fulfillmentService.DeliverOrder(ctx, order.ID)
+
+		// Assert: the download is still delivered, but the order is not done
+		assert.EqualError(t, err, "not enough license keys for product: Editor")
+		assert.Len(t, deliveries, 1)
+		mockOrderRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Download file missing", func(t *testing.T) {
+		// Setup
+		mockDeliveryRepo := new(MockDigitalDeliveryRepository)
+		mockProductRepo := new(MockProductRepository)
+		mockOrderRepo := new(MockOrderRepository)
+		fulfillmentService := newDigitalFulfillmentService(mockDeliveryRepo, mockProductRepo, mockOrderRepo, nil, t.TempDir())
+		order := digitalOrder()
+		order.Items = order.Items[1:]
+		mockOrderRepo.On("FindByID", ctx, order.ID).Return(order, nil)
+		mockProductRepo.On("FindByID", ctx, uint(5)).Return(&domain.Product{ID: 5}, nil)
+
+		// Execute
+		_, err := fulfillmentService.DeliverOrder(ctx, order.ID)
+
+		// Assert
+		assert.EqualError(t, err, "no download file for product: Soundtrack")
+		mockDeliveryRepo.AssertNotCalled(t, "DeliverItem", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestResolveDownloadRejectsBadLinks(t *testing.T) {
+	ctx := context.Background()
+
+	deliveredAt := time.Now()
+	order := digitalOrder()
+	order.Items[1].DeliveredAt = &deliveredAt
+
+	// signedLink returns the expiry and signature of the download link of a
+	// service whose links stay valid for ttl
+	signedLink := func(fulfillmentService service.DigitalFulfillmentService) (int64, string) {
+		deliveries, err := fulfillmentService.GetOrderDeliveries(ctx, order.ID)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		link, err := url.Parse(deliveries[0].DownloadURL)
+		require.NoError(t, err)
+		expires, err := strconv.ParseInt(link.Query().Get("expires"), 10, 64)
+		require.NoError(t, err)
+		return expires, link.Query().Get("signature")
+	}
+
+	mockDeliveryRepo := new(MockDigitalDeliveryRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	mockOrderRepo.On("FindByID", ctx, order.ID).Return(order, nil)
+	mockDeliveryRepo.On("FindLicenseKeysByOrderID", ctx, order.ID).Return([]domain.LicenseKey{}, nil)
+	fulfillmentService := newDigitalFulfillmentService(mockDeliveryRepo, new(MockProductRepository), mockOrderRepo, nil, t.TempDir())
+	expires, signature := signedLink(fulfillmentService)
+
+	// Links signed by a service whose links expire at once
+	expiredService := service.NewDigitalFulfillmentService(mockDeliveryRepo, new(MockProductRepository), mockOrderRepo, paymentsWithStatus(domain.PaymentStatusCompleted), nil, nil, nil, nil, t.TempDir(), "https://shop.example.com", "secret", -time.Minute)
+	expiredAt, expiredSignature := signedLink(expiredService)
+
+	tests := []struct {
+		name      string
+		itemID    uint
+		expires   int64
+		signature string
+		wantErr   string
+	}{
+		{name: "Tampered expiry", itemID: 4, expires: expires + 3600, signature: signature, wantErr: "invalid download link"},
+		{name: "Other line", itemID: 3, expires: expires, signature: signature, wantErr: "invalid download link"},
+		{name: "Missing signature", itemID: 4, expires: expires, wantErr: "invalid download link"},
+		{name: "Expired", itemID: 4, expires: expiredAt, signature: expiredSignature, wantErr: "download link has expired"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute
+			_, err := fulfillmentService.ResolveDownload(ctx, order.ID, tt.itemID, tt.expires, tt.signature)
+
+			// Assert
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestCreateOrderShippingAddress(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		product *domain.Product
+		wantErr string
+	}{
+		{
+			name:    "Physical product needs an address",
+			product: &domain.Product{ID: 1, Name: "Book", Price: usd(1000), Stock: 5, Type: domain.ProductTypePhysical},
+			wantErr: "shipping address is required",
+		},
+		{
+			name:    "Download needs no address nor stock",
+			product: &domain.Product{ID: 1, Name: "Soundtrack", Price: usd(1000), Type: domain.ProductTypeDigital, DeliveryMethod: domain.DeliveryMethodDownload},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockOrderRepo := new(MockOrderRepository)
+			mockCartRepo := new(MockCartRepository)
+			mockProductRepo := new(MockProductRepository)
+			mockUserRepo := new(MockUserRepository)
+			orderService := service.NewOrderService(mockOrderRepo, mockCartRepo, mockProductRepo, mockUserRepo, nil)
+
+			cart := &domain.Cart{ID: 1, UserID: 1, Items: []domain.CartItem{{ProductID: 1, Quantity: 2}}}
+			mockUserRepo.On("FindByID", ctx, uint(1)).Return(&domain.User{ID: 1}, nil)
+			mockCartRepo.On("FindByUserID", ctx, uint(1)).Return(cart, nil)
+			mockProductRepo.On("FindByID", ctx, uint(1)).Return(tt.product, nil)
+			mockOrderRepo.On("Create", ctx, mock.AnythingOfType("*domain.Order")).Return(nil)
+			mockCartRepo.On("ClearCart", ctx, cart.ID).Return(nil)
+
+			// Execute
+			order, err := orderService.CreateOrder(ctx, 1, "", "1 Billing St", 0)
+
+			// Assert
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				mockOrderRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, domain.DeliveryMethodDownload, order.Items[0].DeliveryMethod)
+			assert.Equal(t, 0, order.Items[0].InStockQuantity())
+			mockProductRepo.AssertNotCalled(t, "DecrementStock", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestCreateDigitalProduct(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		product domain.Product
+		wantErr string
+	}{
+		{name: "Defaults to physical", product: domain.Product{Name: "Book"}},
+		{name: "Physical with delivery method", product: domain.Product{Type: domain.ProductTypePhysical, DeliveryMethod: domain.DeliveryMethodDownload}, wantErr: "only digital products have a delivery method"},
+		{name: "Digital without delivery method", product: domain.Product{Type: domain.ProductTypeDigital}, wantErr: "invalid delivery method"},
+		{name: "Digital backordered", product: domain.Product{Type: domain.ProductTypeDigital, DeliveryMethod: domain.DeliveryMethodLicenseKey, BackorderPolicy: domain.BackorderPolicyUnlimited}, wantErr: "digital products cannot be backordered"},
+		{name: "Unknown type", product: domain.Product{Type: "service"}, wantErr: "invalid product type"},
+		{name: "License key", product: domain.Product{Type: domain.ProductTypeDigital, DeliveryMethod: domain.DeliveryMethodLicenseKey}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockRepo := new(MockProductRepository)
+			productService := service.NewProductService(mockRepo)
+			mockRepo.On("Create", ctx, &tt.product).Return(nil)
+
+			// Execute
+			err := productService.CreateProduct(ctx, &tt.product)
+
+			// Assert
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotEqual(t, domain.ProductType(""), tt.product.Type)
+		})
+	}
+}
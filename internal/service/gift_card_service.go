@@ -0,0 +1,397 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+)
+
+// giftCardCodeAlphabet leaves out characters easily mistaken for one another
+const giftCardCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// giftCardCodeLength is the number of characters of a generated gift card code
+const giftCardCodeLength = 16
+
+// GiftCardService defines the interface for gift card and store credit business logic
+type GiftCardService interface {
+	// IssueGiftCard issues a gift card worth value with a new code
+	IssueGiftCard(ctx context.Context, value domain.Money, recipientEmail string, expiresAt *time.Time) (*domain.GiftCard, error)
+
+	// GetGiftCards retrieves all gift cards with pagination
+	GetGiftCards(ctx context.Context, page, pageSize int) ([]domain.GiftCard, int64, error)
+
+	// GetGiftCardByID retrieves a gift card by its ID
+	GetGiftCardByID(ctx context.Context, id uint) (*domain.GiftCard, error)
+
+	// GetGiftCardByCode retrieves a gift card by its code, in any case and with spaces or dashes
+	GetGiftCardByCode(ctx context.Context, code string) (*domain.GiftCard, error)
+
+	// UpdateGiftCard updates whether a gift card is active, its expiry and its recipient
+	UpdateGiftCard(ctx context.Context, id uint, active bool, expiresAt *time.Time, recipientEmail string) (*domain.GiftCard, error)
+
+	// GetGiftCardLedger retrieves the balance movements of a gift card
+	GetGiftCardLedger(ctx context.Context, id uint) ([]domain.BalanceEntry, error)
+
+	// IssuePurchasedGiftCards issues the gift cards bought with an order line,
+	// one per unit worth the price paid. Lines issued before are not issued again.
+	IssuePurchasedGiftCards(ctx context.Context, order *domain.Order, item *domain.OrderItem) ([]domain.GiftCard, error)
+
+	// GetPurchasedGiftCards retrieves the gift cards bought with an order line
+	GetPurchasedGiftCards(ctx context.Context, orderItemID uint) ([]domain.GiftCard, error)
+
+	// GetStoreCredit retrieves the store credit of a user, one account per currency
+	GetStoreCredit(ctx context.Context, userID uint) ([]domain.StoreCreditAccount, error)
+
+	// GetStoreCreditLedger retrieves the balance movements of the store credit of a user
+	GetStoreCreditLedger(ctx context.Context, userID uint) ([]domain.BalanceEntry, error)
+
+	// AdjustStoreCredit credits a positive or debits a negative amount to the store credit of a user
+	AdjustStoreCredit(ctx context.Context, userID uint, delta domain.Money, note string) (*domain.StoreCreditAccount, error)
+
+	// CreditRefund credits the refunded amount of an order to the store credit of its customer
+	CreditRefund(ctx context.Context, order *domain.Order, amount domain.Money) error
+
+	// RedeemTenders takes the requested parts of the total paid for an order
+	// from gift cards and store credit, all or nothing
+	RedeemTenders(ctx context.Context, order *domain.Order, total domain.Money, requests []domain.TenderRequest) ([]domain.PaymentTender, error)
+
+	// RestoreTenders returns the parts of a payment taken from gift cards and
+	// store credit to where they were taken from
+	RestoreTenders(ctx context.Context, order *domain.Order, tenders []domain.PaymentTender, reason domain.BalanceReason) error
+}
+
+// GiftCardServiceImpl implements the GiftCardService interface
+type GiftCardServiceImpl struct {
+	giftCardRepo    repository.GiftCardRepository
+	storeCreditRepo repository.StoreCreditRepository
+}
+
+// NewGiftCardService creates a new GiftCardServiceImpl
+func NewGiftCardService(giftCardRepo repository.GiftCardRepository, storeCreditRepo repository.StoreCreditRepository) GiftCardService {
+	return &GiftCardServiceImpl{
+		giftCardRepo:    giftCardRepo,
+		storeCreditRepo: storeCreditRepo,
+	}
+}
+
+// IssueGiftCard issues a gift card worth value with a new code
+func (s *GiftCardServiceImpl) IssueGiftCard(ctx context.Context, value domain.Money, recipientEmail string, expiresAt *time.Time) (*domain.GiftCard, error) {
+	if !value.IsPositive() {
+		return nil, errors.New("gift card value must be greater than zero")
+	}
+	if err := checkCurrency(value.Currency); err != nil {
+		return nil, err
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, errors.New("gift card expiry must be in the future")
+	}
+
+	card := &domain.GiftCard{
+		InitialValue:   value,
+		Balance:        value,
+		Source:         domain.GiftCardSourceAdmin,
+		RecipientEmail: recipientEmail,
+		ExpiresAt:      expiresAt,
+		Active:         true,
+	}
+	if err := s.createGiftCard(ctx, card, 0); err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+// GetGiftCards retrieves all gift cards with pagination
+func (s *GiftCardServiceImpl) GetGiftCards(ctx context.Context, page, pageSize int) ([]domain.GiftCard, int64, error) {
+	return s.giftCardRepo.FindAll(ctx, page, pageSize)
+}
+
+// GetGiftCardByID retrieves a gift card by its ID
+func (s *GiftCardServiceImpl) GetGiftCardByID(ctx context.Context, id uint) (*domain.GiftCard, error) {
+	card, err := s.giftCardRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("gift card not found")
+	}
+	return card, nil
+}
+
+// GetGiftCardByCode retrieves a gift card by its code, in any case and with spaces or dashes
+func (s *GiftCardServiceImpl) GetGiftCardByCode(ctx context.Context, code string) (*domain.GiftCard, error) {
+	card, err := s.giftCardRepo.FindByCode(ctx, domain.NormalizeGiftCardCode(code))
+	if err != nil {
+		return nil, errors.New("gift card not found")
+	}
+	return card, nil
+}
+
+// UpdateGiftCard updates whether a gift card is active, its expiry and its recipient
+func (s *GiftCardServiceImpl) UpdateGiftCard(ctx context.Context, id uint, active bool, expiresAt *time.Time, recipientEmail string) (*domain.GiftCard, error) {
+	card, err := s.GetGiftCardByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	card.Active = active
+	card.ExpiresAt = expiresAt
+	card.RecipientEmail = recipientEmail
+	if err := s.giftCardRepo.Update(ctx, card); err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+// GetGiftCardLedger retrieves the balance movements of a gift card
+func (s *GiftCardServiceImpl) GetGiftCardLedger(ctx context.Context, id uint) ([]domain.BalanceEntry, error) {
+	if _, err := s.GetGiftCardByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.giftCardRepo.FindLedger(ctx, id)
+}
+
+// IssuePurchasedGiftCards issues the gift cards bought with an order line, one
+// per unit worth the price paid in the currency of the order. Lines issued
+// before are not issued again, so failed deliveries can be retried.
+func (s *GiftCardServiceImpl) IssuePurchasedGiftCards(ctx context.Context, order *domain.Order, item *domain.OrderItem) ([]domain.GiftCard, error) {
+	cards, err := s.giftCardRepo.FindByOrderItemID(ctx, item.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for len(cards) < item.Quantity {
+		card := domain.GiftCard{
+			InitialValue: item.Price,
+			Balance:      item.Price,
+			Source:       domain.GiftCardSourcePurchase,
+			OrderID:      &order.ID,
+			OrderItemID:  &item.ID,
+			Active:       true,
+		}
+		if err := s.createGiftCard(ctx, &card, order.ID); err != nil {
+			return nil, err
+		}
+		cards = append(cards, card)
+	}
+	return cards, nil
+}
+
+// GetPurchasedGiftCards retrieves the gift cards bought with an order line
+func (s *GiftCardServiceImpl) GetPurchasedGiftCards(ctx context.Context, orderItemID uint) ([]domain.GiftCard, error) {
+	return s.giftCardRepo.FindByOrderItemID(ctx, orderItemID)
+}
+
+// GetStoreCredit retrieves the store credit of a user, one account per currency
+func (s *GiftCardServiceImpl) GetStoreCredit(ctx context.Context, userID uint) ([]domain.StoreCreditAccount, error) {
+	return s.storeCreditRepo.FindByUserID(ctx, userID)
+}
+
+// GetStoreCreditLedger retrieves the balance movements of the store credit of a user
+func (s *GiftCardServiceImpl) GetStoreCreditLedger(ctx context.Context, userID uint) ([]domain.BalanceEntry, error) {
+	return s.storeCreditRepo.FindLedger(ctx, userID)
+}
+
+// AdjustStoreCredit credits a positive or debits a negative amount to the
+// store credit of a user. Debits never take the balance below zero.
+func (s *GiftCardServiceImpl) AdjustStoreCredit(ctx context.Context, userID uint, delta domain.Money, note string) (*domain.StoreCreditAccount, error) {
+	if delta.Amount == 0 {
+		return nil, errors.New("adjustment must not be zero")
+	}
+	if err := checkCurrency(delta.Currency); err != nil {
+		return nil, err
+	}
+
+	account, err := s.storeCreditRepo.Adjust(ctx, userID, delta, balanceChange(ctx, domain.BalanceReasonAdjustment, 0, note))
+	if errors.Is(err, domain.ErrInsufficientBalance) {
+		return nil, errors.New("insufficient store credit")
+	}
+	return account, err
+}
+
+// CreditRefund credits the refunded amount of an order to the store credit of its customer
+func (s *GiftCardServiceImpl) CreditRefund(ctx context.Context, order *domain.Order, amount domain.Money) error {
+	if !amount.IsPositive() {
+		return nil
+	}
+	_, err := s.storeCreditRepo.Adjust(ctx, order.UserID, amount, balanceChange(ctx, domain.BalanceReasonRefund, order.ID, ""))
+	return err
+}
+
+// RedeemTenders takes the requested parts of the total paid for an order from
+// gift cards and store credit, in the order requested and never more than the
+// total. A request without an amount takes as much as the balance covers. If
+// any request cannot be met, the parts already taken are returned.
+func (s *GiftCardServiceImpl) RedeemTenders(ctx context.Context, order *domain.Order, total domain.Money, requests []domain.TenderRequest) ([]domain.PaymentTender, error) {
+	now := time.Now()
+	remaining := total
+	var tenders []domain.PaymentTender
+	for _, request := range requests {
+		if !remaining.IsPositive() {
+			break
+		}
+
+		tender, err := s.redeemTender(ctx, order, request, remaining, now)
+		if err != nil {
+			// Return what was taken so a failed payment never keeps a balance
+			if restoreErr := s.RestoreTenders(ctx, order, tenders, domain.BalanceReasonReversal); restoreErr != nil {
+				return nil, errors.Join(err, restoreErr)
+			}
+			return nil, err
+		}
+		tenders = append(tenders, *tender)
+		if remaining, err = remaining.Subtract(tender.Amount); err != nil {
+			return nil, err
+		}
+	}
+	return tenders, nil
+}
+
+// redeemTender takes a part of at most remaining from a gift card or store credit
+func (s *GiftCardServiceImpl) redeemTender(ctx context.Context, order *domain.Order, request domain.TenderRequest, remaining domain.Money, now time.Time) (*domain.PaymentTender, error) {
+	if request.Amount.Amount < 0 {
+		return nil, errors.New("tender amount must be greater than zero")
+	}
+	change := balanceChange(ctx, domain.BalanceReasonRedemption, order.ID, "")
+
+	switch request.Method {
+	case domain.PaymentMethodGiftCard:
+		card, err := s.GetGiftCardByCode(ctx, request.GiftCardCode)
+		if err != nil {
+			return nil, err
+		}
+		if !card.IsRedeemable(now) {
+			return nil, errors.New("gift card cannot be redeemed")
+		}
+		if card.Balance.Currency != remaining.Currency {
+			return nil, errors.New("gift card currency does not match order currency")
+		}
+
+		amount, err := tenderAmount(request.Amount, card.Balance, remaining)
+		if err != nil {
+			return nil, errors.New("insufficient gift card balance")
+		}
+		if _, err := s.giftCardRepo.Adjust(ctx, card.ID, amount.Multiply(-1), change); err != nil {
+			if errors.Is(err, domain.ErrInsufficientBalance) {
+				return nil, errors.New("insufficient gift card balance")
+			}
+			return nil, err
+		}
+		return &domain.PaymentTender{
+			Method:     domain.PaymentMethodGiftCard,
+			GiftCardID: &card.ID,
+			Amount:     amount,
+		}, nil
+
+	case domain.PaymentMethodStoreCredit:
+		account, err := s.storeCreditRepo.FindAccount(ctx, order.UserID, remaining.Currency)
+		if err != nil {
+			return nil, errors.New("insufficient store credit")
+		}
+
+		amount, err := tenderAmount(request.Amount, account.Balance, remaining)
+		if err != nil {
+			return nil, errors.New("insufficient store credit")
+		}
+		account, err = s.storeCreditRepo.Adjust(ctx, order.UserID, amount.Multiply(-1), change)
+		if err != nil {
+			if errors.Is(err, domain.ErrInsufficientBalance) {
+				return nil, errors.New("insufficient store credit")
+			}
+			return nil, err
+		}
+		return &domain.PaymentTender{
+			Method:               domain.PaymentMethodStoreCredit,
+			StoreCreditAccountID: &account.ID,
+			Amount:               amount,
+		}, nil
+	}
+
+	return nil, errors.New("invalid tender method")
+}
+
+// RestoreTenders returns the parts of a payment taken from gift cards and
+// store credit to where they were taken from. Gift cards get their balance
+// back even once deactivated or expired.
+func (s *GiftCardServiceImpl) RestoreTenders(ctx context.Context, order *domain.Order, tenders []domain.PaymentTender, reason domain.BalanceReason) error {
+	change := balanceChange(ctx, reason, order.ID, "")
+
+	var errs []error
+	for _, tender := range tenders {
+		var err error
+		switch {
+		case tender.GiftCardID != nil:
+			_, err = s.giftCardRepo.Adjust(ctx, *tender.GiftCardID, tender.Amount, change)
+		case tender.StoreCreditAccountID != nil:
+			_, err = s.storeCreditRepo.Adjust(ctx, order.UserID, tender.Amount, change)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tender %s %s: %w", tender.Method, tender.Amount.Number(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// createGiftCard creates a gift card with a new code, drawing another code in
+// the unlikely case the first one is taken
+func (s *GiftCardServiceImpl) createGiftCard(ctx context.Context, card *domain.GiftCard, orderID uint) error {
+	change := balanceChange(ctx, domain.BalanceReasonIssue, orderID, "")
+
+	var err error
+	for attempt := 0; attempt < 3; attempt++ {
+		card.Code, err = newGiftCardCode()
+		if err != nil {
+			return err
+		}
+		if _, findErr := s.giftCardRepo.FindByCode(ctx, card.Code); findErr == nil {
+			continue
+		}
+		return s.giftCardRepo.Create(ctx, card, change)
+	}
+	return errors.New("failed to generate a gift card code")
+}
+
+// checkCurrency checks that a balance is held in a valid currency
+func checkCurrency(currency string) error {
+	if !currencyCodePattern.MatchString(currency) {
+		return errors.New("invalid currency code")
+	}
+	return nil
+}
+
+// tenderAmount works out how much a tender takes from a balance: the amount
+// requested, or without one as much as the balance covers, never more than remaining
+func tenderAmount(requested, balance, remaining domain.Money) (domain.Money, error) {
+	amount := balance
+	if requested.IsPositive() {
+		if requested.Amount > balance.Amount {
+			return domain.Money{}, domain.ErrInsufficientBalance
+		}
+		amount.Amount = requested.Amount
+	}
+	if amount.Amount > remaining.Amount {
+		amount.Amount = remaining.Amount
+	}
+	if !amount.IsPositive() {
+		return domain.Money{}, domain.ErrInsufficientBalance
+	}
+	return amount, nil
+}
+
+// balanceChange describes a balance change made by the actor of ctx
+func balanceChange(ctx context.Context, reason domain.BalanceReason, referenceID uint, note string) domain.BalanceChange {
+	return domain.BalanceChange{Reason: reason, ReferenceID: referenceID, Actor: actorFromContext(ctx), Note: note}
+}
+
+// newGiftCardCode generates a random gift card code
+func newGiftCardCode() (string, error) {
+	b := make([]byte, giftCardCodeLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = giftCardCodeAlphabet[int(b[i])%len(giftCardCodeAlphabet)]
+	}
+	return string(b), nil
+}
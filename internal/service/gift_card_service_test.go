@@ -0,0 +1,509 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockGiftCardRepository struct {
+	mock.Mock
+}
+
+func (m *MockGiftCardRepository) FindByID(ctx context.Context, id uint) (*domain.GiftCard, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.GiftCard), args.Error(1)
+}
+
+func (m *MockGiftCardRepository) FindByCode(ctx context.Context, code string) (*domain.GiftCard, error) {
+	args := m.Called(ctx, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.GiftCard), args.Error(1)
+}
+
+func (m *MockGiftCardRepository) FindByOrderItemID(ctx context.Context, orderItemID uint) ([]domain.GiftCard, error) {
+	args := m.Called(ctx, orderItemID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.GiftCard), args.Error(1)
+}
+
+func (m *MockGiftCardRepository) FindAll(ctx context.Context, page, pageSize int) ([]domain.GiftCard, int64, error) {
+	args := m.Called(ctx, page, pageSize)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]domain.GiftCard), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockGiftCardRepository) Create(ctx context.Context, card *domain.GiftCard, change domain.BalanceChange) error {
+	args := m.Called(ctx, card, change)
+	return args.Error(0)
+}
+
+func (m *MockGiftCardRepository) Update(ctx context.Context, card *domain.GiftCard) error {
+	args := m.Called(ctx, card)
+	return args.Error(0)
+}
+
+func (m *MockGiftCardRepository) Adjust(ctx context.Context, id uint, delta domain.Money, change domain.BalanceChange) (*domain.GiftCard, error) {
+	args := m.Called(ctx, id, delta, change)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.GiftCard), args.Error(1)
+}
+
+func (m *MockGiftCardRepository) FindLedger(ctx context.Context, id uint) ([]domain.BalanceEntry, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.BalanceEntry), args.Error(1)
+}
+
+type MockStoreCreditRepository struct {
+	mock.Mock
+}
+
+func (m *MockStoreCreditRepository) FindByUserID(ctx context.Context, userID uint) ([]domain.StoreCreditAccount, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.StoreCreditAccount), args.Error(1)
+}
+
+func (m *MockStoreCreditRepository) FindAccount(ctx context.Context, userID uint, currency string) (*domain.StoreCreditAccount, error) {
+	args := m.Called(ctx, userID, currency)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.StoreCreditAccount), args.Error(1)
+}
+
+func (m *MockStoreCreditRepository) Adjust(ctx context.Context, userID uint, delta domain.Money, change domain.BalanceChange) (*domain.StoreCreditAccount, error) {
+	args := m.Called(ctx, userID, delta, change)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.StoreCreditAccount), args.Error(1)
+}
+
+func (m *MockStoreCreditRepository) FindLedger(ctx context.Context, userID uint) ([]domain.BalanceEntry, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.BalanceEntry), args.Error(1)
+}
+
+// giftCardOrder is an order of $100.00 placed by user 2
+func giftCardOrder() *domain.Order {
+	return &domain.Order{ID: 1, UserID: 2, Status: domain.OrderStatusPending, TotalAmount: usd(10000)}
+}
+
+// redeemableGiftCard is an active gift card holding balance
+func redeemableGiftCard(balance domain.Money) *domain.GiftCard {
+	return &domain.GiftCard{ID: 5, Code: "ABCDEFGHJKLMNPQR", InitialValue: balance, Balance: balance, Active: true}
+}
+
+func balanceChangeOf(reason domain.BalanceReason, referenceID uint) domain.BalanceChange {
+	return domain.BalanceChange{Reason: reason, ReferenceID: referenceID, Actor: "system"}
+}
+
+func TestIssueGiftCard(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		// Setup
+		mockGiftCardRepo := new(MockGiftCardRepository)
+		giftCardService := service.NewGiftCardService(mockGiftCardRepo, new(MockStoreCreditRepository))
+
+		mockGiftCardRepo.On("FindByCode", ctx, mock.AnythingOfType("string")).Return(nil, errors.New("record not found"))
+		mockGiftCardRepo.On("Create", ctx, mock.AnythingOfType("*domain.GiftCard"), balanceChangeOf(domain.BalanceReasonIssue, 0)).Return(nil)
+
+		// Execute
+		card, err := giftCardService.IssueGiftCard(ctx, usd(5000), "friend@example.com", nil)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Len(t, card.Code, 16)
+		assert.Equal(t, card.Code, domain.NormalizeGiftCardCode(card.Code))
+		assert.Equal(t, usd(5000), card.Balance)
+		assert.Equal(t, usd(5000), card.InitialValue)
+		assert.Equal(t, domain.GiftCardSourceAdmin, card.Source)
+		assert.True(t, card.Active)
+		mockGiftCardRepo.AssertExpectations(t)
+	})
+
+	t.Run("Rejects invalid cards", func(t *testing.T) {
+		mockGiftCardRepo := new(MockGiftCardRepository)
+		giftCardService := service.NewGiftCardService(mockGiftCardRepo, new(MockStoreCreditRepository))
+		past := time.Now().Add(-time.Hour)
+
+		_, err := giftCardService.IssueGiftCard(ctx, usd(0), "", nil)
+		assert.EqualError(t, err, "gift card value must be greater than zero")
+
+		_, err = giftCardService.IssueGiftCard(ctx, domain.Money{Amount: 5000, Currency: "usd"}, "", nil)
+		assert.EqualError(t, err, "invalid currency code")
+
+		_, err = giftCardService.IssueGiftCard(ctx, usd(5000), "", &past)
+		assert.EqualError(t, err, "gift card expiry must be in the future")
+
+		mockGiftCardRepo.AssertNotCalled(t, "Create")
+	})
+}
+
+func TestIssuePurchasedGiftCards(t *testing.T) {
+	ctx := context.Background()
+	mockGiftCardRepo := new(MockGiftCardRepository)
+	giftCardService := service.NewGiftCardService(mockGiftCardRepo, new(MockStoreCreditRepository))
+
+	order := giftCardOrder()
+	item := &domain.OrderItem{ID: 3, OrderID: 1, Quantity: 2, Price: usd(2500), DeliveryMethod: domain.DeliveryMethodGiftCard}
+
+	// One card was issued by an earlier delivery that failed
+	issued := domain.GiftCard{ID: 8, Code: "ISSUEDBEFORE2345", Balance: usd(2500), OrderItemID: uintPtr(3)}
+	mockGiftCardRepo.On("FindByOrderItemID", ctx, uint(3)).Return([]domain.GiftCard{issued}, nil)
+	mockGiftCardRepo.On("FindByCode", ctx, mock.AnythingOfType("string")).Return(nil, errors.New("record not found"))
+	mockGiftCardRepo.On("Create", ctx, mock.AnythingOfType("*domain.GiftCard"), balanceChangeOf(domain.BalanceReasonIssue, 1)).Return(nil).Once()
+
+	// Execute
+	cards, err := giftCardService.IssuePurchasedGiftCards(ctx, order, item)
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, cards, 2)
+	assert.Equal(t, "ISSUEDBEFORE2345", cards[0].Code)
+	assert.Equal(t, usd(2500), cards[1].Balance)
+	assert.Equal(t, domain.GiftCardSourcePurchase, cards[1].Source)
+	assert.Equal(t, uintPtr(3), cards[1].OrderItemID)
+	mockGiftCardRepo.AssertExpectations(t)
+}
+
+func TestRedeemTenders(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Takes gift cards then store credit up to the order total", func(t *testing.T) {
+		// Setup
+		mockGiftCardRepo := new(MockGiftCardRepository)
+		mockStoreCreditRepo := new(MockStoreCreditRepository)
+		giftCardService := service.NewGiftCardService(mockGiftCardRepo, mockStoreCreditRepo)
+
+		card := redeemableGiftCard(usd(3000))
+		account := &domain.StoreCreditAccount{ID: 9, UserID: 2, Currency: "USD", Balance: usd(20000)}
+		redemption := balanceChangeOf(domain.BalanceReasonRedemption, 1)
+
+		mockGiftCardRepo.On("FindByCode", ctx, "ABCDEFGHJKLMNPQR").Return(card, nil)
+		mockGiftCardRepo.On("Adjust", ctx, uint(5), usd(-3000), redemption).Return(card, nil).Once()
+		mockStoreCreditRepo.On("FindAccount", ctx, uint(2), "USD").Return(account, nil)
+		mockStoreCreditRepo.On("Adjust", ctx, uint(2), usd(-7000), redemption).Return(account, nil).Once()
+
+		// Execute
+		tenders, err := giftCardService.RedeemTenders(ctx, giftCardOrder(), usd(10000), []domain.TenderRequest{
+			{Method: domain.PaymentMethodGiftCard, GiftCardCode: "abcd-efgh-jklm-npqr"},
+			{Method: domain.PaymentMethodStoreCredit},
+		})
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, tenders, 2)
+		assert.Equal(t, domain.PaymentMethodGiftCard, tenders[0].Method)
+		assert.Equal(t, uintPtr(5), tenders[0].GiftCardID)
+		assert.Equal(t, usd(3000), tenders[0].Amount)
+		assert.Equal(t, domain.PaymentMethodStoreCredit, tenders[1].Method)
+		assert.Equal(t, uintPtr(9), tenders[1].StoreCreditAccountID)
+		assert.Equal(t, usd(7000), tenders[1].Amount)
+		mockGiftCardRepo.AssertExpectations(t)
+		mockStoreCreditRepo.AssertExpectations(t)
+	})
+
+	t.Run("Takes no more than the total paid", func(t *testing.T) {
+		// Setup
+		mockStoreCreditRepo := new(MockStoreCreditRepository)
+		giftCardService := service.NewGiftCardService(new(MockGiftCardRepository), mockStoreCreditRepo)
+
+		// The order's stored total is stale; the payment is for the computed total
+		order := giftCardOrder()
+		order.TotalAmount = usd(12000)
+		account := &domain.StoreCreditAccount{ID: 9, UserID: 2, Currency: "USD", Balance: usd(20000)}
+		mockStoreCreditRepo.On("FindAccount", ctx, uint(2), "USD").Return(account, nil)
+		mockStoreCreditRepo.On("Adjust", ctx, uint(2), usd(-10000), balanceChangeOf(domain.BalanceReasonRedemption, 1)).Return(account, nil).Once()
+
+		// Execute
+		tenders, err := giftCardService.RedeemTenders(ctx, order, usd(10000), []domain.TenderRequest{
+			{Method: domain.PaymentMethodStoreCredit},
+		})
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, tenders, 1)
+		assert.Equal(t, usd(10000), tenders[0].Amount)
+		mockStoreCreditRepo.AssertExpectations(t)
+	})
+
+	t.Run("Returns what was taken when a tender fails", func(t *testing.T) {
+		// Setup
+		mockGiftCardRepo := new(MockGiftCardRepository)
+		mockStoreCreditRepo := new(MockStoreCreditRepository)
+		giftCardService := service.NewGiftCardService(mockGiftCardRepo, mockStoreCreditRepo)
+
+		card := redeemableGiftCard(usd(3000))
+		mockGiftCardRepo.On("FindByCode", ctx, "ABCDEFGHJKLMNPQR").Return(card, nil)
+		mockGiftCardRepo.On("Adjust", ctx, uint(5), usd(-2000), balanceChangeOf(domain.BalanceReasonRedemption, 1)).Return(card, nil).Once()
+		mockStoreCreditRepo.On("FindAccount", ctx, uint(2), "USD").Return(nil, errors.New("record not found"))
+		mockGiftCardRepo.On("Adjust", ctx, uint(5), usd(2000), balanceChangeOf(domain.BalanceReasonReversal, 1)).Return(card, nil).Once()
+
+		// Execute
+		tenders, err := giftCardService.RedeemTenders(ctx, giftCardOrder(), usd(10000), []domain.TenderRequest{
+			{Method: domain.PaymentMethodGiftCard, GiftCardCode: "ABCDEFGHJKLMNPQR", Amount: usd(2000)},
+			{Method: domain.PaymentMethodStoreCredit},
+		})
+
+		// Assert
+		assert.EqualError(t, err, "insufficient store credit")
+		assert.Nil(t, tenders)
+		mockGiftCardRepo.AssertExpectations(t)
+	})
+
+	t.Run("Rejects gift cards that cannot pay for the order", func(t *testing.T) {
+		past := time.Now().Add(-time.Hour)
+		expired := redeemableGiftCard(usd(3000))
+		expired.ExpiresAt = &past
+		euros := redeemableGiftCard(domain.Money{Amount: 3000, Currency: "EUR"})
+
+		tests := []struct {
+			name string
+			card *domain.GiftCard
+			want string
+		}{
+			{"expired", expired, "gift card cannot be redeemed"},
+			{"other currency", euros, "gift card currency does not match order currency"},
+			{"not enough balance", redeemableGiftCard(usd(1000)), "insufficient gift card balance"},
+		}
+		for _, tt := range tests {
+			mockGiftCardRepo := new(MockGiftCardRepository)
+			giftCardService := service.NewGiftCardService(mockGiftCardRepo, new(MockStoreCreditRepository))
+			mockGiftCardRepo.On("FindByCode", ctx, "ABCDEFGHJKLMNPQR").Return(tt.card, nil)
+
+			_, err := giftCardService.RedeemTenders(ctx, giftCardOrder(), usd(10000), []domain.TenderRequest{
+				{Method: domain.PaymentMethodGiftCard, GiftCardCode: "ABCDEFGHJKLMNPQR", Amount: usd(2000)},
+			})
+
+			assert.EqualError(t, err, tt.want, tt.name)
+			mockGiftCardRepo.AssertNotCalled(t, "Adjust")
+		}
+	})
+}
+
+func TestAdjustStoreCredit(t *testing.T) {
+	ctx := context.Background()
+	mockStoreCreditRepo := new(MockStoreCreditRepository)
+	giftCardService := service.NewGiftCardService(new(MockGiftCardRepository), mockStoreCreditRepo)
+
+	adjustment := domain.BalanceChange{Reason: domain.BalanceReasonAdjustment, Actor: "user:1", Note: "goodwill"}
+	adminCtx := domain.WithUserID(ctx, 1)
+	account := &domain.StoreCreditAccount{ID: 9, UserID: 2, Currency: "USD", Balance: usd(1500)}
+	mockStoreCreditRepo.On("Adjust", adminCtx, uint(2), usd(1500), adjustment).Return(account, nil).Once()
+	mockStoreCreditRepo.On("Adjust", adminCtx, uint(2), usd(-5000), adjustment).Return(nil, domain.ErrInsufficientBalance).Once()
+
+	// Credits open or top up the account
+	result, err := giftCardService.AdjustStoreCredit(adminCtx, 2, usd(1500), "goodwill")
+	require.NoError(t, err)
+	assert.Equal(t, account, result)
+
+	// Debits never take the balance below zero
+	_, err = giftCardService.AdjustStoreCredit(adminCtx, 2, usd(-5000), "goodwill")
+	assert.EqualError(t, err, "insufficient store credit")
+
+	_, err = giftCardService.AdjustStoreCredit(adminCtx, 2, usd(0), "goodwill")
+	assert.EqualError(t, err, "adjustment must not be zero")
+
+	mockStoreCreditRepo.AssertExpectations(t)
+}
+
+func TestCreatePaymentWithGiftCard(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Gift card pays part and the method the rest", func(t *testing.T) {
+		// Setup
+		mockPaymentRepo := new(MockPaymentRepository)
+		mockOrderRepo := new(MockOrderRepository)
+		mockGiftCardRepo := new(MockGiftCardRepository)
+		giftCardService := service.NewGiftCardService(mockGiftCardRepo, new(MockStoreCreditRepository))
+		paymentService := service.NewPaymentService(mockPaymentRepo, mockOrderRepo, nil, nil, nil, nil, giftCardService, nil)
+
+		card := redeemableGiftCard(usd(3000))
+		mockOrderRepo.On("FindByID", ctx, uint(1)).Return(giftCardOrder(), nil)
+		mockPaymentRepo.On("FindByOrderID", ctx, uint(1)).Return(nil, errors.New("record not found"))
+		mockOrderRepo.On("GetOrderTotal", ctx, uint(1)).Return(usd(10000), nil)
+		mockGiftCardRepo.On("FindByCode", ctx, "ABCDEFGHJKLMNPQR").Return(card, nil)
+		mockGiftCardRepo.On("Adjust", ctx, uint(5), usd(-3000), balanceChangeOf(domain.BalanceReasonRedemption, 1)).Return(card, nil).Once()
+		mockPaymentRepo.On("Create", ctx, mock.AnythingOfType("*domain.Payment")).Return(nil)
+
+		// Execute
+		payment, err := paymentService.CreatePayment(ctx, 1, usd(10000), domain.PaymentMethodCreditCard, []domain.TenderRequest{
+			{Method: domain.PaymentMethodGiftCard, GiftCardCode: "ABCDEFGHJKLMNPQR"},
+		})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, domain.PaymentMethodCreditCard, payment.Method)
+		assert.Equal(t, usd(10000), payment.Amount)
+		require.Len(t, payment.Tenders, 1)
+		tenders, err := payment.TenderTotal()
+		require.NoError(t, err)
+		assert.Equal(t, usd(3000), tenders)
+		remainder, err := payment.Remainder()
+		require.NoError(t, err)
+		assert.Equal(t, usd(7000), remainder)
+		mockGiftCardRepo.AssertExpectations(t)
+		mockPaymentRepo.AssertExpectations(t)
+	})
+
+	t.Run("Gift card covering the order pays it alone", func(t *testing.T) {
+		// Setup
+		mockPaymentRepo := new(MockPaymentRepository)
+		mockOrderRepo := new(MockOrderRepository)
+		mockGiftCardRepo := new(MockGiftCardRepository)
+		giftCardService := service.NewGiftCardService(mockGiftCardRepo, new(MockStoreCreditRepository))
+		paymentService := service.NewPaymentService(mockPaymentRepo, mockOrderRepo, nil, nil, nil, nil, giftCardService, nil)
+
+		card := redeemableGiftCard(usd(25000))
+		mockOrderRepo.On("FindByID", ctx, uint(1)).Return(giftCardOrder(), nil)
+		mockPaymentRepo.On("FindByOrderID", ctx, uint(1)).Return(nil, errors.New("record not found"))
+		mockOrderRepo.On("GetOrderTotal", ctx, uint(1)).Return(usd(10000), nil)
+		mockGiftCardRepo.On("FindByCode", ctx, "ABCDEFGHJKLMNPQR").Return(card, nil)
+		mockGiftCardRepo.On("Adjust", ctx, uint(5), usd(-10000), balanceChangeOf(domain.BalanceReasonRedemption, 1)).Return(card, nil).Once()
+		mockPaymentRepo.On("Create", ctx, mock.AnythingOfType("*domain.Payment")).Return(nil)
+
+		// Execute
+		payment, err := paymentService.CreatePayment(ctx, 1, usd(10000), domain.PaymentMethodCreditCard, []domain.TenderRequest{
+			{Method: domain.PaymentMethodGiftCard, GiftCardCode: "ABCDEFGHJKLMNPQR"},
+		})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, domain.PaymentMethodGiftCard, payment.Method)
+		remainder, err := payment.Remainder()
+		require.NoError(t, err)
+		assert.False(t, remainder.IsPositive())
+	})
+
+	t.Run("Rest left for a balance method rolls the gift card back", func(t *testing.T) {
+		// Setup
+		mockPaymentRepo := new(MockPaymentRepository)
+		mockOrderRepo := new(MockOrderRepository)
+		mockGiftCardRepo := new(MockGiftCardRepository)
+		transactor := new(fakeTransactor)
+		giftCardService := service.NewGiftCardService(mockGiftCardRepo, new(MockStoreCreditRepository))
+		paymentService := service.NewPaymentService(mockPaymentRepo, mockOrderRepo, nil, transactor, nil, nil, giftCardService, nil)
+
+		card := redeemableGiftCard(usd(3000))
+		mockOrderRepo.On("FindByID", ctx, uint(1)).Return(giftCardOrder(), nil)
+		mockPaymentRepo.On("FindByOrderID", ctx, uint(1)).Return(nil, errors.New("record not found"))
+		mockOrderRepo.On("GetOrderTotal", ctx, uint(1)).Return(usd(10000), nil)
+		mockGiftCardRepo.On("FindByCode", mock.MatchedBy(inTransaction), "ABCDEFGHJKLMNPQR").Return(card, nil)
+		mockGiftCardRepo.On("Adjust", mock.MatchedBy(inTransaction), uint(5), usd(-3000), balanceChangeOf(domain.BalanceReasonRedemption, 1)).Return(card, nil).Once()
+
+		// Execute
+		payment, err := paymentService.CreatePayment(ctx, 1, usd(10000), domain.PaymentMethodGiftCard, []domain.TenderRequest{
+			{Method: domain.PaymentMethodGiftCard, GiftCardCode: "ABCDEFGHJKLMNPQR"},
+		})
+
+		// Assert: the redemption is rolled back with the payment
+		assert.EqualError(t, err, "remaining amount must be paid by another payment method")
+		assert.Nil(t, payment)
+		assert.Equal(t, 1, transactor.rolledBack)
+		mockGiftCardRepo.AssertExpectations(t)
+		mockPaymentRepo.AssertNotCalled(t, "Create")
+	})
+
+	t.Run("Payment saved along with the redemption", func(t *testing.T) {
+		// Setup
+		mockPaymentRepo := new(MockPaymentRepository)
+		mockOrderRepo := new(MockOrderRepository)
+		mockGiftCardRepo := new(MockGiftCardRepository)
+		transactor := new(fakeTransactor)
+		giftCardService := service.NewGiftCardService(mockGiftCardRepo, new(MockStoreCreditRepository))
+		paymentService := service.NewPaymentService(mockPaymentRepo, mockOrderRepo, nil, transactor, nil, nil, giftCardService, nil)
+
+		card := redeemableGiftCard(usd(3000))
+		mockOrderRepo.On("FindByID", ctx, uint(1)).Return(giftCardOrder(), nil)
+		mockPaymentRepo.On("FindByOrderID", ctx, uint(1)).Return(nil, errors.New("record not found"))
+		mockOrderRepo.On("GetOrderTotal", ctx, uint(1)).Return(usd(10000), nil)
+		mockGiftCardRepo.On("FindByCode", mock.MatchedBy(inTransaction), "ABCDEFGHJKLMNPQR").Return(card, nil)
+		mockGiftCardRepo.On("Adjust", mock.MatchedBy(inTransaction), uint(5), usd(-3000), balanceChangeOf(domain.BalanceReasonRedemption, 1)).Return(card, nil).Once()
+		// Another payment for the order was saved meanwhile
+		mockPaymentRepo.On("Create", mock.MatchedBy(inTransaction), mock.AnythingOfType("*domain.Payment")).Return(errors.New("duplicate entry")).Once()
+
+		// Execute
+		payment, err := paymentService.CreatePayment(ctx, 1, usd(10000), domain.PaymentMethodCreditCard, []domain.TenderRequest{
+			{Method: domain.PaymentMethodGiftCard, GiftCardCode: "ABCDEFGHJKLMNPQR"},
+		})
+
+		// Assert: the gift card keeps its balance as the redemption rolls back
+		assert.EqualError(t, err, "duplicate entry")
+		assert.Nil(t, payment)
+		assert.Equal(t, 1, transactor.rolledBack)
+		mockGiftCardRepo.AssertExpectations(t)
+		mockPaymentRepo.AssertExpectations(t)
+	})
+}
+
+func TestRefundPaymentToStoreCredit(t *testing.T) {
+	ctx := context.Background()
+
+	// Setup
+	mockPaymentRepo := new(MockPaymentRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	mockGiftCardRepo := new(MockGiftCardRepository)
+	mockStoreCreditRepo := new(MockStoreCreditRepository)
+	giftCardService := service.NewGiftCardService(mockGiftCardRepo, mockStoreCreditRepo)
+	paymentService := service.NewPaymentService(mockPaymentRepo, mockOrderRepo, nil, nil, nil, nil, giftCardService, nil)
+
+	payment := &domain.Payment{
+		ID:      4,
+		OrderID: 1,
+		Amount:  usd(10000),
+		Method:  domain.PaymentMethodCreditCard,
+		Status:  domain.PaymentStatusCompleted,
+		Tenders: []domain.PaymentTender{
+			{Method: domain.PaymentMethodGiftCard, GiftCardID: uintPtr(5), Amount: usd(3000)},
+		},
+	}
+	refund := balanceChangeOf(domain.BalanceReasonRefund, 1)
+
+	mockPaymentRepo.On("FindByID", ctx, uint(4)).Return(payment, nil)
+	mockPaymentRepo.On("UpdateStatus", ctx, uint(4), domain.PaymentStatusRefunded).Return(nil)
+	order := giftCardOrder()
+	order.Status = domain.OrderStatusProcessing
+	mockOrderRepo.On("UpdateStatus", ctx, uint(1), domain.OrderStatusProcessing, domain.OrderStatusRefunded, domain.OrderStatusChange{Reason: "payment refunded", Actor: "system"}).Return(nil)
+	mockOrderRepo.On("FindByID", ctx, uint(1)).Return(order, nil)
+	mockGiftCardRepo.On("Adjust", ctx, uint(5), usd(3000), refund).Return(redeemableGiftCard(usd(3000)), nil).Once()
+	mockStoreCreditRepo.On("Adjust", ctx, uint(2), usd(7000), refund).Return(&domain.StoreCreditAccount{ID: 9, UserID: 2, Currency: "USD", Balance: usd(7000)}, nil).Once()
+
+	// Execute
+	err := paymentService.RefundPaymentToStoreCredit(ctx, 4, "")
+
+	// Assert: the gift card gets its part back and the rest becomes store credit
+	require.NoError(t, err)
+	mockGiftCardRepo.AssertExpectations(t)
+	mockStoreCreditRepo.AssertExpectations(t)
+	mockPaymentRepo.AssertExpectations(t)
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+)
+
+// maxIdempotencyKeyLength is the longest idempotency key accepted
+const maxIdempotencyKeyLength = 255
+
+// IdempotencyService defines the interface for idempotency key business logic
+type IdempotencyService interface {
+	// Begin starts a request made with an idempotency key. It returns the
+	// completed record of the first request made with the key, whose response
+	// is to be replayed, or when the request is the first and should run, the
+	// record of the lock it took on the key.
+	Begin(ctx context.Context, key, fingerprint string) (*domain.IdempotencyRecord, error)
+
+	// Complete stores the response to the first request made with an
+	// idempotency key, replayed to repeated requests, in place of its lock
+	Complete(ctx context.Context, lock *domain.IdempotencyRecord, response domain.IdempotentResponse) error
+
+	// Abandon forgets the first request made with an idempotency key, which
+	// failed, so that it can be retried
+	Abandon(ctx context.Context, lock *domain.IdempotencyRecord) error
+}
+
+// IdempotencyServiceImpl implements the IdempotencyService interface
+type IdempotencyServiceImpl struct {
+	idempotencyRepo repository.IdempotencyRepository
+	ttl             time.Duration
+	lockTimeout     time.Duration
+}
+
+// NewIdempotencyService creates a new IdempotencyServiceImpl. Responses are
+// kept for ttl; a request that never completes releases its key after
+// lockTimeout.
+func NewIdempotencyService(idempotencyRepo repository.IdempotencyRepository, ttl, lockTimeout time.Duration) IdempotencyService {
+	return &IdempotencyServiceImpl{
+		idempotencyRepo: idempotencyRepo,
+		ttl:             ttl,
+		lockTimeout:     lockTimeout,
+	}
+}
+
+// Begin starts a request made with an idempotency key by locking the key for
+// it under a token of its own. A key sent again with another request is
+// refused, as is one whose first request has not finished yet.
+func (s *IdempotencyServiceImpl) Begin(ctx context.Context, key, fingerprint string) (*domain.IdempotencyRecord, error) {
+	if len(key) > maxIdempotencyKeyLength {
+		return nil, domain.ErrInvalidIdempotencyKey
+	}
+	token, err := newIdempotencyToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// The record of the first request may expire between failing to take the
+	// lock and reading it, in which case the lock is taken again
+	for attempt := 0; attempt < 3; attempt++ {
+		lock := &domain.IdempotencyRecord{
+			Key:         key,
+			Fingerprint: fingerprint,
+			Token:       token,
+			CreatedAt:   time.Now(),
+		}
+		locked, err := s.idempotencyRepo.Create(ctx, lock, s.lockTimeout)
+		if err != nil {
+			return nil, err
+		}
+		if locked {
+			return lock, nil
+		}
+
+		record, err := s.idempotencyRepo.FindByKey(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if record == nil {
+			continue
+		}
+
+		switch {
+		case record.Fingerprint != fingerprint:
+			return nil, domain.ErrIdempotencyKeyReused
+		case !record.IsCompleted():
+			return nil, domain.ErrIdempotencyKeyInProgress
+		}
+		return record, nil
+	}
+	return nil, domain.ErrIdempotencyKeyInProgress
+}
+
+// Complete stores the response to the first request made with an
+// idempotency key, replacing its lock. A request whose lock expired meanwhile
+// gets domain.ErrIdempotencyKeyLockLost, as a retry may have run again.
+func (s *IdempotencyServiceImpl) Complete(ctx context.Context, lock *domain.IdempotencyRecord, response domain.IdempotentResponse) error {
+	return s.idempotencyRepo.Update(ctx, &domain.IdempotencyRecord{
+		Key:         lock.Key,
+		Fingerprint: lock.Fingerprint,
+		Token:       lock.Token,
+		Response:    &response,
+		CreatedAt:   time.Now(),
+	}, s.ttl)
+}
+
+// Abandon forgets the first request made with an idempotency key, releasing
+// its lock unless another request already took the key over
+func (s *IdempotencyServiceImpl) Abandon(ctx context.Context, lock *domain.IdempotencyRecord) error {
+	return s.idempotencyRepo.Delete(ctx, lock.Key, lock.Token)
+}
+
+// newIdempotencyToken generates the random token identifying the request
+// holding an idempotency key
+func newIdempotencyToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
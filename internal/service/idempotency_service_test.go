@@ -0,0 +1,295 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockIdempotencyRepository is a mock implementation of the IdempotencyRepository interface
+type MockIdempotencyRepository struct {
+	mock.Mock
+}
+
+func (m *MockIdempotencyRepository) FindByKey(ctx context.Context, key string) (*domain.IdempotencyRecord, error) {
+	args := m.Called(ctx, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.IdempotencyRecord), args.Error(1)
+}
+
+func (m *MockIdempotencyRepository) Create(ctx context.Context, record *domain.IdempotencyRecord, ttl time.Duration) (bool, error) {
+	args := m.Called(ctx, record, ttl)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockIdempotencyRepository) Update(ctx context.Context, record *domain.IdempotencyRecord, ttl time.Duration) error {
+	args := m.Called(ctx, record, ttl)
+	return args.Error(0)
+}
+
+func (m *MockIdempotencyRepository) Delete(ctx context.Context, key, token string) error {
+	args := m.Called(ctx, key, token)
+	return args.Error(0)
+}
+
+// idempotencyRepository is an IdempotencyRepository keeping records in
+// memory. Like Redis, Create stores a record only if its key has none, and
+// Update and Delete only touch a record holding the same token.
+type idempotencyRepository struct {
+	mu      sync.Mutex
+	records map[string]domain.IdempotencyRecord
+}
+
+func (r *idempotencyRepository) FindByKey(ctx context.Context, key string) (*domain.IdempotencyRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	record, ok := r.records[key]
+	if !ok {
+		return nil, nil
+	}
+	return &record, nil
+}
+
+func (r *idempotencyRepository) Create(ctx context.Context, record *domain.IdempotencyRecord, ttl time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.records[record.Key]; ok {
+		return false, nil
+	}
+	r.records[record.Key] = *record
+	return true, nil
+}
+
+func (r *idempotencyRepository) Update(ctx context.Context, record *domain.IdempotencyRecord, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if current, ok := r.records[record.Key]; !ok || current.Token != record.Token {
+		return domain.ErrIdempotencyKeyLockLost
+	}
+	r.records[record.Key] = *record
+	return nil
+}
+
+func (r *idempotencyRepository) Delete(ctx context.Context, key, token string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if current, ok := r.records[key]; ok && current.Token == token {
+		delete(r.records, key)
+	}
+	return nil
+}
+
+func TestBeginIdempotentRequest(t *testing.T) {
+	ctx := context.Background()
+	key := "user:1:order-1"
+	response := domain.IdempotentResponse{StatusCode: 201, ContentType: "application/json", Body: []byte(`{"id":1}`)}
+
+	t.Run("First Request", func(t *testing.T) {
+		mockRepo := new(MockIdempotencyRepository)
+		idempotencyService := service.NewIdempotencyService(mockRepo, 24*time.Hour, time.Minute)
+
+		mockRepo.On("Create", ctx, mock.MatchedBy(func(record *domain.IdempotencyRecord) bool {
+			return record.Key == key && record.Fingerprint == "abc" && record.Token != "" && !record.IsCompleted()
+		}), time.Minute).Return(true, nil).Once()
+
+		record, err := idempotencyService.Begin(ctx, key, "abc")
+
+		// The request runs under the lock it took
+		assert.NoError(t, err)
+		assert.False(t, record.IsCompleted())
+		assert.NotEmpty(t, record.Token)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Repeated Request", func(t *testing.T) {
+		mockRepo := new(MockIdempotencyRepository)
+		idempotencyService := service.NewIdempotencyService(mockRepo, 24*time.Hour, time.Minute)
+		completed := &domain.IdempotencyRecord{Key: key, Fingerprint: "abc", Response: &response}
+
+		mockRepo.On("Create", ctx, mock.Anything, time.Minute).Return(false, nil).Once()
+		mockRepo.On("FindByKey", ctx, key).Return(completed, nil).Once()
+
+		record, err := idempotencyService.Begin(ctx, key, "abc")
+
+		assert.NoError(t, err)
+		assert.Equal(t, completed, record)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Different Request", func(t *testing.T) {
+		mockRepo := new(MockIdempotencyRepository)
+		idempotencyService := service.NewIdempotencyService(mockRepo, 24*time.Hour, time.Minute)
+
+		mockRepo.On("Create", ctx, mock.Anything, time.Minute).Return(false, nil).Once()
+		mockRepo.On("FindByKey", ctx, key).Return(&domain.IdempotencyRecord{Key: key, Fingerprint: "abc", Response: &response}, nil).Once()
+
+		record, err := idempotencyService.Begin(ctx, key, "def")
+
+		assert.ErrorIs(t, err, domain.ErrIdempotencyKeyReused)
+		assert.Nil(t, record)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("First Request In Progress", func(t *testing.T) {
+		mockRepo := new(MockIdempotencyRepository)
+		idempotencyService := service.NewIdempotencyService(mockRepo, 24*time.Hour, time.Minute)
+
+		mockRepo.On("Create", ctx, mock.Anything, time.Minute).Return(false, nil).Once()
+		mockRepo.On("FindByKey", ctx, key).Return(&domain.IdempotencyRecord{Key: key, Fingerprint: "abc"}, nil).Once()
+
+		record, err := idempotencyService.Begin(ctx, key, "abc")
+
+		assert.ErrorIs(t, err, domain.ErrIdempotencyKeyInProgress)
+		assert.Nil(t, record)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("First Request Expired Meanwhile", func(t *testing.T) {
+		mockRepo := new(MockIdempotencyRepository)
+		idempotencyService := service.NewIdempotencyService(mockRepo, 24*time.Hour, time.Minute)
+
+		mockRepo.On("Create", ctx, mock.Anything, time.Minute).Return(false, nil).Once()
+		mockRepo.On("FindByKey", ctx, key).Return(nil, nil).Once()
+		mockRepo.On("Create", ctx, mock.Anything, time.Minute).Return(true, nil).Once()
+
+		record, err := idempotencyService.Begin(ctx, key, "abc")
+
+		assert.NoError(t, err)
+		assert.False(t, record.IsCompleted())
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Key Too Long", func(t *testing.T) {
+		mockRepo := new(MockIdempotencyRepository)
+		idempotencyService := service.NewIdempotencyService(mockRepo, 24*time.Hour, time.Minute)
+
+		record, err := idempotencyService.Begin(ctx, string(make([]byte, 256)), "abc")
+
+		assert.ErrorIs(t, err, domain.ErrInvalidIdempotencyKey)
+		assert.Nil(t, record)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Database Error", func(t *testing.T) {
+		mockRepo := new(MockIdempotencyRepository)
+		idempotencyService := service.NewIdempotencyService(mockRepo, 24*time.Hour, time.Minute)
+
+		mockRepo.On("Create", ctx, mock.Anything, time.Minute).Return(false, errors.New("connection refused")).Once()
+
+		record, err := idempotencyService.Begin(ctx, key, "abc")
+
+		assert.EqualError(t, err, "connection refused")
+		assert.Nil(t, record)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestCompleteIdempotentRequest(t *testing.T) {
+	ctx := context.Background()
+	response := domain.IdempotentResponse{StatusCode: 201, ContentType: "application/json", Body: []byte(`{"id":1}`)}
+	lock := &domain.IdempotencyRecord{Key: "user:1:order-1", Fingerprint: "abc", Token: "t1"}
+
+	t.Run("Success", func(t *testing.T) {
+		mockRepo := new(MockIdempotencyRepository)
+		idempotencyService := service.NewIdempotencyService(mockRepo, 24*time.Hour, time.Minute)
+
+		// The response replaces the lock of the request and is kept for the key TTL
+		mockRepo.On("Update", ctx, mock.MatchedBy(func(record *domain.IdempotencyRecord) bool {
+			return record.Key == "user:1:order-1" && record.Fingerprint == "abc" && record.Token == "t1" &&
+				assert.ObjectsAreEqual(&response, record.Response)
+		}), 24*time.Hour).Return(nil).Once()
+
+		err := idempotencyService.Complete(ctx, lock, response)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Lock Lost", func(t *testing.T) {
+		repo := &idempotencyRepository{records: map[string]domain.IdempotencyRecord{}}
+		idempotencyService := service.NewIdempotencyService(repo, 24*time.Hour, time.Minute)
+
+		// The lock expired and a retry took the key over, so its record stays
+		retry, err := idempotencyService.Begin(ctx, "user:1:order-1", "abc")
+		assert.NoError(t, err)
+
+		err = idempotencyService.Complete(ctx, lock, response)
+
+		assert.ErrorIs(t, err, domain.ErrIdempotencyKeyLockLost)
+		record, _ := repo.FindByKey(ctx, "user:1:order-1")
+		assert.Equal(t, retry.Token, record.Token)
+		assert.False(t, record.IsCompleted())
+	})
+}
+
+func TestConcurrentIdempotentRequests(t *testing.T) {
+	const requests = 20
+
+	ctx := context.Background()
+	repo := &idempotencyRepository{records: map[string]domain.IdempotencyRecord{}}
+	idempotencyService := service.NewIdempotencyService(repo, 24*time.Hour, time.Minute)
+
+	// Every duplicate arrives while the first request is still running
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	started, inProgress := 0, 0
+	var lock *domain.IdempotencyRecord
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			record, err := idempotencyService.Begin(ctx, "user:1:order-1", "abc")
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case errors.Is(err, domain.ErrIdempotencyKeyInProgress):
+				inProgress++
+			case err == nil && !record.IsCompleted():
+				lock = record
+				started++
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Only one request runs; once it completes, retries replay its response
+	assert.Equal(t, 1, started)
+	assert.Equal(t, requests-1, inProgress)
+
+	response := domain.IdempotentResponse{StatusCode: 201, ContentType: "application/json", Body: []byte(`{"id":1}`)}
+	assert.NoError(t, idempotencyService.Complete(ctx, lock, response))
+	record, err := idempotencyService.Begin(ctx, "user:1:order-1", "abc")
+	assert.NoError(t, err)
+	assert.Equal(t, &response, record.Response)
+}
+
+func TestAbandonIdempotentRequest(t *testing.T) {
+	ctx := context.Background()
+	repo := &idempotencyRepository{records: map[string]domain.IdempotencyRecord{}}
+	idempotencyService := service.NewIdempotencyService(repo, 24*time.Hour, time.Minute)
+
+	// A failed request releases its key, so the retry runs
+	lock, err := idempotencyService.Begin(ctx, "user:1:order-1", "abc")
+	assert.NoError(t, err)
+	assert.NoError(t, idempotencyService.Abandon(ctx, lock))
+
+	retry, err := idempotencyService.Begin(ctx, "user:1:order-1", "abc")
+	assert.NoError(t, err)
+	assert.False(t, retry.IsCompleted())
+
+	// Abandoning the first request again leaves the retry's lock alone
+	assert.NoError(t, idempotencyService.Abandon(ctx, lock))
+	_, err = idempotencyService.Begin(ctx, "user:1:order-1", "abc")
+	assert.ErrorIs(t, err, domain.ErrIdempotencyKeyInProgress)
+}
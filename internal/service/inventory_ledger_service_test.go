@@ -0,0 +1,118 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockInventoryLedgerRepository struct {
+	mock.Mock
+}
+
+func (m *MockInventoryLedgerRepository) FindByProductID(ctx context.Context, productID uint, page, pageSize int) ([]domain.InventoryLedgerEntry, int64, error) {
+	args := m.Called(ctx, productID, page, pageSize)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]domain.InventoryLedgerEntry), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockInventoryLedgerRepository) Reconcile(ctx context.Context) ([]domain.StockDiscrepancy, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.StockDiscrepancy), args.Error(1)
+}
+
+func TestGetProductLedger(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		// Setup
+		mockLedgerRepo := new(MockInventoryLedgerRepository)
+		mockProductRepo := new(MockProductRepository)
+		inventoryService := service.NewInventoryService(new(MockInventoryRepository), mockLedgerRepo, mockProductRepo, domain.AllocationStrategyPriority)
+
+		entries := []domain.InventoryLedgerEntry{
+			{ID: 2, ProductID: 1, Delta: -2, Balance: 8, Reason: domain.LedgerReasonOrder, ReferenceID: 4, Actor: "user:3"},
+			{ID: 1, ProductID: 1, Delta: 10, Balance: 10, Reason: domain.LedgerReasonManualAdjustment, Actor: "user:1"},
+		}
+		mockProductRepo.On("FindByID", ctx, uint(1)).Return(&domain.Product{ID: 1, Stock: 8}, nil)
+		mockLedgerRepo.On("FindByProductID", ctx, uint(1), 1, 20).Return(entries, int64(2), nil)
+
+		// Execute
+		result, total, err := inventoryService.GetProductLedger(ctx, 1, 1, 20)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		assert.Equal(t, entries, result)
+		mockLedgerRepo.AssertExpectations(t)
+	})
+
+	t.Run("Product not found", func(t *testing.T) {
+		// Setup
+		mockLedgerRepo := new(MockInventoryLedgerRepository)
+		mockProductRepo := new(MockProductRepository)
+		inventoryService := service.NewInventoryService(new(MockInventoryRepository), mockLedgerRepo, mockProductRepo, domain.AllocationStrategyPriority)
+
+		mockProductRepo.On("FindByID", ctx, uint(9)).Return(nil, errors.New("record not found"))
+
+		// Execute
+		_, _, err := inventoryService.GetProductLedger(ctx, 9, 1, 20)
+
+		// Assert
+		assert.Error(t, err)
+		assert.Equal(t, "product not found", err.Error())
+		mockLedgerRepo.AssertNotCalled(t, "FindByProductID", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestReconcileStock(t *testing.T) {
+	// Setup
+	mockLedgerRepo := new(MockInventoryLedgerRepository)
+	inventoryService := service.NewInventoryService(new(MockInventoryRepository), mockLedgerRepo, new(MockProductRepository), domain.AllocationStrategyPriority)
+	ctx := context.Background()
+
+	discrepancies := []domain.StockDiscrepancy{{ProductID: 1, ProductName: "Test Product", Stock: 7, LedgerStock: 8}}
+	mockLedgerRepo.On("Reconcile", ctx).Return(discrepancies, nil)
+
+	// Execute
+	result, err := inventoryService.ReconcileStock(ctx)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, discrepancies, result)
+	mockLedgerRepo.AssertExpectations(t)
+}
+
+func TestSetStockLevelRecordsActor(t *testing.T) {
+	// Setup
+	mockInventoryRepo := new(MockInventoryRepository)
+	mockProductRepo := new(MockProductRepository)
+	inventoryService := service.NewInventoryService(mockInventoryRepo, nil, mockProductRepo, domain.AllocationStrategyPriority)
+	ctx := domain.WithUserID(context.Background(), 3)
+
+	// The level is set by the authenticated user as a manual adjustment
+	recordedByUser := mock.MatchedBy(func(ctx context.Context) bool {
+		return domain.StockChangeFromContext(ctx) == domain.StockChange{Reason: domain.LedgerReasonManualAdjustment, Actor: "user:3"}
+	})
+	mockProductRepo.On("FindByID", ctx, uint(1)).Return(&domain.Product{ID: 1}, nil)
+	mockInventoryRepo.On("FindWarehouseByID", ctx, uint(2)).Return(&domain.Warehouse{ID: 2}, nil)
+	mockInventoryRepo.On("SetLevel", recordedByUser, uint(1), uint(2), 15).Return(nil)
+
+	// Execute
+	err := inventoryService.SetStockLevel(ctx, 1, 2, 15)
+
+	// Assert
+	assert.NoError(t, err)
+	mockInventoryRepo.AssertExpectations(t)
+}
@@ -0,0 +1,311 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+)
+
+// InventoryService defines the interface for warehouse inventory business logic
+type InventoryService interface {
+	// GetWarehouses retrieves all warehouses
+	GetWarehouses(ctx context.Context) ([]domain.Warehouse, error)
+
+	// CreateWarehouse creates a new warehouse
+	CreateWarehouse(ctx context.Context, warehouse *domain.Warehouse) error
+
+	// UpdateWarehouse updates an existing warehouse
+	UpdateWarehouse(ctx context.Context, warehouse *domain.Warehouse) error
+
+	// GetWarehouseByID retrieves a warehouse by its ID
+	GetWarehouseByID(ctx context.Context, id uint) (*domain.Warehouse, error)
+
+	// GetProductAvailability retrieves the stock of a product across all warehouses
+	GetProductAvailability(ctx context.Context, productID uint) (*domain.ProductAvailability, error)
+
+	// SetStockLevel sets the stock of a product at a warehouse
+	SetStockLevel(ctx context.Context, productID, warehouseID uint, quantity int) error
+
+	// TransferStock moves stock of a product from one warehouse to another
+	TransferStock(ctx context.Context, productID, fromWarehouseID, toWarehouseID uint, quantity int) (*domain.InventoryTransfer, error)
+
+	// AllocateOrder reserves the stock of every order line at specific warehouses
+	AllocateOrder(ctx context.Context, order *domain.Order) error
+
+	// ReleaseOrder returns the stock reserved for an order to its warehouses
+	ReleaseOrder(ctx context.Context, orderID uint) error
+
+	// GetOrderAllocations retrieves the stock reserved for an order
+	GetOrderAllocations(ctx context.Context, orderID uint) ([]domain.OrderAllocation, error)
+
+	// GetProductLedger retrieves the stock movements of a product with optional pagination
+	GetProductLedger(ctx context.Context, productID uint, page, pageSize int) ([]domain.InventoryLedgerEntry, int64, error)
+
+	// ReconcileStock retrieves the products whose stock differs from their ledger
+	ReconcileStock(ctx context.Context) ([]domain.StockDiscrepancy, error)
+}
+
+// InventoryServiceImpl implements the InventoryService interface
+type InventoryServiceImpl struct {
+	inventoryRepo repository.InventoryRepository
+	ledgerRepo    repository.InventoryLedgerRepository
+	productRepo   repository.ProductRepository
+	strategy      domain.AllocationStrategy
+}
+
+// NewInventoryService creates a new InventoryServiceImpl
+func NewInventoryService(
+	inventoryRepo repository.InventoryRepository,
+	ledgerRepo repository.InventoryLedgerRepository,
+	productRepo repository.ProductRepository,
+	strategy domain.AllocationStrategy,
+) InventoryService {
+	return &InventoryServiceImpl{
+		inventoryRepo: inventoryRepo,
+		ledgerRepo:    ledgerRepo,
+		productRepo:   productRepo,
+		strategy:      strategy,
+	}
+}
+
+// GetWarehouses retrieves all warehouses
+func (s *InventoryServiceImpl) GetWarehouses(ctx context.Context) ([]domain.Warehouse, error) {
+	return s.inventoryRepo.FindWarehouses(ctx)
+}
+
+// CreateWarehouse creates a new warehouse
+func (s *InventoryServiceImpl) CreateWarehouse(ctx context.Context, warehouse *domain.Warehouse) error {
+	return s.inventoryRepo.CreateWarehouse(ctx, warehouse)
+}
+
+// UpdateWarehouse updates an existing warehouse
+func (s *InventoryServiceImpl) UpdateWarehouse(ctx context.Context, warehouse *domain.Warehouse) error {
+	return s.inventoryRepo.UpdateWarehouse(ctx, warehouse)
+}
+
+// GetWarehouseByID retrieves a warehouse by its ID
+func (s *InventoryServiceImpl) GetWarehouseByID(ctx context.Context, id uint) (*domain.Warehouse, error) {
+	return s.inventoryRepo.FindWarehouseByID(ctx, id)
+}
+
+// GetProductAvailability retrieves the stock of a product across all warehouses.
+// Products without warehouse levels report their single stock figure.
+func (s *InventoryServiceImpl) GetProductAvailability(ctx context.Context, productID uint) (*domain.ProductAvailability, error) {
+	product, err := s.productRepo.FindByID(ctx, productID)
+	if err != nil {
+		return nil, errors.New("product not found")
+	}
+
+	levels, err := s.inventoryRepo.FindLevelsByProductID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	availability := &domain.ProductAvailability{
+		ProductID: productID,
+		Total:     product.Stock,
+		Locations: []domain.InventoryLevel{},
+	}
+	if len(levels) == 0 {
+		return availability, nil
+	}
+
+	availability.Total = 0
+	for _, level := range levels {
+		if !level.Warehouse.Active {
+			continue
+		}
+		availability.Total += level.Quantity
+		availability.Locations = append(availability.Locations, level)
+	}
+
+	return availability, nil
+}
+
+// SetStockLevel sets the stock of a product at a warehouse
+func (s *InventoryServiceImpl) SetStockLevel(ctx context.Context, productID, warehouseID uint, quantity int) error {
+	if quantity < 0 {
+		return errors.New("quantity cannot be negative")
+	}
+
+	// Check if product and warehouse exist
+	if _, err := s.productRepo.FindByID(ctx, productID); err != nil {
+		return errors.New("product not found")
+	}
+	if _, err := s.inventoryRepo.FindWarehouseByID(ctx, warehouseID); err != nil {
+		return errors.New("warehouse not found")
+	}
+
+	return s.inventoryRepo.SetLevel(withManualStockChange(ctx), productID, warehouseID, quantity)
+}
+
+// TransferStock moves stock of a product from one warehouse to another
+func (s *InventoryServiceImpl) TransferStock(ctx context.Context, productID, fromWarehouseID, toWarehouseID uint, quantity int) (*domain.InventoryTransfer, error) {
+	if quantity <= 0 {
+		return nil, errors.New("quantity must be greater than zero")
+	}
+	if fromWarehouseID == toWarehouseID {
+		return nil, errors.New("source and destination warehouses must differ")
+	}
+
+	// Check if warehouses exist
+	if _, err := s.inventoryRepo.FindWarehouseByID(ctx, fromWarehouseID); err != nil {
+		return nil, errors.New("source warehouse not found")
+	}
+	if _, err := s.inventoryRepo.FindWarehouseByID(ctx, toWarehouseID); err != nil {
+		return nil, errors.New("destination warehouse not found")
+	}
+
+	transfer := &domain.InventoryTransfer{
+		ProductID:       productID,
+		FromWarehouseID: fromWarehouseID,
+		ToWarehouseID:   toWarehouseID,
+		Quantity:        quantity,
+	}
+	if err := s.inventoryRepo.Transfer(ctx, transfer); err != nil {
+		return nil, err
+	}
+
+	return transfer, nil
+}
+
+// AllocateOrder reserves the stock of every order line at specific warehouses.
+// Lines for products without warehouse levels are not allocated, nor are
+// backordered units, which are allocated as stock arrives.
+func (s *InventoryServiceImpl) AllocateOrder(ctx context.Context, order *domain.Order) error {
+	var allocations []domain.OrderAllocation
+	for _, item := range order.Items {
+		if item.InStockQuantity() == 0 {
+			continue
+		}
+
+		levels, err := s.inventoryRepo.FindLevelsByProductID(ctx, item.ProductID)
+		if err != nil {
+			return err
+		}
+		if len(levels) == 0 {
+			continue
+		}
+
+		lineAllocations, err := s.planAllocation(levels, item.InStockQuantity(), order.ShippingAddress)
+		if err != nil {
+			return fmt.Errorf("%w: %s", err, item.ProductName)
+		}
+		for i := range lineAllocations {
+			lineAllocations[i].OrderID = order.ID
+		}
+		allocations = append(allocations, lineAllocations...)
+	}
+
+	return s.inventoryRepo.Allocate(ctx, allocations)
+}
+
+// planAllocation picks the warehouses fulfilling quantity units according to
+// the configured strategy. Nearest and priority ship from a single warehouse
+// when one has enough stock and split the line otherwise.
+func (s *InventoryServiceImpl) planAllocation(levels []domain.InventoryLevel, quantity int, shippingAddress string) ([]domain.OrderAllocation, error) {
+	candidates := make([]domain.InventoryLevel, 0, len(levels))
+	for _, level := range levels {
+		if level.Warehouse.Active && level.Quantity > 0 {
+			candidates = append(candidates, level)
+		}
+	}
+
+	address := strings.ToLower(shippingAddress)
+	isNear := func(level domain.InventoryLevel) bool {
+		return level.Warehouse.Region != "" && strings.Contains(address, strings.ToLower(level.Warehouse.Region))
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if s.strategy == domain.AllocationStrategyNearest && isNear(candidates[i]) != isNear(candidates[j]) {
+			return isNear(candidates[i])
+		}
+		return candidates[i].Warehouse.Priority < candidates[j].Warehouse.Priority
+	})
+
+	if s.strategy != domain.AllocationStrategySplit {
+		for _, level := range candidates {
+			if level.Quantity >= quantity {
+				return []domain.OrderAllocation{{
+					ProductID:   level.ProductID,
+					WarehouseID: level.WarehouseID,
+					Quantity:    quantity,
+				}}, nil
+			}
+		}
+	}
+
+	var allocations []domain.OrderAllocation
+	remaining := quantity
+	for _, level := range candidates {
+		if remaining == 0 {
+			break
+		}
+		take := level.Quantity
+		if take > remaining {
+			take = remaining
+		}
+		allocations = append(allocations, domain.OrderAllocation{
+			ProductID:   level.ProductID,
+			WarehouseID: level.WarehouseID,
+			Quantity:    take,
+		})
+		remaining -= take
+	}
+
+	if remaining > 0 {
+		return nil, errors.New("insufficient stock at fulfillment locations for product")
+	}
+
+	return allocations, nil
+}
+
+// ReleaseOrder returns the stock reserved for an order to its warehouses
+func (s *InventoryServiceImpl) ReleaseOrder(ctx context.Context, orderID uint) error {
+	return s.inventoryRepo.ReleaseAllocations(ctx, orderID)
+}
+
+// GetOrderAllocations retrieves the stock reserved for an order
+func (s *InventoryServiceImpl) GetOrderAllocations(ctx context.Context, orderID uint) ([]domain.OrderAllocation, error) {
+	return s.inventoryRepo.FindAllocationsByOrderID(ctx, orderID)
+}
+
+// GetProductLedger retrieves the stock movements of a product with optional pagination
+func (s *InventoryServiceImpl) GetProductLedger(ctx context.Context, productID uint, page, pageSize int) ([]domain.InventoryLedgerEntry, int64, error) {
+	if _, err := s.productRepo.FindByID(ctx, productID); err != nil {
+		return nil, 0, errors.New("product not found")
+	}
+	return s.ledgerRepo.FindByProductID(ctx, productID, page, pageSize)
+}
+
+// ReconcileStock retrieves the products whose stock differs from their ledger
+func (s *InventoryServiceImpl) ReconcileStock(ctx context.Context) ([]domain.StockDiscrepancy, error) {
+	return s.ledgerRepo.Reconcile(ctx)
+}
+
+// stockChange describes a stock change made on behalf of the caller
+func stockChange(ctx context.Context, reason domain.LedgerReason, referenceID uint) domain.StockChange {
+	return domain.StockChange{Reason: reason, ReferenceID: referenceID, Actor: actorFromContext(ctx)}
+}
+
+// actorFromContext identifies who changes stock: the authenticated user of an
+// API request, or the system for background work
+func actorFromContext(ctx context.Context) string {
+	if userID, ok := domain.UserIDFromContext(ctx); ok {
+		return fmt.Sprintf("user:%d", userID)
+	}
+	return "system"
+}
+
+// withManualStockChange records stock set outright by an authenticated user
+// as their manual adjustment. Other callers keep the stock change of ctx.
+func withManualStockChange(ctx context.Context) context.Context {
+	if _, ok := domain.UserIDFromContext(ctx); !ok {
+		return ctx
+	}
+	return domain.WithStockChange(ctx, stockChange(ctx, domain.LedgerReasonManualAdjustment, 0))
+}
@@ -0,0 +1,274 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockInventoryRepository struct {
+	mock.Mock
+}
+
+func (m *MockInventoryRepository) FindWarehouses(ctx context.Context) ([]domain.Warehouse, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Warehouse), args.Error(1)
+}
+
+func (m *MockInventoryRepository) FindWarehouseByID(ctx context.Context, id uint) (*domain.Warehouse, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Warehouse), args.Error(1)
+}
+
+func (m *MockInventoryRepository) CreateWarehouse(ctx context.Context, warehouse *domain.Warehouse) error {
+	args := m.Called(ctx, warehouse)
+	return args.Error(0)
+}
+
+func (m *MockInventoryRepository) UpdateWarehouse(ctx context.Context, warehouse *domain.Warehouse) error {
+	args := m.Called(ctx, warehouse)
+	return args.Error(0)
+}
+
+func (m *MockInventoryRepository) FindLevelsByProductID(ctx context.Context, productID uint) ([]domain.InventoryLevel, error) {
+	args := m.Called(ctx, productID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.InventoryLevel), args.Error(1)
+}
+
+func (m *MockInventoryRepository) SetLevel(ctx context.Context, productID, warehouseID uint, quantity int) error {
+	args := m.Called(ctx, productID, warehouseID, quantity)
+	return args.Error(0)
+}
+
+func (m *MockInventoryRepository) Transfer(ctx context.Context, transfer *domain.InventoryTransfer) error {
+	args := m.Called(ctx, transfer)
+	return args.Error(0)
+}
+
+func (m *MockInventoryRepository) Allocate(ctx context.Context, allocations []domain.OrderAllocation) error {
+	args := m.Called(ctx, allocations)
+	return args.Error(0)
+}
+
+func (m *MockInventoryRepository) FindAllocationsByOrderID(ctx context.Context, orderID uint) ([]domain.OrderAllocation, error) {
+	args := m.Called(ctx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.OrderAllocation), args.Error(1)
+}
+
+func (m *MockInventoryRepository) ReleaseAllocations(ctx context.Context, orderID uint) error {
+	args := m.Called(ctx, orderID)
+	return args.Error(0)
+}
+
+func warehouseLevels(productID uint) []domain.InventoryLevel {
+	return []domain.InventoryLevel{
+		{ProductID: productID, WarehouseID: 1, Quantity: 3, Warehouse: domain.Warehouse{ID: 1, Region: "Hanoi", Priority: 2, Active: true}},
+		{ProductID: productID, WarehouseID: 2, Quantity: 4, Warehouse: domain.Warehouse{ID: 2, Region: "Saigon", Priority: 1, Active: true}},
+		{ProductID: productID, WarehouseID: 3, Quantity: 50, Warehouse: domain.Warehouse{ID: 3, Region: "Danang", Priority: 0, Active: false}},
+	}
+}
+
+func TestGetProductAvailability(t *testing.T) {
+	ctx := context.Background()
+	productID := uint(1)
+
+	t.Run("Across warehouses", func(t *testing.T) {
+		// Setup
+		mockInventoryRepo := new(MockInventoryRepository)
+		mockProductRepo := new(MockProductRepository)
+		inventoryService := service.NewInventoryService(mockInventoryRepo, nil, mockProductRepo, domain.AllocationStrategyPriority)
+
+		mockProductRepo.On("FindByID", ctx, productID).Return(&domain.Product{ID: productID, Stock: 7}, nil)
+		mockInventoryRepo.On("FindLevelsByProductID", ctx, productID).Return(warehouseLevels(productID), nil)
+
+		// Execute
+		availability, err := inventoryService.GetProductAvailability(ctx, productID)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 7, availability.Total)
+		assert.Len(t, availability.Locations, 2)
+	})
+
+	t.Run("Without warehouse levels", func(t *testing.T) {
+		// Setup
+		mockInventoryRepo := new(MockInventoryRepository)
+		mockProductRepo := new(MockProductRepository)
+		inventoryService := service.NewInventoryService(mockInventoryRepo, nil, mockProductRepo, domain.AllocationStrategyPriority)
+
+		mockProductRepo.On("FindByID", ctx, productID).Return(&domain.Product{ID: productID, Stock: 12}, nil)
+		mockInventoryRepo.On("FindLevelsByProductID", ctx, productID).Return([]domain.InventoryLevel{}, nil)
+
+		// Execute
+		availability, err := inventoryService.GetProductAvailability(ctx, productID)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 12, availability.Total)
+		assert.Empty(t, availability.Locations)
+	})
+}
+
+func TestAllocateOrder(t *testing.T) {
+	ctx := context.Background()
+	productID := uint(1)
+
+	newOrder := func(quantity int) *domain.Order {
+		return &domain.Order{
+			ID:              10,
+			ShippingAddress: "12 Trang Tien, Hanoi",
+			Items:           []domain.OrderItem{{ProductID: productID, ProductName: "Phone", Quantity: quantity}},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		strategy domain.AllocationStrategy
+		quantity int
+		expected []domain.OrderAllocation
+	}{
+		{
+			name:     "Priority ships from the highest priority warehouse",
+			strategy: domain.AllocationStrategyPriority,
+			quantity: 2,
+			expected: []domain.OrderAllocation{{OrderID: 10, ProductID: productID, WarehouseID: 2, Quantity: 2}},
+		},
+		{
+			name:     "Priority ships from a single warehouse when one has enough",
+			strategy: domain.AllocationStrategyPriority,
+			quantity: 3,
+			expected: []domain.OrderAllocation{{OrderID: 10, ProductID: productID, WarehouseID: 2, Quantity: 3}},
+		},
+		{
+			name:     "Nearest prefers the warehouse in the shipping region",
+			strategy: domain.AllocationStrategyNearest,
+			quantity: 2,
+			expected: []domain.OrderAllocation{{OrderID: 10, ProductID: productID, WarehouseID: 1, Quantity: 2}},
+		},
+		{
+			name:     "Split draws from warehouses in priority order",
+			strategy: domain.AllocationStrategySplit,
+			quantity: 6,
+			expected: []domain.OrderAllocation{
+				{OrderID: 10, ProductID: productID, WarehouseID: 2, Quantity: 4},
+				{OrderID: 10, ProductID: productID, WarehouseID: 1, Quantity: 2},
+			},
+		},
+		{
+			name:     "Priority splits when no warehouse has enough",
+			strategy: domain.AllocationStrategyPriority,
+			quantity: 7,
+			expected: []domain.OrderAllocation{
+				{OrderID: 10, ProductID: productID, WarehouseID: 2, Quantity: 4},
+				{OrderID: 10, ProductID: productID, WarehouseID: 1, Quantity: 3},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockInventoryRepo := new(MockInventoryRepository)
+			mockProductRepo := new(MockProductRepository)
+			inventoryService := service.NewInventoryService(mockInventoryRepo, nil, mockProductRepo, tt.strategy)
+
+			mockInventoryRepo.On("FindLevelsByProductID", ctx, productID).Return(warehouseLevels(productID), nil)
+			mockInventoryRepo.On("Allocate", ctx, tt.expected).Return(nil)
+
+			// Execute
+			err := inventoryService.AllocateOrder(ctx, newOrder(tt.quantity))
+
+			// Assert
+			assert.NoError(t, err)
+			mockInventoryRepo.AssertExpectations(t)
+		})
+	}
+
+	t.Run("Insufficient stock", func(t *testing.T) {
+		// Setup
+		mockInventoryRepo := new(MockInventoryRepository)
+		mockProductRepo := new(MockProductRepository)
+		inventoryService := service.NewInventoryService(mockInventoryRepo, nil, mockProductRepo, domain.AllocationStrategyPriority)
+
+		mockInventoryRepo.On("FindLevelsByProductID", ctx, productID).Return(warehouseLevels(productID), nil)
+
+		// Execute
+		err := inventoryService.AllocateOrder(ctx, newOrder(8))
+
+		// Assert
+		assert.Error(t, err)
+		mockInventoryRepo.AssertNotCalled(t, "Allocate", mock.Anything, mock.Anything)
+	})
+}
+
+func TestTransferStock(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		// Setup
+		mockInventoryRepo := new(MockInventoryRepository)
+		mockProductRepo := new(MockProductRepository)
+		inventoryService := service.NewInventoryService(mockInventoryRepo, nil, mockProductRepo, domain.AllocationStrategyPriority)
+
+		mockInventoryRepo.On("FindWarehouseByID", ctx, uint(1)).Return(&domain.Warehouse{ID: 1}, nil)
+		mockInventoryRepo.On("FindWarehouseByID", ctx, uint(2)).Return(&domain.Warehouse{ID: 2}, nil)
+		mockInventoryRepo.On("Transfer", ctx, mock.AnythingOfType("*domain.InventoryTransfer")).Return(nil)
+
+		// Execute
+		transfer, err := inventoryService.TransferStock(ctx, 5, 1, 2, 3)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, uint(5), transfer.ProductID)
+		assert.Equal(t, 3, transfer.Quantity)
+		mockInventoryRepo.AssertExpectations(t)
+	})
+
+	t.Run("Same warehouse", func(t *testing.T) {
+		// Setup
+		mockInventoryRepo := new(MockInventoryRepository)
+		mockProductRepo := new(MockProductRepository)
+		inventoryService := service.NewInventoryService(mockInventoryRepo, nil, mockProductRepo, domain.AllocationStrategyPriority)
+
+		// Execute
+		_, err := inventoryService.TransferStock(ctx, 5, 1, 1, 3)
+
+		// Assert
+		assert.Error(t, err)
+	})
+
+	t.Run("Insufficient stock at source", func(t *testing.T) {
+		// Setup
+		mockInventoryRepo := new(MockInventoryRepository)
+		mockProductRepo := new(MockProductRepository)
+		inventoryService := service.NewInventoryService(mockInventoryRepo, nil, mockProductRepo, domain.AllocationStrategyPriority)
+
+		mockInventoryRepo.On("FindWarehouseByID", ctx, uint(1)).Return(&domain.Warehouse{ID: 1}, nil)
+		mockInventoryRepo.On("FindWarehouseByID", ctx, uint(2)).Return(&domain.Warehouse{ID: 2}, nil)
+		mockInventoryRepo.On("Transfer", ctx, mock.AnythingOfType("*domain.InventoryTransfer")).Return(errors.New("insufficient stock at warehouse 1"))
+
+		// Execute
+		_, err := inventoryService.TransferStock(ctx, 5, 1, 2, 30)
+
+		// Assert
+		assert.Error(t, err)
+	})
+}
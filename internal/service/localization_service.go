@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+)
+
+// localePattern matches normalized locale tags such as "fr", "pt-BR" or "zh-Hant-TW"
+var localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// LocalizationService defines the interface for localized content business logic
+type LocalizationService interface {
+	// DefaultLocale returns the locale products and categories are written in
+	DefaultLocale() string
+
+	// Locale returns the locale the request prefers, or the default locale
+	// when it asked for none
+	Locale(ctx context.Context) string
+
+	// LocalizeProducts replaces the names and descriptions of products with
+	// their translations in the locales the request prefers
+	LocalizeProducts(ctx context.Context, products []domain.Product) error
+
+	// LocalizeProduct replaces the name and description of a product with its
+	// translation in the locales the request prefers
+	LocalizeProduct(ctx context.Context, product *domain.Product) error
+
+	// LocalizeCategories replaces the names of categories with their
+	// translations in the locales the request prefers
+	LocalizeCategories(ctx context.Context, categories []domain.ProductCategory) error
+
+	// GetProductTranslations retrieves every translation of a product
+	GetProductTranslations(ctx context.Context, productID uint) ([]domain.ProductTranslation, error)
+
+	// SetProductTranslation creates or updates the translation of a product in a locale
+	SetProductTranslation(ctx context.Context, translation *domain.ProductTranslation) error
+
+	// DeleteProductTranslation deletes the translation of a product in a locale
+	DeleteProductTranslation(ctx context.Context, productID uint, locale string) error
+
+	// GetCategoryTranslations retrieves every translation of a category
+	GetCategoryTranslations(ctx context.Context, categoryID uint) ([]domain.CategoryTranslation, error)
+
+	// SetCategoryTranslation creates or updates the translation of a category in a locale
+	SetCategoryTranslation(ctx context.Context, translation *domain.CategoryTranslation) error
+
+	// DeleteCategoryTranslation deletes the translation of a category in a locale
+	DeleteCategoryTranslation(ctx context.Context, categoryID uint, locale string) error
+}
+
+// LocalizationServiceImpl implements the LocalizationService interface
+type LocalizationServiceImpl struct {
+	translationRepo repository.TranslationRepository
+	productRepo     repository.ProductRepository
+	defaultLocale   string
+}
+
+// NewLocalizationService creates a new LocalizationServiceImpl. Products and
+// categories are written in defaultLocale.
+func NewLocalizationService(translationRepo repository.TranslationRepository, productRepo repository.ProductRepository, defaultLocale string) LocalizationService {
+	return &LocalizationServiceImpl{
+		translationRepo: translationRepo,
+		productRepo:     productRepo,
+		defaultLocale:   domain.NormalizeLocale(defaultLocale),
+	}
+}
+
+// DefaultLocale returns the locale products and categories are written in
+func (s *LocalizationServiceImpl) DefaultLocale() string {
+	return s.defaultLocale
+}
+
+// Locale returns the locale the request prefers, or the default locale
+// when it asked for none
+func (s *LocalizationServiceImpl) Locale(ctx context.Context) string {
+	return s.fallbacks(ctx)[0]
+}
+
+// LocalizeProducts replaces the names and descriptions of products with
+// their translations in the locales the request prefers. Each field falls
+// back along the locale chain to the product's own content.
+func (s *LocalizationServiceImpl) LocalizeProducts(ctx context.Context, products []domain.Product) error {
+	locales, rank := s.translatedLocales(ctx)
+	if len(locales) == 0 || len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, 0, len(products))
+	for _, product := range products {
+		ids = append(ids, product.ID)
+	}
+	translations, err := s.translationRepo.FindProductTranslations(ctx, ids, locales)
+	if err != nil {
+		return err
+	}
+
+	type best struct {
+		name, description         string
+		nameRank, descriptionRank int
+	}
+	byProduct := make(map[uint]*best)
+	for _, translation := range translations {
+		b, ok := byProduct[translation.ProductID]
+		if !ok {
+			b = &best{nameRank: len(locales), descriptionRank: len(locales)}
+			byProduct[translation.ProductID] = b
+		}
+		r := rank[translation.Locale]
+		if translation.Name != "" && r < b.nameRank {
+			b.name, b.nameRank = translation.Name, r
+		}
+		if translation.Description != "" && r < b.descriptionRank {
+			b.description, b.descriptionRank = translation.Description, r
+		}
+	}
+
+	for i := range products {
+		b, ok := byProduct[products[i].ID]
+		if !ok {
+			continue
+		}
+		if b.name != "" {
+			products[i].Name = b.name
+		}
+		if b.description != "" {
+			products[i].Description = b.description
+		}
+	}
+	return nil
+}
+
+// LocalizeProduct replaces the name and description of a product with its
+// translation in the locales the request prefers
+func (s *LocalizationServiceImpl) LocalizeProduct(ctx context.Context, product *domain.Product) error {
+	products := []domain.Product{*product}
+	if err := s.LocalizeProducts(ctx, products); err != nil {
+		return err
+	}
+	*product = products[0]
+	return nil
+}
+
+// LocalizeCategories replaces the names of categories with their
+// translations in the locales the request prefers
+func (s *LocalizationServiceImpl) LocalizeCategories(ctx context.Context, categories []domain.ProductCategory) error {
+	locales, rank := s.translatedLocales(ctx)
+	if len(locales) == 0 || len(categories) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, 0, len(categories))
+	for _, category := range categories {
+		ids = append(ids, category.ID)
+	}
+	translations, err := s.translationRepo.FindCategoryTranslations(ctx, ids, locales)
+	if err != nil {
+		return err
+	}
+
+	names := make(map[uint]domain.CategoryTranslation)
+	for _, translation := range translations {
+		if current, ok := names[translation.CategoryID]; !ok || rank[translation.Locale] < rank[current.Locale] {
+			names[translation.CategoryID] = translation
+		}
+	}
+
+	for i := range categories {
+		if translation, ok := names[categories[i].ID]; ok {
+			categories[i].Name = translation.Name
+		}
+	}
+	return nil
+}
+
+// GetProductTranslations retrieves every translation of a product
+func (s *LocalizationServiceImpl) GetProductTranslations(ctx context.Context, productID uint) ([]domain.ProductTranslation, error) {
+	return s.translationRepo.FindProductTranslationsByProductID(ctx, productID)
+}
+
+// SetProductTranslation creates or updates the translation of a product in a locale
+func (s *LocalizationServiceImpl) SetProductTranslation(ctx context.Context, translation *domain.ProductTranslation) error {
+	locale, err := s.validateLocale(translation.Locale)
+	if err != nil {
+		return err
+	}
+	if translation.Name == "" {
+		return errors.New("translated name is required")
+	}
+	if _, err := s.productRepo.FindByID(ctx, translation.ProductID); err != nil {
+		return errors.New("product not found")
+	}
+
+	translation.Locale = locale
+	return s.translationRepo.SaveProductTranslation(ctx, translation)
+}
+
+// DeleteProductTranslation deletes the translation of a product in a locale
+func (s *LocalizationServiceImpl) DeleteProductTranslation(ctx context.Context, productID uint, locale string) error {
+	return s.translationRepo.DeleteProductTranslation(ctx, productID, domain.NormalizeLocale(locale))
+}
+
+// GetCategoryTranslations retrieves every translation of a category
+func (s *LocalizationServiceImpl) GetCategoryTranslations(ctx context.Context, categoryID uint) ([]domain.CategoryTranslation, error) {
+	return s.translationRepo.FindCategoryTranslationsByCategoryID(ctx, categoryID)
+}
+
+// SetCategoryTranslation creates or updates the translation of a category in a locale
+func (s *LocalizationServiceImpl) SetCategoryTranslation(ctx context.Context, translation *domain.CategoryTranslation) error {
+	locale, err := s.validateLocale(translation.Locale)
+	if err != nil {
+		return err
+	}
+	if translation.Name == "" {
+		return errors.New("translated name is required")
+	}
+	if _, err := s.productRepo.FindCategoryByID(ctx, translation.CategoryID); err != nil {
+		return errors.New("category not found")
+	}
+
+	translation.Locale = locale
+	return s.translationRepo.SaveCategoryTranslation(ctx, translation)
+}
+
+// DeleteCategoryTranslation deletes the translation of a category in a locale
+func (s *LocalizationServiceImpl) DeleteCategoryTranslation(ctx context.Context, categoryID uint, locale string) error {
+	return s.translationRepo.DeleteCategoryTranslation(ctx, categoryID, domain.NormalizeLocale(locale))
+}
+
+// fallbacks returns the locale chain of the request, ending with the default locale
+func (s *LocalizationServiceImpl) fallbacks(ctx context.Context) []string {
+	return domain.LocaleFallbacks(domain.LocalesFromContext(ctx), s.defaultLocale)
+}
+
+// translatedLocales returns the locales of the request's chain that content
+// is translated into, with their rank in the chain. Content in the default
+// locale is the product's or category's own and always exists, so the
+// chain stops there.
+func (s *LocalizationServiceImpl) translatedLocales(ctx context.Context) ([]string, map[string]int) {
+	var locales []string
+	for _, locale := range s.fallbacks(ctx) {
+		if locale == s.defaultLocale {
+			break
+		}
+		locales = append(locales, locale)
+	}
+
+	rank := make(map[string]int, len(locales))
+	for i, locale := range locales {
+		rank[locale] = i
+	}
+	return locales, rank
+}
+
+// validateLocale normalizes the locale of a translation, which cannot be
+// the default locale
+func (s *LocalizationServiceImpl) validateLocale(locale string) (string, error) {
+	locale = domain.NormalizeLocale(locale)
+	if !localePattern.MatchString(locale) {
+		return "", errors.New("invalid locale")
+	}
+	if locale == s.defaultLocale {
+		return "", errors.New("content in the default locale is set on the product or category")
+	}
+	return locale, nil
+}
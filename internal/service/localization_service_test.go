@@ -0,0 +1,234 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockTranslationRepository struct {
+	mock.Mock
+}
+
+func (m *MockTranslationRepository) FindProductTranslations(ctx context.Context, productIDs []uint, locales []string) ([]domain.ProductTranslation, error) {
+	args := m.Called(ctx, productIDs, locales)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.ProductTranslation), args.Error(1)
+}
+
+func (m *MockTranslationRepository) FindProductTranslationsByProductID(ctx context.Context, productID uint) ([]domain.ProductTranslation, error) {
+	args := m.Called(ctx, productID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.ProductTranslation), args.Error(1)
+}
+
+func (m *MockTranslationRepository) SaveProductTranslation(ctx context.Context, translation *domain.ProductTranslation) error {
+	args := m.Called(ctx, translation)
+	return args.Error(0)
+}
+
+func (m *MockTranslationRepository) DeleteProductTranslation(ctx context.Context, productID uint, locale string) error {
+	args := m.Called(ctx, productID, locale)
+	return args.Error(0)
+}
+
+func (m *MockTranslationRepository) FindCategoryTranslations(ctx context.Context, categoryIDs []uint, locales []string) ([]domain.CategoryTranslation, error) {
+	args := m.Called(ctx, categoryIDs, locales)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.CategoryTranslation), args.Error(1)
+}
+
+func (m *MockTranslationRepository) FindCategoryTranslationsByCategoryID(ctx context.Context, categoryID uint) ([]domain.CategoryTranslation, error) {
+	args := m.Called(ctx, categoryID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.CategoryTranslation), args.Error(1)
+}
+
+func (m *MockTranslationRepository) SaveCategoryTranslation(ctx context.Context, translation *domain.CategoryTranslation) error {
+	args := m.Called(ctx, translation)
+	return args.Error(0)
+}
+
+func (m *MockTranslationRepository) DeleteCategoryTranslation(ctx context.Context, categoryID uint, locale string) error {
+	args := m.Called(ctx, categoryID, locale)
+	return args.Error(0)
+}
+
+func TestLocaleFallbacks(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested []string
+		want      []string
+	}{
+		{name: "Nothing requested", want: []string{"en"}},
+		{name: "Region falls back to language", requested: []string{"fr-ca"}, want: []string{"fr-CA", "fr", "en"}},
+		{name: "Several locales", requested: []string{"pt_BR", "es"}, want: []string{"pt-BR", "pt", "es", "en"}},
+		{name: "Script and region", requested: []string{"zh-hant-tw"}, want: []string{"zh-Hant-TW", "zh-Hant", "zh", "en"}},
+		{name: "Default requested", requested: []string{"en-GB", "fr"}, want: []string{"en-GB", "en", "fr"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, domain.LocaleFallbacks(tt.requested, "en"))
+		})
+	}
+}
+
+func TestLocalizeProducts(t *testing.T) {
+	// Setup
+	mockTranslationRepo := new(MockTranslationRepository)
+	localizationService := service.NewLocalizationService(mockTranslationRepo, new(MockProductRepository), "en")
+
+	ctx := domain.WithLocales(context.Background(), []string{"fr-CA"})
+	products := []domain.Product{
+		{ID: 1, Name: "Mug", Description: "A mug"},
+		{ID: 2, Name: "Coffee", Description: "A bag of coffee"},
+		{ID: 3, Name: "Teapot", Description: "A teapot"},
+	}
+	mockTranslationRepo.On("FindProductTranslations", ctx, []uint{1, 2, 3}, []string{"fr-CA", "fr"}).Return([]domain.ProductTranslation{
+		{ProductID: 1, Locale: "fr", Name: "Tasse", Description: "Une tasse"},
+		{ProductID: 1, Locale: "fr-CA", Name: "Tasse à café"},
+		{ProductID: 2, Locale: "fr", Name: "Café"},
+	}, nil)
+
+	// Execute
+	err := localizationService.LocalizeProducts(ctx, products)
+
+	// Assert: each field falls back on its own, down to the product's content
+	require.NoError(t, err)
+	assert.Equal(t, "Tasse à café", products[0].Name)
+	assert.Equal(t, "Une tasse", products[0].Description)
+	assert.Equal(t, "Café", products[1].Name)
+	assert.Equal(t, "A bag of coffee", products[1].Description)
+	assert.Equal(t, "Teapot", products[2].Name)
+	assert.Equal(t, "fr-CA", localizationService.Locale(ctx))
+}
+
+func TestLocalizeProductsInDefaultLocale(t *testing.T) {
+	// Setup
+	mockTranslationRepo := new(MockTranslationRepository)
+	localizationService := service.NewLocalizationService(mockTranslationRepo, new(MockProductRepository), "en")
+	products := []domain.Product{{ID: 1, Name: "Mug"}}
+
+	// Execute
+	err := localizationService.LocalizeProducts(context.Background(), products)
+
+	// Assert: products are written in the default locale
+	assert.NoError(t, err)
+	assert.Equal(t, "Mug", products[0].Name)
+	assert.Equal(t, "en", localizationService.Locale(context.Background()))
+	mockTranslationRepo.AssertNotCalled(t, "FindProductTranslations", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestLocalizeCategories(t *testing.T) {
+	// Setup
+	mockTranslationRepo := new(MockTranslationRepository)
+	localizationService := service.NewLocalizationService(mockTranslationRepo, new(MockProductRepository), "en")
+
+	ctx := domain.WithLocales(context.Background(), []string{"de-AT", "fr"})
+	categories := []domain.ProductCategory{{ID: 1, Name: "Kitchen"}, {ID: 2, Name: "Garden"}}
+	mockTranslationRepo.On("FindCategoryTranslations", ctx, []uint{1, 2}, []string{"de-AT", "de", "fr"}).Return([]domain.CategoryTranslation{
+		{CategoryID: 1, Locale: "fr", Name: "Cuisine"},
+		{CategoryID: 1, Locale: "de", Name: "Küche"},
+		{CategoryID: 2, Locale: "fr", Name: "Jardin"},
+	}, nil)
+
+	// Execute
+	err := localizationService.LocalizeCategories(ctx, categories)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "Küche", categories[0].Name)
+	assert.Equal(t, "Jardin", categories[1].Name)
+}
+
+func TestSetProductTranslation(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		translation domain.ProductTranslation
+		wantLocale  string
+		wantErr     string
+	}{
+		{name: "Invalid locale", translation: domain.ProductTranslation{ProductID: 1, Locale: "french", Name: "Tasse"}, wantErr: "invalid locale"},
+		{name: "Default locale", translation: domain.ProductTranslation{ProductID: 1, Locale: "EN", Name: "Mug"}, wantErr: "content in the default locale is set on the product or category"},
+		{name: "Missing name", translation: domain.ProductTranslation{ProductID: 1, Locale: "fr"}, wantErr: "translated name is required"},
+		{name: "Unknown product", translation: domain.ProductTranslation{ProductID: 99, Locale: "fr", Name: "Tasse"}, wantErr: "product not found"},
+		{name: "Success", translation: domain.ProductTranslation{ProductID: 1, Locale: "fr_ca", Name: "Tasse"}, wantLocale: "fr-CA"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockTranslationRepo := new(MockTranslationRepository)
+			mockProductRepo := new(MockProductRepository)
+			localizationService := service.NewLocalizationService(mockTranslationRepo, mockProductRepo, "en")
+			mockProductRepo.On("FindByID", ctx, uint(1)).Return(&domain.Product{ID: 1, Name: "Mug"}, nil)
+			mockProductRepo.On("FindByID", ctx, uint(99)).Return(nil, errors.New("record not found"))
+			mockTranslationRepo.On("SaveProductTranslation", ctx, mock.AnythingOfType("*domain.ProductTranslation")).Return(nil)
+
+			// Execute
+			translation := tt.translation
+			err := localizationService.SetProductTranslation(ctx, &translation)
+
+			// Assert
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				mockTranslationRepo.AssertNotCalled(t, "SaveProductTranslation", mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantLocale, translation.Locale)
+			mockTranslationRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreateOrderInLocale(t *testing.T) {
+	// Setup
+	mockOrderRepo := new(MockOrderRepository)
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(MockProductRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockTranslationRepo := new(MockTranslationRepository)
+	localizationService := service.NewLocalizationService(mockTranslationRepo, mockProductRepo, "en")
+	orderService := service.NewOrderService(mockOrderRepo, mockCartRepo, mockProductRepo, mockUserRepo, nil, service.WithLocalization(localizationService))
+
+	ctx := domain.WithLocales(context.Background(), []string{"fr"})
+	cart := &domain.Cart{ID: 1, UserID: 1, Items: []domain.CartItem{{ProductID: 1, Quantity: 2}}}
+	product := &domain.Product{ID: 1, Name: "Mug", Price: usd(800), Stock: 10}
+	mockUserRepo.On("FindByID", ctx, uint(1)).Return(&domain.User{ID: 1}, nil)
+	mockCartRepo.On("FindByUserID", ctx, uint(1)).Return(cart, nil)
+	mockProductRepo.On("FindByID", ctx, uint(1)).Return(product, nil)
+	mockTranslationRepo.On("FindProductTranslations", ctx, []uint{1}, []string{"fr"}).Return([]domain.ProductTranslation{
+		{ProductID: 1, Locale: "fr", Name: "Tasse"},
+	}, nil)
+	mockProductRepo.On("DecrementStock", ctx, mock.Anything, mock.Anything).Return(nil)
+	mockOrderRepo.On("Create", ctx, mock.AnythingOfType("*domain.Order")).Return(nil)
+	mockCartRepo.On("ClearCart", ctx, cart.ID).Return(nil)
+
+	// Execute
+	order, err := orderService.CreateOrder(ctx, 1, "1 Shipping St", "1 Billing St", 0)
+
+	// Assert: the item name is a snapshot in the order's locale
+	require.NoError(t, err)
+	assert.Equal(t, "fr", order.Locale)
+	assert.Equal(t, "Tasse", order.Items[0].ProductName)
+	assert.Equal(t, "Mug", product.Name)
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"slices"
 	"time"
 
 	"awesomeEcommerce/internal/domain"
@@ -18,11 +19,16 @@ type OrderService interface {
 	// GetOrdersByUserID retrieves orders by user ID with optional pagination
 	GetOrdersByUserID(ctx context.Context, userID uint, page, pageSize int) ([]domain.Order, int64, error)
 
-	// CreateOrder creates a new order from a cart
-	CreateOrder(ctx context.Context, userID uint, shippingAddress, billingAddress string) (*domain.Order, error)
+	// CreateOrder creates a new order from a cart, shipped with the chosen
+	// shipping method
+	CreateOrder(ctx context.Context, userID uint, shippingAddress, billingAddress string, shippingMethodID uint) (*domain.Order, error)
 
-	// UpdateOrderStatus updates the status of an order
-	UpdateOrderStatus(ctx context.Context, id uint, status domain.OrderStatus) error
+	// UpdateOrderStatus moves an order to a status its lifecycle allows,
+	// recording the change and its reason in its status history
+	UpdateOrderStatus(ctx context.Context, id uint, status domain.OrderStatus, reason string) error
+
+	// GetOrderTimeline retrieves the status history of an order, oldest first
+	GetOrderTimeline(ctx context.Context, orderID uint) ([]domain.OrderStatusHistory, error)
 
 	// CancelOrder cancels an order
 	CancelOrder(ctx context.Context, id uint) error
@@ -40,16 +46,104 @@ type OrderService interface {
 	GetOrderItems(ctx context.Context, orderID uint) ([]domain.OrderItem, error)
 
 	// GetOrderTotal calculates the total price of an order
-	GetOrderTotal(ctx context.Context, orderID uint) (float64, error)
+	GetOrderTotal(ctx context.Context, orderID uint) (domain.Money, error)
 }
 
 // OrderServiceImpl implements the OrderService interface
 type OrderServiceImpl struct {
-	orderRepo   repository.OrderRepository
-	cartRepo    repository.CartRepository
-	productRepo repository.ProductRepository
-	userRepo    repository.UserRepository
-	producer    *messaging.KafkaProducer
+	orderRepo    repository.OrderRepository
+	cartRepo     repository.CartRepository
+	productRepo  repository.ProductRepository
+	userRepo     repository.UserRepository
+	inventory    InventoryService
+	reservations ReservationService
+	backorders   BackorderService
+	bundles      BundleService
+	currencies   CurrencyService
+	localization LocalizationService
+	coupons      CouponService
+	promotions   PromotionService
+	taxes        TaxCalculator
+	shipping     ShippingService
+	abandoned    AbandonedCartService
+	payments     PaymentService
+	stateMachine OrderStateMachine
+	transactor   repository.Transactor
+	producer     *messaging.KafkaProducer
+}
+
+// OrderServiceOption sets an optional collaborator of an OrderServiceImpl.
+// Checkout skips the steps of the collaborators that are not set.
+type OrderServiceOption func(*OrderServiceImpl)
+
+// WithInventory allocates the stock of orders across warehouses
+func WithInventory(inventory InventoryService) OrderServiceOption {
+	return func(s *OrderServiceImpl) { s.inventory = inventory }
+}
+
+// WithReservations takes the stock of orders through checkout reservations
+func WithReservations(reservations ReservationService) OrderServiceOption {
+	return func(s *OrderServiceImpl) { s.reservations = reservations }
+}
+
+// WithBackorders backorders the lines of orders ordered beyond their stock
+func WithBackorders(backorders BackorderService) OrderServiceOption {
+	return func(s *OrderServiceImpl) { s.backorders = backorders }
+}
+
+// WithBundles orders bundles from the stock of their components
+func WithBundles(bundles BundleService) OrderServiceOption {
+	return func(s *OrderServiceImpl) { s.bundles = bundles }
+}
+
+// WithCurrencies places orders in the currency of the request
+func WithCurrencies(currencies CurrencyService) OrderServiceOption {
+	return func(s *OrderServiceImpl) { s.currencies = currencies }
+}
+
+// WithLocalization names order items in the locale of the request
+func WithLocalization(localization LocalizationService) OrderServiceOption {
+	return func(s *OrderServiceImpl) { s.localization = localization }
+}
+
+// WithCoupons applies the coupon of the cart to orders
+func WithCoupons(coupons CouponService) OrderServiceOption {
+	return func(s *OrderServiceImpl) { s.coupons = coupons }
+}
+
+// WithPromotions applies automatic promotions to orders
+func WithPromotions(promotions PromotionService) OrderServiceOption {
+	return func(s *OrderServiceImpl) { s.promotions = promotions }
+}
+
+// WithTaxes taxes orders at their destination
+func WithTaxes(taxes TaxCalculator) OrderServiceOption {
+	return func(s *OrderServiceImpl) { s.taxes = taxes }
+}
+
+// WithShipping charges orders the shipping method they chose
+func WithShipping(shipping ShippingService) OrderServiceOption {
+	return func(s *OrderServiceImpl) { s.shipping = shipping }
+}
+
+// WithAbandonedCarts records the abandoned carts orders recover
+func WithAbandonedCarts(abandoned AbandonedCartService) OrderServiceOption {
+	return func(s *OrderServiceImpl) { s.abandoned = abandoned }
+}
+
+// WithPayments refunds the payment of orders moved to refunded
+func WithPayments(payments PaymentService) OrderServiceOption {
+	return func(s *OrderServiceImpl) { s.payments = payments }
+}
+
+// WithStateMachine moves orders through another lifecycle than the default one
+func WithStateMachine(stateMachine OrderStateMachine) OrderServiceOption {
+	return func(s *OrderServiceImpl) { s.stateMachine = stateMachine }
+}
+
+// WithTransactor creates orders in a single database transaction
+func WithTransactor(transactor repository.Transactor) OrderServiceOption {
+	return func(s *OrderServiceImpl) { s.transactor = transactor }
 }
 
 // NewOrderService creates a new OrderServiceImpl
@@ -59,14 +153,23 @@ func NewOrderService(
 	productRepo repository.ProductRepository,
 	userRepo repository.UserRepository,
 	producer *messaging.KafkaProducer,
+	opts ...OrderServiceOption,
 ) OrderService {
-	return &OrderServiceImpl{
+	s := &OrderServiceImpl{
 		orderRepo:   orderRepo,
 		cartRepo:    cartRepo,
 		productRepo: productRepo,
 		userRepo:    userRepo,
 		producer:    producer,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.stateMachine == nil {
+		s.stateMachine = NewOrderStateMachine(domain.DefaultOrderTransitions(), DefaultOrderStatusGuards(nil))
+	}
+	return s
 }
 
 // GetOrderByID retrieves an order by its ID
@@ -85,10 +188,11 @@ func (s *OrderServiceImpl) GetOrdersByUserID(ctx context.Context, userID uint, p
 	return s.orderRepo.FindByUserID(ctx, userID, page, pageSize)
 }
 
-// CreateOrder creates a new order from a cart
-func (s *OrderServiceImpl) CreateOrder(ctx context.Context, userID uint, shippingAddress, billingAddress string) (*domain.Order, error) {
+// CreateOrder creates a new order from a cart, shipped with the chosen
+// shipping method. Orders that are not shipped need none.
+func (s *OrderServiceImpl) CreateOrder(ctx context.Context, userID uint, shippingAddress, billingAddress string, shippingMethodID uint) (*domain.Order, error) {
 	// Check if user exists
-	_, err := s.userRepo.FindByID(ctx, userID)
+	user, err := s.userRepo.FindByID(ctx, userID)
 	if err != nil {
 		return nil, errors.New("user not found")
 	}
@@ -104,63 +208,227 @@ func (s *OrderServiceImpl) CreateOrder(ctx context.Context, userID uint, shippin
 		return nil, errors.New("cart is empty")
 	}
 
-	// Calculate total amount
-	total, err := s.cartRepo.GetCartTotal(ctx, cart.ID)
+	// Prices are charged in the currency the customer shops in
+	conversion, err := s.conversion(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	// Create order items from cart items
 	var orderItems []domain.OrderItem
+	var lines []domain.PricedLine
+	var shortages []domain.StockShortage
+	var warnings, stockWarnings []domain.CartWarning
+	var parcel domain.Parcel
 	for _, cartItem := range cart.Items {
 		// Check if product exists and has enough stock
 		product, err := s.productRepo.FindByID(ctx, cartItem.ProductID)
 		if err != nil {
 			return nil, errors.New("product not found")
 		}
+		parcel = parcel.Add(product, cartItem.Quantity)
+
+		// Archived products are no longer sold, and a changed price must be
+		// accepted before the item is ordered at it
+		cartItem.Product = *product
+		if product.Archived {
+			warnings = append(warnings, domain.ArchivedWarning(&cartItem))
+			continue
+		}
+		if cartItem.PriceChanged() {
+			warnings = append(warnings, domain.PriceWarning(&cartItem, conversion))
+		}
 
-		if product.Stock < cartItem.Quantity {
-			return nil, errors.New("insufficient stock for product: " + product.Name)
+		// Bundles are recorded as a bundle line followed by a line per component
+		if product.IsBundle() {
+			bundleItems, bundleShortages, err := s.bundleLines(ctx, product, cartItem.Quantity, conversion)
+			if err != nil {
+				return nil, err
+			}
+			shortages = append(shortages, bundleShortages...)
+			stockWarnings = appendStockWarnings(stockWarnings, &cartItem, bundleShortages)
+			orderItems = append(orderItems, bundleItems...)
+			lines = append(lines, pricedLine(product, bundleItems[0]))
+			continue
+		}
+
+		_, backordered, err := s.splitLine(ctx, product, cartItem.Quantity)
+		var stockErr *domain.InsufficientStockError
+		if errors.As(err, &stockErr) {
+			shortages = append(shortages, stockErr.Shortages...)
+			stockWarnings = appendStockWarnings(stockWarnings, &cartItem, stockErr.Shortages)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name, err := s.productName(ctx, product)
+		if err != nil {
+			return nil, err
 		}
 
 		// Create order item
 		orderItem := domain.OrderItem{
-			ProductID:   product.ID,
-			ProductName: product.Name,
-			Price:       product.Price,
-			Quantity:    cartItem.Quantity,
+			ProductID:           product.ID,
+			ProductName:         name,
+			Price:               conversion.Convert(product.Price),
+			Quantity:            cartItem.Quantity,
+			BackorderedQuantity: backordered,
+			ProductType:         product.Type,
+			DeliveryMethod:      product.DeliveryMethod,
+		}
+		if backordered > 0 {
+			orderItem.ExpectedAt = product.AvailableAt
 		}
 		orderItems = append(orderItems, orderItem)
+		lines = append(lines, pricedLine(product, orderItem))
+	}
 
-		// Update product stock
-		err = s.productRepo.UpdateStock(ctx, product.ID, -cartItem.Quantity)
+	// Report every change to the cart at once, or else every line short of stock
+	if len(warnings) > 0 {
+		return nil, &domain.CartChangedError{Warnings: append(warnings, stockWarnings...)}
+	}
+	if len(shortages) > 0 {
+		return nil, &domain.InsufficientStockError{Shortages: shortages}
+	}
+
+	// Charge the sum of the converted lines, so the subtotal adds up to them
+	var subtotal domain.Money
+	for _, item := range orderItems {
+		if subtotal, err = subtotal.Add(item.Price.Multiply(item.Quantity)); err != nil {
+			return nil, err
+		}
+	}
+	if conversion.Currency != "" {
+		subtotal.Currency = conversion.Currency
+	}
+
+	// Take the running promotions off the subtotal, then the cart's coupon off
+	// what they leave. The coupon's use is counted as the order is saved,
+	// until the order is cancelled.
+	discounts, lines, err := applyPromotions(ctx, s.promotions, lines, conversion)
+	if err != nil {
+		return nil, err
+	}
+	var coupon *domain.Coupon
+	if cart.CouponCode != "" && s.coupons != nil {
+		var couponDiscounts []domain.OrderDiscount
+		coupon, couponDiscounts, err = s.coupons.Evaluate(ctx, userID, cart.CouponCode, lines, conversion)
 		if err != nil {
 			return nil, err
 		}
+		discounts = append(discounts, couponDiscounts...)
+		if lines, err = domain.NetLines(lines, couponDiscounts); err != nil {
+			return nil, err
+		}
+	}
+
+	// Ship with the method the customer chose, free shipping thresholds
+	// applying to what the discounts leave
+	discount, err := domain.DiscountTotal(discounts)
+	if err != nil {
+		return nil, err
+	}
+	if parcel.Subtotal, err = subtotal.Subtract(discount); err != nil {
+		return nil, err
+	}
+	shippingOption, err := s.shippingOption(ctx, shippingMethodID, parcel, conversion)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tax what the discounts leave, at the destination of the request
+	taxes, exemptions, err := calculateTax(ctx, s.taxes, user, lines)
+	if err != nil {
+		return nil, err
+	}
+	discounts = append(discounts, exemptions...)
+
+	mode := taxMode(s.taxes)
+	if discount, err = domain.DiscountTotal(discounts); err != nil {
+		return nil, err
+	}
+	if discount, err = (domain.Money{Currency: subtotal.Currency}).Add(discount); err != nil {
+		return nil, err
+	}
+	tax, err := domain.TaxTotal(taxes)
+	if err != nil {
+		return nil, err
+	}
+	if tax, err = (domain.Money{Currency: subtotal.Currency}).Add(tax); err != nil {
+		return nil, err
+	}
+	shipping := domain.Money{Currency: subtotal.Currency}
+	if shippingOption != nil {
+		if shipping, err = shipping.Add(shippingOption.Cost); err != nil {
+			return nil, err
+		}
+	}
+	total, err := mode.Total(subtotal, discount, tax, shipping)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create the order
 	order := &domain.Order{
 		UserID:          userID,
 		Items:           orderItems,
+		Discounts:       discounts,
+		Taxes:           taxes,
+		SubtotalAmount:  subtotal,
+		DiscountAmount:  discount,
+		TaxAmount:       tax,
+		ShippingAmount:  shipping,
 		TotalAmount:     total,
+		TaxMode:         mode,
+		TaxExempt:       user.TaxExempt,
+		Destination:     requestDestination(ctx),
+		ExchangeRate:    conversion.Rate,
+		Locale:          s.locale(ctx),
 		Status:          domain.OrderStatusPending,
 		ShippingAddress: shippingAddress,
 		BillingAddress:  billingAddress,
 	}
 
-	// Save the order
-	err = s.orderRepo.Create(ctx, order)
-	if err != nil {
-		return nil, err
+	if coupon != nil {
+		order.CouponID = &coupon.ID
+		order.CouponCode = coupon.Code
+	}
+	if shippingOption != nil {
+		order.ShippingMethodID = &shippingOption.MethodID
+		order.ShippingMethod = shippingOption.Name
+		order.ShippingCarrier = shippingOption.Carrier
+	}
+
+	// Orders of digital products only are delivered online, and orders
+	// picked up from the store need no address
+	pickup := shippingOption != nil && shippingOption.Type == domain.ShippingMethodPickup
+	if order.RequiresShipping() && shippingAddress == "" && !pickup {
+		return nil, errors.New("shipping address is required")
 	}
 
-	// Clear the cart
-	err = s.cartRepo.ClearCart(ctx, cart.ID)
+	// Save the order, take its stock and clear the cart as one unit of work,
+	// so a failure at any step leaves no order, stock and cart as they were
+	err = withinTransaction(ctx, s.transactor, func(ctx context.Context) error {
+		if err := s.orderRepo.Create(ctx, order); err != nil {
+			return err
+		}
+		if err := s.takeStock(ctx, order); err != nil {
+			return err
+		}
+		return s.cartRepo.ClearCart(ctx, cart.ID)
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	// Count the order as a recovery if the cart had been abandoned. The order
+	// stands even if recording that fails.
+	if s.abandoned != nil {
+		s.abandoned.RecordRecovery(ctx, cart.ID, order)
+	}
+
 	// Publish order created event
 	// Note: In a real application, we would serialize the order to JSON
 	// and publish it to Kafka. For simplicity, we're just logging here.
@@ -169,21 +437,221 @@ func (s *OrderServiceImpl) CreateOrder(ctx context.Context, userID uint, shippin
 	return order, nil
 }
 
-// UpdateOrderStatus updates the status of an order
-func (s *OrderServiceImpl) UpdateOrderStatus(ctx context.Context, id uint, status domain.OrderStatus) error {
+// takeStock holds the stock of a new order until it is paid, or takes it from
+// stock at once without reservations. Backordered units are taken as stock
+// arrives.
+func (s *OrderServiceImpl) takeStock(ctx context.Context, order *domain.Order) error {
+	if s.reservations != nil {
+		return s.reservations.ReserveOrder(ctx, order)
+	}
+
+	// Take every line from stock at once. The stock checked while pricing the
+	// cart may be stale by now, so the decrement itself refuses to take stock
+	// below zero.
+	stockLines := make([]domain.StockLine, 0, len(order.Items))
+	for _, item := range order.Items {
+		if item.InStockQuantity() > 0 {
+			stockLines = append(stockLines, domain.StockLine{ProductID: item.ProductID, Quantity: item.InStockQuantity()})
+		}
+	}
+	if len(stockLines) > 0 {
+		if err := s.productRepo.DecrementStock(ctx, stockLines, stockChange(ctx, domain.LedgerReasonOrder, order.ID)); err != nil {
+			return err
+		}
+	}
+
+	// Reserve the stock at the warehouses fulfilling the order
+	if s.inventory != nil {
+		return s.inventory.AllocateOrder(ctx, order)
+	}
+	return nil
+}
+
+// bundleLines builds the bundle line for quantity bundles and the component
+// lines taking their stock, which are priced at zero as the bundle line holds
+// the bundle price. Components short of stock are returned as shortages.
+func (s *OrderServiceImpl) bundleLines(ctx context.Context, bundle *domain.Product, quantity int, conversion domain.Conversion) ([]domain.OrderItem, []domain.StockShortage, error) {
+	if s.bundles == nil {
+		return nil, nil, errors.New("bundles are not supported")
+	}
+	components, err := s.bundles.GetComponents(ctx, bundle.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(components) == 0 {
+		return nil, nil, errors.New("bundle has no components")
+	}
+
+	bundleName, err := s.productName(ctx, bundle)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	items := []domain.OrderItem{{
+		ProductID:   bundle.ID,
+		ProductName: bundleName,
+		Price:       conversion.Convert(bundle.Price),
+		Quantity:    quantity,
+		ProductType: bundle.Type,
+	}}
+	var shortages []domain.StockShortage
+	for _, component := range components {
+		product := component.Product
+
+		_, backordered, err := s.splitLine(ctx, &product, quantity*component.Quantity)
+		var stockErr *domain.InsufficientStockError
+		if errors.As(err, &stockErr) {
+			shortages = append(shortages, stockErr.Shortages...)
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		name, err := s.productName(ctx, &product)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		item := domain.OrderItem{
+			ProductID:           product.ID,
+			ProductName:         name,
+			Quantity:            quantity * component.Quantity,
+			BackorderedQuantity: backordered,
+			ProductType:         product.Type,
+			DeliveryMethod:      product.DeliveryMethod,
+			BundleQuantity:      component.Quantity,
+		}
+		if backordered > 0 {
+			item.ExpectedAt = product.AvailableAt
+		}
+		items = append(items, item)
+	}
+
+	return items, shortages, nil
+}
+
+// pricedLine returns an order line as discounts see it
+func pricedLine(product *domain.Product, item domain.OrderItem) domain.PricedLine {
+	return domain.PricedLine{
+		ProductID:  product.ID,
+		CategoryID: product.CategoryID,
+		TaxClass:   product.TaxClass,
+		Quantity:   item.Quantity,
+		Total:      item.Price.Multiply(item.Quantity),
+	}
+}
+
+// conversion returns the conversion of base prices to the currency the
+// customer shops in. Without currency support prices stay as they are.
+func (s *OrderServiceImpl) conversion(ctx context.Context) (domain.Conversion, error) {
+	if s.currencies == nil {
+		return domain.Conversion{Rate: 1}, nil
+	}
+	return s.currencies.GetConversion(ctx)
+}
+
+// shippingOption returns the option of the shipping method the customer
+// chose for a parcel, nil when the parcel is not shipped or no method is
+// offered for its destination
+func (s *OrderServiceImpl) shippingOption(ctx context.Context, methodID uint, parcel domain.Parcel, conversion domain.Conversion) (*domain.ShippingOption, error) {
+	if s.shipping == nil || parcel.IsEmpty() {
+		return nil, nil
+	}
+
+	destination := requestDestination(ctx)
+	if methodID > 0 {
+		return s.shipping.Quote(ctx, methodID, destination, parcel, conversion)
+	}
+
+	options, err := s.shipping.Options(ctx, destination, parcel, conversion)
+	if err != nil {
+		return nil, err
+	}
+	if len(options) > 0 {
+		return nil, errors.New("shipping method is required")
+	}
+	return nil, nil
+}
+
+// withinTransaction runs fn as a single unit of work with a transactor, and
+// as it is without one
+func withinTransaction(ctx context.Context, transactor repository.Transactor, fn func(ctx context.Context) error) error {
+	if transactor == nil {
+		return fn(ctx)
+	}
+	return transactor.WithinTransaction(ctx, fn)
+}
+
+// appendStockWarnings appends the warnings for the shortages of a cart item
+func appendStockWarnings(warnings []domain.CartWarning, item *domain.CartItem, shortages []domain.StockShortage) []domain.CartWarning {
+	for _, shortage := range shortages {
+		warnings = append(warnings, domain.StockWarning(item, shortage))
+	}
+	return warnings
+}
+
+// locale returns the locale the customer shops in, empty without localized content
+func (s *OrderServiceImpl) locale(ctx context.Context) string {
+	if s.localization == nil {
+		return ""
+	}
+	return s.localization.Locale(ctx)
+}
+
+// productName returns the name of a product in the locale the customer shops in
+func (s *OrderServiceImpl) productName(ctx context.Context, product *domain.Product) (string, error) {
+	if s.localization == nil {
+		return product.Name, nil
+	}
+
+	localized := *product
+	if err := s.localization.LocalizeProduct(ctx, &localized); err != nil {
+		return "", err
+	}
+	return localized.Name, nil
+}
+
+// splitLine divides a line into the units taken from stock and the units
+// backordered. Without backorders every unit must be in stock.
+func (s *OrderServiceImpl) splitLine(ctx context.Context, product *domain.Product, quantity int) (int, int, error) {
+	if !product.TracksStock() {
+		return quantity, 0, nil
+	}
+	if s.backorders != nil {
+		return s.backorders.SplitLine(ctx, product, quantity)
+	}
+
+	if product.Stock < quantity {
+		return 0, 0, &domain.InsufficientStockError{Shortages: []domain.StockShortage{{
+			ProductID:   product.ID,
+			ProductName: product.Name,
+			Requested:   quantity,
+			Available:   product.Stock,
+		}}}
+	}
+	return quantity, 0, nil
+}
+
+// UpdateOrderStatus moves an order to a status its lifecycle allows,
+// recording the change and its reason in its status history. An order whose
+// payment is still held is refunded by refunding its payment, which moves it.
+func (s *OrderServiceImpl) UpdateOrderStatus(ctx context.Context, id uint, status domain.OrderStatus, reason string) error {
 	// Check if order exists
 	order, err := s.orderRepo.FindByID(ctx, id)
 	if err != nil {
 		return errors.New("order not found")
 	}
 
-	// Validate status transition
-	if !s.isValidStatusTransition(order.Status, status) {
-		return errors.New("invalid status transition")
+	if status == domain.OrderStatusRefunded && s.payments != nil {
+		refunded, err := s.refundPayment(ctx, order, reason)
+		if err != nil || refunded {
+			return err
+		}
 	}
 
 	// Update the status
-	err = s.orderRepo.UpdateStatus(ctx, id, status)
+	err = transitionOrder(ctx, s.orderRepo, s.stateMachine, order, status, orderStatusChange(ctx, reason))
 	if err != nil {
 		return err
 	}
@@ -196,33 +664,31 @@ func (s *OrderServiceImpl) UpdateOrderStatus(ctx context.Context, id uint, statu
 	return nil
 }
 
-// isValidStatusTransition checks if a status transition is valid
-func (s *OrderServiceImpl) isValidStatusTransition(from, to domain.OrderStatus) bool {
-	// Define valid transitions
-	validTransitions := map[domain.OrderStatus][]domain.OrderStatus{
-		domain.OrderStatusPending: {
-			domain.OrderStatusProcessing,
-			domain.OrderStatusCancelled,
-		},
-		domain.OrderStatusProcessing: {
-			domain.OrderStatusShipped,
-			domain.OrderStatusCancelled,
-		},
-		domain.OrderStatusShipped: {
-			domain.OrderStatusDelivered,
-		},
-		domain.OrderStatusDelivered: {},
-		domain.OrderStatusCancelled: {},
-	}
-
-	// Check if the transition is valid
-	for _, validTo := range validTransitions[from] {
-		if to == validTo {
-			return true
-		}
+// refundPayment refunds the payment of an order moving to refunded for a
+// reason, reporting whether it did. Orders without a payment left to refund
+// are not refunded by it.
+func (s *OrderServiceImpl) refundPayment(ctx context.Context, order *domain.Order, reason string) (bool, error) {
+	payment, err := s.payments.GetPaymentByOrderID(ctx, order.ID)
+	if err != nil || payment.Status != domain.PaymentStatusCompleted {
+		return false, nil
+	}
+
+	// Only refund orders their lifecycle lets be refunded
+	if !slices.Contains(s.stateMachine.Transitions(order.Status), domain.OrderStatusRefunded) {
+		return false, errors.New("invalid status transition")
 	}
+	return true, s.payments.RefundPayment(ctx, payment.ID, reason)
+}
+
+// GetOrderTimeline retrieves the status history of an order, oldest first
+func (s *OrderServiceImpl) GetOrderTimeline(ctx context.Context, orderID uint) ([]domain.OrderStatusHistory, error) {
+	return s.orderRepo.FindStatusHistory(ctx, orderID)
+}
 
-	return false
+// orderStatusChange records a status change made for a reason by whoever ctx
+// acts for
+func orderStatusChange(ctx context.Context, reason string) domain.OrderStatusChange {
+	return domain.OrderStatusChange{Reason: reason, Actor: actorFromContext(ctx)}
 }
 
 // CancelOrder cancels an order
@@ -234,22 +700,42 @@ func (s *OrderServiceImpl) CancelOrder(ctx context.Context, id uint) error {
 	}
 
 	// Check if order can be cancelled
-	if order.Status != domain.OrderStatusPending && order.Status != domain.OrderStatusProcessing {
+	if err := s.stateMachine.CanTransition(ctx, order, domain.OrderStatusCancelled); err != nil {
 		return errors.New("order cannot be cancelled")
 	}
 
-	// Update the status
-	err = s.orderRepo.UpdateStatus(ctx, id, domain.OrderStatusCancelled)
-	if err != nil {
-		return err
-	}
-
-	// Return items to inventory
-	for _, item := range order.Items {
-		err = s.productRepo.UpdateStock(ctx, item.ProductID, item.Quantity)
+	// The status and the stock returned by the cancellation change together
+	err = withinTransaction(ctx, s.transactor, func(ctx context.Context) error {
+		// Update the status
+		err := transitionOrder(ctx, s.orderRepo, s.stateMachine, order, domain.OrderStatusCancelled, orderStatusChange(ctx, "cancelled by customer"))
 		if err != nil {
 			return err
 		}
+
+		// Release the stock reservations of the order
+		if s.reservations != nil {
+			return s.reservations.ReleaseOrder(ctx, order, domain.LedgerReasonCancellation)
+		}
+
+		// Return items to inventory. Delivered license keys cannot be taken back.
+		for _, item := range order.Items {
+			if item.TakenFromStock() == 0 || item.IsDelivered() {
+				continue
+			}
+			err = s.productRepo.UpdateStock(ctx, item.ProductID, item.TakenFromStock(), stockChange(ctx, domain.LedgerReasonCancellation, id))
+			if err != nil {
+				return err
+			}
+		}
+
+		// Return warehouse allocations
+		if s.inventory != nil {
+			return s.inventory.ReleaseOrder(ctx, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	// Publish order cancelled event
@@ -292,6 +778,6 @@ func (s *OrderServiceImpl) GetOrderItems(ctx context.Context, orderID uint) ([]d
 }
 
 // GetOrderTotal calculates the total price of an order
-func (s *OrderServiceImpl) GetOrderTotal(ctx context.Context, orderID uint) (float64, error) {
+func (s *OrderServiceImpl) GetOrderTotal(ctx context.Context, orderID uint) (domain.Money, error) {
 	return s.orderRepo.GetOrderTotal(ctx, orderID)
-}
\ No newline at end of file
+}
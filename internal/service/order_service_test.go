@@ -2,7 +2,9 @@ package service_test
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"awesomeEcommerce/internal/domain"
 	"awesomeEcommerce/internal/service"
@@ -11,6 +13,36 @@ import (
 	"github.com/stretchr/testify/mock"
 )
 
+// usd returns an amount of US dollars in cents
+func usd(cents int64) domain.Money {
+	return domain.Money{Amount: cents, Currency: "USD"}
+}
+
+// transactionKey marks the context of work run by a fakeTransactor
+type transactionKey struct{}
+
+// fakeTransactor runs work in a pretend transaction, counting the
+// transactions that committed and rolled back
+type fakeTransactor struct {
+	committed  int
+	rolledBack int
+}
+
+func (f *fakeTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := fn(context.WithValue(ctx, transactionKey{}, true)); err != nil {
+		f.rolledBack++
+		return err
+	}
+	f.committed++
+	return nil
+}
+
+// inTransaction reports whether a context carries a fakeTransactor transaction
+func inTransaction(ctx context.Context) bool {
+	_, ok := ctx.Value(transactionKey{}).(bool)
+	return ok
+}
+
 // Mock repositories
 type MockOrderRepository struct {
 	mock.Mock
@@ -39,11 +71,19 @@ func (m *MockOrderRepository) Update(ctx context.Context, order *domain.Order) e
 	return args.Error(0)
 }
 
-func (m *MockOrderRepository) UpdateStatus(ctx context.Context, id uint, status domain.OrderStatus) error {
-	args := m.Called(ctx, id, status)
+func (m *MockOrderRepository) UpdateStatus(ctx context.Context, id uint, from, to domain.OrderStatus, change domain.OrderStatusChange) error {
+	args := m.Called(ctx, id, from, to, change)
 	return args.Error(0)
 }
 
+func (m *MockOrderRepository) FindStatusHistory(ctx context.Context, orderID uint) ([]domain.OrderStatusHistory, error) {
+	args := m.Called(ctx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.OrderStatusHistory), args.Error(1)
+}
+
 func (m *MockOrderRepository) Delete(ctx context.Context, id uint) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
@@ -69,9 +109,9 @@ func (m *MockOrderRepository) FindByStatus(ctx context.Context, status domain.Or
 	return args.Get(0).([]domain.Order), args.Get(1).(int64), args.Error(2)
 }
 
-func (m *MockOrderRepository) GetOrderTotal(ctx context.Context, orderID uint) (float64, error) {
+func (m *MockOrderRepository) GetOrderTotal(ctx context.Context, orderID uint) (domain.Money, error) {
 	args := m.Called(ctx, orderID)
-	return args.Get(0).(float64), args.Error(1)
+	return args.Get(0).(domain.Money), args.Error(1)
 }
 
 func (m *MockOrderRepository) FindByDateRange(ctx context.Context, startDate, endDate string, page, pageSize int) ([]domain.Order, int64, error) {
@@ -134,6 +174,11 @@ func (m *MockCartRepository) ClearCart(ctx context.Context, cartID uint) error {
 	return args.Error(0)
 }
 
+func (m *MockCartRepository) SetCouponCode(ctx context.Context, cartID uint, code string) error {
+	args := m.Called(ctx, cartID, code)
+	return args.Error(0)
+}
+
 func (m *MockCartRepository) GetCartItems(ctx context.Context, cartID uint) ([]domain.CartItem, error) {
 	args := m.Called(ctx, cartID)
 	if args.Get(0) == nil {
@@ -142,9 +187,17 @@ func (m *MockCartRepository) GetCartItems(ctx context.Context, cartID uint) ([]d
 	return args.Get(0).([]domain.CartItem), args.Error(1)
 }
 
-func (m *MockCartRepository) GetCartTotal(ctx context.Context, cartID uint) (float64, error) {
+func (m *MockCartRepository) GetCartTotal(ctx context.Context, cartID uint) (domain.Money, error) {
 	args := m.Called(ctx, cartID)
-	return args.Get(0).(float64), args.Error(1)
+	return args.Get(0).(domain.Money), args.Error(1)
+}
+
+func (m *MockCartRepository) FindIdle(ctx context.Context, since, before time.Time) ([]domain.Cart, error) {
+	args := m.Called(ctx, since, before)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Cart), args.Error(1)
 }
 
 type MockProductRepository struct {
@@ -192,11 +245,24 @@ func (m *MockProductRepository) FindBySKU(ctx context.Context, sku string) (*dom
 	return args.Get(0).(*domain.Product), args.Error(1)
 }
 
-func (m *MockProductRepository) UpdateStock(ctx context.Context, id uint, quantity int) error {
-	args := m.Called(ctx, id, quantity)
+func (m *MockProductRepository) UpdateStock(ctx context.Context, id uint, quantity int, change domain.StockChange) error {
+	args := m.Called(ctx, id, quantity, change)
+	return args.Error(0)
+}
+
+func (m *MockProductRepository) DecrementStock(ctx context.Context, lines []domain.StockLine, change domain.StockChange) error {
+	args := m.Called(ctx, lines, change)
 	return args.Error(0)
 }
 
+func (m *MockProductRepository) GetCacheStats(ctx context.Context) (map[string]domain.CacheStats, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]domain.CacheStats), args.Error(1)
+}
+
 func (m *MockProductRepository) FindCategories(ctx context.Context) ([]domain.ProductCategory, error) {
 	args := m.Called(ctx)
 	return args.Get(0).([]domain.ProductCategory), args.Error(1)
@@ -310,7 +376,7 @@ func TestGetOrderByID(t *testing.T) {
 	expectedOrder := &domain.Order{
 		ID:              orderID,
 		UserID:          uint(1),
-		TotalAmount:     100.0,
+		TotalAmount:     usd(10000),
 		Status:          domain.OrderStatusPending,
 		ShippingAddress: "123 Main St",
 		BillingAddress:  "123 Main St",
@@ -364,21 +430,20 @@ func TestCreateOrder(t *testing.T) {
 	product := &domain.Product{
 		ID:    uint(1),
 		Name:  "Test Product",
-		Price: 50.0,
+		Price: usd(5000),
 		Stock: 10,
 	}
 
 	// Expectations
 	mockUserRepo.On("FindByID", ctx, userID).Return(user, nil)
 	mockCartRepo.On("FindByUserID", ctx, userID).Return(cart, nil)
-	mockCartRepo.On("GetCartTotal", ctx, cart.ID).Return(100.0, nil)
 	mockProductRepo.On("FindByID", ctx, uint(1)).Return(product, nil)
-	mockProductRepo.On("UpdateStock", ctx, uint(1), -2).Return(nil)
+	mockProductRepo.On("DecrementStock", ctx, []domain.StockLine{{ProductID: 1, Quantity: 2}}, domain.StockChange{Reason: domain.LedgerReasonOrder, Actor: "system"}).Return(nil)
 	mockOrderRepo.On("Create", ctx, mock.AnythingOfType("*domain.Order")).Return(nil)
 	mockCartRepo.On("ClearCart", ctx, cart.ID).Return(nil)
 
 	// Execute
-	order, err := orderService.CreateOrder(ctx, userID, shippingAddress, billingAddress)
+	order, err := orderService.CreateOrder(ctx, userID, shippingAddress, billingAddress, 0)
 
 	// Assert
 	assert.NoError(t, err)
@@ -387,7 +452,7 @@ func TestCreateOrder(t *testing.T) {
 	assert.Equal(t, domain.OrderStatusPending, order.Status)
 	assert.Equal(t, shippingAddress, order.ShippingAddress)
 	assert.Equal(t, billingAddress, order.BillingAddress)
-	assert.Equal(t, 100.0, order.TotalAmount)
+	assert.Equal(t, usd(10000), order.TotalAmount)
 	assert.Len(t, order.Items, 1)
 
 	mockUserRepo.AssertExpectations(t)
@@ -396,6 +461,172 @@ func TestCreateOrder(t *testing.T) {
 	mockOrderRepo.AssertExpectations(t)
 }
 
+func TestCreateOrderWithChangedCart(t *testing.T) {
+	ctx := context.Background()
+	userID := uint(1)
+	user := &domain.User{ID: userID, Email: "user@example.com"}
+
+	t.Run("Unaccepted Price Change", func(t *testing.T) {
+		mockOrderRepo := new(MockOrderRepository)
+		mockCartRepo := new(MockCartRepository)
+		mockProductRepo := new(MockProductRepository)
+		mockUserRepo := new(MockUserRepository)
+		orderService := service.NewOrderService(mockOrderRepo, mockCartRepo, mockProductRepo, mockUserRepo, nil)
+
+		cart := &domain.Cart{ID: 1, UserID: userID, Items: []domain.CartItem{
+			{ID: 1, CartID: 1, ProductID: 1, Quantity: 2, Price: usd(4000)},
+			{ID: 2, CartID: 1, ProductID: 2, Quantity: 1, Price: usd(1000)},
+		}}
+
+		mockUserRepo.On("FindByID", ctx, userID).Return(user, nil)
+		mockCartRepo.On("FindByUserID", ctx, userID).Return(cart, nil)
+		mockProductRepo.On("FindByID", ctx, uint(1)).Return(&domain.Product{ID: 1, Name: "Dearer", Price: usd(5000), Stock: 10}, nil)
+		mockProductRepo.On("FindByID", ctx, uint(2)).Return(&domain.Product{ID: 2, Name: "Retired", Price: usd(1000), Stock: 10, Archived: true}, nil)
+
+		order, err := orderService.CreateOrder(ctx, userID, "123 Main St", "123 Main St", 0)
+
+		assert.Nil(t, order)
+		var changedErr *domain.CartChangedError
+		assert.ErrorAs(t, err, &changedErr)
+		assert.Equal(t, []domain.CartWarning{
+			{Type: domain.CartWarningPriceIncreased, CartItemID: 1, ProductID: 1, ProductName: "Dearer", PreviousPrice: usd(4000), CurrentPrice: usd(5000)},
+			{Type: domain.CartWarningProductArchived, CartItemID: 2, ProductID: 2, ProductName: "Retired"},
+		}, changedErr.Warnings)
+		mockOrderRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Accepted Price", func(t *testing.T) {
+		mockOrderRepo := new(MockOrderRepository)
+		mockCartRepo := new(MockCartRepository)
+		mockProductRepo := new(MockProductRepository)
+		mockUserRepo := new(MockUserRepository)
+		orderService := service.NewOrderService(mockOrderRepo, mockCartRepo, mockProductRepo, mockUserRepo, nil)
+
+		cart := &domain.Cart{ID: 1, UserID: userID, Items: []domain.CartItem{
+			{ID: 1, CartID: 1, ProductID: 1, Quantity: 2, Price: usd(5000)},
+		}}
+
+		mockUserRepo.On("FindByID", ctx, userID).Return(user, nil)
+		mockCartRepo.On("FindByUserID", ctx, userID).Return(cart, nil)
+		mockProductRepo.On("FindByID", ctx, uint(1)).Return(&domain.Product{ID: 1, Name: "Dearer", Price: usd(5000), Stock: 10}, nil)
+		mockProductRepo.On("DecrementStock", ctx, []domain.StockLine{{ProductID: 1, Quantity: 2}}, domain.StockChange{Reason: domain.LedgerReasonOrder, Actor: "system"}).Return(nil)
+		mockOrderRepo.On("Create", ctx, mock.AnythingOfType("*domain.Order")).Return(nil)
+		mockCartRepo.On("ClearCart", ctx, cart.ID).Return(nil)
+
+		order, err := orderService.CreateOrder(ctx, userID, "123 Main St", "123 Main St", 0)
+
+		assert.NoError(t, err)
+		assert.Equal(t, usd(10000), order.TotalAmount)
+		mockOrderRepo.AssertExpectations(t)
+	})
+}
+
+func TestCreateOrderRollsBack(t *testing.T) {
+	ctx := context.Background()
+	userID := uint(1)
+	cart := &domain.Cart{ID: 1, UserID: userID, Items: []domain.CartItem{{ID: 1, CartID: 1, ProductID: 1, Quantity: 2}}}
+	product := &domain.Product{ID: 1, Name: "Test Product", Price: usd(5000), Stock: 10}
+	stockLines := []domain.StockLine{{ProductID: 1, Quantity: 2}}
+	level := domain.InventoryLevel{ProductID: 1, WarehouseID: 1, Quantity: 10, Warehouse: domain.Warehouse{ID: 1, Active: true}}
+
+	tests := []struct {
+		name   string
+		expect func(orderRepo *MockOrderRepository, productRepo *MockProductRepository, cartRepo *MockCartRepository, inventoryRepo *MockInventoryRepository)
+	}{
+		{
+			name: "Order Not Saved",
+			expect: func(orderRepo *MockOrderRepository, productRepo *MockProductRepository, cartRepo *MockCartRepository, inventoryRepo *MockInventoryRepository) {
+				orderRepo.On("Create", mock.MatchedBy(inTransaction), mock.AnythingOfType("*domain.Order")).Return(errors.New("database error"))
+			},
+		},
+		{
+			name: "Stock Not Taken",
+			expect: func(orderRepo *MockOrderRepository, productRepo *MockProductRepository, cartRepo *MockCartRepository, inventoryRepo *MockInventoryRepository) {
+				orderRepo.On("Create", mock.MatchedBy(inTransaction), mock.AnythingOfType("*domain.Order")).Return(nil)
+				productRepo.On("DecrementStock", mock.MatchedBy(inTransaction), stockLines, mock.AnythingOfType("domain.StockChange")).Return(errors.New("database error"))
+			},
+		},
+		{
+			name: "Warehouse Stock Not Allocated",
+			expect: func(orderRepo *MockOrderRepository, productRepo *MockProductRepository, cartRepo *MockCartRepository, inventoryRepo *MockInventoryRepository) {
+				orderRepo.On("Create", mock.MatchedBy(inTransaction), mock.AnythingOfType("*domain.Order")).Return(nil)
+				productRepo.On("DecrementStock", mock.MatchedBy(inTransaction), stockLines, mock.AnythingOfType("domain.StockChange")).Return(nil)
+				inventoryRepo.On("FindLevelsByProductID", mock.MatchedBy(inTransaction), uint(1)).Return([]domain.InventoryLevel{level}, nil)
+				inventoryRepo.On("Allocate", mock.MatchedBy(inTransaction), mock.Anything).Return(errors.New("database error"))
+			},
+		},
+		{
+			name: "Cart Not Cleared",
+			expect: func(orderRepo *MockOrderRepository, productRepo *MockProductRepository, cartRepo *MockCartRepository, inventoryRepo *MockInventoryRepository) {
+				orderRepo.On("Create", mock.MatchedBy(inTransaction), mock.AnythingOfType("*domain.Order")).Return(nil)
+				productRepo.On("DecrementStock", mock.MatchedBy(inTransaction), stockLines, mock.AnythingOfType("domain.StockChange")).Return(nil)
+				inventoryRepo.On("FindLevelsByProductID", mock.MatchedBy(inTransaction), uint(1)).Return([]domain.InventoryLevel{level}, nil)
+				inventoryRepo.On("Allocate", mock.MatchedBy(inTransaction), mock.Anything).Return(nil)
+				cartRepo.On("ClearCart", mock.MatchedBy(inTransaction), cart.ID).Return(errors.New("database error"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockOrderRepo := new(MockOrderRepository)
+			mockCartRepo := new(MockCartRepository)
+			mockProductRepo := new(MockProductRepository)
+			mockUserRepo := new(MockUserRepository)
+			mockInventoryRepo := new(MockInventoryRepository)
+			transactor := new(fakeTransactor)
+			inventoryService := service.NewInventoryService(mockInventoryRepo, nil, mockProductRepo, domain.AllocationStrategyPriority)
+			orderService := service.NewOrderService(mockOrderRepo, mockCartRepo, mockProductRepo, mockUserRepo, nil, service.WithInventory(inventoryService), service.WithTransactor(transactor))
+
+			mockUserRepo.On("FindByID", ctx, userID).Return(&domain.User{ID: userID}, nil)
+			mockCartRepo.On("FindByUserID", ctx, userID).Return(cart, nil)
+			mockProductRepo.On("FindByID", ctx, uint(1)).Return(product, nil)
+			tt.expect(mockOrderRepo, mockProductRepo, mockCartRepo, mockInventoryRepo)
+
+			order, err := orderService.CreateOrder(ctx, userID, "123 Main St", "123 Main St", 0)
+
+			// Every step runs in the transaction, which rolls back as a whole
+			// rather than being undone step by step
+			assert.Nil(t, order)
+			assert.EqualError(t, err, "database error")
+			assert.Equal(t, 1, transactor.rolledBack)
+			assert.Equal(t, 0, transactor.committed)
+			mockOrderRepo.AssertExpectations(t)
+			mockProductRepo.AssertExpectations(t)
+			mockCartRepo.AssertExpectations(t)
+			mockInventoryRepo.AssertExpectations(t)
+			mockOrderRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			mockProductRepo.AssertNotCalled(t, "UpdateStock", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("Committed", func(t *testing.T) {
+		mockOrderRepo := new(MockOrderRepository)
+		mockCartRepo := new(MockCartRepository)
+		mockProductRepo := new(MockProductRepository)
+		mockUserRepo := new(MockUserRepository)
+		transactor := new(fakeTransactor)
+		orderService := service.NewOrderService(mockOrderRepo, mockCartRepo, mockProductRepo, mockUserRepo, nil, service.WithTransactor(transactor))
+
+		mockUserRepo.On("FindByID", ctx, userID).Return(&domain.User{ID: userID}, nil)
+		mockCartRepo.On("FindByUserID", ctx, userID).Return(cart, nil)
+		mockProductRepo.On("FindByID", ctx, uint(1)).Return(product, nil)
+		mockOrderRepo.On("Create", mock.MatchedBy(inTransaction), mock.AnythingOfType("*domain.Order")).Return(nil)
+		mockProductRepo.On("DecrementStock", mock.MatchedBy(inTransaction), stockLines, mock.AnythingOfType("domain.StockChange")).Return(nil)
+		mockCartRepo.On("ClearCart", mock.MatchedBy(inTransaction), cart.ID).Return(nil)
+
+		order, err := orderService.CreateOrder(ctx, userID, "123 Main St", "123 Main St", 0)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, order)
+		assert.Equal(t, 1, transactor.committed)
+		assert.Equal(t, 0, transactor.rolledBack)
+		mockOrderRepo.AssertExpectations(t)
+		mockProductRepo.AssertExpectations(t)
+		mockCartRepo.AssertExpectations(t)
+	})
+}
+
 func TestUpdateOrderStatus(t *testing.T) {
 	// Setup
 	mockOrderRepo := new(MockOrderRepository)
@@ -418,16 +649,189 @@ func TestUpdateOrderStatus(t *testing.T) {
 
 	// Expectations
 	mockOrderRepo.On("FindByID", ctx, orderID).Return(order, nil)
-	mockOrderRepo.On("UpdateStatus", ctx, orderID, newStatus).Return(nil)
+	mockOrderRepo.On("UpdateStatus", ctx, orderID, currentStatus, newStatus, domain.OrderStatusChange{Actor: "system"}).Return(nil)
 
 	// Execute
-	err := orderService.UpdateOrderStatus(ctx, orderID, newStatus)
+	err := orderService.UpdateOrderStatus(ctx, orderID, newStatus, "")
 
 	// Assert
 	assert.NoError(t, err)
 	mockOrderRepo.AssertExpectations(t)
 }
 
+func TestUpdateOrderStatusHistory(t *testing.T) {
+	t.Run("Reason And Actor", func(t *testing.T) {
+		mockOrderRepo := new(MockOrderRepository)
+		orderService := service.NewOrderService(mockOrderRepo, new(MockCartRepository), new(MockProductRepository), new(MockUserRepository), nil)
+
+		// An administrator holds the order, giving the reason with the request
+		ctx := domain.WithUserID(context.Background(), 9)
+		order := &domain.Order{ID: 1, Status: domain.OrderStatusPending}
+
+		mockOrderRepo.On("FindByID", ctx, uint(1)).Return(order, nil)
+		mockOrderRepo.On("UpdateStatus", ctx, uint(1), domain.OrderStatusPending, domain.OrderStatusOnHold, domain.OrderStatusChange{Reason: "address needs checking", Actor: "user:9"}).Return(nil)
+
+		err := orderService.UpdateOrderStatus(ctx, 1, domain.OrderStatusOnHold, "address needs checking")
+
+		assert.NoError(t, err)
+		mockOrderRepo.AssertExpectations(t)
+	})
+
+	t.Run("Status Changed Meanwhile", func(t *testing.T) {
+		mockOrderRepo := new(MockOrderRepository)
+		orderService := service.NewOrderService(mockOrderRepo, new(MockCartRepository), new(MockProductRepository), new(MockUserRepository), nil)
+		ctx := context.Background()
+
+		// The order was pending when checked, but another change moved it
+		// before the hold was written
+		mockOrderRepo.On("FindByID", ctx, uint(1)).Return(&domain.Order{ID: 1, Status: domain.OrderStatusPending}, nil)
+		mockOrderRepo.On("UpdateStatus", ctx, uint(1), domain.OrderStatusPending, domain.OrderStatusOnHold, mock.Anything).Return(domain.ErrOrderStatusChanged)
+
+		err := orderService.UpdateOrderStatus(ctx, 1, domain.OrderStatusOnHold, "")
+
+		assert.ErrorIs(t, err, domain.ErrOrderStatusChanged)
+		mockOrderRepo.AssertExpectations(t)
+	})
+
+	t.Run("Invalid Transition", func(t *testing.T) {
+		mockOrderRepo := new(MockOrderRepository)
+		orderService := service.NewOrderService(mockOrderRepo, new(MockCartRepository), new(MockProductRepository), new(MockUserRepository), nil)
+		ctx := context.Background()
+
+		mockOrderRepo.On("FindByID", ctx, uint(1)).Return(&domain.Order{ID: 1, Status: domain.OrderStatusDelivered}, nil)
+
+		err := orderService.UpdateOrderStatus(ctx, 1, domain.OrderStatusProcessing, "")
+
+		assert.EqualError(t, err, "invalid status transition")
+		mockOrderRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Guard Rejects", func(t *testing.T) {
+		mockOrderRepo := new(MockOrderRepository)
+		orderService := service.NewOrderService(mockOrderRepo, new(MockCartRepository), new(MockProductRepository), new(MockUserRepository), nil)
+		ctx := context.Background()
+
+		// Part of the order still waits for stock, so it cannot ship in full
+		order := &domain.Order{ID: 1, Status: domain.OrderStatusProcessing, Items: []domain.OrderItem{
+			{ID: 1, ProductID: 1, Quantity: 2, BackorderedQuantity: 1},
+		}}
+		mockOrderRepo.On("FindByID", ctx, uint(1)).Return(order, nil)
+
+		err := orderService.UpdateOrderStatus(ctx, 1, domain.OrderStatusShipped, "")
+
+		assert.EqualError(t, err, "order has backordered items awaiting stock")
+		mockOrderRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Configured Transitions", func(t *testing.T) {
+		mockOrderRepo := new(MockOrderRepository)
+		transitions, err := domain.ParseOrderTransitions("pending:processing;processing:delivered")
+		assert.NoError(t, err)
+		stateMachine := service.NewOrderStateMachine(transitions, nil)
+		orderService := service.NewOrderService(mockOrderRepo, new(MockCartRepository), new(MockProductRepository), new(MockUserRepository), nil, service.WithStateMachine(stateMachine))
+		ctx := context.Background()
+
+		// Without the cancellation transition, orders can no longer be cancelled
+		mockOrderRepo.On("FindByID", ctx, uint(1)).Return(&domain.Order{ID: 1, Status: domain.OrderStatusPending}, nil)
+
+		err = orderService.CancelOrder(ctx, 1)
+
+		assert.EqualError(t, err, "order cannot be cancelled")
+		mockOrderRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestUpdateOrderStatusRefunded(t *testing.T) {
+	t.Run("Refunds The Payment", func(t *testing.T) {
+		mockOrderRepo := new(MockOrderRepository)
+		mockPaymentRepo := new(MockPaymentRepository)
+		stateMachine := service.NewOrderStateMachine(domain.DefaultOrderTransitions(), service.DefaultOrderStatusGuards(mockPaymentRepo))
+		paymentService := service.NewPaymentService(mockPaymentRepo, mockOrderRepo, stateMachine, nil, nil, nil, nil, nil)
+		orderService := service.NewOrderService(mockOrderRepo, new(MockCartRepository), new(MockProductRepository), new(MockUserRepository), nil,
+			service.WithPayments(paymentService), service.WithStateMachine(stateMachine))
+		ctx := context.Background()
+
+		// The order was paid but never shipped, so refunding it gives the
+		// payment back, which moves the order
+		order := &domain.Order{ID: 1, Status: domain.OrderStatusProcessing}
+		payment := &domain.Payment{ID: 3, OrderID: 1, Amount: usd(10000), Status: domain.PaymentStatusCompleted}
+		refunded := &domain.Payment{ID: 3, OrderID: 1, Amount: usd(10000), Status: domain.PaymentStatusRefunded}
+		mockOrderRepo.On("FindByID", ctx, uint(1)).Return(order, nil)
+		mockPaymentRepo.On("FindByOrderID", ctx, uint(1)).Return(payment, nil).Once()
+		mockPaymentRepo.On("FindByID", ctx, uint(3)).Return(payment, nil).Once()
+		mockPaymentRepo.On("UpdateStatus", ctx, uint(3), domain.PaymentStatusRefunded).Return(nil).Once()
+		mockPaymentRepo.On("FindByOrderID", ctx, uint(1)).Return(refunded, nil).Once()
+		mockOrderRepo.On("UpdateStatus", ctx, uint(1), domain.OrderStatusProcessing, domain.OrderStatusRefunded, domain.OrderStatusChange{Reason: "customer changed their mind", Actor: "system"}).Return(nil).Once()
+
+		err := orderService.UpdateOrderStatus(ctx, 1, domain.OrderStatusRefunded, "customer changed their mind")
+
+		assert.NoError(t, err)
+		mockOrderRepo.AssertExpectations(t)
+		mockPaymentRepo.AssertExpectations(t)
+	})
+
+	t.Run("Payment Already Refunded", func(t *testing.T) {
+		mockOrderRepo := new(MockOrderRepository)
+		mockPaymentRepo := new(MockPaymentRepository)
+		stateMachine := service.NewOrderStateMachine(domain.DefaultOrderTransitions(), service.DefaultOrderStatusGuards(mockPaymentRepo))
+		paymentService := service.NewPaymentService(mockPaymentRepo, mockOrderRepo, stateMachine, nil, nil, nil, nil, nil)
+		orderService := service.NewOrderService(mockOrderRepo, new(MockCartRepository), new(MockProductRepository), new(MockUserRepository), nil,
+			service.WithPayments(paymentService), service.WithStateMachine(stateMachine))
+		ctx := context.Background()
+
+		// The payment was refunded at the payment provider, so the order only
+		// records it
+		order := &domain.Order{ID: 1, Status: domain.OrderStatusReturned}
+		refunded := &domain.Payment{ID: 3, OrderID: 1, Amount: usd(10000), Status: domain.PaymentStatusRefunded}
+		mockOrderRepo.On("FindByID", ctx, uint(1)).Return(order, nil)
+		mockPaymentRepo.On("FindByOrderID", ctx, uint(1)).Return(refunded, nil)
+		mockOrderRepo.On("UpdateStatus", ctx, uint(1), domain.OrderStatusReturned, domain.OrderStatusRefunded, domain.OrderStatusChange{Reason: "refunded by bank transfer", Actor: "system"}).Return(nil).Once()
+
+		err := orderService.UpdateOrderStatus(ctx, 1, domain.OrderStatusRefunded, "refunded by bank transfer")
+
+		assert.NoError(t, err)
+		mockOrderRepo.AssertExpectations(t)
+		mockPaymentRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Invalid Transition", func(t *testing.T) {
+		mockOrderRepo := new(MockOrderRepository)
+		mockPaymentRepo := new(MockPaymentRepository)
+		paymentService := service.NewPaymentService(mockPaymentRepo, mockOrderRepo, nil, nil, nil, nil, nil, nil)
+		orderService := service.NewOrderService(mockOrderRepo, new(MockCartRepository), new(MockProductRepository), new(MockUserRepository), nil, service.WithPayments(paymentService))
+		ctx := context.Background()
+
+		// A shipped order is returned before it is refunded, so its payment
+		// is kept
+		mockOrderRepo.On("FindByID", ctx, uint(1)).Return(&domain.Order{ID: 1, Status: domain.OrderStatusShipped}, nil)
+		mockPaymentRepo.On("FindByOrderID", ctx, uint(1)).Return(&domain.Payment{ID: 3, OrderID: 1, Status: domain.PaymentStatusCompleted}, nil)
+
+		err := orderService.UpdateOrderStatus(ctx, 1, domain.OrderStatusRefunded, "")
+
+		assert.EqualError(t, err, "invalid status transition")
+		mockPaymentRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+		mockOrderRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestGetOrderTimeline(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	orderService := service.NewOrderService(mockOrderRepo, new(MockCartRepository), new(MockProductRepository), new(MockUserRepository), nil)
+	ctx := context.Background()
+
+	expectedHistory := []domain.OrderStatusHistory{
+		{ID: 1, OrderID: 1, ToStatus: domain.OrderStatusPending, Actor: "user:2", Reason: "order placed"},
+		{ID: 2, OrderID: 1, FromStatus: domain.OrderStatusPending, ToStatus: domain.OrderStatusProcessing, Actor: "system", Reason: "payment completed"},
+	}
+	mockOrderRepo.On("FindStatusHistory", ctx, uint(1)).Return(expectedHistory, nil)
+
+	history, err := orderService.GetOrderTimeline(ctx, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedHistory, history)
+	mockOrderRepo.AssertExpectations(t)
+}
+
 func TestCancelOrder(t *testing.T) {
 	// Setup
 	mockOrderRepo := new(MockOrderRepository)
@@ -456,8 +860,8 @@ func TestCancelOrder(t *testing.T) {
 
 	// Expectations
 	mockOrderRepo.On("FindByID", ctx, orderID).Return(order, nil)
-	mockOrderRepo.On("UpdateStatus", ctx, orderID, domain.OrderStatusCancelled).Return(nil)
-	mockProductRepo.On("UpdateStock", ctx, uint(1), 2).Return(nil)
+	mockOrderRepo.On("UpdateStatus", ctx, orderID, domain.OrderStatusPending, domain.OrderStatusCancelled, domain.OrderStatusChange{Reason: "cancelled by customer", Actor: "system"}).Return(nil)
+	mockProductRepo.On("UpdateStock", ctx, uint(1), 2, domain.StockChange{Reason: domain.LedgerReasonCancellation, ReferenceID: orderID, Actor: "system"}).Return(nil)
 
 	// Execute
 	err := orderService.CancelOrder(ctx, orderID)
@@ -468,6 +872,37 @@ func TestCancelOrder(t *testing.T) {
 	mockProductRepo.AssertExpectations(t)
 }
 
+func TestCancelOrderRollsBack(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	mockProductRepo := new(MockProductRepository)
+	transactor := new(fakeTransactor)
+	orderService := service.NewOrderService(mockOrderRepo, new(MockCartRepository), mockProductRepo, new(MockUserRepository), nil, service.WithTransactor(transactor))
+	ctx := context.Background()
+
+	order := &domain.Order{
+		ID:     1,
+		Status: domain.OrderStatusPending,
+		Items: []domain.OrderItem{
+			{ID: 1, OrderID: 1, ProductID: 1, Quantity: 2},
+			{ID: 2, OrderID: 1, ProductID: 2, Quantity: 1},
+		},
+	}
+	mockOrderRepo.On("FindByID", ctx, uint(1)).Return(order, nil)
+	mockOrderRepo.On("UpdateStatus", mock.MatchedBy(inTransaction), uint(1), domain.OrderStatusPending, domain.OrderStatusCancelled, mock.Anything).Return(nil).Once()
+	mockProductRepo.On("UpdateStock", mock.MatchedBy(inTransaction), uint(1), 2, mock.Anything).Return(nil).Once()
+	mockProductRepo.On("UpdateStock", mock.MatchedBy(inTransaction), uint(2), 1, mock.Anything).Return(errors.New("database error")).Once()
+
+	err := orderService.CancelOrder(ctx, 1)
+
+	// The order stays open with its stock, rather than cancelled with part
+	// of it returned
+	assert.EqualError(t, err, "database error")
+	assert.Equal(t, 1, transactor.rolledBack)
+	assert.Equal(t, 0, transactor.committed)
+	mockOrderRepo.AssertExpectations(t)
+	mockProductRepo.AssertExpectations(t)
+}
+
 func TestGetOrdersByUserID(t *testing.T) {
 	// Setup
 	mockOrderRepo := new(MockOrderRepository)
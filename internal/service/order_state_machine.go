@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+)
+
+// OrderStatusGuard is a hook deciding whether an order may move to a status
+// its current status allows, returning the reason it may not
+type OrderStatusGuard func(ctx context.Context, order *domain.Order, to domain.OrderStatus) error
+
+// OrderStateMachine defines the interface for the order lifecycle
+type OrderStateMachine interface {
+	// Transitions returns the statuses an order in a status can move to
+	Transitions(from domain.OrderStatus) []domain.OrderStatus
+
+	// CanTransition returns an error unless the order may move to a status:
+	// the transition has to be configured and pass the guards of the status
+	CanTransition(ctx context.Context, order *domain.Order, to domain.OrderStatus) error
+}
+
+// ConfiguredOrderStateMachine implements the OrderStateMachine interface
+// with configured transitions and the guards registered for each status
+type ConfiguredOrderStateMachine struct {
+	transitions domain.OrderTransitions
+	guards      map[domain.OrderStatus][]OrderStatusGuard
+}
+
+// NewOrderStateMachine creates a new ConfiguredOrderStateMachine
+func NewOrderStateMachine(transitions domain.OrderTransitions, guards map[domain.OrderStatus][]OrderStatusGuard) OrderStateMachine {
+	return &ConfiguredOrderStateMachine{
+		transitions: transitions,
+		guards:      guards,
+	}
+}
+
+// Transitions returns the statuses an order in a status can move to
+func (m *ConfiguredOrderStateMachine) Transitions(from domain.OrderStatus) []domain.OrderStatus {
+	return m.transitions[from]
+}
+
+// CanTransition returns an error unless the order may move to a status
+func (m *ConfiguredOrderStateMachine) CanTransition(ctx context.Context, order *domain.Order, to domain.OrderStatus) error {
+	if !m.transitions.Allows(order.Status, to) {
+		return errors.New("invalid status transition")
+	}
+
+	for _, guard := range m.guards[to] {
+		if err := guard(ctx, order, to); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// transitionOrder moves an order to a status its lifecycle allows. Every
+// change of an order's status goes through it: the repository only applies
+// the transition while the order is still in the status it was checked from.
+func transitionOrder(ctx context.Context, orderRepo repository.OrderRepository, stateMachine OrderStateMachine, order *domain.Order, to domain.OrderStatus, change domain.OrderStatusChange) error {
+	if err := stateMachine.CanTransition(ctx, order, to); err != nil {
+		return err
+	}
+	return orderRepo.UpdateStatus(ctx, order.ID, order.Status, to, change)
+}
+
+// DefaultOrderStatusGuards returns the guards every order lifecycle keeps:
+// orders only ship in full once their backorders have stock, orders with
+// physical lines are shipped before they are delivered, and orders are only
+// refunded once their payment is
+func DefaultOrderStatusGuards(paymentRepo repository.PaymentRepository) map[domain.OrderStatus][]OrderStatusGuard {
+	guards := map[domain.OrderStatus][]OrderStatusGuard{
+		domain.OrderStatusShipped:   {requireBackordersAllocated},
+		domain.OrderStatusDelivered: {requireShippedBeforeDelivery},
+	}
+	if paymentRepo != nil {
+		guards[domain.OrderStatusRefunded] = []OrderStatusGuard{requirePaymentRefunded(paymentRepo)}
+	}
+	return guards
+}
+
+// requireBackordersAllocated keeps orders waiting for backordered stock from
+// shipping in full
+func requireBackordersAllocated(ctx context.Context, order *domain.Order, to domain.OrderStatus) error {
+	for _, item := range order.Items {
+		if item.OutstandingBackorder() > 0 {
+			return errors.New("order has backordered items awaiting stock")
+		}
+	}
+	return nil
+}
+
+// requireShippedBeforeDelivery keeps orders with physical lines from being
+// delivered before they are shipped
+func requireShippedBeforeDelivery(ctx context.Context, order *domain.Order, to domain.OrderStatus) error {
+	if order.Status == domain.OrderStatusProcessing && order.RequiresShipping() {
+		return errors.New("order has to be shipped before it is delivered")
+	}
+	return nil
+}
+
+// requirePaymentRefunded keeps orders from being marked refunded before
+// their payment is refunded
+func requirePaymentRefunded(paymentRepo repository.PaymentRepository) OrderStatusGuard {
+	return func(ctx context.Context, order *domain.Order, to domain.OrderStatus) error {
+		payment, err := paymentRepo.FindByOrderID(ctx, order.ID)
+		if err != nil || payment.Status != domain.PaymentStatusRefunded {
+			return errors.New("order payment has not been refunded")
+		}
+		return nil
+	}
+}
@@ -0,0 +1,138 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderStateMachineTransitions(t *testing.T) {
+	ctx := context.Background()
+	stateMachine := service.NewOrderStateMachine(domain.DefaultOrderTransitions(), nil)
+
+	tests := []struct {
+		name    string
+		from    domain.OrderStatus
+		to      domain.OrderStatus
+		allowed bool
+	}{
+		{"Pending To Processing", domain.OrderStatusPending, domain.OrderStatusProcessing, true},
+		{"Pending To On Hold", domain.OrderStatusPending, domain.OrderStatusOnHold, true},
+		{"On Hold To Processing", domain.OrderStatusOnHold, domain.OrderStatusProcessing, true},
+		{"Processing To Partially Shipped", domain.OrderStatusProcessing, domain.OrderStatusPartiallyShipped, true},
+		{"Partially Shipped To Shipped", domain.OrderStatusPartiallyShipped, domain.OrderStatusShipped, true},
+		{"Delivered To Returned", domain.OrderStatusDelivered, domain.OrderStatusReturned, true},
+		{"Returned To Refunded", domain.OrderStatusReturned, domain.OrderStatusRefunded, true},
+		{"Processing To Refunded", domain.OrderStatusProcessing, domain.OrderStatusRefunded, true},
+		{"Pending To Shipped", domain.OrderStatusPending, domain.OrderStatusShipped, false},
+		{"Partially Shipped To Cancelled", domain.OrderStatusPartiallyShipped, domain.OrderStatusCancelled, false},
+		{"Refunded To Processing", domain.OrderStatusRefunded, domain.OrderStatusProcessing, false},
+		{"Unknown Status", domain.OrderStatusPending, domain.OrderStatus("lost"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := stateMachine.CanTransition(ctx, &domain.Order{ID: 1, Status: tt.from}, tt.to)
+
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, "invalid status transition")
+			}
+		})
+	}
+}
+
+func TestOrderStateMachineGuards(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Backorders Awaiting Stock", func(t *testing.T) {
+		stateMachine := service.NewOrderStateMachine(domain.DefaultOrderTransitions(), service.DefaultOrderStatusGuards(nil))
+		order := &domain.Order{ID: 1, Status: domain.OrderStatusProcessing, Items: []domain.OrderItem{
+			{ID: 1, ProductID: 1, Quantity: 3, BackorderedQuantity: 2, BackorderAllocated: 1},
+		}}
+
+		// The order can only ship in part until the backorder has stock
+		assert.EqualError(t, stateMachine.CanTransition(ctx, order, domain.OrderStatusShipped), "order has backordered items awaiting stock")
+		assert.NoError(t, stateMachine.CanTransition(ctx, order, domain.OrderStatusPartiallyShipped))
+
+		order.Items[0].BackorderAllocated = 2
+		assert.NoError(t, stateMachine.CanTransition(ctx, order, domain.OrderStatusShipped))
+	})
+
+	t.Run("Delivery Before Shipping", func(t *testing.T) {
+		stateMachine := service.NewOrderStateMachine(domain.DefaultOrderTransitions(), service.DefaultOrderStatusGuards(nil))
+		physical := &domain.Order{ID: 1, Status: domain.OrderStatusProcessing, Items: []domain.OrderItem{
+			{ID: 1, ProductID: 1, Quantity: 1, ProductType: domain.ProductTypePhysical},
+		}}
+		digital := &domain.Order{ID: 2, Status: domain.OrderStatusProcessing, Items: []domain.OrderItem{
+			{ID: 2, ProductID: 2, Quantity: 1, ProductType: domain.ProductTypeDigital},
+		}}
+
+		assert.EqualError(t, stateMachine.CanTransition(ctx, physical, domain.OrderStatusDelivered), "order has to be shipped before it is delivered")
+		assert.NoError(t, stateMachine.CanTransition(ctx, digital, domain.OrderStatusDelivered))
+	})
+
+	t.Run("Refund Before Payment Refunded", func(t *testing.T) {
+		mockPaymentRepo := new(MockPaymentRepository)
+		stateMachine := service.NewOrderStateMachine(domain.DefaultOrderTransitions(), service.DefaultOrderStatusGuards(mockPaymentRepo))
+		order := &domain.Order{ID: 1, Status: domain.OrderStatusReturned}
+
+		mockPaymentRepo.On("FindByOrderID", ctx, uint(1)).Return(&domain.Payment{ID: 3, OrderID: 1, Status: domain.PaymentStatusCompleted}, nil).Once()
+		assert.EqualError(t, stateMachine.CanTransition(ctx, order, domain.OrderStatusRefunded), "order payment has not been refunded")
+
+		mockPaymentRepo.On("FindByOrderID", ctx, uint(1)).Return(&domain.Payment{ID: 3, OrderID: 1, Status: domain.PaymentStatusRefunded}, nil).Once()
+		assert.NoError(t, stateMachine.CanTransition(ctx, order, domain.OrderStatusRefunded))
+		mockPaymentRepo.AssertExpectations(t)
+	})
+
+	t.Run("Custom Guard", func(t *testing.T) {
+		stateMachine := service.NewOrderStateMachine(domain.DefaultOrderTransitions(), map[domain.OrderStatus][]service.OrderStatusGuard{
+			domain.OrderStatusOnHold: {func(ctx context.Context, order *domain.Order, to domain.OrderStatus) error {
+				if order.TotalAmount.Amount < 100000 {
+					return errors.New("only large orders are held for review")
+				}
+				return nil
+			}},
+		})
+
+		assert.EqualError(t, stateMachine.CanTransition(ctx, &domain.Order{ID: 1, Status: domain.OrderStatusPending, TotalAmount: usd(5000)}, domain.OrderStatusOnHold), "only large orders are held for review")
+		assert.NoError(t, stateMachine.CanTransition(ctx, &domain.Order{ID: 2, Status: domain.OrderStatusPending, TotalAmount: usd(250000)}, domain.OrderStatusOnHold))
+	})
+}
+
+func TestParseOrderTransitions(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		transitions, err := domain.ParseOrderTransitions("pending:processing, cancelled; processing:shipped;shipped:")
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.OrderTransitions{
+			domain.OrderStatusPending:    {domain.OrderStatusProcessing, domain.OrderStatusCancelled},
+			domain.OrderStatusProcessing: {domain.OrderStatusShipped},
+			domain.OrderStatusShipped:    {},
+		}, transitions)
+
+		stateMachine := service.NewOrderStateMachine(transitions, nil)
+		assert.Equal(t, []domain.OrderStatus{domain.OrderStatusShipped}, stateMachine.Transitions(domain.OrderStatusProcessing))
+		assert.Empty(t, stateMachine.Transitions(domain.OrderStatusShipped))
+	})
+
+	t.Run("Unknown Status", func(t *testing.T) {
+		transitions, err := domain.ParseOrderTransitions("pending:processing,lost")
+
+		assert.EqualError(t, err, `unknown order status "lost"`)
+		assert.Nil(t, transitions)
+	})
+
+	t.Run("Malformed Entry", func(t *testing.T) {
+		transitions, err := domain.ParseOrderTransitions("pending processing")
+
+		assert.EqualError(t, err, `invalid order transition "pending processing"`)
+		assert.Nil(t, transitions)
+	})
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"slices"
 	"time"
 
 	"awesomeEcommerce/internal/domain"
@@ -18,8 +19,9 @@ type PaymentService interface {
 	// GetPaymentByOrderID retrieves a payment by order ID
 	GetPaymentByOrderID(ctx context.Context, orderID uint) (*domain.Payment, error)
 
-	// CreatePayment creates a new payment for an order
-	CreatePayment(ctx context.Context, orderID uint, amount float64, method domain.PaymentMethod) (*domain.Payment, error)
+	// CreatePayment creates a new payment for an order, taking the tenders
+	// requested from gift cards and store credit and the rest by method
+	CreatePayment(ctx context.Context, orderID uint, amount domain.Money, method domain.PaymentMethod, tenders []domain.TenderRequest) (*domain.Payment, error)
 
 	// ProcessPayment processes a payment (simulated)
 	ProcessPayment(ctx context.Context, paymentID uint, transactionID string) error
@@ -27,8 +29,13 @@ type PaymentService interface {
 	// UpdatePaymentStatus updates the status of a payment
 	UpdatePaymentStatus(ctx context.Context, id uint, status domain.PaymentStatus) error
 
-	// RefundPayment refunds a payment
-	RefundPayment(ctx context.Context, id uint) error
+	// RefundPayment refunds a payment, recording the reason in the history
+	// of its order
+	RefundPayment(ctx context.Context, id uint, reason string) error
+
+	// RefundPaymentToStoreCredit refunds a payment, crediting the part not
+	// paid from gift cards and store credit to the customer's store credit
+	RefundPaymentToStoreCredit(ctx context.Context, id uint, reason string) error
 
 	// GetAllPayments retrieves all payments with optional pagination
 	GetAllPayments(ctx context.Context, page, pageSize int) ([]domain.Payment, int64, error)
@@ -45,21 +52,44 @@ type PaymentService interface {
 
 // PaymentServiceImpl implements the PaymentService interface
 type PaymentServiceImpl struct {
-	paymentRepo repository.PaymentRepository
-	orderRepo   repository.OrderRepository
-	producer    *messaging.KafkaProducer
+	paymentRepo  repository.PaymentRepository
+	orderRepo    repository.OrderRepository
+	stateMachine OrderStateMachine
+	transactor   repository.Transactor
+	producer     *messaging.KafkaProducer
+
+	reservations ReservationService
+	fulfillment  DigitalFulfillmentService
+	giftCards    GiftCardService
 }
 
-// NewPaymentService creates a new PaymentServiceImpl
+// NewPaymentService creates a new PaymentServiceImpl. Payments move their
+// orders through the lifecycle of stateMachine, the default one when nil.
+// Without a transactor, the steps of a payment are not run as one unit.
 func NewPaymentService(
 	paymentRepo repository.PaymentRepository,
 	orderRepo repository.OrderRepository,
+	stateMachine OrderStateMachine,
+	transactor repository.Transactor,
+	reservations ReservationService,
+	fulfillment DigitalFulfillmentService,
+	giftCards GiftCardService,
 	producer *messaging.KafkaProducer,
 ) PaymentService {
+	if stateMachine == nil {
+		stateMachine = NewOrderStateMachine(domain.DefaultOrderTransitions(), DefaultOrderStatusGuards(nil))
+	}
+
 	return &PaymentServiceImpl{
-		paymentRepo: paymentRepo,
-		orderRepo:   orderRepo,
-		producer:    producer,
+		paymentRepo:  paymentRepo,
+		orderRepo:    orderRepo,
+		stateMachine: stateMachine,
+		transactor:   transactor,
+		producer:     producer,
+
+		reservations: reservations,
+		fulfillment:  fulfillment,
+		giftCards:    giftCards,
 	}
 }
 
@@ -73,10 +103,12 @@ func (s *PaymentServiceImpl) GetPaymentByOrderID(ctx context.Context, orderID ui
 	return s.paymentRepo.FindByOrderID(ctx, orderID)
 }
 
-// CreatePayment creates a new payment for an order
-func (s *PaymentServiceImpl) CreatePayment(ctx context.Context, orderID uint, amount float64, method domain.PaymentMethod) (*domain.Payment, error) {
+// CreatePayment creates a new payment for an order, taking the tenders
+// requested from gift cards and store credit and the rest by method. Tenders
+// covering the whole amount make the payment one by the first tender's method.
+func (s *PaymentServiceImpl) CreatePayment(ctx context.Context, orderID uint, amount domain.Money, method domain.PaymentMethod, tenders []domain.TenderRequest) (*domain.Payment, error) {
 	// Check if order exists
-	_, err := s.orderRepo.FindByID(ctx, orderID)
+	order, err := s.orderRepo.FindByID(ctx, orderID)
 	if err != nil {
 		return nil, errors.New("order not found")
 	}
@@ -88,7 +120,7 @@ func (s *PaymentServiceImpl) CreatePayment(ctx context.Context, orderID uint, am
 	}
 
 	// Validate amount
-	if amount <= 0 {
+	if !amount.IsPositive() {
 		return nil, errors.New("amount must be greater than zero")
 	}
 
@@ -98,21 +130,50 @@ func (s *PaymentServiceImpl) CreatePayment(ctx context.Context, orderID uint, am
 		return nil, err
 	}
 
+	// Amounts are exact, in the currency the order was placed in
 	if amount != orderTotal {
 		return nil, errors.New("payment amount does not match order total")
 	}
 
 	// Create the payment
 	payment := &domain.Payment{
-		OrderID:  orderID,
-		Amount:   amount,
-		Currency: "USD", // Default currency
-		Method:   method,
-		Status:   domain.PaymentStatusPending,
+		OrderID: orderID,
+		Amount:  amount,
+		Method:  method,
+		Status:  domain.PaymentStatusPending,
+
+		ExchangeRate: order.ExchangeRate,
 	}
 
-	// Save the payment
-	err = s.paymentRepo.Create(ctx, payment)
+	if len(tenders) > 0 && s.giftCards == nil {
+		return nil, errors.New("gift cards and store credit are not accepted")
+	}
+
+	// Take the tenders from their balances and save the payment as one unit,
+	// so a payment that cannot be made keeps no balance. Orders have one
+	// payment, so of two made at once only the first is saved.
+	err = withinTransaction(ctx, s.transactor, func(ctx context.Context) error {
+		if len(tenders) > 0 {
+			var err error
+			if payment.Tenders, err = s.giftCards.RedeemTenders(ctx, order, amount, tenders); err != nil {
+				return err
+			}
+		}
+
+		remainder, err := payment.Remainder()
+		if err != nil {
+			return err
+		}
+		if remainder.IsPositive() {
+			if method.IsBalance() {
+				return errors.New("remaining amount must be paid by another payment method")
+			}
+		} else {
+			payment.Method = payment.Tenders[0].Method
+		}
+
+		return s.paymentRepo.Create(ctx, payment)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -138,6 +199,13 @@ func (s *PaymentServiceImpl) ProcessPayment(ctx context.Context, paymentID uint,
 		return errors.New("payment is not in pending status")
 	}
 
+	// Take the reserved stock for good before accepting the payment
+	if s.reservations != nil {
+		if err := s.reservations.ConvertOrder(ctx, payment.OrderID); err != nil {
+			return err
+		}
+	}
+
 	// Update payment with transaction ID and completed status
 	payment.TransactionID = transactionID
 	payment.Status = domain.PaymentStatusCompleted
@@ -150,11 +218,16 @@ func (s *PaymentServiceImpl) ProcessPayment(ctx context.Context, paymentID uint,
 	}
 
 	// Update order status to processing
-	err = s.orderRepo.UpdateStatus(ctx, payment.OrderID, domain.OrderStatusProcessing)
+	err = s.moveOrder(ctx, payment.OrderID, domain.OrderStatusProcessing, "payment completed")
 	if err != nil {
 		return err
 	}
 
+	// Hand over the digital items of the order
+	if err := s.deliverDigitalItems(ctx, payment.OrderID); err != nil {
+		return err
+	}
+
 	// Publish payment processed event
 	// Note: In a real application, we would serialize the payment to JSON
 	// and publish it to Kafka. For simplicity, we're just logging here.
@@ -176,6 +249,13 @@ func (s *PaymentServiceImpl) UpdatePaymentStatus(ctx context.Context, id uint, s
 		return errors.New("invalid status transition")
 	}
 
+	// Take the reserved stock for good before accepting the payment
+	if status == domain.PaymentStatusCompleted && s.reservations != nil {
+		if err := s.reservations.ConvertOrder(ctx, payment.OrderID); err != nil {
+			return err
+		}
+	}
+
 	// Update the status
 	err = s.paymentRepo.UpdateStatus(ctx, id, status)
 	if err != nil {
@@ -184,15 +264,30 @@ func (s *PaymentServiceImpl) UpdatePaymentStatus(ctx context.Context, id uint, s
 
 	// If payment is completed, update order status to processing
 	if status == domain.PaymentStatusCompleted {
-		err = s.orderRepo.UpdateStatus(ctx, payment.OrderID, domain.OrderStatusProcessing)
+		err = s.moveOrder(ctx, payment.OrderID, domain.OrderStatusProcessing, "payment completed")
 		if err != nil {
 			return err
 		}
+
+		// Hand over the digital items of the order
+		if err := s.deliverDigitalItems(ctx, payment.OrderID); err != nil {
+			return err
+		}
 	}
 
 	// If payment is failed, update order status to cancelled
 	if status == domain.PaymentStatusFailed {
-		err = s.orderRepo.UpdateStatus(ctx, payment.OrderID, domain.OrderStatusCancelled)
+		err = s.moveOrder(ctx, payment.OrderID, domain.OrderStatusCancelled, "payment failed")
+		if err != nil {
+			return err
+		}
+
+		err = s.releaseOrderStock(ctx, payment.OrderID, domain.LedgerReasonCancellation)
+		if err != nil {
+			return err
+		}
+
+		err = s.returnTenders(ctx, payment, domain.BalanceReasonReversal)
 		if err != nil {
 			return err
 		}
@@ -206,6 +301,25 @@ func (s *PaymentServiceImpl) UpdatePaymentStatus(ctx context.Context, id uint, s
 	return nil
 }
 
+// moveOrder moves the order of a payment to a status its lifecycle allows
+func (s *PaymentServiceImpl) moveOrder(ctx context.Context, orderID uint, status domain.OrderStatus, reason string) error {
+	order, err := s.orderRepo.FindByID(ctx, orderID)
+	if err != nil {
+		return errors.New("order not found")
+	}
+	return transitionOrder(ctx, s.orderRepo, s.stateMachine, order, status, orderStatusChange(ctx, reason))
+}
+
+// deliverDigitalItems delivers the digital lines of a paid order. Failed
+// deliveries can be retried, lines delivered before are not delivered again.
+func (s *PaymentServiceImpl) deliverDigitalItems(ctx context.Context, orderID uint) error {
+	if s.fulfillment == nil {
+		return nil
+	}
+	_, err := s.fulfillment.DeliverOrder(ctx, orderID)
+	return err
+}
+
 // isValidStatusTransition checks if a status transition is valid
 func (s *PaymentServiceImpl) isValidStatusTransition(from, to domain.PaymentStatus) bool {
 	// Define valid transitions
@@ -231,8 +345,26 @@ func (s *PaymentServiceImpl) isValidStatusTransition(from, to domain.PaymentStat
 	return false
 }
 
-// RefundPayment refunds a payment
-func (s *PaymentServiceImpl) RefundPayment(ctx context.Context, id uint) error {
+// RefundPayment refunds a payment, returning the parts paid from gift cards
+// and store credit to them
+func (s *PaymentServiceImpl) RefundPayment(ctx context.Context, id uint, reason string) error {
+	return s.refundPayment(ctx, id, reason, false)
+}
+
+// RefundPaymentToStoreCredit refunds a payment, returning the parts paid from
+// gift cards and store credit to them and crediting the rest to the
+// customer's store credit in the currency of the order
+func (s *PaymentServiceImpl) RefundPaymentToStoreCredit(ctx context.Context, id uint, reason string) error {
+	if s.giftCards == nil {
+		return errors.New("store credit is not available")
+	}
+	return s.refundPayment(ctx, id, reason, true)
+}
+
+// refundPayment refunds a payment, optionally to store credit. The payment,
+// its order, the order's stock and the balances it was paid from change
+// together or not at all.
+func (s *PaymentServiceImpl) refundPayment(ctx context.Context, id uint, reason string, toStoreCredit bool) error {
 	// Check if payment exists
 	payment, err := s.paymentRepo.FindByID(ctx, id)
 	if err != nil {
@@ -244,28 +376,90 @@ func (s *PaymentServiceImpl) RefundPayment(ctx context.Context, id uint) error {
 		return errors.New("only completed payments can be refunded")
 	}
 
-	// Update payment status to refunded
-	err = s.paymentRepo.UpdateStatus(ctx, id, domain.PaymentStatusRefunded)
+	// Check the order's lifecycle lets it be refunded before changing
+	// anything. The guards of the refunded status need the payment refunded,
+	// so they run once it is, in the transaction below.
+	order, err := s.orderRepo.FindByID(ctx, payment.OrderID)
 	if err != nil {
-		return err
+		return errors.New("order not found")
+	}
+	if !slices.Contains(s.stateMachine.Transitions(order.Status), domain.OrderStatusRefunded) {
+		return errors.New("invalid status transition")
 	}
 
-	// Update order status to cancelled
-	err = s.orderRepo.UpdateStatus(ctx, payment.OrderID, domain.OrderStatusCancelled)
-	if err != nil {
-		return err
+	if reason == "" {
+		reason = "payment refunded"
 	}
 
-	// Get order items to return to inventory
-	// Note: In a real implementation, we would get the order items and return them to inventory
-	// For simplicity, we're skipping this step
+	return withinTransaction(ctx, s.transactor, func(ctx context.Context) error {
+		// Update payment status to refunded
+		if err := s.paymentRepo.UpdateStatus(ctx, id, domain.PaymentStatusRefunded); err != nil {
+			return err
+		}
 
-	// Publish payment refunded event
-	// Note: In a real application, we would serialize the payment to JSON
-	// and publish it to Kafka. For simplicity, we're just logging here.
-	// s.producer.Publish(ctx, "payment-refunded", []byte(fmt.Sprintf("%d", id)), []byte(paymentJSON))
+		// Update order status to refunded
+		if err := transitionOrder(ctx, s.orderRepo, s.stateMachine, order, domain.OrderStatusRefunded, orderStatusChange(ctx, reason)); err != nil {
+			return err
+		}
 
-	return nil
+		// Return the order's stock to inventory
+		if s.reservations != nil {
+			if err := s.reservations.ReleaseOrder(ctx, order, domain.LedgerReasonRefund); err != nil {
+				return err
+			}
+		}
+
+		// Return the balances the payment was taken from
+		if s.giftCards != nil && len(payment.Tenders) > 0 {
+			if err := s.giftCards.RestoreTenders(ctx, order, payment.Tenders, domain.BalanceReasonRefund); err != nil {
+				return err
+			}
+		}
+
+		if toStoreCredit {
+			remainder, err := payment.Remainder()
+			if err != nil {
+				return err
+			}
+			if err := s.giftCards.CreditRefund(ctx, order, remainder); err != nil {
+				return err
+			}
+		}
+
+		// Publish payment refunded event
+		// Note: In a real application, we would serialize the payment to JSON
+		// and publish it to Kafka. For simplicity, we're just logging here.
+		// s.producer.Publish(ctx, "payment-refunded", []byte(fmt.Sprintf("%d", id)), []byte(paymentJSON))
+
+		return nil
+	})
+}
+
+// returnTenders returns the parts of a payment taken from gift cards and store
+// credit to where they were taken from
+func (s *PaymentServiceImpl) returnTenders(ctx context.Context, payment *domain.Payment, reason domain.BalanceReason) error {
+	if s.giftCards == nil || len(payment.Tenders) == 0 {
+		return nil
+	}
+
+	order, err := s.orderRepo.FindByID(ctx, payment.OrderID)
+	if err != nil {
+		return errors.New("order not found")
+	}
+	return s.giftCards.RestoreTenders(ctx, order, payment.Tenders, reason)
+}
+
+// releaseOrderStock puts the stock held by a cancelled order back on sale
+func (s *PaymentServiceImpl) releaseOrderStock(ctx context.Context, orderID uint, reason domain.LedgerReason) error {
+	if s.reservations == nil {
+		return nil
+	}
+
+	order, err := s.orderRepo.FindByID(ctx, orderID)
+	if err != nil {
+		return errors.New("order not found")
+	}
+	return s.reservations.ReleaseOrder(ctx, order, reason)
 }
 
 // GetAllPayments retrieves all payments with optional pagination
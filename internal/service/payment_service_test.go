@@ -87,11 +87,11 @@ func TestCreatePayment(t *testing.T) {
 	// Setup
 	mockPaymentRepo := new(MockPaymentRepository)
 	mockOrderRepo := new(MockOrderRepository)
-	paymentService := service.NewPaymentService(mockPaymentRepo, mockOrderRepo, nil)
+	paymentService := service.NewPaymentService(mockPaymentRepo, mockOrderRepo, nil, nil, nil, nil, nil, nil)
 
 	ctx := context.Background()
 	orderID := uint(1)
-	amount := 100.0
+	amount := usd(10000)
 	method := domain.PaymentMethodCreditCard
 
 	t.Run("Success", func(t *testing.T) {
@@ -110,7 +110,7 @@ func TestCreatePayment(t *testing.T) {
 		mockPaymentRepo.On("Create", ctx, mock.AnythingOfType("*domain.Payment")).Return(nil).Once()
 
 		// Execute
-		payment, err := paymentService.CreatePayment(ctx, orderID, amount, method)
+		payment, err := paymentService.CreatePayment(ctx, orderID, amount, method, nil)
 
 		// Assert
 		assert.NoError(t, err)
@@ -128,13 +128,13 @@ func TestCreatePayment(t *testing.T) {
 		// Reset mocks
 		mockPaymentRepo = new(MockPaymentRepository)
 		mockOrderRepo = new(MockOrderRepository)
-		paymentService = service.NewPaymentService(mockPaymentRepo, mockOrderRepo, nil)
+		paymentService = service.NewPaymentService(mockPaymentRepo, mockOrderRepo, nil, nil, nil, nil, nil, nil)
 
 		// Expectations
 		mockOrderRepo.On("FindByID", ctx, orderID).Return(nil, errors.New("order not found")).Once()
 
 		// Execute
-		payment, err := paymentService.CreatePayment(ctx, orderID, amount, method)
+		payment, err := paymentService.CreatePayment(ctx, orderID, amount, method, nil)
 
 		// Assert
 		assert.Error(t, err)
@@ -149,7 +149,7 @@ func TestCreatePayment(t *testing.T) {
 		// Reset mocks
 		mockPaymentRepo = new(MockPaymentRepository)
 		mockOrderRepo = new(MockOrderRepository)
-		paymentService = service.NewPaymentService(mockPaymentRepo, mockOrderRepo, nil)
+		paymentService = service.NewPaymentService(mockPaymentRepo, mockOrderRepo, nil, nil, nil, nil, nil, nil)
 
 		// Test data
 		order := &domain.Order{
@@ -169,7 +169,7 @@ func TestCreatePayment(t *testing.T) {
 		mockPaymentRepo.On("FindByOrderID", ctx, orderID).Return(existingPayment, nil).Once()
 
 		// Execute
-		payment, err := paymentService.CreatePayment(ctx, orderID, amount, method)
+		payment, err := paymentService.CreatePayment(ctx, orderID, amount, method, nil)
 
 		// Assert
 		assert.Error(t, err)
@@ -184,23 +184,23 @@ func TestCreatePayment(t *testing.T) {
 		// Reset mocks
 		mockPaymentRepo = new(MockPaymentRepository)
 		mockOrderRepo = new(MockOrderRepository)
-		paymentService = service.NewPaymentService(mockPaymentRepo, mockOrderRepo, nil)
+		paymentService = service.NewPaymentService(mockPaymentRepo, mockOrderRepo, nil, nil, nil, nil, nil, nil)
 
 		// Test data
 		order := &domain.Order{
 			ID:          orderID,
 			UserID:      1,
-			TotalAmount: 200.0, // Different from payment amount
+			TotalAmount: usd(20000), // Different from payment amount
 			Status:      domain.OrderStatusPending,
 		}
 
 		// Expectations
 		mockOrderRepo.On("FindByID", ctx, orderID).Return(order, nil).Once()
 		mockPaymentRepo.On("FindByOrderID", ctx, orderID).Return(nil, errors.New("not found")).Once()
-		mockOrderRepo.On("GetOrderTotal", ctx, orderID).Return(200.0, nil).Once()
+		mockOrderRepo.On("GetOrderTotal", ctx, orderID).Return(usd(20000), nil).Once()
 
 		// Execute
-		payment, err := paymentService.CreatePayment(ctx, orderID, amount, method)
+		payment, err := paymentService.CreatePayment(ctx, orderID, amount, method, nil)
 
 		// Assert
 		assert.Error(t, err)
@@ -211,11 +211,40 @@ func TestCreatePayment(t *testing.T) {
 		mockPaymentRepo.AssertExpectations(t)
 	})
 
+	t.Run("Currency Mismatch", func(t *testing.T) {
+		// Reset mocks
+		mockPaymentRepo = new(MockPaymentRepository)
+		mockOrderRepo = new(MockOrderRepository)
+		paymentService = service.NewPaymentService(mockPaymentRepo, mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+		// Test data: the same number of minor units, in another currency
+		order := &domain.Order{
+			ID:          orderID,
+			UserID:      1,
+			TotalAmount: domain.Money{Amount: 10000, Currency: "EUR"},
+			Status:      domain.OrderStatusPending,
+		}
+
+		// Expectations
+		mockOrderRepo.On("FindByID", ctx, orderID).Return(order, nil).Once()
+		mockPaymentRepo.On("FindByOrderID", ctx, orderID).Return(nil, errors.New("not found")).Once()
+		mockOrderRepo.On("GetOrderTotal", ctx, orderID).Return(order.TotalAmount, nil).Once()
+
+		// Execute
+		payment, err := paymentService.CreatePayment(ctx, orderID, amount, method, nil)
+
+		// Assert
+		assert.Error(t, err)
+		assert.Nil(t, payment)
+		assert.Contains(t, err.Error(), "payment amount does not match order total")
+		mockPaymentRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
 	t.Run("Payment Creation Error", func(t *testing.T) {
 		// Reset mocks
 		mockPaymentRepo = new(MockPaymentRepository)
 		mockOrderRepo = new(MockOrderRepository)
-		paymentService = service.NewPaymentService(mockPaymentRepo, mockOrderRepo, nil)
+		paymentService = service.NewPaymentService(mockPaymentRepo, mockOrderRepo, nil, nil, nil, nil, nil, nil)
 
 		// Test data
 		order := &domain.Order{
@@ -232,7 +261,7 @@ func TestCreatePayment(t *testing.T) {
 		mockPaymentRepo.On("Create", ctx, mock.AnythingOfType("*domain.Payment")).Return(errors.New("failed to create payment")).Once()
 
 		// Execute
-		payment, err := paymentService.CreatePayment(ctx, orderID, amount, method)
+		payment, err := paymentService.CreatePayment(ctx, orderID, amount, method, nil)
 
 		// Assert
 		assert.Error(t, err)
@@ -248,7 +277,7 @@ func TestProcessPayment(t *testing.T) {
 	// Setup
 	mockPaymentRepo := new(MockPaymentRepository)
 	mockOrderRepo := new(MockOrderRepository)
-	paymentService := service.NewPaymentService(mockPaymentRepo, mockOrderRepo, nil)
+	paymentService := service.NewPaymentService(mockPaymentRepo, mockOrderRepo, nil, nil, nil, nil, nil, nil)
 
 	ctx := context.Background()
 	paymentID := uint(1)
@@ -259,14 +288,15 @@ func TestProcessPayment(t *testing.T) {
 		payment := &domain.Payment{
 			ID:      paymentID,
 			OrderID: 1,
-			Amount:  100.0,
+			Amount:  usd(10000),
 			Status:  domain.PaymentStatusPending,
 		}
 
 		// Expectations
 		mockPaymentRepo.On("FindByID", ctx, paymentID).Return(payment, nil).Once()
 		mockPaymentRepo.On("Update", ctx, mock.AnythingOfType("*domain.Payment")).Return(nil).Once()
-		mockOrderRepo.On("UpdateStatus", ctx, payment.OrderID, domain.OrderStatusProcessing).Return(nil).Once()
+		mockOrderRepo.On("FindByID", ctx, payment.OrderID).Return(&domain.Order{ID: payment.OrderID, Status: domain.OrderStatusPending}, nil).Once()
+		mockOrderRepo.On("UpdateStatus", ctx, payment.OrderID, domain.OrderStatusPending, domain.OrderStatusProcessing, domain.OrderStatusChange{Reason: "payment completed", Actor: "system"}).Return(nil).Once()
 
 		// Execute
 		err := paymentService.ProcessPayment(ctx, paymentID, transactionID)
@@ -282,7 +312,7 @@ func TestProcessPayment(t *testing.T) {
 		// Reset mocks
 		mockPaymentRepo = new(MockPaymentRepository)
 		mockOrderRepo = new(MockOrderRepository)
-		paymentService = service.NewPaymentService(mockPaymentRepo, mockOrderRepo, nil)
+		paymentService = service.NewPaymentService(mockPaymentRepo, mockOrderRepo, nil, nil, nil, nil, nil, nil)
 
 		// Expectations
 		mockPaymentRepo.On("FindByID", ctx, paymentID).Return(nil, errors.New("payment not found")).Once()
@@ -302,13 +332,13 @@ func TestProcessPayment(t *testing.T) {
 		// Reset mocks
 		mockPaymentRepo = new(MockPaymentRepository)
 		mockOrderRepo = new(MockOrderRepository)
-		paymentService = service.NewPaymentService(mockPaymentRepo, mockOrderRepo, nil)
+		paymentService = service.NewPaymentService(mockPaymentRepo, mockOrderRepo, nil, nil, nil, nil, nil, nil)
 
 		// Test data
 		payment := &domain.Payment{
 			ID:      paymentID,
 			OrderID: 1,
-			Amount:  100.0,
+			Amount:  usd(10000),
 			Status:  domain.PaymentStatusCompleted, // Already completed
 		}
 
@@ -330,13 +360,13 @@ func TestProcessPayment(t *testing.T) {
 		// Reset mocks
 		mockPaymentRepo = new(MockPaymentRepository)
 		mockOrderRepo = new(MockOrderRepository)
-		paymentService = service.NewPaymentService(mockPaymentRepo, mockOrderRepo, nil)
+		paymentService = service.NewPaymentService(mockPaymentRepo, mockOrderRepo, nil, nil, nil, nil, nil, nil)
 
 		// Test data
 		payment := &domain.Payment{
 			ID:      paymentID,
 			OrderID: 1,
-			Amount:  100.0,
+			Amount:  usd(10000),
 			Status:  domain.PaymentStatusPending,
 		}
 
@@ -360,7 +390,7 @@ func TestGetPaymentByID(t *testing.T) {
 	// Setup
 	mockPaymentRepo := new(MockPaymentRepository)
 	mockOrderRepo := new(MockOrderRepository)
-	paymentService := service.NewPaymentService(mockPaymentRepo, mockOrderRepo, nil)
+	paymentService := service.NewPaymentService(mockPaymentRepo, mockOrderRepo, nil, nil, nil, nil, nil, nil)
 
 	ctx := context.Background()
 	paymentID := uint(1)
@@ -371,8 +401,7 @@ func TestGetPaymentByID(t *testing.T) {
 		expectedPayment := &domain.Payment{
 			ID:            paymentID,
 			OrderID:       1,
-			Amount:        100.0,
-			Currency:      "USD",
+			Amount:        usd(10000),
 			Method:        domain.PaymentMethodCreditCard,
 			Status:        domain.PaymentStatusCompleted,
 			TransactionID: "txn_123456",
@@ -396,7 +425,7 @@ func TestGetPaymentByID(t *testing.T) {
 		// Reset mocks
 		mockPaymentRepo = new(MockPaymentRepository)
 		mockOrderRepo = new(MockOrderRepository)
-		paymentService = service.NewPaymentService(mockPaymentRepo, mockOrderRepo, nil)
+		paymentService = service.NewPaymentService(mockPaymentRepo, mockOrderRepo, nil, nil, nil, nil, nil, nil)
 
 		// Expectations
 		mockPaymentRepo.On("FindByID", ctx, paymentID).Return(nil, errors.New("payment not found")).Once()
@@ -417,7 +446,7 @@ func TestRefundPayment(t *testing.T) {
 	// Setup
 	mockPaymentRepo := new(MockPaymentRepository)
 	mockOrderRepo := new(MockOrderRepository)
-	paymentService := service.NewPaymentService(mockPaymentRepo, mockOrderRepo, nil)
+	paymentService := service.NewPaymentService(mockPaymentRepo, mockOrderRepo, nil, nil, nil, nil, nil, nil)
 
 	ctx := context.Background()
 	paymentID := uint(1)
@@ -428,8 +457,7 @@ func TestRefundPayment(t *testing.T) {
 		payment := &domain.Payment{
 			ID:            paymentID,
 			OrderID:       1,
-			Amount:        100.0,
-			Currency:      "USD",
+			Amount:        usd(10000),
 			Method:        domain.PaymentMethodCreditCard,
 			Status:        domain.PaymentStatusCompleted,
 			TransactionID: "txn_123456",
@@ -439,10 +467,11 @@ func TestRefundPayment(t *testing.T) {
 		// Expectations
 		mockPaymentRepo.On("FindByID", ctx, paymentID).Return(payment, nil).Once()
 		mockPaymentRepo.On("UpdateStatus", ctx, paymentID, domain.PaymentStatusRefunded).Return(nil).Once()
-		mockOrderRepo.On("UpdateStatus", ctx, payment.OrderID, domain.OrderStatusCancelled).Return(nil).Once()
+		mockOrderRepo.On("FindByID", ctx, payment.OrderID).Return(&domain.Order{ID: payment.OrderID, Status: domain.OrderStatusDelivered}, nil).Once()
+		mockOrderRepo.On("UpdateStatus", ctx, payment.OrderID, domain.OrderStatusDelivered, domain.OrderStatusRefunded, domain.OrderStatusChange{Reason: "payment refunded", Actor: "system"}).Return(nil).Once()
 
 		// Execute
-		err := paymentService.RefundPayment(ctx, paymentID)
+		err := paymentService.RefundPayment(ctx, paymentID, "")
 
 		// Assert
 		assert.NoError(t, err)
@@ -455,13 +484,13 @@ func TestRefundPayment(t *testing.T) {
 		// Reset mocks
 		mockPaymentRepo = new(MockPaymentRepository)
 		mockOrderRepo = new(MockOrderRepository)
-		paymentService = service.NewPaymentService(mockPaymentRepo, mockOrderRepo, nil)
+		paymentService = service.NewPaymentService(mockPaymentRepo, mockOrderRepo, nil, nil, nil, nil, nil, nil)
 
 		// Expectations
 		mockPaymentRepo.On("FindByID", ctx, paymentID).Return(nil, errors.New("payment not found")).Once()
 
 		// Execute
-		err := paymentService.RefundPayment(ctx, paymentID)
+		err := paymentService.RefundPayment(ctx, paymentID, "")
 
 		// Assert
 		assert.Error(t, err)
@@ -475,15 +504,14 @@ func TestRefundPayment(t *testing.T) {
 		// Reset mocks
 		mockPaymentRepo = new(MockPaymentRepository)
 		mockOrderRepo = new(MockOrderRepository)
-		paymentService = service.NewPaymentService(mockPaymentRepo, mockOrderRepo, nil)
+		paymentService = service.NewPaymentService(mockPaymentRepo, mockOrderRepo, nil, nil, nil, nil, nil, nil)
 
 		// Test data
 		now := time.Now()
 		payment := &domain.Payment{
 			ID:            paymentID,
 			OrderID:       1,
-			Amount:        100.0,
-			Currency:      "USD",
+			Amount:        usd(10000),
 			Method:        domain.PaymentMethodCreditCard,
 			Status:        domain.PaymentStatusPending, // Not completed
 			TransactionID: "txn_123456",
@@ -494,7 +522,7 @@ func TestRefundPayment(t *testing.T) {
 		mockPaymentRepo.On("FindByID", ctx, paymentID).Return(payment, nil).Once()
 
 		// Execute
-		err := paymentService.RefundPayment(ctx, paymentID)
+		err := paymentService.RefundPayment(ctx, paymentID, "")
 
 		// Assert
 		assert.Error(t, err)
@@ -503,4 +531,54 @@ func TestRefundPayment(t *testing.T) {
 		mockPaymentRepo.AssertExpectations(t)
 		mockOrderRepo.AssertNotCalled(t, "UpdateStatus")
 	})
+
+	t.Run("Order Cannot Be Refunded", func(t *testing.T) {
+		// Reset mocks
+		mockPaymentRepo = new(MockPaymentRepository)
+		mockOrderRepo = new(MockOrderRepository)
+		paymentService = service.NewPaymentService(mockPaymentRepo, mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+		// Test data: a shipped order is returned before it is refunded
+		payment := &domain.Payment{ID: paymentID, OrderID: 1, Amount: usd(10000), Status: domain.PaymentStatusCompleted}
+
+		// Expectations
+		mockPaymentRepo.On("FindByID", ctx, paymentID).Return(payment, nil).Once()
+		mockOrderRepo.On("FindByID", ctx, payment.OrderID).Return(&domain.Order{ID: payment.OrderID, Status: domain.OrderStatusShipped}, nil).Once()
+
+		// Execute
+		err := paymentService.RefundPayment(ctx, paymentID, "")
+
+		// Assert: the payment is kept
+		assert.EqualError(t, err, "invalid status transition")
+
+		mockPaymentRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+		mockOrderRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Order Update Fails", func(t *testing.T) {
+		// Reset mocks
+		mockPaymentRepo = new(MockPaymentRepository)
+		mockOrderRepo = new(MockOrderRepository)
+		transactor := new(fakeTransactor)
+		paymentService = service.NewPaymentService(mockPaymentRepo, mockOrderRepo, nil, transactor, nil, nil, nil, nil)
+
+		// Test data
+		payment := &domain.Payment{ID: paymentID, OrderID: 1, Amount: usd(10000), Status: domain.PaymentStatusCompleted}
+
+		// Expectations
+		mockPaymentRepo.On("FindByID", ctx, paymentID).Return(payment, nil).Once()
+		mockOrderRepo.On("FindByID", ctx, payment.OrderID).Return(&domain.Order{ID: payment.OrderID, Status: domain.OrderStatusDelivered}, nil).Once()
+		mockPaymentRepo.On("UpdateStatus", mock.MatchedBy(inTransaction), paymentID, domain.PaymentStatusRefunded).Return(nil).Once()
+		mockOrderRepo.On("UpdateStatus", mock.MatchedBy(inTransaction), payment.OrderID, domain.OrderStatusDelivered, domain.OrderStatusRefunded, domain.OrderStatusChange{Reason: "returned damaged", Actor: "system"}).Return(errors.New("database error")).Once()
+
+		// Execute
+		err := paymentService.RefundPayment(ctx, paymentID, "returned damaged")
+
+		// Assert: the refunded payment rolls back with the order
+		assert.EqualError(t, err, "database error")
+		assert.Equal(t, 1, transactor.rolledBack)
+
+		mockPaymentRepo.AssertExpectations(t)
+		mockOrderRepo.AssertExpectations(t)
+	})
 }
@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"errors"
+	"strings"
 
 	"awesomeEcommerce/internal/domain"
 	"awesomeEcommerce/internal/repository"
@@ -30,8 +32,8 @@ type ProductService interface {
 	// GetProductBySKU retrieves a product by its SKU
 	GetProductBySKU(ctx context.Context, sku string) (*domain.Product, error)
 
-	// UpdateProductStock updates the stock of a product
-	UpdateProductStock(ctx context.Context, id uint, quantity int) error
+	// UpdateProductStock updates the stock of a product, recording the reason in the inventory ledger
+	UpdateProductStock(ctx context.Context, id uint, quantity int, reason domain.LedgerReason) error
 
 	// GetCategories retrieves all product categories
 	GetCategories(ctx context.Context) ([]domain.ProductCategory, error)
@@ -47,6 +49,9 @@ type ProductService interface {
 
 	// DeleteCategory deletes a product category by its ID
 	DeleteCategory(ctx context.Context, id uint) error
+
+	// GetCacheStats retrieves the hit and miss counters of the product caches by namespace
+	GetCacheStats(ctx context.Context) (map[string]domain.CacheStats, error)
 }
 
 // ProductServiceImpl implements the ProductService interface
@@ -78,12 +83,32 @@ func (s *ProductServiceImpl) GetProductsByCategory(ctx context.Context, category
 
 // CreateProduct creates a new product
 func (s *ProductServiceImpl) CreateProduct(ctx context.Context, product *domain.Product) error {
-	return s.productRepo.Create(ctx, product)
+	if err := validateBackorderSettings(product); err != nil {
+		return err
+	}
+	if err := validateProductType(product); err != nil {
+		return err
+	}
+	normalizeTaxClass(product)
+	if err := validateReorderSettings(product.ReorderPoint, product.ReorderQuantity); err != nil {
+		return err
+	}
+	return s.productRepo.Create(withManualStockChange(ctx), product)
 }
 
 // UpdateProduct updates an existing product
 func (s *ProductServiceImpl) UpdateProduct(ctx context.Context, product *domain.Product) error {
-	return s.productRepo.Update(ctx, product)
+	if err := validateBackorderSettings(product); err != nil {
+		return err
+	}
+	if err := validateProductType(product); err != nil {
+		return err
+	}
+	normalizeTaxClass(product)
+	if err := validateReorderSettings(product.ReorderPoint, product.ReorderQuantity); err != nil {
+		return err
+	}
+	return s.productRepo.Update(withManualStockChange(ctx), product)
 }
 
 // DeleteProduct deletes a product by its ID
@@ -96,9 +121,9 @@ func (s *ProductServiceImpl) GetProductBySKU(ctx context.Context, sku string) (*
 	return s.productRepo.FindBySKU(ctx, sku)
 }
 
-// UpdateProductStock updates the stock of a product
-func (s *ProductServiceImpl) UpdateProductStock(ctx context.Context, id uint, quantity int) error {
-	return s.productRepo.UpdateStock(ctx, id, quantity)
+// UpdateProductStock updates the stock of a product, recording the reason in the inventory ledger
+func (s *ProductServiceImpl) UpdateProductStock(ctx context.Context, id uint, quantity int, reason domain.LedgerReason) error {
+	return s.productRepo.UpdateStock(ctx, id, quantity, stockChange(ctx, reason, 0))
 }
 
 // GetCategories retrieves all product categories
@@ -113,15 +138,121 @@ func (s *ProductServiceImpl) GetCategoryByID(ctx context.Context, id uint) (*dom
 
 // CreateCategory creates a new product category
 func (s *ProductServiceImpl) CreateCategory(ctx context.Context, category *domain.ProductCategory) error {
+	if err := validateReorderSettings(category.ReorderPoint, category.ReorderQuantity); err != nil {
+		return err
+	}
 	return s.productRepo.CreateCategory(ctx, category)
 }
 
 // UpdateCategory updates an existing product category
 func (s *ProductServiceImpl) UpdateCategory(ctx context.Context, category *domain.ProductCategory) error {
+	if err := validateReorderSettings(category.ReorderPoint, category.ReorderQuantity); err != nil {
+		return err
+	}
 	return s.productRepo.UpdateCategory(ctx, category)
 }
 
 // DeleteCategory deletes a product category by its ID
 func (s *ProductServiceImpl) DeleteCategory(ctx context.Context, id uint) error {
 	return s.productRepo.DeleteCategory(ctx, id)
-}
\ No newline at end of file
+}
+
+// GetCacheStats retrieves the hit and miss counters of the product caches by namespace
+func (s *ProductServiceImpl) GetCacheStats(ctx context.Context) (map[string]domain.CacheStats, error) {
+	return s.productRepo.GetCacheStats(ctx)
+}
+
+// validateBackorderSettings checks the backorder policy and pre-order mode of
+// a product, defaulting to no backorders
+func validateBackorderSettings(product *domain.Product) error {
+	switch product.BackorderPolicy {
+	case "":
+		product.BackorderPolicy = domain.BackorderPolicyDeny
+	case domain.BackorderPolicyDeny, domain.BackorderPolicyUnlimited:
+	case domain.BackorderPolicyLimited:
+		if product.BackorderLimit <= 0 {
+			return errors.New("backorder limit must be greater than zero")
+		}
+	default:
+		return errors.New("invalid backorder policy")
+	}
+
+	if product.PreOrder {
+		if product.AvailableAt == nil {
+			return errors.New("pre-order products need an availability date")
+		}
+		if product.BackorderPolicy == domain.BackorderPolicyDeny {
+			return errors.New("pre-order products need a backorder policy allowing backorders")
+		}
+	}
+
+	return nil
+}
+
+// normalizeTaxClass writes the tax class of a product the way tax rates
+// store it, products without one being taxed at the standard rate
+func normalizeTaxClass(product *domain.Product) {
+	product.TaxClass = strings.ToLower(strings.TrimSpace(product.TaxClass))
+	if product.TaxClass == "" {
+		product.TaxClass = domain.TaxClassStandard
+	}
+}
+
+// validateProductType checks the type of a product, defaulting to physical.
+// Digital products need a delivery method and bundles a pricing; neither is
+// ever backordered.
+func validateProductType(product *domain.Product) error {
+	if product.Type == "" {
+		product.Type = domain.ProductTypePhysical
+	}
+	if product.Type != domain.ProductTypeDigital && product.DeliveryMethod != "" {
+		return errors.New("only digital products have a delivery method")
+	}
+	if product.Type != domain.ProductTypeBundle && product.BundlePricing != "" {
+		return errors.New("only bundles have a bundle pricing")
+	}
+
+	switch product.Type {
+	case domain.ProductTypePhysical:
+	case domain.ProductTypeDigital:
+		switch product.DeliveryMethod {
+		case domain.DeliveryMethodLicenseKey, domain.DeliveryMethodDownload, domain.DeliveryMethodGiftCard:
+		default:
+			return errors.New("invalid delivery method")
+		}
+		if product.BackorderPolicy != domain.BackorderPolicyDeny {
+			return errors.New("digital products cannot be backordered")
+		}
+	case domain.ProductTypeBundle:
+		switch product.BundlePricing {
+		case "":
+			product.BundlePricing = domain.BundlePricingFixed
+		case domain.BundlePricingFixed:
+		case domain.BundlePricingPercentOff:
+			if product.BundleDiscount < 0 || product.BundleDiscount > 100 {
+				return errors.New("bundle discount must be between 0 and 100")
+			}
+		default:
+			return errors.New("invalid bundle pricing")
+		}
+		if product.BackorderPolicy != domain.BackorderPolicyDeny {
+			return errors.New("bundles cannot be backordered")
+		}
+	default:
+		return errors.New("invalid product type")
+	}
+
+	return nil
+}
+
+// validateReorderSettings checks the reorder point and quantity of a product
+// or category. Unset values are inherited from the category or the defaults.
+func validateReorderSettings(reorderPoint, reorderQuantity *int) error {
+	if reorderPoint != nil && *reorderPoint < 0 {
+		return errors.New("reorder point cannot be negative")
+	}
+	if reorderQuantity != nil && *reorderQuantity <= 0 {
+		return errors.New("reorder quantity must be greater than zero")
+	}
+	return nil
+}
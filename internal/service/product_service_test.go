@@ -24,7 +24,7 @@ func TestGetProductByID(t *testing.T) {
 			ID:          productID,
 			Name:        "Test Product",
 			Description: "Test Description",
-			Price:       99.99,
+			Price:       usd(9999),
 			SKU:         "TEST-SKU-123",
 			Stock:       100,
 			CategoryID:  1,
@@ -76,7 +76,7 @@ func TestGetProducts(t *testing.T) {
 				ID:          1,
 				Name:        "Product 1",
 				Description: "Description 1",
-				Price:       99.99,
+				Price:       usd(9999),
 				SKU:         "SKU-1",
 				Stock:       100,
 				CategoryID:  1,
@@ -85,7 +85,7 @@ func TestGetProducts(t *testing.T) {
 				ID:          2,
 				Name:        "Product 2",
 				Description: "Description 2",
-				Price:       199.99,
+				Price:       usd(19999),
 				SKU:         "SKU-2",
 				Stock:       50,
 				CategoryID:  2,
@@ -141,7 +141,7 @@ func TestGetProductsByCategory(t *testing.T) {
 				ID:          1,
 				Name:        "Product 1",
 				Description: "Description 1",
-				Price:       99.99,
+				Price:       usd(9999),
 				SKU:         "SKU-1",
 				Stock:       100,
 				CategoryID:  categoryID,
@@ -150,7 +150,7 @@ func TestGetProductsByCategory(t *testing.T) {
 				ID:          3,
 				Name:        "Product 3",
 				Description: "Description 3",
-				Price:       299.99,
+				Price:       usd(29999),
 				SKU:         "SKU-3",
 				Stock:       75,
 				CategoryID:  categoryID,
@@ -201,7 +201,7 @@ func TestCreateProduct(t *testing.T) {
 		product := &domain.Product{
 			Name:        "New Product",
 			Description: "New Description",
-			Price:       149.99,
+			Price:       usd(14999),
 			SKU:         "NEW-SKU-123",
 			Stock:       200,
 			CategoryID:  1,
@@ -227,7 +227,7 @@ func TestCreateProduct(t *testing.T) {
 		product := &domain.Product{
 			Name:        "New Product",
 			Description: "New Description",
-			Price:       149.99,
+			Price:       usd(14999),
 			SKU:         "NEW-SKU-123",
 			Stock:       200,
 			CategoryID:  1,
@@ -258,7 +258,7 @@ func TestUpdateProduct(t *testing.T) {
 			ID:          1,
 			Name:        "Updated Product",
 			Description: "Updated Description",
-			Price:       199.99,
+			Price:       usd(19999),
 			SKU:         "UPD-SKU-123",
 			Stock:       150,
 			CategoryID:  2,
@@ -285,7 +285,7 @@ func TestUpdateProduct(t *testing.T) {
 			ID:          1,
 			Name:        "Updated Product",
 			Description: "Updated Description",
-			Price:       199.99,
+			Price:       usd(19999),
 			SKU:         "UPD-SKU-123",
 			Stock:       150,
 			CategoryID:  2,
@@ -354,7 +354,7 @@ func TestGetProductBySKU(t *testing.T) {
 			ID:          1,
 			Name:        "Test Product",
 			Description: "Test Description",
-			Price:       99.99,
+			Price:       usd(9999),
 			SKU:         sku,
 			Stock:       100,
 			CategoryID:  1,
@@ -398,13 +398,14 @@ func TestUpdateProductStock(t *testing.T) {
 	ctx := context.Background()
 	productID := uint(1)
 	quantity := 50
+	change := domain.StockChange{Reason: domain.LedgerReasonStockUpdateEvent, Actor: "system"}
 
 	t.Run("Success", func(t *testing.T) {
 		// Expectations
-		mockRepo.On("UpdateStock", ctx, productID, quantity).Return(nil).Once()
+		mockRepo.On("UpdateStock", ctx, productID, quantity, change).Return(nil).Once()
 
 		// Execute
-		err := productService.UpdateProductStock(ctx, productID, quantity)
+		err := productService.UpdateProductStock(ctx, productID, quantity, domain.LedgerReasonStockUpdateEvent)
 
 		// Assert
 		assert.NoError(t, err)
@@ -417,10 +418,10 @@ func TestUpdateProductStock(t *testing.T) {
 		productService = service.NewProductService(mockRepo)
 
 		// Expectations
-		mockRepo.On("UpdateStock", ctx, productID, quantity).Return(errors.New("database error")).Once()
+		mockRepo.On("UpdateStock", ctx, productID, quantity, change).Return(errors.New("database error")).Once()
 
 		// Execute
-		err := productService.UpdateProductStock(ctx, productID, quantity)
+		err := productService.UpdateProductStock(ctx, productID, quantity, domain.LedgerReasonStockUpdateEvent)
 
 		// Assert
 		assert.Error(t, err)
@@ -653,3 +654,26 @@ func TestDeleteCategory(t *testing.T) {
 		mockRepo.AssertExpectations(t)
 	})
 }
+
+func TestGetCacheStats(t *testing.T) {
+	// Setup
+	mockRepo := new(MockProductRepository)
+	productService := service.NewProductService(mockRepo)
+	ctx := context.Background()
+
+	stats := map[string]domain.CacheStats{
+		"product":  {Hits: 8, Misses: 2},
+		"category": {Hits: 5},
+	}
+
+	// Expectations
+	mockRepo.On("GetCacheStats", ctx).Return(stats, nil).Once()
+
+	// Execute
+	result, err := productService.GetCacheStats(ctx)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, stats, result)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+)
+
+// PromotionService defines the interface for promotion business logic
+type PromotionService interface {
+	// GetPromotions retrieves all promotions with optional pagination
+	GetPromotions(ctx context.Context, page, pageSize int) ([]domain.Promotion, int64, error)
+
+	// GetPromotionByID retrieves a promotion by its ID
+	GetPromotionByID(ctx context.Context, id uint) (*domain.Promotion, error)
+
+	// CreatePromotion creates a new promotion
+	CreatePromotion(ctx context.Context, promotion *domain.Promotion) error
+
+	// UpdatePromotion updates an existing promotion
+	UpdatePromotion(ctx context.Context, promotion *domain.Promotion) error
+
+	// DeletePromotion deletes a promotion
+	DeletePromotion(ctx context.Context, id uint) error
+
+	// Apply computes the discount lines of the running promotions on priced lines
+	Apply(ctx context.Context, lines []domain.PricedLine, conversion domain.Conversion) ([]domain.OrderDiscount, error)
+}
+
+// PromotionServiceImpl implements the PromotionService interface
+type PromotionServiceImpl struct {
+	promotionRepo repository.PromotionRepository
+}
+
+// NewPromotionService creates a new PromotionServiceImpl
+func NewPromotionService(promotionRepo repository.PromotionRepository) PromotionService {
+	return &PromotionServiceImpl{
+		promotionRepo: promotionRepo,
+	}
+}
+
+// GetPromotions retrieves all promotions with optional pagination
+func (s *PromotionServiceImpl) GetPromotions(ctx context.Context, page, pageSize int) ([]domain.Promotion, int64, error) {
+	return s.promotionRepo.FindAll(ctx, page, pageSize)
+}
+
+// GetPromotionByID retrieves a promotion by its ID
+func (s *PromotionServiceImpl) GetPromotionByID(ctx context.Context, id uint) (*domain.Promotion, error) {
+	return s.promotionRepo.FindByID(ctx, id)
+}
+
+// CreatePromotion creates a new promotion
+func (s *PromotionServiceImpl) CreatePromotion(ctx context.Context, promotion *domain.Promotion) error {
+	if err := validatePromotion(promotion); err != nil {
+		return err
+	}
+	return s.promotionRepo.Create(ctx, promotion)
+}
+
+// UpdatePromotion updates an existing promotion
+func (s *PromotionServiceImpl) UpdatePromotion(ctx context.Context, promotion *domain.Promotion) error {
+	if err := validatePromotion(promotion); err != nil {
+		return err
+	}
+	return s.promotionRepo.Update(ctx, promotion)
+}
+
+// DeletePromotion deletes a promotion
+func (s *PromotionServiceImpl) DeletePromotion(ctx context.Context, id uint) error {
+	if _, err := s.promotionRepo.FindByID(ctx, id); err != nil {
+		return errors.New("promotion not found")
+	}
+	return s.promotionRepo.Delete(ctx, id)
+}
+
+// Apply computes the discount lines of the running promotions on priced lines
+func (s *PromotionServiceImpl) Apply(ctx context.Context, lines []domain.PricedLine, conversion domain.Conversion) ([]domain.OrderDiscount, error) {
+	promotions, err := s.promotionRepo.FindLive(ctx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return domain.ApplyPromotions(promotions, lines, conversion)
+}
+
+// applyPromotions computes the discount lines of the running promotions on
+// priced lines and returns the lines less them, as a coupon sees them.
+// Without promotion support the lines stay as they are.
+func applyPromotions(ctx context.Context, promotions PromotionService, lines []domain.PricedLine, conversion domain.Conversion) ([]domain.OrderDiscount, []domain.PricedLine, error) {
+	if promotions == nil {
+		return nil, lines, nil
+	}
+	discounts, err := promotions.Apply(ctx, lines, conversion)
+	if err != nil {
+		return nil, nil, err
+	}
+	net, err := domain.NetLines(lines, discounts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return discounts, net, nil
+}
+
+// validatePromotion checks the settings of a promotion against its rule. Buy
+// X get Y promotions give their units away unless they set a percentage.
+func validatePromotion(promotion *domain.Promotion) error {
+	promotion.Name = strings.TrimSpace(promotion.Name)
+	if promotion.Name == "" {
+		return errors.New("promotion name is required")
+	}
+
+	switch promotion.Type {
+	case domain.PromotionTypeCategoryPercent:
+		if len(promotion.CategoryIDs) == 0 {
+			return errors.New("category promotions need at least one category")
+		}
+		if !validPercent(promotion.PercentOff) {
+			return errors.New("percent off must be between 0 and 100")
+		}
+	case domain.PromotionTypeSpendTiers:
+		if len(promotion.Tiers) == 0 {
+			return errors.New("spend tier promotions need at least one tier")
+		}
+		for _, tier := range promotion.Tiers {
+			if tier.MinSubtotal.Amount < 0 {
+				return errors.New("tier minimum subtotal cannot be negative")
+			}
+			if !validPercent(tier.PercentOff) {
+				return errors.New("tier percent off must be between 0 and 100")
+			}
+		}
+	case domain.PromotionTypeBuyXGetY:
+		if promotion.BuyQuantity < 1 || promotion.GetQuantity < 1 {
+			return errors.New("buy and get quantities must be at least 1")
+		}
+		if promotion.PercentOff == 0 {
+			promotion.PercentOff = 100
+		}
+		if !validPercent(promotion.PercentOff) {
+			return errors.New("percent off must be between 0 and 100")
+		}
+	case domain.PromotionTypeCheapestFree:
+		if promotion.MinQuantity < 2 {
+			return errors.New("minimum quantity must be at least 2")
+		}
+	default:
+		return errors.New("invalid promotion type")
+	}
+
+	if promotion.StartsAt != nil && promotion.EndsAt != nil && !promotion.EndsAt.After(*promotion.StartsAt) {
+		return errors.New("promotion must end after it starts")
+	}
+	return nil
+}
+
+// validPercent reports whether a percentage takes something, but no more
+// than everything, off
+func validPercent(percent float64) bool {
+	return percent > 0 && percent <= 100
+}
@@ -0,0 +1,295 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockPromotionRepository struct {
+	mock.Mock
+}
+
+func (m *MockPromotionRepository) FindByID(ctx context.Context, id uint) (*domain.Promotion, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Promotion), args.Error(1)
+}
+
+func (m *MockPromotionRepository) FindAll(ctx context.Context, page, pageSize int) ([]domain.Promotion, int64, error) {
+	args := m.Called(ctx, page, pageSize)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]domain.Promotion), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockPromotionRepository) FindLive(ctx context.Context, at time.Time) ([]domain.Promotion, error) {
+	args := m.Called(ctx, at)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Promotion), args.Error(1)
+}
+
+func (m *MockPromotionRepository) Create(ctx context.Context, promotion *domain.Promotion) error {
+	args := m.Called(ctx, promotion)
+	return args.Error(0)
+}
+
+func (m *MockPromotionRepository) Update(ctx context.Context, promotion *domain.Promotion) error {
+	args := m.Called(ctx, promotion)
+	return args.Error(0)
+}
+
+func (m *MockPromotionRepository) Delete(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// appliedDiscount is a discount line as the promotion tests compare them
+type appliedDiscount struct {
+	PromotionID uint
+	ProductID   uint
+	Amount      int64
+}
+
+func TestApplyPromotions(t *testing.T) {
+	// Three mugs and a teapot in category 10, two bags of coffee in category 20
+	lines := []domain.PricedLine{
+		{ProductID: 1, CategoryID: 10, Quantity: 3, Total: usd(2400)},
+		{ProductID: 2, CategoryID: 10, Quantity: 1, Total: usd(2500)},
+		{ProductID: 3, CategoryID: 20, Quantity: 2, Total: usd(2400)},
+	}
+	kitchenSale := domain.Promotion{ID: 1, Name: "Kitchen sale", Type: domain.PromotionTypeCategoryPercent, CategoryIDs: []uint{10}, PercentOff: 10}
+	mugsThreeForTwo := domain.Promotion{ID: 2, Name: "Mugs 3 for 2", Type: domain.PromotionTypeBuyXGetY, ProductIDs: []uint{1}, BuyQuantity: 2, GetQuantity: 1, PercentOff: 100, Priority: 10}
+
+	tests := []struct {
+		name       string
+		promotions []domain.Promotion
+		want       []appliedDiscount
+	}{
+		{
+			name:       "Category percentage",
+			promotions: []domain.Promotion{{ID: 1, Type: domain.PromotionTypeCategoryPercent, CategoryIDs: []uint{10}, PercentOff: 20}},
+			want:       []appliedDiscount{{1, 1, 480}, {1, 2, 500}},
+		},
+		{
+			name: "Highest spend tier reached",
+			promotions: []domain.Promotion{{ID: 1, Type: domain.PromotionTypeSpendTiers, Tiers: []domain.PromotionTier{
+				{MinSubtotal: usd(5000), PercentOff: 5},
+				{MinSubtotal: usd(10000), PercentOff: 15},
+				{MinSubtotal: usd(7000), PercentOff: 10},
+			}}},
+			want: []appliedDiscount{{1, 1, 240}, {1, 2, 250}, {1, 3, 240}},
+		},
+		{
+			name:       "No spend tier reached",
+			promotions: []domain.Promotion{{ID: 1, Type: domain.PromotionTypeSpendTiers, Tiers: []domain.PromotionTier{{MinSubtotal: usd(10000), PercentOff: 15}}}},
+		},
+		{
+			name:       "Buy two get one free",
+			promotions: []domain.Promotion{mugsThreeForTwo},
+			want:       []appliedDiscount{{2, 1, 800}},
+		},
+		{
+			name:       "Buy one get one half price takes the cheapest units",
+			promotions: []domain.Promotion{{ID: 1, Type: domain.PromotionTypeBuyXGetY, CategoryIDs: []uint{10}, BuyQuantity: 1, GetQuantity: 1, PercentOff: 50}},
+			want:       []appliedDiscount{{1, 1, 800}},
+		},
+		{
+			name:       "Cheapest item free",
+			promotions: []domain.Promotion{{ID: 1, Type: domain.PromotionTypeCheapestFree, MinQuantity: 5}},
+			want:       []appliedDiscount{{1, 1, 800}},
+		},
+		{
+			name:       "Cheapest item free below the minimum quantity",
+			promotions: []domain.Promotion{{ID: 1, Type: domain.PromotionTypeCheapestFree, MinQuantity: 7}},
+		},
+		{
+			name:       "Stacked by priority on what is left",
+			promotions: []domain.Promotion{kitchenSale, mugsThreeForTwo},
+			want:       []appliedDiscount{{2, 1, 800}, {1, 1, 160}, {1, 2, 250}},
+		},
+		{
+			name: "Exclusive promotion stops the others",
+			promotions: []domain.Promotion{
+				kitchenSale,
+				{ID: 3, Type: domain.PromotionTypeCategoryPercent, CategoryIDs: []uint{20}, PercentOff: 20, Priority: 20, Exclusive: true},
+			},
+			want: []appliedDiscount{{3, 3, 480}},
+		},
+		{
+			name: "Exclusive promotion skipped once another applied",
+			promotions: []domain.Promotion{
+				{ID: 1, Type: domain.PromotionTypeCategoryPercent, CategoryIDs: []uint{10}, PercentOff: 10, Priority: 10},
+				{ID: 3, Type: domain.PromotionTypeCategoryPercent, CategoryIDs: []uint{20}, PercentOff: 50, Exclusive: true},
+			},
+			want: []appliedDiscount{{1, 1, 240}, {1, 2, 250}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			discounts, err := domain.ApplyPromotions(tt.promotions, lines, domain.Conversion{Rate: 1})
+			assert.NoError(t, err)
+
+			var got []appliedDiscount
+			for _, discount := range discounts {
+				assert.Equal(t, domain.DiscountSourcePromotion, discount.Source)
+				got = append(got, appliedDiscount{discount.SourceID, discount.ProductID, discount.Amount.Amount})
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAppliedPromotions(t *testing.T) {
+	discounts := []domain.OrderDiscount{
+		{ProductID: 1, Source: domain.DiscountSourcePromotion, SourceID: 2, Label: "Mugs 3 for 2", Amount: usd(800)},
+		{ProductID: 1, Source: domain.DiscountSourcePromotion, SourceID: 1, Label: "Kitchen sale", Amount: usd(160)},
+		{ProductID: 2, Source: domain.DiscountSourcePromotion, SourceID: 1, Label: "Kitchen sale", Amount: usd(250)},
+		{ProductID: 2, Source: domain.DiscountSourceCoupon, SourceID: 1, Label: "SPRING10", Amount: usd(225)},
+	}
+
+	applied, err := domain.AppliedPromotions(discounts)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.AppliedPromotion{
+		{PromotionID: 2, Name: "Mugs 3 for 2", ProductIDs: []uint{1}, Amount: usd(800)},
+		{PromotionID: 1, Name: "Kitchen sale", ProductIDs: []uint{1, 2}, Amount: usd(410)},
+	}, applied)
+}
+
+func TestCreatePromotion(t *testing.T) {
+	ctx := context.Background()
+	starts := time.Date(2026, 11, 27, 0, 0, 0, 0, time.UTC)
+	ends := starts.Add(-time.Hour)
+
+	tests := []struct {
+		name      string
+		promotion domain.Promotion
+		wantErr   string
+	}{
+		{name: "Missing name", promotion: domain.Promotion{Name: " ", Type: domain.PromotionTypeCheapestFree, MinQuantity: 3}, wantErr: "promotion name is required"},
+		{name: "Invalid type", promotion: domain.Promotion{Name: "Sale", Type: "mystery"}, wantErr: "invalid promotion type"},
+		{name: "Category promotion without categories", promotion: domain.Promotion{Name: "Sale", Type: domain.PromotionTypeCategoryPercent, PercentOff: 10}, wantErr: "category promotions need at least one category"},
+		{name: "Spend tiers without tiers", promotion: domain.Promotion{Name: "Sale", Type: domain.PromotionTypeSpendTiers}, wantErr: "spend tier promotions need at least one tier"},
+		{name: "Tier taking nothing off", promotion: domain.Promotion{Name: "Sale", Type: domain.PromotionTypeSpendTiers, Tiers: []domain.PromotionTier{{MinSubtotal: usd(5000)}}}, wantErr: "tier percent off must be between 0 and 100"},
+		{name: "Nothing to buy", promotion: domain.Promotion{Name: "Sale", Type: domain.PromotionTypeBuyXGetY, GetQuantity: 1}, wantErr: "buy and get quantities must be at least 1"},
+		{name: "Cheapest free of a single item", promotion: domain.Promotion{Name: "Sale", Type: domain.PromotionTypeCheapestFree, MinQuantity: 1}, wantErr: "minimum quantity must be at least 2"},
+		{name: "Ends before it starts", promotion: domain.Promotion{Name: "Sale", Type: domain.PromotionTypeCheapestFree, MinQuantity: 3, StartsAt: &starts, EndsAt: &ends}, wantErr: "promotion must end after it starts"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockPromotionRepo := new(MockPromotionRepository)
+			promotionService := service.NewPromotionService(mockPromotionRepo)
+
+			// Execute
+			promotion := tt.promotion
+			err := promotionService.CreatePromotion(ctx, &promotion)
+
+			// Assert
+			assert.EqualError(t, err, tt.wantErr)
+			mockPromotionRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("Buy X get Y free by default", func(t *testing.T) {
+		// Setup
+		mockPromotionRepo := new(MockPromotionRepository)
+		promotionService := service.NewPromotionService(mockPromotionRepo)
+		mockPromotionRepo.On("Create", ctx, mock.AnythingOfType("*domain.Promotion")).Return(nil)
+
+		// Execute
+		promotion := &domain.Promotion{Name: " Mugs 3 for 2 ", Type: domain.PromotionTypeBuyXGetY, ProductIDs: []uint{1}, BuyQuantity: 2, GetQuantity: 1}
+		err := promotionService.CreatePromotion(ctx, promotion)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "Mugs 3 for 2", promotion.Name)
+		assert.Equal(t, float64(100), promotion.PercentOff)
+		mockPromotionRepo.AssertExpectations(t)
+	})
+}
+
+func TestGetCartSummaryWithPromotionsAndCoupon(t *testing.T) {
+	// Setup
+	mockCartRepo := new(MockCartRepository)
+	mockCouponRepo := new(MockCouponRepository)
+	mockPromotionRepo := new(MockPromotionRepository)
+	couponService := service.NewCouponService(mockCouponRepo)
+	promotionService := service.NewPromotionService(mockPromotionRepo)
+	cartService := service.NewCartService(mockCartRepo, new(MockProductRepository), new(MockUserRepository), service.WithCartCoupons(couponService), service.WithCartPromotions(promotionService))
+
+	ctx := context.Background()
+	items := []domain.CartItem{
+		{ID: 1, CartID: 1, ProductID: 1, Quantity: 3, Product: domain.Product{ID: 1, Price: usd(800), CategoryID: 10}},
+		{ID: 2, CartID: 1, ProductID: 3, Quantity: 1, Product: domain.Product{ID: 3, Price: usd(1200), CategoryID: 20}},
+	}
+	promotions := []domain.Promotion{{ID: 2, Name: "Mugs 3 for 2", Type: domain.PromotionTypeBuyXGetY, ProductIDs: []uint{1}, BuyQuantity: 2, GetQuantity: 1, PercentOff: 100, Active: true}}
+	mockCartRepo.On("GetCartItems", ctx, uint(1)).Return(items, nil)
+	mockCartRepo.On("FindByID", ctx, uint(1)).Return(&domain.Cart{ID: 1, UserID: 1, Items: items, CouponCode: "SPRING10"}, nil)
+	mockPromotionRepo.On("FindLive", ctx, mock.AnythingOfType("time.Time")).Return(promotions, nil)
+	mockCouponRepo.On("FindByCode", ctx, "SPRING10").Return(springSale(), nil)
+
+	// Execute
+	summary, err := cartService.GetCartSummary(ctx, 1)
+
+	// Assert: the free mug comes off first and the coupon takes 10% of what is left
+	require.NoError(t, err)
+	assert.Equal(t, usd(3600), summary.Subtotal)
+	assert.Equal(t, usd(1080), summary.Discount)
+	assert.Equal(t, usd(2520), summary.Total)
+	require.Len(t, summary.Discounts, 3)
+	assert.Equal(t, usd(160), summary.Discounts[1].Amount)
+	assert.Equal(t, []domain.AppliedPromotion{
+		{PromotionID: 2, Name: "Mugs 3 for 2", ProductIDs: []uint{1}, Amount: usd(800)},
+	}, summary.Promotions)
+}
+
+func TestCreateOrderWithPromotion(t *testing.T) {
+	// Setup
+	mockOrderRepo := new(MockOrderRepository)
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(MockProductRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockPromotionRepo := new(MockPromotionRepository)
+	promotionService := service.NewPromotionService(mockPromotionRepo)
+	orderService := service.NewOrderService(mockOrderRepo, mockCartRepo, mockProductRepo, mockUserRepo, nil, service.WithPromotions(promotionService))
+
+	ctx := context.Background()
+	cart := &domain.Cart{ID: 1, UserID: 1, Items: []domain.CartItem{{ProductID: 1, Quantity: 3}}}
+	promotions := []domain.Promotion{{ID: 4, Name: "Third mug free", Type: domain.PromotionTypeCheapestFree, MinQuantity: 3, Active: true}}
+	mockUserRepo.On("FindByID", ctx, uint(1)).Return(&domain.User{ID: 1}, nil)
+	mockCartRepo.On("FindByUserID", ctx, uint(1)).Return(cart, nil)
+	mockProductRepo.On("FindByID", ctx, uint(1)).Return(&domain.Product{ID: 1, Name: "Mug", Price: usd(800), Stock: 10}, nil)
+	mockPromotionRepo.On("FindLive", ctx, mock.AnythingOfType("time.Time")).Return(promotions, nil)
+	mockProductRepo.On("DecrementStock", ctx, mock.Anything, mock.Anything).Return(nil)
+	mockOrderRepo.On("Create", ctx, mock.AnythingOfType("*domain.Order")).Return(nil)
+	mockCartRepo.On("ClearCart", ctx, cart.ID).Return(nil)
+
+	// Execute
+	order, err := orderService.CreateOrder(ctx, 1, "1 Shipping St", "1 Billing St", 0)
+
+	// Assert: the order records the promotion against the line it discounted
+	require.NoError(t, err)
+	assert.Equal(t, usd(800), order.DiscountAmount)
+	assert.Equal(t, usd(1600), order.TotalAmount)
+	require.Len(t, order.Discounts, 1)
+	assert.Equal(t, domain.DiscountSourcePromotion, order.Discounts[0].Source)
+	assert.Equal(t, uint(4), order.Discounts[0].SourceID)
+	assert.Equal(t, "Third mug free", order.Discounts[0].Label)
+	assert.Nil(t, order.CouponID)
+}
@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+)
+
+// RecommendationService defines the interface for product recommendation business logic
+type RecommendationService interface {
+	// GetRelatedProducts retrieves products related to a product
+	GetRelatedProducts(ctx context.Context, productID uint, limit int) ([]domain.ProductRecommendation, error)
+
+	// GetCartRecommendations retrieves products a user may also like based on their cart
+	GetCartRecommendations(ctx context.Context, userID uint, limit int) ([]domain.ProductRecommendation, error)
+
+	// RebuildRecommendations recomputes all recommendations and returns the last order ID included
+	RebuildRecommendations(ctx context.Context) (uint, error)
+
+	// RefreshRecommendations folds orders created after the given order ID into the
+	// bought-together scores and returns the last order ID included
+	RefreshRecommendations(ctx context.Context, afterOrderID uint) (uint, error)
+}
+
+// RecommendationServiceImpl implements the RecommendationService interface
+type RecommendationServiceImpl struct {
+	recommendationRepo repository.RecommendationRepository
+	productRepo        repository.ProductRepository
+	cartRepo           repository.CartRepository
+	maxRelated         int
+}
+
+// refreshBatchSize is the number of orders folded into the scores per refresh query
+const refreshBatchSize = 100
+
+// NewRecommendationService creates a new RecommendationServiceImpl
+func NewRecommendationService(
+	recommendationRepo repository.RecommendationRepository,
+	productRepo repository.ProductRepository,
+	cartRepo repository.CartRepository,
+	maxRelated int,
+) RecommendationService {
+	return &RecommendationServiceImpl{
+		recommendationRepo: recommendationRepo,
+		productRepo:        productRepo,
+		cartRepo:           cartRepo,
+		maxRelated:         maxRelated,
+	}
+}
+
+// GetRelatedProducts retrieves products related to a product.
+// Bought-together scores are order counts (>= 1) while same-category scores are
+// in (0, 1], so ordering by score ranks co-purchases ahead of category matches.
+func (s *RecommendationServiceImpl) GetRelatedProducts(ctx context.Context, productID uint, limit int) ([]domain.ProductRecommendation, error) {
+	// Check if product exists
+	_, err := s.productRepo.FindByID(ctx, productID)
+	if err != nil {
+		return nil, errors.New("product not found")
+	}
+
+	// Fetch both recommendation types, a product may appear under each of them
+	recommendations, err := s.recommendationRepo.FindByProductID(ctx, productID, limit*2)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.rank(recommendations, map[uint]bool{productID: true}, limit), nil
+}
+
+// GetCartRecommendations retrieves products a user may also like based on their cart
+func (s *RecommendationServiceImpl) GetCartRecommendations(ctx context.Context, userID uint, limit int) ([]domain.ProductRecommendation, error) {
+	cart, err := s.cartRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return []domain.ProductRecommendation{}, nil
+	}
+
+	// Products already in the cart are never recommended
+	inCart := make(map[uint]bool)
+	var productIDs []uint
+	for _, item := range cart.Items {
+		inCart[item.ProductID] = true
+		productIDs = append(productIDs, item.ProductID)
+	}
+	if len(productIDs) == 0 {
+		return []domain.ProductRecommendation{}, nil
+	}
+
+	recommendations, err := s.recommendationRepo.FindByProductIDs(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.rank(recommendations, inCart, limit), nil
+}
+
+// rank merges recommendations by related product, summing their scores, and
+// returns the best in-stock products that are not excluded
+func (s *RecommendationServiceImpl) rank(recommendations []domain.ProductRecommendation, exclude map[uint]bool, limit int) []domain.ProductRecommendation {
+	merged := make(map[uint]*domain.ProductRecommendation)
+	var order []uint
+	for _, recommendation := range recommendations {
+		if exclude[recommendation.RelatedProductID] || recommendation.RelatedProduct.Stock <= 0 {
+			continue
+		}
+
+		if existing, ok := merged[recommendation.RelatedProductID]; ok {
+			existing.Score += recommendation.Score
+			continue
+		}
+
+		recommendationCopy := recommendation
+		merged[recommendation.RelatedProductID] = &recommendationCopy
+		order = append(order, recommendation.RelatedProductID)
+	}
+
+	result := make([]domain.ProductRecommendation, 0, len(order))
+	for _, id := range order {
+		result = append(result, *merged[id])
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Score > result[j].Score
+	})
+
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+
+	return result
+}
+
+// RebuildRecommendations recomputes all recommendations and returns the last order ID included
+func (s *RecommendationServiceImpl) RebuildRecommendations(ctx context.Context) (uint, error) {
+	latestOrderID, err := s.recommendationRepo.GetLatestOrderID(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := s.recommendationRepo.RebuildBoughtTogether(ctx, latestOrderID); err != nil {
+		return 0, err
+	}
+
+	if err := s.rebuildSameCategory(ctx); err != nil {
+		return 0, err
+	}
+
+	return latestOrderID, nil
+}
+
+// rebuildSameCategory scores every pair of products in the same category by how
+// close their prices are and keeps the best matches for each product
+func (s *RecommendationServiceImpl) rebuildSameCategory(ctx context.Context) error {
+	// Load the whole catalog page by page
+	byCategory := make(map[uint][]domain.Product)
+	for page := 1; ; page++ {
+		products, _, err := s.productRepo.FindAll(ctx, page, 100)
+		if err != nil {
+			return err
+		}
+		for _, product := range products {
+			if product.CategoryID != 0 {
+				byCategory[product.CategoryID] = append(byCategory[product.CategoryID], product)
+			}
+		}
+		if len(products) < 100 {
+			break
+		}
+	}
+
+	var recommendations []domain.ProductRecommendation
+	for _, products := range byCategory {
+		for _, product := range products {
+			var candidates []domain.ProductRecommendation
+			for _, other := range products {
+				if other.ID == product.ID {
+					continue
+				}
+				candidates = append(candidates, domain.ProductRecommendation{
+					ProductID:        product.ID,
+					RelatedProductID: other.ID,
+					Type:             domain.RecommendationTypeSameCategory,
+					Score:            priceSimilarity(product.Price, other.Price),
+				})
+			}
+
+			sort.SliceStable(candidates, func(i, j int) bool {
+				return candidates[i].Score > candidates[j].Score
+			})
+			if len(candidates) > s.maxRelated {
+				candidates = candidates[:s.maxRelated]
+			}
+			recommendations = append(recommendations, candidates...)
+		}
+	}
+
+	return s.recommendationRepo.ReplaceByType(ctx, domain.RecommendationTypeSameCategory, recommendations)
+}
+
+// priceSimilarity returns a score in (0, 1] that is 1 for identical prices
+func priceSimilarity(a, b domain.Money) float64 {
+	highest := math.Max(float64(a.Amount), float64(b.Amount))
+	if highest <= 0 {
+		return 1
+	}
+	return 1 / (1 + math.Abs(float64(a.Amount-b.Amount))/highest)
+}
+
+// RefreshRecommendations folds orders created after the given order ID into the
+// bought-together scores and returns the last order ID included
+func (s *RecommendationServiceImpl) RefreshRecommendations(ctx context.Context, afterOrderID uint) (uint, error) {
+	lastOrderID := afterOrderID
+	for {
+		items, batchLastOrderID, err := s.recommendationRepo.FindOrderItemsAfter(ctx, lastOrderID, refreshBatchSize)
+		if err != nil {
+			return lastOrderID, err
+		}
+		if batchLastOrderID == lastOrderID {
+			return lastOrderID, nil
+		}
+
+		// Group the distinct products of each order
+		productsByOrder := make(map[uint][]uint)
+		var orderIDs []uint
+		for _, item := range items {
+			if _, ok := productsByOrder[item.OrderID]; !ok {
+				orderIDs = append(orderIDs, item.OrderID)
+			}
+			if !containsUint(productsByOrder[item.OrderID], item.ProductID) {
+				productsByOrder[item.OrderID] = append(productsByOrder[item.OrderID], item.ProductID)
+			}
+		}
+
+		for _, orderID := range orderIDs {
+			productIDs := productsByOrder[orderID]
+			for _, productID := range productIDs {
+				for _, relatedProductID := range productIDs {
+					if productID == relatedProductID {
+						continue
+					}
+					if err := s.recommendationRepo.IncrementBoughtTogether(ctx, productID, relatedProductID, 1); err != nil {
+						return lastOrderID, err
+					}
+				}
+			}
+			lastOrderID = orderID
+		}
+
+		// Batches of orders without purchased items are passed over
+		lastOrderID = batchLastOrderID
+	}
+}
+
+// containsUint reports whether ids contains id
+func containsUint(ids []uint, id uint) bool {
+	for _, existing := range ids {
+		if existing == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,229 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRecommendationRepository struct {
+	mock.Mock
+}
+
+func (m *MockRecommendationRepository) FindByProductID(ctx context.Context, productID uint, limit int) ([]domain.ProductRecommendation, error) {
+	args := m.Called(ctx, productID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.ProductRecommendation), args.Error(1)
+}
+
+func (m *MockRecommendationRepository) FindByProductIDs(ctx context.Context, productIDs []uint) ([]domain.ProductRecommendation, error) {
+	args := m.Called(ctx, productIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.ProductRecommendation), args.Error(1)
+}
+
+func (m *MockRecommendationRepository) IncrementBoughtTogether(ctx context.Context, productID, relatedProductID uint, delta float64) error {
+	args := m.Called(ctx, productID, relatedProductID, delta)
+	return args.Error(0)
+}
+
+func (m *MockRecommendationRepository) RebuildBoughtTogether(ctx context.Context, upToOrderID uint) error {
+	args := m.Called(ctx, upToOrderID)
+	return args.Error(0)
+}
+
+func (m *MockRecommendationRepository) ReplaceByType(ctx context.Context, recommendationType domain.RecommendationType, recommendations []domain.ProductRecommendation) error {
+	args := m.Called(ctx, recommendationType, recommendations)
+	return args.Error(0)
+}
+
+func (m *MockRecommendationRepository) GetLatestOrderID(ctx context.Context) (uint, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(uint), args.Error(1)
+}
+
+func (m *MockRecommendationRepository) FindOrderItemsAfter(ctx context.Context, afterOrderID uint, limit int) ([]domain.OrderItem, uint, error) {
+	args := m.Called(ctx, afterOrderID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(uint), args.Error(2)
+	}
+	return args.Get(0).([]domain.OrderItem), args.Get(1).(uint), args.Error(2)
+}
+
+func TestGetRelatedProducts(t *testing.T) {
+	ctx := context.Background()
+	productID := uint(1)
+
+	t.Run("Success", func(t *testing.T) {
+		// Setup
+		mockRecommendationRepo := new(MockRecommendationRepository)
+		mockProductRepo := new(MockProductRepository)
+		mockCartRepo := new(MockCartRepository)
+		recommendationService := service.NewRecommendationService(mockRecommendationRepo, mockProductRepo, mockCartRepo, 10)
+
+		recommendations := []domain.ProductRecommendation{
+			{ProductID: productID, RelatedProductID: 2, Type: domain.RecommendationTypeBoughtTogether, Score: 3, RelatedProduct: domain.Product{ID: 2, Stock: 5}},
+			{ProductID: productID, RelatedProductID: 3, Type: domain.RecommendationTypeSameCategory, Score: 0.9, RelatedProduct: domain.Product{ID: 3, Stock: 0}},
+			{ProductID: productID, RelatedProductID: 4, Type: domain.RecommendationTypeSameCategory, Score: 0.8, RelatedProduct: domain.Product{ID: 4, Stock: 2}},
+			{ProductID: productID, RelatedProductID: 2, Type: domain.RecommendationTypeSameCategory, Score: 0.5, RelatedProduct: domain.Product{ID: 2, Stock: 5}},
+		}
+
+		// Expectations
+		mockProductRepo.On("FindByID", ctx, productID).Return(&domain.Product{ID: productID}, nil).Once()
+		mockRecommendationRepo.On("FindByProductID", ctx, productID, 20).Return(recommendations, nil).Once()
+
+		// Execute
+		result, err := recommendationService.GetRelatedProducts(ctx, productID, 10)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Len(t, result, 2)
+		assert.Equal(t, uint(2), result[0].RelatedProductID)
+		assert.Equal(t, 3.5, result[0].Score)
+		assert.Equal(t, uint(4), result[1].RelatedProductID)
+		mockProductRepo.AssertExpectations(t)
+		mockRecommendationRepo.AssertExpectations(t)
+	})
+
+	t.Run("Product Not Found", func(t *testing.T) {
+		// Setup
+		mockRecommendationRepo := new(MockRecommendationRepository)
+		mockProductRepo := new(MockProductRepository)
+		mockCartRepo := new(MockCartRepository)
+		recommendationService := service.NewRecommendationService(mockRecommendationRepo, mockProductRepo, mockCartRepo, 10)
+
+		// Expectations
+		mockProductRepo.On("FindByID", ctx, productID).Return(nil, errors.New("record not found")).Once()
+
+		// Execute
+		result, err := recommendationService.GetRelatedProducts(ctx, productID, 10)
+
+		// Assert
+		assert.Error(t, err)
+		assert.Nil(t, result)
+		assert.Equal(t, "product not found", err.Error())
+		mockRecommendationRepo.AssertNotCalled(t, "FindByProductID", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestGetCartRecommendations(t *testing.T) {
+	// Setup
+	mockRecommendationRepo := new(MockRecommendationRepository)
+	mockProductRepo := new(MockProductRepository)
+	mockCartRepo := new(MockCartRepository)
+	recommendationService := service.NewRecommendationService(mockRecommendationRepo, mockProductRepo, mockCartRepo, 10)
+	ctx := context.Background()
+	userID := uint(1)
+
+	cart := &domain.Cart{
+		ID:     uint(1),
+		UserID: userID,
+		Items: []domain.CartItem{
+			{ProductID: 1, Quantity: 1},
+			{ProductID: 2, Quantity: 1},
+		},
+	}
+	recommendations := []domain.ProductRecommendation{
+		{ProductID: 1, RelatedProductID: 2, Type: domain.RecommendationTypeBoughtTogether, Score: 5, RelatedProduct: domain.Product{ID: 2, Stock: 5}},
+		{ProductID: 1, RelatedProductID: 3, Type: domain.RecommendationTypeBoughtTogether, Score: 2, RelatedProduct: domain.Product{ID: 3, Stock: 5}},
+		{ProductID: 2, RelatedProductID: 4, Type: domain.RecommendationTypeBoughtTogether, Score: 3, RelatedProduct: domain.Product{ID: 4, Stock: 5}},
+		{ProductID: 2, RelatedProductID: 3, Type: domain.RecommendationTypeBoughtTogether, Score: 2, RelatedProduct: domain.Product{ID: 3, Stock: 5}},
+	}
+
+	// Expectations
+	mockCartRepo.On("FindByUserID", ctx, userID).Return(cart, nil).Once()
+	mockRecommendationRepo.On("FindByProductIDs", ctx, []uint{1, 2}).Return(recommendations, nil).Once()
+
+	// Execute
+	result, err := recommendationService.GetCartRecommendations(ctx, userID, 10)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	assert.Equal(t, uint(3), result[0].RelatedProductID)
+	assert.Equal(t, 4.0, result[0].Score)
+	assert.Equal(t, uint(4), result[1].RelatedProductID)
+	mockCartRepo.AssertExpectations(t)
+	mockRecommendationRepo.AssertExpectations(t)
+}
+
+func TestRebuildRecommendations(t *testing.T) {
+	// Setup
+	mockRecommendationRepo := new(MockRecommendationRepository)
+	mockProductRepo := new(MockProductRepository)
+	mockCartRepo := new(MockCartRepository)
+	recommendationService := service.NewRecommendationService(mockRecommendationRepo, mockProductRepo, mockCartRepo, 1)
+	ctx := context.Background()
+
+	products := []domain.Product{
+		{ID: 1, Price: usd(1000), CategoryID: 1},
+		{ID: 2, Price: usd(1200), CategoryID: 1},
+		{ID: 3, Price: usd(10000), CategoryID: 1},
+		{ID: 4, Price: usd(1000), CategoryID: 0},
+	}
+
+	// Expectations
+	mockRecommendationRepo.On("GetLatestOrderID", ctx).Return(uint(42), nil).Once()
+	mockRecommendationRepo.On("RebuildBoughtTogether", ctx, uint(42)).Return(nil).Once()
+	mockProductRepo.On("FindAll", ctx, 1, 100).Return(products, int64(4), nil).Once()
+	mockRecommendationRepo.On("ReplaceByType", ctx, domain.RecommendationTypeSameCategory, mock.MatchedBy(func(recommendations []domain.ProductRecommendation) bool {
+		related := make(map[uint]uint)
+		for _, recommendation := range recommendations {
+			related[recommendation.ProductID] = recommendation.RelatedProductID
+		}
+		return len(recommendations) == 3 && related[1] == 2 && related[2] == 1 && related[3] == 2
+	})).Return(nil).Once()
+
+	// Execute
+	lastOrderID, err := recommendationService.RebuildRecommendations(ctx)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, uint(42), lastOrderID)
+	mockRecommendationRepo.AssertExpectations(t)
+	mockProductRepo.AssertExpectations(t)
+}
+
+func TestRefreshRecommendations(t *testing.T) {
+	// Setup
+	mockRecommendationRepo := new(MockRecommendationRepository)
+	mockProductRepo := new(MockProductRepository)
+	mockCartRepo := new(MockCartRepository)
+	recommendationService := service.NewRecommendationService(mockRecommendationRepo, mockProductRepo, mockCartRepo, 10)
+	ctx := context.Background()
+
+	items := []domain.OrderItem{
+		{OrderID: 11, ProductID: 1},
+		{OrderID: 11, ProductID: 2},
+		{OrderID: 12, ProductID: 3},
+	}
+
+	// Expectations: order 13 was cancelled, and so was the whole next batch,
+	// which must not stop the refresh before the orders after it
+	mockRecommendationRepo.On("FindOrderItemsAfter", ctx, uint(10), 100).Return(items, uint(13), nil).Once()
+	mockRecommendationRepo.On("IncrementBoughtTogether", ctx, uint(1), uint(2), 1.0).Return(nil).Once()
+	mockRecommendationRepo.On("IncrementBoughtTogether", ctx, uint(2), uint(1), 1.0).Return(nil).Once()
+	mockRecommendationRepo.On("FindOrderItemsAfter", ctx, uint(13), 100).Return([]domain.OrderItem{}, uint(113), nil).Once()
+	mockRecommendationRepo.On("FindOrderItemsAfter", ctx, uint(113), 100).Return([]domain.OrderItem{{OrderID: 114, ProductID: 2}, {OrderID: 114, ProductID: 3}}, uint(114), nil).Once()
+	mockRecommendationRepo.On("IncrementBoughtTogether", ctx, uint(2), uint(3), 1.0).Return(nil).Once()
+	mockRecommendationRepo.On("IncrementBoughtTogether", ctx, uint(3), uint(2), 1.0).Return(nil).Once()
+	mockRecommendationRepo.On("FindOrderItemsAfter", ctx, uint(114), 100).Return([]domain.OrderItem{}, uint(114), nil).Once()
+
+	// Execute
+	lastOrderID, err := recommendationService.RefreshRecommendations(ctx, 10)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, uint(114), lastOrderID)
+	mockRecommendationRepo.AssertExpectations(t)
+}
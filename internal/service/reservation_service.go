@@ -0,0 +1,267 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+)
+
+// expireBatchSize is the number of expired orders handled per sweep
+const expireBatchSize = 100
+
+// ReservationService defines the interface for checkout stock reservation business logic
+type ReservationService interface {
+	// ReserveOrder holds the stock of every order line until the reservation TTL elapses
+	ReserveOrder(ctx context.Context, order *domain.Order) error
+
+	// ConvertOrder turns the reservations of a paid order into a stock decrement
+	ConvertOrder(ctx context.Context, orderID uint) error
+
+	// ReleaseOrder puts the stock held by a cancelled or refunded order back on sale
+	ReleaseOrder(ctx context.Context, order *domain.Order, reason domain.LedgerReason) error
+
+	// ExpireReservations cancels unpaid orders whose reservations expired,
+	// returning the number of orders cancelled
+	ExpireReservations(ctx context.Context) (int, error)
+
+	// GetAvailableStock calculates the stock of a product minus its active reservations
+	GetAvailableStock(ctx context.Context, productID uint) (int, error)
+
+	// GetReservedStock sums the active reservations of a product
+	GetReservedStock(ctx context.Context, productID uint) (int, error)
+}
+
+// ReservationServiceImpl implements the ReservationService interface
+type ReservationServiceImpl struct {
+	reservationRepo repository.ReservationRepository
+	orderRepo       repository.OrderRepository
+	stateMachine    OrderStateMachine
+	productRepo     repository.ProductRepository
+	inventory       InventoryService
+	ttl             time.Duration
+}
+
+// NewReservationService creates a new ReservationServiceImpl. Expired orders
+// are cancelled through the lifecycle of stateMachine, the default one when
+// nil.
+func NewReservationService(
+	reservationRepo repository.ReservationRepository,
+	orderRepo repository.OrderRepository,
+	stateMachine OrderStateMachine,
+	productRepo repository.ProductRepository,
+	inventory InventoryService,
+	ttl time.Duration,
+) ReservationService {
+	if stateMachine == nil {
+		stateMachine = NewOrderStateMachine(domain.DefaultOrderTransitions(), DefaultOrderStatusGuards(nil))
+	}
+
+	return &ReservationServiceImpl{
+		reservationRepo: reservationRepo,
+		orderRepo:       orderRepo,
+		stateMachine:    stateMachine,
+		productRepo:     productRepo,
+		inventory:       inventory,
+		ttl:             ttl,
+	}
+}
+
+// ReserveOrder holds the stock of every order line until the reservation TTL
+// elapses. Backordered units have no stock to hold.
+func (s *ReservationServiceImpl) ReserveOrder(ctx context.Context, order *domain.Order) error {
+	expiresAt := time.Now().Add(s.ttl)
+
+	var reservations []domain.StockReservation
+	for _, item := range order.Items {
+		if item.InStockQuantity() == 0 {
+			continue
+		}
+		reservations = append(reservations, domain.StockReservation{
+			ProductID: item.ProductID,
+			OrderID:   order.ID,
+			Quantity:  item.InStockQuantity(),
+			Status:    domain.ReservationStatusActive,
+			ExpiresAt: expiresAt,
+		})
+	}
+
+	return s.reservationRepo.Reserve(ctx, reservations)
+}
+
+// ConvertOrder turns the reservations of a paid order into a stock decrement.
+// Warehouse stock is allocated at the same time, so that locations only give
+// up stock for orders that were paid.
+func (s *ReservationServiceImpl) ConvertOrder(ctx context.Context, orderID uint) error {
+	order, err := s.orderRepo.FindByID(ctx, orderID)
+	if err != nil {
+		return errors.New("order not found")
+	}
+	if order.Status == domain.OrderStatusCancelled {
+		return errors.New("order has been cancelled")
+	}
+
+	reservations, err := s.reservationRepo.FindByOrderID(ctx, orderID)
+	if err != nil {
+		return err
+	}
+
+	// Orders placed before reservations took their stock at checkout
+	if len(reservations) == 0 {
+		return nil
+	}
+	if !hasReservationStatus(reservations, domain.ReservationStatusActive) {
+		if hasReservationStatus(reservations, domain.ReservationStatusConverted) {
+			return nil
+		}
+		return errors.New("stock reservation has expired")
+	}
+
+	// Stock whose reservation lapsed counts as available again, and another
+	// checkout may hold it by now, even before the sweep marks it expired
+	now := time.Now()
+	for _, reservation := range reservations {
+		if reservation.IsExpiredAt(now) {
+			return errors.New("stock reservation has expired")
+		}
+	}
+
+	if s.inventory != nil {
+		if err := s.inventory.AllocateOrder(ctx, order); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.reservationRepo.Convert(ctx, orderID, stockChange(ctx, domain.LedgerReasonOrder, orderID)); err != nil {
+		if s.inventory != nil {
+			s.inventory.ReleaseOrder(ctx, orderID)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// ReleaseOrder puts the stock held by a cancelled or refunded order back on sale
+func (s *ReservationServiceImpl) ReleaseOrder(ctx context.Context, order *domain.Order, reason domain.LedgerReason) error {
+	change := stockChange(ctx, reason, order.ID)
+
+	reservations, err := s.reservationRepo.FindByOrderID(ctx, order.ID)
+	if err != nil {
+		return err
+	}
+
+	// Orders placed before reservations took their stock at checkout, and
+	// backorders take theirs as stock arrives. Delivered license keys cannot
+	// be taken back.
+	for _, item := range order.Items {
+		restock := item.BackorderAllocated
+		if len(reservations) == 0 {
+			restock = item.TakenFromStock()
+		}
+		if restock == 0 || item.IsDelivered() {
+			continue
+		}
+		if err := s.productRepo.UpdateStock(ctx, item.ProductID, restock, change); err != nil {
+			return err
+		}
+	}
+
+	if len(reservations) > 0 {
+		if err := s.reservationRepo.Release(ctx, order.ID, change); err != nil {
+			return err
+		}
+	}
+
+	// Return warehouse allocations
+	if s.inventory != nil {
+		return s.inventory.ReleaseOrder(ctx, order.ID)
+	}
+
+	return nil
+}
+
+// ExpireReservations cancels unpaid orders whose reservations expired,
+// returning the number of orders cancelled
+func (s *ReservationServiceImpl) ExpireReservations(ctx context.Context) (int, error) {
+	orderIDs, err := s.reservationRepo.FindExpiredOrderIDs(ctx, time.Now(), expireBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, orderID := range orderIDs {
+		if err := s.reservationRepo.Expire(ctx, orderID); err != nil {
+			return expired, err
+		}
+
+		order, err := s.orderRepo.FindByID(ctx, orderID)
+		if err != nil || order.Status != domain.OrderStatusPending {
+			continue
+		}
+		// An order paid meanwhile is no longer pending, so it is kept
+		err = transitionOrder(ctx, s.orderRepo, s.stateMachine, order, domain.OrderStatusCancelled, orderStatusChange(ctx, "stock reservation expired"))
+		if errors.Is(err, domain.ErrOrderStatusChanged) {
+			continue
+		}
+		if err != nil {
+			return expired, err
+		}
+
+		// Give back the stock that arrived for its backorders
+		if hasAllocatedBackorders(order) {
+			if err := s.ReleaseOrder(ctx, order, domain.LedgerReasonCancellation); err != nil {
+				return expired, err
+			}
+		}
+		expired++
+	}
+
+	return expired, nil
+}
+
+// GetAvailableStock calculates the stock of a product minus its active reservations
+func (s *ReservationServiceImpl) GetAvailableStock(ctx context.Context, productID uint) (int, error) {
+	product, err := s.productRepo.FindByID(ctx, productID)
+	if err != nil {
+		return 0, errors.New("product not found")
+	}
+
+	reserved, err := s.reservationRepo.GetReservedQuantity(ctx, productID)
+	if err != nil {
+		return 0, err
+	}
+
+	available := product.Stock - reserved
+	if available < 0 {
+		available = 0
+	}
+	return available, nil
+}
+
+// GetReservedStock sums the active reservations of a product
+func (s *ReservationServiceImpl) GetReservedStock(ctx context.Context, productID uint) (int, error) {
+	return s.reservationRepo.GetReservedQuantity(ctx, productID)
+}
+
+// hasReservationStatus reports whether any reservation is in status
+func hasReservationStatus(reservations []domain.StockReservation, status domain.ReservationStatus) bool {
+	for _, reservation := range reservations {
+		if reservation.Status == status {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAllocatedBackorders reports whether stock was allocated to any backordered line of an order
+func hasAllocatedBackorders(order *domain.Order) bool {
+	for _, item := range order.Items {
+		if item.BackorderAllocated > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,320 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockReservationRepository struct {
+	mock.Mock
+}
+
+func (m *MockReservationRepository) Reserve(ctx context.Context, reservations []domain.StockReservation) error {
+	args := m.Called(ctx, reservations)
+	return args.Error(0)
+}
+
+func (m *MockReservationRepository) FindByOrderID(ctx context.Context, orderID uint) ([]domain.StockReservation, error) {
+	args := m.Called(ctx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.StockReservation), args.Error(1)
+}
+
+func (m *MockReservationRepository) GetReservedQuantity(ctx context.Context, productID uint) (int, error) {
+	args := m.Called(ctx, productID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockReservationRepository) Convert(ctx context.Context, orderID uint, change domain.StockChange) (int, error) {
+	args := m.Called(ctx, orderID, change)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockReservationRepository) Release(ctx context.Context, orderID uint, change domain.StockChange) error {
+	args := m.Called(ctx, orderID, change)
+	return args.Error(0)
+}
+
+func (m *MockReservationRepository) FindExpiredOrderIDs(ctx context.Context, before time.Time, limit int) ([]uint, error) {
+	args := m.Called(ctx, before, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (m *MockReservationRepository) Expire(ctx context.Context, orderID uint) error {
+	args := m.Called(ctx, orderID)
+	return args.Error(0)
+}
+
+func TestReserveOrder(t *testing.T) {
+	ctx := context.Background()
+
+	// Setup
+	mockReservationRepo := new(MockReservationRepository)
+	reservationService := service.NewReservationService(mockReservationRepo, new(MockOrderRepository), nil, new(MockProductRepository), nil, 15*time.Minute)
+
+	order := &domain.Order{
+		ID: 7,
+		Items: []domain.OrderItem{
+			{ProductID: 1, Quantity: 2},
+			{ProductID: 2, Quantity: 1},
+		},
+	}
+
+	var reserved []domain.StockReservation
+	mockReservationRepo.On("Reserve", ctx, mock.AnythingOfType("[]domain.StockReservation")).
+		Run(func(args mock.Arguments) { reserved = args.Get(1).([]domain.StockReservation) }).
+		Return(nil)
+
+	// Execute
+	err := reservationService.ReserveOrder(ctx, order)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, reserved, 2)
+	for i, reservation := range reserved {
+		assert.Equal(t, order.ID, reservation.OrderID)
+		assert.Equal(t, order.Items[i].ProductID, reservation.ProductID)
+		assert.Equal(t, order.Items[i].Quantity, reservation.Quantity)
+		assert.Equal(t, domain.ReservationStatusActive, reservation.Status)
+		assert.WithinDuration(t, time.Now().Add(15*time.Minute), reservation.ExpiresAt, time.Minute)
+	}
+}
+
+func TestConvertOrder(t *testing.T) {
+	ctx := context.Background()
+	orderID := uint(7)
+
+	t.Run("Success", func(t *testing.T) {
+		// Setup
+		mockReservationRepo := new(MockReservationRepository)
+		mockOrderRepo := new(MockOrderRepository)
+		reservationService := service.NewReservationService(mockReservationRepo, mockOrderRepo, nil, new(MockProductRepository), nil, 15*time.Minute)
+
+		mockOrderRepo.On("FindByID", ctx, orderID).Return(&domain.Order{ID: orderID, Status: domain.OrderStatusPending}, nil)
+		mockReservationRepo.On("FindByOrderID", ctx, orderID).Return([]domain.StockReservation{
+			{OrderID: orderID, ProductID: 1, Quantity: 2, Status: domain.ReservationStatusActive, ExpiresAt: time.Now().Add(10 * time.Minute)},
+		}, nil)
+		mockReservationRepo.On("Convert", ctx, orderID, domain.StockChange{Reason: domain.LedgerReasonOrder, ReferenceID: orderID, Actor: "system"}).Return(1, nil)
+
+		// Execute
+		err := reservationService.ConvertOrder(ctx, orderID)
+
+		// Assert
+		assert.NoError(t, err)
+		mockReservationRepo.AssertExpectations(t)
+	})
+
+	t.Run("Expired reservation", func(t *testing.T) {
+		// Setup
+		mockReservationRepo := new(MockReservationRepository)
+		mockOrderRepo := new(MockOrderRepository)
+		reservationService := service.NewReservationService(mockReservationRepo, mockOrderRepo, nil, new(MockProductRepository), nil, 15*time.Minute)
+
+		mockOrderRepo.On("FindByID", ctx, orderID).Return(&domain.Order{ID: orderID, Status: domain.OrderStatusPending}, nil)
+		mockReservationRepo.On("FindByOrderID", ctx, orderID).Return([]domain.StockReservation{
+			{OrderID: orderID, ProductID: 1, Quantity: 2, Status: domain.ReservationStatusExpired},
+		}, nil)
+
+		// Execute
+		err := reservationService.ConvertOrder(ctx, orderID)
+
+		// Assert
+		assert.Error(t, err)
+		mockReservationRepo.AssertNotCalled(t, "Convert", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Paid after the TTL", func(t *testing.T) {
+		// Setup
+		mockReservationRepo := new(MockReservationRepository)
+		mockOrderRepo := new(MockOrderRepository)
+		reservationService := service.NewReservationService(mockReservationRepo, mockOrderRepo, nil, new(MockProductRepository), nil, 15*time.Minute)
+
+		// The sweep has not marked the reservation expired yet, but its stock
+		// is on sale again and another checkout may hold it
+		mockOrderRepo.On("FindByID", ctx, orderID).Return(&domain.Order{ID: orderID, Status: domain.OrderStatusPending}, nil)
+		mockReservationRepo.On("FindByOrderID", ctx, orderID).Return([]domain.StockReservation{
+			{OrderID: orderID, ProductID: 1, Quantity: 2, Status: domain.ReservationStatusActive, ExpiresAt: time.Now().Add(-time.Minute)},
+		}, nil)
+
+		// Execute
+		err := reservationService.ConvertOrder(ctx, orderID)
+
+		// Assert
+		assert.EqualError(t, err, "stock reservation has expired")
+		mockReservationRepo.AssertNotCalled(t, "Convert", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Cancelled order", func(t *testing.T) {
+		// Setup
+		mockReservationRepo := new(MockReservationRepository)
+		mockOrderRepo := new(MockOrderRepository)
+		reservationService := service.NewReservationService(mockReservationRepo, mockOrderRepo, nil, new(MockProductRepository), nil, 15*time.Minute)
+
+		mockOrderRepo.On("FindByID", ctx, orderID).Return(&domain.Order{ID: orderID, Status: domain.OrderStatusCancelled}, nil)
+
+		// Execute
+		err := reservationService.ConvertOrder(ctx, orderID)
+
+		// Assert
+		assert.Error(t, err)
+	})
+}
+
+func TestReleaseOrder(t *testing.T) {
+	ctx := context.Background()
+	order := &domain.Order{
+		ID:    7,
+		Items: []domain.OrderItem{{ProductID: 1, Quantity: 2}},
+	}
+	change := domain.StockChange{Reason: domain.LedgerReasonCancellation, ReferenceID: order.ID, Actor: "system"}
+
+	t.Run("With reservations", func(t *testing.T) {
+		// Setup
+		mockReservationRepo := new(MockReservationRepository)
+		mockProductRepo := new(MockProductRepository)
+		reservationService := service.NewReservationService(mockReservationRepo, new(MockOrderRepository), nil, mockProductRepo, nil, 15*time.Minute)
+
+		mockReservationRepo.On("FindByOrderID", ctx, order.ID).Return([]domain.StockReservation{
+			{OrderID: order.ID, ProductID: 1, Quantity: 2, Status: domain.ReservationStatusActive},
+		}, nil)
+		mockReservationRepo.On("Release", ctx, order.ID, change).Return(nil)
+
+		// Execute
+		err := reservationService.ReleaseOrder(ctx, order, domain.LedgerReasonCancellation)
+
+		// Assert
+		assert.NoError(t, err)
+		mockReservationRepo.AssertExpectations(t)
+		mockProductRepo.AssertNotCalled(t, "UpdateStock", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Order placed before reservations", func(t *testing.T) {
+		// Setup
+		mockReservationRepo := new(MockReservationRepository)
+		mockProductRepo := new(MockProductRepository)
+		reservationService := service.NewReservationService(mockReservationRepo, new(MockOrderRepository), nil, mockProductRepo, nil, 15*time.Minute)
+
+		mockReservationRepo.On("FindByOrderID", ctx, order.ID).Return([]domain.StockReservation{}, nil)
+		mockProductRepo.On("UpdateStock", ctx, uint(1), 2, change).Return(nil)
+
+		// Execute
+		err := reservationService.ReleaseOrder(ctx, order, domain.LedgerReasonCancellation)
+
+		// Assert
+		assert.NoError(t, err)
+		mockProductRepo.AssertExpectations(t)
+		mockReservationRepo.AssertNotCalled(t, "Release", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestExpireReservations(t *testing.T) {
+	ctx := context.Background()
+
+	// Setup
+	mockReservationRepo := new(MockReservationRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	reservationService := service.NewReservationService(mockReservationRepo, mockOrderRepo, nil, new(MockProductRepository), nil, 15*time.Minute)
+
+	mockReservationRepo.On("FindExpiredOrderIDs", ctx, mock.AnythingOfType("time.Time"), 100).Return([]uint{1, 2}, nil)
+	mockReservationRepo.On("Expire", ctx, uint(1)).Return(nil)
+	mockReservationRepo.On("Expire", ctx, uint(2)).Return(nil)
+	mockOrderRepo.On("FindByID", ctx, uint(1)).Return(&domain.Order{ID: 1, Status: domain.OrderStatusPending}, nil)
+	mockOrderRepo.On("FindByID", ctx, uint(2)).Return(&domain.Order{ID: 2, Status: domain.OrderStatusCancelled}, nil)
+	mockOrderRepo.On("UpdateStatus", ctx, uint(1), domain.OrderStatusPending, domain.OrderStatusCancelled, domain.OrderStatusChange{Reason: "stock reservation expired", Actor: "system"}).Return(nil)
+
+	// Execute
+	expired, err := reservationService.ExpireReservations(ctx)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 1, expired)
+	mockReservationRepo.AssertExpectations(t)
+	mockOrderRepo.AssertExpectations(t)
+}
+
+func TestGetAvailableStock(t *testing.T) {
+	ctx := context.Background()
+	productID := uint(1)
+
+	t.Run("Success", func(t *testing.T) {
+		// Setup
+		mockReservationRepo := new(MockReservationRepository)
+		mockProductRepo := new(MockProductRepository)
+		reservationService := service.NewReservationService(mockReservationRepo, new(MockOrderRepository), nil, mockProductRepo, nil, 15*time.Minute)
+
+		mockProductRepo.On("FindByID", ctx, productID).Return(&domain.Product{ID: productID, Stock: 10}, nil)
+		mockReservationRepo.On("GetReservedQuantity", ctx, productID).Return(4, nil)
+
+		// Execute
+		available, err := reservationService.GetAvailableStock(ctx, productID)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 6, available)
+	})
+
+	t.Run("Product not found", func(t *testing.T) {
+		// Setup
+		mockReservationRepo := new(MockReservationRepository)
+		mockProductRepo := new(MockProductRepository)
+		reservationService := service.NewReservationService(mockReservationRepo, new(MockOrderRepository), nil, mockProductRepo, nil, 15*time.Minute)
+
+		mockProductRepo.On("FindByID", ctx, productID).Return(nil, errors.New("record not found"))
+
+		// Execute
+		_, err := reservationService.GetAvailableStock(ctx, productID)
+
+		// Assert
+		assert.Error(t, err)
+	})
+}
+
+func TestCreateOrderReservesStock(t *testing.T) {
+	ctx := context.Background()
+	userID := uint(1)
+
+	// Setup
+	mockOrderRepo := new(MockOrderRepository)
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(MockProductRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockReservationRepo := new(MockReservationRepository)
+	reservationService := service.NewReservationService(mockReservationRepo, mockOrderRepo, nil, mockProductRepo, nil, 15*time.Minute)
+	orderService := service.NewOrderService(mockOrderRepo, mockCartRepo, mockProductRepo, mockUserRepo, nil, service.WithReservations(reservationService))
+
+	cart := &domain.Cart{
+		ID:     uint(1),
+		UserID: userID,
+		Items:  []domain.CartItem{{ID: 1, CartID: 1, ProductID: 1, Quantity: 2}},
+	}
+
+	mockUserRepo.On("FindByID", ctx, userID).Return(&domain.User{ID: userID}, nil)
+	mockCartRepo.On("FindByUserID", ctx, userID).Return(cart, nil)
+	mockProductRepo.On("FindByID", ctx, uint(1)).Return(&domain.Product{ID: 1, Name: "Test Product", Price: usd(5000), Stock: 10}, nil)
+	mockOrderRepo.On("Create", ctx, mock.AnythingOfType("*domain.Order")).Return(nil)
+	mockReservationRepo.On("Reserve", ctx, mock.AnythingOfType("[]domain.StockReservation")).Return(nil)
+	mockCartRepo.On("ClearCart", ctx, cart.ID).Return(nil)
+
+	// Execute
+	order, err := orderService.CreateOrder(ctx, userID, "123 Main St", "123 Main St", 0)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NotNil(t, order)
+	mockReservationRepo.AssertExpectations(t)
+	// Stock is only decremented once the order is paid
+	mockProductRepo.AssertNotCalled(t, "UpdateStock", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+)
+
+// ShipmentService defines the interface for shipping orders and tracking their shipments
+type ShipmentService interface {
+	// CreateShipment sends units of the lines of an order with a carrier, or
+	// every unit left to ship when no items are given, and moves the order
+	// to the status its shipments make it. The carrier defaults to the
+	// carrier of the order's shipping method.
+	CreateShipment(ctx context.Context, orderID uint, carrier, trackingNumber string, items []domain.ShipmentItem) (*domain.Shipment, error)
+
+	// GetOrderShipments retrieves the shipments of an order, oldest first
+	GetOrderShipments(ctx context.Context, orderID uint) ([]domain.Shipment, error)
+
+	// HandleTrackingWebhook applies the tracking updates a carrier posts to
+	// the webhook through its adapter, returning how many were applied
+	HandleTrackingWebhook(ctx context.Context, carrier string, header http.Header, body []byte) (int, error)
+}
+
+// ShipmentServiceImpl implements the ShipmentService interface
+type ShipmentServiceImpl struct {
+	shipmentRepo repository.ShipmentRepository
+	orderRepo    repository.OrderRepository
+	stateMachine OrderStateMachine
+	transactor   repository.Transactor
+	adapters     map[string]CarrierTrackingAdapter
+}
+
+// NewShipmentService creates a new ShipmentServiceImpl. Tracking updates are
+// read by the adapter of their carrier.
+func NewShipmentService(shipmentRepo repository.ShipmentRepository, orderRepo repository.OrderRepository, stateMachine OrderStateMachine, transactor repository.Transactor, adapters ...CarrierTrackingAdapter) ShipmentService {
+	if stateMachine == nil {
+		stateMachine = NewOrderStateMachine(domain.DefaultOrderTransitions(), DefaultOrderStatusGuards(nil))
+	}
+
+	byCarrier := make(map[string]CarrierTrackingAdapter, len(adapters))
+	for _, adapter := range adapters {
+		byCarrier[adapter.Carrier()] = adapter
+	}
+	return &ShipmentServiceImpl{
+		shipmentRepo: shipmentRepo,
+		orderRepo:    orderRepo,
+		stateMachine: stateMachine,
+		transactor:   transactor,
+		adapters:     byCarrier,
+	}
+}
+
+// CreateShipment sends units of the lines of an order with a carrier
+func (s *ShipmentServiceImpl) CreateShipment(ctx context.Context, orderID uint, carrier, trackingNumber string, items []domain.ShipmentItem) (*domain.Shipment, error) {
+	if trackingNumber == "" {
+		return nil, errors.New("tracking number is required")
+	}
+
+	// Check if order exists
+	order, err := s.orderRepo.FindByID(ctx, orderID)
+	if err != nil {
+		return nil, errors.New("order not found")
+	}
+
+	if carrier == "" {
+		carrier = order.ShippingCarrier
+	}
+	if carrier == "" {
+		return nil, errors.New("carrier is required")
+	}
+
+	var shipment *domain.Shipment
+	err = withinTransaction(ctx, s.transactor, func(ctx context.Context) error {
+		shipments, err := s.shipmentRepo.FindByOrderID(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+
+		lines, err := shipmentLines(order, domain.ShippedQuantities(shipments), items)
+		if err != nil {
+			return err
+		}
+
+		shipment = &domain.Shipment{
+			OrderID:        order.ID,
+			Carrier:        carrier,
+			TrackingNumber: trackingNumber,
+			Status:         domain.ShipmentStatusShipped,
+			Items:          lines,
+			ShippedAt:      time.Now(),
+		}
+
+		// The order has to be in a status it can ship from
+		status, _ := domain.ShipmentOrderStatus(order, append(shipments, *shipment))
+		if status != order.Status {
+			if err := s.stateMachine.CanTransition(ctx, order, status); err != nil {
+				return fmt.Errorf("order cannot be shipped: %w", err)
+			}
+		}
+
+		if err := s.shipmentRepo.Create(ctx, shipment); err != nil {
+			return err
+		}
+
+		if status == order.Status {
+			return nil
+		}
+		reason := fmt.Sprintf("shipment %s sent with %s", trackingNumber, carrier)
+		return transitionOrder(ctx, s.orderRepo, s.stateMachine, order, status, orderStatusChange(ctx, reason))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return shipment, nil
+}
+
+// GetOrderShipments retrieves the shipments of an order, oldest first
+func (s *ShipmentServiceImpl) GetOrderShipments(ctx context.Context, orderID uint) ([]domain.Shipment, error) {
+	return s.shipmentRepo.FindByOrderID(ctx, orderID)
+}
+
+// HandleTrackingWebhook applies the tracking updates a carrier posts to the webhook
+func (s *ShipmentServiceImpl) HandleTrackingWebhook(ctx context.Context, carrier string, header http.Header, body []byte) (int, error) {
+	adapter, ok := s.adapters[carrier]
+	if !ok {
+		return 0, domain.ErrUnknownCarrier
+	}
+
+	updates, err := adapter.ParseTrackingUpdates(header, body)
+	if err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	for _, update := range updates {
+		ok, err := s.applyTrackingUpdate(ctx, carrier, update)
+		if err != nil {
+			return applied, err
+		}
+		if ok {
+			applied++
+		}
+	}
+
+	return applied, nil
+}
+
+// applyTrackingUpdate records a tracking update on its shipment and moves the
+// order to the status its shipments now make it, when its lifecycle allows.
+// Updates of shipments the store does not know, and updates older than the
+// last one applied, since carriers do not always report in order, are skipped.
+// The shipment is read locked in the transaction applying the update, so
+// concurrent updates of it never apply to a state another one replaced.
+func (s *ShipmentServiceImpl) applyTrackingUpdate(ctx context.Context, carrier string, update domain.TrackingUpdate) (bool, error) {
+	occurredAt := update.OccurredAt
+	if occurredAt.IsZero() {
+		occurredAt = time.Now()
+	}
+
+	applied := false
+	err := withinTransaction(ctx, s.transactor, func(ctx context.Context) error {
+		shipment, err := s.shipmentRepo.FindByTrackingNumber(ctx, carrier, update.TrackingNumber)
+		if err != nil {
+			return nil
+		}
+		if shipment.TrackedAt != nil && occurredAt.Before(*shipment.TrackedAt) {
+			return nil
+		}
+
+		shipment.Status = update.Status
+		shipment.StatusDetail = update.Detail
+		shipment.TrackedAt = &occurredAt
+		if update.Status == domain.ShipmentStatusDelivered {
+			shipment.DeliveredAt = &occurredAt
+		}
+		if err := s.shipmentRepo.Update(ctx, shipment); err != nil {
+			return err
+		}
+		applied = true
+
+		order, err := s.orderRepo.FindByID(ctx, shipment.OrderID)
+		if err != nil {
+			return err
+		}
+		shipments, err := s.shipmentRepo.FindByOrderID(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+
+		status, ok := domain.ShipmentOrderStatus(order, shipments)
+		if !ok || status == order.Status || s.stateMachine.CanTransition(ctx, order, status) != nil {
+			return nil
+		}
+		return transitionOrder(ctx, s.orderRepo, s.stateMachine, order, status, domain.OrderStatusChange{
+			Reason: fmt.Sprintf("%s reported shipment %s %s", carrier, shipment.TrackingNumber, update.Status),
+			Actor:  "carrier:" + carrier,
+		})
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return applied, nil
+}
+
+// shipmentLines returns the lines of a shipment carrying the requested units
+// of an order, or every unit left to ship when none are requested. Only
+// units with stock that no other shipment carries can be shipped.
+func shipmentLines(order *domain.Order, shipped map[uint]int, requested []domain.ShipmentItem) ([]domain.ShipmentItem, error) {
+	left := make(map[uint]int, len(order.Items))
+	items := make(map[uint]domain.OrderItem, len(order.Items))
+	for _, item := range order.Items {
+		if item.IsShippable() {
+			left[item.ID] = item.ReadyToShip() - shipped[item.ID]
+			items[item.ID] = item
+		}
+	}
+
+	if len(requested) == 0 {
+		var lines []domain.ShipmentItem
+		for _, item := range order.Items {
+			if left[item.ID] > 0 {
+				lines = append(lines, domain.ShipmentItem{OrderItemID: item.ID, ProductID: item.ProductID, Quantity: left[item.ID]})
+			}
+		}
+		if len(lines) == 0 {
+			return nil, errors.New("order has nothing left to ship")
+		}
+		return lines, nil
+	}
+
+	lines := make([]domain.ShipmentItem, 0, len(requested))
+	for _, line := range requested {
+		item, ok := items[line.OrderItemID]
+		if !ok {
+			return nil, fmt.Errorf("order item %d is not shipped", line.OrderItemID)
+		}
+		if line.Quantity <= 0 {
+			return nil, errors.New("quantity must be positive")
+		}
+		if line.Quantity > left[item.ID] {
+			return nil, fmt.Errorf("order item %d has %d units left to ship", item.ID, max(left[item.ID], 0))
+		}
+		left[item.ID] -= line.Quantity
+		lines = append(lines, domain.ShipmentItem{OrderItemID: item.ID, ProductID: item.ProductID, Quantity: line.Quantity})
+	}
+	return lines, nil
+}
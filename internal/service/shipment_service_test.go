@@ -0,0 +1,364 @@
+package service_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockShipmentRepository is a mock implementation of the ShipmentRepository interface
+type MockShipmentRepository struct {
+	mock.Mock
+}
+
+func (m *MockShipmentRepository) FindByOrderID(ctx context.Context, orderID uint) ([]domain.Shipment, error) {
+	args := m.Called(ctx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Shipment), args.Error(1)
+}
+
+func (m *MockShipmentRepository) FindByTrackingNumber(ctx context.Context, carrier, trackingNumber string) (*domain.Shipment, error) {
+	args := m.Called(ctx, carrier, trackingNumber)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Shipment), args.Error(1)
+}
+
+func (m *MockShipmentRepository) Create(ctx context.Context, shipment *domain.Shipment) error {
+	args := m.Called(ctx, shipment)
+	return args.Error(0)
+}
+
+func (m *MockShipmentRepository) Update(ctx context.Context, shipment *domain.Shipment) error {
+	args := m.Called(ctx, shipment)
+	return args.Error(0)
+}
+
+// shippableOrder returns a paid order of two physical lines, two units of
+// which wait for stock, and a digital line
+func shippableOrder() *domain.Order {
+	return &domain.Order{
+		ID:              1,
+		UserID:          2,
+		Status:          domain.OrderStatusProcessing,
+		ShippingCarrier: service.LocalCarrier,
+		Items: []domain.OrderItem{
+			{ID: 11, ProductID: 1, Quantity: 2, ProductType: domain.ProductTypePhysical},
+			{ID: 12, ProductID: 2, Quantity: 3, BackorderedQuantity: 2, ProductType: domain.ProductTypePhysical},
+			{ID: 13, ProductID: 3, Quantity: 1, ProductType: domain.ProductTypeDigital, DeliveryMethod: domain.DeliveryMethodDownload},
+		},
+	}
+}
+
+// signTracking signs a tracking webhook body as the local carrier does
+func signTracking(secret string, body []byte) http.Header {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	header := http.Header{}
+	header.Set("X-Signature", hex.EncodeToString(mac.Sum(nil)))
+	return header
+}
+
+func TestCreateShipment(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Everything Ready", func(t *testing.T) {
+		mockShipmentRepo := new(MockShipmentRepository)
+		mockOrderRepo := new(MockOrderRepository)
+		shipmentService := service.NewShipmentService(mockShipmentRepo, mockOrderRepo, nil, nil)
+		order := shippableOrder()
+
+		// The backordered units and the digital line stay behind
+		mockOrderRepo.On("FindByID", ctx, uint(1)).Return(order, nil)
+		mockShipmentRepo.On("FindByOrderID", ctx, uint(1)).Return([]domain.Shipment{}, nil)
+		mockShipmentRepo.On("Create", ctx, mock.MatchedBy(func(shipment *domain.Shipment) bool {
+			return shipment.Carrier == service.LocalCarrier && shipment.TrackingNumber == "LC1" && shipment.Status == domain.ShipmentStatusShipped &&
+				assert.ObjectsAreEqual([]domain.ShipmentItem{
+					{OrderItemID: 11, ProductID: 1, Quantity: 2},
+					{OrderItemID: 12, ProductID: 2, Quantity: 1},
+				}, shipment.Items)
+		})).Return(nil)
+		mockOrderRepo.On("UpdateStatus", ctx, uint(1), domain.OrderStatusProcessing, domain.OrderStatusPartiallyShipped, domain.OrderStatusChange{Reason: "shipment LC1 sent with local", Actor: "system"}).Return(nil)
+
+		shipment, err := shipmentService.CreateShipment(ctx, 1, "", "LC1", nil)
+
+		assert.NoError(t, err)
+		assert.Len(t, shipment.Items, 2)
+		mockShipmentRepo.AssertExpectations(t)
+		mockOrderRepo.AssertExpectations(t)
+	})
+
+	t.Run("Last Units", func(t *testing.T) {
+		mockShipmentRepo := new(MockShipmentRepository)
+		mockOrderRepo := new(MockOrderRepository)
+		shipmentService := service.NewShipmentService(mockShipmentRepo, mockOrderRepo, nil, nil)
+		order := shippableOrder()
+		order.Status = domain.OrderStatusPartiallyShipped
+		order.Items[1].BackorderAllocated = 2
+
+		// The backorder got its stock, so its units complete the order
+		mockOrderRepo.On("FindByID", ctx, uint(1)).Return(order, nil)
+		mockShipmentRepo.On("FindByOrderID", ctx, uint(1)).Return([]domain.Shipment{{ID: 5, OrderID: 1, Status: domain.ShipmentStatusInTransit, Items: []domain.ShipmentItem{
+			{OrderItemID: 11, ProductID: 1, Quantity: 2},
+			{OrderItemID: 12, ProductID: 2, Quantity: 1},
+		}}}, nil)
+		mockShipmentRepo.On("Create", ctx, mock.MatchedBy(func(shipment *domain.Shipment) bool {
+			return assert.ObjectsAreEqual([]domain.ShipmentItem{{OrderItemID: 12, ProductID: 2, Quantity: 2}}, shipment.Items)
+		})).Return(nil)
+		mockOrderRepo.On("UpdateStatus", ctx, uint(1), domain.OrderStatusPartiallyShipped, domain.OrderStatusShipped, mock.Anything).Return(nil)
+
+		_, err := shipmentService.CreateShipment(ctx, 1, "ups", "1Z2", []domain.ShipmentItem{{OrderItemID: 12, Quantity: 2}})
+
+		assert.NoError(t, err)
+		mockShipmentRepo.AssertExpectations(t)
+		mockOrderRepo.AssertExpectations(t)
+	})
+
+	t.Run("More Than Left", func(t *testing.T) {
+		mockShipmentRepo := new(MockShipmentRepository)
+		mockOrderRepo := new(MockOrderRepository)
+		shipmentService := service.NewShipmentService(mockShipmentRepo, mockOrderRepo, nil, nil)
+
+		mockOrderRepo.On("FindByID", ctx, uint(1)).Return(shippableOrder(), nil)
+		mockShipmentRepo.On("FindByOrderID", ctx, uint(1)).Return([]domain.Shipment{}, nil)
+
+		// Two of the three units are backordered
+		_, err := shipmentService.CreateShipment(ctx, 1, "", "LC1", []domain.ShipmentItem{{OrderItemID: 12, Quantity: 2}})
+
+		assert.EqualError(t, err, "order item 12 has 1 units left to ship")
+		mockShipmentRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Digital Line", func(t *testing.T) {
+		mockShipmentRepo := new(MockShipmentRepository)
+		mockOrderRepo := new(MockOrderRepository)
+		shipmentService := service.NewShipmentService(mockShipmentRepo, mockOrderRepo, nil, nil)
+
+		mockOrderRepo.On("FindByID", ctx, uint(1)).Return(shippableOrder(), nil)
+		mockShipmentRepo.On("FindByOrderID", ctx, uint(1)).Return([]domain.Shipment{}, nil)
+
+		_, err := shipmentService.CreateShipment(ctx, 1, "", "LC1", []domain.ShipmentItem{{OrderItemID: 13, Quantity: 1}})
+
+		assert.EqualError(t, err, "order item 13 is not shipped")
+		mockShipmentRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Unpaid Order", func(t *testing.T) {
+		mockShipmentRepo := new(MockShipmentRepository)
+		mockOrderRepo := new(MockOrderRepository)
+		shipmentService := service.NewShipmentService(mockShipmentRepo, mockOrderRepo, nil, nil)
+		order := shippableOrder()
+		order.Status = domain.OrderStatusPending
+
+		mockOrderRepo.On("FindByID", ctx, uint(1)).Return(order, nil)
+		mockShipmentRepo.On("FindByOrderID", ctx, uint(1)).Return([]domain.Shipment{}, nil)
+
+		_, err := shipmentService.CreateShipment(ctx, 1, "", "LC1", nil)
+
+		assert.EqualError(t, err, "order cannot be shipped: invalid status transition")
+		mockShipmentRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		mockOrderRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Rolled Back", func(t *testing.T) {
+		mockShipmentRepo := new(MockShipmentRepository)
+		mockOrderRepo := new(MockOrderRepository)
+		transactor := &fakeTransactor{}
+		shipmentService := service.NewShipmentService(mockShipmentRepo, mockOrderRepo, nil, transactor)
+
+		// The shipment is only kept along with the order status it makes
+		mockOrderRepo.On("FindByID", ctx, uint(1)).Return(shippableOrder(), nil)
+		mockShipmentRepo.On("FindByOrderID", mock.MatchedBy(inTransaction), uint(1)).Return([]domain.Shipment{}, nil)
+		mockShipmentRepo.On("Create", mock.MatchedBy(inTransaction), mock.Anything).Return(nil)
+		mockOrderRepo.On("UpdateStatus", mock.MatchedBy(inTransaction), uint(1), domain.OrderStatusProcessing, domain.OrderStatusPartiallyShipped, mock.Anything).Return(errors.New("database error"))
+
+		_, err := shipmentService.CreateShipment(ctx, 1, "", "LC1", nil)
+
+		assert.EqualError(t, err, "database error")
+		assert.Equal(t, 1, transactor.rolledBack)
+		mockShipmentRepo.AssertExpectations(t)
+	})
+}
+
+func TestHandleTrackingWebhook(t *testing.T) {
+	ctx := context.Background()
+	secret := "webhook-secret"
+	shippedAt := time.Date(2026, 5, 4, 10, 0, 0, 0, time.UTC)
+
+	t.Run("Last Shipment Delivered", func(t *testing.T) {
+		mockShipmentRepo := new(MockShipmentRepository)
+		mockOrderRepo := new(MockOrderRepository)
+		shipmentService := service.NewShipmentService(mockShipmentRepo, mockOrderRepo, nil, nil, service.NewLocalTrackingAdapter(secret))
+		order := shippableOrder()
+		order.Status = domain.OrderStatusShipped
+		order.Items[1].BackorderAllocated = 2
+
+		first := domain.Shipment{ID: 5, OrderID: 1, Carrier: "local", TrackingNumber: "LC1", Status: domain.ShipmentStatusDelivered, Items: []domain.ShipmentItem{
+			{OrderItemID: 11, ProductID: 1, Quantity: 2},
+			{OrderItemID: 12, ProductID: 2, Quantity: 1},
+		}}
+		second := &domain.Shipment{ID: 6, OrderID: 1, Carrier: "local", TrackingNumber: "LC2", Status: domain.ShipmentStatusInTransit, TrackedAt: &shippedAt, Items: []domain.ShipmentItem{
+			{OrderItemID: 12, ProductID: 2, Quantity: 2},
+		}}
+		body := []byte(`{"events":[{"tracking_number":"LC2","status":"delivered","detail":"Left at front door","occurred_at":"2026-05-06T15:04:05Z"}]}`)
+		deliveredAt := time.Date(2026, 5, 6, 15, 4, 5, 0, time.UTC)
+
+		mockShipmentRepo.On("FindByTrackingNumber", ctx, "local", "LC2").Return(second, nil)
+		mockShipmentRepo.On("Update", ctx, mock.MatchedBy(func(shipment *domain.Shipment) bool {
+			return shipment.Status == domain.ShipmentStatusDelivered && shipment.StatusDetail == "Left at front door" &&
+				shipment.DeliveredAt != nil && shipment.DeliveredAt.Equal(deliveredAt)
+		})).Return(nil)
+		mockOrderRepo.On("FindByID", ctx, uint(1)).Return(order, nil)
+		mockShipmentRepo.On("FindByOrderID", ctx, uint(1)).Return([]domain.Shipment{first, {ID: 6, OrderID: 1, Status: domain.ShipmentStatusDelivered, Items: second.Items}}, nil)
+		mockOrderRepo.On("UpdateStatus", ctx, uint(1), domain.OrderStatusShipped, domain.OrderStatusDelivered, domain.OrderStatusChange{Reason: "local reported shipment LC2 delivered", Actor: "carrier:local"}).Return(nil)
+
+		applied, err := shipmentService.HandleTrackingWebhook(ctx, "local", signTracking(secret, body), body)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, applied)
+		mockShipmentRepo.AssertExpectations(t)
+		mockOrderRepo.AssertExpectations(t)
+	})
+
+	t.Run("Order Still Partially Shipped", func(t *testing.T) {
+		mockShipmentRepo := new(MockShipmentRepository)
+		mockOrderRepo := new(MockOrderRepository)
+		shipmentService := service.NewShipmentService(mockShipmentRepo, mockOrderRepo, nil, nil, service.NewLocalTrackingAdapter(secret))
+		order := shippableOrder()
+		order.Status = domain.OrderStatusPartiallyShipped
+
+		shipment := &domain.Shipment{ID: 5, OrderID: 1, Carrier: "local", TrackingNumber: "LC1", Status: domain.ShipmentStatusShipped, Items: []domain.ShipmentItem{
+			{OrderItemID: 11, ProductID: 1, Quantity: 2},
+		}}
+		body := []byte(`{"events":[{"tracking_number":"LC1","status":"delivered"}]}`)
+
+		// Delivering part of the order leaves it partially shipped
+		mockShipmentRepo.On("FindByTrackingNumber", ctx, "local", "LC1").Return(shipment, nil)
+		mockShipmentRepo.On("Update", ctx, shipment).Return(nil)
+		mockOrderRepo.On("FindByID", ctx, uint(1)).Return(order, nil)
+		mockShipmentRepo.On("FindByOrderID", ctx, uint(1)).Return([]domain.Shipment{*shipment}, nil)
+
+		applied, err := shipmentService.HandleTrackingWebhook(ctx, "local", signTracking(secret, body), body)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, applied)
+		mockOrderRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Stale And Unknown Updates", func(t *testing.T) {
+		mockShipmentRepo := new(MockShipmentRepository)
+		mockOrderRepo := new(MockOrderRepository)
+		shipmentService := service.NewShipmentService(mockShipmentRepo, mockOrderRepo, nil, nil, service.NewLocalTrackingAdapter(secret))
+
+		trackedAt := shippedAt.Add(48 * time.Hour)
+		shipment := &domain.Shipment{ID: 5, OrderID: 1, Carrier: "local", TrackingNumber: "LC1", Status: domain.ShipmentStatusDelivered, TrackedAt: &trackedAt}
+		body := []byte(`{"events":[{"tracking_number":"LC1","status":"in_transit","occurred_at":"2026-05-05T08:00:00Z"},{"tracking_number":"XX9","status":"delivered"}]}`)
+
+		// The carrier reports an older event late, and a parcel that is not ours
+		mockShipmentRepo.On("FindByTrackingNumber", ctx, "local", "LC1").Return(shipment, nil)
+		mockShipmentRepo.On("FindByTrackingNumber", ctx, "local", "XX9").Return(nil, errors.New("record not found"))
+
+		applied, err := shipmentService.HandleTrackingWebhook(ctx, "local", signTracking(secret, body), body)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, applied)
+		assert.Equal(t, domain.ShipmentStatusDelivered, shipment.Status)
+		mockShipmentRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Concurrent Updates", func(t *testing.T) {
+		mockShipmentRepo := new(MockShipmentRepository)
+		mockOrderRepo := new(MockOrderRepository)
+		transactor := &fakeTransactor{}
+		shipmentService := service.NewShipmentService(mockShipmentRepo, mockOrderRepo, nil, transactor, service.NewLocalTrackingAdapter(secret))
+
+		// Another update delivered the shipment while this older one waited
+		// for its lock, so the older one finds the delivery and is skipped
+		trackedAt := shippedAt.Add(48 * time.Hour)
+		delivered := &domain.Shipment{ID: 5, OrderID: 1, Carrier: "local", TrackingNumber: "LC1", Status: domain.ShipmentStatusDelivered, TrackedAt: &trackedAt, DeliveredAt: &trackedAt}
+		body := []byte(`{"events":[{"tracking_number":"LC1","status":"out_for_delivery","occurred_at":"2026-05-05T08:00:00Z"}]}`)
+
+		mockShipmentRepo.On("FindByTrackingNumber", mock.MatchedBy(inTransaction), "local", "LC1").Return(delivered, nil).Once()
+
+		applied, err := shipmentService.HandleTrackingWebhook(ctx, "local", signTracking(secret, body), body)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, applied)
+		assert.Equal(t, domain.ShipmentStatusDelivered, delivered.Status)
+		mockShipmentRepo.AssertExpectations(t)
+		mockShipmentRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Invalid Signature", func(t *testing.T) {
+		mockShipmentRepo := new(MockShipmentRepository)
+		shipmentService := service.NewShipmentService(mockShipmentRepo, new(MockOrderRepository), nil, nil, service.NewLocalTrackingAdapter(secret))
+		body := []byte(`{"events":[{"tracking_number":"LC1","status":"delivered"}]}`)
+
+		applied, err := shipmentService.HandleTrackingWebhook(ctx, "local", signTracking("guessed", body), body)
+
+		assert.ErrorIs(t, err, domain.ErrInvalidWebhookSignature)
+		assert.Equal(t, 0, applied)
+		mockShipmentRepo.AssertNotCalled(t, "FindByTrackingNumber", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Unknown Status", func(t *testing.T) {
+		shipmentService := service.NewShipmentService(new(MockShipmentRepository), new(MockOrderRepository), nil, nil, service.NewLocalTrackingAdapter(secret))
+		body := []byte(`{"events":[{"tracking_number":"LC1","status":"lost"}]}`)
+
+		_, err := shipmentService.HandleTrackingWebhook(ctx, "local", signTracking(secret, body), body)
+
+		assert.ErrorIs(t, err, domain.ErrInvalidTrackingPayload)
+	})
+
+	t.Run("Unknown Carrier", func(t *testing.T) {
+		shipmentService := service.NewShipmentService(new(MockShipmentRepository), new(MockOrderRepository), nil, nil, service.NewLocalTrackingAdapter(secret))
+
+		_, err := shipmentService.HandleTrackingWebhook(ctx, "ups", http.Header{}, []byte(`{}`))
+
+		assert.ErrorIs(t, err, domain.ErrUnknownCarrier)
+	})
+}
+
+func TestShipmentOrderStatus(t *testing.T) {
+	order := shippableOrder()
+	order.Items[1].BackorderAllocated = 2
+	all := []domain.ShipmentItem{{OrderItemID: 11, Quantity: 2}, {OrderItemID: 12, Quantity: 3}}
+
+	tests := []struct {
+		name      string
+		shipments []domain.Shipment
+		expected  domain.OrderStatus
+	}{
+		{"Part Shipped", []domain.Shipment{{Status: domain.ShipmentStatusDelivered, Items: all[:1]}}, domain.OrderStatusPartiallyShipped},
+		{"All Shipped", []domain.Shipment{{Status: domain.ShipmentStatusInTransit, Items: all}}, domain.OrderStatusShipped},
+		{"Some Delivered", []domain.Shipment{{Status: domain.ShipmentStatusDelivered, Items: all[:1]}, {Status: domain.ShipmentStatusFailed, Items: all[1:]}}, domain.OrderStatusShipped},
+		{"All Delivered", []domain.Shipment{{Status: domain.ShipmentStatusDelivered, Items: all[:1]}, {Status: domain.ShipmentStatusDelivered, Items: all[1:]}}, domain.OrderStatusDelivered},
+		{"All Returned", []domain.Shipment{{Status: domain.ShipmentStatusReturned, Items: all}}, domain.OrderStatusReturned},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, ok := domain.ShipmentOrderStatus(order, tt.shipments)
+
+			assert.True(t, ok)
+			assert.Equal(t, tt.expected, status)
+		})
+	}
+
+	_, ok := domain.ShipmentOrderStatus(order, nil)
+	assert.False(t, ok)
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"awesomeEcommerce/internal/domain"
+)
+
+// ShippingRateProvider defines the interface for carriers pricing the
+// shipping of their methods, so carrier APIs can plug in
+type ShippingRateProvider interface {
+	// Carrier returns the name carrier methods pick the provider by
+	Carrier() string
+
+	// Quote returns what shipping a parcel to a destination with a method of
+	// the carrier costs, in the base currency
+	Quote(ctx context.Context, method *domain.ShippingMethod, destination domain.Destination, parcel domain.Parcel) (domain.Money, error)
+}
+
+// LocalCarrier is the carrier name of the local rate provider
+const LocalCarrier = "local"
+
+// localMaxWeight is the heaviest parcel, in grams, the local carrier takes
+const localMaxWeight = 30000
+
+// LocalRateProvider implements the ShippingRateProvider interface without a
+// carrier API, standing in for one in development and tests. It prices
+// parcels as carriers do, by the started kilogram of their billable weight.
+type LocalRateProvider struct{}
+
+// NewLocalRateProvider creates a new LocalRateProvider
+func NewLocalRateProvider() ShippingRateProvider {
+	return &LocalRateProvider{}
+}
+
+// Carrier returns the name carrier methods pick the provider by
+func (p *LocalRateProvider) Carrier() string {
+	return LocalCarrier
+}
+
+// Quote returns the method's rate plus its rate per started kilogram of the
+// parcel's billable weight. Parcels over 30 kg are refused.
+func (p *LocalRateProvider) Quote(ctx context.Context, method *domain.ShippingMethod, destination domain.Destination, parcel domain.Parcel) (domain.Money, error) {
+	weight := parcel.BillableWeight()
+	if weight > localMaxWeight {
+		return domain.Money{}, errors.New("parcel exceeds the carrier's weight limit")
+	}
+	return method.Rate.Add(method.PerKg.Multiply(domain.StartedKilograms(weight)))
+}
@@ -0,0 +1,291 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+)
+
+// ShippingService defines the interface for shipping zones, methods and rates
+type ShippingService interface {
+	// GetZones retrieves every shipping zone
+	GetZones(ctx context.Context) ([]domain.ShippingZone, error)
+
+	// GetZoneByID retrieves a shipping zone by its ID
+	GetZoneByID(ctx context.Context, id uint) (*domain.ShippingZone, error)
+
+	// CreateZone creates a new shipping zone
+	CreateZone(ctx context.Context, zone *domain.ShippingZone) error
+
+	// UpdateZone updates an existing shipping zone
+	UpdateZone(ctx context.Context, zone *domain.ShippingZone) error
+
+	// DeleteZone deletes a shipping zone no shipping method uses
+	DeleteZone(ctx context.Context, id uint) error
+
+	// GetMethods retrieves every shipping method
+	GetMethods(ctx context.Context) ([]domain.ShippingMethod, error)
+
+	// GetMethodByID retrieves a shipping method by its ID
+	GetMethodByID(ctx context.Context, id uint) (*domain.ShippingMethod, error)
+
+	// CreateMethod creates a new shipping method
+	CreateMethod(ctx context.Context, method *domain.ShippingMethod) error
+
+	// UpdateMethod updates an existing shipping method
+	UpdateMethod(ctx context.Context, method *domain.ShippingMethod) error
+
+	// DeleteMethod deletes a shipping method
+	DeleteMethod(ctx context.Context, id uint) error
+
+	// Options returns the shipping options of a parcel sent to a destination,
+	// priced in the currency the customer shops in
+	Options(ctx context.Context, destination domain.Destination, parcel domain.Parcel, conversion domain.Conversion) ([]domain.ShippingOption, error)
+
+	// Quote returns the option of a shipping method for a parcel sent to a
+	// destination, failing when the method is not offered there
+	Quote(ctx context.Context, methodID uint, destination domain.Destination, parcel domain.Parcel, conversion domain.Conversion) (*domain.ShippingOption, error)
+}
+
+// ShippingServiceImpl implements the ShippingService interface
+type ShippingServiceImpl struct {
+	zoneRepo   repository.ShippingZoneRepository
+	methodRepo repository.ShippingMethodRepository
+	providers  map[string]ShippingRateProvider
+}
+
+// NewShippingService creates a new ShippingServiceImpl. Carrier methods are
+// priced by the provider of their carrier.
+func NewShippingService(zoneRepo repository.ShippingZoneRepository, methodRepo repository.ShippingMethodRepository, providers ...ShippingRateProvider) ShippingService {
+	byCarrier := make(map[string]ShippingRateProvider, len(providers))
+	for _, provider := range providers {
+		byCarrier[provider.Carrier()] = provider
+	}
+	return &ShippingServiceImpl{
+		zoneRepo:   zoneRepo,
+		methodRepo: methodRepo,
+		providers:  byCarrier,
+	}
+}
+
+// GetZones retrieves every shipping zone
+func (s *ShippingServiceImpl) GetZones(ctx context.Context) ([]domain.ShippingZone, error) {
+	return s.zoneRepo.FindAll(ctx)
+}
+
+// GetZoneByID retrieves a shipping zone by its ID
+func (s *ShippingServiceImpl) GetZoneByID(ctx context.Context, id uint) (*domain.ShippingZone, error) {
+	return s.zoneRepo.FindByID(ctx, id)
+}
+
+// CreateZone creates a new shipping zone
+func (s *ShippingServiceImpl) CreateZone(ctx context.Context, zone *domain.ShippingZone) error {
+	if err := validateShippingZone(zone); err != nil {
+		return err
+	}
+	return s.zoneRepo.Create(ctx, zone)
+}
+
+// UpdateZone updates an existing shipping zone
+func (s *ShippingServiceImpl) UpdateZone(ctx context.Context, zone *domain.ShippingZone) error {
+	if err := validateShippingZone(zone); err != nil {
+		return err
+	}
+	return s.zoneRepo.Update(ctx, zone)
+}
+
+// DeleteZone deletes a shipping zone no shipping method uses
+func (s *ShippingServiceImpl) DeleteZone(ctx context.Context, id uint) error {
+	if _, err := s.zoneRepo.FindByID(ctx, id); err != nil {
+		return errors.New("shipping zone not found")
+	}
+
+	methods, err := s.methodRepo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	for _, method := range methods {
+		if method.ZoneID != nil && *method.ZoneID == id {
+			return errors.New("shipping zone has shipping methods")
+		}
+	}
+	return s.zoneRepo.Delete(ctx, id)
+}
+
+// GetMethods retrieves every shipping method
+func (s *ShippingServiceImpl) GetMethods(ctx context.Context) ([]domain.ShippingMethod, error) {
+	return s.methodRepo.FindAll(ctx)
+}
+
+// GetMethodByID retrieves a shipping method by its ID
+func (s *ShippingServiceImpl) GetMethodByID(ctx context.Context, id uint) (*domain.ShippingMethod, error) {
+	return s.methodRepo.FindByID(ctx, id)
+}
+
+// CreateMethod creates a new shipping method
+func (s *ShippingServiceImpl) CreateMethod(ctx context.Context, method *domain.ShippingMethod) error {
+	if err := s.validateShippingMethod(ctx, method); err != nil {
+		return err
+	}
+	return s.methodRepo.Create(ctx, method)
+}
+
+// UpdateMethod updates an existing shipping method
+func (s *ShippingServiceImpl) UpdateMethod(ctx context.Context, method *domain.ShippingMethod) error {
+	if err := s.validateShippingMethod(ctx, method); err != nil {
+		return err
+	}
+	return s.methodRepo.Update(ctx, method)
+}
+
+// DeleteMethod deletes a shipping method
+func (s *ShippingServiceImpl) DeleteMethod(ctx context.Context, id uint) error {
+	if _, err := s.methodRepo.FindByID(ctx, id); err != nil {
+		return errors.New("shipping method not found")
+	}
+	return s.methodRepo.Delete(ctx, id)
+}
+
+// Options returns the shipping options of a parcel sent to a destination,
+// priced in the currency the customer shops in. The active methods of the
+// most narrowly located zone covering the destination are offered, with the
+// methods of no zone. Carrier methods whose carrier cannot quote the parcel
+// are left out. Parcels with nothing to ship have no options.
+func (s *ShippingServiceImpl) Options(ctx context.Context, destination domain.Destination, parcel domain.Parcel, conversion domain.Conversion) ([]domain.ShippingOption, error) {
+	if parcel.IsEmpty() {
+		return nil, nil
+	}
+	destination = domain.NormalizeDestination(destination)
+
+	zones, err := s.zoneRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	zone := domain.FindShippingZone(zones, destination)
+
+	methods, err := s.methodRepo.FindActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var options []domain.ShippingOption
+	for i := range methods {
+		method := &methods[i]
+		if method.ZoneID != nil && (zone == nil || *method.ZoneID != zone.ID) {
+			continue
+		}
+		cost, err := s.cost(ctx, method, destination, parcel, conversion)
+		if err != nil {
+			continue
+		}
+		options = append(options, method.Option(cost))
+	}
+	return options, nil
+}
+
+// Quote returns the option of a shipping method for a parcel sent to a
+// destination, failing when the method is not offered there
+func (s *ShippingServiceImpl) Quote(ctx context.Context, methodID uint, destination domain.Destination, parcel domain.Parcel, conversion domain.Conversion) (*domain.ShippingOption, error) {
+	options, err := s.Options(ctx, destination, parcel, conversion)
+	if err != nil {
+		return nil, err
+	}
+	for i := range options {
+		if options[i].MethodID == methodID {
+			return &options[i], nil
+		}
+	}
+	return nil, errors.New("shipping method is not available for this destination")
+}
+
+// cost returns what a method charges for a parcel, in the currency the
+// customer shops in
+func (s *ShippingServiceImpl) cost(ctx context.Context, method *domain.ShippingMethod, destination domain.Destination, parcel domain.Parcel, conversion domain.Conversion) (domain.Money, error) {
+	if method.Type != domain.ShippingMethodCarrier {
+		return method.Cost(parcel, conversion)
+	}
+
+	provider, ok := s.providers[method.Carrier]
+	if !ok {
+		return domain.Money{}, errors.New("unknown carrier")
+	}
+	quote, err := provider.Quote(ctx, method, destination, parcel)
+	if err != nil {
+		return domain.Money{}, err
+	}
+	return conversion.Convert(quote), nil
+}
+
+// validateShippingZone normalizes the destinations of a shipping zone the
+// way destinations are written
+func validateShippingZone(zone *domain.ShippingZone) error {
+	zone.Name = strings.TrimSpace(zone.Name)
+	if zone.Name == "" {
+		return errors.New("shipping zone name is required")
+	}
+	if len(zone.Countries) == 0 {
+		return errors.New("shipping zone needs at least one country")
+	}
+
+	for i, country := range zone.Countries {
+		zone.Countries[i] = domain.NormalizeDestination(domain.Destination{Country: country}).Country
+		if len(zone.Countries[i]) != 2 {
+			return errors.New("countries must be two-letter codes")
+		}
+	}
+	for i, region := range zone.Regions {
+		zone.Regions[i] = domain.NormalizeDestination(domain.Destination{Region: region}).Region
+	}
+	for i, prefix := range zone.PostalPrefixes {
+		zone.PostalPrefixes[i] = domain.NormalizeDestination(domain.Destination{PostalCode: prefix}).PostalCode
+	}
+	return nil
+}
+
+// validateShippingMethod checks the settings of a shipping method against
+// how it prices parcels
+func (s *ShippingServiceImpl) validateShippingMethod(ctx context.Context, method *domain.ShippingMethod) error {
+	method.Name = strings.TrimSpace(method.Name)
+	if method.Name == "" {
+		return errors.New("shipping method name is required")
+	}
+	if method.ZoneID != nil {
+		if _, err := s.zoneRepo.FindByID(ctx, *method.ZoneID); err != nil {
+			return errors.New("shipping zone not found")
+		}
+	}
+	if method.Rate.Amount < 0 || method.PerKg.Amount < 0 || method.FreeOver.Amount < 0 {
+		return errors.New("shipping amounts cannot be negative")
+	}
+
+	switch method.Type {
+	case domain.ShippingMethodFlatRate:
+	case domain.ShippingMethodWeightBased:
+		if !method.PerKg.IsPositive() {
+			return errors.New("weight-based methods need a rate per kilogram")
+		}
+	case domain.ShippingMethodFreeOver:
+		if !method.FreeOver.IsPositive() {
+			return errors.New("free shipping threshold must be greater than zero")
+		}
+	case domain.ShippingMethodPickup:
+		method.PickupAddress = strings.TrimSpace(method.PickupAddress)
+		if method.PickupAddress == "" {
+			return errors.New("pickup address is required")
+		}
+	case domain.ShippingMethodCarrier:
+		if _, ok := s.providers[method.Carrier]; !ok {
+			return errors.New("unknown carrier")
+		}
+	default:
+		return errors.New("invalid shipping method type")
+	}
+
+	if method.MinDays < 0 || (method.MaxDays > 0 && method.MaxDays < method.MinDays) {
+		return errors.New("delivery estimate must not end before it starts")
+	}
+	return nil
+}
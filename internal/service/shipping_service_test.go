@@ -0,0 +1,421 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockShippingZoneRepository struct {
+	mock.Mock
+}
+
+func (m *MockShippingZoneRepository) FindByID(ctx context.Context, id uint) (*domain.ShippingZone, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ShippingZone), args.Error(1)
+}
+
+func (m *MockShippingZoneRepository) FindAll(ctx context.Context) ([]domain.ShippingZone, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.ShippingZone), args.Error(1)
+}
+
+func (m *MockShippingZoneRepository) Create(ctx context.Context, zone *domain.ShippingZone) error {
+	args := m.Called(ctx, zone)
+	return args.Error(0)
+}
+
+func (m *MockShippingZoneRepository) Update(ctx context.Context, zone *domain.ShippingZone) error {
+	args := m.Called(ctx, zone)
+	return args.Error(0)
+}
+
+func (m *MockShippingZoneRepository) Delete(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+type MockShippingMethodRepository struct {
+	mock.Mock
+}
+
+func (m *MockShippingMethodRepository) FindByID(ctx context.Context, id uint) (*domain.ShippingMethod, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ShippingMethod), args.Error(1)
+}
+
+func (m *MockShippingMethodRepository) FindAll(ctx context.Context) ([]domain.ShippingMethod, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.ShippingMethod), args.Error(1)
+}
+
+func (m *MockShippingMethodRepository) FindActive(ctx context.Context) ([]domain.ShippingMethod, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.ShippingMethod), args.Error(1)
+}
+
+func (m *MockShippingMethodRepository) Create(ctx context.Context, method *domain.ShippingMethod) error {
+	args := m.Called(ctx, method)
+	return args.Error(0)
+}
+
+func (m *MockShippingMethodRepository) Update(ctx context.Context, method *domain.ShippingMethod) error {
+	args := m.Called(ctx, method)
+	return args.Error(0)
+}
+
+func (m *MockShippingMethodRepository) Delete(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// shippingZones are the zones of the shipping tests: the United States, with
+// New York City as a zone of its own
+func shippingZones() []domain.ShippingZone {
+	return []domain.ShippingZone{
+		{ID: 1, Name: "United States", Countries: []string{"US"}},
+		{ID: 2, Name: "New York City", Countries: []string{"US"}, Regions: []string{"NY"}, PostalPrefixes: []string{"100", "112"}},
+		{ID: 3, Name: "Europe", Countries: []string{"DE", "FR"}},
+	}
+}
+
+// shippingMethods are the methods of the shipping tests: standard and
+// carrier shipping across the United States, a same-day courier in New York
+// City and pickup from the store anywhere
+func shippingMethods() []domain.ShippingMethod {
+	us, nyc := uint(1), uint(2)
+	return []domain.ShippingMethod{
+		{ID: 1, ZoneID: &us, Name: "Standard", Type: domain.ShippingMethodFreeOver, Rate: usd(599), FreeOver: usd(5000), Active: true},
+		{ID: 2, ZoneID: &us, Name: "Ground", Type: domain.ShippingMethodCarrier, Carrier: service.LocalCarrier, Rate: usd(400), PerKg: usd(100), Active: true},
+		{ID: 3, ZoneID: &nyc, Name: "Same day", Type: domain.ShippingMethodFlatRate, Rate: usd(1500), Active: true},
+		{ID: 4, Name: "Store pickup", Type: domain.ShippingMethodPickup, PickupAddress: "1 Store St", Active: true},
+	}
+}
+
+func newShippingService(zones []domain.ShippingZone, methods []domain.ShippingMethod) (service.ShippingService, *MockShippingZoneRepository, *MockShippingMethodRepository) {
+	mockZoneRepo := new(MockShippingZoneRepository)
+	mockMethodRepo := new(MockShippingMethodRepository)
+	mockZoneRepo.On("FindAll", mock.Anything).Return(zones, nil)
+	mockMethodRepo.On("FindActive", mock.Anything).Return(methods, nil)
+	return service.NewShippingService(mockZoneRepo, mockMethodRepo, service.NewLocalRateProvider()), mockZoneRepo, mockMethodRepo
+}
+
+func TestFindShippingZone(t *testing.T) {
+	zones := shippingZones()
+
+	tests := []struct {
+		name        string
+		destination domain.Destination
+		wantID      uint
+	}{
+		{name: "Postal codes before country", destination: domain.Destination{Country: "US", Region: "NY", PostalCode: "11201"}, wantID: 2},
+		{name: "Country", destination: domain.Destination{Country: "US", Region: "NY", PostalCode: "14201"}, wantID: 1},
+		{name: "One of several countries", destination: domain.Destination{Country: "FR"}, wantID: 3},
+		{name: "Not shipped to", destination: domain.Destination{Country: "JP"}},
+		{name: "No destination", destination: domain.Destination{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zone := domain.FindShippingZone(zones, tt.destination)
+			if tt.wantID == 0 {
+				assert.Nil(t, zone)
+				return
+			}
+			require.NotNil(t, zone)
+			assert.Equal(t, tt.wantID, zone.ID)
+		})
+	}
+}
+
+func TestShippingMethodCost(t *testing.T) {
+	// Two mugs of 400 g, each packed in a 20 x 15 x 10 cm box
+	mug := &domain.Product{ID: 1, Weight: 400, Length: 20, Width: 15, Height: 10}
+	parcel := domain.Parcel{Subtotal: usd(4000)}.Add(mug, 2).Add(&domain.Product{ID: 2, Type: domain.ProductTypeDigital, Weight: 1000}, 1)
+	euros := domain.Conversion{Currency: "EUR", Rate: 0.5}
+
+	assert.Equal(t, 2, parcel.Units)
+	assert.Equal(t, 800, parcel.Weight)
+	assert.Equal(t, 1200, parcel.BillableWeight())
+
+	tests := []struct {
+		name       string
+		method     domain.ShippingMethod
+		parcel     domain.Parcel
+		conversion domain.Conversion
+		want       domain.Money
+	}{
+		{name: "Flat rate", method: domain.ShippingMethod{Type: domain.ShippingMethodFlatRate, Rate: usd(599)}, parcel: parcel, want: usd(599)},
+		{name: "Weight based by the started kilogram", method: domain.ShippingMethod{Type: domain.ShippingMethodWeightBased, Rate: usd(300), PerKg: usd(150)}, parcel: parcel.Add(mug, 1), want: usd(600)},
+		{name: "Below the free shipping threshold", method: domain.ShippingMethod{Type: domain.ShippingMethodFreeOver, Rate: usd(599), FreeOver: usd(5000)}, parcel: parcel, want: usd(599)},
+		{name: "Free shipping threshold in the customer's currency", method: domain.ShippingMethod{Type: domain.ShippingMethodFreeOver, Rate: usd(599), FreeOver: usd(5000)}, parcel: domain.Parcel{Subtotal: domain.Money{Amount: 2500, Currency: "EUR"}}, conversion: euros, want: domain.Money{Currency: "EUR"}},
+		{name: "Pickup", method: domain.ShippingMethod{Type: domain.ShippingMethodPickup, Rate: usd(599)}, parcel: parcel, want: usd(0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cost, err := tt.method.Cost(tt.parcel, tt.conversion)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, cost)
+		})
+	}
+}
+
+func TestShippingOptions(t *testing.T) {
+	ctx := context.Background()
+	parcel := domain.Parcel{Units: 2, Weight: 1500, Subtotal: usd(4000)}
+
+	t.Run("Methods of the most narrowly located zone and of no zone", func(t *testing.T) {
+		// Setup
+		shippingService, _, _ := newShippingService(shippingZones(), shippingMethods())
+
+		// Execute
+		options, err := shippingService.Options(ctx, domain.Destination{Country: "us", Region: "ny", PostalCode: "10001"}, parcel, domain.Conversion{})
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, options, 2)
+		assert.Equal(t, "Same day", options[0].Name)
+		assert.Equal(t, usd(1500), options[0].Cost)
+		assert.Equal(t, "Store pickup", options[1].Name)
+		assert.Equal(t, "1 Store St", options[1].PickupAddress)
+	})
+
+	t.Run("Carrier rates", func(t *testing.T) {
+		// Setup
+		shippingService, _, _ := newShippingService(shippingZones(), shippingMethods())
+
+		// Execute
+		options, err := shippingService.Options(ctx, domain.Destination{Country: "US", Region: "CA"}, parcel, domain.Conversion{})
+
+		// Assert: 1.5 kg is charged as two kilograms
+		require.NoError(t, err)
+		require.Len(t, options, 3)
+		assert.Equal(t, usd(599), options[0].Cost)
+		assert.Equal(t, service.LocalCarrier, options[1].Carrier)
+		assert.Equal(t, usd(600), options[1].Cost)
+	})
+
+	t.Run("Carriers that cannot quote are left out", func(t *testing.T) {
+		// Setup
+		shippingService, _, _ := newShippingService(shippingZones(), shippingMethods())
+		heavy := domain.Parcel{Units: 1, Weight: 31000, Subtotal: usd(6000)}
+
+		// Execute
+		options, err := shippingService.Options(ctx, domain.Destination{Country: "US", Region: "CA"}, heavy, domain.Conversion{})
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, options, 2)
+		assert.Equal(t, "Standard", options[0].Name)
+		assert.Equal(t, int64(0), options[0].Cost.Amount)
+		assert.Equal(t, "Store pickup", options[1].Name)
+	})
+
+	t.Run("Nothing to ship", func(t *testing.T) {
+		// Setup
+		shippingService, mockZoneRepo, _ := newShippingService(shippingZones(), shippingMethods())
+
+		// Execute
+		options, err := shippingService.Options(ctx, domain.Destination{Country: "US"}, domain.Parcel{}, domain.Conversion{})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Empty(t, options)
+		mockZoneRepo.AssertNotCalled(t, "FindAll", mock.Anything)
+	})
+
+	t.Run("Method not offered at the destination", func(t *testing.T) {
+		// Setup
+		shippingService, _, _ := newShippingService(shippingZones(), shippingMethods())
+
+		// Execute
+		option, err := shippingService.Quote(ctx, 3, domain.Destination{Country: "US", Region: "CA"}, parcel, domain.Conversion{})
+
+		// Assert
+		assert.Nil(t, option)
+		assert.EqualError(t, err, "shipping method is not available for this destination")
+	})
+}
+
+func TestCreateShippingMethod(t *testing.T) {
+	ctx := context.Background()
+	missingZone := uint(9)
+
+	tests := []struct {
+		name    string
+		method  domain.ShippingMethod
+		wantErr string
+	}{
+		{name: "Missing name", method: domain.ShippingMethod{Name: " ", Type: domain.ShippingMethodFlatRate}, wantErr: "shipping method name is required"},
+		{name: "Unknown zone", method: domain.ShippingMethod{Name: "Standard", Type: domain.ShippingMethodFlatRate, ZoneID: &missingZone}, wantErr: "shipping zone not found"},
+		{name: "Negative rate", method: domain.ShippingMethod{Name: "Standard", Type: domain.ShippingMethodFlatRate, Rate: usd(-100)}, wantErr: "shipping amounts cannot be negative"},
+		{name: "Weight based without a rate per kilogram", method: domain.ShippingMethod{Name: "Standard", Type: domain.ShippingMethodWeightBased, Rate: usd(300)}, wantErr: "weight-based methods need a rate per kilogram"},
+		{name: "Free over nothing", method: domain.ShippingMethod{Name: "Standard", Type: domain.ShippingMethodFreeOver, Rate: usd(599)}, wantErr: "free shipping threshold must be greater than zero"},
+		{name: "Pickup without an address", method: domain.ShippingMethod{Name: "Pickup", Type: domain.ShippingMethodPickup}, wantErr: "pickup address is required"},
+		{name: "Unknown carrier", method: domain.ShippingMethod{Name: "Air", Type: domain.ShippingMethodCarrier, Carrier: "pigeon"}, wantErr: "unknown carrier"},
+		{name: "Invalid type", method: domain.ShippingMethod{Name: "Standard", Type: "teleport"}, wantErr: "invalid shipping method type"},
+		{name: "Delivery estimate backwards", method: domain.ShippingMethod{Name: "Standard", Type: domain.ShippingMethodFlatRate, MinDays: 5, MaxDays: 2}, wantErr: "delivery estimate must not end before it starts"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			shippingService, mockZoneRepo, mockMethodRepo := newShippingService(nil, nil)
+			mockZoneRepo.On("FindByID", ctx, missingZone).Return(nil, assert.AnError)
+
+			// Execute
+			method := tt.method
+			err := shippingService.CreateMethod(ctx, &method)
+
+			// Assert
+			assert.EqualError(t, err, tt.wantErr)
+			mockMethodRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestCreateShippingZone(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Normalized destinations", func(t *testing.T) {
+		// Setup
+		shippingService, mockZoneRepo, _ := newShippingService(nil, nil)
+		mockZoneRepo.On("Create", ctx, mock.AnythingOfType("*domain.ShippingZone")).Return(nil)
+
+		// Execute
+		zone := &domain.ShippingZone{Name: " London ", Countries: []string{"gb"}, PostalPrefixes: []string{"sw1 a"}}
+		err := shippingService.CreateZone(ctx, zone)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "London", zone.Name)
+		assert.Equal(t, []string{"GB"}, zone.Countries)
+		assert.Equal(t, []string{"SW1A"}, zone.PostalPrefixes)
+	})
+
+	t.Run("Invalid country", func(t *testing.T) {
+		// Setup
+		shippingService, mockZoneRepo, _ := newShippingService(nil, nil)
+
+		// Execute
+		err := shippingService.CreateZone(ctx, &domain.ShippingZone{Name: "Europe", Countries: []string{"Europe"}})
+
+		// Assert
+		assert.EqualError(t, err, "countries must be two-letter codes")
+		mockZoneRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Zone with methods cannot be deleted", func(t *testing.T) {
+		// Setup
+		shippingService, mockZoneRepo, mockMethodRepo := newShippingService(nil, nil)
+		mockZoneRepo.On("FindByID", ctx, uint(1)).Return(&shippingZones()[0], nil)
+		mockMethodRepo.On("FindAll", ctx).Return(shippingMethods(), nil)
+
+		// Execute
+		err := shippingService.DeleteZone(ctx, 1)
+
+		// Assert
+		assert.EqualError(t, err, "shipping zone has shipping methods")
+		mockZoneRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+}
+
+func TestGetCartShippingOptions(t *testing.T) {
+	// Setup
+	mockCartRepo := new(MockCartRepository)
+	shippingService, _, _ := newShippingService(shippingZones(), shippingMethods())
+	cartService := service.NewCartService(mockCartRepo, new(MockProductRepository), new(MockUserRepository), service.WithCartShipping(shippingService))
+
+	ctx := domain.WithDestination(context.Background(), domain.Destination{Country: "US", Region: "CA"})
+	items := []domain.CartItem{
+		{ID: 1, CartID: 1, ProductID: 1, Quantity: 2, Product: domain.Product{ID: 1, Price: usd(3000), Weight: 2500}},
+		{ID: 2, CartID: 1, ProductID: 2, Quantity: 1, Product: domain.Product{ID: 2, Price: usd(1000), Type: domain.ProductTypeDigital}},
+	}
+	mockCartRepo.On("GetCartItems", ctx, uint(1)).Return(items, nil)
+
+	// Execute
+	options, err := cartService.GetShippingOptions(ctx, 1)
+
+	// Assert: the cart is over the free shipping threshold and weighs 5 kg
+	require.NoError(t, err)
+	require.Len(t, options, 3)
+	assert.Equal(t, int64(0), options[0].Cost.Amount)
+	assert.Equal(t, usd(900), options[1].Cost)
+}
+
+func TestCreateOrderWithShipping(t *testing.T) {
+	newYork := domain.Destination{Country: "US", Region: "NY", PostalCode: "10001"}
+
+	tests := []struct {
+		name            string
+		methodID        uint
+		shippingAddress string
+		wantErr         string
+		wantShipping    int64
+		wantMethod      string
+	}{
+		{name: "Chosen method", methodID: 3, shippingAddress: "1 Shipping St", wantShipping: 1500, wantMethod: "Same day"},
+		{name: "Pickup needs no address", methodID: 4, wantMethod: "Store pickup"},
+		{name: "No method chosen", shippingAddress: "1 Shipping St", wantErr: "shipping method is required"},
+		{name: "Method of another zone", methodID: 1, shippingAddress: "1 Shipping St", wantErr: "shipping method is not available for this destination"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockOrderRepo := new(MockOrderRepository)
+			mockCartRepo := new(MockCartRepository)
+			mockProductRepo := new(MockProductRepository)
+			mockUserRepo := new(MockUserRepository)
+			shippingService, _, _ := newShippingService(shippingZones(), shippingMethods())
+			orderService := service.NewOrderService(mockOrderRepo, mockCartRepo, mockProductRepo, mockUserRepo, nil, service.WithShipping(shippingService))
+
+			ctx := domain.WithDestination(context.Background(), newYork)
+			cart := &domain.Cart{ID: 1, UserID: 1, Items: []domain.CartItem{{ProductID: 1, Quantity: 2}}}
+			mockUserRepo.On("FindByID", ctx, uint(1)).Return(&domain.User{ID: 1}, nil)
+			mockCartRepo.On("FindByUserID", ctx, uint(1)).Return(cart, nil)
+			mockProductRepo.On("FindByID", ctx, uint(1)).Return(&domain.Product{ID: 1, Name: "Mug", Price: usd(1000), Stock: 10, Weight: 400}, nil)
+			mockProductRepo.On("DecrementStock", ctx, mock.Anything, mock.Anything).Return(nil)
+			mockOrderRepo.On("Create", ctx, mock.AnythingOfType("*domain.Order")).Return(nil)
+			mockCartRepo.On("ClearCart", ctx, cart.ID).Return(nil)
+
+			// Execute
+			order, err := orderService.CreateOrder(ctx, 1, tt.shippingAddress, "1 Billing St", tt.methodID)
+
+			// Assert
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				mockOrderRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantShipping, order.ShippingAmount.Amount)
+			assert.Equal(t, usd(2000+tt.wantShipping), order.TotalAmount)
+			assert.Equal(t, tt.wantMethod, order.ShippingMethod)
+			require.NotNil(t, order.ShippingMethodID)
+			assert.Equal(t, tt.methodID, *order.ShippingMethodID)
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/notification"
+	"awesomeEcommerce/internal/repository"
+)
+
+// stockAlertPageSize is the number of products checked per page when checking every product
+const stockAlertPageSize = 100
+
+// StockAlertService defines the interface for low-stock alerting business logic
+type StockAlertService interface {
+	// GetReorderSettings resolves the reorder point and quantity of a product
+	GetReorderSettings(ctx context.Context, product *domain.Product) domain.ReorderSettings
+
+	// CheckProduct compares the stock of a product with its reorder point,
+	// sending an alert when it falls to it
+	CheckProduct(ctx context.Context, productID uint) error
+
+	// CheckAllProducts checks the stock of every product
+	CheckAllProducts(ctx context.Context) error
+
+	// GetLowStockReport retrieves the products alerting for low stock
+	GetLowStockReport(ctx context.Context) ([]domain.LowStockReportLine, error)
+}
+
+// StockAlertServiceImpl implements the StockAlertService interface
+type StockAlertServiceImpl struct {
+	alertRepo   repository.StockAlertRepository
+	productRepo repository.ProductRepository
+	channel     notification.Channel
+	defaults    domain.ReorderSettings
+}
+
+// NewStockAlertService creates a new StockAlertServiceImpl. Products whose
+// category tree sets no reorder settings use the defaults.
+func NewStockAlertService(
+	alertRepo repository.StockAlertRepository,
+	productRepo repository.ProductRepository,
+	channel notification.Channel,
+	defaults domain.ReorderSettings,
+) StockAlertService {
+	defaults.Source = "default"
+	return &StockAlertServiceImpl{
+		alertRepo:   alertRepo,
+		productRepo: productRepo,
+		channel:     channel,
+		defaults:    defaults,
+	}
+}
+
+// GetReorderSettings resolves the reorder point and quantity of a product.
+// Each falls back from the product to its category, then up the parent
+// categories, then to the defaults.
+func (s *StockAlertServiceImpl) GetReorderSettings(ctx context.Context, product *domain.Product) domain.ReorderSettings {
+	settings := s.defaults
+	reorderPoint, reorderQuantity := product.ReorderPoint, product.ReorderQuantity
+	if reorderPoint != nil {
+		settings.Source = "product"
+	}
+
+	visited := make(map[uint]bool)
+	categoryID := product.CategoryID
+	for categoryID != 0 && !visited[categoryID] && (reorderPoint == nil || reorderQuantity == nil) {
+		visited[categoryID] = true
+
+		// Products may point at a category that no longer exists
+		category, err := s.productRepo.FindCategoryByID(ctx, categoryID)
+		if err != nil {
+			break
+		}
+		if reorderPoint == nil && category.ReorderPoint != nil {
+			reorderPoint = category.ReorderPoint
+			settings.Source = "category"
+		}
+		if reorderQuantity == nil {
+			reorderQuantity = category.ReorderQuantity
+		}
+
+		categoryID = 0
+		if category.ParentID != nil {
+			categoryID = *category.ParentID
+		}
+	}
+
+	if reorderPoint != nil {
+		settings.ReorderPoint = *reorderPoint
+	}
+	if reorderQuantity != nil {
+		settings.ReorderQuantity = *reorderQuantity
+	}
+	return settings
+}
+
+// CheckProduct compares the stock of a product with its reorder point,
+// sending an alert when it falls to it
+func (s *StockAlertServiceImpl) CheckProduct(ctx context.Context, productID uint) error {
+	product, err := s.productRepo.FindByID(ctx, productID)
+	if err != nil {
+		return errors.New("product not found")
+	}
+	return s.checkProduct(ctx, product)
+}
+
+// CheckAllProducts checks the stock of every product. A failing product does
+// not stop the others.
+func (s *StockAlertServiceImpl) CheckAllProducts(ctx context.Context) error {
+	var errs []error
+	for page := 1; ; page++ {
+		products, total, err := s.productRepo.FindAll(ctx, page, stockAlertPageSize)
+		if err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+
+		for i := range products {
+			if err := s.checkProduct(ctx, &products[i]); err != nil {
+				errs = append(errs, fmt.Errorf("product %d: %w", products[i].ID, err))
+			}
+		}
+
+		if len(products) == 0 || int64(page*stockAlertPageSize) >= total {
+			break
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkProduct moves the alert state of a product to match its stock. The
+// state is only saved once the alert is sent, so a failed alert is retried.
+func (s *StockAlertServiceImpl) checkProduct(ctx context.Context, product *domain.Product) error {
+	settings := s.GetReorderSettings(ctx, product)
+
+	alert, err := s.alertRepo.FindByProductID(ctx, product.ID)
+	if err != nil {
+		return err
+	}
+	previous := *alert
+
+	if alert.Transition(product.Stock, settings, time.Now()) {
+		if err := s.channel.Send(ctx, lowStockNotification(product, settings)); err != nil {
+			return err
+		}
+	}
+
+	// Nothing worth recording changed since the last check
+	if alert.ID != 0 && alert.State == previous.State &&
+		alert.ReorderPoint == previous.ReorderPoint && alert.ReorderQuantity == previous.ReorderQuantity {
+		return nil
+	}
+	return s.alertRepo.Save(ctx, alert)
+}
+
+// lowStockNotification builds the alert sent when a product falls to its reorder point
+func lowStockNotification(product *domain.Product, settings domain.ReorderSettings) notification.Notification {
+	return notification.Notification{
+		Type: "low-stock-alert",
+		Key:  strconv.FormatUint(uint64(product.ID), 10),
+		Subject: fmt.Sprintf("Low stock for product %d (%s): %d units remaining, reorder %d units",
+			product.ID, product.Name, product.Stock, settings.ReorderQuantity),
+		Data: map[string]interface{}{
+			"product_id":       product.ID,
+			"product_name":     product.Name,
+			"product_sku":      product.SKU,
+			"current_stock":    product.Stock,
+			"reorder_point":    settings.ReorderPoint,
+			"reorder_quantity": settings.ReorderQuantity,
+		},
+	}
+}
+
+// GetLowStockReport retrieves the products alerting for low stock
+func (s *StockAlertServiceImpl) GetLowStockReport(ctx context.Context) ([]domain.LowStockReportLine, error) {
+	return s.alertRepo.FindLowStock(ctx)
+}
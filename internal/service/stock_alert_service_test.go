@@ -0,0 +1,265 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/notification"
+	"awesomeEcommerce/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockStockAlertRepository struct {
+	mock.Mock
+}
+
+func (m *MockStockAlertRepository) FindByProductID(ctx context.Context, productID uint) (*domain.StockAlert, error) {
+	args := m.Called(ctx, productID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.StockAlert), args.Error(1)
+}
+
+func (m *MockStockAlertRepository) Save(ctx context.Context, alert *domain.StockAlert) error {
+	args := m.Called(ctx, alert)
+	return args.Error(0)
+}
+
+func (m *MockStockAlertRepository) FindLowStock(ctx context.Context) ([]domain.LowStockReportLine, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.LowStockReportLine), args.Error(1)
+}
+
+type MockChannel struct {
+	mock.Mock
+}
+
+func (m *MockChannel) Send(ctx context.Context, n notification.Notification) error {
+	args := m.Called(ctx, n)
+	return args.Error(0)
+}
+
+var defaultReorderSettings = domain.ReorderSettings{ReorderPoint: 10, ReorderQuantity: 50}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func uintPtr(v uint) *uint {
+	return &v
+}
+
+func TestGetReorderSettings(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		product domain.Product
+		want    domain.ReorderSettings
+	}{
+		{
+			name:    "Product settings",
+			product: domain.Product{ID: 1, CategoryID: 2, ReorderPoint: intPtr(3), ReorderQuantity: intPtr(12)},
+			want:    domain.ReorderSettings{ReorderPoint: 3, ReorderQuantity: 12, Source: "product"},
+		},
+		{
+			name:    "Category settings",
+			product: domain.Product{ID: 1, CategoryID: 2},
+			want:    domain.ReorderSettings{ReorderPoint: 5, ReorderQuantity: 50, Source: "category"},
+		},
+		{
+			name:    "Parent category settings",
+			product: domain.Product{ID: 1, CategoryID: 3, ReorderQuantity: intPtr(8)},
+			want:    domain.ReorderSettings{ReorderPoint: 20, ReorderQuantity: 8, Source: "category"},
+		},
+		{
+			name:    "Defaults",
+			product: domain.Product{ID: 1},
+			want:    domain.ReorderSettings{ReorderPoint: 10, ReorderQuantity: 50, Source: "default"},
+		},
+		{
+			name:    "Missing category",
+			product: domain.Product{ID: 1, CategoryID: 9},
+			want:    domain.ReorderSettings{ReorderPoint: 10, ReorderQuantity: 50, Source: "default"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup: category 3 is a subcategory of category 4
+			mockProductRepo := new(MockProductRepository)
+			stockAlertService := service.NewStockAlertService(new(MockStockAlertRepository), mockProductRepo, new(MockChannel), defaultReorderSettings)
+
+			mockProductRepo.On("FindCategoryByID", ctx, uint(2)).Return(&domain.ProductCategory{ID: 2, ReorderPoint: intPtr(5)}, nil)
+			mockProductRepo.On("FindCategoryByID", ctx, uint(3)).Return(&domain.ProductCategory{ID: 3, ParentID: uintPtr(4)}, nil)
+			mockProductRepo.On("FindCategoryByID", ctx, uint(4)).Return(&domain.ProductCategory{ID: 4, ReorderPoint: intPtr(20), ReorderQuantity: intPtr(100)}, nil)
+			mockProductRepo.On("FindCategoryByID", ctx, uint(9)).Return(nil, errors.New("record not found"))
+
+			// Execute
+			settings := stockAlertService.GetReorderSettings(ctx, &tt.product)
+
+			// Assert
+			assert.Equal(t, tt.want, settings)
+		})
+	}
+}
+
+func TestCheckProduct(t *testing.T) {
+	ctx := context.Background()
+	lowProduct := &domain.Product{ID: 1, Name: "Test Product", SKU: "TEST-001", Stock: 4}
+
+	t.Run("Alerts when stock falls to the reorder point", func(t *testing.T) {
+		// Setup
+		mockAlertRepo := new(MockStockAlertRepository)
+		mockProductRepo := new(MockProductRepository)
+		mockChannel := new(MockChannel)
+		stockAlertService := service.NewStockAlertService(mockAlertRepo, mockProductRepo, mockChannel, defaultReorderSettings)
+
+		mockProductRepo.On("FindByID", ctx, uint(1)).Return(lowProduct, nil)
+		mockAlertRepo.On("FindByProductID", ctx, uint(1)).Return(&domain.StockAlert{ID: 7, ProductID: 1, State: domain.StockAlertStateOK}, nil)
+		mockChannel.On("Send", ctx, mock.MatchedBy(func(n notification.Notification) bool {
+			return n.Type == "low-stock-alert" && n.Key == "1" && n.Data["current_stock"] == 4 && n.Data["reorder_quantity"] == 50
+		})).Return(nil)
+		mockAlertRepo.On("Save", ctx, mock.MatchedBy(func(alert *domain.StockAlert) bool {
+			return alert.State == domain.StockAlertStateLow && alert.AlertedAt != nil && alert.Stock == 4
+		})).Return(nil)
+
+		// Execute
+		err := stockAlertService.CheckProduct(ctx, 1)
+
+		// Assert
+		assert.NoError(t, err)
+		mockChannel.AssertExpectations(t)
+		mockAlertRepo.AssertExpectations(t)
+	})
+
+	t.Run("Does not alert again while stock stays low", func(t *testing.T) {
+		// Setup
+		mockAlertRepo := new(MockStockAlertRepository)
+		mockProductRepo := new(MockProductRepository)
+		mockChannel := new(MockChannel)
+		stockAlertService := service.NewStockAlertService(mockAlertRepo, mockProductRepo, mockChannel, defaultReorderSettings)
+
+		mockProductRepo.On("FindByID", ctx, uint(1)).Return(&domain.Product{ID: 1, Stock: 0}, nil)
+		mockAlertRepo.On("FindByProductID", ctx, uint(1)).Return(&domain.StockAlert{
+			ID: 7, ProductID: 1, State: domain.StockAlertStateLow, Stock: 4, ReorderPoint: 10, ReorderQuantity: 50,
+		}, nil)
+
+		// Execute
+		err := stockAlertService.CheckProduct(ctx, 1)
+
+		// Assert
+		assert.NoError(t, err)
+		mockChannel.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+		mockAlertRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Recovers once stock is above the reorder point", func(t *testing.T) {
+		// Setup
+		mockAlertRepo := new(MockStockAlertRepository)
+		mockProductRepo := new(MockProductRepository)
+		mockChannel := new(MockChannel)
+		stockAlertService := service.NewStockAlertService(mockAlertRepo, mockProductRepo, mockChannel, defaultReorderSettings)
+
+		mockProductRepo.On("FindByID", ctx, uint(1)).Return(&domain.Product{ID: 1, Stock: 60}, nil)
+		mockAlertRepo.On("FindByProductID", ctx, uint(1)).Return(&domain.StockAlert{
+			ID: 7, ProductID: 1, State: domain.StockAlertStateLow, Stock: 4, ReorderPoint: 10, ReorderQuantity: 50,
+		}, nil)
+		mockAlertRepo.On("Save", ctx, mock.MatchedBy(func(alert *domain.StockAlert) bool {
+			return alert.State == domain.StockAlertStateOK && alert.RecoveredAt != nil
+		})).Return(nil)
+
+		// Execute
+		err := stockAlertService.CheckProduct(ctx, 1)
+
+		// Assert
+		assert.NoError(t, err)
+		mockChannel.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+		mockAlertRepo.AssertExpectations(t)
+	})
+
+	t.Run("Failed alert is retried on the next check", func(t *testing.T) {
+		// Setup
+		mockAlertRepo := new(MockStockAlertRepository)
+		mockProductRepo := new(MockProductRepository)
+		mockChannel := new(MockChannel)
+		stockAlertService := service.NewStockAlertService(mockAlertRepo, mockProductRepo, mockChannel, defaultReorderSettings)
+
+		mockProductRepo.On("FindByID", ctx, uint(1)).Return(lowProduct, nil)
+		mockAlertRepo.On("FindByProductID", ctx, uint(1)).Return(&domain.StockAlert{ProductID: 1, State: domain.StockAlertStateOK}, nil)
+		mockChannel.On("Send", ctx, mock.Anything).Return(errors.New("broker unavailable"))
+
+		// Execute
+		err := stockAlertService.CheckProduct(ctx, 1)
+
+		// Assert: the alert state stays ok so the next check alerts again
+		assert.Error(t, err)
+		mockAlertRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	})
+}
+
+func TestCheckAllProducts(t *testing.T) {
+	// Setup
+	mockAlertRepo := new(MockStockAlertRepository)
+	mockProductRepo := new(MockProductRepository)
+	mockChannel := new(MockChannel)
+	stockAlertService := service.NewStockAlertService(mockAlertRepo, mockProductRepo, mockChannel, defaultReorderSettings)
+	ctx := context.Background()
+
+	products := []domain.Product{
+		{ID: 1, Name: "Low Product", Stock: 2},
+		{ID: 2, Name: "Stocked Product", Stock: 80},
+	}
+	mockProductRepo.On("FindAll", ctx, 1, 100).Return(products, int64(2), nil)
+	mockAlertRepo.On("FindByProductID", ctx, uint(1)).Return(&domain.StockAlert{ProductID: 1, State: domain.StockAlertStateOK}, nil)
+	mockAlertRepo.On("FindByProductID", ctx, uint(2)).Return(nil, errors.New("database error"))
+	mockChannel.On("Send", ctx, mock.Anything).Return(nil).Once()
+	mockAlertRepo.On("Save", ctx, mock.AnythingOfType("*domain.StockAlert")).Return(nil)
+
+	// Execute
+	err := stockAlertService.CheckAllProducts(ctx)
+
+	// Assert: a failing product does not stop the others
+	assert.Error(t, err)
+	mockChannel.AssertExpectations(t)
+	mockAlertRepo.AssertExpectations(t)
+}
+
+func TestGetLowStockReport(t *testing.T) {
+	// Setup
+	mockAlertRepo := new(MockStockAlertRepository)
+	stockAlertService := service.NewStockAlertService(mockAlertRepo, new(MockProductRepository), new(MockChannel), defaultReorderSettings)
+	ctx := context.Background()
+
+	lines := []domain.LowStockReportLine{{ProductID: 1, ProductName: "Test Product", Stock: 2, ReorderPoint: 10, ReorderQuantity: 50}}
+	mockAlertRepo.On("FindLowStock", ctx).Return(lines, nil)
+
+	// Execute
+	result, err := stockAlertService.GetLowStockReport(ctx)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, lines, result)
+}
+
+func TestCreateCategoryReorderSettings(t *testing.T) {
+	// Setup
+	mockRepo := new(MockProductRepository)
+	productService := service.NewProductService(mockRepo)
+	ctx := context.Background()
+
+	// Execute
+	err := productService.CreateCategory(ctx, &domain.ProductCategory{Name: "Test Category", ReorderQuantity: intPtr(0)})
+
+	// Assert
+	assert.EqualError(t, err, "reorder quantity must be greater than zero")
+	mockRepo.AssertNotCalled(t, "CreateCategory", mock.Anything, mock.Anything)
+}
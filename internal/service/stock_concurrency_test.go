@@ -0,0 +1,382 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// stockRepository is a ProductRepository keeping stock in memory. Like the
+// database, DecrementStock checks and takes every line as one atomic step.
+type stockRepository struct {
+	*MockProductRepository
+	mu    sync.Mutex
+	stock map[uint]int
+}
+
+func newStockRepository(stock map[uint]int) *stockRepository {
+	return &stockRepository{
+		MockProductRepository: new(MockProductRepository),
+		stock:                 stock,
+	}
+}
+
+func (r *stockRepository) FindByID(ctx context.Context, id uint) (*domain.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &domain.Product{ID: id, Name: fmt.Sprintf("Product %d", id), Price: usd(1000), Stock: r.stock[id]}, nil
+}
+
+func (r *stockRepository) DecrementStock(ctx context.Context, lines []domain.StockLine, change domain.StockChange) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var shortages []domain.StockShortage
+	for _, line := range lines {
+		if r.stock[line.ProductID] < line.Quantity {
+			shortages = append(shortages, domain.StockShortage{
+				ProductID:   line.ProductID,
+				ProductName: fmt.Sprintf("Product %d", line.ProductID),
+				Requested:   line.Quantity,
+				Available:   r.stock[line.ProductID],
+			})
+		}
+	}
+	if len(shortages) > 0 {
+		return &domain.InsufficientStockError{Shortages: shortages}
+	}
+
+	for _, line := range lines {
+		r.stock[line.ProductID] -= line.Quantity
+	}
+	return nil
+}
+
+// reservationStore is a ReservationRepository keeping reservations in memory
+// next to the stock of a stockRepository. Like the database, Reserve and
+// Convert lock the stock they check and take.
+type reservationStore struct {
+	*MockReservationRepository
+	products     *stockRepository
+	reservations []domain.StockReservation
+}
+
+func newReservationStore(products *stockRepository) *reservationStore {
+	return &reservationStore{
+		MockReservationRepository: new(MockReservationRepository),
+		products:                  products,
+	}
+}
+
+func (r *reservationStore) Reserve(ctx context.Context, reservations []domain.StockReservation) error {
+	r.products.mu.Lock()
+	defer r.products.mu.Unlock()
+
+	var shortages []domain.StockShortage
+	for _, reservation := range reservations {
+		available := r.products.stock[reservation.ProductID] - r.reserved(reservation.ProductID)
+		if available < reservation.Quantity {
+			shortages = append(shortages, domain.StockShortage{
+				ProductID:   reservation.ProductID,
+				ProductName: fmt.Sprintf("Product %d", reservation.ProductID),
+				Requested:   reservation.Quantity,
+				Available:   available,
+			})
+		}
+	}
+	if len(shortages) > 0 {
+		return &domain.InsufficientStockError{Shortages: shortages}
+	}
+
+	r.reservations = append(r.reservations, reservations...)
+	return nil
+}
+
+func (r *reservationStore) FindByOrderID(ctx context.Context, orderID uint) ([]domain.StockReservation, error) {
+	r.products.mu.Lock()
+	defer r.products.mu.Unlock()
+
+	var reservations []domain.StockReservation
+	for _, reservation := range r.reservations {
+		if reservation.OrderID == orderID {
+			reservations = append(reservations, reservation)
+		}
+	}
+	return reservations, nil
+}
+
+func (r *reservationStore) GetReservedQuantity(ctx context.Context, productID uint) (int, error) {
+	r.products.mu.Lock()
+	defer r.products.mu.Unlock()
+	return r.reserved(productID), nil
+}
+
+func (r *reservationStore) Convert(ctx context.Context, orderID uint, change domain.StockChange) (int, error) {
+	r.products.mu.Lock()
+	defer r.products.mu.Unlock()
+
+	converted := 0
+	for i := range r.reservations {
+		reservation := &r.reservations[i]
+		if reservation.OrderID != orderID || reservation.Status != domain.ReservationStatusActive {
+			continue
+		}
+		if r.products.stock[reservation.ProductID] < reservation.Quantity {
+			return converted, fmt.Errorf("insufficient stock for product %d", reservation.ProductID)
+		}
+		r.products.stock[reservation.ProductID] -= reservation.Quantity
+		reservation.Status = domain.ReservationStatusConverted
+		converted++
+	}
+	return converted, nil
+}
+
+// reserved sums the active reservations of a product. The caller holds the
+// stock lock.
+func (r *reservationStore) reserved(productID uint) int {
+	reserved := 0
+	for _, reservation := range r.reservations {
+		if reservation.ProductID == productID && reservation.Status == domain.ReservationStatusActive {
+			reserved += reservation.Quantity
+		}
+	}
+	return reserved
+}
+
+func TestCreateOrderListsEveryShortage(t *testing.T) {
+	// Setup
+	mockOrderRepo := new(MockOrderRepository)
+	mockCartRepo := new(MockCartRepository)
+	mockUserRepo := new(MockUserRepository)
+	productRepo := newStockRepository(map[uint]int{1: 1, 2: 5, 3: 0})
+	orderService := service.NewOrderService(mockOrderRepo, mockCartRepo, productRepo, mockUserRepo, nil)
+	ctx := context.Background()
+
+	cart := &domain.Cart{
+		ID:     1,
+		UserID: 1,
+		Items: []domain.CartItem{
+			{ProductID: 1, Quantity: 3},
+			{ProductID: 2, Quantity: 2},
+			{ProductID: 3, Quantity: 1},
+		},
+	}
+	mockUserRepo.On("FindByID", ctx, uint(1)).Return(&domain.User{ID: 1}, nil)
+	mockCartRepo.On("FindByUserID", ctx, uint(1)).Return(cart, nil)
+
+	// Execute
+	order, err := orderService.CreateOrder(ctx, 1, "123 Main St", "123 Main St", 0)
+
+	// Assert
+	assert.Nil(t, order)
+	var stockErr *domain.InsufficientStockError
+	require.True(t, errors.As(err, &stockErr))
+	assert.Equal(t, []domain.StockShortage{
+		{ProductID: 1, ProductName: "Product 1", Requested: 3, Available: 1},
+		{ProductID: 3, ProductName: "Product 3", Requested: 1, Available: 0},
+	}, stockErr.Shortages)
+	assert.Equal(t, "insufficient stock for product: Product 1, Product 3", err.Error())
+	mockOrderRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestCreateOrderStockTakenMeanwhile(t *testing.T) {
+	// Setup
+	mockOrderRepo := new(MockOrderRepository)
+	mockCartRepo := new(MockCartRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockProductRepo := new(MockProductRepository)
+	transactor := new(fakeTransactor)
+	orderService := service.NewOrderService(mockOrderRepo, mockCartRepo, mockProductRepo, mockUserRepo, nil, service.WithTransactor(transactor))
+	ctx := context.Background()
+
+	cart := &domain.Cart{ID: 1, UserID: 1, Items: []domain.CartItem{{ProductID: 1, Quantity: 2}}}
+	stockErr := &domain.InsufficientStockError{Shortages: []domain.StockShortage{
+		{ProductID: 1, ProductName: "Test Product", Requested: 2, Available: 1},
+	}}
+
+	// The stock check passes, but another checkout takes the stock before the decrement
+	mockUserRepo.On("FindByID", ctx, uint(1)).Return(&domain.User{ID: 1}, nil)
+	mockCartRepo.On("FindByUserID", ctx, uint(1)).Return(cart, nil)
+	mockProductRepo.On("FindByID", ctx, uint(1)).Return(&domain.Product{ID: 1, Name: "Test Product", Price: usd(1000), Stock: 2}, nil)
+	mockOrderRepo.On("Create", mock.MatchedBy(inTransaction), mock.AnythingOfType("*domain.Order")).Return(nil)
+	mockProductRepo.On("DecrementStock", mock.MatchedBy(inTransaction), []domain.StockLine{{ProductID: 1, Quantity: 2}}, mock.AnythingOfType("domain.StockChange")).Return(stockErr)
+
+	// Execute
+	order, err := orderService.CreateOrder(ctx, 1, "123 Main St", "123 Main St", 0)
+
+	// Assert: rolling back drops the order, so there is nothing to cancel
+	assert.Nil(t, order)
+	assert.Equal(t, stockErr, err)
+	assert.Equal(t, 1, transactor.rolledBack)
+	mockOrderRepo.AssertExpectations(t)
+	mockOrderRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockCartRepo.AssertNotCalled(t, "ClearCart", mock.Anything, mock.Anything)
+}
+
+func TestConcurrentCheckoutsDoNotOversell(t *testing.T) {
+	const stock = 10
+	const checkouts = 50
+
+	// Setup
+	mockOrderRepo := new(MockOrderRepository)
+	mockCartRepo := new(MockCartRepository)
+	mockUserRepo := new(MockUserRepository)
+	productRepo := newStockRepository(map[uint]int{1: stock, 2: stock * 3})
+	orderService := service.NewOrderService(mockOrderRepo, mockCartRepo, productRepo, mockUserRepo, nil)
+	ctx := context.Background()
+
+	// Every order wants one unit of product 1 and two of product 2
+	cart := &domain.Cart{
+		ID: 1,
+		Items: []domain.CartItem{
+			{ProductID: 1, Quantity: 1},
+			{ProductID: 2, Quantity: 2},
+		},
+	}
+	mockUserRepo.On("FindByID", ctx, mock.Anything).Return(&domain.User{}, nil)
+	mockCartRepo.On("FindByUserID", ctx, mock.Anything).Return(cart, nil)
+	mockCartRepo.On("ClearCart", ctx, cart.ID).Return(nil)
+	mockOrderRepo.On("Create", ctx, mock.AnythingOfType("*domain.Order")).Return(nil)
+	mockOrderRepo.On("UpdateStatus", ctx, mock.Anything, domain.OrderStatusPending, domain.OrderStatusCancelled, mock.Anything).Return(nil)
+
+	// Execute
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	placed, refused := 0, 0
+	start := make(chan struct{})
+	for i := 0; i < checkouts; i++ {
+		wg.Add(1)
+		go func(userID uint) {
+			defer wg.Done()
+			<-start
+
+			_, err := orderService.CreateOrder(ctx, userID, "123 Main St", "123 Main St", 0)
+
+			mu.Lock()
+			defer mu.Unlock()
+			var stockErr *domain.InsufficientStockError
+			switch {
+			case err == nil:
+				placed++
+			case errors.As(err, &stockErr):
+				refused++
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}(uint(i + 1))
+	}
+	close(start)
+	wg.Wait()
+
+	// Assert
+	assert.Equal(t, stock, placed)
+	assert.Equal(t, checkouts-stock, refused)
+	assert.Equal(t, 0, productRepo.stock[1])
+	assert.Equal(t, stock, productRepo.stock[2])
+}
+
+func TestConcurrentReservedCheckoutsDoNotOversell(t *testing.T) {
+	const stock = 10
+	const checkouts = 50
+
+	// Setup
+	mockOrderRepo := new(MockOrderRepository)
+	mockCartRepo := new(MockCartRepository)
+	mockUserRepo := new(MockUserRepository)
+	productRepo := newStockRepository(map[uint]int{1: stock, 2: stock * 3})
+	reservationRepo := newReservationStore(productRepo)
+	reservationService := service.NewReservationService(reservationRepo, mockOrderRepo, nil, productRepo, nil, 15*time.Minute)
+	orderService := service.NewOrderService(mockOrderRepo, mockCartRepo, productRepo, mockUserRepo, nil, service.WithReservations(reservationService))
+	ctx := context.Background()
+
+	// Every order wants one unit of product 1 and two of product 2
+	cart := &domain.Cart{
+		ID: 1,
+		Items: []domain.CartItem{
+			{ProductID: 1, Quantity: 1},
+			{ProductID: 2, Quantity: 2},
+		},
+	}
+	var lastOrderID atomic.Uint32
+	mockUserRepo.On("FindByID", ctx, mock.Anything).Return(&domain.User{}, nil)
+	mockCartRepo.On("FindByUserID", ctx, mock.Anything).Return(cart, nil)
+	mockCartRepo.On("ClearCart", ctx, cart.ID).Return(nil)
+	mockOrderRepo.On("Create", ctx, mock.AnythingOfType("*domain.Order")).
+		Run(func(args mock.Arguments) { args.Get(1).(*domain.Order).ID = uint(lastOrderID.Add(1)) }).
+		Return(nil)
+	mockOrderRepo.On("FindByID", ctx, mock.Anything).Return(&domain.Order{Status: domain.OrderStatusPending}, nil)
+
+	// Execute: check out concurrently
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var placed []uint
+	refused := 0
+	start := make(chan struct{})
+	for i := 0; i < checkouts; i++ {
+		wg.Add(1)
+		go func(userID uint) {
+			defer wg.Done()
+			<-start
+
+			order, err := orderService.CreateOrder(ctx, userID, "123 Main St", "123 Main St", 0)
+
+			mu.Lock()
+			defer mu.Unlock()
+			var stockErr *domain.InsufficientStockError
+			switch {
+			case err == nil:
+				placed = append(placed, order.ID)
+			case errors.As(err, &stockErr):
+				refused++
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}(uint(i + 1))
+	}
+	close(start)
+	wg.Wait()
+
+	// Assert: the reservations hold the stock without taking it yet
+	require.Len(t, placed, stock)
+	assert.Equal(t, checkouts-stock, refused)
+	reserved, err := reservationService.GetReservedStock(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, stock, reserved)
+	assert.Equal(t, stock, productRepo.stock[1])
+
+	// Execute: pay every order concurrently, each payment confirmed twice
+	start = make(chan struct{})
+	for _, orderID := range placed {
+		for attempt := 0; attempt < 2; attempt++ {
+			wg.Add(1)
+			go func(orderID uint) {
+				defer wg.Done()
+				<-start
+
+				if err := reservationService.ConvertOrder(ctx, orderID); err != nil {
+					t.Errorf("unexpected error converting order %d: %v", orderID, err)
+				}
+			}(orderID)
+		}
+	}
+	close(start)
+	wg.Wait()
+
+	// Assert: each reservation was taken from stock exactly once
+	reserved, err = reservationService.GetReservedStock(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 0, reserved)
+	assert.Equal(t, 0, productRepo.stock[1])
+	assert.Equal(t, stock, productRepo.stock[2])
+}
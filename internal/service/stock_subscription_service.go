@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/notification"
+	"awesomeEcommerce/internal/repository"
+)
+
+// StockSubscriptionService defines the interface for back-in-stock subscription business logic
+type StockSubscriptionService interface {
+	// Subscribe subscribes an email, and the user it belongs to if any, to an
+	// out-of-stock product. Subscribing twice returns the pending subscription.
+	Subscribe(ctx context.Context, productID uint, userID *uint, email string) (*domain.StockSubscription, error)
+
+	// Unsubscribe cancels the pending subscription with an unsubscribe token
+	Unsubscribe(ctx context.Context, token string) error
+
+	// GetUserSubscriptions retrieves the subscriptions of a user
+	GetUserSubscriptions(ctx context.Context, userID uint) ([]domain.StockSubscription, error)
+
+	// NotifySubscribers tells the subscribers of restocked products that they
+	// are back in stock, returning how many were notified
+	NotifySubscribers(ctx context.Context) (int, error)
+
+	// ExpireSubscriptions expires the subscriptions past their expiry, returning how many expired
+	ExpireSubscriptions(ctx context.Context) (int64, error)
+}
+
+// StockSubscriptionServiceImpl implements the StockSubscriptionService interface
+type StockSubscriptionServiceImpl struct {
+	subscriptionRepo repository.StockSubscriptionRepository
+	productRepo      repository.ProductRepository
+	reservations     ReservationService
+	channel          notification.Channel
+	ttl              time.Duration
+}
+
+// NewStockSubscriptionService creates a new StockSubscriptionServiceImpl.
+// Subscriptions still waiting after ttl expire.
+func NewStockSubscriptionService(
+	subscriptionRepo repository.StockSubscriptionRepository,
+	productRepo repository.ProductRepository,
+	reservations ReservationService,
+	channel notification.Channel,
+	ttl time.Duration,
+) StockSubscriptionService {
+	return &StockSubscriptionServiceImpl{
+		subscriptionRepo: subscriptionRepo,
+		productRepo:      productRepo,
+		reservations:     reservations,
+		channel:          channel,
+		ttl:              ttl,
+	}
+}
+
+// Subscribe subscribes an email, and the user it belongs to if any, to an
+// out-of-stock product. Subscribing twice returns the pending subscription.
+func (s *StockSubscriptionServiceImpl) Subscribe(ctx context.Context, productID uint, userID *uint, email string) (*domain.StockSubscription, error) {
+	if email == "" {
+		return nil, errors.New("email is required")
+	}
+
+	product, err := s.productRepo.FindByID(ctx, productID)
+	if err != nil {
+		return nil, errors.New("product not found")
+	}
+
+	available, err := s.availableStock(ctx, product)
+	if err != nil {
+		return nil, err
+	}
+	if available > 0 {
+		return nil, errors.New("product is in stock")
+	}
+
+	if existing, err := s.subscriptionRepo.FindPendingByEmail(ctx, productID, email); err == nil {
+		return existing, nil
+	}
+
+	token, err := newSubscriptionToken()
+	if err != nil {
+		return nil, err
+	}
+
+	subscription := &domain.StockSubscription{
+		ProductID: productID,
+		UserID:    userID,
+		Email:     email,
+		Token:     token,
+		Status:    domain.StockSubscriptionStatusPending,
+		ExpiresAt: time.Now().Add(s.ttl),
+	}
+	if err := s.subscriptionRepo.Create(ctx, subscription); err != nil {
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+// Unsubscribe cancels the pending subscription with an unsubscribe token
+func (s *StockSubscriptionServiceImpl) Unsubscribe(ctx context.Context, token string) error {
+	subscription, err := s.subscriptionRepo.FindByToken(ctx, token)
+	if err != nil {
+		return errors.New("subscription not found")
+	}
+
+	if subscription.Status != domain.StockSubscriptionStatusPending {
+		return errors.New("subscription is no longer pending")
+	}
+
+	return s.subscriptionRepo.UpdateStatus(ctx, subscription.ID, domain.StockSubscriptionStatusCancelled)
+}
+
+// GetUserSubscriptions retrieves the subscriptions of a user
+func (s *StockSubscriptionServiceImpl) GetUserSubscriptions(ctx context.Context, userID uint) ([]domain.StockSubscription, error) {
+	return s.subscriptionRepo.FindByUserID(ctx, userID)
+}
+
+// NotifySubscribers tells the subscribers of restocked products that they
+// are back in stock, returning how many were notified. A failing product does
+// not stop the others.
+func (s *StockSubscriptionServiceImpl) NotifySubscribers(ctx context.Context) (int, error) {
+	productIDs, err := s.subscriptionRepo.FindSubscribedProductIDs(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	notified := 0
+	var errs []error
+	for _, productID := range productIDs {
+		count, err := s.notifyProduct(ctx, productID)
+		notified += count
+		if err != nil {
+			errs = append(errs, fmt.Errorf("product %d: %w", productID, err))
+		}
+	}
+
+	return notified, errors.Join(errs...)
+}
+
+// notifyProduct notifies the subscribers of a product waiting since before
+// its last restock, oldest first. No more subscribers are notified per restock
+// than there are units available, counting those already notified.
+func (s *StockSubscriptionServiceImpl) notifyProduct(ctx context.Context, productID uint) (int, error) {
+	restockedAt, err := s.subscriptionRepo.FindLastRestock(ctx, productID)
+	if err != nil || restockedAt == nil {
+		return 0, err
+	}
+
+	product, err := s.productRepo.FindByID(ctx, productID)
+	if err != nil {
+		return 0, err
+	}
+
+	available, err := s.availableStock(ctx, product)
+	if err != nil {
+		return 0, err
+	}
+	alreadyNotified, err := s.subscriptionRepo.CountNotifiedSince(ctx, productID, *restockedAt)
+	if err != nil {
+		return 0, err
+	}
+	budget := available - int(alreadyNotified)
+	if budget <= 0 {
+		return 0, nil
+	}
+
+	subscriptions, err := s.subscriptionRepo.FindPendingBefore(ctx, productID, *restockedAt, budget)
+	if err != nil {
+		return 0, err
+	}
+
+	notified := 0
+	for _, subscription := range subscriptions {
+		// Stop at the first failure so later subscribers are not notified ahead of earlier ones
+		if err := s.channel.Send(ctx, backInStockNotification(product, subscription)); err != nil {
+			return notified, err
+		}
+		if err := s.subscriptionRepo.MarkNotified(ctx, subscription.ID, time.Now()); err != nil {
+			return notified, err
+		}
+		notified++
+	}
+
+	return notified, nil
+}
+
+// ExpireSubscriptions expires the subscriptions past their expiry, returning how many expired
+func (s *StockSubscriptionServiceImpl) ExpireSubscriptions(ctx context.Context) (int64, error) {
+	return s.subscriptionRepo.ExpireBefore(ctx, time.Now())
+}
+
+// availableStock returns the stock of a product not held by checkout reservations
+func (s *StockSubscriptionServiceImpl) availableStock(ctx context.Context, product *domain.Product) (int, error) {
+	if s.reservations == nil {
+		return product.Stock, nil
+	}
+	reserved, err := s.reservations.GetReservedStock(ctx, product.ID)
+	if err != nil {
+		return 0, err
+	}
+	return product.Stock - reserved, nil
+}
+
+// backInStockNotification builds the notification telling a subscriber a product is back in stock
+func backInStockNotification(product *domain.Product, subscription domain.StockSubscription) notification.Notification {
+	return notification.Notification{
+		Type:    "back-in-stock",
+		Key:     strconv.FormatUint(uint64(product.ID), 10),
+		Subject: fmt.Sprintf("%s is back in stock", product.Name),
+		Data: map[string]interface{}{
+			"subscription_id":   subscription.ID,
+			"email":             subscription.Email,
+			"user_id":           subscription.UserID,
+			"product_id":        product.ID,
+			"product_name":      product.Name,
+			"product_sku":       product.SKU,
+			"price":             product.Price,
+			"unsubscribe_token": subscription.Token,
+		},
+	}
+}
+
+// newSubscriptionToken generates the random token a subscriber unsubscribes with
+func newSubscriptionToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,321 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/notification"
+	"awesomeEcommerce/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockStockSubscriptionRepository struct {
+	mock.Mock
+}
+
+func (m *MockStockSubscriptionRepository) Create(ctx context.Context, subscription *domain.StockSubscription) error {
+	args := m.Called(ctx, subscription)
+	return args.Error(0)
+}
+
+func (m *MockStockSubscriptionRepository) FindPendingByEmail(ctx context.Context, productID uint, email string) (*domain.StockSubscription, error) {
+	args := m.Called(ctx, productID, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.StockSubscription), args.Error(1)
+}
+
+func (m *MockStockSubscriptionRepository) FindByToken(ctx context.Context, token string) (*domain.StockSubscription, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.StockSubscription), args.Error(1)
+}
+
+func (m *MockStockSubscriptionRepository) FindByUserID(ctx context.Context, userID uint) ([]domain.StockSubscription, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.StockSubscription), args.Error(1)
+}
+
+func (m *MockStockSubscriptionRepository) UpdateStatus(ctx context.Context, id uint, status domain.StockSubscriptionStatus) error {
+	args := m.Called(ctx, id, status)
+	return args.Error(0)
+}
+
+func (m *MockStockSubscriptionRepository) FindSubscribedProductIDs(ctx context.Context) ([]uint, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (m *MockStockSubscriptionRepository) FindLastRestock(ctx context.Context, productID uint) (*time.Time, error) {
+	args := m.Called(ctx, productID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*time.Time), args.Error(1)
+}
+
+func (m *MockStockSubscriptionRepository) CountNotifiedSince(ctx context.Context, productID uint, since time.Time) (int64, error) {
+	args := m.Called(ctx, productID, since)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockStockSubscriptionRepository) FindPendingBefore(ctx context.Context, productID uint, before time.Time, limit int) ([]domain.StockSubscription, error) {
+	args := m.Called(ctx, productID, before, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.StockSubscription), args.Error(1)
+}
+
+func (m *MockStockSubscriptionRepository) MarkNotified(ctx context.Context, id uint, notifiedAt time.Time) error {
+	args := m.Called(ctx, id, notifiedAt)
+	return args.Error(0)
+}
+
+func (m *MockStockSubscriptionRepository) ExpireBefore(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+const subscriptionTTL = 90 * 24 * time.Hour
+
+func TestSubscribe(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Guest subscribes by email", func(t *testing.T) {
+		// Setup
+		mockSubscriptionRepo := new(MockStockSubscriptionRepository)
+		mockProductRepo := new(MockProductRepository)
+		subscriptionService := service.NewStockSubscriptionService(mockSubscriptionRepo, mockProductRepo, nil, new(MockChannel), subscriptionTTL)
+
+		mockProductRepo.On("FindByID", ctx, uint(1)).Return(&domain.Product{ID: 1, Stock: 0}, nil)
+		mockSubscriptionRepo.On("FindPendingByEmail", ctx, uint(1), "guest@example.com").Return(nil, errors.New("record not found"))
+		mockSubscriptionRepo.On("Create", ctx, mock.AnythingOfType("*domain.StockSubscription")).Return(nil)
+
+		// Execute
+		subscription, err := subscriptionService.Subscribe(ctx, 1, nil, "guest@example.com")
+
+		// Assert
+		require.NoError(t, err)
+		assert.Nil(t, subscription.UserID)
+		assert.Equal(t, domain.StockSubscriptionStatusPending, subscription.Status)
+		assert.Len(t, subscription.Token, 64)
+		assert.WithinDuration(t, time.Now().Add(subscriptionTTL), subscription.ExpiresAt, time.Minute)
+		mockSubscriptionRepo.AssertExpectations(t)
+	})
+
+	t.Run("Subscribing twice returns the pending subscription", func(t *testing.T) {
+		// Setup
+		mockSubscriptionRepo := new(MockStockSubscriptionRepository)
+		mockProductRepo := new(MockProductRepository)
+		subscriptionService := service.NewStockSubscriptionService(mockSubscriptionRepo, mockProductRepo, nil, new(MockChannel), subscriptionTTL)
+
+		existing := &domain.StockSubscription{ID: 4, ProductID: 1, UserID: uintPtr(2), Email: "user@example.com", Status: domain.StockSubscriptionStatusPending}
+		mockProductRepo.On("FindByID", ctx, uint(1)).Return(&domain.Product{ID: 1, Stock: 0}, nil)
+		mockSubscriptionRepo.On("FindPendingByEmail", ctx, uint(1), "user@example.com").Return(existing, nil)
+
+		// Execute
+		subscription, err := subscriptionService.Subscribe(ctx, 1, uintPtr(2), "user@example.com")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, existing, subscription)
+		mockSubscriptionRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Product in stock", func(t *testing.T) {
+		// Setup
+		mockSubscriptionRepo := new(MockStockSubscriptionRepository)
+		mockProductRepo := new(MockProductRepository)
+		subscriptionService := service.NewStockSubscriptionService(mockSubscriptionRepo, mockProductRepo, nil, new(MockChannel), subscriptionTTL)
+
+		mockProductRepo.On("FindByID", ctx, uint(1)).Return(&domain.Product{ID: 1, Stock: 3}, nil)
+
+		// Execute
+		subscription, err := subscriptionService.Subscribe(ctx, 1, nil, "guest@example.com")
+
+		// Assert
+		assert.Nil(t, subscription)
+		assert.EqualError(t, err, "product is in stock")
+	})
+
+	t.Run("Email required", func(t *testing.T) {
+		// Setup
+		subscriptionService := service.NewStockSubscriptionService(new(MockStockSubscriptionRepository), new(MockProductRepository), nil, new(MockChannel), subscriptionTTL)
+
+		// Execute
+		_, err := subscriptionService.Subscribe(ctx, 1, nil, "")
+
+		// Assert
+		assert.EqualError(t, err, "email is required")
+	})
+}
+
+func TestUnsubscribe(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		// Setup
+		mockSubscriptionRepo := new(MockStockSubscriptionRepository)
+		subscriptionService := service.NewStockSubscriptionService(mockSubscriptionRepo, new(MockProductRepository), nil, new(MockChannel), subscriptionTTL)
+
+		mockSubscriptionRepo.On("FindByToken", ctx, "token").Return(&domain.StockSubscription{ID: 4, Status: domain.StockSubscriptionStatusPending}, nil)
+		mockSubscriptionRepo.On("UpdateStatus", ctx, uint(4), domain.StockSubscriptionStatusCancelled).Return(nil)
+
+		// Execute
+		err := subscriptionService.Unsubscribe(ctx, "token")
+
+		// Assert
+		assert.NoError(t, err)
+		mockSubscriptionRepo.AssertExpectations(t)
+	})
+
+	t.Run("Already notified", func(t *testing.T) {
+		// Setup
+		mockSubscriptionRepo := new(MockStockSubscriptionRepository)
+		subscriptionService := service.NewStockSubscriptionService(mockSubscriptionRepo, new(MockProductRepository), nil, new(MockChannel), subscriptionTTL)
+
+		mockSubscriptionRepo.On("FindByToken", ctx, "token").Return(&domain.StockSubscription{ID: 4, Status: domain.StockSubscriptionStatusNotified}, nil)
+
+		// Execute
+		err := subscriptionService.Unsubscribe(ctx, "token")
+
+		// Assert
+		assert.EqualError(t, err, "subscription is no longer pending")
+		mockSubscriptionRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestNotifySubscribers(t *testing.T) {
+	ctx := context.Background()
+	restockedAt := time.Now().Add(-time.Hour)
+
+	t.Run("Notifies no more subscribers than units available, oldest first", func(t *testing.T) {
+		// Setup
+		mockSubscriptionRepo := new(MockStockSubscriptionRepository)
+		mockProductRepo := new(MockProductRepository)
+		mockChannel := new(MockChannel)
+		subscriptionService := service.NewStockSubscriptionService(mockSubscriptionRepo, mockProductRepo, nil, mockChannel, subscriptionTTL)
+
+		// Three units came back and one subscriber was already told
+		subscriptions := []domain.StockSubscription{
+			{ID: 5, ProductID: 1, Email: "first@example.com", Token: "first"},
+			{ID: 8, ProductID: 1, Email: "second@example.com", Token: "second"},
+		}
+		mockSubscriptionRepo.On("FindSubscribedProductIDs", ctx).Return([]uint{1}, nil)
+		mockSubscriptionRepo.On("FindLastRestock", ctx, uint(1)).Return(&restockedAt, nil)
+		mockProductRepo.On("FindByID", ctx, uint(1)).Return(&domain.Product{ID: 1, Name: "Test Product", Stock: 3}, nil)
+		mockSubscriptionRepo.On("CountNotifiedSince", ctx, uint(1), restockedAt).Return(int64(1), nil)
+		mockSubscriptionRepo.On("FindPendingBefore", ctx, uint(1), restockedAt, 2).Return(subscriptions, nil)
+
+		var sent []string
+		mockChannel.On("Send", ctx, mock.AnythingOfType("notification.Notification")).Run(func(args mock.Arguments) {
+			n := args.Get(1).(notification.Notification)
+			sent = append(sent, n.Data["email"].(string))
+		}).Return(nil)
+		mockSubscriptionRepo.On("MarkNotified", ctx, mock.Anything, mock.AnythingOfType("time.Time")).Return(nil)
+
+		// Execute
+		notified, err := subscriptionService.NotifySubscribers(ctx)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 2, notified)
+		assert.Equal(t, []string{"first@example.com", "second@example.com"}, sent)
+		mockSubscriptionRepo.AssertExpectations(t)
+	})
+
+	t.Run("Budget already spent", func(t *testing.T) {
+		// Setup
+		mockSubscriptionRepo := new(MockStockSubscriptionRepository)
+		mockProductRepo := new(MockProductRepository)
+		subscriptionService := service.NewStockSubscriptionService(mockSubscriptionRepo, mockProductRepo, nil, new(MockChannel), subscriptionTTL)
+
+		mockSubscriptionRepo.On("FindSubscribedProductIDs", ctx).Return([]uint{1}, nil)
+		mockSubscriptionRepo.On("FindLastRestock", ctx, uint(1)).Return(&restockedAt, nil)
+		mockProductRepo.On("FindByID", ctx, uint(1)).Return(&domain.Product{ID: 1, Stock: 2}, nil)
+		mockSubscriptionRepo.On("CountNotifiedSince", ctx, uint(1), restockedAt).Return(int64(2), nil)
+
+		// Execute
+		notified, err := subscriptionService.NotifySubscribers(ctx)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 0, notified)
+		mockSubscriptionRepo.AssertNotCalled(t, "FindPendingBefore", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Never restocked", func(t *testing.T) {
+		// Setup
+		mockSubscriptionRepo := new(MockStockSubscriptionRepository)
+		mockProductRepo := new(MockProductRepository)
+		subscriptionService := service.NewStockSubscriptionService(mockSubscriptionRepo, mockProductRepo, nil, new(MockChannel), subscriptionTTL)
+
+		mockSubscriptionRepo.On("FindSubscribedProductIDs", ctx).Return([]uint{1}, nil)
+		mockSubscriptionRepo.On("FindLastRestock", ctx, uint(1)).Return(nil, nil)
+
+		// Execute
+		notified, err := subscriptionService.NotifySubscribers(ctx)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 0, notified)
+		mockProductRepo.AssertNotCalled(t, "FindByID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Failed notification stops before later subscribers", func(t *testing.T) {
+		// Setup
+		mockSubscriptionRepo := new(MockStockSubscriptionRepository)
+		mockProductRepo := new(MockProductRepository)
+		mockChannel := new(MockChannel)
+		subscriptionService := service.NewStockSubscriptionService(mockSubscriptionRepo, mockProductRepo, nil, mockChannel, subscriptionTTL)
+
+		subscriptions := []domain.StockSubscription{{ID: 5, ProductID: 1}, {ID: 8, ProductID: 1}}
+		mockSubscriptionRepo.On("FindSubscribedProductIDs", ctx).Return([]uint{1}, nil)
+		mockSubscriptionRepo.On("FindLastRestock", ctx, uint(1)).Return(&restockedAt, nil)
+		mockProductRepo.On("FindByID", ctx, uint(1)).Return(&domain.Product{ID: 1, Stock: 5}, nil)
+		mockSubscriptionRepo.On("CountNotifiedSince", ctx, uint(1), restockedAt).Return(int64(0), nil)
+		mockSubscriptionRepo.On("FindPendingBefore", ctx, uint(1), restockedAt, 5).Return(subscriptions, nil)
+		mockChannel.On("Send", ctx, mock.Anything).Return(errors.New("broker unavailable")).Once()
+
+		// Execute
+		notified, err := subscriptionService.NotifySubscribers(ctx)
+
+		// Assert
+		assert.Error(t, err)
+		assert.Equal(t, 0, notified)
+		mockChannel.AssertNumberOfCalls(t, "Send", 1)
+		mockSubscriptionRepo.AssertNotCalled(t, "MarkNotified", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestExpireSubscriptions(t *testing.T) {
+	// Setup
+	mockSubscriptionRepo := new(MockStockSubscriptionRepository)
+	subscriptionService := service.NewStockSubscriptionService(mockSubscriptionRepo, new(MockProductRepository), nil, new(MockChannel), subscriptionTTL)
+	ctx := context.Background()
+
+	mockSubscriptionRepo.On("ExpireBefore", ctx, mock.AnythingOfType("time.Time")).Return(int64(3), nil)
+
+	// Execute
+	expired, err := subscriptionService.ExpireSubscriptions(ctx)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), expired)
+}
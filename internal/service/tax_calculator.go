@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+)
+
+// TaxCalculator defines the interface for calculating the tax on carts and orders
+type TaxCalculator interface {
+	// Mode returns whether prices include tax or have it added
+	Mode() domain.TaxMode
+
+	// Calculate returns the tax lines of priced lines delivered to a destination
+	Calculate(ctx context.Context, destination domain.Destination, lines []domain.PricedLine) ([]domain.TaxLine, error)
+}
+
+// TableTaxCalculator implements the TaxCalculator interface with the tax
+// rates table, taxing each line at the most narrowly located rate of its tax
+// class at the destination
+type TableTaxCalculator struct {
+	rateRepo       repository.TaxRateRepository
+	mode           domain.TaxMode
+	defaultCountry string
+}
+
+// NewTableTaxCalculator creates a new TableTaxCalculator. Destinations
+// without a country are taxed as the default country, if any.
+func NewTableTaxCalculator(rateRepo repository.TaxRateRepository, mode domain.TaxMode, defaultCountry string) TaxCalculator {
+	return &TableTaxCalculator{
+		rateRepo:       rateRepo,
+		mode:           mode,
+		defaultCountry: defaultCountry,
+	}
+}
+
+// Mode returns whether prices include tax or have it added
+func (c *TableTaxCalculator) Mode() domain.TaxMode {
+	return c.mode
+}
+
+// Calculate returns the tax lines of priced lines delivered to a destination.
+// Lines whose tax class has no rate at the destination are not taxed.
+func (c *TableTaxCalculator) Calculate(ctx context.Context, destination domain.Destination, lines []domain.PricedLine) ([]domain.TaxLine, error) {
+	destination = domain.NormalizeDestination(destination)
+	if destination.Country == "" {
+		destination = domain.NormalizeDestination(domain.Destination{Country: c.defaultCountry})
+	}
+	if destination.Country == "" {
+		return nil, nil
+	}
+
+	rates, err := c.rateRepo.FindByCountry(ctx, destination.Country)
+	if err != nil {
+		return nil, err
+	}
+
+	var taxes []domain.TaxLine
+	for _, line := range lines {
+		taxClass := line.TaxClass
+		if taxClass == "" {
+			taxClass = domain.TaxClassStandard
+		}
+		rate := domain.FindTaxRate(rates, destination, taxClass)
+		if rate == nil || !line.Total.IsPositive() {
+			continue
+		}
+
+		amount := c.mode.Tax(line.Total, rate.Rate)
+		if !amount.IsPositive() {
+			continue
+		}
+		taxes = append(taxes, domain.TaxLine{
+			ProductID: line.ProductID,
+			TaxRateID: rate.ID,
+			Name:      rate.Name,
+			TaxClass:  taxClass,
+			Rate:      rate.Rate,
+			Taxable:   line.Total,
+			Amount:    amount,
+		})
+	}
+	return taxes, nil
+}
+
+// calculateTax returns the tax lines of priced lines delivered to the
+// destination of the request. Tax-exempt customers pay no tax: where prices
+// include tax, it is taken off them as discount lines instead. Without tax
+// support no tax is charged.
+func calculateTax(ctx context.Context, taxes TaxCalculator, user *domain.User, lines []domain.PricedLine) ([]domain.TaxLine, []domain.OrderDiscount, error) {
+	if taxes == nil {
+		return nil, nil, nil
+	}
+	if user.TaxExempt && taxes.Mode() != domain.TaxModeInclusive {
+		return nil, nil, nil
+	}
+
+	taxLines, err := taxes.Calculate(ctx, requestDestination(ctx), lines)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !user.TaxExempt {
+		return taxLines, nil, nil
+	}
+
+	exemptions := make([]domain.OrderDiscount, 0, len(taxLines))
+	for _, tax := range taxLines {
+		exemptions = append(exemptions, domain.OrderDiscount{
+			ProductID: tax.ProductID,
+			Source:    domain.DiscountSourceTaxExemption,
+			SourceID:  tax.TaxRateID,
+			Label:     tax.Name,
+			Amount:    tax.Amount,
+		})
+	}
+	return nil, exemptions, nil
+}
+
+// taxMode returns whether prices include tax, which they do not without tax support
+func taxMode(taxes TaxCalculator) domain.TaxMode {
+	if taxes == nil {
+		return domain.TaxModeExclusive
+	}
+	return taxes.Mode()
+}
+
+// requestDestination returns the destination the request delivers to, as
+// far as it says
+func requestDestination(ctx context.Context) domain.Destination {
+	return domain.NormalizeDestination(domain.DestinationFromContext(ctx))
+}
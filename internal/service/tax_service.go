@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/repository"
+)
+
+// TaxService defines the interface for managing tax rates and exemptions
+type TaxService interface {
+	// GetTaxRates retrieves every tax rate
+	GetTaxRates(ctx context.Context) ([]domain.TaxRate, error)
+
+	// GetTaxRateByID retrieves a tax rate by its ID
+	GetTaxRateByID(ctx context.Context, id uint) (*domain.TaxRate, error)
+
+	// CreateTaxRate creates a new tax rate
+	CreateTaxRate(ctx context.Context, rate *domain.TaxRate) error
+
+	// UpdateTaxRate updates an existing tax rate
+	UpdateTaxRate(ctx context.Context, rate *domain.TaxRate) error
+
+	// DeleteTaxRate deletes a tax rate
+	DeleteTaxRate(ctx context.Context, id uint) error
+
+	// SetTaxExempt sets whether a customer is exempt from tax
+	SetTaxExempt(ctx context.Context, userID uint, exempt bool) (*domain.User, error)
+}
+
+// TaxServiceImpl implements the TaxService interface
+type TaxServiceImpl struct {
+	rateRepo repository.TaxRateRepository
+	userRepo repository.UserRepository
+}
+
+// NewTaxService creates a new TaxServiceImpl
+func NewTaxService(rateRepo repository.TaxRateRepository, userRepo repository.UserRepository) TaxService {
+	return &TaxServiceImpl{
+		rateRepo: rateRepo,
+		userRepo: userRepo,
+	}
+}
+
+// GetTaxRates retrieves every tax rate
+func (s *TaxServiceImpl) GetTaxRates(ctx context.Context) ([]domain.TaxRate, error) {
+	return s.rateRepo.FindAll(ctx)
+}
+
+// GetTaxRateByID retrieves a tax rate by its ID
+func (s *TaxServiceImpl) GetTaxRateByID(ctx context.Context, id uint) (*domain.TaxRate, error) {
+	return s.rateRepo.FindByID(ctx, id)
+}
+
+// CreateTaxRate creates a new tax rate
+func (s *TaxServiceImpl) CreateTaxRate(ctx context.Context, rate *domain.TaxRate) error {
+	if err := s.validateTaxRate(ctx, rate); err != nil {
+		return err
+	}
+	return s.rateRepo.Create(ctx, rate)
+}
+
+// UpdateTaxRate updates an existing tax rate
+func (s *TaxServiceImpl) UpdateTaxRate(ctx context.Context, rate *domain.TaxRate) error {
+	if err := s.validateTaxRate(ctx, rate); err != nil {
+		return err
+	}
+	return s.rateRepo.Update(ctx, rate)
+}
+
+// DeleteTaxRate deletes a tax rate
+func (s *TaxServiceImpl) DeleteTaxRate(ctx context.Context, id uint) error {
+	if _, err := s.rateRepo.FindByID(ctx, id); err != nil {
+		return errors.New("tax rate not found")
+	}
+	return s.rateRepo.Delete(ctx, id)
+}
+
+// SetTaxExempt sets whether a customer is exempt from tax
+func (s *TaxServiceImpl) SetTaxExempt(ctx context.Context, userID uint, exempt bool) (*domain.User, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	user.TaxExempt = exempt
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// validateTaxRate normalizes the location and tax class of a tax rate and
+// checks that no other rate taxes the same tax class at the same location
+func (s *TaxServiceImpl) validateTaxRate(ctx context.Context, rate *domain.TaxRate) error {
+	location := domain.NormalizeDestination(domain.Destination{Country: rate.Country, Region: rate.Region, PostalCode: rate.PostalPrefix})
+	rate.Name = strings.TrimSpace(rate.Name)
+	rate.Country = location.Country
+	rate.Region = location.Region
+	rate.PostalPrefix = location.PostalCode
+	rate.TaxClass = strings.ToLower(strings.TrimSpace(rate.TaxClass))
+	if rate.TaxClass == "" {
+		rate.TaxClass = domain.TaxClassStandard
+	}
+
+	if rate.Name == "" {
+		return errors.New("tax rate name is required")
+	}
+	if len(rate.Country) != 2 {
+		return errors.New("country must be a two-letter code")
+	}
+	if rate.Rate < 0 || rate.Rate > 100 {
+		return errors.New("rate must be between 0 and 100")
+	}
+
+	rates, err := s.rateRepo.FindByCountry(ctx, rate.Country)
+	if err != nil {
+		return err
+	}
+	for _, other := range rates {
+		if other.ID != rate.ID && other.Region == rate.Region && other.PostalPrefix == rate.PostalPrefix && other.TaxClass == rate.TaxClass {
+			return errors.New("a tax rate already exists for this location and tax class")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,353 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"awesomeEcommerce/internal/domain"
+	"awesomeEcommerce/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockTaxRateRepository struct {
+	mock.Mock
+}
+
+func (m *MockTaxRateRepository) FindByID(ctx context.Context, id uint) (*domain.TaxRate, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.TaxRate), args.Error(1)
+}
+
+func (m *MockTaxRateRepository) FindAll(ctx context.Context) ([]domain.TaxRate, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.TaxRate), args.Error(1)
+}
+
+func (m *MockTaxRateRepository) FindByCountry(ctx context.Context, country string) ([]domain.TaxRate, error) {
+	args := m.Called(ctx, country)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.TaxRate), args.Error(1)
+}
+
+func (m *MockTaxRateRepository) Create(ctx context.Context, rate *domain.TaxRate) error {
+	args := m.Called(ctx, rate)
+	return args.Error(0)
+}
+
+func (m *MockTaxRateRepository) Update(ctx context.Context, rate *domain.TaxRate) error {
+	args := m.Called(ctx, rate)
+	return args.Error(0)
+}
+
+func (m *MockTaxRateRepository) Delete(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// usRates are the tax rates of the United States in the tax tests: a state
+// rate for New York, a higher city rate for Manhattan and a reduced rate for
+// food
+func usRates() []domain.TaxRate {
+	return []domain.TaxRate{
+		{ID: 1, Name: "NY State", Country: "US", Region: "NY", TaxClass: domain.TaxClassStandard, Rate: 4},
+		{ID: 2, Name: "NYC", Country: "US", Region: "NY", PostalPrefix: "100", TaxClass: domain.TaxClassStandard, Rate: 8.875},
+		{ID: 3, Name: "NY Food", Country: "US", Region: "NY", TaxClass: "food", Rate: 0},
+		{ID: 4, Name: "US Federal", Country: "US", TaxClass: domain.TaxClassStandard, Rate: 2},
+	}
+}
+
+func TestFindTaxRate(t *testing.T) {
+	rates := usRates()
+
+	tests := []struct {
+		name        string
+		destination domain.Destination
+		taxClass    string
+		wantID      uint
+	}{
+		{name: "Postal prefix before region", destination: domain.Destination{Country: "US", Region: "NY", PostalCode: "10001"}, taxClass: domain.TaxClassStandard, wantID: 2},
+		{name: "Region before country", destination: domain.Destination{Country: "US", Region: "NY", PostalCode: "14201"}, taxClass: domain.TaxClassStandard, wantID: 1},
+		{name: "Country alone", destination: domain.Destination{Country: "US", Region: "CA"}, taxClass: domain.TaxClassStandard, wantID: 4},
+		{name: "Tax class of its own", destination: domain.Destination{Country: "US", Region: "NY", PostalCode: "10001"}, taxClass: "food", wantID: 3},
+		{name: "No rate for the tax class", destination: domain.Destination{Country: "US", Region: "CA"}, taxClass: "food"},
+		{name: "Other country", destination: domain.Destination{Country: "CA", Region: "NY"}, taxClass: domain.TaxClassStandard},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate := domain.FindTaxRate(rates, tt.destination, tt.taxClass)
+			if tt.wantID == 0 {
+				assert.Nil(t, rate)
+				return
+			}
+			require.NotNil(t, rate)
+			assert.Equal(t, tt.wantID, rate.ID)
+		})
+	}
+}
+
+func TestTaxModes(t *testing.T) {
+	// Exclusive prices have the tax added on top
+	assert.Equal(t, usd(200), domain.TaxModeExclusive.Tax(usd(1000), 20))
+	assert.Equal(t, usd(89), domain.TaxModeExclusive.Tax(usd(1000), 8.875))
+	total, err := domain.TaxModeExclusive.Total(usd(1200), usd(100), usd(90), usd(100))
+	assert.NoError(t, err)
+	assert.Equal(t, usd(1290), total)
+
+	// Inclusive prices hold the tax, which totals report without adding it
+	assert.Equal(t, usd(200), domain.TaxModeInclusive.Tax(usd(1200), 20))
+	assert.Equal(t, usd(167), domain.TaxModeInclusive.Tax(usd(1000), 20))
+	total, err = domain.TaxModeInclusive.Total(usd(1200), usd(100), usd(183), usd(100))
+	assert.NoError(t, err)
+	assert.Equal(t, usd(1200), total)
+
+	assert.Equal(t, domain.TaxModeInclusive, domain.ParseTaxMode(" Inclusive "))
+	assert.Equal(t, domain.TaxModeExclusive, domain.ParseTaxMode(""))
+}
+
+func TestCalculateTax(t *testing.T) {
+	ctx := context.Background()
+	lines := []domain.PricedLine{
+		{ProductID: 1, Quantity: 2, Total: usd(2000)},
+		{ProductID: 2, TaxClass: "food", Quantity: 1, Total: usd(500)},
+		{ProductID: 3, TaxClass: "books", Quantity: 1, Total: usd(1500)},
+		{ProductID: 4, Quantity: 1},
+	}
+
+	t.Run("Most narrowly located rate per line", func(t *testing.T) {
+		// Setup
+		mockRateRepo := new(MockTaxRateRepository)
+		calculator := service.NewTableTaxCalculator(mockRateRepo, domain.TaxModeExclusive, "")
+		mockRateRepo.On("FindByCountry", ctx, "US").Return(usRates(), nil)
+
+		// Execute
+		taxes, err := calculator.Calculate(ctx, domain.Destination{Country: "us", Region: "ny", PostalCode: "100 01"}, lines)
+
+		// Assert: food is taxed at zero, books have no rate and free lines no tax
+		require.NoError(t, err)
+		require.Len(t, taxes, 1)
+		assert.Equal(t, uint(1), taxes[0].ProductID)
+		assert.Equal(t, uint(2), taxes[0].TaxRateID)
+		assert.Equal(t, "NYC", taxes[0].Name)
+		assert.Equal(t, domain.TaxClassStandard, taxes[0].TaxClass)
+		assert.Equal(t, usd(2000), taxes[0].Taxable)
+		assert.Equal(t, usd(178), taxes[0].Amount)
+	})
+
+	t.Run("Default country", func(t *testing.T) {
+		// Setup
+		mockRateRepo := new(MockTaxRateRepository)
+		calculator := service.NewTableTaxCalculator(mockRateRepo, domain.TaxModeExclusive, "us")
+		mockRateRepo.On("FindByCountry", ctx, "US").Return(usRates(), nil)
+
+		// Execute
+		taxes, err := calculator.Calculate(ctx, domain.Destination{}, lines)
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, taxes, 1)
+		assert.Equal(t, uint(4), taxes[0].TaxRateID)
+		assert.Equal(t, usd(40), taxes[0].Amount)
+	})
+
+	t.Run("No destination", func(t *testing.T) {
+		// Setup
+		mockRateRepo := new(MockTaxRateRepository)
+		calculator := service.NewTableTaxCalculator(mockRateRepo, domain.TaxModeExclusive, "")
+
+		// Execute
+		taxes, err := calculator.Calculate(ctx, domain.Destination{}, lines)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Empty(t, taxes)
+		mockRateRepo.AssertNotCalled(t, "FindByCountry", mock.Anything, mock.Anything)
+	})
+}
+
+func TestCreateTaxRate(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		rate    domain.TaxRate
+		wantErr string
+	}{
+		{name: "Missing name", rate: domain.TaxRate{Name: " ", Country: "US", Rate: 4}, wantErr: "tax rate name is required"},
+		{name: "Invalid country", rate: domain.TaxRate{Name: "US", Country: "USA", Rate: 4}, wantErr: "country must be a two-letter code"},
+		{name: "Negative rate", rate: domain.TaxRate{Name: "US", Country: "US", Rate: -1}, wantErr: "rate must be between 0 and 100"},
+		{name: "Same location and tax class", rate: domain.TaxRate{Name: "New York", Country: "US", Region: "ny", Rate: 4.5}, wantErr: "a tax rate already exists for this location and tax class"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockRateRepo := new(MockTaxRateRepository)
+			taxService := service.NewTaxService(mockRateRepo, new(MockUserRepository))
+			mockRateRepo.On("FindByCountry", ctx, "US").Return(usRates(), nil)
+
+			// Execute
+			rate := tt.rate
+			err := taxService.CreateTaxRate(ctx, &rate)
+
+			// Assert
+			assert.EqualError(t, err, tt.wantErr)
+			mockRateRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("Normalized location and default tax class", func(t *testing.T) {
+		// Setup
+		mockRateRepo := new(MockTaxRateRepository)
+		taxService := service.NewTaxService(mockRateRepo, new(MockUserRepository))
+		mockRateRepo.On("FindByCountry", ctx, "GB").Return([]domain.TaxRate{}, nil)
+		mockRateRepo.On("Create", ctx, mock.AnythingOfType("*domain.TaxRate")).Return(nil)
+
+		// Execute
+		rate := &domain.TaxRate{Name: " London VAT ", Country: "gb", PostalPrefix: "sw1 a", Rate: 20}
+		err := taxService.CreateTaxRate(ctx, rate)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "London VAT", rate.Name)
+		assert.Equal(t, "GB", rate.Country)
+		assert.Equal(t, "SW1A", rate.PostalPrefix)
+		assert.Equal(t, domain.TaxClassStandard, rate.TaxClass)
+		mockRateRepo.AssertExpectations(t)
+	})
+}
+
+func TestSetTaxExempt(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		// Setup
+		mockUserRepo := new(MockUserRepository)
+		taxService := service.NewTaxService(new(MockTaxRateRepository), mockUserRepo)
+		mockUserRepo.On("FindByID", ctx, uint(1)).Return(&domain.User{ID: 1}, nil)
+		mockUserRepo.On("Update", ctx, mock.AnythingOfType("*domain.User")).Return(nil)
+
+		// Execute
+		user, err := taxService.SetTaxExempt(ctx, 1, true)
+
+		// Assert
+		require.NoError(t, err)
+		assert.True(t, user.TaxExempt)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("User not found", func(t *testing.T) {
+		// Setup
+		mockUserRepo := new(MockUserRepository)
+		taxService := service.NewTaxService(new(MockTaxRateRepository), mockUserRepo)
+		mockUserRepo.On("FindByID", ctx, uint(2)).Return(nil, assert.AnError)
+
+		// Execute
+		user, err := taxService.SetTaxExempt(ctx, 2, true)
+
+		// Assert
+		assert.Nil(t, user)
+		assert.EqualError(t, err, "user not found")
+		mockUserRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+}
+
+func TestGetCartSummaryWithTax(t *testing.T) {
+	newYork := domain.Destination{Country: "US", Region: "NY", PostalCode: "14201"}
+	items := []domain.CartItem{
+		{ID: 1, CartID: 1, ProductID: 1, Quantity: 2, Product: domain.Product{ID: 1, Price: usd(1000), TaxClass: domain.TaxClassStandard}},
+		{ID: 2, CartID: 1, ProductID: 2, Quantity: 1, Product: domain.Product{ID: 2, Price: usd(500), TaxClass: "food"}},
+	}
+
+	tests := []struct {
+		name         string
+		mode         domain.TaxMode
+		exempt       bool
+		wantTax      int64
+		wantDiscount int64
+		wantTotal    int64
+	}{
+		{name: "Exclusive", mode: domain.TaxModeExclusive, wantTax: 80, wantTotal: 2580},
+		{name: "Inclusive", mode: domain.TaxModeInclusive, wantTax: 77, wantTotal: 2500},
+		{name: "Exempt customer on exclusive prices", mode: domain.TaxModeExclusive, exempt: true, wantTotal: 2500},
+		{name: "Exempt customer on inclusive prices", mode: domain.TaxModeInclusive, exempt: true, wantDiscount: 77, wantTotal: 2423},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockCartRepo := new(MockCartRepository)
+			mockUserRepo := new(MockUserRepository)
+			mockRateRepo := new(MockTaxRateRepository)
+			calculator := service.NewTableTaxCalculator(mockRateRepo, tt.mode, "")
+			cartService := service.NewCartService(mockCartRepo, new(MockProductRepository), mockUserRepo, service.WithCartTaxes(calculator))
+
+			ctx := domain.WithDestination(context.Background(), newYork)
+			mockCartRepo.On("GetCartItems", ctx, uint(1)).Return(items, nil)
+			mockCartRepo.On("FindByID", ctx, uint(1)).Return(&domain.Cart{ID: 1, UserID: 1, Items: items}, nil)
+			mockUserRepo.On("FindByID", ctx, uint(1)).Return(&domain.User{ID: 1, TaxExempt: tt.exempt}, nil)
+			mockRateRepo.On("FindByCountry", ctx, "US").Return(usRates(), nil)
+
+			// Execute
+			summary, err := cartService.GetCartSummary(ctx, 1)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, tt.mode, summary.TaxMode)
+			assert.Equal(t, usd(2500), summary.Subtotal)
+			assert.Equal(t, tt.wantTax, summary.Tax.Amount)
+			assert.Equal(t, tt.wantDiscount, summary.Discount.Amount)
+			assert.Equal(t, usd(tt.wantTotal), summary.Total)
+			if tt.wantDiscount > 0 {
+				require.Len(t, summary.Discounts, 1)
+				assert.Equal(t, domain.DiscountSourceTaxExemption, summary.Discounts[0].Source)
+			}
+		})
+	}
+}
+
+func TestCreateOrderWithTax(t *testing.T) {
+	// Setup
+	mockOrderRepo := new(MockOrderRepository)
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(MockProductRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockRateRepo := new(MockTaxRateRepository)
+	calculator := service.NewTableTaxCalculator(mockRateRepo, domain.TaxModeExclusive, "")
+	orderService := service.NewOrderService(mockOrderRepo, mockCartRepo, mockProductRepo, mockUserRepo, nil, service.WithTaxes(calculator))
+
+	ctx := domain.WithDestination(context.Background(), domain.Destination{Country: "US", Region: "NY", PostalCode: "10001"})
+	cart := &domain.Cart{ID: 1, UserID: 1, Items: []domain.CartItem{{ProductID: 1, Quantity: 2}}}
+	mockUserRepo.On("FindByID", ctx, uint(1)).Return(&domain.User{ID: 1}, nil)
+	mockCartRepo.On("FindByUserID", ctx, uint(1)).Return(cart, nil)
+	mockProductRepo.On("FindByID", ctx, uint(1)).Return(&domain.Product{ID: 1, Name: "Mug", Price: usd(1000), Stock: 10}, nil)
+	mockRateRepo.On("FindByCountry", ctx, "US").Return(usRates(), nil)
+	mockProductRepo.On("DecrementStock", ctx, mock.Anything, mock.Anything).Return(nil)
+	mockOrderRepo.On("Create", ctx, mock.AnythingOfType("*domain.Order")).Return(nil)
+	mockCartRepo.On("ClearCart", ctx, cart.ID).Return(nil)
+
+	// Execute
+	order, err := orderService.CreateOrder(ctx, 1, "1 Shipping St", "1 Billing St", 0)
+
+	// Assert: the order keeps its breakdown and where it was taxed
+	require.NoError(t, err)
+	assert.Equal(t, usd(2000), order.SubtotalAmount)
+	assert.Equal(t, usd(178), order.TaxAmount)
+	assert.Zero(t, order.ShippingAmount.Amount)
+	assert.Equal(t, usd(2178), order.TotalAmount)
+	assert.Equal(t, domain.TaxModeExclusive, order.TaxMode)
+	assert.Equal(t, "NY", order.Destination.Region)
+	require.Len(t, order.Taxes, 1)
+	assert.Equal(t, uint(2), order.Taxes[0].TaxRateID)
+}
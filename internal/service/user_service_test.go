@@ -876,13 +876,13 @@ func TestGetUserOrders(t *testing.T) {
 			{
 				ID:          1,
 				UserID:      userID,
-				TotalAmount: 99.99,
+				TotalAmount: usd(9999),
 				Status:      domain.OrderStatusPending,
 			},
 			{
 				ID:          2,
 				UserID:      userID,
-				TotalAmount: 149.99,
+				TotalAmount: usd(14999),
 				Status:      domain.OrderStatusDelivered,
 			},
 		}
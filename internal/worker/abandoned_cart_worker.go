@@ -0,0 +1,58 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"awesomeEcommerce/internal/service"
+)
+
+// AbandonedCartWorker periodically reminds customers of the carts they left
+// untouched
+type AbandonedCartWorker struct {
+	abandonedCartService service.AbandonedCartService
+	checkInterval        time.Duration
+}
+
+// NewAbandonedCartWorker creates a new AbandonedCartWorker
+func NewAbandonedCartWorker(abandonedCartService service.AbandonedCartService, checkInterval time.Duration) *AbandonedCartWorker {
+	return &AbandonedCartWorker{
+		abandonedCartService: abandonedCartService,
+		checkInterval:        checkInterval,
+	}
+}
+
+// Start starts the abandoned cart worker
+func (w *AbandonedCartWorker) Start(ctx context.Context) {
+	go w.run(ctx)
+
+	log.Println("Abandoned cart worker started")
+}
+
+// run sends the reminders due on every tick
+func (w *AbandonedCartWorker) run(ctx context.Context) {
+	ticker := time.NewTicker(w.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Context cancelled, stopping abandoned cart worker")
+			return
+		case <-ticker.C:
+			w.process(ctx)
+		}
+	}
+}
+
+// process sends the reminders due for abandoned carts
+func (w *AbandonedCartWorker) process(ctx context.Context) {
+	sent, err := w.abandonedCartService.SendReminders(ctx)
+	if err != nil {
+		log.Printf("Error sending abandoned cart reminders: %v", err)
+	}
+	if sent > 0 {
+		log.Printf("Sent %d abandoned cart reminders", sent)
+	}
+}
@@ -0,0 +1,84 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strconv"
+	"time"
+
+	"awesomeEcommerce/internal/messaging"
+	"awesomeEcommerce/internal/service"
+)
+
+// BackorderWorker periodically hands incoming stock to the oldest backorders
+type BackorderWorker struct {
+	backorderService   service.BackorderService
+	producer           *messaging.KafkaProducer
+	allocationInterval time.Duration
+}
+
+// NewBackorderWorker creates a new BackorderWorker
+func NewBackorderWorker(
+	backorderService service.BackorderService,
+	producer *messaging.KafkaProducer,
+	allocationInterval time.Duration,
+) *BackorderWorker {
+	return &BackorderWorker{
+		backorderService:   backorderService,
+		producer:           producer,
+		allocationInterval: allocationInterval,
+	}
+}
+
+// Start starts the backorder worker
+func (w *BackorderWorker) Start(ctx context.Context) {
+	go w.run(ctx)
+
+	log.Println("Backorder worker started")
+}
+
+// run allocates incoming stock on every tick
+func (w *BackorderWorker) run(ctx context.Context) {
+	ticker := time.NewTicker(w.allocationInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Context cancelled, stopping backorder worker")
+			return
+		case <-ticker.C:
+			w.allocate(ctx)
+		}
+	}
+}
+
+// allocate hands incoming stock to backorders and publishes a
+// backorder-allocated event for every order line that received stock
+func (w *BackorderWorker) allocate(ctx context.Context) {
+	allocations, err := w.backorderService.AllocateIncomingStock(ctx)
+	if err != nil {
+		log.Printf("Error allocating stock to backorders: %v", err)
+	}
+
+	for _, allocation := range allocations {
+		allocatedJSON, _ := json.Marshal(map[string]interface{}{
+			"order_id":      allocation.OrderID,
+			"order_item_id": allocation.OrderItemID,
+			"product_id":    allocation.ProductID,
+			"quantity":      allocation.Quantity,
+			"remaining":     allocation.Remaining,
+			"fulfilled":     allocation.Remaining == 0,
+			"timestamp":     time.Now(),
+		})
+		err := w.producer.Publish(ctx, "backorder-allocated", []byte(strconv.FormatUint(uint64(allocation.OrderID), 10)), allocatedJSON)
+		if err != nil {
+			log.Printf("Error publishing backorder-allocated event: %v", err)
+		}
+	}
+
+	if len(allocations) > 0 {
+		log.Printf("Allocated incoming stock to %d backordered order lines", len(allocations))
+	}
+}
@@ -66,14 +66,14 @@ func (w *OrderWorker) handleOrderCreated(msg kafka.Message) error {
 	}
 
 	// Process the order (e.g., send confirmation email, notify inventory, etc.)
-	log.Printf("Processing order %d for user %d with total amount %f", order.ID, order.UserID, order.TotalAmount)
+	log.Printf("Processing order %d for user %d with total amount %s", order.ID, order.UserID, order.TotalAmount)
 
 	// In a real application, we would perform additional processing here
 	// For this example, we'll just simulate a delay
 	time.Sleep(500 * time.Millisecond)
 
 	// Update the order status to processing
-	err = w.orderService.UpdateOrderStatus(context.Background(), order.ID, domain.OrderStatusProcessing)
+	err = w.orderService.UpdateOrderStatus(context.Background(), order.ID, domain.OrderStatusProcessing, "order processed")
 	if err != nil {
 		log.Printf("Error updating order status: %v", err)
 		return err
@@ -155,7 +155,7 @@ func (w *OrderWorker) handlePaymentStatus(msg kafka.Message) error {
 	case domain.PaymentStatusCompleted:
 		// Payment completed, update order status to processing
 		log.Printf("Payment %d for order %d completed", paymentUpdate.ID, paymentUpdate.OrderID)
-		err := w.orderService.UpdateOrderStatus(context.Background(), paymentUpdate.OrderID, domain.OrderStatusProcessing)
+		err := w.orderService.UpdateOrderStatus(context.Background(), paymentUpdate.OrderID, domain.OrderStatusProcessing, "payment completed")
 		if err != nil {
 			log.Printf("Error updating order status: %v", err)
 			return err
@@ -164,7 +164,7 @@ func (w *OrderWorker) handlePaymentStatus(msg kafka.Message) error {
 	case domain.PaymentStatusFailed:
 		// Payment failed, update order status to cancelled
 		log.Printf("Payment %d for order %d failed", paymentUpdate.ID, paymentUpdate.OrderID)
-		err := w.orderService.UpdateOrderStatus(context.Background(), paymentUpdate.OrderID, domain.OrderStatusCancelled)
+		err := w.orderService.UpdateOrderStatus(context.Background(), paymentUpdate.OrderID, domain.OrderStatusCancelled, "payment failed")
 		if err != nil {
 			log.Printf("Error updating order status: %v", err)
 			return err
@@ -173,7 +173,7 @@ func (w *OrderWorker) handlePaymentStatus(msg kafka.Message) error {
 	case domain.PaymentStatusRefunded:
 		// Payment refunded, update order status to cancelled
 		log.Printf("Payment %d for order %d refunded", paymentUpdate.ID, paymentUpdate.OrderID)
-		err := w.orderService.UpdateOrderStatus(context.Background(), paymentUpdate.OrderID, domain.OrderStatusCancelled)
+		err := w.orderService.UpdateOrderStatus(context.Background(), paymentUpdate.OrderID, domain.OrderStatusCancelled, "payment refunded")
 		if err != nil {
 			log.Printf("Error updating order status: %v", err)
 			return err
@@ -16,21 +16,24 @@ import (
 
 // ProductWorker handles asynchronous product processing tasks
 type ProductWorker struct {
-	productService service.ProductService
-	consumer       *messaging.KafkaConsumer
-	producer       *messaging.KafkaProducer
+	productService    service.ProductService
+	stockAlertService service.StockAlertService
+	consumer          *messaging.KafkaConsumer
+	producer          *messaging.KafkaProducer
 }
 
 // NewProductWorker creates a new ProductWorker
 func NewProductWorker(
 	productService service.ProductService,
+	stockAlertService service.StockAlertService,
 	consumer *messaging.KafkaConsumer,
 	producer *messaging.KafkaProducer,
 ) *ProductWorker {
 	return &ProductWorker{
-		productService: productService,
-		consumer:       consumer,
-		producer:       producer,
+		productService:    productService,
+		stockAlertService: stockAlertService,
+		consumer:          consumer,
+		producer:          producer,
 	}
 }
 
@@ -57,12 +60,15 @@ func (w *ProductWorker) handleProductSync(msg kafka.Message) error {
 		return err
 	}
 
+	// Stock loaded with synced products is recorded as an import in the inventory ledger
+	ctx := domain.WithStockChange(context.Background(), domain.StockChange{Reason: domain.LedgerReasonImport, Actor: "product-sync"})
+
 	// Process the product sync request based on the action
 	switch syncRequest.Action {
 	case "create":
 		// Create a new product
 		log.Printf("Creating product: %s", syncRequest.Product.Name)
-		err := w.productService.CreateProduct(context.Background(), &syncRequest.Product)
+		err := w.productService.CreateProduct(ctx, &syncRequest.Product)
 		if err != nil {
 			log.Printf("Error creating product: %v", err)
 			return err
@@ -72,7 +78,7 @@ func (w *ProductWorker) handleProductSync(msg kafka.Message) error {
 	case "update":
 		// Update an existing product
 		log.Printf("Updating product: %d", syncRequest.Product.ID)
-		err := w.productService.UpdateProduct(context.Background(), &syncRequest.Product)
+		err := w.productService.UpdateProduct(ctx, &syncRequest.Product)
 		if err != nil {
 			log.Printf("Error updating product: %v", err)
 			return err
@@ -133,40 +139,19 @@ func (w *ProductWorker) handleProductStockUpdate(msg kafka.Message) error {
 
 	// Update the stock
 	log.Printf("Updating stock for product %d (%s): %+d", product.ID, product.Name, stockUpdate.Quantity)
-	err = w.productService.UpdateProductStock(context.Background(), product.ID, stockUpdate.Quantity)
+	err = w.productService.UpdateProductStock(context.Background(), product.ID, stockUpdate.Quantity, domain.LedgerReasonStockUpdateEvent)
 	if err != nil {
 		log.Printf("Error updating product stock: %v", err)
 		return err
 	}
+	log.Printf("Stock updated successfully for product %d", product.ID)
 
-	// Get the updated product
-	updatedProduct, err := w.productService.GetProductByID(context.Background(), stockUpdate.ProductID)
-	if err != nil {
-		log.Printf("Error getting updated product: %v", err)
+	// Alert if the stock fell to the product's reorder point
+	if err := w.stockAlertService.CheckProduct(context.Background(), product.ID); err != nil {
+		log.Printf("Error checking stock of product %d: %v", product.ID, err)
 		return err
 	}
 
-	log.Printf("Stock updated successfully for product %d. New stock: %d", product.ID, updatedProduct.Stock)
-
-	// Check if stock is low and needs replenishment
-	if updatedProduct.Stock < 10 {
-		log.Printf("Low stock alert for product %d (%s): %d units remaining", updatedProduct.ID, updatedProduct.Name, updatedProduct.Stock)
-		
-		// Publish a low-stock-alert event
-		alertJSON, _ := json.Marshal(map[string]interface{}{
-			"product_id":   updatedProduct.ID,
-			"product_name": updatedProduct.Name,
-			"product_sku":  updatedProduct.SKU,
-			"current_stock": updatedProduct.Stock,
-			"timestamp":    time.Now(),
-		})
-		err := w.producer.Publish(context.Background(), "low-stock-alert", []byte(strconv.FormatUint(uint64(updatedProduct.ID), 10)), alertJSON)
-		if err != nil {
-			log.Printf("Error publishing low-stock-alert event: %v", err)
-			return err
-		}
-	}
-
 	return nil
 }
 
@@ -180,7 +165,7 @@ func (w *ProductWorker) SyncProductsFromExternalSource(ctx context.Context) erro
 		{
 			Name:        "Sample Product 1",
 			Description: "This is a sample product for testing",
-			Price:       19.99,
+			Price:       domain.Money{Amount: 1999, Currency: "USD"},
 			Stock:       100,
 			SKU:         "SAMPLE-001",
 			ImageURL:    "https://example.com/sample1.jpg",
@@ -189,7 +174,7 @@ func (w *ProductWorker) SyncProductsFromExternalSource(ctx context.Context) erro
 		{
 			Name:        "Sample Product 2",
 			Description: "Another sample product for testing",
-			Price:       29.99,
+			Price:       domain.Money{Amount: 2999, Currency: "USD"},
 			Stock:       50,
 			SKU:         "SAMPLE-002",
 			ImageURL:    "https://example.com/sample2.jpg",
@@ -198,7 +183,7 @@ func (w *ProductWorker) SyncProductsFromExternalSource(ctx context.Context) erro
 		{
 			Name:        "Sample Product 3",
 			Description: "Yet another sample product for testing",
-			Price:       39.99,
+			Price:       domain.Money{Amount: 3999, Currency: "USD"},
 			Stock:       25,
 			SKU:         "SAMPLE-003",
 			ImageURL:    "https://example.com/sample3.jpg",
@@ -0,0 +1,87 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"awesomeEcommerce/internal/service"
+)
+
+// RecommendationWorker periodically recomputes product recommendations
+type RecommendationWorker struct {
+	recommendationService service.RecommendationService
+	refreshInterval       time.Duration
+	rebuildInterval       time.Duration
+	lastOrderID           uint
+}
+
+// NewRecommendationWorker creates a new RecommendationWorker
+func NewRecommendationWorker(
+	recommendationService service.RecommendationService,
+	refreshInterval time.Duration,
+	rebuildInterval time.Duration,
+) *RecommendationWorker {
+	return &RecommendationWorker{
+		recommendationService: recommendationService,
+		refreshInterval:       refreshInterval,
+		rebuildInterval:       rebuildInterval,
+	}
+}
+
+// Start starts the recommendation worker
+func (w *RecommendationWorker) Start(ctx context.Context) {
+	go w.run(ctx)
+
+	log.Println("Recommendation worker started")
+}
+
+// run rebuilds all recommendations once, then folds new orders in on every
+// refresh tick and rebuilds from scratch on every rebuild tick
+func (w *RecommendationWorker) run(ctx context.Context) {
+	w.rebuild(ctx)
+
+	refreshTicker := time.NewTicker(w.refreshInterval)
+	defer refreshTicker.Stop()
+	rebuildTicker := time.NewTicker(w.rebuildInterval)
+	defer rebuildTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Context cancelled, stopping recommendation worker")
+			return
+		case <-refreshTicker.C:
+			w.refresh(ctx)
+		case <-rebuildTicker.C:
+			w.rebuild(ctx)
+		}
+	}
+}
+
+// rebuild recomputes all recommendations
+func (w *RecommendationWorker) rebuild(ctx context.Context) {
+	log.Println("Rebuilding product recommendations")
+
+	lastOrderID, err := w.recommendationService.RebuildRecommendations(ctx)
+	if err != nil {
+		log.Printf("Error rebuilding product recommendations: %v", err)
+		return
+	}
+	w.lastOrderID = lastOrderID
+
+	log.Printf("Product recommendations rebuilt up to order %d", lastOrderID)
+}
+
+// refresh folds orders placed since the last run into the recommendations
+func (w *RecommendationWorker) refresh(ctx context.Context) {
+	lastOrderID, err := w.recommendationService.RefreshRecommendations(ctx, w.lastOrderID)
+	if err != nil {
+		log.Printf("Error refreshing product recommendations: %v", err)
+	}
+
+	if lastOrderID != w.lastOrderID {
+		log.Printf("Product recommendations refreshed up to order %d", lastOrderID)
+		w.lastOrderID = lastOrderID
+	}
+}
@@ -0,0 +1,65 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"awesomeEcommerce/internal/service"
+)
+
+// ReservationWorker periodically cancels unpaid orders whose stock reservations expired
+type ReservationWorker struct {
+	reservationService service.ReservationService
+	sweepInterval      time.Duration
+}
+
+// NewReservationWorker creates a new ReservationWorker
+func NewReservationWorker(
+	reservationService service.ReservationService,
+	sweepInterval time.Duration,
+) *ReservationWorker {
+	return &ReservationWorker{
+		reservationService: reservationService,
+		sweepInterval:      sweepInterval,
+	}
+}
+
+// Start starts the reservation worker
+func (w *ReservationWorker) Start(ctx context.Context) {
+	go w.run(ctx)
+
+	log.Println("Reservation worker started")
+}
+
+// run sweeps expired reservations on every tick
+func (w *ReservationWorker) run(ctx context.Context) {
+	ticker := time.NewTicker(w.sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Context cancelled, stopping reservation worker")
+			return
+		case <-ticker.C:
+			w.sweep(ctx)
+		}
+	}
+}
+
+// sweep releases expired reservations until none are left
+func (w *ReservationWorker) sweep(ctx context.Context) {
+	for {
+		expired, err := w.reservationService.ExpireReservations(ctx)
+		if err != nil {
+			log.Printf("Error expiring stock reservations: %v", err)
+			return
+		}
+		if expired == 0 {
+			return
+		}
+
+		log.Printf("Cancelled %d orders with expired stock reservations", expired)
+	}
+}
@@ -0,0 +1,49 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"awesomeEcommerce/internal/service"
+)
+
+// StockAlertWorker periodically checks every product for low stock, catching
+// stock taken by orders and adjustments made outside the stock update events
+type StockAlertWorker struct {
+	stockAlertService service.StockAlertService
+	checkInterval     time.Duration
+}
+
+// NewStockAlertWorker creates a new StockAlertWorker
+func NewStockAlertWorker(stockAlertService service.StockAlertService, checkInterval time.Duration) *StockAlertWorker {
+	return &StockAlertWorker{
+		stockAlertService: stockAlertService,
+		checkInterval:     checkInterval,
+	}
+}
+
+// Start starts the stock alert worker
+func (w *StockAlertWorker) Start(ctx context.Context) {
+	go w.run(ctx)
+
+	log.Println("Stock alert worker started")
+}
+
+// run checks every product on every tick
+func (w *StockAlertWorker) run(ctx context.Context) {
+	ticker := time.NewTicker(w.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Context cancelled, stopping stock alert worker")
+			return
+		case <-ticker.C:
+			if err := w.stockAlertService.CheckAllProducts(ctx); err != nil {
+				log.Printf("Error checking products for low stock: %v", err)
+			}
+		}
+	}
+}
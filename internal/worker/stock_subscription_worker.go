@@ -0,0 +1,66 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"awesomeEcommerce/internal/service"
+)
+
+// StockSubscriptionWorker periodically tells subscribers their products are
+// back in stock and expires the subscriptions that waited too long
+type StockSubscriptionWorker struct {
+	subscriptionService service.StockSubscriptionService
+	notifyInterval      time.Duration
+}
+
+// NewStockSubscriptionWorker creates a new StockSubscriptionWorker
+func NewStockSubscriptionWorker(subscriptionService service.StockSubscriptionService, notifyInterval time.Duration) *StockSubscriptionWorker {
+	return &StockSubscriptionWorker{
+		subscriptionService: subscriptionService,
+		notifyInterval:      notifyInterval,
+	}
+}
+
+// Start starts the stock subscription worker
+func (w *StockSubscriptionWorker) Start(ctx context.Context) {
+	go w.run(ctx)
+
+	log.Println("Stock subscription worker started")
+}
+
+// run expires subscriptions and notifies subscribers on every tick
+func (w *StockSubscriptionWorker) run(ctx context.Context) {
+	ticker := time.NewTicker(w.notifyInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Context cancelled, stopping stock subscription worker")
+			return
+		case <-ticker.C:
+			w.process(ctx)
+		}
+	}
+}
+
+// process expires subscriptions past their expiry, then notifies the
+// subscribers of restocked products
+func (w *StockSubscriptionWorker) process(ctx context.Context) {
+	expired, err := w.subscriptionService.ExpireSubscriptions(ctx)
+	if err != nil {
+		log.Printf("Error expiring stock subscriptions: %v", err)
+	} else if expired > 0 {
+		log.Printf("Expired %d stock subscriptions", expired)
+	}
+
+	notified, err := w.subscriptionService.NotifySubscribers(ctx)
+	if err != nil {
+		log.Printf("Error notifying stock subscribers: %v", err)
+	}
+	if notified > 0 {
+		log.Printf("Notified %d subscribers of products back in stock", notified)
+	}
+}
@@ -0,0 +1,123 @@
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrentCheckoutsDoNotOversell(t *testing.T) {
+	// Setup test environment
+	client := SetupTest(t)
+	defer TeardownTest(t)
+
+	const stock = 3
+	const shoppers = 12
+
+	var productID uint
+
+	// Create a product with little stock (as admin)
+	t.Run("Create Scarce Product", func(t *testing.T) {
+		token := CreateTestAdmin(t)
+		client.SetAuthToken(token)
+
+		productReqBody := map[string]interface{}{
+			"name":        "Scarce Product",
+			"description": "A product with less stock than shoppers",
+			"price":       9.99,
+			"stock":       stock,
+			"sku":         fmt.Sprintf("SCARCE-%d", time.Now().UnixNano()),
+		}
+
+		resp, err := client.DoRequest(http.MethodPost, "/products", productReqBody)
+		require.NoError(t, err, "Failed to create product")
+
+		var productRespBody struct {
+			Product struct {
+				ID uint `json:"id"`
+			} `json:"product"`
+		}
+
+		err = ParseResponse(resp, &productRespBody)
+		require.NoError(t, err, "Failed to parse product creation response")
+		productID = productRespBody.Product.ID
+	})
+
+	// Every shopper puts one unit in their cart
+	shopperClients := make([]*TestClient, shoppers)
+	t.Run("Fill Carts", func(t *testing.T) {
+		for i := range shopperClients {
+			shopperClients[i] = NewTestClient()
+			shopperClients[i].SetAuthToken(RegisterTestUser(t, shopperClients[i]))
+
+			reqBody := map[string]interface{}{
+				"product_id": productID,
+				"quantity":   1,
+			}
+
+			resp, err := shopperClients[i].DoRequest(http.MethodPost, "/carts/items", reqBody)
+			require.NoError(t, err, "Failed to add item to cart")
+			require.NoError(t, ParseResponse(resp, nil), "Failed to add item to cart")
+		}
+	})
+
+	// All shoppers check out at the same moment
+	t.Run("Concurrent Checkouts", func(t *testing.T) {
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		statuses := map[int]int{}
+		start := make(chan struct{})
+
+		for _, shopper := range shopperClients {
+			wg.Add(1)
+			go func(shopper *TestClient) {
+				defer wg.Done()
+				<-start
+
+				reqBody := map[string]interface{}{
+					"shipping_address": "123 Shipping St, Test City",
+					"billing_address":  "123 Billing St, Test City",
+				}
+
+				resp, err := shopper.DoRequest(http.MethodPost, "/orders", reqBody)
+				if !assert.NoError(t, err, "Failed to create order") {
+					return
+				}
+				resp.Body.Close()
+
+				mu.Lock()
+				statuses[resp.StatusCode]++
+				mu.Unlock()
+			}(shopper)
+		}
+		close(start)
+		wg.Wait()
+
+		// Exactly the stock on hand is sold; everyone else is told why
+		assert.Equal(t, stock, statuses[http.StatusCreated], "Only the stock on hand should be sold")
+		assert.Equal(t, shoppers-stock, statuses[http.StatusConflict], "Remaining checkouts should be refused")
+	})
+
+	// Stock never goes below zero
+	t.Run("Verify Availability", func(t *testing.T) {
+		resp, err := client.DoRequest(http.MethodGet, "/products/"+strconv.FormatUint(uint64(productID), 10)+"/availability", nil)
+		require.NoError(t, err, "Failed to get availability")
+
+		var respBody struct {
+			Reserved  int `json:"reserved"`
+			Available int `json:"available"`
+		}
+
+		err = ParseResponse(resp, &respBody)
+		require.NoError(t, err, "Failed to parse availability response")
+
+		assert.Equal(t, stock, respBody.Reserved, "Placed orders should hold all the stock")
+		assert.Equal(t, 0, respBody.Available, "No stock should be left to sell")
+	})
+}